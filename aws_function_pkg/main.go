@@ -21,15 +21,20 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/openclarity/function-clarity/pkg/clients"
 	i "github.com/openclarity/function-clarity/pkg/init"
 	"github.com/openclarity/function-clarity/pkg/integrity"
+	"github.com/openclarity/function-clarity/pkg/logging"
+	"github.com/openclarity/function-clarity/pkg/metrics"
 	opts "github.com/openclarity/function-clarity/pkg/options"
 	"github.com/openclarity/function-clarity/pkg/verify"
 	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/sigstore/sigstore/pkg/tuf"
+	"golang.org/x/exp/slog"
 	"gopkg.in/yaml.v3"
 	"io"
 	"log"
@@ -43,6 +48,7 @@ type ResponseElement struct {
 }
 
 type RecordMessage struct {
+	EventID          string          `json:"eventID"`
 	AwsRegion        string          `json:"awsRegion"`
 	EventSource      string          `json:"eventSource"`
 	EventName        string          `json:"eventName"`
@@ -61,60 +67,158 @@ type FilterRecord struct {
 
 var config *i.AWSInput = nil
 
-func HandleRequest(context context.Context, cloudWatchEvent events.CloudwatchLogsEvent) error {
+const tufCacheDir = "/tmp/.sigstore/root"
+
+func HandleRequest(ctx context.Context, cloudWatchEvent events.CloudwatchLogsEvent) error {
 	filterRecord, err := extractDataFromEvent(cloudWatchEvent)
 	if err != nil {
-		log.Printf("Failed to extract data from event: %v", err)
+		slog.Error("failed to extract data from event", err)
 		return fmt.Errorf("failed to extract data from event: %w", err)
 	}
-	recordMessage := RecordMessage{}
 	logEvents := filterRecord.LogEvents
 	if config == nil {
 		err := initConfig()
 		if err != nil {
 			return err
 		}
+		if config.EnableMetrics {
+			metrics.Enable()
+		}
+		initEnv()
 	}
+	// a deploy of many functions is verified concurrently, the events of the same function one after the other
+	var tasks []verify.PoolTask
+	var dockerClients []*clients.AwsClient
+	regions := map[string]bool{}
 	for logEvent := range logEvents {
+		recordMessage := RecordMessage{}
 		err = json.Unmarshal([]byte(logEvents[logEvent].Message), &recordMessage)
 		if err != nil {
-			log.Printf("failed to extract message from event, skipping message. %s", logEvents[logEvent].Message)
+			slog.Warn("failed to extract message from event, skipping message", "message", logEvents[logEvent].Message, "error", err)
 			continue
 		}
 		if shouldHandleEvent(recordMessage) {
-			log.Printf("handling function name: %s, event name: %s, event source: %s, region: %s\n", recordMessage.ResponseElements.FunctionName, recordMessage.EventName, recordMessage.EventSource, recordMessage.AwsRegion)
-			handleFunctionEvent(recordMessage, config.IncludedFuncTagKeys, config.IncludedFuncRegions, context)
+			if !regions[recordMessage.AwsRegion] {
+				regions[recordMessage.AwsRegion] = true
+				dockerClients = append(dockerClients, clients.NewAwsClient("", "", config.Bucket, recordMessage.AwsRegion, recordMessage.AwsRegion))
+			}
+			tasks = append(tasks, verify.PoolTask{
+				Key: recordMessage.AwsRegion + "/" + recordMessage.ResponseElements.FunctionName,
+				Verify: func(ctx context.Context) error {
+					slog.Info("handling function event", "function", recordMessage.ResponseElements.FunctionName, "eventName", recordMessage.EventName,
+						"eventSource", recordMessage.EventSource, "region", recordMessage.AwsRegion, "eventId", recordMessage.EventID)
+					return handleFunctionEvent(recordMessage, config.IncludedFuncTagKeys, config.IncludedFuncRegions, ctx)
+				},
+			})
 		}
 	}
-
+	if len(tasks) == 0 {
+		return nil
+	}
+	if err = integrity.InitDocker(dockerClients...); err != nil {
+		slog.Error("failed to init docker", err)
+		return fmt.Errorf("failed to init docker: %w", err)
+	}
+	pool := verify.WorkerPool{Size: config.Concurrency, Timeout: config.FunctionTimeout}
+	poolErr := pool.Run(ctx, tasks)
+	if poolErr != nil {
+		slog.Error("failed to handle events", poolErr)
+	}
+	// the execution environment may be frozen or discarded once the invocation returns, so its metrics are pushed
+	// now, under the log stream as instance since every execution environment keeps its own counters
+	if registry := metrics.Default(); registry != nil && config.MetricsPushGateway != "" {
+		if err = registry.Push(ctx, config.MetricsPushGateway, opts.DefaultMetricsJob, os.Getenv("AWS_LAMBDA_LOG_STREAM_NAME")); err != nil {
+			slog.Warn("failed to push the metrics", "gateway", config.MetricsPushGateway, "error", err)
+		}
+	}
+	// failing the invocation has lambda retry the events, so only the events that couldn't be verified fail it: the
+	// verification failures were already acted on and notified
+	if !onlyVerificationFailures(poolErr) {
+		return fmt.Errorf("failed to handle events: %w", poolErr)
+	}
 	return nil
 }
 
+// onlyVerificationFailures tells whether the error of a pool run is nil or only holds verification failures.
+func onlyVerificationFailures(err error) bool {
+	var poolErr verify.PoolError
+	if !errors.As(err, &poolErr) {
+		return err == nil
+	}
+	for _, taskErr := range poolErr.Errs {
+		if !errors.Is(taskErr, verify.VerifyError{}) {
+			return false
+		}
+	}
+	return true
+}
+
 func shouldHandleEvent(recordMessage RecordMessage) bool {
 	return (strings.Contains(recordMessage.EventName, "CreateFunction") || strings.Contains(recordMessage.EventName, "UpdateFunctionCode")) &&
 		clients.FunctionClarityLambdaVerierName != recordMessage.ResponseElements.FunctionName && "" != recordMessage.ResponseElements.FunctionName
 }
 
-func handleFunctionEvent(recordMessage RecordMessage, tagKeysFilter []string, regionsFilter []string, ctx context.Context) {
-	awsClientForDocker := clients.NewAwsClient("", "", config.Bucket, recordMessage.AwsRegion, recordMessage.AwsRegion)
-	err := integrity.InitDocker(awsClientForDocker)
-	if err != nil {
-		log.Printf("Failed to init docker. %v", err)
-		return
-	}
+func handleFunctionEvent(recordMessage RecordMessage, tagKeysFilter []string, regionsFilter []string, ctx context.Context) error {
 	o := getVerifierOptions(config.IsKeyless, config.PublicKey)
-	log.Printf("about to execute verification with post action: %s.", config.Action)
-	awsClient := clients.NewAwsClient("", "", config.Bucket, config.Region, recordMessage.AwsRegion)
-	err = verify.Verify(awsClient, recordMessage.ResponseElements.FunctionName, o, ctx, config.Action, config.SnsTopicArn, tagKeysFilter, regionsFilter)
+	o.Rekor.URL = opts.RekorURL(config.RekorUrl)
+	o.TrustRoots.TufRoot = config.TufRootPath
+	o.TrustRoots.TufMirror = config.TufMirrorUrl
+	o.VerifyConcurrency = config.VerifyConcurrency
+	o.VerifyLayers = config.VerifyLayers
+	o.SignatureFreshness = config.SignatureFreshness
+	o.ClockSkew = config.ClockSkew
+	o.PinSigner = config.PinSigner
+	o.RequireKeyAndKeyless = config.RequireKeyAndKeyless
+	o.UntrustedSignerAction = config.UntrustedSignerAction
+	o.DryRun = config.DryRun
+	o.RequireAwsCodeSigning = config.RequireAwsCodeSigning
+	o.RequireImageDigestPin = config.RequireImageDigestPin
+	o.RequireSignedLayers = config.RequireSignedLayers
+	o.IncludedFuncTags = config.IncludedFuncTags
+	o.ExcludedFuncTagKeys = config.ExcludedFuncTagKeys
+	o.ExcludedFuncRegions = config.ExcludedFuncRegions
+	o.CertVerify.EnforceSCT = config.EnforceSCT
+	o.CertVerify.CertIdentity = config.CertIdentity
+	o.CertVerify.CertOidcIssuer = config.CertOidcIssuer
+	o.CertIdentityRegexp = config.CertIdentityRegexp
+	o.CertOidcIssuerRegexp = config.CertOidcIssuerRegexp
+	o.IncludedSigners = config.IncludedSigners
+	o.Offline = config.Offline
+	o.TlogVerify = config.TlogUpload
+	o.NotificationRouting = config.NotificationRouting
+	o.SlackWebhookURL = config.SlackWebhookUrl
+	o.WebhookURL = config.WebhookUrl
+	o.WebhookHeaders = config.WebhookHeaders
+	o.ResultQueue = config.ResultQueue
+	// a retried invocation for the same cloudtrail event emits results with the same deduplication key
+	o.ScanID = recordMessage.EventID
+	slog.Info("about to execute verification", "function", recordMessage.ResponseElements.FunctionName, "action", config.Action)
+	awsClient := clients.NewAwsClient("", "", config.Bucket, config.Region, recordMessage.AwsRegion).WithKmsKey(config.KmsKeyArn)
+	if config.Timeout != 0 {
+		awsClient = awsClient.WithTimeout(config.Timeout)
+	}
+	err := verify.Verify(awsClient, recordMessage.ResponseElements.FunctionName, o, ctx, config.Action, config.SnsTopicArn, tagKeysFilter, regionsFilter)
 
 	if err != nil {
-		log.Printf("Failed to handle lambda result: %s, %v", recordMessage.ResponseElements.FunctionArn, err)
+		return fmt.Errorf("failed to handle lambda result: %s: %w", recordMessage.ResponseElements.FunctionArn, err)
+	}
+	return nil
+}
+
+// initEnv sets the environment the verifications read once, before the pool runs them concurrently.
+func initEnv() {
+	if (config.IsKeyless && config.PublicKey == "") || config.RequireKeyAndKeyless {
+		os.Setenv(integrity.ExperimentalEnv, "1")
+	}
+	if config.TufRootPath != "" || config.TufMirrorUrl != "" {
+		// the home directory of the lambda is read-only, the TUF metadata is cached in /tmp instead
+		os.Setenv(tuf.TufRootEnv, tufCacheDir)
 	}
 }
 
 func initConfig() error {
+	// the config holds the webhook urls and headers, it is never logged
 	envConfig := os.Getenv(clients.ConfigEnvVariableName)
-	log.Printf("config: %s", envConfig)
 	decodedConfig, err := base64.StdEncoding.DecodeString(envConfig)
 	if err != nil {
 		return err
@@ -130,11 +234,15 @@ func getVerifierOptions(isKeyless bool, publicKey string) *opts.VerifyOpts {
 	key := "cosign.pub"
 	if isKeyless && publicKey == "" {
 		key = ""
-		os.Setenv(integrity.ExperimentalEnv, "1")
 	}
 
 	o := &opts.VerifyOpts{
 		BundlePath: "",
+		LayerCache: opts.LayerCacheOptions{
+			Dir:                opts.DefaultLayerCacheDir,
+			MaxSizeMb:          opts.DefaultLayerCacheSizeMb,
+			MaxConcurrentPulls: opts.DefaultMaxConcurrentLayerPull,
+		},
 		VerifyOptions: co.VerifyOptions{
 			Key:          key,
 			CheckClaims:  true,
@@ -192,5 +300,9 @@ func extractDataFromEvent(cloudWatchEvent events.CloudwatchLogsEvent) (*FilterRe
 }
 
 func main() {
+	// cloudwatch logs keeps a json record per line queryable with logs insights
+	if err := logging.Configure(os.Stderr, logging.DefaultLevel, logging.FormatJSON); err != nil {
+		log.Fatal(err)
+	}
 	lambda.Start(HandleRequest)
 }
@@ -16,17 +16,25 @@
 package aws
 
 import (
+	"context"
 	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	"github.com/openclarity/function-clarity/cmd/function-clarity/cli/common"
 	opt "github.com/openclarity/function-clarity/cmd/function-clarity/cli/options"
+	"github.com/openclarity/function-clarity/pkg/cache"
 	"github.com/openclarity/function-clarity/pkg/clients"
 	i "github.com/openclarity/function-clarity/pkg/init"
+	"github.com/openclarity/function-clarity/pkg/notify"
 	"github.com/openclarity/function-clarity/pkg/options"
+	"github.com/openclarity/function-clarity/pkg/report"
 	"github.com/openclarity/function-clarity/pkg/verify"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"golang.org/x/exp/slog"
 	"gopkg.in/yaml.v3"
+	"io"
 	"os"
+	"time"
 )
 
 func AwsSign() *cobra.Command {
@@ -35,61 +43,370 @@ func AwsSign() *cobra.Command {
 		Short: "sign code/image and upload to aws",
 	}
 	cmd.AddCommand(AwsSignCode())
-	cmd.AddCommand(common.SignImage())
+	cmd.AddCommand(AwsSignLayer())
+	cmd.AddCommand(AwsSignFunctions())
+	cmd.AddCommand(common.SignImage(resolveFunctionImage))
 	return cmd
 }
 
+// resolveFunctionImage returns the image an image function runs, pinned to the digest lambda resolved on deployment.
+func resolveFunctionImage(functionIdentifier string, functionRegion string) (string, error) {
+	awsClient := clients.NewAwsClient(viper.GetString("accesskey"), viper.GetString("secretkey"), "", "", functionRegion).WithMaxRetries(viper.GetInt("maxretries")).WithTimeout(viper.GetDuration("timeout"))
+	packageType, err := awsClient.ResolvePackageType(functionIdentifier)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve package type for function: %s: %w", functionIdentifier, err)
+	}
+	if packageType != "Image" {
+		return "", fmt.Errorf("function: %s is a %s function, sign its code with: sign aws code", functionIdentifier, packageType)
+	}
+	return awsClient.GetFuncResolvedImageURI(functionIdentifier)
+}
+
 func AwsVerify() *cobra.Command {
 	o := &options.VerifyOpts{}
+	so := &options.ScanOptions{}
+	pco := &options.PackageCacheOptions{}
+	mo := &options.MetricsOptions{}
 	var lambdaRegion string
+	var functionArn string
+	var all bool
+	var failOn string
+	var output string
 	cmd := &cobra.Command{
 		Use:   "aws",
 		Short: "verify function identity",
-		Args:  cobra.ExactArgs(1),
+		Long: "verify function identity. with --function-arn the function is verified on demand: regardless of the\n" +
+			"function filters, without any action or notification, and the command fails when it isn't verified.\n" +
+			"with --all every function of the function region in scope of the filters is verified on demand, a summary\n" +
+			"is printed and the command fails per --fail-on",
+		Args: cobra.MaximumNArgs(1),
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			if err := viper.BindPFlag("accessKey", cmd.Flags().Lookup("aws-access-key")); err != nil {
-				return fmt.Errorf("error binding accessKey: %w", err)
+			modes := len(args)
+			if functionArn != "" {
+				modes++
 			}
-			if err := viper.BindPFlag("secretKey", cmd.Flags().Lookup("aws-secret-key")); err != nil {
-				return fmt.Errorf("error binding secretKey: %w", err)
+			if all {
+				modes++
 			}
-			if err := viper.BindPFlag("region", cmd.Flags().Lookup("region")); err != nil {
-				return fmt.Errorf("error binding region: %w", err)
+			if modes != 1 {
+				return fmt.Errorf("expected either a function to verify, --function-arn or --all")
 			}
-			if err := viper.BindPFlag("bucket", cmd.Flags().Lookup("bucket")); err != nil {
-				return fmt.Errorf("error binding bucket: %w", err)
+			if _, err := (verify.ScanSummary{}).Fails(failOn); err != nil {
+				return err
 			}
-			if err := viper.BindPFlag("publickey", cmd.Flags().Lookup("key")); err != nil {
-				return fmt.Errorf("error binding publickey: %w", err)
+			if err := report.ValidateOutput(output); err != nil {
+				return err
 			}
-			if err := viper.BindPFlag("action", cmd.Flags().Lookup("action")); err != nil {
-				return fmt.Errorf("error binding action: %w", err)
+			if output != report.OutputTable && len(args) == 1 {
+				return fmt.Errorf("--output %s requires --function-arn or --all", output)
 			}
-			if err := viper.BindPFlag("includedfunctagkeys", cmd.Flags().Lookup("included-func-tags")); err != nil {
-				return fmt.Errorf("error binding action: %w", err)
+			if _, _, err := so.ParallelismLevel(); err != nil {
+				return err
 			}
-			if err := viper.BindPFlag("includedfuncregions", cmd.Flags().Lookup("included-func-regions")); err != nil {
-				return fmt.Errorf("error binding action: %w", err)
+			if err := mo.Validate(); err != nil {
+				return err
 			}
-			if err := viper.BindPFlag("snsTopicArn", cmd.Flags().Lookup("sns-topic-arn")); err != nil {
-				return fmt.Errorf("error binding snsTopicArn: %w", err)
+			if functionArn != "" && lambdaRegion == "" {
+				parsed, err := arn.Parse(functionArn)
+				if err != nil || parsed.Service != "lambda" {
+					return fmt.Errorf("invalid function arn: %s, expected arn:aws:lambda:<region>:<account>:function:<name>", functionArn)
+				}
+				lambdaRegion = parsed.Region
 			}
-			return nil
+			if lambdaRegion == "" {
+				return fmt.Errorf("required flag(s) \"function-region\" not set")
+			}
+			return bindAwsVerifyFlags(cmd)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			o.Key = viper.GetString("publickey")
-			awsClient := clients.NewAwsClient(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("bucket"), viper.GetString("region"), lambdaRegion)
+			o.Rekor.URL = options.RekorURL(viper.GetString("rekorurl"))
+			o.TrustRoots.TufRoot = viper.GetString("tufrootpath")
+			o.TrustRoots.TufMirror = viper.GetString("tufmirrorurl")
+			o.VerifyConcurrency = viper.GetBool("verifyconcurrency")
+			o.VerifyLayers = viper.GetBool("verifylayers")
+			o.SignatureFreshness = viper.GetDuration("signaturefreshness")
+			o.ClockSkew = viper.GetDuration("clockskew")
+			o.PinSigner = viper.GetBool("pinsigner")
+			o.RequireKeyAndKeyless = viper.GetBool("requirekeyandkeyless")
+			o.UntrustedSignerAction = viper.GetString("untrustedsigneraction")
+			o.DryRun = viper.GetBool("dryrun")
+			o.RequireAwsCodeSigning = viper.GetBool("requireawscodesigning")
+			o.RequireImageDigestPin = viper.GetBool("requireimagedigestpin")
+			o.RequireSignedLayers = viper.GetBool("requiresignedlayers")
+			o.IncludedFuncTags = viper.GetStringMapString("includedfunctags")
+			o.ExcludedFuncTagKeys = viper.GetStringSlice("excludedfunctagkeys")
+			o.ExcludedFuncRegions = viper.GetStringSlice("excludedfuncregions")
+			o.CertVerify.EnforceSCT = viper.GetBool("enforcesct")
+			o.CertVerify.CertIdentity = viper.GetString("certidentity")
+			o.CertVerify.CertOidcIssuer = viper.GetString("certoidcissuer")
+			o.CertIdentityRegexp = viper.GetString("certidentityregexp")
+			o.CertOidcIssuerRegexp = viper.GetString("certoidcissuerregexp")
+			o.Offline = viper.GetBool("offline")
+			o.TlogVerify = viper.GetBool("tlogupload")
+			o.NotificationRouting.TagKey = viper.GetString("notificationrouting.tagkey")
+			o.NotificationRouting.Routes = viper.GetStringMapString("notificationrouting.routes")
+			o.SlackWebhookURL = viper.GetString("slackwebhookurl")
+			o.WebhookURL = viper.GetString("webhookurl")
+			headers, err := webhookHeaders(cmd)
+			if err != nil {
+				return err
+			}
+			o.WebhookHeaders = headers
+			if o.IncludedSigners, err = includedSigners(cmd); err != nil {
+				return err
+			}
+			o.ResultQueue.URL = viper.GetString("resultqueue.url")
+			o.ResultQueue.Attributes = viper.GetStringMapString("resultqueue.attributes")
+			pushMetrics := enableMetrics(mo)
+			defer pushMetrics(cmd.Context())
+			awsClient := clients.NewAwsClient(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("bucket"), viper.GetString("region"), lambdaRegion).WithKmsKey(viper.GetString("kmskeyarn")).WithMaxRetries(viper.GetInt("maxretries")).WithTimeout(viper.GetDuration("timeout"))
+			if functionArn != "" {
+				return verifyOnDemand(awsClient, functionArn, o, cmd.Context(), cmd.OutOrStdout(), output)
+			}
+			if all {
+				return verifyAll(awsClient, o, so, pco, cmd.Context(), cmd.OutOrStdout(), failOn, output)
+			}
 			return verify.Verify(awsClient, args[0], o, cmd.Context(), viper.GetString("action"), viper.GetString("snsTopicArn"),
 				viper.GetStringSlice("includedfunctagkeys"), viper.GetStringSlice("includedfuncregions"))
 		},
 	}
-	cmd.Flags().StringVar(&lambdaRegion, "function-region", "", "aws region where the verified lambda runs")
-	cmd.MarkFlagRequired("function-region") //nolint:errcheck
+	cmd.Flags().StringVar(&lambdaRegion, "function-region", "", "aws region where the verified lambda runs, the region of --function-arn by default")
+	cmd.Flags().StringVar(&functionArn, "function-arn", "", "arn of a function to verify on demand, instead of the function argument")
+	cmd.Flags().BoolVar(&all, "all", false, "verify every function of --function-region in scope of the filters on demand, instead of the function argument")
+	cmd.Flags().StringVar(&failOn, "fail-on", verify.FailOnUnsigned, "with --all, fail when any function isn't verified (unsigned), "+
+		"only when the verification of a function errored (error), or never")
+	cmd.Flags().StringVarP(&output, "output", "o", report.OutputTable, "format of the results with --function-arn or --all: table, "+
+		"json to print only the results on stdout, a JSON array with --all, and the verification progress on stderr, "+
+		"or sarif to print a SARIF report of the functions that didn't pass verification the same way")
+	cmd.Flags().StringVar(&o.VexOutput, "vex-output", "", "write an OpenVEX document to the given path, with a statement for the function when it fails verification")
+	cmd.Flags().StringVar(&o.ScanID, "scan-id", "", "scan id in the deduplication key of the emitted result, e.g. the id of the pipeline run, a random one by default")
+	cmd.Flags().StringVar(&o.SigningIdentityOutput, "signing-identity-output", "", "write the OIDC claims and certificate chain of the keyless signature to the given path as JSON, when the function passes verification")
 	o.AddFlags(cmd)
+	o.NotificationRouting.AddFlags(cmd)
+	o.ResultQueue.AddFlags(cmd)
+	so.AddFlags(cmd)
+	pco.AddFlags(cmd)
+	mo.AddFlags(cmd)
 	initAwsVerifyFlags(cmd)
 	return cmd
 }
 
+// verifyAll verifies every function of the client region in scope of the filters on demand, writes the result of each
+// and a summary, or the results as a JSON array or a SARIF report, to out and fails per failOn. The packages shared by
+// several functions are downloaded once, unless the package cache is disabled.
+func verifyAll(client *clients.AwsClient, o *options.VerifyOpts, so *options.ScanOptions, pco *options.PackageCacheOptions,
+	ctx context.Context, out io.Writer, failOn string, output string) error {
+	if output != report.OutputTable {
+		o.RecordedAnnotations = options.NewAnnotationRecorder()
+	}
+	if !pco.Disabled {
+		packageCache, err := cache.NewPackageCache(pco.Dir)
+		if err != nil {
+			return err
+		}
+		defer packageCache.Close()
+		defer func() {
+			stats := packageCache.Stats()
+			slog.Info("package cache", "packagesReused", stats.Hits, "packagesDownloaded", stats.Misses)
+		}()
+		client = client.WithPackageCache(packageCache)
+	}
+	functions, err := client.ListAllFunctions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list functions: %w", err)
+	}
+	var candidates []clients.FunctionConfig
+	for _, function := range functions {
+		if function.FunctionName != clients.FunctionClarityLambdaVerierName {
+			candidates = append(candidates, function)
+		}
+	}
+	summary, err := verify.AuditFunctions(client, candidates, o, so, ctx, viper.GetStringSlice("includedfunctagkeys"), viper.GetStringSlice("includedfuncregions"))
+	if err != nil {
+		return err
+	}
+	signed := summary.Count(verify.ResultPassed)
+	if output != report.OutputTable {
+		now := time.Now()
+		results := make([]report.VerificationResult, 0, len(summary.Results))
+		for _, result := range summary.Results {
+			results = append(results, result.Report(o.RecordedAnnotations.Get(result.FunctionIdentifier), now))
+		}
+		if err := writeVerificationResults(out, results, output, true); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintf(out, "%-70s %-26s %s\n", "FUNCTION", "RESULT", "REASON")
+		for _, result := range summary.Results {
+			fmt.Fprintf(out, "%-70s %-26s %s\n", result.FunctionIdentifier, result.Result, result.Reason)
+		}
+		failed := summary.Count(verify.ResultError) + summary.Count(verify.ResultTimedOut)
+		fmt.Fprintf(out, "%d functions: %d signed, %d unsigned, %d failed\n", len(summary.Results), signed, len(summary.Results)-signed-failed, failed)
+	}
+	if fails, _ := summary.Fails(failOn); fails {
+		return fmt.Errorf("%d of %d functions didn't pass verification", len(summary.Results)-signed, len(summary.Results))
+	}
+	return nil
+}
+
+// verifyOnDemand verifies the function and writes to out whether it is verified, with the signer identity of a keyless
+// signature, or the result as a JSON object or a SARIF report. It fails when the function isn't verified.
+func verifyOnDemand(client clients.Client, functionArn string, o *options.VerifyOpts, ctx context.Context, out io.Writer, output string) error {
+	if output != report.OutputTable {
+		o.RecordedAnnotations = options.NewAnnotationRecorder()
+		result, err := verify.VerifyOnDemandResult(client, functionArn, o, ctx)
+		if digest, digestErr := client.GetFuncCodeSha256(functionArn); digestErr == nil {
+			result.Digest = digest
+		}
+		reported := []report.VerificationResult{result.Report(o.RecordedAnnotations.Get(functionArn), time.Now())}
+		if writeErr := writeVerificationResults(out, reported, output, false); writeErr != nil {
+			return writeErr
+		}
+		return err
+	}
+	signingIdentity, err := verify.VerifyOnDemand(client, functionArn, o, ctx)
+	if err != nil {
+		fmt.Fprintf(out, "NOT VERIFIED: %s\n", functionArn)
+		return err
+	}
+	fmt.Fprintf(out, "VERIFIED: %s\n", functionArn)
+	if signingIdentity != nil {
+		fmt.Fprintf(out, "signer: %s, issuer: %s\n", signingIdentity.Subject, signingIdentity.Issuer)
+	}
+	return nil
+}
+
+// writeVerificationResults writes the results to out as a SARIF report, or as JSON: an array when asArray is set and
+// the single result otherwise.
+func writeVerificationResults(out io.Writer, results []report.VerificationResult, output string, asArray bool) error {
+	switch {
+	case output == report.OutputSARIF:
+		return report.WriteSarifReport(out, results)
+	case asArray:
+		return report.WriteVerificationResults(out, results)
+	}
+	return report.WriteVerificationResult(out, results[0])
+}
+
+func bindAwsVerifyFlags(cmd *cobra.Command) error {
+	if err := viper.BindPFlag("accessKey", cmd.Flags().Lookup("aws-access-key")); err != nil {
+		return fmt.Errorf("error binding accessKey: %w", err)
+	}
+	if err := viper.BindPFlag("secretKey", cmd.Flags().Lookup("aws-secret-key")); err != nil {
+		return fmt.Errorf("error binding secretKey: %w", err)
+	}
+	if err := viper.BindPFlag("region", cmd.Flags().Lookup("region")); err != nil {
+		return fmt.Errorf("error binding region: %w", err)
+	}
+	if err := viper.BindPFlag("bucket", cmd.Flags().Lookup("bucket")); err != nil {
+		return fmt.Errorf("error binding bucket: %w", err)
+	}
+	if err := viper.BindPFlag("publickey", cmd.Flags().Lookup("key")); err != nil {
+		return fmt.Errorf("error binding publickey: %w", err)
+	}
+	if err := viper.BindPFlag("action", cmd.Flags().Lookup("action")); err != nil {
+		return fmt.Errorf("error binding action: %w", err)
+	}
+	if err := viper.BindPFlag("includedfunctagkeys", cmd.Flags().Lookup("included-func-tags")); err != nil {
+		return fmt.Errorf("error binding action: %w", err)
+	}
+	if err := viper.BindPFlag("excludedfunctagkeys", cmd.Flags().Lookup("excluded-func-tags")); err != nil {
+		return fmt.Errorf("error binding excludedfunctagkeys: %w", err)
+	}
+	if err := viper.BindPFlag("includedfuncregions", cmd.Flags().Lookup("included-func-regions")); err != nil {
+		return fmt.Errorf("error binding action: %w", err)
+	}
+	if err := viper.BindPFlag("excludedfuncregions", cmd.Flags().Lookup("excluded-func-regions")); err != nil {
+		return fmt.Errorf("error binding excludedfuncregions: %w", err)
+	}
+	if err := viper.BindPFlag("snsTopicArn", cmd.Flags().Lookup("sns-topic-arn")); err != nil {
+		return fmt.Errorf("error binding snsTopicArn: %w", err)
+	}
+	if err := viper.BindPFlag("slackwebhookurl", cmd.Flags().Lookup("slack-webhook-url")); err != nil {
+		return fmt.Errorf("error binding slackwebhookurl: %w", err)
+	}
+	if err := viper.BindPFlag("webhookurl", cmd.Flags().Lookup("webhook-url")); err != nil {
+		return fmt.Errorf("error binding webhookurl: %w", err)
+	}
+	if err := viper.BindPFlag("rekorurl", cmd.Flags().Lookup("rekor-url")); err != nil {
+		return fmt.Errorf("error binding rekorurl: %w", err)
+	}
+	if err := viper.BindPFlag("tufrootpath", cmd.Flags().Lookup("tuf-root")); err != nil {
+		return fmt.Errorf("error binding tufrootpath: %w", err)
+	}
+	if err := viper.BindPFlag("tufmirrorurl", cmd.Flags().Lookup("tuf-mirror")); err != nil {
+		return fmt.Errorf("error binding tufmirrorurl: %w", err)
+	}
+	if err := viper.BindPFlag("verifyconcurrency", cmd.Flags().Lookup("verify-concurrency")); err != nil {
+		return fmt.Errorf("error binding verifyconcurrency: %w", err)
+	}
+	if err := viper.BindPFlag("verifylayers", cmd.Flags().Lookup("verify-layers")); err != nil {
+		return fmt.Errorf("error binding verifylayers: %w", err)
+	}
+	if err := viper.BindPFlag("signaturefreshness", cmd.Flags().Lookup("signature-freshness")); err != nil {
+		return fmt.Errorf("error binding signaturefreshness: %w", err)
+	}
+	if err := viper.BindPFlag("clockskew", cmd.Flags().Lookup("clock-skew")); err != nil {
+		return fmt.Errorf("error binding clockskew: %w", err)
+	}
+	if err := viper.BindPFlag("pinsigner", cmd.Flags().Lookup("pin-signer")); err != nil {
+		return fmt.Errorf("error binding pinsigner: %w", err)
+	}
+	if err := viper.BindPFlag("requirekeyandkeyless", cmd.Flags().Lookup("require-key-and-keyless")); err != nil {
+		return fmt.Errorf("error binding requirekeyandkeyless: %w", err)
+	}
+	if err := viper.BindPFlag("enforcesct", cmd.Flags().Lookup("enforce-sct")); err != nil {
+		return fmt.Errorf("error binding enforcesct: %w", err)
+	}
+	if err := viper.BindPFlag("certidentity", cmd.Flags().Lookup("certificate-identity")); err != nil {
+		return fmt.Errorf("error binding certidentity: %w", err)
+	}
+	if err := viper.BindPFlag("certoidcissuer", cmd.Flags().Lookup("certificate-oidc-issuer")); err != nil {
+		return fmt.Errorf("error binding certoidcissuer: %w", err)
+	}
+	if err := viper.BindPFlag("certidentityregexp", cmd.Flags().Lookup("certificate-identity-regexp")); err != nil {
+		return fmt.Errorf("error binding certidentityregexp: %w", err)
+	}
+	if err := viper.BindPFlag("certoidcissuerregexp", cmd.Flags().Lookup("certificate-oidc-issuer-regexp")); err != nil {
+		return fmt.Errorf("error binding certoidcissuerregexp: %w", err)
+	}
+	if err := viper.BindPFlag("offline", cmd.Flags().Lookup("offline")); err != nil {
+		return fmt.Errorf("error binding offline: %w", err)
+	}
+	if err := viper.BindPFlag("tlogupload", cmd.Flags().Lookup("tlog-verify")); err != nil {
+		return fmt.Errorf("error binding tlogupload: %w", err)
+	}
+	if err := viper.BindPFlag("untrustedsigneraction", cmd.Flags().Lookup("untrusted-signer-action")); err != nil {
+		return fmt.Errorf("error binding untrustedsigneraction: %w", err)
+	}
+	if err := viper.BindPFlag("dryrun", cmd.Flags().Lookup("dry-run")); err != nil {
+		return fmt.Errorf("error binding dryrun: %w", err)
+	}
+	if err := viper.BindPFlag("requireawscodesigning", cmd.Flags().Lookup("require-aws-code-signing")); err != nil {
+		return fmt.Errorf("error binding requireawscodesigning: %w", err)
+	}
+	if err := viper.BindPFlag("requireimagedigestpin", cmd.Flags().Lookup("require-image-digest-pin")); err != nil {
+		return fmt.Errorf("error binding requireimagedigestpin: %w", err)
+	}
+	if err := viper.BindPFlag("requiresignedlayers", cmd.Flags().Lookup("require-signed-layers")); err != nil {
+		return fmt.Errorf("error binding requiresignedlayers: %w", err)
+	}
+	if err := viper.BindPFlag("notificationrouting.tagkey", cmd.Flags().Lookup("routing-tag-key")); err != nil {
+		return fmt.Errorf("error binding notificationrouting.tagkey: %w", err)
+	}
+	if err := viper.BindPFlag("notificationrouting.routes", cmd.Flags().Lookup("notification-routes")); err != nil {
+		return fmt.Errorf("error binding notificationrouting.routes: %w", err)
+	}
+	if err := viper.BindPFlag("resultqueue.url", cmd.Flags().Lookup("result-queue-url")); err != nil {
+		return fmt.Errorf("error binding resultqueue.url: %w", err)
+	}
+	if err := viper.BindPFlag("resultqueue.attributes", cmd.Flags().Lookup("result-queue-attributes")); err != nil {
+		return fmt.Errorf("error binding resultqueue.attributes: %w", err)
+	}
+	return nil
+}
+
 func initAwsVerifyFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&opt.Config, "config", "", "config file (default: $HOME/.fs)")
 	cmd.Flags().String("aws-access-key", "", "aws access key")
@@ -98,38 +415,152 @@ func initAwsVerifyFlags(cmd *cobra.Command) {
 	cmd.Flags().String("bucket", "", "s3 bucket to work against")
 	cmd.Flags().String("key", "", "public key")
 	cmd.Flags().String("action", "", "action to perform upon validation result")
-	cmd.Flags().StringSlice("included-func-tags", []string{}, "function tags to include when verifying")
+	cmd.Flags().StringSlice("included-func-tags", []string{}, "function tag keys, or key=value tags, to include when verifying")
+	cmd.Flags().StringSlice("excluded-func-tags", []string{}, "function tags to exclude when verifying, takes precedence over the included tags")
 	cmd.Flags().StringSlice("included-func-regions", []string{}, "function regions to include when verifying")
+	cmd.Flags().StringSlice("excluded-func-regions", []string{}, "function regions to exclude when verifying, takes precedence over the included regions")
 	cmd.Flags().String("sns-topic-arn", "", "SNS topic ARN for notifications")
+	cmd.Flags().String("slack-webhook-url", "", "slack incoming webhook url notified when signature verification fails")
+	cmd.Flags().String("webhook-url", "", "url the notification is posted to as JSON when signature verification fails")
+	cmd.Flags().StringArray("webhook-header", nil, "key:value header of the --webhook-url posts, e.g. an auth token, can be repeated")
+	cmd.Flags().StringArray("signer", nil, "issuer=<regexp>,subject=<regexp> of an identity allowed to sign keylessly, can be repeated, any identity when none")
+}
+
+// includedSigners returns the signers allowed by the --signer flags, or by the config file when none is given.
+func includedSigners(cmd *cobra.Command) ([]options.SignerIdentity, error) {
+	flagged, err := cmd.Flags().GetStringArray("signer")
+	if err != nil {
+		return nil, err
+	}
+	if len(flagged) > 0 {
+		return options.ParseSignerIdentities(flagged)
+	}
+	var signers []options.SignerIdentity
+	if err = viper.UnmarshalKey("includedsigners", &signers); err != nil {
+		return nil, fmt.Errorf("invalid includedsigners: %w", err)
+	}
+	for _, signer := range signers {
+		if err = signer.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return signers, nil
+}
+
+// webhookHeaders returns the webhook headers of the config file overridden by the --webhook-header flags.
+func webhookHeaders(cmd *cobra.Command) (map[string]string, error) {
+	headers := viper.GetStringMapString("webhookheaders")
+	flagged, err := cmd.Flags().GetStringArray("webhook-header")
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := notify.ParseHeaders(flagged)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range parsed {
+		headers[key] = value
+	}
+	return headers, nil
 }
 
 func AwsInit() *cobra.Command {
+	var input i.AWSInput
+	var configPath string
+	var webhookHeaders, signers []string
 	cmd := &cobra.Command{
 		Use:   "aws",
 		Short: "initialize configuration and deploy to aws",
-		Args:  cobra.NoArgs,
+		Long: "initialize the configuration and deploy to aws. the parameters given neither as flags nor in the --config file\n" +
+			"are prompted for, nothing is prompted for when the access key, secret key and region are given or stdin isn't a\n" +
+			"terminal. flags take precedence over the --config file",
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			var input i.AWSInput
-			if err := ReceiveParameters(&input); err != nil {
+			headers, err := notify.ParseHeaders(webhookHeaders)
+			if err != nil {
+				return err
+			}
+			if len(headers) > 0 {
+				input.WebhookHeaders = headers
+			}
+			if input.IncludedSigners, err = options.ParseSignerIdentities(signers); err != nil {
+				return err
+			}
+			if err = readSecretFlags(cmd.Flags(), common.StdPrompter); err != nil {
+				return err
+			}
+			var fromFile map[string]bool
+			if configPath != "" {
+				file, err := i.LoadAWSInputFromFile(configPath)
+				if err != nil {
+					return err
+				}
+				var merged *i.AWSInput
+				merged, fromFile = mergeInitConfig(file, &input, cmd.Flags())
+				input = *merged
+			}
+			if err := ReceiveParameters(cmd.Context(), &input, cmd.Flags(), fromFile, common.StdPrompter); err != nil {
 				return err
 			}
 			if input.Bucket == "" {
 				input.Bucket = clients.FunctionClarityBucketName
 			}
+			if input.ClockSkew == 0 {
+				input.ClockSkew = options.DefaultClockSkew
+			}
+			input.Timeout = viper.GetDuration("timeout")
 			var configForDeployment i.AWSInput
 			configForDeployment.Bucket = input.Bucket
+			configForDeployment.KmsKeyArn = input.KmsKeyArn
+			configForDeployment.SignatureRetentionDays = input.SignatureRetentionDays
 			configForDeployment.Action = input.Action
 			configForDeployment.Region = input.Region
 			configForDeployment.IsKeyless = input.IsKeyless
+			configForDeployment.FulcioUrl = input.FulcioUrl
+			configForDeployment.RekorUrl = input.RekorUrl
+			configForDeployment.TufRootPath = input.TufRootPath
+			configForDeployment.TufMirrorUrl = input.TufMirrorUrl
 			configForDeployment.SnsTopicArn = input.SnsTopicArn
+			configForDeployment.SlackWebhookUrl = input.SlackWebhookUrl
+			configForDeployment.WebhookUrl = input.WebhookUrl
+			configForDeployment.WebhookHeaders = input.WebhookHeaders
 			configForDeployment.IncludedFuncTagKeys = input.IncludedFuncTagKeys
+			configForDeployment.IncludedFuncTags = input.IncludedFuncTags
+			configForDeployment.ExcludedFuncTagKeys = input.ExcludedFuncTagKeys
 			configForDeployment.IncludedFuncRegions = input.IncludedFuncRegions
+			configForDeployment.ExcludedFuncRegions = input.ExcludedFuncRegions
+			configForDeployment.VerifyConcurrency = input.VerifyConcurrency
+			configForDeployment.VerifyLayers = input.VerifyLayers
+			configForDeployment.SignatureFreshness = input.SignatureFreshness
+			configForDeployment.ClockSkew = input.ClockSkew
+			configForDeployment.Concurrency = input.Concurrency
+			configForDeployment.FunctionTimeout = input.FunctionTimeout
+			configForDeployment.Timeout = input.Timeout
+			configForDeployment.EnableMetrics = input.EnableMetrics
+			configForDeployment.MetricsPushGateway = input.MetricsPushGateway
+			configForDeployment.PinSigner = input.PinSigner
+			configForDeployment.RequireKeyAndKeyless = input.RequireKeyAndKeyless
+			configForDeployment.UntrustedSignerAction = input.UntrustedSignerAction
+			configForDeployment.DryRun = input.DryRun
+			configForDeployment.RequireAwsCodeSigning = input.RequireAwsCodeSigning
+			configForDeployment.RequireImageDigestPin = input.RequireImageDigestPin
+			configForDeployment.RequireSignedLayers = input.RequireSignedLayers
+			configForDeployment.EnforceSCT = input.EnforceSCT
+			configForDeployment.CertIdentity = input.CertIdentity
+			configForDeployment.CertOidcIssuer = input.CertOidcIssuer
+			configForDeployment.CertIdentityRegexp = input.CertIdentityRegexp
+			configForDeployment.CertOidcIssuerRegexp = input.CertOidcIssuerRegexp
+			configForDeployment.IncludedSigners = input.IncludedSigners
+			configForDeployment.Offline = input.Offline
+			configForDeployment.TlogUpload = input.TlogUpload
+			configForDeployment.NotificationRouting = input.NotificationRouting
+			configForDeployment.ResultQueue = input.ResultQueue
 			onlyCreateConfig, err := cmd.Flags().GetBool("only-create-config")
 			if err != nil {
 				return err
 			}
 			if !onlyCreateConfig {
-				awsClient := clients.NewAwsClientInit(input.AccessKey, input.SecretKey, input.Region)
+				awsClient := initClient(cmd.Context(), &input)
 				err = awsClient.DeployFunctionClarity(input.CloudTrail.Name, input.PublicKey, configForDeployment, "")
 				if err != nil {
 					return fmt.Errorf("failed to deploy function clarity: %w", err)
@@ -152,10 +583,61 @@ func AwsInit() *cobra.Command {
 			if _, err = f.Write(d); err != nil {
 				return fmt.Errorf("init command fail: %w", err)
 			}
+			if err = offerToSaveInput(&input, newInitPrompts(cmd.Flags(), fromFile, common.StdPrompter)); err != nil {
+				return fmt.Errorf("failed to save init answers: %w", err)
+			}
 			return nil
 		},
 	}
 	cmd.Flags().Bool("only-create-config", false, "determine whether to only create config file without deploying")
+	cmd.Flags().StringVar(&configPath, "config", "", "yaml or json file with the init configuration, e.g. a version controlled copy of the config file written by init")
+	cmd.Flags().StringVar(&input.AccessKey, "access-key", "", "aws access key, the default aws credential chain is used when empty")
+	cmd.Flags().StringVar(&input.SecretKey, "secret-key", "", "aws secret key, required with --access-key, - to read it from stdin")
+	cmd.Flags().String("secret-key-file", "", "file holding the aws secret key, kept out of the shell history unlike --secret-key")
+	cmd.Flags().StringVar(&input.Region, "region", "", "aws region to deploy to")
+	cmd.Flags().StringVar(&input.AssumeRoleArn, "assume-role-arn", "", "arn of an IAM role to assume with the credentials for the deployment")
+	cmd.Flags().StringVar(&input.ExternalId, "external-id", "", "external id required to assume --assume-role-arn")
+	cmd.Flags().DurationVar(&input.AssumeRoleDuration, "assume-role-duration", clients.DefaultAssumeRoleDuration, "session duration of the assumed role")
+	cmd.Flags().StringVar(&input.EndpointUrl, "endpoint-url", "", "url replacing the aws endpoints, e.g. http://localhost:4566 to run against LocalStack")
+	cmd.Flags().StringVar(&input.Bucket, "bucket", "", "existing bucket holding the signatures, a bucket named functionclarity is created when empty")
+	cmd.Flags().StringVar(&input.KmsKeyArn, "kms-key", "", "arn of the kms key encrypting the signatures, in --region, the bucket default encryption is used when empty")
+	cmd.Flags().IntVar(&input.SignatureRetentionDays, "signature-retention-days", 0, "expire the signatures in the bucket after the number of days, never when 0")
+	cmd.Flags().Bool("allow-cross-region-bucket", false, "allow a --bucket in another region than --region")
+	cmd.Flags().StringVar(&input.Action, "action", "", "post verification action: detect or block, none when empty")
+	cmd.Flags().BoolVar(&input.DryRun, "block-dry-run", false, "with the block action, the verifier lambda only logs and notifies the functions it would block or unblock")
+	cmd.Flags().StringSliceVar(&input.Notifiers, "notifiers", nil, "notifiers of failed signature verifications: sns, slack, webhook, "+
+		"a notifier whose --sns-topic, --slack-webhook-url or --webhook-url is given is selected too")
+	cmd.Flags().StringVar(&input.SnsTopicArn, "sns-topic", "", "arn of the sns topic notified when signature verification fails")
+	cmd.Flags().StringVar(&input.SlackWebhookUrl, "slack-webhook-url", "", "slack incoming webhook url notified when signature verification fails")
+	cmd.Flags().StringVar(&input.WebhookUrl, "webhook-url", "", "url the notification is posted to as JSON when signature verification fails")
+	cmd.Flags().StringArrayVar(&webhookHeaders, "webhook-header", nil, "key:value header of the --webhook-url posts, e.g. an auth token, can be repeated")
+	cmd.Flags().StringVar(&input.CloudTrail.Name, "cloudtrail", "", "existing trail in the region to use, a trail is created when empty")
+	cmd.Flags().BoolVar(&input.IsKeyless, "keyless", false, "work in keyless mode")
+	cmd.Flags().StringVar(&input.FulcioUrl, "fulcio-url", "", "fulcio url of a private sigstore instance used in keyless mode, the public one when empty")
+	cmd.Flags().StringVar(&input.RekorUrl, "rekor-url", "", "rekor url of a private sigstore instance used in keyless mode or with --tlog-upload, the public one when empty")
+	cmd.Flags().BoolVar(&input.TlogUpload, "tlog-upload", false, "upload the signatures made with the key pair to rekor at --rekor-url, and require their log entry on verification")
+	cmd.Flags().StringVar(&input.TufRootPath, "tuf-root", "", "path to the root.json of a private sigstore TUF repository used in keyless mode, the public one when empty")
+	cmd.Flags().StringVar(&input.CertIdentity, "certificate-identity", "", "identity expected to sign the functions in keyless mode, e.g. an email or a workflow url, any when empty")
+	cmd.Flags().StringVar(&input.CertOidcIssuer, "certificate-oidc-issuer", "", "oidc issuer of the identity expected to sign the functions in keyless mode, any when empty")
+	cmd.Flags().StringVar(&input.CertIdentityRegexp, "certificate-identity-regexp", "", "regular expression the identity signing the functions in keyless mode matches")
+	cmd.Flags().StringVar(&input.CertOidcIssuerRegexp, "certificate-oidc-issuer-regexp", "", "regular expression the oidc issuer of the identity signing the functions in keyless mode matches")
+	cmd.Flags().StringArrayVar(&signers, "signer", nil, "issuer=<regexp>,subject=<regexp> of an identity allowed to sign keylessly, can be repeated, any identity when none")
+	cmd.Flags().StringVar(&input.TufMirrorUrl, "tuf-mirror", "", "url of the private sigstore TUF repository mirror used in keyless mode, the public one when empty")
+	cmd.Flags().StringVar(&input.KmsKeyRef, "kms-key-ref", "", "aws kms key signing the code instead of a key pair, i.e: awskms:///<key arn>, its public key is written to kms.pub")
+	cmd.Flags().StringVar(&input.PublicKey, "public-key", "", "path to the public key for code signing, a key pair is generated when empty and not keyless")
+	cmd.Flags().StringVar(&input.PrivateKey, "private-key", "", "path to the private key for code signing, required with --public-key")
+	cmd.Flags().StringVar(&input.PrivateKeyPassword, "private-key-password", "", "- to read the password of an encrypted --private-key from stdin")
+	cmd.Flags().String("private-key-password-file", "", "file holding the password of an encrypted --private-key")
+	cmd.Flags().StringVar(&input.KeyDir, "key-dir", "", "directory the key pair is generated in when no --public-key is given, created when missing, the current directory when empty")
+	cmd.Flags().StringVar(&input.KeyPrefix, "key-prefix", "", "file name prefix of the generated key pair, cosign when empty")
+	cmd.Flags().Bool("force", false, "overwrite the files of an existing key pair when generating one")
+	cmd.Flags().StringSliceVar(&input.IncludedFuncTagKeys, "include-tags", nil, "tag keys, or key=value tags, of the functions to include in the verification, all when empty")
+	cmd.Flags().StringSliceVar(&input.ExcludedFuncTagKeys, "exclude-tags", nil, "tag keys of the functions to skip in the verification, takes precedence over --include-tags")
+	cmd.Flags().StringSliceVar(&input.IncludedFuncRegions, "include-regions", nil, "function regions to include in the verification, i.e: us-east-1,us-west-1, all when empty")
+	cmd.Flags().StringSliceVar(&input.ExcludedFuncRegions, "exclude-regions", nil, "function regions to skip in the verification, takes precedence over --include-regions")
+	cmd.Flags().IntVar(&input.Concurrency, "concurrency", 0, "maximum number of functions the verifier lambda verifies at once for the events of an invocation, the number of CPUs when 0")
+	cmd.Flags().BoolVar(&input.EnableMetrics, "enable-metrics", false, "record prometheus metrics of the verifications of the verifier lambda")
+	cmd.Flags().StringVar(&input.MetricsPushGateway, "metrics-push-gateway", "", "url of a prometheus push gateway the verifier lambda pushes its metrics to after every invocation, with --enable-metrics")
 	return cmd
 }
 
@@ -168,13 +650,66 @@ func AwsDeploy() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var configForDeployment i.AWSInput
 			configForDeployment.Bucket = viper.GetString("bucket")
+			configForDeployment.KmsKeyArn = viper.GetString("kmskeyarn")
+			configForDeployment.SignatureRetentionDays = viper.GetInt("signatureretentiondays")
 			configForDeployment.Action = viper.GetString("action")
 			configForDeployment.Region = viper.GetString("region")
 			configForDeployment.IsKeyless = viper.GetBool("iskeyless")
+			configForDeployment.FulcioUrl = viper.GetString("fulciourl")
+			configForDeployment.RekorUrl = viper.GetString("rekorurl")
+			configForDeployment.TufRootPath = viper.GetString("tufrootpath")
+			configForDeployment.TufMirrorUrl = viper.GetString("tufmirrorurl")
 			configForDeployment.SnsTopicArn = viper.GetString("snsTopicArn")
+			configForDeployment.SlackWebhookUrl = viper.GetString("slackwebhookurl")
+			configForDeployment.WebhookUrl = viper.GetString("webhookurl")
+			configForDeployment.WebhookHeaders = viper.GetStringMapString("webhookheaders")
 			configForDeployment.IncludedFuncTagKeys = viper.GetStringSlice("includedfunctagkeys")
+			configForDeployment.IncludedFuncTags = viper.GetStringMapString("includedfunctags")
+			configForDeployment.ExcludedFuncTagKeys = viper.GetStringSlice("excludedfunctagkeys")
 			configForDeployment.IncludedFuncRegions = viper.GetStringSlice("includedfuncregions")
-			awsClient := clients.NewAwsClientInit(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("region"))
+			configForDeployment.ExcludedFuncRegions = viper.GetStringSlice("excludedfuncregions")
+			configForDeployment.VerifyConcurrency = viper.GetBool("verifyconcurrency")
+			configForDeployment.VerifyLayers = viper.GetBool("verifylayers")
+			configForDeployment.SignatureFreshness = viper.GetDuration("signaturefreshness")
+			configForDeployment.ClockSkew = options.DefaultClockSkew
+			if viper.IsSet("clockskew") {
+				configForDeployment.ClockSkew = viper.GetDuration("clockskew")
+			}
+			configForDeployment.Concurrency = viper.GetInt("concurrency")
+			configForDeployment.FunctionTimeout = viper.GetDuration("functiontimeout")
+			configForDeployment.Timeout = viper.GetDuration("timeout")
+			configForDeployment.EnableMetrics = viper.GetBool("enablemetrics")
+			configForDeployment.MetricsPushGateway = viper.GetString("metricspushgateway")
+			configForDeployment.PinSigner = viper.GetBool("pinsigner")
+			configForDeployment.RequireKeyAndKeyless = viper.GetBool("requirekeyandkeyless")
+			configForDeployment.UntrustedSignerAction = viper.GetString("untrustedsigneraction")
+			configForDeployment.DryRun = viper.GetBool("dryrun")
+			configForDeployment.RequireAwsCodeSigning = viper.GetBool("requireawscodesigning")
+			configForDeployment.RequireImageDigestPin = viper.GetBool("requireimagedigestpin")
+			configForDeployment.RequireSignedLayers = viper.GetBool("requiresignedlayers")
+			configForDeployment.EnforceSCT = viper.GetBool("enforcesct")
+			configForDeployment.CertIdentity = viper.GetString("certidentity")
+			configForDeployment.CertOidcIssuer = viper.GetString("certoidcissuer")
+			configForDeployment.CertIdentityRegexp = viper.GetString("certidentityregexp")
+			configForDeployment.CertOidcIssuerRegexp = viper.GetString("certoidcissuerregexp")
+			if err := viper.UnmarshalKey("includedsigners", &configForDeployment.IncludedSigners); err != nil {
+				return fmt.Errorf("invalid includedsigners: %w", err)
+			}
+			configForDeployment.Offline = viper.GetBool("offline")
+			configForDeployment.TlogUpload = viper.GetBool("tlogupload")
+			configForDeployment.NotificationRouting.TagKey = viper.GetString("notificationrouting.tagkey")
+			configForDeployment.NotificationRouting.Routes = viper.GetStringMapString("notificationrouting.routes")
+			configForDeployment.ResultQueue.URL = viper.GetString("resultqueue.url")
+			configForDeployment.ResultQueue.Attributes = viper.GetStringMapString("resultqueue.attributes")
+			awsClient := initClient(cmd.Context(), &i.AWSInput{
+				AccessKey:          viper.GetString("accesskey"),
+				SecretKey:          viper.GetString("secretkey"),
+				Region:             viper.GetString("region"),
+				AssumeRoleArn:      viper.GetString("assumerolearn"),
+				ExternalId:         viper.GetString("externalid"),
+				AssumeRoleDuration: viper.GetDuration("assumeroleduration"),
+				EndpointUrl:        viper.GetString("endpointurl"),
+			})
 			err := awsClient.DeployFunctionClarity(viper.GetString("cloudtrail.name"), viper.GetString("publickey"), configForDeployment, "")
 			if err != nil {
 				return fmt.Errorf("failed to deploy function clarity: %w", err)
@@ -220,7 +755,7 @@ func AwsUpdateFuncConfig() *cobra.Command {
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			awsClient := clients.NewAwsClientInit(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("region"))
+			awsClient := clients.NewAwsClientInit(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("region")).WithMaxRetries(viper.GetInt("maxretries")).WithTimeout(viper.GetDuration("timeout")).WithContext(cmd.Context())
 			includedFuncTagKeysStringArray := viper.GetStringSlice("includedfunctagkeys")
 			includedFuncTagKeys := &includedFuncTagKeysStringArray
 			if !viper.IsSet("includedfunctagkeys") && !cmd.Flags().Lookup("included-func-tags").Changed {
@@ -0,0 +1,165 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"fmt"
+	opt "github.com/openclarity/function-clarity/cmd/function-clarity/cli/options"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"github.com/openclarity/function-clarity/pkg/verify"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type compareScope struct {
+	accessKey      string
+	secretKey      string
+	region         string
+	bucket         string
+	functionRegion string
+}
+
+func (s compareScope) client() *clients.AwsClient {
+	accessKey, secretKey := s.accessKey, s.secretKey
+	if accessKey == "" && secretKey == "" {
+		accessKey, secretKey = viper.GetString("accesskey"), viper.GetString("secretkey")
+	}
+	region := s.region
+	if region == "" {
+		region = viper.GetString("region")
+	}
+	bucket := s.bucket
+	if bucket == "" {
+		bucket = viper.GetString("bucket")
+	}
+	return clients.NewAwsClient(accessKey, secretKey, bucket, region, s.functionRegion).WithMaxRetries(viper.GetInt("maxretries")).WithTimeout(viper.GetDuration("timeout"))
+}
+
+func AwsCompare() *cobra.Command {
+	o := &options.VerifyOpts{}
+	so := &options.ScanOptions{}
+	source := compareScope{}
+	destination := compareScope{}
+	cmd := &cobra.Command{
+		Use:   "aws",
+		Short: "compare the function digests and signatures of two aws accounts or regions",
+		Long: "compare the functions of a source and a destination scope, for example staging and production.\n" +
+			"functions are matched by name, each function present in both scopes is verified in both, and is reported when\n" +
+			"its code digest or its verification result differs. functions existing in only one scope are reported too.\n" +
+			"each scope uses its own credentials, region and bucket when given, and the configured ones otherwise",
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := viper.BindPFlag("accessKey", cmd.Flags().Lookup("aws-access-key")); err != nil {
+				return fmt.Errorf("error binding accessKey: %w", err)
+			}
+			if err := viper.BindPFlag("secretKey", cmd.Flags().Lookup("aws-secret-key")); err != nil {
+				return fmt.Errorf("error binding secretKey: %w", err)
+			}
+			if err := viper.BindPFlag("region", cmd.Flags().Lookup("region")); err != nil {
+				return fmt.Errorf("error binding region: %w", err)
+			}
+			if err := viper.BindPFlag("bucket", cmd.Flags().Lookup("bucket")); err != nil {
+				return fmt.Errorf("error binding bucket: %w", err)
+			}
+			if err := viper.BindPFlag("publickey", cmd.Flags().Lookup("key")); err != nil {
+				return fmt.Errorf("error binding publickey: %w", err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Key = viper.GetString("publickey")
+			sourceClient, destinationClient := source.client(), destination.client()
+			sourceFunctions, err := sourceClient.ListAllFunctions(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to list source functions: %w", err)
+			}
+			destinationFunctions, err := destinationClient.ListAllFunctions(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to list destination functions: %w", err)
+			}
+			inBoth := map[string]bool{}
+			for _, function := range sourceFunctions {
+				inBoth[function.FunctionName] = false
+			}
+			for _, function := range destinationFunctions {
+				if _, ok := inBoth[function.FunctionName]; ok {
+					inBoth[function.FunctionName] = true
+				}
+			}
+			sourceResults := verifyByName(sourceClient, sourceFunctions, inBoth, o, so, cmd)
+			destinationResults := verifyByName(destinationClient, destinationFunctions, inBoth, o, so, cmd)
+
+			compared := verify.CompareFunctions(sourceFunctions, destinationFunctions, sourceResults, destinationResults)
+			differs := 0
+			fmt.Printf("%-40s %-18s %-8s %-8s\n", "FUNCTION", "PARITY", "SOURCE", "DESTINATION")
+			for _, c := range compared {
+				if c.Parity != verify.ParityMatch {
+					differs++
+				}
+				fmt.Printf("%-40s %-18s %-8s %-8s\n", c.FunctionName, c.Parity, c.SourceResult, c.DestinationResult)
+			}
+			if differs > 0 {
+				return fmt.Errorf("%d out of %d functions differ between source and destination", differs, len(compared))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&source.functionRegion, "source-function-region", "", "aws region of the source functions")
+	cmd.MarkFlagRequired("source-function-region") //nolint:errcheck
+	cmd.Flags().StringVar(&source.accessKey, "source-aws-access-key", "", "aws access key of the source account")
+	cmd.Flags().StringVar(&source.secretKey, "source-aws-secret-key", "", "aws secret key of the source account")
+	cmd.Flags().StringVar(&source.region, "source-region", "", "aws region of the source signature bucket")
+	cmd.Flags().StringVar(&source.bucket, "source-bucket", "", "s3 bucket holding the source signatures")
+	cmd.Flags().StringVar(&destination.functionRegion, "destination-function-region", "", "aws region of the destination functions")
+	cmd.MarkFlagRequired("destination-function-region") //nolint:errcheck
+	cmd.Flags().StringVar(&destination.accessKey, "destination-aws-access-key", "", "aws access key of the destination account")
+	cmd.Flags().StringVar(&destination.secretKey, "destination-aws-secret-key", "", "aws secret key of the destination account")
+	cmd.Flags().StringVar(&destination.region, "destination-region", "", "aws region of the destination signature bucket")
+	cmd.Flags().StringVar(&destination.bucket, "destination-bucket", "", "s3 bucket holding the destination signatures")
+	o.AddFlags(cmd)
+	so.AddFlags(cmd)
+	initAwsCompareFlags(cmd)
+	return cmd
+}
+
+// verifyByName verifies the functions present in both scopes and returns their verification results by name.
+func verifyByName(client *clients.AwsClient, functions []clients.FunctionConfig, inBoth map[string]bool,
+	o *options.VerifyOpts, so *options.ScanOptions, cmd *cobra.Command) map[string]string {
+	var toVerify []clients.FunctionConfig
+	names := map[string]string{}
+	for _, function := range functions {
+		if inBoth[function.FunctionName] {
+			toVerify = append(toVerify, function)
+			names[function.FunctionArn] = function.FunctionName
+		}
+	}
+	summary := verify.VerifyFunctions(client, toVerify, o, so, cmd.Context(), "", "", nil, nil)
+	results := map[string]string{}
+	for _, result := range summary.Results {
+		results[names[result.FunctionIdentifier]] = result.Result
+	}
+	return results
+}
+
+func initAwsCompareFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&opt.Config, "config", "", "config file (default: $HOME/.fs)")
+	cmd.Flags().String("aws-access-key", "", "aws access key used by scopes without their own credentials")
+	cmd.Flags().String("aws-secret-key", "", "aws secret key used by scopes without their own credentials")
+	cmd.Flags().String("region", "", "aws region of the signature bucket used by scopes without their own region")
+	cmd.Flags().String("bucket", "", "s3 bucket used by scopes without their own bucket")
+	cmd.Flags().String("key", "", "public key for verification")
+}
@@ -0,0 +1,113 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"fmt"
+	"github.com/openclarity/function-clarity/cmd/function-clarity/cli/options"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	o "github.com/openclarity/function-clarity/pkg/options"
+	"github.com/openclarity/function-clarity/pkg/sign"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func AwsImport() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "aws",
+		Short: "import signatures into aws",
+	}
+	cmd.AddCommand(AwsImportCode())
+	return cmd
+}
+
+func AwsImportCode() *cobra.Command {
+	vo := &o.VerifyOpts{}
+	var signature, certificate, fromFile string
+
+	cmd := &cobra.Command{
+		Use:   "code [code path]",
+		Short: "import existing signatures of function clarity code identities and upload them to aws",
+		Long: "import existing signatures of function clarity code identities and upload them to aws, e.g. signatures copied\n" +
+			"from the signature store of another deployment. each signature is verified against the identity of its code before\n" +
+			"it is stored, a signature made by 'cosign sign-blob' over the code itself can't be imported, sign the code instead.\n" +
+			"signatures and certificates are read from files or http(s) urls.\n" +
+			"either import a single signature: 'import aws code <code path> --signature <file/url>', or several signatures\n" +
+			"listed in a yaml file: 'import aws code --from-file <file>', where each entry has code, signature and certificate keys.\n" +
+			"image signatures are read by function clarity directly from the registry and don't need to be imported",
+		Args: cobra.MaximumNArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := viper.BindPFlag("accessKey", cmd.Flags().Lookup("aws-access-key")); err != nil {
+				return fmt.Errorf("error binding accessKey: %w", err)
+			}
+			if err := viper.BindPFlag("secretKey", cmd.Flags().Lookup("aws-secret-key")); err != nil {
+				return fmt.Errorf("error binding secretKey: %w", err)
+			}
+			if err := viper.BindPFlag("region", cmd.Flags().Lookup("region")); err != nil {
+				return fmt.Errorf("error binding region: %w", err)
+			}
+			if err := viper.BindPFlag("bucket", cmd.Flags().Lookup("bucket")); err != nil {
+				return fmt.Errorf("error binding bucket: %w", err)
+			}
+			if err := viper.BindPFlag("publickey", cmd.Flags().Lookup("key")); err != nil {
+				return fmt.Errorf("error binding publickey: %w", err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var entries []sign.ImportEntry
+			switch {
+			case fromFile != "" && len(args) == 0:
+				loaded, err := sign.LoadImportEntries(fromFile)
+				if err != nil {
+					return err
+				}
+				entries = loaded
+			case fromFile == "" && len(args) == 1:
+				entries = []sign.ImportEntry{{Code: args[0], Signature: signature, Certificate: certificate}}
+			default:
+				return fmt.Errorf("either a code path or --from-file must be provided")
+			}
+			vo.Key = viper.GetString("publickey")
+			awsClient := clients.NewAwsClient(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("bucket"), viper.GetString("region"), "").WithKmsKey(viper.GetString("kmskeyarn")).WithMaxRetries(viper.GetInt("maxretries")).WithTimeout(viper.GetDuration("timeout"))
+			failed := 0
+			for _, entry := range entries {
+				if err := sign.ImportCodeSignature(awsClient, entry, vo, cmd.Context()); err != nil {
+					fmt.Printf("failed to import signature for code: %s: %v\n", entry.Code, err)
+					failed++
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("failed to import %d out of %d signatures", failed, len(entries))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&signature, "signature", "", "path or http(s) url of the signature to import")
+	cmd.Flags().StringVar(&certificate, "certificate", "", "path or http(s) url of the signing certificate (keyless mode)")
+	cmd.Flags().StringVar(&fromFile, "from-file", "", "yaml file listing the signatures to import")
+	initAwsImportCodeFlags(cmd)
+	return cmd
+}
+
+func initAwsImportCodeFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&options.Config, "config", "", "config file (default: $HOME/.fs)")
+	cmd.Flags().String("aws-access-key", "", "aws access key")
+	cmd.Flags().String("aws-secret-key", "", "aws secret key")
+	cmd.Flags().String("region", "", "aws region to perform the operation against")
+	cmd.Flags().String("bucket", "", "s3 bucket to work against")
+	cmd.Flags().String("key", "", "public key used to validate the imported signatures")
+}
@@ -0,0 +1,112 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"fmt"
+	opt "github.com/openclarity/function-clarity/cmd/function-clarity/cli/options"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/integrity"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"github.com/openclarity/function-clarity/pkg/ping"
+	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"time"
+)
+
+func AwsPing() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "aws",
+		Short: "check the aws credentials, the signature bucket and, in keyless mode, fulcio and rekor",
+		Long: "call sts get-caller-identity and s3 head-bucket on the signature bucket, and when keyless mode is configured or\n" +
+			"COSIGN_EXPERIMENTAL is set check fulcio and rekor answer. the latency of every check and the caller identity\n" +
+			"are printed, the command fails when any check fails",
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := viper.BindPFlag("accessKey", cmd.Flags().Lookup("aws-access-key")); err != nil {
+				return fmt.Errorf("error binding accessKey: %w", err)
+			}
+			if err := viper.BindPFlag("secretKey", cmd.Flags().Lookup("aws-secret-key")); err != nil {
+				return fmt.Errorf("error binding secretKey: %w", err)
+			}
+			if err := viper.BindPFlag("region", cmd.Flags().Lookup("region")); err != nil {
+				return fmt.Errorf("error binding region: %w", err)
+			}
+			if err := viper.BindPFlag("bucket", cmd.Flags().Lookup("bucket")); err != nil {
+				return fmt.Errorf("error binding bucket: %w", err)
+			}
+			if err := viper.BindPFlag("fulciourl", cmd.Flags().Lookup("fulcio-url")); err != nil {
+				return fmt.Errorf("error binding fulciourl: %w", err)
+			}
+			if err := viper.BindPFlag("rekorurl", cmd.Flags().Lookup("rekor-url")); err != nil {
+				return fmt.Errorf("error binding rekorurl: %w", err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			fulcioURL := options.FulcioURL(viper.GetString("fulciourl"))
+			rekorURL := options.RekorURL(viper.GetString("rekorurl"))
+			awsClient := clients.NewAwsClient(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("bucket"), viper.GetString("region"), "").WithMaxRetries(viper.GetInt("maxretries")).WithTimeout(viper.GetDuration("timeout"))
+			checks := []ping.Check{
+				ping.Run("sts", func() (string, error) {
+					arn, account, err := awsClient.CallerIdentity(ctx)
+					if err != nil {
+						return "", err
+					}
+					return fmt.Sprintf("%s (account %s)", arn, account), nil
+				}),
+				ping.Run("s3", func() (string, error) {
+					return "bucket " + viper.GetString("bucket"), awsClient.HeadBucket(ctx)
+				}),
+			}
+			isKeyless, err := integrity.IsExperimentalEnv()
+			if err != nil {
+				return err
+			}
+			if isKeyless {
+				checks = append(checks,
+					ping.Run("fulcio", func() (string, error) {
+						return ping.Reachable(ctx, ping.Endpoint(fulcioURL, "/api/v1/rootCert"))
+					}),
+					ping.Run("rekor", func() (string, error) {
+						return ping.Reachable(ctx, ping.Endpoint(rekorURL, "/api/v1/log"))
+					}))
+			}
+			fmt.Printf("%-8s %-6s %-10s %s\n", "CHECK", "STATUS", "LATENCY", "DETAILS")
+			for _, check := range checks {
+				status, details := "ok", check.Detail
+				if check.Err != nil {
+					status, details = "failed", check.Err.Error()
+				}
+				fmt.Printf("%-8s %-6s %-10s %s\n", check.Name, status, check.Latency.Round(time.Millisecond), details)
+			}
+			if failed := ping.Failed(checks); failed > 0 {
+				return fmt.Errorf("%d out of %d checks failed", failed, len(checks))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&opt.Config, "config", "", "config file (default: $HOME/.fs)")
+	cmd.Flags().String("aws-access-key", "", "aws access key")
+	cmd.Flags().String("aws-secret-key", "", "aws secret key")
+	cmd.Flags().String("region", "", "aws region of the signature bucket")
+	cmd.Flags().String("bucket", "", "s3 bucket holding the signatures")
+	cmd.Flags().String("fulcio-url", co.DefaultFulcioURL, "address of the fulcio server checked in keyless mode, the one configured by init by default")
+	cmd.Flags().String("rekor-url", co.DefaultRekorURL, "address of the rekor server checked in keyless mode, the one configured by init by default")
+	return cmd
+}
@@ -0,0 +1,130 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"fmt"
+	"github.com/openclarity/function-clarity/cmd/function-clarity/cli/common"
+	opt "github.com/openclarity/function-clarity/cmd/function-clarity/cli/options"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/prune"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"time"
+)
+
+func AwsPrune() *cobra.Command {
+	var functionRegions, keep []string
+	var minAge time.Duration
+	var dryRun, archive bool
+	cmd := &cobra.Command{
+		Use:   "aws",
+		Short: "delete or archive the signatures in the bucket not referenced by any function of the function regions",
+		Long: "the functions of every function region are listed and the code identity of each zip function is computed,\n" +
+			"signatures, certificates, metadata and signer pins not referenced by any of them are deleted, or moved under\n" +
+			"the archive/ prefix with --archive. nothing is pruned when the functions of a region can't be enumerated.\n" +
+			"every region with functions signed into the bucket must be listed, signatures of other regions are pruned otherwise",
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := viper.BindPFlag("accessKey", cmd.Flags().Lookup("aws-access-key")); err != nil {
+				return fmt.Errorf("error binding accessKey: %w", err)
+			}
+			if err := viper.BindPFlag("secretKey", cmd.Flags().Lookup("aws-secret-key")); err != nil {
+				return fmt.Errorf("error binding secretKey: %w", err)
+			}
+			if err := viper.BindPFlag("region", cmd.Flags().Lookup("region")); err != nil {
+				return fmt.Errorf("error binding region: %w", err)
+			}
+			if err := viper.BindPFlag("bucket", cmd.Flags().Lookup("bucket")); err != nil {
+				return fmt.Errorf("error binding bucket: %w", err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			live := prune.LiveObjects{}
+			live.Keep(keep)
+			total := 0
+			for _, functionRegion := range functionRegions {
+				regionClient := clients.NewAwsClient(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("bucket"), viper.GetString("region"), functionRegion).WithMaxRetries(viper.GetInt("maxretries")).WithTimeout(viper.GetDuration("timeout"))
+				functions, err := regionClient.ListAllFunctions(cmd.Context())
+				if err != nil {
+					return fmt.Errorf("failed to enumerate functions, nothing was pruned: %w", err)
+				}
+				if err = live.Add(regionClient, functions); err != nil {
+					return fmt.Errorf("failed to resolve signatures of region: %s, nothing was pruned: %w", functionRegion, err)
+				}
+				total += len(functions)
+			}
+			bucketClient := clients.NewAwsClient(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("bucket"), viper.GetString("region"), "").WithKmsKey(viper.GetString("kmskeyarn")).WithMaxRetries(viper.GetInt("maxretries")).WithTimeout(viper.GetDuration("timeout"))
+			objects, err := bucketClient.ListBucketObjects(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("nothing was pruned: %w", err)
+			}
+			stale := prune.Stale(objects, live, minAge, time.Now())
+			if len(stale) == 0 {
+				fmt.Printf("no stale signatures found for %d functions in %d regions\n", total, len(functionRegions))
+				return nil
+			}
+			var keys []string
+			var size int64
+			fmt.Printf("%-90s %-25s %s\n", "OBJECT", "LAST MODIFIED", "SIZE")
+			for _, object := range stale {
+				keys = append(keys, object.Key)
+				size += object.Size
+				fmt.Printf("%-90s %-25s %d\n", object.Key, object.LastModified.UTC().Format(time.RFC3339), object.Size)
+			}
+			verb := "delete"
+			if archive {
+				verb = "archive"
+			}
+			if dryRun {
+				fmt.Printf("dry run: would %s %d stale objects (%d bytes)\n", verb, len(keys), size)
+				return nil
+			}
+			confirmed, err := common.StdPrompter.Confirm(fmt.Sprintf("%s %d stale objects (%d bytes) from bucket: %s? (y/n, default n): ", verb, len(keys), size,
+				viper.GetString("bucket")))
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				fmt.Println("nothing was pruned")
+				return nil
+			}
+			if archive {
+				err = bucketClient.ArchiveBucketObjects(cmd.Context(), keys, prune.ArchivePrefix)
+			} else {
+				err = bucketClient.DeleteBucketObjects(cmd.Context(), keys)
+			}
+			if err != nil {
+				return fmt.Errorf("prune failed: %w", err)
+			}
+			fmt.Printf("%d stale objects pruned\n", len(keys))
+			return nil
+		},
+	}
+	cmd.Flags().StringSliceVar(&functionRegions, "function-regions", nil, "aws regions of the functions whose signatures are kept, i.e: us-east-1,us-west-1")
+	cmd.MarkFlagRequired("function-regions") //nolint:errcheck
+	cmd.Flags().DurationVar(&minAge, "min-age", prune.DefaultMinAge, "keep objects modified more recently than this, code is usually signed before it is deployed")
+	cmd.Flags().StringSliceVar(&keep, "keep", nil, "identities whose signatures are kept although no function references them, e.g. signed snapshots")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "list the stale objects without pruning them")
+	cmd.Flags().BoolVar(&archive, "archive", false, "move the stale objects under the "+prune.ArchivePrefix+" prefix instead of deleting them")
+	cmd.Flags().StringVar(&opt.Config, "config", "", "config file (default: $HOME/.fs)")
+	cmd.Flags().String("aws-access-key", "", "aws access key")
+	cmd.Flags().String("aws-secret-key", "", "aws secret key")
+	cmd.Flags().String("region", "", "aws region of the signature bucket")
+	cmd.Flags().String("bucket", "", "s3 bucket to prune")
+	return cmd
+}
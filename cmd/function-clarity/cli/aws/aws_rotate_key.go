@@ -0,0 +1,137 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	o "github.com/openclarity/function-clarity/pkg/options"
+	"github.com/openclarity/function-clarity/pkg/sign"
+	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func AwsRotateKey() *cobra.Command {
+	sbo := &o.SignBlobOptions{}
+	ro := &co.RootOptions{}
+	var functionArns, functionRegions []string
+	var fromFile, oldPublicKey, newPublicKey string
+	var concurrency int
+
+	cmd := &cobra.Command{
+		Use:   "aws",
+		Short: "re-sign the functions signed with the old key with the new key",
+		Long: "the zip functions of every --function-regions region, or the ones given with --function-arn or --from-file,\n" +
+			"whose signature verifies with --old-public-key are signed again with --key, the private key of --new-public-key.\n" +
+			"the old signature of a function is only replaced once the new one verifies with --new-public-key, and is put\n" +
+			"back when the upload fails. functions signed with another key, or not signed, are skipped. deploy the verifier\n" +
+			"with the new public key once every function is re-signed",
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := viper.BindPFlag("accessKey", cmd.Flags().Lookup("aws-access-key")); err != nil {
+				return fmt.Errorf("error binding accessKey: %w", err)
+			}
+			if err := viper.BindPFlag("secretKey", cmd.Flags().Lookup("aws-secret-key")); err != nil {
+				return fmt.Errorf("error binding secretKey: %w", err)
+			}
+			if err := viper.BindPFlag("region", cmd.Flags().Lookup("region")); err != nil {
+				return fmt.Errorf("error binding region: %w", err)
+			}
+			if err := viper.BindPFlag("bucket", cmd.Flags().Lookup("bucket")); err != nil {
+				return fmt.Errorf("error binding bucket: %w", err)
+			}
+			if err := viper.BindPFlag("privatekey", cmd.Flags().Lookup("key")); err != nil {
+				return fmt.Errorf("error binding privatekey: %w", err)
+			}
+			if err := viper.BindPFlag("rekorurl", cmd.Flags().Lookup("rekor-url")); err != nil {
+				return fmt.Errorf("error binding rekorurl: %w", err)
+			}
+			if err := viper.BindPFlag("tlogupload", cmd.Flags().Lookup("tlog-upload")); err != nil {
+				return fmt.Errorf("error binding tlogupload: %w", err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sbo.Rekor.URL = o.RekorURL(viper.GetString("rekorurl"))
+			sbo.TlogUpload = viper.GetBool("tlogupload")
+			if fromFile != "" {
+				loaded, err := sign.LoadFunctionArns(fromFile)
+				if err != nil {
+					return err
+				}
+				functionArns = append(functionArns, loaded...)
+			}
+			newClient := func(region string) clients.Client {
+				return clients.NewAwsClient(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("bucket"), viper.GetString("region"), region).WithKmsKey(viper.GetString("kmskeyarn")).WithMaxRetries(viper.GetInt("maxretries")).WithTimeout(viper.GetDuration("timeout"))
+			}
+			for _, functionRegion := range functionRegions {
+				functions, err := newClient(functionRegion).ListAllFunctions(cmd.Context())
+				if err != nil {
+					return fmt.Errorf("failed to enumerate functions of region: %s, nothing was re-signed: %w", functionRegion, err)
+				}
+				for _, function := range functions {
+					if function.PackageType == "Zip" {
+						functionArns = append(functionArns, function.FunctionArn)
+					}
+				}
+			}
+			if len(functionArns) == 0 {
+				return fmt.Errorf("no function to re-sign, either --function-regions, --function-arn or --from-file must be provided")
+			}
+			results, err := sign.RotateKey(functionArns, concurrency, newClient, oldPublicKey, newPublicKey, sbo, ro)
+			if err != nil {
+				return err
+			}
+			resigned, skipped, failed := 0, 0, 0
+			for _, result := range results {
+				switch {
+				case errors.Is(result.Err, sign.ErrNotSignedWithKey):
+					skipped++
+				case result.Err != nil:
+					failed++
+				default:
+					resigned++
+				}
+			}
+			fmt.Printf("%d functions: %d re-signed, %d skipped, %d failed\n", len(results), resigned, skipped, failed)
+			if failed == 0 {
+				return nil
+			}
+			for _, result := range results {
+				if result.Err != nil && !errors.Is(result.Err, sign.ErrNotSignedWithKey) {
+					fmt.Printf("  %s: %v\n", result.FunctionArn, result.Err)
+				}
+			}
+			return fmt.Errorf("failed to re-sign %d out of %d functions, they keep their old signature", failed, len(results))
+		},
+	}
+	cmd.Flags().StringVar(&oldPublicKey, "old-public-key", "", "public key of the key pair being replaced")
+	cmd.Flags().StringVar(&newPublicKey, "new-public-key", "", "public key of --key, the new signatures are verified with it before they are uploaded")
+	cmd.Flags().StringSliceVar(&functionRegions, "function-regions", nil, "aws regions whose zip functions are re-signed, i.e: us-east-1,us-west-1")
+	cmd.Flags().StringArrayVar(&functionArns, "function-arn", nil, "arn of a function to re-sign, repeatable")
+	cmd.Flags().StringVar(&fromFile, "from-file", "", "file listing the arns of the functions to re-sign, one per line, lines starting with # are skipped")
+	cmd.Flags().IntVar(&concurrency, "concurrency", defaultSignConcurrency, "number of functions re-signed concurrently")
+	initAwsSignCodeFlags(cmd)
+	cmd.Flags().Lookup("key").Usage = "new private key, the functions are re-signed with it"
+	cmd.MarkFlagRequired("old-public-key") //nolint:errcheck
+	cmd.MarkFlagRequired("new-public-key") //nolint:errcheck
+	cmd.MarkFlagRequired("key")            //nolint:errcheck
+	sbo.AddFlags(cmd)
+	ro.AddFlags(cmd)
+	return cmd
+}
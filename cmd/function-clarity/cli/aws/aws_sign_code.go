@@ -29,6 +29,7 @@ import (
 func AwsSignCode() *cobra.Command {
 	sbo := &o.SignBlobOptions{}
 	ro := &co.RootOptions{}
+	var lambdaRegion string
 
 	cmd := &cobra.Command{
 		Use:   "code",
@@ -50,13 +51,26 @@ func AwsSignCode() *cobra.Command {
 			if err := viper.BindPFlag("privatekey", cmd.Flags().Lookup("key")); err != nil {
 				return fmt.Errorf("error binding privatekey: %w", err)
 			}
+			if err := viper.BindPFlag("fulciourl", cmd.Flags().Lookup("fulcio-url")); err != nil {
+				return fmt.Errorf("error binding fulciourl: %w", err)
+			}
+			if err := viper.BindPFlag("rekorurl", cmd.Flags().Lookup("rekor-url")); err != nil {
+				return fmt.Errorf("error binding rekorurl: %w", err)
+			}
+			if err := viper.BindPFlag("tlogupload", cmd.Flags().Lookup("tlog-upload")); err != nil {
+				return fmt.Errorf("error binding tlogupload: %w", err)
+			}
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			awsClient := clients.NewAwsClient(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("bucket"), viper.GetString("region"), "")
+			sbo.Fulcio.URL = o.FulcioURL(viper.GetString("fulciourl"))
+			sbo.Rekor.URL = o.RekorURL(viper.GetString("rekorurl"))
+			sbo.TlogUpload = viper.GetBool("tlogupload")
+			awsClient := clients.NewAwsClient(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("bucket"), viper.GetString("region"), lambdaRegion).WithKmsKey(viper.GetString("kmskeyarn")).WithMaxRetries(viper.GetInt("maxretries")).WithTimeout(viper.GetDuration("timeout"))
 			return sign.SignAndUploadCode(awsClient, args[0], sbo, ro)
 		},
 	}
+	cmd.Flags().StringVar(&lambdaRegion, "function-region", "", "aws region where the baseline function runs")
 	initAwsSignCodeFlags(cmd)
 	sbo.AddFlags(cmd)
 	ro.AddFlags(cmd)
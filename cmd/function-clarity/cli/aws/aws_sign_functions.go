@@ -0,0 +1,115 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"fmt"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	o "github.com/openclarity/function-clarity/pkg/options"
+	"github.com/openclarity/function-clarity/pkg/sign"
+	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// defaultSignConcurrency is the number of functions signed at a time by default.
+const defaultSignConcurrency = 4
+
+func AwsSignFunctions() *cobra.Command {
+	sbo := &o.SignBlobOptions{}
+	ro := &co.RootOptions{}
+	var functionArns []string
+	var fromFile string
+	var concurrency int
+
+	cmd := &cobra.Command{
+		Use:   "functions",
+		Short: "sign the code of several deployed functions concurrently and upload their signatures to aws",
+		Long: "sign the code of deployed zip functions given with --function-arn, repeated, or listed in --from-file, one\n" +
+			"arn per line. the functions are signed --concurrency at a time, each one from the region of its arn. a failure\n" +
+			"doesn't stop the batch, the failed functions are reported at the end and the command then fails",
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := viper.BindPFlag("accessKey", cmd.Flags().Lookup("aws-access-key")); err != nil {
+				return fmt.Errorf("error binding accessKey: %w", err)
+			}
+			if err := viper.BindPFlag("secretKey", cmd.Flags().Lookup("aws-secret-key")); err != nil {
+				return fmt.Errorf("error binding secretKey: %w", err)
+			}
+			if err := viper.BindPFlag("region", cmd.Flags().Lookup("region")); err != nil {
+				return fmt.Errorf("error binding region: %w", err)
+			}
+			if err := viper.BindPFlag("bucket", cmd.Flags().Lookup("bucket")); err != nil {
+				return fmt.Errorf("error binding bucket: %w", err)
+			}
+			if err := viper.BindPFlag("privatekey", cmd.Flags().Lookup("key")); err != nil {
+				return fmt.Errorf("error binding privatekey: %w", err)
+			}
+			if err := viper.BindPFlag("fulciourl", cmd.Flags().Lookup("fulcio-url")); err != nil {
+				return fmt.Errorf("error binding fulciourl: %w", err)
+			}
+			if err := viper.BindPFlag("rekorurl", cmd.Flags().Lookup("rekor-url")); err != nil {
+				return fmt.Errorf("error binding rekorurl: %w", err)
+			}
+			if err := viper.BindPFlag("tlogupload", cmd.Flags().Lookup("tlog-upload")); err != nil {
+				return fmt.Errorf("error binding tlogupload: %w", err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sbo.Fulcio.URL = o.FulcioURL(viper.GetString("fulciourl"))
+			sbo.Rekor.URL = o.RekorURL(viper.GetString("rekorurl"))
+			sbo.TlogUpload = viper.GetBool("tlogupload")
+			if fromFile != "" {
+				loaded, err := sign.LoadFunctionArns(fromFile)
+				if err != nil {
+					return err
+				}
+				functionArns = append(functionArns, loaded...)
+			}
+			if len(functionArns) == 0 {
+				return fmt.Errorf("either --function-arn or --from-file must be provided")
+			}
+			newClient := func(region string) clients.Client {
+				return clients.NewAwsClient(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("bucket"), viper.GetString("region"), region).WithKmsKey(viper.GetString("kmskeyarn")).WithMaxRetries(viper.GetInt("maxretries")).WithTimeout(viper.GetDuration("timeout"))
+			}
+			results := sign.SignFunctions(functionArns, concurrency, newClient, sbo, ro)
+			failed := 0
+			for _, result := range results {
+				if result.Err != nil {
+					failed++
+				}
+			}
+			fmt.Printf("%d functions: %d signed, %d failed\n", len(results), len(results)-failed, failed)
+			if failed == 0 {
+				return nil
+			}
+			for _, result := range results {
+				if result.Err != nil {
+					fmt.Printf("  %s: %v\n", result.FunctionArn, result.Err)
+				}
+			}
+			return fmt.Errorf("failed to sign %d out of %d functions", failed, len(results))
+		},
+	}
+	cmd.Flags().StringArrayVar(&functionArns, "function-arn", nil, "arn of a function to sign, repeatable")
+	cmd.Flags().StringVar(&fromFile, "from-file", "", "file listing the arns of the functions to sign, one per line, lines starting with # are skipped")
+	cmd.Flags().IntVar(&concurrency, "concurrency", defaultSignConcurrency, "number of functions signed concurrently")
+	initAwsSignCodeFlags(cmd)
+	sbo.AddFlags(cmd)
+	ro.AddFlags(cmd)
+	return cmd
+}
@@ -0,0 +1,83 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	o "github.com/openclarity/function-clarity/pkg/options"
+	"github.com/openclarity/function-clarity/pkg/sign"
+	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"strings"
+)
+
+func AwsSignLayer() *cobra.Command {
+	sbo := &o.SignBlobOptions{}
+	ro := &co.RootOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "layer <layer version arn>",
+		Short: "sign the content of a lambda layer version and upload its signature to aws",
+		Long: "sign the content of a lambda layer version, downloaded from the region of its arn, and upload its signature\n" +
+			"to aws. functions with the layer attached verify it with --require-signed-layers",
+		Args: cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := viper.BindPFlag("accessKey", cmd.Flags().Lookup("aws-access-key")); err != nil {
+				return fmt.Errorf("error binding accessKey: %w", err)
+			}
+			if err := viper.BindPFlag("secretKey", cmd.Flags().Lookup("aws-secret-key")); err != nil {
+				return fmt.Errorf("error binding secretKey: %w", err)
+			}
+			if err := viper.BindPFlag("region", cmd.Flags().Lookup("region")); err != nil {
+				return fmt.Errorf("error binding region: %w", err)
+			}
+			if err := viper.BindPFlag("bucket", cmd.Flags().Lookup("bucket")); err != nil {
+				return fmt.Errorf("error binding bucket: %w", err)
+			}
+			if err := viper.BindPFlag("privatekey", cmd.Flags().Lookup("key")); err != nil {
+				return fmt.Errorf("error binding privatekey: %w", err)
+			}
+			if err := viper.BindPFlag("fulciourl", cmd.Flags().Lookup("fulcio-url")); err != nil {
+				return fmt.Errorf("error binding fulciourl: %w", err)
+			}
+			if err := viper.BindPFlag("rekorurl", cmd.Flags().Lookup("rekor-url")); err != nil {
+				return fmt.Errorf("error binding rekorurl: %w", err)
+			}
+			if err := viper.BindPFlag("tlogupload", cmd.Flags().Lookup("tlog-upload")); err != nil {
+				return fmt.Errorf("error binding tlogupload: %w", err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sbo.Fulcio.URL = o.FulcioURL(viper.GetString("fulciourl"))
+			sbo.Rekor.URL = o.RekorURL(viper.GetString("rekorurl"))
+			sbo.TlogUpload = viper.GetBool("tlogupload")
+			layerArn, err := arn.Parse(args[0])
+			if err != nil || layerArn.Service != "lambda" || strings.Count(layerArn.Resource, ":") != 2 {
+				return fmt.Errorf("invalid layer version arn: %s, expected arn:aws:lambda:<region>:<account>:layer:<name>:<version>", args[0])
+			}
+			awsClient := clients.NewAwsClient(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("bucket"), viper.GetString("region"), layerArn.Region).WithKmsKey(viper.GetString("kmskeyarn")).WithMaxRetries(viper.GetInt("maxretries")).WithTimeout(viper.GetDuration("timeout"))
+			return sign.SignLayer(awsClient, args[0], sbo, ro)
+		},
+	}
+	initAwsSignCodeFlags(cmd)
+	sbo.AddFlags(cmd)
+	ro.AddFlags(cmd)
+	return cmd
+}
@@ -0,0 +1,156 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"fmt"
+	opt "github.com/openclarity/function-clarity/cmd/function-clarity/cli/options"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"github.com/openclarity/function-clarity/pkg/snapshot"
+	"github.com/openclarity/function-clarity/pkg/verify"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"time"
+)
+
+func AwsSnapshot() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "aws",
+		Short: "create or verify a golden snapshot of the aws functions",
+	}
+	cmd.AddCommand(AwsCreateSnapshot())
+	cmd.AddCommand(AwsVerifySnapshot())
+	return cmd
+}
+
+func AwsCreateSnapshot() *cobra.Command {
+	var lambdaRegion string
+	var output string
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "write the digests and configuration of all functions in the function region to a snapshot file",
+		Long: "write the digests and configuration of all functions in the function region to a snapshot file.\n" +
+			"sign the snapshot like function code, with 'sign aws code <snapshot file>', to use it as a golden snapshot",
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := viper.BindPFlag("accessKey", cmd.Flags().Lookup("aws-access-key")); err != nil {
+				return fmt.Errorf("error binding accessKey: %w", err)
+			}
+			if err := viper.BindPFlag("secretKey", cmd.Flags().Lookup("aws-secret-key")); err != nil {
+				return fmt.Errorf("error binding secretKey: %w", err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			awsClient := clients.NewAwsClient(viper.GetString("accesskey"), viper.GetString("secretkey"), "", "", lambdaRegion).WithMaxRetries(viper.GetInt("maxretries")).WithTimeout(viper.GetDuration("timeout"))
+			functions, err := listSnapshotFunctions(cmd, awsClient)
+			if err != nil {
+				return err
+			}
+			if err = snapshot.New(functions, lambdaRegion, time.Now()).Write(output); err != nil {
+				return err
+			}
+			fmt.Printf("snapshot of %d functions written to: %s\n", len(functions), output)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&lambdaRegion, "function-region", "", "aws region of the functions")
+	cmd.MarkFlagRequired("function-region") //nolint:errcheck
+	cmd.Flags().StringVar(&output, "output", "snapshot.json", "path of the snapshot file")
+	cmd.Flags().StringVar(&opt.Config, "config", "", "config file (default: $HOME/.fs)")
+	cmd.Flags().String("aws-access-key", "", "aws access key")
+	cmd.Flags().String("aws-secret-key", "", "aws secret key")
+	return cmd
+}
+
+func AwsVerifySnapshot() *cobra.Command {
+	o := &options.VerifyOpts{}
+	var lambdaRegion string
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "verify the functions in the function region against a signed golden snapshot",
+		Long: "verify the signature of the snapshot file, then report every function of the function region whose digest\n" +
+			"or configuration deviates from the snapshot, and every function missing from or unexpected by the snapshot.\n" +
+			"the function region may differ from the region the snapshot was taken in, e.g. to validate a disaster recovery region",
+		Args: cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := viper.BindPFlag("accessKey", cmd.Flags().Lookup("aws-access-key")); err != nil {
+				return fmt.Errorf("error binding accessKey: %w", err)
+			}
+			if err := viper.BindPFlag("secretKey", cmd.Flags().Lookup("aws-secret-key")); err != nil {
+				return fmt.Errorf("error binding secretKey: %w", err)
+			}
+			if err := viper.BindPFlag("region", cmd.Flags().Lookup("region")); err != nil {
+				return fmt.Errorf("error binding region: %w", err)
+			}
+			if err := viper.BindPFlag("bucket", cmd.Flags().Lookup("bucket")); err != nil {
+				return fmt.Errorf("error binding bucket: %w", err)
+			}
+			if err := viper.BindPFlag("publickey", cmd.Flags().Lookup("key")); err != nil {
+				return fmt.Errorf("error binding publickey: %w", err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Key = viper.GetString("publickey")
+			awsClient := clients.NewAwsClient(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("bucket"), viper.GetString("region"), lambdaRegion).WithKmsKey(viper.GetString("kmskeyarn")).WithMaxRetries(viper.GetInt("maxretries")).WithTimeout(viper.GetDuration("timeout"))
+			functions, err := listSnapshotFunctions(cmd, awsClient)
+			if err != nil {
+				return err
+			}
+			deviations, err := verify.VerifySnapshot(awsClient, args[0], functions, o, cmd.Context())
+			if err != nil {
+				return err
+			}
+			if len(deviations) == 0 {
+				fmt.Printf("all %d functions match the snapshot\n", len(functions))
+				return nil
+			}
+			fmt.Printf("%-40s %-16s %s\n", "FUNCTION", "DEVIATION", "DETAILS")
+			for _, d := range deviations {
+				fmt.Printf("%-40s %-16s %s\n", d.FunctionName, d.Kind, d.Details)
+			}
+			return fmt.Errorf("%d deviations from the snapshot found", len(deviations))
+		},
+	}
+	cmd.Flags().StringVar(&lambdaRegion, "function-region", "", "aws region of the verified functions")
+	cmd.MarkFlagRequired("function-region") //nolint:errcheck
+	o.AddFlags(cmd)
+	cmd.Flags().StringVar(&opt.Config, "config", "", "config file (default: $HOME/.fs)")
+	cmd.Flags().String("aws-access-key", "", "aws access key")
+	cmd.Flags().String("aws-secret-key", "", "aws secret key")
+	cmd.Flags().String("region", "", "aws region of the signature bucket")
+	cmd.Flags().String("bucket", "", "s3 bucket holding the snapshot signature")
+	cmd.Flags().String("key", "", "public key")
+	return cmd
+}
+
+// listSnapshotFunctions lists the functions covered by snapshots, function clarity's own verifier is left out.
+func listSnapshotFunctions(cmd *cobra.Command, awsClient *clients.AwsClient) ([]clients.FunctionConfig, error) {
+	functions, err := awsClient.ListAllFunctions(cmd.Context())
+	if err != nil {
+		return nil, err
+	}
+	var inScope []clients.FunctionConfig
+	for _, function := range functions {
+		if function.FunctionName == clients.FunctionClarityLambdaVerierName {
+			continue
+		}
+		inScope = append(inScope, function)
+	}
+	return inScope, nil
+}
@@ -0,0 +1,259 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"fmt"
+	"github.com/openclarity/function-clarity/cmd/function-clarity/cli/common"
+	opt "github.com/openclarity/function-clarity/cmd/function-clarity/cli/options"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	i "github.com/openclarity/function-clarity/pkg/init"
+	"github.com/openclarity/function-clarity/pkg/notify"
+	"github.com/openclarity/function-clarity/pkg/state"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+func AwsExportState() *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "aws",
+		Short: "export the config file, public key and signature bucket content to a gzipped tar archive",
+		Long: "the archive holds the config file, the public key and every object of the signature bucket: signatures,\n" +
+			"certificates, signature metadata and signer pins. credentials and the path of the private key are redacted,\n" +
+			"they are entered again on import",
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := viper.BindPFlag("accessKey", cmd.Flags().Lookup("aws-access-key")); err != nil {
+				return fmt.Errorf("error binding accessKey: %w", err)
+			}
+			if err := viper.BindPFlag("secretKey", cmd.Flags().Lookup("aws-secret-key")); err != nil {
+				return fmt.Errorf("error binding secretKey: %w", err)
+			}
+			if err := viper.BindPFlag("region", cmd.Flags().Lookup("region")); err != nil {
+				return fmt.Errorf("error binding region: %w", err)
+			}
+			if err := viper.BindPFlag("bucket", cmd.Flags().Lookup("bucket")); err != nil {
+				return fmt.Errorf("error binding bucket: %w", err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath := viper.ConfigFileUsed()
+			if configPath == "" {
+				return fmt.Errorf("no config file found, export requires the config file created by 'init aws'")
+			}
+			content, err := os.ReadFile(configPath)
+			if err != nil {
+				return fmt.Errorf("failed to read config file: %s: %w", configPath, err)
+			}
+			var config i.AWSInput
+			if err = yaml.Unmarshal(content, &config); err != nil {
+				return fmt.Errorf("failed to parse config file: %s: %w", configPath, err)
+			}
+			s := &state.State{
+				Manifest: state.Manifest{ExportedAt: time.Now().UTC(), Bucket: viper.GetString("bucket"), Region: viper.GetString("region")},
+				Config:   config,
+				Objects:  map[string][]byte{},
+			}
+			if config.PublicKey != "" {
+				if s.PublicKey, err = os.ReadFile(config.PublicKey); err != nil {
+					return fmt.Errorf("failed to read public key: %s: %w", config.PublicKey, err)
+				}
+			}
+			bucketClient := clients.NewAwsClient(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("bucket"), viper.GetString("region"), "").WithKmsKey(viper.GetString("kmskeyarn")).WithMaxRetries(viper.GetInt("maxretries")).WithTimeout(viper.GetDuration("timeout"))
+			objects, err := bucketClient.ListBucketObjects(cmd.Context())
+			if err != nil {
+				return err
+			}
+			for _, object := range objects {
+				if s.Objects[object.Key], err = bucketClient.GetBucketObject(cmd.Context(), object.Key); err != nil {
+					return err
+				}
+			}
+			f, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+			if err != nil {
+				return fmt.Errorf("failed to create state archive: %s: %w", output, err)
+			}
+			defer f.Close()
+			if err = state.Write(f, s); err != nil {
+				return err
+			}
+			fmt.Printf("exported the config and %d objects of bucket: %s to: %s, credentials and the private key path were redacted\n",
+				len(s.Objects), viper.GetString("bucket"), output)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&output, "output", "function-clarity-state.tar.gz", "path of the state archive")
+	cmd.Flags().StringVar(&opt.Config, "config", "", "config file (default: $HOME/.fs)")
+	cmd.Flags().String("aws-access-key", "", "aws access key")
+	cmd.Flags().String("aws-secret-key", "", "aws secret key")
+	cmd.Flags().String("region", "", "aws region of the signature bucket")
+	cmd.Flags().String("bucket", "", "s3 bucket to export")
+	return cmd
+}
+
+func AwsImportState() *cobra.Command {
+	var accessKey, secretKey, bucket, region, keyDir, configOutput string
+	var skipConfig, skipObjects bool
+	cmd := &cobra.Command{
+		Use:   "aws <state archive>",
+		Short: "recreate the config file and the signature bucket content from an exported archive",
+		Long: "the configuration of the archive is validated before anything is applied. credentials are prompted when not\n" +
+			"given as flags, and in key mode the path of the private key used for signing, then the slack webhook url and the\n" +
+			"webhook headers, which are redacted from the archive. the signature bucket must exist, in a new account import\n" +
+			"with --skip-objects, run 'deploy aws' to create the deployment and its bucket, then import again with --skip-config",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to open state archive: %w", err)
+			}
+			defer f.Close()
+			s, err := state.Read(f)
+			if err != nil {
+				return err
+			}
+			config := s.Config
+			if bucket != "" {
+				config.Bucket = bucket
+			}
+			if region != "" {
+				config.Region = region
+			}
+			if err = state.Validate(config); err != nil {
+				return err
+			}
+			if accessKey == "" {
+				if err = common.InputStringParameter("enter Access Key: ", &accessKey, false); err != nil {
+					return err
+				}
+			}
+			if secretKey == "" {
+				if err = common.InputStringParameter("enter Secret Key: ", &secretKey, false); err != nil {
+					return err
+				}
+			}
+			awsClient := clients.NewAwsClientInit(accessKey, secretKey, config.Region).WithMaxRetries(viper.GetInt("maxretries")).WithTimeout(viper.GetDuration("timeout"))
+			if err := awsClient.ValidateCredentials(cmd.Context()); err != nil {
+				return fmt.Errorf("validation error: credentials aren't valid")
+			}
+			if !skipConfig {
+				if err = importConfig(s, config, accessKey, secretKey, keyDir, configOutput); err != nil {
+					return err
+				}
+			}
+			if skipObjects {
+				return nil
+			}
+			if exists, err := awsClient.IsBucketExist(config.Bucket); err != nil {
+				return err
+			} else if !exists {
+				return fmt.Errorf("bucket: %s doesn't exist, deploy with 'deploy aws' first and import the objects with --skip-config", config.Bucket)
+			}
+			bucketClient := clients.NewAwsClient(accessKey, secretKey, config.Bucket, config.Region, "").WithKmsKey(config.KmsKeyArn).WithMaxRetries(viper.GetInt("maxretries")).WithTimeout(viper.GetDuration("timeout"))
+			keys := make([]string, 0, len(s.Objects))
+			for key := range s.Objects {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for imported, key := range keys {
+				if err = bucketClient.PutBucketObject(cmd.Context(), key, s.Objects[key]); err != nil {
+					return fmt.Errorf("import stopped after %d of %d objects: %w", imported, len(keys), err)
+				}
+			}
+			fmt.Printf("imported %d objects to bucket: %s\n", len(keys), config.Bucket)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&accessKey, "aws-access-key", "", "aws access key, prompted when empty")
+	cmd.Flags().StringVar(&secretKey, "aws-secret-key", "", "aws secret key, prompted when empty")
+	cmd.Flags().StringVar(&bucket, "bucket", "", "s3 bucket to import to, replacing the bucket of the archive")
+	cmd.Flags().StringVar(&region, "region", "", "aws region to import to, replacing the region of the archive")
+	cmd.Flags().StringVar(&keyDir, "key-dir", ".", "directory in which the public key of the archive is written")
+	cmd.Flags().StringVar(&configOutput, "config-output", "", "path of the config file to write (default: $HOME/.fc)")
+	cmd.Flags().BoolVar(&skipConfig, "skip-config", false, "only import the signature bucket content")
+	cmd.Flags().BoolVar(&skipObjects, "skip-objects", false, "only import the config file and the public key")
+	return cmd
+}
+
+// importConfig writes the public key of the archive and the config file completed with the entered secrets, slack
+// webhook url and webhook headers.
+func importConfig(s *state.State, config i.AWSInput, accessKey string, secretKey string, keyDir string, configOutput string) error {
+	if len(s.PublicKey) > 0 {
+		config.PublicKey = filepath.Join(keyDir, "cosign.pub")
+		if err := os.WriteFile(config.PublicKey, s.PublicKey, 0644); err != nil {
+			return fmt.Errorf("failed to write public key: %w", err)
+		}
+	}
+	if !config.IsKeyless {
+		if err := common.InputStringParameter("enter path to the private key for code signing (leave empty to only verify): ", &config.PrivateKey, true); err != nil {
+			return err
+		}
+	}
+	if err := common.InputStringParameter("enter the slack incoming webhook url notified when signature verification fails (leave empty for none): ", &config.SlackWebhookUrl, true); err != nil {
+		return err
+	}
+	if config.SlackWebhookUrl != "" && !strings.HasPrefix(config.SlackWebhookUrl, "https://") {
+		return fmt.Errorf("validation error: %s is not a valid slack webhook url, expected https://hooks.slack.com/services/...", config.SlackWebhookUrl)
+	}
+	if config.WebhookUrl != "" {
+		var headers []string
+		if err := common.InputStringArrayParameter("enter key:value headers of the webhook posts, e.g. Authorization:Bearer <token>, comma separated (leave empty for none): ", &headers, true); err != nil {
+			return err
+		}
+		parsed, err := notify.ParseHeaders(headers)
+		if err != nil {
+			return fmt.Errorf("validation error: %w", err)
+		}
+		if len(parsed) > 0 {
+			config.WebhookHeaders = parsed
+		}
+	}
+	config.AccessKey = accessKey
+	config.SecretKey = secretKey
+	if configOutput == "" {
+		h, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to import config: %w", err)
+		}
+		configOutput = h + "/.fc"
+	}
+	if _, err := os.Stat(configOutput); err == nil {
+		overwrite, err := common.StdPrompter.Confirm(fmt.Sprintf("overwrite config file: %s? (y/n, default n): ", configOutput))
+		if err != nil {
+			return err
+		}
+		if !overwrite {
+			return fmt.Errorf("config file: %s exists, nothing was imported", configOutput)
+		}
+	}
+	d, err := yaml.Marshal(&config)
+	if err != nil {
+		return fmt.Errorf("failed to import config: %w", err)
+	}
+	if err = os.WriteFile(configOutput, d, 0600); err != nil {
+		return fmt.Errorf("failed to import config: %w", err)
+	}
+	fmt.Printf("config written to: %s\n", configOutput)
+	return nil
+}
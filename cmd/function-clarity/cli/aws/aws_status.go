@@ -0,0 +1,175 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"fmt"
+	opt "github.com/openclarity/function-clarity/cmd/function-clarity/cli/options"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/ping"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"time"
+)
+
+// defaultInvocationWindow is how recently the verifier must have been invoked by default.
+const defaultInvocationWindow = 24 * time.Hour
+
+// statusClient is the part of clients.AwsClient the deployment checks call.
+type statusClient interface {
+	CallerIdentity(ctx context.Context) (string, string, error)
+	IsBucketExist(bucketName string) (bool, error)
+	CheckBucketWritable(ctx context.Context) error
+	IsSnsTopicExist(topicArn string) (bool, error)
+	IsCloudTrailExist(trailName string) (bool, error)
+	IsCloudTrailLogging(trailName string) (bool, error)
+	GetVerifierState(ctx context.Context) (string, error)
+	VerifierInvocations(ctx context.Context, since time.Time) (int, error)
+}
+
+func AwsStatus() *cobra.Command {
+	var invocationWindow time.Duration
+	cmd := &cobra.Command{
+		Use:   "aws",
+		Short: "check the function clarity deployment works: credentials, bucket, sns topic, trail and verifier",
+		Long: "check the credentials are valid, the signature bucket exists and is writable, the sns topic exists when one is\n" +
+			"configured, the trail exists and is logging, and the verifier function is deployed, active and was invoked within\n" +
+			"--invocation-window. a pass or fail is printed for every check, the command fails when any check fails",
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := viper.BindPFlag("accessKey", cmd.Flags().Lookup("aws-access-key")); err != nil {
+				return fmt.Errorf("error binding accessKey: %w", err)
+			}
+			if err := viper.BindPFlag("secretKey", cmd.Flags().Lookup("aws-secret-key")); err != nil {
+				return fmt.Errorf("error binding secretKey: %w", err)
+			}
+			if err := viper.BindPFlag("region", cmd.Flags().Lookup("region")); err != nil {
+				return fmt.Errorf("error binding region: %w", err)
+			}
+			if err := viper.BindPFlag("bucket", cmd.Flags().Lookup("bucket")); err != nil {
+				return fmt.Errorf("error binding bucket: %w", err)
+			}
+			if err := viper.BindPFlag("snstopicarn", cmd.Flags().Lookup("sns-topic")); err != nil {
+				return fmt.Errorf("error binding snstopicarn: %w", err)
+			}
+			if err := viper.BindPFlag("cloudtrail.name", cmd.Flags().Lookup("cloudtrail")); err != nil {
+				return fmt.Errorf("error binding cloudtrail.name: %w", err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			awsClient := clients.NewAwsClient(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("bucket"), viper.GetString("region"), "").WithKmsKey(viper.GetString("kmskeyarn")).WithMaxRetries(viper.GetInt("maxretries")).WithTimeout(viper.GetDuration("timeout"))
+			trailName := viper.GetString("cloudtrail.name")
+			if trailName == "" {
+				trailName = clients.FunctionClarityTrailName
+			}
+			checks := statusChecks(cmd.Context(), awsClient, viper.GetString("bucket"), viper.GetString("snstopicarn"), trailName, invocationWindow)
+			for _, check := range checks {
+				status, details := "pass", check.Detail
+				if check.Err != nil {
+					status, details = "fail", check.Err.Error()
+				}
+				fmt.Printf("[%s] %-20s %s\n", status, check.Name, details)
+			}
+			if failed := ping.Failed(checks); failed > 0 {
+				return fmt.Errorf("%d out of %d checks failed", failed, len(checks))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&opt.Config, "config", "", "config file (default: $HOME/.fs)")
+	cmd.Flags().String("aws-access-key", "", "aws access key")
+	cmd.Flags().String("aws-secret-key", "", "aws secret key")
+	cmd.Flags().String("region", "", "aws region function clarity is deployed in")
+	cmd.Flags().String("bucket", "", "s3 bucket holding the signatures")
+	cmd.Flags().String("sns-topic", "", "arn of the sns topic notified when verification fails, not checked when empty")
+	cmd.Flags().String("cloudtrail", "", "trail triggering the verifier, the function clarity trail when empty")
+	cmd.Flags().DurationVar(&invocationWindow, "invocation-window", defaultInvocationWindow, "the verifier must have been invoked within this duration")
+	return cmd
+}
+
+// statusChecks checks every part of the deployment, in the order they depend on each other. A check failing doesn't
+// stop the next ones so that every broken part is reported at once.
+func statusChecks(ctx context.Context, client statusClient, bucket string, topicArn string, trailName string,
+	invocationWindow time.Duration) []ping.Check {
+	return []ping.Check{
+		ping.Run("credentials", func() (string, error) {
+			arn, account, err := client.CallerIdentity(ctx)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%s (account %s)", arn, account), nil
+		}),
+		ping.Run("bucket exists", func() (string, error) {
+			if exists, err := client.IsBucketExist(bucket); err != nil {
+				return "", err
+			} else if !exists {
+				return "", fmt.Errorf("bucket: %s doesn't exist or isn't accessible", bucket)
+			}
+			return "bucket " + bucket, nil
+		}),
+		ping.Run("bucket writable", func() (string, error) {
+			return "bucket " + bucket, client.CheckBucketWritable(ctx)
+		}),
+		ping.Run("sns topic", func() (string, error) {
+			if topicArn == "" {
+				return "no topic configured", nil
+			}
+			if exists, err := client.IsSnsTopicExist(topicArn); err != nil {
+				return "", err
+			} else if !exists {
+				return "", fmt.Errorf("sns topic: %s doesn't exist or isn't accessible", topicArn)
+			}
+			return topicArn, nil
+		}),
+		ping.Run("trail logging", func() (string, error) {
+			if exists, err := client.IsCloudTrailExist(trailName); err != nil {
+				return "", err
+			} else if !exists {
+				return "", fmt.Errorf("trail: %s doesn't exist", trailName)
+			}
+			logging, err := client.IsCloudTrailLogging(trailName)
+			if err != nil {
+				return "", err
+			}
+			if !logging {
+				return "", fmt.Errorf("trail: %s is stopped, the verifier isn't triggered", trailName)
+			}
+			return "trail " + trailName, nil
+		}),
+		ping.Run("verifier deployed", func() (string, error) {
+			state, err := client.GetVerifierState(ctx)
+			if err != nil {
+				return "", err
+			}
+			if state != "Active" {
+				return "", fmt.Errorf("verifier function: %s is %s", clients.FunctionClarityLambdaVerierName, state)
+			}
+			return clients.FunctionClarityLambdaVerierName + " " + state, nil
+		}),
+		ping.Run("verifier invoked", func() (string, error) {
+			invocations, err := client.VerifierInvocations(ctx, time.Now().Add(-invocationWindow))
+			if err != nil {
+				return "", err
+			}
+			if invocations == 0 {
+				return "", fmt.Errorf("verifier function wasn't invoked in the last %s", invocationWindow)
+			}
+			return fmt.Sprintf("%d invocations in the last %s", invocations, invocationWindow), nil
+		}),
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeDeployment struct {
+	statusClient
+	trailLogging bool
+	invocations  int
+}
+
+func (f fakeDeployment) CallerIdentity(context.Context) (string, string, error) {
+	return "arn:aws:iam::123456789012:user/admin", "123456789012", nil
+}
+
+func (f fakeDeployment) IsBucketExist(string) (bool, error) {
+	return true, nil
+}
+
+func (f fakeDeployment) CheckBucketWritable(context.Context) error {
+	return fmt.Errorf("access denied")
+}
+
+func (f fakeDeployment) IsCloudTrailExist(string) (bool, error) {
+	return true, nil
+}
+
+func (f fakeDeployment) IsCloudTrailLogging(string) (bool, error) {
+	return f.trailLogging, nil
+}
+
+func (f fakeDeployment) GetVerifierState(context.Context) (string, error) {
+	return "Active", nil
+}
+
+func (f fakeDeployment) VerifierInvocations(context.Context, time.Time) (int, error) {
+	return f.invocations, nil
+}
+
+func TestStatusChecks(t *testing.T) {
+	checks := statusChecks(context.Background(), fakeDeployment{trailLogging: false, invocations: 0}, "signatures", "", "trail", time.Hour)
+	failed := map[string]string{}
+	for _, check := range checks {
+		if check.Err != nil {
+			failed[check.Name] = check.Err.Error()
+		}
+	}
+	if len(checks) != 7 || len(failed) != 3 {
+		t.Fatalf("expected 3 failed checks out of 7, got: %v", failed)
+	}
+	if !strings.Contains(failed["bucket writable"], "access denied") || !strings.Contains(failed["trail logging"], "stopped") ||
+		!strings.Contains(failed["verifier invoked"], "1h0m0s") {
+		t.Fatalf("unexpected failures: %v", failed)
+	}
+
+	for _, check := range statusChecks(context.Background(), fakeDeployment{trailLogging: true, invocations: 5}, "signatures", "", "trail", time.Hour) {
+		if check.Err != nil && check.Name != "bucket writable" {
+			t.Fatalf("unexpected failure of check: %s: %v", check.Name, check.Err)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"fmt"
+	opt "github.com/openclarity/function-clarity/cmd/function-clarity/cli/options"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/manifest"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"github.com/openclarity/function-clarity/pkg/verify"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func AwsVerifyManifest() *cobra.Command {
+	o := &options.VerifyOpts{}
+	so := &options.ScanOptions{}
+	var lambdaRegion string
+	cmd := &cobra.Command{
+		Use:   "manifest <file>",
+		Short: "verify the aws functions listed in a release manifest run their expected digest and are signed",
+		Long: "verify that every function listed in the manifest is deployed in the function region, runs the digest the\n" +
+			"manifest expects and passes signature verification. every deviation is reported and the command fails when any\n" +
+			"function deviates. functions deployed but not listed are ignored. the manifest is yaml or json:\n" +
+			"functions:\n" +
+			"  - name: <function name>\n" +
+			"    digest: <code sha256 reported by lambda, base64 or hex>",
+		Args: cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := viper.BindPFlag("accessKey", cmd.Flags().Lookup("aws-access-key")); err != nil {
+				return fmt.Errorf("error binding accessKey: %w", err)
+			}
+			if err := viper.BindPFlag("secretKey", cmd.Flags().Lookup("aws-secret-key")); err != nil {
+				return fmt.Errorf("error binding secretKey: %w", err)
+			}
+			if err := viper.BindPFlag("region", cmd.Flags().Lookup("region")); err != nil {
+				return fmt.Errorf("error binding region: %w", err)
+			}
+			if err := viper.BindPFlag("bucket", cmd.Flags().Lookup("bucket")); err != nil {
+				return fmt.Errorf("error binding bucket: %w", err)
+			}
+			if err := viper.BindPFlag("publickey", cmd.Flags().Lookup("key")); err != nil {
+				return fmt.Errorf("error binding publickey: %w", err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			release, err := manifest.Load(args[0])
+			if err != nil {
+				return err
+			}
+			if _, _, err := so.ParallelismLevel(); err != nil {
+				return err
+			}
+			o.Key = viper.GetString("publickey")
+			awsClient := clients.NewAwsClient(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("bucket"), viper.GetString("region"), lambdaRegion).WithKmsKey(viper.GetString("kmskeyarn")).WithMaxRetries(viper.GetInt("maxretries")).WithTimeout(viper.GetDuration("timeout"))
+			functions, err := awsClient.ListAllFunctions(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to list functions: %w", err)
+			}
+			listed := release.Names()
+			var toVerify []clients.FunctionConfig
+			names := map[string]string{}
+			for _, function := range functions {
+				if listed[function.FunctionName] {
+					toVerify = append(toVerify, function)
+					names[function.FunctionArn] = function.FunctionName
+				}
+			}
+			summary := verify.VerifyFunctions(awsClient, toVerify, o, so, cmd.Context(), "", "", nil, nil)
+			results := map[string]string{}
+			for _, result := range summary.Results {
+				results[names[result.FunctionIdentifier]] = result.Result
+			}
+			deviations := release.Check(toVerify, results)
+			if len(deviations) == 0 {
+				fmt.Printf("all %d functions match the manifest\n", len(release.Functions))
+				return nil
+			}
+			fmt.Printf("%-40s %-16s %s\n", "FUNCTION", "DEVIATION", "DETAILS")
+			for _, d := range deviations {
+				fmt.Printf("%-40s %-16s %s\n", d.FunctionName, d.Kind, d.Details)
+			}
+			return fmt.Errorf("%d deviations from the manifest found", len(deviations))
+		},
+	}
+	cmd.Flags().StringVar(&lambdaRegion, "function-region", "", "aws region of the verified functions")
+	cmd.MarkFlagRequired("function-region") //nolint:errcheck
+	o.AddFlags(cmd)
+	so.AddFlags(cmd)
+	cmd.Flags().StringVar(&opt.Config, "config", "", "config file (default: $HOME/.fs)")
+	cmd.Flags().String("aws-access-key", "", "aws access key")
+	cmd.Flags().String("aws-secret-key", "", "aws secret key")
+	cmd.Flags().String("region", "", "aws region of the signature bucket")
+	cmd.Flags().String("bucket", "", "s3 bucket holding the signatures")
+	cmd.Flags().String("key", "", "public key")
+	return cmd
+}
@@ -0,0 +1,130 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"strings"
+	"time"
+)
+
+// completionTimeout bounds the aws calls listing the suggestions, the shell waits on them.
+const completionTimeout = 5 * time.Second
+
+// regionFlags and functionFlags are the flags of the aws commands completed with the regions enabled for the account
+// and with the functions of the region.
+var (
+	regionFlags   = []string{"region", "function-regions", "include-regions", "exclude-regions"}
+	functionFlags = []string{"function-arn", "function"}
+)
+
+// RegisterCompletions registers the dynamic completion of the region and function flags of the aws commands under
+// root. The suggestions are listed with the credentials of the command line or the config file, failing calls, e.g.
+// for missing credentials, suggest nothing rather than failing the completion.
+func RegisterCompletions(root *cobra.Command) {
+	for _, cmd := range root.Commands() {
+		if cmd.Name() == "aws" {
+			registerAwsCompletions(cmd)
+		} else {
+			RegisterCompletions(cmd)
+		}
+	}
+}
+
+func registerAwsCompletions(cmd *cobra.Command) {
+	for _, flag := range regionFlags {
+		if cmd.Flags().Lookup(flag) != nil {
+			cmd.RegisterFlagCompletionFunc(flag, completeRegions) //nolint:errcheck
+		}
+	}
+	for _, flag := range functionFlags {
+		if cmd.Flags().Lookup(flag) != nil {
+			cmd.RegisterFlagCompletionFunc(flag, completeFunctionArns) //nolint:errcheck
+		}
+	}
+	for _, child := range cmd.Commands() {
+		registerAwsCompletions(child)
+	}
+}
+
+func completeRegions(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+	regions, err := completionClient(cmd).EnabledRegions(ctx, completionRegion(cmd))
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeListItem(regions, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeFunctionArns(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+	functions, err := completionClient(cmd).ListAllFunctions(ctx)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var functionArns []string
+	for _, function := range functions {
+		if strings.HasPrefix(function.FunctionArn, toComplete) {
+			functionArns = append(functionArns, function.FunctionArn)
+		}
+	}
+	return functionArns, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeListItem completes the last item of a comma separated list, e.g. us-east-1,eu-w completes to
+// us-east-1,eu-west-1 and us-east-1,eu-west-2.
+func completeListItem(values []string, toComplete string) []string {
+	head, last := "", toComplete
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		head, last = toComplete[:i+1], toComplete[i+1:]
+	}
+	var completions []string
+	for _, value := range values {
+		if strings.HasPrefix(value, last) {
+			completions = append(completions, head+value)
+		}
+	}
+	return completions
+}
+
+// completionClient is created from the credential flags of the command, init and sign do not name them the same, or
+// the config file since the flags aren't bound to it while completing.
+func completionClient(cmd *cobra.Command) *clients.AwsClient {
+	region := completionRegion(cmd)
+	return clients.NewAwsClient(completionFlag(cmd, "accesskey", "aws-access-key", "access-key"),
+		completionFlag(cmd, "secretkey", "aws-secret-key", "secret-key"), "", region, region)
+}
+
+func completionRegion(cmd *cobra.Command) string {
+	if region := completionFlag(cmd, "region", "region"); region != "" {
+		return region
+	}
+	return "us-east-1"
+}
+
+func completionFlag(cmd *cobra.Command, key string, flags ...string) string {
+	for _, name := range flags {
+		if flag := cmd.Flags().Lookup(name); flag != nil && flag.Changed {
+			return flag.Value.String()
+		}
+	}
+	return viper.GetString(key)
+}
@@ -0,0 +1,61 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"github.com/spf13/cobra"
+	"reflect"
+	"testing"
+)
+
+func TestCompleteListItem(t *testing.T) {
+	regions := []string{"us-east-1", "eu-west-1", "eu-west-2"}
+	if completions := completeListItem(regions, "eu-"); !reflect.DeepEqual(completions, []string{"eu-west-1", "eu-west-2"}) {
+		t.Fatalf("unexpected completions: %v", completions)
+	}
+	if completions := completeListItem(regions, "us-east-1,eu-west-2"); !reflect.DeepEqual(completions, []string{"us-east-1,eu-west-2"}) {
+		t.Fatalf("expected the last item of the list to be completed, got: %v", completions)
+	}
+	if completions := completeListItem(regions, "ap-"); len(completions) != 0 {
+		t.Fatalf("expected no completion, got: %v", completions)
+	}
+}
+
+func TestRegisterCompletions(t *testing.T) {
+	root := &cobra.Command{Use: "function-clarity"}
+	sign := &cobra.Command{Use: "sign"}
+	aws := &cobra.Command{Use: "aws"}
+	functions := &cobra.Command{Use: "functions"}
+	functions.Flags().String("region", "", "")
+	functions.Flags().StringArray("function-arn", nil, "")
+	aws.AddCommand(functions)
+	sign.AddCommand(aws)
+	gcp := &cobra.Command{Use: "gcp"}
+	gcp.Flags().String("region", "", "")
+	sign.AddCommand(gcp)
+	root.AddCommand(sign)
+
+	RegisterCompletions(root)
+	for _, flag := range []string{"region", "function-arn"} {
+		// registering a completion of a flag again fails
+		if err := functions.RegisterFlagCompletionFunc(flag, completeRegions); err == nil {
+			t.Fatalf("expected --%s of sign aws functions to be completed", flag)
+		}
+	}
+	if err := gcp.RegisterFlagCompletionFunc("region", completeRegions); err != nil {
+		t.Fatalf("expected the flags of other clouds not to be completed with aws")
+	}
+}
@@ -16,205 +16,1078 @@
 package aws
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/openclarity/function-clarity/cmd/function-clarity/cli/common"
 	"github.com/openclarity/function-clarity/pkg/clients"
 	i "github.com/openclarity/function-clarity/pkg/init"
+	"github.com/openclarity/function-clarity/pkg/notify"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"github.com/openclarity/function-clarity/pkg/utils"
 	"github.com/sigstore/cosign/cmd/cosign/cli/generate"
+	awskms "github.com/sigstore/sigstore/pkg/signature/kms/aws"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
 	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
-func ReceiveParameters(i *i.AWSInput) error {
-	awsClient, err := receiveAndValidateCredentials(i)
+// initPrompts reads the init parameters given neither as flags nor in the config file from stdin. Nothing is read
+// when every required parameter is given, or when stdin isn't a terminal: the optional parameters keep their value or
+// default, and a missing required parameter is an error instead of a prompt waiting on stdin.
+type initPrompts struct {
+	flags       *pflag.FlagSet
+	fromFile    map[string]bool
+	interactive bool
+	prompter    *common.Prompter
+}
+
+func newInitPrompts(flags *pflag.FlagSet, fromFile map[string]bool, prompter *common.Prompter) initPrompts {
+	prompts := initPrompts{flags: flags, fromFile: fromFile, prompter: prompter}
+	prompts.interactive = prompts.missingRequired() && prompter.IsTerminal()
+	return prompts
+}
+
+// missingRequired tells whether a required parameter isn't given: the region, and the secret key of a given access
+// key. Without an access key the credentials come from the default aws credential chain.
+func (p initPrompts) missingRequired() bool {
+	return !p.given("region") || (p.given("access-key") && !p.given("secret-key"))
+}
+
+func (p initPrompts) given(flag string) bool {
+	return (p.flags != nil && p.flags.Changed(flag)) || p.fromFile[flag]
+}
+
+// prompt tells whether the parameter of the flag is read from stdin, and fails for a compulsory parameter that is
+// neither given nor can be prompted for.
+func (p initPrompts) prompt(flag string, em bool) (bool, error) {
+	if p.given(flag) || p.interactive {
+		return !p.given(flag), nil
+	}
+	if !em {
+		return false, fmt.Errorf("missing required flag: --%s, stdin isn't a terminal to prompt for it", flag)
+	}
+	return false, nil
+}
+
+// confirm asks to confirm an action, which is confirmed by --yes and declined without asking when nothing is prompted
+// for.
+func (p initPrompts) confirm(q string) (bool, error) {
+	if p.prompter != nil && p.prompter.AssumeYes {
+		return true, nil
+	}
+	if !p.interactive {
+		return false, nil
+	}
+	return p.prompter.Confirm(q)
+}
+
+func (p initPrompts) stringParameter(flag string, q string, v *string, em bool) error {
+	prompt, err := p.prompt(flag, em)
+	if err != nil || !prompt {
+		if err == nil && !em && *v == "" {
+			return fmt.Errorf("--%s is a compulsory parameter", flag)
+		}
+		return err
+	}
+	return p.prompter.String(q, v, em)
+}
+
+func (p initPrompts) secretParameter(flag string, q string, v *string) error {
+	prompt, err := p.prompt(flag, false)
+	if err != nil || !prompt {
+		if err == nil && *v == "" {
+			return fmt.Errorf("--%s is a compulsory parameter", flag)
+		}
+		return err
+	}
+	return p.prompter.Secret(q, v)
+}
+
+func (p initPrompts) stringArrayParameter(flag string, q string, v *[]string, em bool) error {
+	prompt, err := p.prompt(flag, em)
+	if err != nil || !prompt {
+		return err
+	}
+	return p.prompter.StringArray(q, v, em)
+}
+
+func (p initPrompts) yesNoParameter(flag string, q string, v *bool) error {
+	prompt, err := p.prompt(flag, true)
+	if err != nil || !prompt {
+		return err
+	}
+	return p.prompter.YesNo(q, v, false)
+}
+
+func (p initPrompts) multipleChoiceParameter(flag string, action string, v *string, m map[string]string, em bool) error {
+	prompt, err := p.prompt(flag, em)
 	if err != nil {
 		return err
 	}
+	if !prompt {
+		for _, element := range m {
+			if *v == element {
+				return nil
+			}
+		}
+		if em && *v == "" {
+			return nil
+		}
+		return fmt.Errorf("invalid --%s: %s", flag, *v)
+	}
+	return p.prompter.MultipleChoice(action, v, m, em)
+}
 
-	if err := receiveAndValidateBucketName(i, awsClient); err != nil {
+// ReceiveParameters fills the init parameters from the flags and the init config file, prompting for the others when
+// possible. The credentials, bucket, sns topic and trail are validated either way.
+func ReceiveParameters(ctx context.Context, i *i.AWSInput, flags *pflag.FlagSet, fromFile map[string]bool, prompter *common.Prompter) error {
+	prompts := newInitPrompts(flags, fromFile, prompter)
+	awsClient, err := receiveAndValidateCredentials(ctx, i, prompts)
+	if err != nil {
 		return err
 	}
 
-	if err := inputStringArrayParameter("enter tag keys of functions to include in the verification (leave empty to include all): ", &i.IncludedFuncTagKeys, true); err != nil {
+	if err := receiveAndValidateBucketName(i, awsClient, prompts); err != nil {
 		return err
 	}
-	if err := inputStringArrayParameter("enter the function regions to include in the verification, i.e: us-east-1,us-west-1 (leave empty to include all): ", &i.IncludedFuncRegions, true); err != nil {
+	if err := receiveAndValidateKmsKey(i, awsClient, prompts); err != nil {
+		return err
+	}
+	awsClient = awsClient.WithKmsKey(i.KmsKeyArn)
+	if err := receiveSignatureRetention(i, prompts); err != nil {
 		return err
 	}
 
-	if err := inputMultipleChoiceParameter("post verification action", &i.Action, map[string]string{"1": "detect", "2": "block"}, true); err != nil {
+	if err := prompts.stringArrayParameter("include-tags", "enter tag keys, or key=value tags, of functions to include in the verification (leave empty to include all): ", &i.IncludedFuncTagKeys, true); err != nil {
+		return err
+	}
+	keys, tags := options.ParseTagFilter(i.IncludedFuncTagKeys)
+	i.IncludedFuncTagKeys = keys
+	for key, value := range tags {
+		if i.IncludedFuncTags == nil {
+			i.IncludedFuncTags = map[string]string{}
+		}
+		i.IncludedFuncTags[key] = value
+	}
+	if err := prompts.stringArrayParameter("exclude-tags", "enter tag keys of functions to skip in the verification, even when they have an included tag (leave empty to skip none): ", &i.ExcludedFuncTagKeys, true); err != nil {
+		return err
+	}
+	if err := receiveAndValidateRegionFilters(ctx, i, awsClient, prompts); err != nil {
 		return err
 	}
 
-	if err := receiveAndValidateSNSTopicArn(i, awsClient); err != nil {
+	if err := prompts.multipleChoiceParameter("action", "post verification action", &i.Action, map[string]string{"1": "detect", "2": "block"}, true); err != nil {
 		return err
 	}
 
-	if err := receiveAndValidateCloudTrail(i, awsClient); err != nil {
+	if err := receiveNotifiers(i, prompts); err != nil {
 		return err
 	}
+	if selectedNotifier(i, notify.KindSNS) {
+		if err := receiveAndValidateSNSTopicArn(i, awsClient, prompts); err != nil {
+			return err
+		}
+	}
+	if selectedNotifier(i, notify.KindSlack) {
+		if err := receiveAndValidateSlackWebhookUrl(i, prompts); err != nil {
+			return err
+		}
+	}
+	if selectedNotifier(i, notify.KindWebhook) {
+		if err := receiveAndValidateWebhook(i, prompts); err != nil {
+			return err
+		}
+	}
 
-	if err := inputYesNoParameter("do you want to work in keyless mode (y/n): ", &i.IsKeyless, false); err != nil {
+	if err := receiveAndValidateCloudTrail(i, awsClient, prompts); err != nil {
 		return err
 	}
 
+	if i.KmsKeyRef != "" && i.IsKeyless {
+		return fmt.Errorf("--kms-key-ref can't be given in keyless mode")
+	}
+	if i.KmsKeyRef == "" {
+		if err := prompts.yesNoParameter("keyless", "do you want to work in keyless mode (y/n): ", &i.IsKeyless); err != nil {
+			return err
+		}
+	}
+
 	if !i.IsKeyless {
-		if err := inputKeyPair(i); err != nil {
+		if err := receiveKmsKeyRef(ctx, i, awsClient, prompts); err != nil {
+			return err
+		}
+		if i.KmsKeyRef == "" {
+			if err := receiveAndValidateKeyPair(i, prompts); err != nil {
+				return err
+			}
+		}
+		if err := prompts.yesNoParameter("tlog-upload", "do you want to upload the signatures to a rekor transparency log (y/n): ", &i.TlogUpload); err != nil {
+			return err
+		}
+		if i.TlogUpload {
+			if err := prompts.stringParameter("rekor-url", "enter the rekor url of a private sigstore instance (leave empty for the public one): ", &i.RekorUrl, true); err != nil {
+				return err
+			}
+		}
+	} else {
+		if err := prompts.stringParameter("fulcio-url", "enter the fulcio url of a private sigstore instance (leave empty for the public one): ", &i.FulcioUrl, true); err != nil {
+			return err
+		}
+		if err := prompts.stringParameter("rekor-url", "enter the rekor url of a private sigstore instance (leave empty for the public one): ", &i.RekorUrl, true); err != nil {
+			return err
+		}
+		if err := receiveAndValidateTufRoot(i, prompts); err != nil {
+			return err
+		}
+		if err := receiveSignerIdentity(i, prompts); err != nil {
 			return err
 		}
 	}
 
-	if err := digestParameters(i); err != nil {
+	if err := digestParameters(i, prompts); err != nil {
 		return err
 	}
 	return nil
 }
 
-func digestParameters(i *i.AWSInput) error {
-	if i.PublicKey == "" && !i.IsKeyless {
-		if err := generate.GenerateKeyPairCmd(context.Background(), "", []string{}); err != nil {
+// offerToSaveInput asks whether to save the answers of an interactive init to a file given back to init with --config.
+// The secret key is left out unless asked for.
+func offerToSaveInput(input *i.AWSInput, prompts initPrompts) error {
+	if !prompts.interactive {
+		return nil
+	}
+	save := false
+	if err := prompts.prompter.YesNo("save the answers to re-run init with --config? (y/n, default n): ", &save, true); err != nil || !save {
+		return err
+	}
+	h, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(h, i.DefaultInputFile)
+	if err = prompts.prompter.String("path of the answers file (leave empty for "+path+"): ", &path, true); err != nil {
+		return err
+	}
+	if path == "" {
+		path = filepath.Join(h, i.DefaultInputFile)
+	}
+	includeSecrets := false
+	if err = prompts.prompter.YesNo("include the secret key in the answers file? (y/n, default n): ", &includeSecrets, true); err != nil {
+		return err
+	}
+	if err = i.SaveAWSInput(input, path, includeSecrets); err != nil {
+		return err
+	}
+	fmt.Printf("init answers saved to: %s\n", path)
+	return nil
+}
+
+// secretFlags are the init flags of secrets, which are read from their -file flag or from stdin when given as "-".
+var secretFlags = []string{"secret-key", "private-key-password"}
+
+// readSecretFlags sets the secret flags given as a file or as "-" to the secret read. The private key password can't
+// be given as is, so that it doesn't end up in the shell history.
+func readSecretFlags(flags *pflag.FlagSet, prompter *common.Prompter) error {
+	for _, name := range secretFlags {
+		value, _ := flags.GetString(name)
+		file, _ := flags.GetString(name + "-file")
+		if file != "" && flags.Changed(name) {
+			return fmt.Errorf("--%s and --%s-file are mutually exclusive", name, name)
+		}
+		if file == "" && value == "-" {
+			file = value
+		}
+		if file == "" {
+			if name == "private-key-password" && flags.Changed(name) {
+				return fmt.Errorf("--%s only takes -, to read it from stdin, give it with --%s-file or COSIGN_PASSWORD otherwise", name, name)
+			}
+			continue
+		}
+		secret, err := prompter.ReadSecret(file)
+		if err != nil {
+			return fmt.Errorf("--%s: %w", name, err)
+		}
+		if err = flags.Set(name, secret); err != nil {
 			return err
 		}
-		i.PublicKey = "cosign.pub"
-		i.PrivateKey = "cosign.key"
 	}
 	return nil
 }
 
-func receiveAndValidateCloudTrail(i *i.AWSInput, awsClient *clients.AwsClient) error {
-	if err := inputStringParameter("is there existing trail in CloudTrail (in the region selected above) which you would like to use? (if no, please press enter): ", &i.CloudTrail.Name, true); err != nil {
+// mergeInitConfig returns the parameters of the init config file overridden by the flags given, along with the
+// parameters the file sets, by flag name. A parameter is set when it isn't empty, keyless mode is also set by a
+// public key.
+func mergeInitConfig(file *i.AWSInput, flagged *i.AWSInput, flags *pflag.FlagSet) (*i.AWSInput, map[string]bool) {
+	fromFile := map[string]bool{
+		"access-key":                     file.AccessKey != "",
+		"secret-key":                     file.SecretKey != "",
+		"region":                         file.Region != "",
+		"bucket":                         file.Bucket != "",
+		"kms-key":                        file.KmsKeyArn != "",
+		"signature-retention-days":       file.SignatureRetentionDays != 0,
+		"action":                         file.Action != "",
+		"block-dry-run":                  file.DryRun,
+		"notifiers":                      len(file.Notifiers) > 0,
+		"sns-topic":                      file.SnsTopicArn != "",
+		"slack-webhook-url":              file.SlackWebhookUrl != "",
+		"webhook-url":                    file.WebhookUrl != "",
+		"webhook-header":                 len(file.WebhookHeaders) > 0,
+		"cloudtrail":                     file.CloudTrail.Name != "",
+		"keyless":                        file.IsKeyless || file.PublicKey != "" || file.KmsKeyRef != "",
+		"kms-key-ref":                    file.KmsKeyRef != "",
+		"key-dir":                        file.KeyDir != "",
+		"key-prefix":                     file.KeyPrefix != "",
+		"public-key":                     file.PublicKey != "",
+		"private-key":                    file.PrivateKey != "",
+		"fulcio-url":                     file.FulcioUrl != "",
+		"rekor-url":                      file.RekorUrl != "",
+		"tlog-upload":                    file.TlogUpload,
+		"tuf-root":                       file.TufRootPath != "",
+		"tuf-mirror":                     file.TufMirrorUrl != "",
+		"certificate-identity":           file.CertIdentity != "",
+		"certificate-oidc-issuer":        file.CertOidcIssuer != "",
+		"certificate-identity-regexp":    file.CertIdentityRegexp != "",
+		"certificate-oidc-issuer-regexp": file.CertOidcIssuerRegexp != "",
+		"signer":                         len(file.IncludedSigners) > 0,
+		"include-tags":                   len(file.IncludedFuncTagKeys) > 0 || len(file.IncludedFuncTags) > 0,
+		"exclude-tags":                   len(file.ExcludedFuncTagKeys) > 0,
+		"include-regions":                len(file.IncludedFuncRegions) > 0,
+		"exclude-regions":                len(file.ExcludedFuncRegions) > 0,
+		"concurrency":                    file.Concurrency != 0,
+		"enable-metrics":                 file.EnableMetrics,
+		"metrics-push-gateway":           file.MetricsPushGateway != "",
+		"assume-role-arn":                file.AssumeRoleArn != "",
+		"external-id":                    file.ExternalId != "",
+		"endpoint-url":                   file.EndpointUrl != "",
+	}
+	merged := *file
+	flags.Visit(func(flag *pflag.Flag) {
+		switch flag.Name {
+		case "access-key":
+			merged.AccessKey = flagged.AccessKey
+		case "secret-key":
+			merged.SecretKey = flagged.SecretKey
+		case "region":
+			merged.Region = flagged.Region
+		case "bucket":
+			merged.Bucket = flagged.Bucket
+		case "kms-key":
+			merged.KmsKeyArn = flagged.KmsKeyArn
+		case "signature-retention-days":
+			merged.SignatureRetentionDays = flagged.SignatureRetentionDays
+		case "action":
+			merged.Action = flagged.Action
+		case "block-dry-run":
+			merged.DryRun = flagged.DryRun
+		case "notifiers":
+			merged.Notifiers = flagged.Notifiers
+		case "sns-topic":
+			merged.SnsTopicArn = flagged.SnsTopicArn
+		case "slack-webhook-url":
+			merged.SlackWebhookUrl = flagged.SlackWebhookUrl
+		case "webhook-url":
+			merged.WebhookUrl = flagged.WebhookUrl
+		case "webhook-header":
+			merged.WebhookHeaders = flagged.WebhookHeaders
+		case "cloudtrail":
+			merged.CloudTrail.Name = flagged.CloudTrail.Name
+		case "key-dir":
+			merged.KeyDir = flagged.KeyDir
+		case "key-prefix":
+			merged.KeyPrefix = flagged.KeyPrefix
+		case "kms-key-ref":
+			merged.KmsKeyRef = flagged.KmsKeyRef
+		case "keyless":
+			merged.IsKeyless = flagged.IsKeyless
+		case "public-key":
+			merged.PublicKey = flagged.PublicKey
+		case "private-key":
+			merged.PrivateKey = flagged.PrivateKey
+		case "private-key-password":
+			merged.PrivateKeyPassword = flagged.PrivateKeyPassword
+		case "fulcio-url":
+			merged.FulcioUrl = flagged.FulcioUrl
+		case "rekor-url":
+			merged.RekorUrl = flagged.RekorUrl
+		case "tlog-upload":
+			merged.TlogUpload = flagged.TlogUpload
+		case "tuf-root":
+			merged.TufRootPath = flagged.TufRootPath
+		case "tuf-mirror":
+			merged.TufMirrorUrl = flagged.TufMirrorUrl
+		case "certificate-identity":
+			merged.CertIdentity = flagged.CertIdentity
+		case "certificate-oidc-issuer":
+			merged.CertOidcIssuer = flagged.CertOidcIssuer
+		case "certificate-identity-regexp":
+			merged.CertIdentityRegexp = flagged.CertIdentityRegexp
+		case "certificate-oidc-issuer-regexp":
+			merged.CertOidcIssuerRegexp = flagged.CertOidcIssuerRegexp
+		case "signer":
+			merged.IncludedSigners = flagged.IncludedSigners
+		case "include-tags":
+			merged.IncludedFuncTagKeys = flagged.IncludedFuncTagKeys
+			merged.IncludedFuncTags = nil
+		case "exclude-tags":
+			merged.ExcludedFuncTagKeys = flagged.ExcludedFuncTagKeys
+		case "include-regions":
+			merged.IncludedFuncRegions = flagged.IncludedFuncRegions
+		case "exclude-regions":
+			merged.ExcludedFuncRegions = flagged.ExcludedFuncRegions
+		case "concurrency":
+			merged.Concurrency = flagged.Concurrency
+		case "enable-metrics":
+			merged.EnableMetrics = flagged.EnableMetrics
+		case "metrics-push-gateway":
+			merged.MetricsPushGateway = flagged.MetricsPushGateway
+		case "assume-role-arn":
+			merged.AssumeRoleArn = flagged.AssumeRoleArn
+		case "external-id":
+			merged.ExternalId = flagged.ExternalId
+		case "endpoint-url":
+			merged.EndpointUrl = flagged.EndpointUrl
+		case "assume-role-duration":
+			merged.AssumeRoleDuration = flagged.AssumeRoleDuration
+		}
+	})
+	return &merged, fromFile
+}
+
+// digestParameters generates the key pair when none is given and the mode isn't keyless, the existing key files are
+// only overwritten with --force.
+func digestParameters(input *i.AWSInput, prompts initPrompts) error {
+	if input.PublicKey != "" || input.IsKeyless {
+		return nil
+	}
+	force := false
+	if prompts.flags != nil {
+		force, _ = prompts.flags.GetBool("force")
+	}
+	publicKey, privateKey, err := i.GenerateKeyPair(input.KeyDir, input.KeyPrefix, force, generate.GetPass)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%w, use --force to overwrite it or another --key-dir or --key-prefix", err)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Key pair written to %s and %s\n", publicKey, privateKey)
+	input.PublicKey, input.PrivateKey = publicKey, privateKey
+	return nil
+}
+
+// receiveAndValidateTufRoot reads the TUF repository of a private sigstore instance, the root is checked to be signed
+// root metadata since the verifier only loads it once deployed.
+func receiveAndValidateTufRoot(i *i.AWSInput, prompts initPrompts) error {
+	if err := prompts.stringParameter("tuf-root", "enter the path of the root.json of a private sigstore TUF repository (leave empty for the public one): ", &i.TufRootPath, true); err != nil {
+		return err
+	}
+	if i.TufRootPath != "" {
+		if _, err := options.LoadTufRoot(i.TufRootPath); err != nil {
+			return err
+		}
+	}
+	return prompts.stringParameter("tuf-mirror", "enter the url of the private sigstore TUF repository mirror (leave empty for the public one): ", &i.TufMirrorUrl, true)
+}
+
+// receiveAndValidateRegionFilters reads the included and excluded function regions, region names or globs, and warns
+// about the filters matching none of the regions enabled for the account and about the regions both included and
+// excluded. The filters are kept either way, e.g. for a region enabled later on.
+func receiveAndValidateRegionFilters(ctx context.Context, i *i.AWSInput, awsClient *clients.AwsClient, prompts initPrompts) error {
+	if err := prompts.stringArrayParameter("include-regions", "enter the function regions to include in the verification, i.e: us-east-1,eu-west-?,ap-* (leave empty to include all): ", &i.IncludedFuncRegions, true); err != nil {
+		return err
+	}
+	if err := prompts.stringArrayParameter("exclude-regions", "enter the function regions to skip in the verification, i.e: ap-* (leave empty to skip none): ", &i.ExcludedFuncRegions, true); err != nil {
+		return err
+	}
+	if len(i.IncludedFuncRegions) == 0 && len(i.ExcludedFuncRegions) == 0 {
+		return nil
+	}
+	all, err := awsClient.EnabledRegions(ctx, i.Region)
+	if err != nil {
+		fmt.Printf("warning: the region filters aren't checked, failed to list the regions: %v\n", err)
+		return nil
+	}
+	for _, pattern := range append(append([]string{}, i.IncludedFuncRegions...), i.ExcludedFuncRegions...) {
+		if pattern != "" && len(matchRegions([]string{pattern}, all)) == 0 {
+			fmt.Printf("warning: region filter: %s matches none of the enabled regions\n", pattern)
+		}
+	}
+	excluded := map[string]bool{}
+	for _, region := range matchRegions(i.ExcludedFuncRegions, all) {
+		excluded[region] = true
+	}
+	for _, region := range matchRegions(i.IncludedFuncRegions, all) {
+		if excluded[region] {
+			fmt.Printf("warning: region: %s is both included and excluded, its functions won't be verified\n", region)
+		}
+	}
+	return nil
+}
+
+// matchRegions returns the regions of all matching one of the patterns, region names or globs like us-* and eu-west-?.
+func matchRegions(patterns []string, all []string) []string {
+	var matched []string
+	for _, region := range all {
+		for _, pattern := range patterns {
+			if ok, err := path.Match(pattern, region); err == nil && ok {
+				matched = append(matched, region)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// trailValidator checks the trail given to init exists, or creates one, implemented by clients.AwsClient.
+type trailValidator interface {
+	IsCloudTrailExist(trailName string) (bool, error)
+	CreateCloudTrail(name string, bucket string) error
+}
+
+// receiveAndValidateCloudTrail reads the existing trail to use. When none is given, creating a trail logging to the
+// FunctionClarity bucket is offered, otherwise the deployment creates its own trail.
+func receiveAndValidateCloudTrail(i *i.AWSInput, awsClient trailValidator, prompts initPrompts) error {
+	if err := prompts.stringParameter("cloudtrail", "is there existing trail in CloudTrail (in the region selected above) which you would like to use? (if no, please press enter): ", &i.CloudTrail.Name, true); err != nil {
 		return err
 	}
 	trailName := i.CloudTrail.Name
-	if trailName != "" && !awsClient.IsCloudTrailExist(trailName) {
-		return fmt.Errorf("validation error: SNS topic doesn't exist or you don't have permissions")
+	if trailName != "" {
+		exists, err := awsClient.IsCloudTrailExist(trailName)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("validation error: CloudTrail %q doesn't exist or you don't have permissions", trailName)
+		}
+		return nil
+	}
+	bucket := i.Bucket
+	if bucket == "" {
+		bucket = clients.FunctionClarityBucketName
+	}
+	create, err := prompts.confirm("create the multi-region trail " + clients.FunctionClarityTrailName + " logging to bucket " + bucket + " now? (y/n, default n, the deployment creates its own trail): ")
+	if err != nil || !create {
+		return err
+	}
+	if err := awsClient.CreateCloudTrail(clients.FunctionClarityTrailName, bucket); err != nil {
+		return err
+	}
+	i.CloudTrail.Name = clients.FunctionClarityTrailName
+	fmt.Printf("trail created: %s\n", clients.FunctionClarityTrailName)
+	return nil
+}
+
+// notifierFlags are the init flags of the notifiers, a notifier whose flag is given is selected without choosing it.
+var notifierFlags = map[string]string{notify.KindSNS: "sns-topic", notify.KindSlack: "slack-webhook-url", notify.KindWebhook: "webhook-url"}
+
+// receiveNotifiers chooses the notifiers of failed verifications, none by default.
+func receiveNotifiers(i *i.AWSInput, prompts initPrompts) error {
+	if err := prompts.stringArrayParameter("notifiers", "choose the notifiers of failed signature verifications, comma separated: "+
+		strings.Join(notify.Kinds, ", ")+" (leave empty for none): ", &i.Notifiers, true); err != nil {
+		return err
+	}
+	if err := notify.ValidateKinds(i.Notifiers); err != nil {
+		return fmt.Errorf("validation error: %w", err)
+	}
+	for _, kind := range notify.Kinds {
+		if prompts.given(notifierFlags[kind]) && !selectedNotifier(i, kind) {
+			i.Notifiers = append(i.Notifiers, kind)
+		}
+	}
+	return nil
+}
+
+func selectedNotifier(i *i.AWSInput, kind string) bool {
+	for _, selected := range i.Notifiers {
+		if selected == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func receiveAndValidateSlackWebhookUrl(i *i.AWSInput, prompts initPrompts) error {
+	if err := prompts.stringParameter("slack-webhook-url", "enter the slack incoming webhook url notified when signature verification fails: ", &i.SlackWebhookUrl, false); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(i.SlackWebhookUrl, "https://") {
+		return fmt.Errorf("validation error: %s is not a valid slack webhook url, expected https://hooks.slack.com/services/...", i.SlackWebhookUrl)
+	}
+	return nil
+}
+
+func receiveAndValidateWebhook(i *i.AWSInput, prompts initPrompts) error {
+	if err := prompts.stringParameter("webhook-url", "enter the url the notification is posted to as JSON when signature verification fails: ", &i.WebhookUrl, false); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(i.WebhookUrl, "https://") && !strings.HasPrefix(i.WebhookUrl, "http://") {
+		return fmt.Errorf("validation error: %s is not a valid webhook url, expected an http(s) url", i.WebhookUrl)
+	}
+	var headers []string
+	if err := prompts.stringArrayParameter("webhook-header", "enter key:value headers of the webhook posts, e.g. Authorization:Bearer <token>, comma separated (leave empty for none): ", &headers, true); err != nil {
+		return err
+	}
+	parsed, err := notify.ParseHeaders(headers)
+	if err != nil {
+		return fmt.Errorf("validation error: %w", err)
+	}
+	if len(parsed) > 0 {
+		i.WebhookHeaders = parsed
+	}
+	return nil
+}
+
+func receiveAndValidateSNSTopicArn(i *i.AWSInput, awsClient *clients.AwsClient, prompts initPrompts) error {
+	if err := prompts.stringParameter("sns-topic", "enter the SNS topic arn notified when signature verification fails: ", &i.SnsTopicArn, false); err != nil {
+		return err
+	}
+	if !isValidSNSArn(i.SnsTopicArn) {
+		return fmt.Errorf("validation error: %s is not a valid SNS topic arn, expected arn:%s:sns:<region>:<account>:<topic>", i.SnsTopicArn, utils.Partition(i.Region))
+	}
+	if topicArn, _ := arn.Parse(i.SnsTopicArn); topicArn.Region != i.Region || topicArn.Partition != utils.Partition(i.Region) {
+		return fmt.Errorf("validation error: SNS topic %s is in region %s, expected the region selected above: %s", i.SnsTopicArn, topicArn.Region, i.Region)
+	}
+	exists, err := awsClient.IsSnsTopicExist(i.SnsTopicArn)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		create, err := prompts.confirm("SNS topic " + i.SnsTopicArn + " doesn't exist or you don't have permissions, create it? (y/n, default n): ")
+		if err != nil {
+			return err
+		}
+		if !create {
+			return fmt.Errorf("validation error: SNS topic doesn't exist or you don't have permissions")
+		}
+		return createSNSTopic(i, awsClient, prompts)
+	}
+	return nil
+}
+
+// createSNSTopic creates the topic named by the given arn in place of it, and subscribes an email address to it.
+func createSNSTopic(i *i.AWSInput, awsClient *clients.AwsClient, prompts initPrompts) error {
+	topicArn, _ := arn.Parse(i.SnsTopicArn)
+	created, err := awsClient.CreateSnsTopic(topicArn.Resource)
+	if err != nil {
+		return err
+	}
+	if created != i.SnsTopicArn {
+		fmt.Printf("SNS topic created as: %s\n", created)
 	}
+	i.SnsTopicArn = created
+	var email string
+	if !prompts.interactive {
+		return nil
+	}
+	if err = prompts.prompter.String("enter an email address to notify through the topic (leave empty for none): ", &email, true); err != nil || email == "" {
+		return err
+	}
+	if err = awsClient.SubscribeEmail(created, email); err != nil {
+		return err
+	}
+	fmt.Printf("confirm the subscription from the email sent to: %s\n", email)
 	return nil
 }
 
-func receiveAndValidateSNSTopicArn(i *i.AWSInput, awsClient *clients.AwsClient) error {
-	if err := inputStringParameter("enter SNS arn if you would like to be notified when signature verification fails, otherwise press enter: ", &i.SnsTopicArn, true); err != nil {
+// arnPartitions are the partitions of the sns topics and kms keys given to init.
+var arnPartitions = map[string]bool{"aws": true, "aws-cn": true, "aws-us-gov": true}
+
+var (
+	accountIdFormat = regexp.MustCompile(`^[0-9]{12}$`)
+	topicNameFormat = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}(\.fifo)?$`)
+)
+
+// isValidSNSArn checks the arn has the arn:<partition>:sns:<region>:<account>:<topic> shape of an SNS topic.
+func isValidSNSArn(topicArn string) bool {
+	parsed, err := arn.Parse(topicArn)
+	if err != nil {
+		return false
+	}
+	return arnPartitions[parsed.Partition] && parsed.Service == "sns" && regionFormat.MatchString(parsed.Region) &&
+		accountIdFormat.MatchString(parsed.AccountID) && topicNameFormat.MatchString(parsed.Resource)
+}
+
+// bucketValidator checks the bucket given to init exists and where, implemented by clients.AwsClient.
+type bucketValidator interface {
+	IsBucketExist(bucketName string) (bool, error)
+	GetBucketRegion(bucket string) (string, error)
+}
+
+// receiveAndValidateBucketName reads the existing bucket to use and checks it is in the region selected, the verifier
+// reading the signatures from another region adds latency and transfer costs. A bucket in another region is prompted
+// for again, entering it again keeps it, and is an error when given as a flag unless --allow-cross-region-bucket is.
+func receiveAndValidateBucketName(i *i.AWSInput, awsClient bucketValidator, prompts initPrompts) error {
+	allowCrossRegion := false
+	if prompts.flags != nil {
+		allowCrossRegion, _ = prompts.flags.GetBool("allow-cross-region-bucket")
+	}
+	rejected := ""
+	for {
+		if err := prompts.stringParameter("bucket", "enter default bucket (you can leave empty and a bucket with name functionclarity will be created): ", &i.Bucket, true); err != nil {
+			return err
+		}
+		if i.Bucket == "" {
+			return nil
+		}
+		exists, err := awsClient.IsBucketExist(i.Bucket)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("validation error: bucket doesn't exist or you don't have permissions")
+		}
+		if allowCrossRegion || i.Bucket == rejected {
+			return nil
+		}
+		region, err := awsClient.GetBucketRegion(i.Bucket)
+		if err != nil {
+			fmt.Printf("warning: the bucket region isn't checked: %v\n", err)
+			return nil
+		}
+		if region == i.Region {
+			return nil
+		}
+		if prompts.given("bucket") {
+			return fmt.Errorf("validation error: bucket %s is in region %s, expected the region selected above: %s, use --allow-cross-region-bucket to use it anyway", i.Bucket, region, i.Region)
+		}
+		fmt.Printf("warning: bucket %s is in region %s, not in %s, reading the signatures from another region adds latency and transfer costs. enter it again to use it anyway\n", i.Bucket, region, i.Region)
+		rejected = i.Bucket
+	}
+}
+
+// receiveSignatureRetention reads the number of days after which the signatures in the bucket expire, e.g. the ones
+// of functions deleted since, the signatures are kept when empty. A function whose signature expired fails
+// verification until signed again.
+func receiveSignatureRetention(i *i.AWSInput, prompts initPrompts) error {
+	var days string
+	if err := prompts.stringParameter("signature-retention-days", "enter the number of days after which signatures expire (leave empty to keep them): ", &days, true); err != nil {
 		return err
 	}
-	if i.SnsTopicArn != "" && !awsClient.IsSnsTopicExist(i.SnsTopicArn) {
-		return fmt.Errorf("validation error: SNS topic doesn't exist or you don't have permissions")
+	if days != "" {
+		parsed, err := strconv.Atoi(strings.TrimSpace(days))
+		if err != nil {
+			return fmt.Errorf("invalid signature retention days: %s", days)
+		}
+		i.SignatureRetentionDays = parsed
+	}
+	if i.SignatureRetentionDays < 0 {
+		return fmt.Errorf("invalid signature retention days: %d, expected a positive number of days", i.SignatureRetentionDays)
 	}
 	return nil
 }
 
-func receiveAndValidateBucketName(i *i.AWSInput, awsClient *clients.AwsClient) error {
-	if err := inputStringParameter("enter default bucket (you can leave empty and a bucket with name functionclarity will be created): ", &i.Bucket, true); err != nil {
+// receiveAndValidateKmsKey reads the kms key encrypting the signatures, which has to be in the region selected like the
+// bucket, and checks it is an enabled symmetric encryption key.
+func receiveAndValidateKmsKey(i *i.AWSInput, awsClient *clients.AwsClient, prompts initPrompts) error {
+	if err := prompts.stringParameter("kms-key", "enter arn of a KMS key to encrypt the signatures with (leave empty for the default encryption of the bucket): ", &i.KmsKeyArn, true); err != nil {
 		return err
 	}
-	if i.Bucket != "" && !awsClient.IsBucketExist(i.Bucket) {
-		return fmt.Errorf("validation error: bucket doesn't exist or you don't have permissions")
+	if i.KmsKeyArn == "" {
+		return nil
+	}
+	if !isValidKmsKeyArn(i.KmsKeyArn) {
+		return fmt.Errorf("validation error: %s is not a valid KMS key arn, expected arn:%s:kms:<region>:<account>:key/<id> or alias/<name>", i.KmsKeyArn, utils.Partition(i.Region))
+	}
+	if keyArn, _ := arn.Parse(i.KmsKeyArn); keyArn.Region != i.Region || keyArn.Partition != utils.Partition(i.Region) {
+		return fmt.Errorf("validation error: KMS key %s is in region %s, expected the region selected above: %s", i.KmsKeyArn, keyArn.Region, i.Region)
+	}
+	if err := awsClient.ValidateKmsKey(i.KmsKeyArn); err != nil {
+		return fmt.Errorf("validation error: %w", err)
 	}
 	return nil
 }
 
-func receiveAndValidateCredentials(i *i.AWSInput) (*clients.AwsClient, error) {
-	if err := inputStringParameter("enter Access Key: ", &i.AccessKey, false); err != nil {
+var kmsKeyFormat = regexp.MustCompile(`^(key/[A-Za-z0-9-]+|alias/[A-Za-z0-9/_-]+)$`)
+
+// isValidKmsKeyArn checks the arn has the arn:<partition>:kms:<region>:<account>:key/<id> shape of a KMS key, or the
+// one of an alias.
+func isValidKmsKeyArn(keyArn string) bool {
+	parsed, err := arn.Parse(keyArn)
+	if err != nil {
+		return false
+	}
+	return arnPartitions[parsed.Partition] && parsed.Service == "kms" && regionFormat.MatchString(parsed.Region) &&
+		accountIdFormat.MatchString(parsed.AccountID) && kmsKeyFormat.MatchString(parsed.Resource)
+}
+
+func receiveAndValidateCredentials(ctx context.Context, i *i.AWSInput, prompts initPrompts) (*clients.AwsClient, error) {
+	if err := prompts.stringParameter("access-key", "enter Access Key (leave empty to use the default aws credential chain): ", &i.AccessKey, true); err != nil {
 		return nil, err
 	}
-	if err := inputStringParameter("enter Secret Key: ", &i.SecretKey, false); err != nil {
+	if i.AccessKey != "" {
+		if err := prompts.secretParameter("secret-key", "enter Secret Key: ", &i.SecretKey); err != nil {
+			return nil, err
+		}
+	} else if i.SecretKey != "" {
+		return nil, fmt.Errorf("a secret key is given without an access key")
+	}
+	if err := receiveAndValidateRegion(ctx, i, prompts); err != nil {
 		return nil, err
 	}
-	if err := inputStringParameter("enter region: ", &i.Region, false); err != nil {
+	if err := prompts.stringParameter("assume-role-arn", "enter arn of an IAM role to assume (leave empty to use the credentials as is): ", &i.AssumeRoleArn, true); err != nil {
 		return nil, err
 	}
-	awsClient := clients.NewAwsClientInit(i.AccessKey, i.SecretKey, i.Region)
-	if credentials := awsClient.ValidateCredentials(); !credentials {
+	if i.AssumeRoleArn != "" {
+		if err := prompts.stringParameter("external-id", "enter external id of the role (leave empty for none): ", &i.ExternalId, true); err != nil {
+			return nil, err
+		}
+	}
+	awsClient := initClient(ctx, i)
+	if err := awsClient.ValidateCredentials(ctx); err != nil {
+		if i.AssumeRoleArn != "" {
+			return nil, fmt.Errorf("validation error: failed to assume role: %s", i.AssumeRoleArn)
+		}
+		if i.AccessKey == "" {
+			return nil, fmt.Errorf("validation error: no valid credentials found in the default aws credential chain")
+		}
 		return nil, fmt.Errorf("validation error: credentials aren't valid")
 	}
 	return awsClient, nil
 }
 
-func inputKeyPair(i *i.AWSInput) error {
-	if err := inputStringParameter("enter path to custom public key for code signing? (if you want us to generate key pair, please press enter): ", &i.PublicKey, true); err != nil {
-		return err
-	}
-	if i.PublicKey != "" {
-		if err := inputStringParameter("enter path to custom private key for code signing: ", &i.PrivateKey, false); err != nil {
+// maxRegionAttempts is the number of times an invalid region is prompted for before init fails.
+const maxRegionAttempts = 3
+
+// regionFormat matches the region names of every partition, i.e: us-east-1, us-gov-west-1, cn-north-1.
+var regionFormat = regexp.MustCompile(`^[a-z]{2}(-gov|-iso[a-z]?)?-[a-z]+-[0-9]$`)
+
+func receiveAndValidateRegion(ctx context.Context, i *i.AWSInput, prompts initPrompts) error {
+	for attempt := 1; ; attempt++ {
+		if err := prompts.stringParameter("region", "enter region: ", &i.Region, false); err != nil {
 			return err
 		}
+		err := validateRegion(ctx, i.Region, initClient(ctx, i))
+		if err == nil {
+			return nil
+		}
+		if prompts.given("region") || attempt == maxRegionAttempts {
+			return err
+		}
+		fmt.Println(err)
+		i.Region = ""
 	}
-	return nil
 }
 
-func inputStringParameter(q string, p *string, em bool) error {
-	fmt.Print(q)
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
-	input = strings.TrimSuffix(input, "\n")
-	if !em && input == "" {
-		return fmt.Errorf("this is a compulsory parameter")
+// validateRegion checks the region is enabled for the account. When the regions can't be listed, e.g. the credentials
+// aren't allowed to describe them, only the format of the region is checked and the credential validation reports
+// the rest.
+func validateRegion(ctx context.Context, region string, awsClient *clients.AwsClient) error {
+	if !regionFormat.MatchString(region) {
+		return fmt.Errorf("invalid region: %s", region)
 	}
-	*p = strings.TrimSuffix(input, "\n")
-	return err
+	regions, err := awsClient.EnabledRegions(ctx, partitionRegion(region))
+	if err != nil {
+		return nil
+	}
+	for _, enabled := range regions {
+		if enabled == region {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid region: %s, enabled regions: %s", region, strings.Join(regions, ", "))
 }
 
-func inputStringArrayParameter(q string, p *[]string, em bool) error {
-	fmt.Print(q)
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
-	input = strings.TrimSuffix(input, "\n")
-	input = strings.TrimSpace(input)
-	if !em && input == "" {
-		return fmt.Errorf("this is a compulsory parameter")
-	}
-	*p = strings.Split(input, ",")
-	for index := range *p {
-		(*p)[index] = strings.TrimSpace((*p)[index])
+// partitionRegion returns a region of the partition of the given region, always available to list the others.
+func partitionRegion(region string) string {
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		return "cn-north-1"
+	case strings.HasPrefix(region, "us-gov-"):
+		return "us-gov-west-1"
+	default:
+		return "us-east-1"
 	}
-	return err
 }
 
-func inputYesNoParameter(q string, p *bool, em bool) error {
-	fmt.Print(q)
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
-	input = strings.TrimSuffix(input, "\n")
-	if !em && input == "" {
-		return fmt.Errorf("this is a compulsory parameter")
+// initClient returns a client with the given access key, or resolving its credentials through the default aws
+// credential chain when none is given, calling the given endpoint url if any. The role to assume, if any, is assumed
+// with these credentials. Its calls are made under the context, each bounded by --timeout.
+func initClient(ctx context.Context, i *i.AWSInput) *clients.AwsClient {
+	awsClient := clients.NewAwsClientInit(i.AccessKey, i.SecretKey, i.Region)
+	if i.AccessKey == "" {
+		awsClient = clients.NewAwsClientFromDefaultChain(i.Region)
+	}
+	if i.EndpointUrl != "" {
+		awsClient = clients.NewAwsClientInitWithEndpoint(i.AccessKey, i.SecretKey, i.Region, i.EndpointUrl)
 	}
-	input = strings.ToLower(strings.TrimSpace(input))
-	if input == "y" {
-		*p = true
-	} else if input == "n" {
-		*p = false
+	awsClient = awsClient.WithMaxRetries(viper.GetInt("maxretries")).WithTimeout(viper.GetDuration("timeout")).WithContext(ctx)
+	if i.AssumeRoleArn == "" {
+		return awsClient
 	}
-	return err
+	return clients.NewAwsClientWithRole(awsClient, clients.AssumeRole{
+		RoleArn:    i.AssumeRoleArn,
+		ExternalId: i.ExternalId,
+		Duration:   i.AssumeRoleDuration,
+	})
 }
 
-func inputMultipleChoiceParameter(action string, p *string, m map[string]string, em bool) error {
-	message := "select " + action + " : "
-	for key, element := range m {
-		message = message + "(" + key + ")" + " for " + element + "; "
+// kmsPublicKeyReader reads the public key of a kms signing key, implemented by clients.AwsClient.
+type kmsPublicKeyReader interface {
+	KmsPublicKeyPEM(ctx context.Context, keyRef string) ([]byte, error)
+}
+
+// kmsPublicKeyFile is the file the public key of the kms signing key is written to, it is deployed with the verifier
+// like a generated public key.
+const kmsPublicKeyFile = "kms.pub"
+
+// receiveKmsKeyRef reads the aws kms key signing the code instead of a local key pair, it isn't prompted for when a
+// key pair is given. The key public key is written to kms.pub for the verification, and the key reference replaces
+// the private key so the sign commands sign with kms.
+func receiveKmsKeyRef(ctx context.Context, i *i.AWSInput, awsClient kmsPublicKeyReader, prompts initPrompts) error {
+	if i.KmsKeyRef == "" && (prompts.given("public-key") || prompts.given("private-key")) {
+		return nil
 	}
-	if em {
-		message = message + "leave empty for no " + action + " to perform: "
+	if err := prompts.stringParameter("kms-key-ref", "enter the aws kms key to sign with, i.e: awskms:///<key arn> (leave empty to use a key pair): ", &i.KmsKeyRef, true); err != nil {
+		return err
+	}
+	if i.KmsKeyRef == "" {
+		return nil
+	}
+	if i.PrivateKey != "" && i.PrivateKey != i.KmsKeyRef {
+		return fmt.Errorf("--kms-key-ref can't be given with --private-key, the key is in kms")
 	}
-	fmt.Print(message)
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
+	if err := awskms.ValidReference(i.KmsKeyRef); err != nil {
+		return fmt.Errorf("validation error: %s is not a valid kms key reference, expected awskms:///<key arn>: %w", i.KmsKeyRef, err)
+	}
+	publicKey, err := awsClient.KmsPublicKeyPEM(ctx, i.KmsKeyRef)
 	if err != nil {
+		return fmt.Errorf("validation error: failed to read the public key of kms key: %s: %w", i.KmsKeyRef, err)
+	}
+	if err = os.WriteFile(kmsPublicKeyFile, publicKey, 0644); err != nil {
+		return fmt.Errorf("failed to write the public key of kms key: %s: %w", i.KmsKeyRef, err)
+	}
+	i.PublicKey = kmsPublicKeyFile
+	i.PrivateKey = i.KmsKeyRef
+	return nil
+}
+
+// receiveAndValidateKeyPair reads the key pair and the password of its private key, and checks the public key is the
+// one of the private key. A mismatching pair is prompted for again, unless it is given as flags. The password is then
+// exported in COSIGN_PASSWORD for cosign to sign with the key.
+func receiveAndValidateKeyPair(input *i.AWSInput, prompts initPrompts) error {
+	for {
+		if err := inputKeyPair(input, prompts); err != nil {
+			return err
+		}
+		if err := receivePrivateKeyPassword(input, prompts); err != nil {
+			return err
+		}
+		if input.PublicKey == "" {
+			return receiveKeyOutput(input, prompts)
+		}
+		err := i.ValidateKeyPair(input.PublicKey, input.PrivateKey, input.PrivateKeyPassword)
+		if err == nil {
+			break
+		}
+		if !prompts.interactive || prompts.given("public-key") || prompts.given("private-key") {
+			return err
+		}
+		fmt.Printf("%v, please enter the key pair again\n", err)
+		input.PublicKey, input.PrivateKey = "", ""
+		if !prompts.given("private-key-password") {
+			input.PrivateKeyPassword = ""
+		}
+	}
+	if input.PrivateKeyPassword == "" {
+		return nil
+	}
+	return os.Setenv("COSIGN_PASSWORD", input.PrivateKeyPassword)
+}
+
+// receiveSignerIdentity reads the identity and the oidc issuer expected to sign the functions in keyless mode, a
+// signature by another identity fails verification. Any identity is accepted when empty.
+func receiveSignerIdentity(i *i.AWSInput, prompts initPrompts) error {
+	if i.CertIdentityRegexp == "" {
+		if err := prompts.stringParameter("certificate-identity", "enter the identity expected to sign the functions, e.g. an email or a workflow url (leave empty to accept any): ", &i.CertIdentity, true); err != nil {
+			return err
+		}
+	}
+	if i.CertOidcIssuerRegexp == "" {
+		if err := prompts.stringParameter("certificate-oidc-issuer", "enter the oidc issuer of the identity, e.g. https://token.actions.githubusercontent.com (leave empty to accept any): ", &i.CertOidcIssuer, true); err != nil {
+			return err
+		}
+	}
+	for flag, expression := range map[string]string{"certificate-identity-regexp": i.CertIdentityRegexp, "certificate-oidc-issuer-regexp": i.CertOidcIssuerRegexp} {
+		if _, err := regexp.Compile(expression); err != nil {
+			return fmt.Errorf("validation error: invalid --%s: %w", flag, err)
+		}
+	}
+	return receiveIncludedSigners(i, prompts)
+}
+
+// receiveIncludedSigners reads the identities allowed to sign keylessly one at a time, e.g. the staging and the
+// production pipelines, any identity is allowed when there is none.
+func receiveIncludedSigners(i *i.AWSInput, prompts initPrompts) error {
+	prompt, err := prompts.prompt("signer", true)
+	if err != nil || !prompt {
+		for _, signer := range i.IncludedSigners {
+			if err == nil {
+				err = signer.Validate()
+			}
+		}
 		return err
 	}
-	input = strings.TrimSuffix(input, "\n")
-	if !em && input == "" {
-		return fmt.Errorf("this is a compulsory parameter")
+	for {
+		var value string
+		if err = prompts.prompter.String("enter an identity allowed to sign as issuer=<regexp>,subject=<regexp> (leave empty when done, or for any identity): ", &value, true); err != nil || value == "" {
+			return err
+		}
+		signer, err := options.ParseSignerIdentity(value)
+		if err != nil {
+			fmt.Printf("%v, please try again\n", err)
+			continue
+		}
+		i.IncludedSigners = append(i.IncludedSigners, signer)
+	}
+}
+
+// receiveKeyOutput reads where the key pair generated for want of a given one is written.
+func receiveKeyOutput(i *i.AWSInput, prompts initPrompts) error {
+	if err := prompts.stringParameter("key-dir", "enter the directory to generate the key pair in (leave empty for the current directory): ", &i.KeyDir, true); err != nil {
+		return err
+	}
+	return prompts.stringParameter("key-prefix", "enter the file name prefix of the generated key pair (leave empty for cosign): ", &i.KeyPrefix, true)
+}
+
+// receivePrivateKeyPassword reads the password of an encrypted private key, from --private-key-password-file or stdin
+// when given, from COSIGN_PASSWORD when set, and checks it decrypts the key.
+func receivePrivateKeyPassword(input *i.AWSInput, prompts initPrompts) error {
+	if input.PrivateKey == "" {
+		return nil
 	}
-	for key, element := range m {
-		if input == key {
-			*p = element
+	encrypted, err := i.IsPrivateKeyEncrypted(input.PrivateKey)
+	if err != nil || !encrypted {
+		return err
+	}
+	password, fromEnv := os.LookupEnv("COSIGN_PASSWORD")
+	switch {
+	case prompts.given("private-key-password"):
+	case fromEnv:
+		input.PrivateKeyPassword = password
+	case prompts.interactive:
+		if err = prompts.prompter.Secret("enter the password of the private key: ", &input.PrivateKeyPassword); err != nil {
+			return err
 		}
+	default:
+		return fmt.Errorf("private key: %s is encrypted, give its password with --private-key-password-file or COSIGN_PASSWORD, stdin isn't a terminal to prompt for it", input.PrivateKey)
 	}
-	if input == "" {
-		if !em {
-			return fmt.Errorf("this is a compulsory parameter")
-		} else {
-			*p = ""
+	return i.CheckPrivateKeyPassword(input.PrivateKey, input.PrivateKeyPassword)
+}
+
+func inputKeyPair(i *i.AWSInput, prompts initPrompts) error {
+	if err := prompts.stringParameter("public-key", "enter path to custom public key for code signing? (if you want us to generate key pair, please press enter): ", &i.PublicKey, true); err != nil {
+		return err
+	}
+	if i.PublicKey != "" {
+		if err := prompts.stringParameter("private-key", "enter path to custom private key for code signing: ", &i.PrivateKey, false); err != nil {
+			return err
 		}
 	}
 	return nil
@@ -0,0 +1,409 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"github.com/openclarity/function-clarity/cmd/function-clarity/cli/common"
+	i "github.com/openclarity/function-clarity/pkg/init"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"github.com/spf13/pflag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type fakeTrails struct {
+	exist bool
+}
+
+func (f fakeTrails) IsCloudTrailExist(string) (bool, error) {
+	return f.exist, nil
+}
+
+func (f fakeTrails) CreateCloudTrail(string, string) error {
+	return nil
+}
+
+func TestReceiveAndValidateCloudTrailMissingTrail(t *testing.T) {
+	input := i.AWSInput{CloudTrail: i.CloudTrail{Name: "audit-trail"}}
+	prompts := initPrompts{fromFile: map[string]bool{"cloudtrail": true}}
+	err := receiveAndValidateCloudTrail(&input, fakeTrails{exist: false}, prompts)
+	if err == nil {
+		t.Fatalf("expected an error for a missing trail")
+	}
+	if !strings.Contains(err.Error(), "CloudTrail") || !strings.Contains(err.Error(), "audit-trail") {
+		t.Fatalf("expected the error to name the CloudTrail and the trail, got: %v", err)
+	}
+	if err = receiveAndValidateCloudTrail(&input, fakeTrails{exist: true}, prompts); err != nil {
+		t.Fatalf("unexpected error for an existing trail: %v", err)
+	}
+}
+
+type fakeBuckets struct {
+	region string
+}
+
+func (f fakeBuckets) IsBucketExist(string) (bool, error) {
+	return true, nil
+}
+
+func (f fakeBuckets) GetBucketRegion(string) (string, error) {
+	return f.region, nil
+}
+
+func TestReceiveAndValidateBucketNameCrossRegion(t *testing.T) {
+	input := i.AWSInput{Region: "us-east-1", Bucket: "signatures"}
+	prompts := initPrompts{fromFile: map[string]bool{"bucket": true}}
+	if err := receiveAndValidateBucketName(&input, fakeBuckets{region: "us-east-1"}, prompts); err != nil {
+		t.Fatalf("unexpected error for a bucket in the region: %v", err)
+	}
+	err := receiveAndValidateBucketName(&input, fakeBuckets{region: "eu-west-1"}, prompts)
+	if err == nil || !strings.Contains(err.Error(), "eu-west-1") {
+		t.Fatalf("expected an error naming the bucket region, got: %v", err)
+	}
+	flags := pflag.NewFlagSet("init", pflag.ContinueOnError)
+	flags.Bool("allow-cross-region-bucket", false, "")
+	if err = flags.Parse([]string{"--allow-cross-region-bucket"}); err != nil {
+		t.Fatal(err)
+	}
+	prompts.flags = flags
+	if err = receiveAndValidateBucketName(&input, fakeBuckets{region: "eu-west-1"}, prompts); err != nil {
+		t.Fatalf("unexpected error with --allow-cross-region-bucket: %v", err)
+	}
+}
+
+func TestReceiveParametersValidatesPromptedAndGivenValues(t *testing.T) {
+	input := i.AWSInput{Region: "us-east-1", Bucket: "signatures", CloudTrail: i.CloudTrail{Name: "audit-trail"}}
+	given := initPrompts{fromFile: map[string]bool{"bucket": true, "cloudtrail": true}}
+	if err := receiveAndValidateBucketName(&input, fakeBuckets{region: "eu-west-1"}, given); err == nil || !strings.Contains(err.Error(), "validation error") {
+		t.Fatalf("expected the given bucket to be validated, got: %v", err)
+	}
+	if err := receiveAndValidateCloudTrail(&input, fakeTrails{exist: false}, given); err == nil || !strings.Contains(err.Error(), "validation error") {
+		t.Fatalf("expected the given trail to be validated, got: %v", err)
+	}
+
+	// the bucket in another region is only used once entered again
+	input = i.AWSInput{Region: "us-east-1"}
+	prompted := scriptedPrompts("signatures\nsignatures\naudit-trail\n")
+	if err := receiveAndValidateBucketName(&input, fakeBuckets{region: "eu-west-1"}, prompted); err != nil || input.Bucket != "signatures" {
+		t.Fatalf("expected the prompted bucket to be confirmed, got: %s, %v", input.Bucket, err)
+	}
+	if err := receiveAndValidateCloudTrail(&input, fakeTrails{exist: false}, prompted); err == nil || !strings.Contains(err.Error(), "validation error") {
+		t.Fatalf("expected the prompted trail to be validated, got: %v", err)
+	}
+}
+
+func TestInitPromptsConfirm(t *testing.T) {
+	if confirmed, err := (initPrompts{}).confirm("q: "); err != nil || confirmed {
+		t.Fatalf("expected the confirmation to be declined without prompting, got: %v, %v", confirmed, err)
+	}
+	prompter := common.NewPrompter(strings.NewReader(""), io.Discard)
+	prompter.NonInteractive, prompter.AssumeYes = true, true
+	if confirmed, err := (initPrompts{prompter: prompter}).confirm("q: "); err != nil || !confirmed {
+		t.Fatalf("expected --yes to confirm, got: %v, %v", confirmed, err)
+	}
+	if confirmed, err := scriptedPrompts("yes\n").confirm("q: "); err != nil || !confirmed {
+		t.Fatalf("expected the prompted confirmation, got: %v, %v", confirmed, err)
+	}
+}
+
+func TestInitPromptsMissingRequired(t *testing.T) {
+	tests := []struct {
+		given   map[string]bool
+		missing bool
+	}{
+		{given: map[string]bool{}, missing: true},
+		// the default aws credential chain needs no keys
+		{given: map[string]bool{"region": true}, missing: false},
+		{given: map[string]bool{"region": true, "access-key": true}, missing: true},
+		{given: map[string]bool{"region": true, "access-key": true, "secret-key": true}, missing: false},
+		{given: map[string]bool{"access-key": true, "secret-key": true}, missing: true},
+	}
+	for _, test := range tests {
+		if missing := (initPrompts{fromFile: test.given}).missingRequired(); missing != test.missing {
+			t.Errorf("expected missing required parameters to be %v when given: %v, got: %v", test.missing, test.given, missing)
+		}
+	}
+}
+
+func secretFlagSet(t *testing.T, args ...string) *pflag.FlagSet {
+	t.Helper()
+	flags := pflag.NewFlagSet("init", pflag.ContinueOnError)
+	for _, name := range secretFlags {
+		flags.String(name, "", "")
+		flags.String(name+"-file", "", "")
+	}
+	if err := flags.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return flags
+}
+
+func TestReadSecretFlags(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(file, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	stdin := common.NewPrompter(strings.NewReader("from-stdin\npassword\n"), io.Discard)
+	flags := secretFlagSet(t, "--secret-key=-", "--private-key-password=-")
+	if err := readSecretFlags(flags, stdin); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	secretKey, _ := flags.GetString("secret-key")
+	password, _ := flags.GetString("private-key-password")
+	if secretKey != "from-stdin" || password != "password" {
+		t.Fatalf("expected the secrets to be read from stdin in order, got: %q, %q", secretKey, password)
+	}
+
+	flags = secretFlagSet(t, "--secret-key-file="+file, "--private-key-password-file="+file)
+	if err := readSecretFlags(flags, stdin); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	secretKey, _ = flags.GetString("secret-key")
+	if secretKey != "from-file" || !flags.Changed("secret-key") || !flags.Changed("private-key-password") {
+		t.Fatalf("expected the secrets to be read from the file as given flags, got: %q", secretKey)
+	}
+
+	flags = secretFlagSet(t, "--secret-key=as-is")
+	if err := readSecretFlags(flags, stdin); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if secretKey, _ = flags.GetString("secret-key"); secretKey != "as-is" {
+		t.Fatalf("expected the given secret key to be kept, got: %q", secretKey)
+	}
+
+	for _, args := range [][]string{
+		{"--secret-key=as-is", "--secret-key-file=" + file},
+		{"--private-key-password=as-is"},
+	} {
+		if err := readSecretFlags(secretFlagSet(t, args...), stdin); err == nil {
+			t.Fatalf("expected %v to fail", args)
+		}
+	}
+}
+
+func TestIsValidSNSArn(t *testing.T) {
+	for _, tc := range []struct {
+		arn   string
+		valid bool
+	}{
+		{"arn:aws:sns:us-east-1:123456789012:alerts", true},
+		{"arn:aws-us-gov:sns:us-gov-west-1:123456789012:alerts.fifo", true},
+		{"", false},
+		{"alerts", false},
+		{"arn:aws:sqs:us-east-1:123456789012:alerts", false},
+		{"arn:aws:sns:us-east-11:123456789012:alerts", false},
+		{"arn:aws:sns:us-east-1:1234:alerts", false},
+		{"arn:aws:sns:us-east-1:123456789012:", false},
+		{"arn:aws:sns:us-east-1:123456789012:alerts/inner", false},
+		{"arn:azure:sns:us-east-1:123456789012:alerts", false},
+	} {
+		if valid := isValidSNSArn(tc.arn); valid != tc.valid {
+			t.Errorf("isValidSNSArn(%q) = %t, expected %t", tc.arn, valid, tc.valid)
+		}
+	}
+}
+
+func TestIsValidKmsKeyArn(t *testing.T) {
+	for _, tc := range []struct {
+		arn   string
+		valid bool
+	}{
+		{"arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab", true},
+		{"arn:aws:kms:us-east-1:123456789012:alias/signatures", true},
+		{"", false},
+		{"1234abcd-12ab-34cd-56ef-1234567890ab", false},
+		{"arn:aws:sns:us-east-1:123456789012:key/1234abcd", false},
+		{"arn:aws:kms:us-east-1:1234:key/1234abcd", false},
+		{"arn:aws:kms:us-east-1:123456789012:key/", false},
+		{"arn:aws:kms:us-east-1:123456789012:grant/1234abcd", false},
+	} {
+		if valid := isValidKmsKeyArn(tc.arn); valid != tc.valid {
+			t.Errorf("isValidKmsKeyArn(%q) = %t, expected %t", tc.arn, valid, tc.valid)
+		}
+	}
+}
+
+func TestMatchRegions(t *testing.T) {
+	all := []string{"us-east-1", "us-west-2", "eu-west-1", "eu-west-3", "eu-central-1", "ap-south-1"}
+	for _, tc := range []struct {
+		patterns []string
+		expected []string
+	}{
+		{patterns: []string{"us-*"}, expected: []string{"us-east-1", "us-west-2"}},
+		{patterns: []string{"eu-west-?"}, expected: []string{"eu-west-1", "eu-west-3"}},
+		{patterns: []string{"ap-south-1", "eu-central-1"}, expected: []string{"eu-central-1", "ap-south-1"}},
+		{patterns: []string{"us-*", "us-east-1"}, expected: []string{"us-east-1", "us-west-2"}},
+		{patterns: []string{"sa-*"}},
+		{patterns: []string{"[us"}},
+	} {
+		if matched := matchRegions(tc.patterns, all); !reflect.DeepEqual(matched, tc.expected) {
+			t.Errorf("matchRegions(%v) = %v, expected %v", tc.patterns, matched, tc.expected)
+		}
+	}
+}
+
+func TestReceiveNotifiers(t *testing.T) {
+	input := i.AWSInput{Notifiers: []string{"slack"}, SnsTopicArn: "arn:aws:sns:us-east-1:123456789012:alerts"}
+	prompts := initPrompts{fromFile: map[string]bool{"notifiers": true, "sns-topic": true}}
+	if err := receiveNotifiers(&input, prompts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(input.Notifiers, []string{"slack", "sns"}) {
+		t.Fatalf("expected slack and the sns notifier of the given topic, got: %v", input.Notifiers)
+	}
+
+	input = i.AWSInput{}
+	if err := receiveNotifiers(&input, initPrompts{}); err != nil || len(input.Notifiers) != 0 {
+		t.Fatalf("expected no notifier by default, got: %v, %v", input.Notifiers, err)
+	}
+
+	input = i.AWSInput{Notifiers: []string{"pagerduty"}}
+	if err := receiveNotifiers(&input, initPrompts{fromFile: map[string]bool{"notifiers": true}}); err == nil || !strings.Contains(err.Error(), "pagerduty") {
+		t.Fatalf("expected an error naming the unsupported notifier, got: %v", err)
+	}
+}
+
+// scriptedPrompts prompts for every parameter and reads the given lines as answers.
+func scriptedPrompts(lines string) initPrompts {
+	return initPrompts{interactive: true, prompter: common.NewPrompter(strings.NewReader(lines), io.Discard)}
+}
+
+func TestReceivePromptedParameters(t *testing.T) {
+	input := i.AWSInput{}
+	if err := receiveNotifiers(&input, scriptedPrompts(" sns, ,webhook\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(input.Notifiers, []string{"sns", "webhook"}) {
+		t.Fatalf("expected the prompted notifiers, got: %v", input.Notifiers)
+	}
+
+	input = i.AWSInput{}
+	prompts := scriptedPrompts("ftp://example.com\n")
+	if err := receiveAndValidateWebhook(&input, prompts); err == nil {
+		t.Fatalf("expected a non http url to fail the validation")
+	}
+	prompts = scriptedPrompts("https://example.com/hook\nAuthorization:Bearer token\n")
+	if err := receiveAndValidateWebhook(&input, prompts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if input.WebhookUrl != "https://example.com/hook" || input.WebhookHeaders["Authorization"] != "Bearer token" {
+		t.Fatalf("expected the prompted webhook, got: %s %v", input.WebhookUrl, input.WebhookHeaders)
+	}
+
+	input = i.AWSInput{IsKeyless: true}
+	if err := prompts.yesNoParameter("keyless", "q: ", &input.IsKeyless); err == nil {
+		t.Fatalf("expected the prompt to fail once the answers are read")
+	}
+	if err := scriptedPrompts("maybe\nno\n").yesNoParameter("keyless", "q: ", &input.IsKeyless); err != nil || input.IsKeyless {
+		t.Fatalf("expected the invalid answer to be prompted for again, got: %v, %v", input.IsKeyless, err)
+	}
+}
+
+func TestReceiveSignerIdentity(t *testing.T) {
+	input := i.AWSInput{IsKeyless: true}
+	if err := receiveSignerIdentity(&input, scriptedPrompts("signer@example.com\nhttps://accounts.google.com\n\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if input.CertIdentity != "signer@example.com" || input.CertOidcIssuer != "https://accounts.google.com" {
+		t.Fatalf("expected the prompted identity and issuer, got: %q, %q", input.CertIdentity, input.CertOidcIssuer)
+	}
+
+	// the exact identity isn't prompted for along with its regexp
+	input = i.AWSInput{IsKeyless: true, CertIdentityRegexp: "@example\\.com$"}
+	if err := receiveSignerIdentity(&input, scriptedPrompts("\n\n")); err != nil || input.CertIdentity != "" {
+		t.Fatalf("expected only the issuer to be prompted for, got: %q, %v", input.CertIdentity, err)
+	}
+
+	input = i.AWSInput{IsKeyless: true, CertOidcIssuerRegexp: "("}
+	if err := receiveSignerIdentity(&input, initPrompts{}); err == nil || !strings.Contains(err.Error(), "certificate-oidc-issuer-regexp") {
+		t.Fatalf("expected an invalid regexp error, got: %v", err)
+	}
+}
+
+func TestReceiveIncludedSigners(t *testing.T) {
+	input := i.AWSInput{IsKeyless: true}
+	prompts := scriptedPrompts("issuer=^https://token\\.actions\\.githubusercontent\\.com$\nsubject=(\nsubject=@example\\.com$\n\n")
+	if err := receiveIncludedSigners(&input, prompts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []options.SignerIdentity{
+		{IssuerRegexp: "^https://token\\.actions\\.githubusercontent\\.com$"},
+		{SubjectRegexp: "@example\\.com$"},
+	}
+	if !reflect.DeepEqual(input.IncludedSigners, expected) {
+		t.Fatalf("expected the valid prompted signers, got: %+v", input.IncludedSigners)
+	}
+
+	input = i.AWSInput{IsKeyless: true}
+	if err := receiveIncludedSigners(&input, scriptedPrompts("\n")); err != nil || input.IncludedSigners != nil {
+		t.Fatalf("expected no signer to allow any identity, got: %+v, %v", input.IncludedSigners, err)
+	}
+
+	input = i.AWSInput{IsKeyless: true, IncludedSigners: []options.SignerIdentity{{SubjectRegexp: "("}}}
+	if err := receiveIncludedSigners(&input, initPrompts{fromFile: map[string]bool{"signer": true}}); err == nil {
+		t.Fatalf("expected the given invalid signer to fail the validation")
+	}
+}
+
+type fakeKmsKeys struct {
+	publicKey []byte
+}
+
+func (f fakeKmsKeys) KmsPublicKeyPEM(context.Context, string) ([]byte, error) {
+	return f.publicKey, nil
+}
+
+func TestReceiveKmsKeyRef(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	keyRef := "awskms:///arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+	input := i.AWSInput{KmsKeyRef: keyRef}
+	prompts := initPrompts{fromFile: map[string]bool{"kms-key-ref": true}}
+	if err = receiveKmsKeyRef(context.Background(), &input, fakeKmsKeys{publicKey: []byte("public key")}, prompts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if input.PublicKey != kmsPublicKeyFile || input.PrivateKey != keyRef {
+		t.Fatalf("expected the kms public key file and the key reference as the key pair, got: %s, %s", input.PublicKey, input.PrivateKey)
+	}
+	if publicKey, err := os.ReadFile(kmsPublicKeyFile); err != nil || string(publicKey) != "public key" {
+		t.Fatalf("expected the kms public key to be written, got: %s, %v", publicKey, err)
+	}
+
+	input = i.AWSInput{KmsKeyRef: keyRef, PrivateKey: "cosign.key"}
+	if err = receiveKmsKeyRef(context.Background(), &input, fakeKmsKeys{}, prompts); err == nil || !strings.Contains(err.Error(), "--private-key") {
+		t.Fatalf("expected an error for a kms key and a private key, got: %v", err)
+	}
+	input = i.AWSInput{KmsKeyRef: "gcpkms://projects/p/locations/l/keyRings/r/cryptoKeys/k"}
+	if err = receiveKmsKeyRef(context.Background(), &input, fakeKmsKeys{}, prompts); err == nil {
+		t.Fatalf("expected an error for a reference that isn't an aws kms key")
+	}
+	input = i.AWSInput{PublicKey: "cosign.pub", PrivateKey: "cosign.key"}
+	if err = receiveKmsKeyRef(context.Background(), &input, fakeKmsKeys{}, initPrompts{fromFile: map[string]bool{"public-key": true}}); err != nil || input.PrivateKey != "cosign.key" {
+		t.Fatalf("expected a given key pair to be kept, got: %s, %v", input.PrivateKey, err)
+	}
+}
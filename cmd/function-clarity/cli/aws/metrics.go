@@ -0,0 +1,40 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"github.com/openclarity/function-clarity/pkg/metrics"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"golang.org/x/exp/slog"
+)
+
+// enableMetrics records the metrics of the verifications when --enable-metrics is given, and returns the function
+// pushing them to the --metrics-push-gateway, a no-op without one. A failed push is logged, it doesn't fail the command.
+func enableMetrics(mo *options.MetricsOptions) func(ctx context.Context) {
+	if !mo.Enabled {
+		return func(ctx context.Context) {}
+	}
+	registry := metrics.Enable()
+	return func(ctx context.Context) {
+		if mo.PushGateway == "" {
+			return
+		}
+		if err := registry.Push(ctx, mo.PushGateway, options.DefaultMetricsJob, ""); err != nil {
+			slog.Warn("failed to push the metrics", "gateway", mo.PushGateway, "error", err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/openclarity/function-clarity/cmd/function-clarity/cli/options"
+	pkgoptions "github.com/openclarity/function-clarity/pkg/options"
+	"github.com/openclarity/function-clarity/pkg/policy"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"sort"
+	"strings"
+)
+
+func AwsPrintPolicy() *cobra.Command {
+	var mode, accountId string
+	cmd := &cobra.Command{
+		Use:   "aws",
+		Short: "print the aws iam policy required for the given mode",
+		Long: "print the aws iam policy required for the given mode, scoped to the configured bucket, trail and sns topic.\n" +
+			"init: initialize and deploy function clarity, verify: verify functions, sign: sign and import code and images,\n" +
+			"prune: prune stale signatures",
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := viper.BindPFlag("region", cmd.Flags().Lookup("region")); err != nil {
+				return fmt.Errorf("error binding region: %w", err)
+			}
+			if err := viper.BindPFlag("bucket", cmd.Flags().Lookup("bucket")); err != nil {
+				return fmt.Errorf("error binding bucket: %w", err)
+			}
+			if err := viper.BindPFlag("cloudtrail.name", cmd.Flags().Lookup("cloudtrail")); err != nil {
+				return fmt.Errorf("error binding cloudtrail.name: %w", err)
+			}
+			if err := viper.BindPFlag("snsTopicArn", cmd.Flags().Lookup("sns-topic-arn")); err != nil {
+				return fmt.Errorf("error binding snsTopicArn: %w", err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var routedTopics []string
+			for _, channel := range viper.GetStringMapString("notificationrouting.routes") {
+				if strings.HasPrefix(channel, "arn:") {
+					routedTopics = append(routedTopics, channel)
+				}
+			}
+			sort.Strings(routedTopics)
+			var resultQueueArn string
+			if resultQueue := (pkgoptions.ResultQueue{URL: viper.GetString("resultqueue.url")}); resultQueue.Enabled() {
+				arn, err := resultQueue.Arn()
+				if err != nil {
+					return err
+				}
+				resultQueueArn = arn
+			}
+			doc, err := policy.AwsPolicy(mode, policy.Params{
+				Bucket:          viper.GetString("bucket"),
+				TrailName:       viper.GetString("cloudtrail.name"),
+				SnsTopicArn:     viper.GetString("snsTopicArn"),
+				RoutedTopicArns: routedTopics,
+				ResultQueueArn:  resultQueueArn,
+				KmsKeyArn:       viper.GetString("kmskeyarn"),
+				Region:          viper.GetString("region"),
+				AccountId:       accountId,
+			})
+			if err != nil {
+				return err
+			}
+			out, err := json.MarshalIndent(doc, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal policy: %w", err)
+			}
+			fmt.Println(string(out))
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&mode, "mode", "", "operation to print the policy for: init, verify, sign or prune")
+	cmd.MarkFlagRequired("mode") //nolint:errcheck
+	cmd.Flags().StringVar(&accountId, "account-id", "", "aws account id to scope the policy to (default: any account)")
+	initAwsPrintPolicyFlags(cmd)
+	return cmd
+}
+
+func initAwsPrintPolicyFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&options.Config, "config", "", "config file (default: $HOME/.fs)")
+	cmd.Flags().String("region", "", "aws region function clarity is deployed to (default: any region)")
+	cmd.Flags().String("bucket", "", "s3 bucket holding the signatures")
+	cmd.Flags().String("cloudtrail", "", "existing cloudtrail name, when empty the policy allows creating the function clarity trail")
+	cmd.Flags().String("sns-topic-arn", "", "sns topic notifications are published to")
+}
@@ -0,0 +1,167 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aws
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/daemon"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"github.com/openclarity/function-clarity/pkg/report"
+	"github.com/openclarity/function-clarity/pkg/verify"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func AwsServe() *cobra.Command {
+	o := &options.VerifyOpts{}
+	so := &options.ScanOptions{}
+	oo := &options.ScanOutputOptions{}
+	sco := &options.ScopeOptions{}
+	mo := &options.MetricsOptions{}
+	do := daemon.Options{}
+	cmd := &cobra.Command{
+		Use:   "aws",
+		Short: "verify all functions in the regions periodically",
+		Long: "verify all functions in the function regions periodically, without relying on cloudtrail events.\n" +
+			"the functions of other accounts are verified too through the --assume-roles roles.\n" +
+			"the scan runs on start and then every --interval, or on the cron --schedule when given.\n" +
+			"health is served on /healthz and metrics on /metrics, SIGTERM stops the daemon gracefully",
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return bindAwsVerifyFlags(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if oo.PartitionBy != report.PartitionByRegion && oo.PartitionBy != report.PartitionByAccount {
+				return fmt.Errorf("unsupported --partition-by: %s, expected %s or %s", oo.PartitionBy, report.PartitionByRegion, report.PartitionByAccount)
+			}
+			if _, _, err := so.ParallelismLevel(); err != nil {
+				return err
+			}
+			if err := mo.Validate(); err != nil {
+				return err
+			}
+			failOnInaccessible, err := sco.FailOnInaccessible()
+			if err != nil {
+				return err
+			}
+			o.Key = viper.GetString("publickey")
+			o.Rekor.URL = options.RekorURL(viper.GetString("rekorurl"))
+			o.TrustRoots.TufRoot = viper.GetString("tufrootpath")
+			o.TrustRoots.TufMirror = viper.GetString("tufmirrorurl")
+			o.VerifyConcurrency = viper.GetBool("verifyconcurrency")
+			o.VerifyLayers = viper.GetBool("verifylayers")
+			o.SignatureFreshness = viper.GetDuration("signaturefreshness")
+			o.ClockSkew = viper.GetDuration("clockskew")
+			o.PinSigner = viper.GetBool("pinsigner")
+			o.RequireKeyAndKeyless = viper.GetBool("requirekeyandkeyless")
+			o.UntrustedSignerAction = viper.GetString("untrustedsigneraction")
+			o.DryRun = viper.GetBool("dryrun")
+			o.RequireAwsCodeSigning = viper.GetBool("requireawscodesigning")
+			o.RequireImageDigestPin = viper.GetBool("requireimagedigestpin")
+			o.RequireSignedLayers = viper.GetBool("requiresignedlayers")
+			o.IncludedFuncTags = viper.GetStringMapString("includedfunctags")
+			o.ExcludedFuncTagKeys = viper.GetStringSlice("excludedfunctagkeys")
+			o.ExcludedFuncRegions = viper.GetStringSlice("excludedfuncregions")
+			o.CertVerify.EnforceSCT = viper.GetBool("enforcesct")
+			o.CertVerify.CertIdentity = viper.GetString("certidentity")
+			o.CertVerify.CertOidcIssuer = viper.GetString("certoidcissuer")
+			o.CertIdentityRegexp = viper.GetString("certidentityregexp")
+			o.CertOidcIssuerRegexp = viper.GetString("certoidcissuerregexp")
+			o.Offline = viper.GetBool("offline")
+			o.TlogVerify = viper.GetBool("tlogupload")
+			o.NotificationRouting.TagKey = viper.GetString("notificationrouting.tagkey")
+			o.NotificationRouting.Routes = viper.GetStringMapString("notificationrouting.routes")
+			o.SlackWebhookURL = viper.GetString("slackwebhookurl")
+			o.WebhookURL = viper.GetString("webhookurl")
+			headers, err := webhookHeaders(cmd)
+			if err != nil {
+				return err
+			}
+			o.WebhookHeaders = headers
+			if o.IncludedSigners, err = includedSigners(cmd); err != nil {
+				return err
+			}
+			o.ResultQueue.URL = viper.GetString("resultqueue.url")
+			o.ResultQueue.Attributes = viper.GetStringMapString("resultqueue.attributes")
+			var scopes []verify.Scope
+			for _, lambdaRegion := range sco.Regions {
+				awsClient := clients.NewAwsClient(viper.GetString("accesskey"), viper.GetString("secretkey"), viper.GetString("bucket"), viper.GetString("region"), lambdaRegion).WithKmsKey(viper.GetString("kmskeyarn")).WithMaxRetries(viper.GetInt("maxretries")).WithTimeout(viper.GetDuration("timeout"))
+				scopes = append(scopes, verify.Scope{Scope: report.Scope{Region: lambdaRegion}, Client: awsClient})
+				for _, role := range sco.AssumeRoles {
+					scopes = append(scopes, verify.Scope{Scope: report.Scope{Role: role, Region: lambdaRegion}, Client: awsClient.WithAssumedRole(role)})
+				}
+			}
+			// the verification metrics are served on /metrics along the daemon ones, and pushed after every scan
+			pushMetrics := enableMetrics(mo)
+			scan := func(ctx context.Context) (verify.ScanSummary, error) {
+				defer pushMetrics(ctx)
+				// every scope of the scan shares its scan id
+				scanOpts := *o
+				scanOpts.ScanID = uuid.NewString()
+				verifyScope := func(ctx context.Context, scope verify.Scope, functions []clients.FunctionConfig) verify.ScanSummary {
+					var inScope []clients.FunctionConfig
+					for _, function := range functions {
+						if function.FunctionName == clients.FunctionClarityLambdaVerierName {
+							continue
+						}
+						inScope = append(inScope, function)
+					}
+					return verify.VerifyFunctions(scope.Client, inScope, &scanOpts, so, ctx, viper.GetString("action"), viper.GetString("snsTopicArn"),
+						viper.GetStringSlice("includedfunctagkeys"), viper.GetStringSlice("includedfuncregions"))
+				}
+				summary, err := verify.ScanScopes(ctx, scopes, failOnInaccessible, verifyScope)
+				if err != nil {
+					return verify.ScanSummary{}, err
+				}
+				if oo.ResultsDir != "" {
+					if err = verify.WriteScanResults(summary, oo.ResultsDir, oo.PartitionBy); err != nil {
+						return summary, err
+					}
+				}
+				return summary, nil
+			}
+			d, err := daemon.New(do, scan)
+			if err != nil {
+				return err
+			}
+			ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGTERM, syscall.SIGINT)
+			defer stop()
+			if err = d.Run(ctx); err != nil {
+				return fmt.Errorf("serve failed: %w", err)
+			}
+			return nil
+		},
+	}
+	sco.AddFlags(cmd)
+	cmd.MarkFlagRequired("function-region") //nolint:errcheck
+	cmd.Flags().DurationVar(&do.Interval, "interval", time.Hour, "time between scans")
+	cmd.Flags().StringVar(&do.Schedule, "schedule", "", "cron expression scheduling the scans, overrides --interval")
+	cmd.Flags().StringVar(&do.ListenAddress, "listen-address", ":8080", "address serving the health and metrics endpoints")
+	o.AddFlags(cmd)
+	o.NotificationRouting.AddFlags(cmd)
+	o.ResultQueue.AddFlags(cmd)
+	so.AddFlags(cmd)
+	oo.AddFlags(cmd)
+	mo.AddFlags(cmd)
+	initAwsVerifyFlags(cmd)
+	return cmd
+}
@@ -0,0 +1,120 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azure
+
+import (
+	"fmt"
+	opt "github.com/openclarity/function-clarity/cmd/function-clarity/cli/options"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"github.com/openclarity/function-clarity/pkg/sign"
+	"github.com/openclarity/function-clarity/pkg/verify"
+	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func AzureSign() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "azure",
+		Short: "sign code and upload its signature to azure",
+	}
+	cmd.AddCommand(AzureSignCode())
+	return cmd
+}
+
+func AzureSignCode() *cobra.Command {
+	sbo := &options.SignBlobOptions{}
+	ro := &co.RootOptions{}
+	cmd := &cobra.Command{
+		Use:   "code",
+		Short: "sign code content and upload its signature to azure",
+		Args:  cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := bindAzureFlags(cmd); err != nil {
+				return err
+			}
+			if err := viper.BindPFlag("privatekey", cmd.Flags().Lookup("key")); err != nil {
+				return fmt.Errorf("error binding privatekey: %w", err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return sign.SignAndUploadCode(newAzureClient(), args[0], sbo, ro)
+		},
+	}
+	initAzureFlags(cmd)
+	cmd.Flags().String("key", "", "private key")
+	sbo.AddFlags(cmd)
+	ro.AddFlags(cmd)
+	return cmd
+}
+
+func AzureVerify() *cobra.Command {
+	o := &options.VerifyOpts{}
+	cmd := &cobra.Command{
+		Use:   "azure",
+		Short: "verify function app identity, given its resource id",
+		Args:  cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := bindAzureFlags(cmd); err != nil {
+				return err
+			}
+			if err := viper.BindPFlag("publickey", cmd.Flags().Lookup("key")); err != nil {
+				return fmt.Errorf("error binding publickey: %w", err)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Key = viper.GetString("publickey")
+			return verify.Verify(newAzureClient(), args[0], o, cmd.Context(), "", "", nil, nil)
+		},
+	}
+	o.AddCommonFlags(cmd)
+	o.AddOutputFlag(cmd)
+	initAzureFlags(cmd)
+	cmd.Flags().String("key", "", "public key")
+	return cmd
+}
+
+func newAzureClient() *clients.AzureClient {
+	return clients.NewAzureClientInit(viper.GetString("tenantid"), viper.GetString("clientid"), viper.GetString("clientsecret"),
+		viper.GetString("storageaccount"), viper.GetString("container"))
+}
+
+func initAzureFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&opt.Config, "config", "", "config file (default: $HOME/.fs)")
+	cmd.Flags().String("tenant-id", "", "azure tenant of the service principal")
+	cmd.Flags().String("client-id", "", "client id of the service principal")
+	cmd.Flags().String("client-secret", "", "client secret of the service principal")
+	cmd.Flags().String("storage-account", "", "storage account holding the signatures")
+	cmd.Flags().String("container", "", "blob container holding the signatures")
+}
+
+func bindAzureFlags(cmd *cobra.Command) error {
+	for key, flag := range map[string]string{
+		"tenantid":       "tenant-id",
+		"clientid":       "client-id",
+		"clientsecret":   "client-secret",
+		"storageaccount": "storage-account",
+		"container":      "container",
+	} {
+		if err := viper.BindPFlag(key, cmd.Flags().Lookup(flag)); err != nil {
+			return fmt.Errorf("error binding %s: %w", key, err)
+		}
+	}
+	return nil
+}
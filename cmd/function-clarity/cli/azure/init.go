@@ -0,0 +1,128 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azure
+
+import (
+	"context"
+	"fmt"
+	"github.com/openclarity/function-clarity/cmd/function-clarity/cli/common"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	i "github.com/openclarity/function-clarity/pkg/init"
+	"github.com/sigstore/cosign/cmd/cosign/cli/generate"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+	"os"
+)
+
+const defaultContainer = "functionclarity"
+
+func AzureInit() *cobra.Command {
+	var input i.AzureInput
+	cmd := &cobra.Command{
+		Use:   "azure",
+		Short: "initialize configuration for azure",
+		Long: "initialize the configuration for azure. the parameters not given as flags are prompted for.\n" +
+			"the service principal credentials are checked, the signatures container is created when it doesn't exist",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := receiveParameters(&input, cmd.Flags()); err != nil {
+				return err
+			}
+			if input.Container == "" {
+				input.Container = defaultContainer
+			}
+			azureClient := clients.NewAzureClientInit(input.TenantId, input.ClientId, input.ClientSecret, input.StorageAccount, input.Container)
+			if err := clients.EnsureStorage(cmd.Context(), azureClient, input.Container); err != nil {
+				return err
+			}
+			if input.PublicKey == "" && !input.IsKeyless {
+				if err := generate.GenerateKeyPairCmd(context.Background(), "", []string{}); err != nil {
+					return err
+				}
+				input.PublicKey = "cosign.pub"
+				input.PrivateKey = "cosign.key"
+			}
+			d, err := yaml.Marshal(&input)
+			if err != nil {
+				return fmt.Errorf("init command fail: %w", err)
+			}
+			h, err := os.UserHomeDir()
+			if err != nil {
+				return fmt.Errorf("init command fail: %w", err)
+			}
+			if err = os.WriteFile(h+"/.fc", d, 0600); err != nil {
+				return fmt.Errorf("init command fail: %w", err)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&input.TenantId, "tenant-id", "", "azure tenant of the service principal")
+	cmd.Flags().StringVar(&input.ClientId, "client-id", "", "client id of the service principal")
+	cmd.Flags().StringVar(&input.ClientSecret, "client-secret", "", "client secret of the service principal")
+	cmd.Flags().StringVar(&input.StorageAccount, "storage-account", "", "storage account holding the signatures")
+	cmd.Flags().StringVar(&input.Container, "container", "", "blob container holding the signatures, a container named functionclarity is used when empty")
+	cmd.Flags().BoolVar(&input.IsKeyless, "keyless", false, "sign and verify in keyless mode")
+	cmd.Flags().StringVar(&input.PublicKey, "public-key", "", "path to the public key for code signing, a key pair is generated when empty")
+	cmd.Flags().StringVar(&input.PrivateKey, "private-key", "", "path to the private key for code signing")
+	return cmd
+}
+
+// receiveParameters prompts for the parameters not given as flags.
+func receiveParameters(input *i.AzureInput, flags *pflag.FlagSet) error {
+	if !flags.Changed("tenant-id") {
+		if err := common.InputStringParameter("enter tenant id: ", &input.TenantId, false); err != nil {
+			return err
+		}
+	}
+	if !flags.Changed("client-id") {
+		if err := common.InputStringParameter("enter service principal client id: ", &input.ClientId, false); err != nil {
+			return err
+		}
+	}
+	if !flags.Changed("client-secret") {
+		if err := common.InputSecretParameter("enter service principal client secret: ", &input.ClientSecret); err != nil {
+			return err
+		}
+	}
+	if !flags.Changed("storage-account") {
+		if err := common.InputStringParameter("enter storage account: ", &input.StorageAccount, false); err != nil {
+			return err
+		}
+	}
+	if !flags.Changed("container") {
+		if err := common.InputStringParameter("enter default container (you can leave empty and a container with name "+defaultContainer+" will be used): ", &input.Container, true); err != nil {
+			return err
+		}
+	}
+	if !flags.Changed("keyless") {
+		if err := common.InputYesNoParameter("do you want to work in keyless mode (y/n): ", &input.IsKeyless, false); err != nil {
+			return err
+		}
+	}
+	if input.IsKeyless || flags.Changed("public-key") {
+		return nil
+	}
+	if err := common.InputStringParameter("enter path to custom public key for code signing? (if you want us to generate key pair, please press enter): ", &input.PublicKey, true); err != nil {
+		return err
+	}
+	if input.PublicKey != "" && !flags.Changed("private-key") {
+		if err := common.InputStringParameter("enter path to custom private key for code signing: ", &input.PrivateKey, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -16,25 +16,66 @@
 package cli
 
 import (
+	"fmt"
+	"github.com/openclarity/function-clarity/cmd/function-clarity/cli/aws"
+	"github.com/openclarity/function-clarity/cmd/function-clarity/cli/common"
 	"github.com/openclarity/function-clarity/cmd/function-clarity/cli/options"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/logging"
+	"github.com/openclarity/function-clarity/pkg/version"
 	"github.com/sigstore/cosign/cmd/cosign/cli"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 func New() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "function-clarity",
-		Short: "cli for signing and verifying function content",
-		Long:  `cli for signing and verifying function content`,
+		Use:     "function-clarity",
+		Short:   "cli for signing and verifying function content",
+		Long:    `cli for signing and verifying function content`,
+		Version: version.Get().String(),
+		// the subcommands define no persistent pre run of their own, so this one runs before each of them
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := viper.BindPFlag("maxretries", cmd.Root().PersistentFlags().Lookup("max-retries")); err != nil {
+				return fmt.Errorf("error binding maxretries: %w", err)
+			}
+			if err := viper.BindPFlag("timeout", cmd.Root().PersistentFlags().Lookup("timeout")); err != nil {
+				return fmt.Errorf("error binding timeout: %w", err)
+			}
+			return nil
+		},
 	}
+	// --version prints the same build metadata as the version command
+	cmd.SetVersionTemplate("{{.Version}}")
+	cmd.CompletionOptions.DisableDefaultCmd = true
+	cmd.PersistentFlags().Int("max-retries", clients.DefaultMaxRetries, "number of times a throttled or failed aws call is retried, with exponential backoff")
+	cmd.PersistentFlags().Duration("timeout", clients.DefaultTimeout, "time after which a check of an aws resource or a download of a signature or package is abandoned, 0 for no limit")
+	cmd.PersistentFlags().BoolVar(&common.StdPrompter.NonInteractive, "non-interactive", false, "fail instead of prompting for a value given neither as a flag nor in the config")
+	cmd.PersistentFlags().StringVar(&options.LogLevel, "log-level", logging.DefaultLevel, "minimum level of the logs written to stderr: debug, info, warn or error")
+	cmd.PersistentFlags().StringVar(&options.LogFormat, "log-format", logging.DefaultFormat, "format of the logs written to stderr: text or json")
+	cmd.PersistentFlags().BoolVarP(&common.StdPrompter.AssumeYes, "yes", "y", false, "confirm every confirmation prompt, e.g. creating a resource or overwriting a file, without asking")
 
 	cmd.AddCommand(Sign())
 	cmd.AddCommand(Verify())
+	cmd.AddCommand(Serve())
+	cmd.AddCommand(Compare())
+	cmd.AddCommand(Snapshot())
+	cmd.AddCommand(Prune())
+	cmd.AddCommand(RotateKey())
+	cmd.AddCommand(ExportState())
+	cmd.AddCommand(ImportState())
+	cmd.AddCommand(Import())
 	cmd.AddCommand(cli.GenerateKeyPair())
 	cmd.AddCommand(cli.ImportKeyPair())
 	cmd.AddCommand(Init())
 	cmd.AddCommand(Deploy())
 	cmd.AddCommand(UpdateFuncConfig())
+	cmd.AddCommand(PrintPolicy())
+	cmd.AddCommand(Ping())
+	cmd.AddCommand(Status())
+	cmd.AddCommand(Version())
+	cmd.AddCommand(Completion())
+	aws.RegisterCompletions(cmd)
 	cobra.OnInitialize(options.CobraInit)
 	return cmd
 }
@@ -19,26 +19,41 @@ import (
 	"flag"
 	"fmt"
 	"github.com/openclarity/function-clarity/cmd/function-clarity/cli/options"
+	"github.com/openclarity/function-clarity/pkg/ci"
 	opt "github.com/openclarity/function-clarity/pkg/options"
 	"github.com/sigstore/cosign/cmd/cosign/cli/generate"
 	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
 	"github.com/sigstore/cosign/cmd/cosign/cli/sign"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"golang.org/x/exp/slog"
 )
 
-func SignImage() *cobra.Command {
+// FunctionImageResolver returns the image an image function runs, pinned to its digest.
+type FunctionImageResolver func(functionIdentifier string, functionRegion string) (string, error)
+
+// SignImage signs the image given as argument, or the image a function runs when resolveFunctionImage is given, the
+// signature is pushed to the registry of the image.
+func SignImage(resolveFunctionImage FunctionImageResolver) *cobra.Command {
 	o := &opt.SignOptions{}
 	ro := &co.RootOptions{}
+	var function string
+	var functionRegion string
 
 	cmd := &cobra.Command{
 		Use:   "image",
 		Short: "sign and upload the image digest to aws",
-		Args:  cobra.ExactArgs(1),
+		Args:  cobra.MaximumNArgs(1),
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			if err := viper.BindPFlag("privatekey", cmd.Flags().Lookup("key")); err != nil {
 				return fmt.Errorf("error binding privatekey: %w", err)
 			}
+			if err := viper.BindPFlag("fulciourl", cmd.Flags().Lookup("fulcio-url")); err != nil {
+				return fmt.Errorf("error binding fulciourl: %w", err)
+			}
+			if err := viper.BindPFlag("rekorurl", cmd.Flags().Lookup("rekor-url")); err != nil {
+				return fmt.Errorf("error binding rekorurl: %w", err)
+			}
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -48,6 +63,22 @@ func SignImage() *cobra.Command {
 			default:
 				return flag.ErrHelp
 			}
+			if (len(args) == 1) == (function != "") {
+				return fmt.Errorf("expected either an image to sign or --function")
+			}
+			if function != "" {
+				image, err := resolveFunctionImage(function, functionRegion)
+				if err != nil {
+					return err
+				}
+				slog.Info("signing image of function", "image", image, "function", function)
+				args = []string{image}
+			}
+			o.Fulcio.URL = opt.FulcioURL(viper.GetString("fulciourl"))
+			o.Rekor.URL = opt.RekorURL(viper.GetString("rekorurl"))
+			if err := o.TrustRoots.Apply(); err != nil {
+				return err
+			}
 			oidcClientSecret, err := o.OIDC.ClientSecret()
 			if err != nil {
 				return err
@@ -69,11 +100,21 @@ func SignImage() *cobra.Command {
 				OIDCProvider:             o.OIDC.Provider,
 				SkipConfirmation:         o.SkipConfirmation,
 			}
-			annotationsMap, err := o.AnnotationsMap()
+			annotations, err := ci.ParseAnnotations(append(append([]string{}, o.Annotations...), o.Annotation...))
 			if err != nil {
 				return err
 			}
-			if err := sign.SignCmd(ro, ko, o.Registry, annotationsMap.Annotations, args, o.Cert, o.CertChain, o.Upload,
+			if !o.NoCIAnnotations {
+				annotations = ci.Merge(annotations, ci.Annotations())
+			}
+			var signatureAnnotations map[string]interface{}
+			if len(annotations) > 0 {
+				signatureAnnotations = map[string]interface{}{}
+				for key, value := range annotations {
+					signatureAnnotations[key] = value
+				}
+			}
+			if err := sign.SignCmd(ro, ko, o.Registry, signatureAnnotations, args, o.Cert, o.CertChain, o.Upload,
 				o.OutputSignature, o.OutputCertificate, o.PayloadPath, o.Force, o.Recursive, o.Attachment, o.NoTlogUpload); err != nil {
 				if o.Attachment == "" {
 					return fmt.Errorf("signing %v: %w", args, err)
@@ -86,6 +127,10 @@ func SignImage() *cobra.Command {
 	o.AddFlags(cmd)
 	ro.AddFlags(cmd)
 	initAwsSignImageFlags(cmd)
+	if resolveFunctionImage != nil {
+		cmd.Flags().StringVar(&function, "function", "", "name or arn of an image function to sign the image it runs, pinned to its digest, instead of the image argument")
+		cmd.Flags().StringVar(&functionRegion, "function-region", "", "aws region where the function of --function runs")
+	}
 	return cmd
 }
 
@@ -0,0 +1,237 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"golang.org/x/term"
+	"io"
+	"os"
+	"strings"
+)
+
+// MaxAttempts is the number of times an invalid answer is prompted for again before the prompt fails, so a typo
+// doesn't abort the whole command.
+var MaxAttempts = 3
+
+// errCompulsory is the error of an empty answer to a compulsory parameter.
+var errCompulsory = errors.New("this is a compulsory parameter")
+
+// ErrNonInteractive is the error of a question asked with --non-interactive.
+var ErrNonInteractive = errors.New("prompting is disabled by --non-interactive")
+
+// Prompter writes questions and reads their answers line by line. A single reader is shared by the questions so that
+// piped answers buffered by one question are read by the next one.
+type Prompter struct {
+	in     io.Reader
+	reader *bufio.Reader
+	writer io.Writer
+	// NonInteractive fails every question instead of reading its answer, set by --non-interactive.
+	NonInteractive bool
+	// AssumeYes confirms every confirmation without asking, set by --yes.
+	AssumeYes bool
+}
+
+func NewPrompter(in io.Reader, out io.Writer) *Prompter {
+	return &Prompter{in: in, reader: bufio.NewReader(in), writer: out}
+}
+
+// StdPrompter asks on stdout and reads the answers from stdin.
+var StdPrompter = NewPrompter(os.Stdin, os.Stdout)
+
+// ask asks the question until parse accepts the answer, up to MaxAttempts times, and returns the error of the last
+// answer otherwise.
+func (p *Prompter) ask(q string, parse func(answer string) error) error {
+	if p.NonInteractive {
+		return fmt.Errorf("%w, no answer to: %s", ErrNonInteractive, strings.TrimSpace(q))
+	}
+	var err error
+	for attempt := 1; attempt <= MaxAttempts; attempt++ {
+		fmt.Fprint(p.writer, q)
+		answer, readErr := p.reader.ReadString('\n')
+		if readErr != nil && (!errors.Is(readErr, io.EOF) || answer == "") {
+			return readErr
+		}
+		if err = parse(strings.TrimSuffix(strings.TrimSuffix(answer, "\n"), "\r")); err == nil {
+			return nil
+		}
+		if attempt < MaxAttempts {
+			fmt.Fprintf(p.writer, "%v, please try again\n", err)
+		}
+	}
+	return err
+}
+
+func (p *Prompter) String(q string, v *string, em bool) error {
+	return p.ask(q, func(answer string) error {
+		if !em && answer == "" {
+			return errCompulsory
+		}
+		*v = answer
+		return nil
+	})
+}
+
+// IsTerminal tells whether the prompter reads a terminal, i.e. there is someone to answer the questions, which is
+// never the case with --non-interactive.
+func (p *Prompter) IsTerminal() bool {
+	file, ok := p.in.(*os.File)
+	return ok && !p.NonInteractive && term.IsTerminal(int(file.Fd()))
+}
+
+// Secret reads a compulsory parameter without echoing it when the prompter reads a terminal.
+func (p *Prompter) Secret(q string, v *string) error {
+	if !p.IsTerminal() {
+		return p.String(q, v, false)
+	}
+	file := p.in.(*os.File)
+	var err error
+	for attempt := 1; attempt <= MaxAttempts; attempt++ {
+		fmt.Fprint(p.writer, q)
+		input, readErr := term.ReadPassword(int(file.Fd()))
+		fmt.Fprintln(p.writer)
+		if readErr != nil {
+			return readErr
+		}
+		if err = errCompulsory; strings.TrimSpace(string(input)) != "" {
+			*v = strings.TrimSpace(string(input))
+			return nil
+		}
+		if attempt < MaxAttempts {
+			fmt.Fprintf(p.writer, "%v, please try again\n", err)
+		}
+	}
+	return err
+}
+
+// StringArray reads a comma separated list, skipping its empty items, an empty answer sets nil when em is true.
+func (p *Prompter) StringArray(q string, v *[]string, em bool) error {
+	return p.ask(q, func(answer string) error {
+		var items []string
+		for _, item := range strings.Split(answer, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		if !em && len(items) == 0 {
+			return errCompulsory
+		}
+		*v = items
+		return nil
+	})
+}
+
+// YesNo reads a y, yes, n or no answer in any case, an empty answer keeps the value when em is true.
+func (p *Prompter) YesNo(q string, v *bool, em bool) error {
+	return p.ask(q, func(answer string) error {
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		switch {
+		case answer == "" && !em:
+			return errCompulsory
+		case answer == "":
+		case answer == "y" || answer == "yes":
+			*v = true
+		case answer == "n" || answer == "no":
+			*v = false
+		default:
+			return fmt.Errorf("invalid answer: %s, expected y, yes, n or no", answer)
+		}
+		return nil
+	})
+}
+
+// ReadSecret reads a secret from file, or from the next line of the answers, e.g. piped to stdin, when file is "-",
+// so that the secret is kept out of the shell history and the process list. The trailing newline is stripped.
+func (p *Prompter) ReadSecret(file string) (string, error) {
+	var secret string
+	if file == "-" {
+		line, err := p.reader.ReadString('\n')
+		if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+			return "", fmt.Errorf("failed to read the secret from stdin: %w", err)
+		}
+		secret = line
+	} else {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the secret file: %w", err)
+		}
+		secret = string(content)
+	}
+	if secret = strings.TrimRight(secret, "\r\n"); secret == "" {
+		return "", errors.New("the secret is empty")
+	}
+	return secret, nil
+}
+
+// Confirm asks to confirm an action, an empty answer declines it. With --yes the action is confirmed without asking.
+func (p *Prompter) Confirm(q string) (bool, error) {
+	if p.AssumeYes {
+		return true, nil
+	}
+	confirmed := false
+	err := p.YesNo(q, &confirmed, true)
+	return confirmed, err
+}
+
+// MultipleChoice reads the key of one of the choices of m and sets the choice, an empty answer sets none when em is
+// true.
+func (p *Prompter) MultipleChoice(action string, v *string, m map[string]string, em bool) error {
+	message := "select " + action + " : "
+	for key, element := range m {
+		message = message + "(" + key + ")" + " for " + element + "; "
+	}
+	if em {
+		message = message + "leave empty for no " + action + " to perform: "
+	}
+	return p.ask(message, func(answer string) error {
+		if answer == "" {
+			if !em {
+				return errCompulsory
+			}
+			*v = ""
+			return nil
+		}
+		element, ok := m[answer]
+		if !ok {
+			return fmt.Errorf("invalid %s: %s", action, answer)
+		}
+		*v = element
+		return nil
+	})
+}
+
+func InputStringParameter(q string, p *string, em bool) error {
+	return StdPrompter.String(q, p, em)
+}
+
+// InputSecretParameter reads a compulsory parameter without echoing it when stdin is a terminal.
+func InputSecretParameter(q string, p *string) error {
+	return StdPrompter.Secret(q, p)
+}
+
+func InputStringArrayParameter(q string, p *[]string, em bool) error {
+	return StdPrompter.StringArray(q, p, em)
+}
+
+func InputYesNoParameter(q string, p *bool, em bool) error {
+	return StdPrompter.YesNo(q, p, em)
+}
+
+func InputMultipleChoiceParameter(action string, p *string, m map[string]string, em bool) error {
+	return StdPrompter.MultipleChoice(action, p, m, em)
+}
@@ -0,0 +1,237 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// script returns a prompter reading the given lines as answers.
+func script(lines string) *Prompter {
+	return NewPrompter(strings.NewReader(lines), io.Discard)
+}
+
+func TestPrompterString(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   string
+		em      bool
+		want    string
+		wantErr bool
+	}{
+		{name: "answer", lines: "bucket\n", want: "bucket"},
+		{name: "reprompt on empty compulsory answer", lines: "\n\nbucket\n", want: "bucket"},
+		{name: "fail after max attempts", lines: "\n\n\nbucket\n", wantErr: true},
+		{name: "empty answer allowed", lines: "\n", em: true, want: ""},
+		{name: "answer without newline", lines: "bucket", want: "bucket"},
+		{name: "end of input", lines: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompter := script(tt.lines)
+			var got string
+			err := prompter.String("q: ", &got, tt.em)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPrompterStringArray(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   string
+		em      bool
+		want    []string
+		wantErr bool
+	}{
+		{name: "list", lines: "a,b\n", want: []string{"a", "b"}},
+		{name: "empty answer", lines: "\n", em: true, want: nil},
+		{name: "whitespace answer", lines: "  \t \n", em: true, want: nil},
+		{name: "commas only", lines: " , ,\n", em: true, want: nil},
+		{name: "empty items", lines: "a,,b\n", want: []string{"a", "b"}},
+		{name: "trailing comma", lines: "a,b,\n", want: []string{"a", "b"}},
+		{name: "surrounding whitespace", lines: "  a , b  \n", want: []string{"a", "b"}},
+		{name: "reprompt on empty compulsory answer", lines: ",\na\n", want: []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompter := script(tt.lines)
+			got := []string{"unset"}
+			err := prompter.StringArray("q: ", &got, tt.em)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %#v, got %#v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPrompterYesNo(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   string
+		em      bool
+		initial bool
+		want    bool
+		wantErr bool
+	}{
+		{name: "y", lines: "y\n", want: true},
+		{name: "n", lines: "n\n", initial: true, want: false},
+		{name: "yes", lines: "yes\n", want: true},
+		{name: "uppercase no", lines: "NO\n", initial: true, want: false},
+		{name: "surrounding whitespace", lines: " Y \n", want: true},
+		{name: "reprompt on invalid answer", lines: "maybe\ny\n", want: true},
+		{name: "reprompt on true", lines: "true\nyes\n", want: true},
+		{name: "invalid answer keeps the value", lines: "a\nb\nc\n", initial: true, want: true, wantErr: true},
+		{name: "fail after max attempts", lines: "a\nb\nc\ny\n", want: false, wantErr: true},
+		{name: "empty answer keeps the value", lines: "\n", em: true, initial: true, want: true},
+		{name: "reprompt on empty compulsory answer", lines: "\nn\n", initial: true, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompter := script(tt.lines)
+			got := tt.initial
+			err := prompter.YesNo("q: ", &got, tt.em)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPrompterMultipleChoice(t *testing.T) {
+	choices := map[string]string{"L": "log", "B": "block"}
+	tests := []struct {
+		name    string
+		lines   string
+		em      bool
+		want    string
+		wantErr bool
+	}{
+		{name: "choice", lines: "B\n", want: "block"},
+		{name: "reprompt on unknown choice", lines: "X\nL\n", want: "log"},
+		{name: "fail after max attempts", lines: "X\nY\nZ\n", wantErr: true},
+		{name: "empty answer allowed", lines: "\n", em: true, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompter := script(tt.lines)
+			got := "unset"
+			err := prompter.MultipleChoice("action", &got, choices, tt.em)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMaxAttempts(t *testing.T) {
+	previous := MaxAttempts
+	MaxAttempts = 1
+	t.Cleanup(func() { MaxAttempts = previous })
+	var got string
+	if err := script("\nbucket\n").String("q: ", &got, false); err == nil {
+		t.Fatalf("expected a single attempt, got: %q", got)
+	}
+}
+
+func TestPrompterSharesBufferedAnswers(t *testing.T) {
+	prompter := script("bucket\ny\n")
+	var bucket string
+	var create bool
+	if err := prompter.String("q: ", &bucket, false); err != nil || bucket != "bucket" {
+		t.Fatalf("unexpected answer: %q, %v", bucket, err)
+	}
+	if err := prompter.YesNo("q: ", &create, false); err != nil || !create {
+		t.Fatalf("expected the second answer to be read from the buffer, got: %v, %v", create, err)
+	}
+}
+
+func TestPrompterNonInteractive(t *testing.T) {
+	prompter := script("bucket\ny\n")
+	prompter.NonInteractive = true
+	bucket := "unset"
+	err := prompter.String("enter default bucket: ", &bucket, true)
+	if !errors.Is(err, ErrNonInteractive) || !strings.Contains(err.Error(), "enter default bucket") {
+		t.Fatalf("expected a non interactive error naming the question, got: %v", err)
+	}
+	if bucket != "unset" {
+		t.Fatalf("expected the value to be kept, got: %q", bucket)
+	}
+	if prompter.IsTerminal() {
+		t.Fatalf("expected a non interactive prompter not to be a terminal")
+	}
+	if _, err = prompter.Confirm("q: "); !errors.Is(err, ErrNonInteractive) {
+		t.Fatalf("expected the confirmation to fail, got: %v", err)
+	}
+	prompter.AssumeYes = true
+	if confirmed, err := prompter.Confirm("q: "); err != nil || !confirmed {
+		t.Fatalf("expected --yes to confirm without asking, got: %v, %v", confirmed, err)
+	}
+}
+
+func TestPrompterConfirm(t *testing.T) {
+	for lines, want := range map[string]bool{"y\n": true, "no\n": false, "\n": false} {
+		if confirmed, err := script(lines).Confirm("q: "); err != nil || confirmed != want {
+			t.Fatalf("expected %q to confirm %v, got: %v, %v", lines, want, confirmed, err)
+		}
+	}
+}
+
+func TestPrompterReadSecret(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(file, []byte("s3cr3t\r\n\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if secret, err := script("").ReadSecret(file); err != nil || secret != "s3cr3t" {
+		t.Fatalf("expected the secret of the file without its trailing newlines, got: %q, %v", secret, err)
+	}
+	prompter := script("from-stdin\nnext\n")
+	if secret, err := prompter.ReadSecret("-"); err != nil || secret != "from-stdin" {
+		t.Fatalf("expected the first line of stdin, got: %q, %v", secret, err)
+	}
+	if secret, err := prompter.ReadSecret("-"); err != nil || secret != "next" {
+		t.Fatalf("expected the next line of stdin, got: %q, %v", secret, err)
+	}
+	if _, err := prompter.ReadSecret("-"); err == nil {
+		t.Fatalf("expected an error once stdin is read")
+	}
+	if _, err := script("\n").ReadSecret("-"); err == nil {
+		t.Fatalf("expected an error for an empty secret")
+	}
+	if _, err := script("").ReadSecret(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatalf("expected an error for a missing file")
+	}
+}
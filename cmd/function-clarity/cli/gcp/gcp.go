@@ -32,7 +32,7 @@ func GcpSign() *cobra.Command {
 		Short: "sign code/image and upload to GCP",
 	}
 	cmd.AddCommand(GCPSignCode())
-	cmd.AddCommand(common.SignImage())
+	cmd.AddCommand(common.SignImage(nil))
 	return cmd
 }
 
@@ -44,16 +44,7 @@ func GcpVerify() *cobra.Command {
 		Short: "verify function identity",
 		Args:  cobra.ExactArgs(1),
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			if err := viper.BindPFlag("location", cmd.Flags().Lookup("location")); err != nil {
-				return fmt.Errorf("error binding location: %w", err)
-			}
-			if err := viper.BindPFlag("bucket", cmd.Flags().Lookup("bucket")); err != nil {
-				return fmt.Errorf("error binding bucket: %w", err)
-			}
-			if err := viper.BindPFlag("publickey", cmd.Flags().Lookup("key")); err != nil {
-				return fmt.Errorf("error binding publickey: %w", err)
-			}
-			return nil
+			return bindGCPVerifyFlags(cmd)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			o.Key = viper.GetString("publickey")
@@ -63,7 +54,8 @@ func GcpVerify() *cobra.Command {
 	}
 	cmd.Flags().StringVar(&functionRegion, "function-location", "", "GCP location where the verified function runs")
 	cmd.MarkFlagRequired("function-region") //nolint:errcheck
-	o.AddFlags(cmd)
+	o.AddCommonFlags(cmd)
+	o.AddOutputFlag(cmd)
 	initGCPVerifyFlags(cmd)
 	return cmd
 }
@@ -74,3 +66,16 @@ func initGCPVerifyFlags(cmd *cobra.Command) {
 	cmd.Flags().String("bucket", "", "GCP bucket to work against")
 	cmd.Flags().String("key", "", "public key")
 }
+
+func bindGCPVerifyFlags(cmd *cobra.Command) error {
+	if err := viper.BindPFlag("location", cmd.Flags().Lookup("location")); err != nil {
+		return fmt.Errorf("error binding location: %w", err)
+	}
+	if err := viper.BindPFlag("bucket", cmd.Flags().Lookup("bucket")); err != nil {
+		return fmt.Errorf("error binding bucket: %w", err)
+	}
+	if err := viper.BindPFlag("publickey", cmd.Flags().Lookup("key")); err != nil {
+		return fmt.Errorf("error binding publickey: %w", err)
+	}
+	return nil
+}
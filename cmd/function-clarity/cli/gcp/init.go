@@ -0,0 +1,150 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"github.com/openclarity/function-clarity/cmd/function-clarity/cli/common"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	i "github.com/openclarity/function-clarity/pkg/init"
+	"github.com/sigstore/cosign/cmd/cosign/cli/generate"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+	"os"
+	"strings"
+)
+
+// defaultBucketPrefix names the bucket created when none is given, bucket names are global in gcp so the project
+// is appended.
+const defaultBucketPrefix = "functionclarity-"
+
+func GcpInit() *cobra.Command {
+	var input i.GCPInput
+	cmd := &cobra.Command{
+		Use:   "gcp",
+		Short: "initialize configuration for gcp",
+		Long: "initialize the configuration for gcp. the parameters not given as flags are prompted for.\n" +
+			"the application default credentials are used, the signatures bucket is created when it doesn't exist",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := receiveParameters(&input, cmd.Flags()); err != nil {
+				return err
+			}
+			gcpClient := clients.NewGCPClientInit(input.Bucket, input.Location, "").WithProject(input.Project)
+			if err := validateBucket(cmd.Context(), &input, gcpClient); err != nil {
+				return err
+			}
+			if input.PublicKey == "" && !input.IsKeyless {
+				if err := generate.GenerateKeyPairCmd(context.Background(), "", []string{}); err != nil {
+					return err
+				}
+				input.PublicKey = "cosign.pub"
+				input.PrivateKey = "cosign.key"
+			}
+			d, err := yaml.Marshal(&input)
+			if err != nil {
+				return fmt.Errorf("init command fail: %w", err)
+			}
+			h, err := os.UserHomeDir()
+			if err != nil {
+				return fmt.Errorf("init command fail: %w", err)
+			}
+			if err = os.WriteFile(h+"/.fc", d, 0600); err != nil {
+				return fmt.Errorf("init command fail: %w", err)
+			}
+			printTriggerInstructions(&input)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&input.Project, "project", "", "gcp project to work against")
+	cmd.Flags().StringVar(&input.Location, "location", "", "gcp location of the signatures bucket and the verifier")
+	cmd.Flags().StringVar(&input.Bucket, "bucket", "", "bucket holding the signatures, a bucket named functionclarity-<project> is used when empty")
+	cmd.Flags().BoolVar(&input.IsKeyless, "keyless", false, "sign and verify in keyless mode")
+	cmd.Flags().StringVar(&input.PublicKey, "public-key", "", "path to the public key for code signing, a key pair is generated when empty")
+	cmd.Flags().StringVar(&input.PrivateKey, "private-key", "", "path to the private key for code signing")
+	return cmd
+}
+
+// receiveParameters prompts for the parameters not given as flags.
+func receiveParameters(input *i.GCPInput, flags *pflag.FlagSet) error {
+	if !flags.Changed("project") {
+		if err := common.InputStringParameter("enter project: ", &input.Project, false); err != nil {
+			return err
+		}
+	}
+	if !flags.Changed("location") {
+		if err := common.InputStringParameter("enter location: ", &input.Location, false); err != nil {
+			return err
+		}
+	}
+	if !flags.Changed("bucket") {
+		if err := common.InputStringParameter("enter default bucket (you can leave empty and a bucket with name "+defaultBucketPrefix+input.Project+" will be used): ", &input.Bucket, true); err != nil {
+			return err
+		}
+	}
+	if !flags.Changed("keyless") {
+		if err := common.InputYesNoParameter("do you want to work in keyless mode (y/n): ", &input.IsKeyless, false); err != nil {
+			return err
+		}
+	}
+	if input.IsKeyless || flags.Changed("public-key") {
+		return nil
+	}
+	if err := common.InputStringParameter("enter path to custom public key for code signing? (if you want us to generate key pair, please press enter): ", &input.PublicKey, true); err != nil {
+		return err
+	}
+	if input.PublicKey != "" && !flags.Changed("private-key") {
+		if err := common.InputStringParameter("enter path to custom private key for code signing: ", &input.PrivateKey, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateBucket checks the credentials and that the bucket given exists, the default bucket is created when it
+// doesn't.
+func validateBucket(ctx context.Context, input *i.GCPInput, provider clients.CloudProvider) error {
+	if input.Bucket == "" {
+		input.Bucket = defaultBucketPrefix + input.Project
+		return clients.EnsureStorage(ctx, provider, input.Bucket)
+	}
+	if err := provider.ValidateCredentials(ctx); err != nil {
+		return fmt.Errorf("validation error: %w", err)
+	}
+	if !provider.IsStorageExist(input.Bucket) {
+		return fmt.Errorf("validation error: bucket doesn't exist or you don't have permissions")
+	}
+	return nil
+}
+
+// printTriggerInstructions prints the commands deploying the verifier and the eventarc trigger sending it the Cloud
+// Audit Logs entries of the functions deployments, they are left to gcloud.
+func printTriggerInstructions(input *i.GCPInput) {
+	fmt.Printf("to verify the functions on deployment, run `function-clarity serve gcp` as a cloud run service named function-clarity in %s with the config written to ~/.fc, and route the audit logs to it:\n", input.Location)
+	for _, method := range clients.GCPFunctionDeployMethods {
+		fmt.Printf("  gcloud eventarc triggers create function-clarity-%s --project=%s --location=%s --destination-run-service=function-clarity "+
+			"--event-filters=type=google.cloud.audit.log.v1.written --event-filters=serviceName=cloudfunctions.googleapis.com --event-filters=methodName=%s\n",
+			triggerSuffix(method), input.Project, input.Location, method)
+	}
+}
+
+// triggerSuffix names the trigger of the audit log method after its api version and method, e.g: v1-createfunction.
+func triggerSuffix(method string) string {
+	parts := strings.Split(method, ".")
+	return parts[3] + "-" + strings.ToLower(parts[len(parts)-1])
+}
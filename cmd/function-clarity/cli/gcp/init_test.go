@@ -0,0 +1,71 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"context"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	i "github.com/openclarity/function-clarity/pkg/init"
+	"testing"
+)
+
+type fakeBucketClient struct {
+	clients.CloudProvider
+	existing []string
+	created  []string
+}
+
+func (f *fakeBucketClient) ValidateCredentials(ctx context.Context) error {
+	return nil
+}
+
+func (f *fakeBucketClient) IsStorageExist(name string) bool {
+	for _, b := range f.existing {
+		if b == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeBucketClient) CreateStorage(name string) error {
+	f.created = append(f.created, name)
+	return nil
+}
+
+func TestValidateBucket(t *testing.T) {
+	client := &fakeBucketClient{existing: []string{"signatures", "functionclarity-existing"}}
+
+	input := &i.GCPInput{Project: "p", Bucket: "signatures"}
+	if err := validateBucket(context.Background(), input, client); err != nil || input.Bucket != "signatures" {
+		t.Fatalf("expected the existing bucket to be kept, got: %s, %v", input.Bucket, err)
+	}
+	input = &i.GCPInput{Project: "p", Bucket: "missing"}
+	if err := validateBucket(context.Background(), input, client); err == nil {
+		t.Fatalf("expected a missing bucket given to fail")
+	}
+	input = &i.GCPInput{Project: "existing"}
+	if err := validateBucket(context.Background(), input, client); err != nil || input.Bucket != "functionclarity-existing" {
+		t.Fatalf("expected the existing default bucket, got: %s, %v", input.Bucket, err)
+	}
+	input = &i.GCPInput{Project: "p"}
+	if err := validateBucket(context.Background(), input, client); err != nil || input.Bucket != "functionclarity-p" {
+		t.Fatalf("expected the default bucket, got: %s, %v", input.Bucket, err)
+	}
+	if len(client.created) != 1 || client.created[0] != "functionclarity-p" {
+		t.Fatalf("expected only the missing default bucket to be created, got: %v", client.created)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"github.com/openclarity/function-clarity/pkg/verify"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+func GcpServe() *cobra.Command {
+	o := &options.VerifyOpts{}
+	var port string
+	cmd := &cobra.Command{
+		Use:   "gcp",
+		Short: "verify the functions deployed, on the Cloud Audit Logs events delivered by eventarc",
+		Long: "serve the eventarc trigger of the Cloud Audit Logs entries of the functions deployments, and verify the\n" +
+			"function of each entry. the port is taken from $PORT when --port isn't given, as set by cloud run",
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return bindGCPVerifyFlags(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Key = viper.GetString("publickey")
+			if !cmd.Flags().Changed("port") && os.Getenv("PORT") != "" {
+				port = os.Getenv("PORT")
+			}
+			handler := auditLogHandler(func(ctx context.Context, functionName string) error {
+				gcpClient := clients.NewGCPClientInit(viper.GetString("bucket"), viper.GetString("location"), clients.GCPFunctionLocation(functionName))
+				return verify.Verify(gcpClient, functionName, o, ctx, "", "", nil, nil)
+			})
+			server := &http.Server{Addr: ":" + port, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+			fmt.Printf("serving audit log events on port %s\n", port)
+			return server.ListenAndServe()
+		},
+	}
+	cmd.Flags().StringVar(&port, "port", "8080", "port to serve the eventarc events on")
+	o.AddCommonFlags(cmd)
+	initGCPVerifyFlags(cmd)
+	return cmd
+}
+
+// auditLogHandler verifies the function deployed by the audit log entry of each event. The failed verifications are
+// answered with a success too, eventarc retrying them wouldn't change the outcome, only malformed events are rejected.
+func auditLogHandler(verifyFunction func(ctx context.Context, functionName string) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		functionName, err := clients.ParseGCPAuditLogEvent(data)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if functionName == "" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if err := verifyFunction(r.Context(), functionName); err != nil {
+			fmt.Printf("verification of function: %s failed: %v\n", functionName, err)
+		} else {
+			fmt.Printf("function: %s verified successfully\n", functionName)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
@@ -0,0 +1,75 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAuditLogHandler(t *testing.T) {
+	var verified []string
+	handler := auditLogHandler(func(ctx context.Context, functionName string) error {
+		verified = append(verified, functionName)
+		return errors.New("function isn't signed")
+	})
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		status int
+	}{
+		{
+			name:   "deployment",
+			method: http.MethodPost,
+			body:   `{"protoPayload":{"methodName":"google.cloud.functions.v2.FunctionService.CreateFunction","resourceName":"projects/p/locations/us-central1/functions/f"}}`,
+			status: http.StatusOK,
+		},
+		{
+			name:   "other method",
+			method: http.MethodPost,
+			body:   `{"protoPayload":{"methodName":"google.cloud.functions.v2.FunctionService.DeleteFunction","resourceName":"projects/p/locations/us-central1/functions/f"}}`,
+			status: http.StatusNoContent,
+		},
+		{
+			name:   "malformed",
+			method: http.MethodPost,
+			body:   `{`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "get",
+			method: http.MethodGet,
+			status: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(tt.method, "/", strings.NewReader(tt.body)))
+			if recorder.Code != tt.status {
+				t.Fatalf("expected status %d, got: %d", tt.status, recorder.Code)
+			}
+		})
+	}
+	if len(verified) != 1 || verified[0] != "projects/p/locations/us-central1/functions/f" {
+		t.Fatalf("expected only the deployed function to be verified, got: %v", verified)
+	}
+}
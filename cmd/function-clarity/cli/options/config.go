@@ -16,15 +16,26 @@
 package options
 
 import (
-	"fmt"
+	"github.com/openclarity/function-clarity/pkg/logging"
+	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"golang.org/x/exp/slog"
 	"log"
 	"os"
 )
 
 var Config string = ""
 
+// LogLevel and LogFormat configure the logs written to stderr, prompts and results stay on stdout.
+var (
+	LogLevel  = logging.DefaultLevel
+	LogFormat = logging.DefaultFormat
+)
+
 func CobraInit() {
+	if err := logging.Configure(os.Stderr, LogLevel, LogFormat); err != nil {
+		log.Fatal(err)
+	}
 	if Config != "" {
 		viper.SetConfigFile(Config)
 		viper.SetConfigType("yaml")
@@ -37,10 +48,26 @@ func CobraInit() {
 		viper.SetConfigName(".fc")
 		viper.SetConfigType("yaml")
 	}
-	if err := viper.ReadInConfig(); err != nil {
-		fmt.Printf("Error loading config file: %s\n", err)
+	err := viper.ReadInConfig()
+	if isCompletion(os.Args) {
+		// the output is the completion script or the suggestions read by the shell
+		return
+	}
+	if err != nil {
+		slog.Warn("error loading config file", "error", err)
 	}
 	if viper.ConfigFileUsed() != "" {
-		fmt.Printf("using config file: %s\n", viper.ConfigFileUsed())
+		slog.Info("using config file", "path", viper.ConfigFileUsed())
+	}
+}
+
+func isCompletion(args []string) bool {
+	if len(args) < 2 {
+		return false
+	}
+	switch args[1] {
+	case "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return true
 	}
+	return false
 }
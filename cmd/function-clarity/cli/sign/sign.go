@@ -16,6 +16,7 @@
 package sign
 
 import (
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/openclarity/function-clarity/pkg/integrity"
@@ -25,9 +26,13 @@ import (
 	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
 	"github.com/sigstore/cosign/cmd/cosign/cli/sign"
 	"github.com/spf13/viper"
+	"os"
 )
 
 func SignIdentity(identity string, o *o.SignBlobOptions, ro *co.RootOptions, isKeyless bool) (string, error) {
+	if err := o.TrustRoots.Apply(); err != nil {
+		return "", fmt.Errorf("signing identity: %w", err)
+	}
 	path := "/tmp/" + uuid.New().String()
 	if err := integrity.SaveTextToFile(identity, path); err != nil {
 		return "", fmt.Errorf("signing identity: %w", err)
@@ -60,6 +65,16 @@ func SignIdentity(identity string, o *o.SignBlobOptions, ro *co.RootOptions, isK
 		outputSignature = "/tmp/" + identity + ".sig"
 		outputCertificate = "/tmp/" + identity + ".crt.base64"
 	}
+	if o.TlogUpload && !isKeyless {
+		// cosign only uploads signatures made with a key to the transparency log in experimental mode
+		defer integrity.EnableExperimental()()
+	}
+	if (isKeyless || o.TlogUpload) && ko.BundlePath == "" {
+		ko.BundlePath = "/tmp/" + identity + "." + integrity.RekorBundleType
+		if err := os.Remove(ko.BundlePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("signing identity: %w", err)
+		}
+	}
 
 	sig, err := sign.SignBlobCmd(ro, ko, o.Registry, path, o.Base64Output, outputSignature, outputCertificate)
 
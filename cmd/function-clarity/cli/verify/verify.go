@@ -17,15 +17,27 @@ package verify
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/openclarity/function-clarity/pkg/integrity"
 	opts "github.com/openclarity/function-clarity/pkg/options"
 	"github.com/sigstore/cosign/cmd/cosign/cli/options"
 	"github.com/sigstore/cosign/cmd/cosign/cli/verify"
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"golang.org/x/exp/slog"
+	"os"
+	"regexp"
+	"time"
 )
 
 func VerifyIdentity(identity string, o *opts.VerifyOpts, ctx context.Context, isKeyless bool) error {
+	if err := o.TrustRoots.Apply(); err != nil {
+		return fmt.Errorf("verifying identity %s: %w", identity, err)
+	}
 	path := "/tmp/" + uuid.New().String()
 	if err := integrity.SaveTextToFile(identity, path); err != nil {
 		return err
@@ -43,6 +55,20 @@ func VerifyIdentity(identity string, o *opts.VerifyOpts, ctx context.Context, is
 	if isKeyless {
 		certRef = "/tmp/" + identity + ".crt.base64"
 	}
+	if o.Offline {
+		// without a rekor client cosign verifies the bundle, the certificate is read from it
+		ko.RekorURL = ""
+		if isKeyless {
+			certRef = ""
+			ko.BundlePath = "/tmp/" + identity + "." + integrity.RekorBundleType
+		}
+	} else if o.TlogVerify && !isKeyless {
+		// cosign only looks signatures made with a key up in the transparency log in experimental mode, it is left
+		// on since functions may be verified in parallel
+		if err := os.Setenv(integrity.ExperimentalEnv, "1"); err != nil {
+			return fmt.Errorf("verifying identity %s: %w", identity, err)
+		}
+	}
 	sigRef := "/tmp/" + identity + ".sig"
 
 	if err := verify.VerifyBlobCmd(ctx, ko, certRef,
@@ -50,7 +76,87 @@ func VerifyIdentity(identity string, o *opts.VerifyOpts, ctx context.Context, is
 		sigRef, path, o.CertVerify.CertGithubWorkflowTrigger, o.CertVerify.CertGithubWorkflowSha,
 		o.CertVerify.CertGithubWorkflowName, o.CertVerify.CertGithubWorkflowRepository, o.CertVerify.CertGithubWorkflowRef,
 		o.CertVerify.EnforceSCT); err != nil {
-		return fmt.Errorf("verifying identity %s: %w", identity, err)
+		if certRef == "" || !withinClockSkew(err, certRef, o.ClockSkew) {
+			return fmt.Errorf("verifying identity %s: %w", identity, err)
+		}
+		slog.Info("certificate is valid within the clock skew tolerance, accepted", "identity", identity, "clockSkew", o.ClockSkew, "error", err)
+	}
+	if isKeyless && (o.CertIdentityRegexp != "" || o.CertOidcIssuerRegexp != "" || len(o.IncludedSigners) > 0) {
+		if err := checkSignerIdentity("/tmp/"+identity+".crt.base64", o); err != nil {
+			return fmt.Errorf("verifying identity %s: %w", identity, err)
+		}
+	}
+	return nil
+}
+
+// checkSignerIdentity checks the subject and the issuer of the signing certificate match the expected regular
+// expressions, which cosign only checks as exact values when verifying a blob, and one of the included signers.
+func checkSignerIdentity(certRef string, o *opts.VerifyOpts) error {
+	cert, err := loadCertificate(certRef)
+	if err != nil {
+		return err
+	}
+	if o.CertIdentityRegexp != "" || o.CertOidcIssuerRegexp != "" {
+		if err = cosign.CheckCertificatePolicy(cert, &cosign.CheckOpts{
+			Identities: []cosign.Identity{{SubjectRegExp: o.CertIdentityRegexp, IssuerRegExp: o.CertOidcIssuerRegexp}},
+		}); err != nil {
+			return err
+		}
+	}
+	if len(o.IncludedSigners) == 0 {
+		return nil
+	}
+	identities := make([]cosign.Identity, 0, len(o.IncludedSigners))
+	for _, signer := range o.IncludedSigners {
+		identities = append(identities, cosign.Identity{SubjectRegExp: signer.SubjectRegexp, IssuerRegExp: signer.IssuerRegexp})
+	}
+	if err = cosign.CheckCertificatePolicy(cert, &cosign.CheckOpts{Identities: identities}); err != nil {
+		return fmt.Errorf("the signer matches none of the included signers: %w", err)
 	}
 	return nil
 }
+
+var certificateExpiryRegex = regexp.MustCompile(`^certificate (?:expired before|was issued after) signatures were entered in log: \S+ is (?:before|after) (\S+)$`)
+
+// withinClockSkew reports whether the verification only failed the certificate validity window check, the last one
+// cosign makes, by no more than the tolerance. Without a transparency log entry the window is checked against the
+// current time, so a certificate issued by a CA whose clock runs ahead of the verifier's looks not yet valid.
+func withinClockSkew(err error, certRef string, tolerance time.Duration) bool {
+	var verificationErr *cosign.VerificationError
+	if tolerance <= 0 || !errors.As(err, &verificationErr) {
+		return false
+	}
+	match := certificateExpiryRegex.FindStringSubmatch(verificationErr.Error())
+	if match == nil {
+		return false
+	}
+	checkedAt, parseErr := time.Parse(time.RFC3339, match[1])
+	if parseErr != nil {
+		return false
+	}
+	cert, loadErr := loadCertificate(certRef)
+	if loadErr != nil {
+		return false
+	}
+	return !checkedAt.Before(cert.NotBefore.Add(-tolerance)) && !checkedAt.After(cert.NotAfter.Add(tolerance))
+}
+
+// loadCertificate reads the first certificate of a PEM file, base64 encoded like the keyless certificates stored
+// next to the signatures or not.
+func loadCertificate(path string) (*x509.Certificate, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if decoded, decodeErr := base64.StdEncoding.DecodeString(string(content)); decodeErr == nil {
+		content = decoded
+	}
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM(content)
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificate found in: %s", path)
+	}
+	return certs[0], nil
+}
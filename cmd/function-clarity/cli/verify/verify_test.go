@@ -0,0 +1,197 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	b64 "encoding/base64"
+	"encoding/pem"
+	opts "github.com/openclarity/function-clarity/pkg/options"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func createCertificate(t *testing.T, template *x509.Certificate, parent *x509.Certificate, pub *ecdsa.PublicKey, signer *ecdsa.PrivateKey) *x509.Certificate {
+	if parent == nil {
+		parent = template
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func generateKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// writeKeylessSignature signs the identity with a certificate without embedded SCT valid between notBefore and
+// notAfter, issued by a root written to the returned chain file.
+func writeKeylessSignature(t *testing.T, identity string, notBefore time.Time, notAfter time.Time) string {
+	rootKey := generateKey(t)
+	root := createCertificate(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-root"},
+		NotBefore:             time.Now().Add(-24 * time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, &rootKey.PublicKey, rootKey)
+	leafKey := generateKey(t)
+	leaf := createCertificate(t, &x509.Certificate{
+		SerialNumber:   big.NewInt(2),
+		EmailAddresses: []string{"signer@example.com"},
+		NotBefore:      notBefore,
+		NotAfter:       notAfter,
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}, root, &leafKey.PublicKey, rootKey)
+
+	digest := sha256.Sum256([]byte(identity))
+	sig, err := ecdsa.SignASN1(rand.Reader, leafKey, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"/tmp/" + identity + ".sig":        b64.StdEncoding.EncodeToString(sig),
+		"/tmp/" + identity + ".crt.base64": b64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf.Raw})),
+	}
+	for path, content := range files {
+		if err = os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		path := path
+		t.Cleanup(func() { os.Remove(path) })
+	}
+	chain := filepath.Join(t.TempDir(), "chain.pem")
+	if err = os.WriteFile(chain, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return chain
+}
+
+func TestVerifyIdentityEnforcesSCT(t *testing.T) {
+	t.Setenv("COSIGN_EXPERIMENTAL", "0")
+	identity := "enforce-sct-test"
+	o := &opts.VerifyOpts{}
+	o.CertVerify.CertChain = writeKeylessSignature(t, identity, time.Now().Add(-time.Minute), time.Now().Add(time.Hour))
+
+	if err := VerifyIdentity(identity, o, context.Background(), true); err != nil {
+		t.Fatalf("expected the signature to verify without sct enforcement: %v", err)
+	}
+	o.CertVerify.EnforceSCT = true
+	err := VerifyIdentity(identity, o, context.Background(), true)
+	if err == nil || !strings.Contains(err.Error(), "SCT") {
+		t.Fatalf("expected a missing sct error, got: %v", err)
+	}
+}
+
+// Without a transparency log entry cosign checks the certificate validity window against the current time.
+func TestVerifyIdentityClockSkew(t *testing.T) {
+	t.Setenv("COSIGN_EXPERIMENTAL", "0")
+	tests := []struct {
+		name      string
+		notBefore time.Duration
+		notAfter  time.Duration
+		fail      bool
+	}{
+		{name: "valid", notBefore: -time.Minute, notAfter: 10 * time.Minute},
+		{name: "issued ahead within skew", notBefore: 110 * time.Second, notAfter: 10 * time.Minute},
+		{name: "issued ahead beyond skew", notBefore: 130 * time.Second, notAfter: 10 * time.Minute, fail: true},
+		{name: "expired within skew", notBefore: -10 * time.Minute, notAfter: -110 * time.Second},
+		{name: "expired beyond skew", notBefore: -10 * time.Minute, notAfter: -130 * time.Second, fail: true},
+	}
+	for _, test := range tests {
+		identity := "clock-skew-test"
+		o := &opts.VerifyOpts{ClockSkew: 2 * time.Minute}
+		now := time.Now()
+		o.CertVerify.CertChain = writeKeylessSignature(t, identity, now.Add(test.notBefore), now.Add(test.notAfter))
+		err := VerifyIdentity(identity, o, context.Background(), true)
+		if test.fail {
+			if err == nil || !strings.Contains(err.Error(), "certificate") {
+				t.Fatalf("%s: expected a certificate validity error, got: %v", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+	}
+}
+
+func TestVerifyIdentityWithoutClockSkew(t *testing.T) {
+	t.Setenv("COSIGN_EXPERIMENTAL", "0")
+	identity := "no-clock-skew-test"
+	o := &opts.VerifyOpts{}
+	o.CertVerify.CertChain = writeKeylessSignature(t, identity, time.Now().Add(30*time.Second), time.Now().Add(10*time.Minute))
+	if err := VerifyIdentity(identity, o, context.Background(), true); err == nil {
+		t.Fatalf("expected a certificate issued ahead to fail without clock skew tolerance")
+	}
+}
+
+// A valid signature made by another identity than the expected one isn't verified.
+func TestVerifyIdentityRejectsUnexpectedSigner(t *testing.T) {
+	t.Setenv("COSIGN_EXPERIMENTAL", "0")
+	identity := "signer-identity-test"
+	tests := []struct {
+		name     string
+		identity string
+		regexp   string
+		issuer   string
+		signers  []opts.SignerIdentity
+		fail     bool
+	}{
+		{name: "any identity"},
+		{name: "expected identity", identity: "signer@example.com"},
+		{name: "unexpected identity", identity: "release@example.com", fail: true},
+		{name: "matching identity regexp", regexp: `^[a-z]+@example\.com$`},
+		{name: "mismatching identity regexp", regexp: `^release@`, fail: true},
+		{name: "mismatching issuer regexp", issuer: `^https://token\.actions\.githubusercontent\.com$`, fail: true},
+		{name: "invalid regexp", regexp: `(`, fail: true},
+		{name: "one matching included signer", signers: []opts.SignerIdentity{{SubjectRegexp: `^release@`}, {SubjectRegexp: `^signer@`}}},
+		{name: "no matching included signer", signers: []opts.SignerIdentity{{SubjectRegexp: `^release@`}, {SubjectRegexp: `@example\.org$`}}, fail: true},
+		{name: "included signer and mismatching regexp", regexp: `^release@`, signers: []opts.SignerIdentity{{SubjectRegexp: `^signer@`}}, fail: true},
+	}
+	for _, test := range tests {
+		o := &opts.VerifyOpts{CertIdentityRegexp: test.regexp, CertOidcIssuerRegexp: test.issuer, IncludedSigners: test.signers}
+		o.CertVerify.CertIdentity = test.identity
+		o.CertVerify.CertChain = writeKeylessSignature(t, identity, time.Now().Add(-time.Minute), time.Now().Add(time.Hour))
+		err := VerifyIdentity(identity, o, context.Background(), true)
+		if test.fail != (err != nil) {
+			t.Fatalf("%s: expected failure: %v, got: %v", test.name, test.fail, err)
+		}
+	}
+}
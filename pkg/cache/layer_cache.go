@@ -0,0 +1,251 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const maxRedirects = 10
+
+var blobPathRegex = regexp.MustCompile(`^/v2/.+/blobs/sha256:([a-f0-9]{64})$`)
+
+type LayerCacheStats struct {
+	Reused      int64
+	Fetched     int64
+	BytesReused int64
+}
+
+// LayerCache is an http.RoundTripper that keeps registry blobs on disk, keyed by their digest.
+// Blobs are immutable, so a layer fetched while verifying one image is served from disk for every
+// other image sharing it. The number of concurrent blob pulls is bounded, and concurrent requests
+// for the same blob wait for a single pull instead of fetching it again.
+type LayerCache struct {
+	dir     string
+	maxSize int64
+	inner   http.RoundTripper
+	pulls   chan struct{}
+
+	mu       sync.Mutex
+	inflight map[string]*sync.Mutex
+
+	reused      int64
+	fetched     int64
+	bytesReused int64
+}
+
+func NewLayerCache(dir string, maxSize int64, maxConcurrentPulls int, inner http.RoundTripper) (*LayerCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create layer cache directory: %s. %v", dir, err)
+	}
+	if maxConcurrentPulls < 1 {
+		maxConcurrentPulls = 1
+	}
+	return &LayerCache{
+		dir:      dir,
+		maxSize:  maxSize,
+		inner:    inner,
+		pulls:    make(chan struct{}, maxConcurrentPulls),
+		inflight: map[string]*sync.Mutex{},
+	}, nil
+}
+
+func (c *LayerCache) Stats() LayerCacheStats {
+	return LayerCacheStats{
+		Reused:      atomic.LoadInt64(&c.reused),
+		Fetched:     atomic.LoadInt64(&c.fetched),
+		BytesReused: atomic.LoadInt64(&c.bytesReused),
+	}
+}
+
+func (c *LayerCache) RoundTrip(req *http.Request) (*http.Response, error) {
+	match := blobPathRegex.FindStringSubmatch(req.URL.Path)
+	if req.Method != http.MethodGet || match == nil {
+		return c.inner.RoundTrip(req)
+	}
+	digest := match[1]
+
+	lock := c.digestLock(digest)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if resp, err := c.cachedResponse(req, digest); err == nil {
+		atomic.AddInt64(&c.reused, 1)
+		atomic.AddInt64(&c.bytesReused, resp.ContentLength)
+		return resp, nil
+	}
+
+	c.pulls <- struct{}{}
+	defer func() { <-c.pulls }()
+
+	resp, err := c.fetch(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	defer resp.Body.Close()
+	if err := c.store(digest, resp.Body); err != nil {
+		return nil, err
+	}
+	atomic.AddInt64(&c.fetched, 1)
+	cached, err := c.cachedResponse(req, digest)
+	if err != nil {
+		return nil, err
+	}
+	c.evict()
+	return cached, nil
+}
+
+func (c *LayerCache) digestLock(digest string) *sync.Mutex {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	lock, ok := c.inflight[digest]
+	if !ok {
+		lock = &sync.Mutex{}
+		c.inflight[digest] = lock
+	}
+	return lock
+}
+
+func (c *LayerCache) blobPath(digest string) string {
+	return filepath.Join(c.dir, "sha256-"+digest)
+}
+
+func (c *LayerCache) cachedResponse(req *http.Request, digest string) (*http.Response, error) {
+	path := c.blobPath(digest)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	// mark the blob as recently used so it is evicted last
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	header := http.Header{}
+	header.Set("Content-Type", "application/octet-stream")
+	header.Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+	header.Set("Docker-Content-Digest", "sha256:"+digest)
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          f,
+		ContentLength: info.Size(),
+		Request:       req,
+	}, nil
+}
+
+// fetch follows redirects itself, registries usually redirect blob downloads to a storage backend
+// and the final response is the one holding the blob content.
+func (c *LayerCache) fetch(req *http.Request) (*http.Response, error) {
+	current := req
+	for i := 0; i < maxRedirects; i++ {
+		resp, err := c.inner.RoundTrip(current)
+		if err != nil {
+			return nil, err
+		}
+		location := resp.Header.Get("Location")
+		if resp.StatusCode < 300 || resp.StatusCode >= 400 || location == "" {
+			return resp, nil
+		}
+		resp.Body.Close()
+		next, err := current.URL.Parse(location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redirect location: %s. %v", location, err)
+		}
+		redirected, err := http.NewRequestWithContext(req.Context(), http.MethodGet, next.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		if next.Host == req.URL.Host {
+			redirected.Header = req.Header.Clone()
+		}
+		current = redirected
+	}
+	return nil, fmt.Errorf("stopped after %d redirects while fetching blob: %s", maxRedirects, req.URL)
+}
+
+func (c *LayerCache) store(digest string, body io.Reader) error {
+	tmp, err := os.CreateTemp(c.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, h), body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to download blob: sha256:%s. %v", digest, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if actual := fmt.Sprintf("%x", h.Sum(nil)); actual != digest {
+		return fmt.Errorf("blob digest mismatch, expected: sha256:%s, got: sha256:%s", digest, actual)
+	}
+	return os.Rename(tmp.Name(), c.blobPath(digest))
+}
+
+func (c *LayerCache) evict() {
+	if c.maxSize <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	var blobs []os.FileInfo
+	var total int64
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "sha256-") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		blobs = append(blobs, info)
+		total += info.Size()
+	}
+	sort.Slice(blobs, func(i, j int) bool {
+		return blobs[i].ModTime().Before(blobs[j].ModTime())
+	})
+	for _, blob := range blobs {
+		if total <= c.maxSize {
+			return
+		}
+		if err := os.Remove(filepath.Join(c.dir, blob.Name())); err == nil {
+			total -= blob.Size()
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func newBlobServer(t *testing.T, blobs map[string][]byte, downloads *int64) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+		// registries usually redirect blob downloads to a storage backend
+		http.Redirect(w, r, "/storage/"+filepath.Base(r.URL.Path), http.StatusTemporaryRedirect)
+	})
+	mux.HandleFunc("/storage/", func(w http.ResponseWriter, r *http.Request) {
+		blob, ok := blobs[filepath.Base(r.URL.Path)]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		atomic.AddInt64(downloads, 1)
+		w.Write(blob) //nolint:errcheck
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func digestOf(data []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
+
+func fetchBlob(t *testing.T, client *http.Client, url string) []byte {
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("failed to fetch blob: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read blob: %v", err)
+	}
+	return data
+}
+
+func TestLayerCacheReusesSharedLayers(t *testing.T) {
+	layer := []byte("shared base layer")
+	var downloads int64
+	server := newBlobServer(t, map[string][]byte{digestOf(layer): layer}, &downloads)
+
+	layerCache, err := NewLayerCache(t.TempDir(), 0, 2, http.DefaultTransport)
+	if err != nil {
+		t.Fatalf("failed to create layer cache: %v", err)
+	}
+	client := &http.Client{Transport: layerCache}
+
+	for _, repo := range []string{"first-function", "second-function"} {
+		data := fetchBlob(t, client, server.URL+"/v2/"+repo+"/blobs/"+digestOf(layer))
+		if string(data) != string(layer) {
+			t.Fatalf("unexpected blob content: %s", data)
+		}
+	}
+
+	if downloads != 1 {
+		t.Fatalf("expected the shared layer to be downloaded once, downloaded %d times", downloads)
+	}
+	stats := layerCache.Stats()
+	if stats.Fetched != 1 || stats.Reused != 1 || stats.BytesReused != int64(len(layer)) {
+		t.Fatalf("unexpected layer cache stats: %+v", stats)
+	}
+}
+
+func TestLayerCacheRejectsDigestMismatch(t *testing.T) {
+	layer := []byte("tampered layer")
+	claimed := digestOf([]byte("original layer"))
+	var downloads int64
+	server := newBlobServer(t, map[string][]byte{claimed: layer}, &downloads)
+
+	dir := t.TempDir()
+	layerCache, err := NewLayerCache(dir, 0, 1, http.DefaultTransport)
+	if err != nil {
+		t.Fatalf("failed to create layer cache: %v", err)
+	}
+	client := &http.Client{Transport: layerCache}
+	if _, err := client.Get(server.URL + "/v2/function/blobs/" + claimed); err == nil {
+		t.Fatalf("expected digest mismatch error")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read cache dir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected a mismatching blob not to be cached, found %d entries", len(entries))
+	}
+}
+
+func TestLayerCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	first := []byte("first layer content")
+	second := []byte("second layer content")
+	var downloads int64
+	server := newBlobServer(t, map[string][]byte{digestOf(first): first, digestOf(second): second}, &downloads)
+
+	dir := t.TempDir()
+	layerCache, err := NewLayerCache(dir, int64(len(second)), 1, http.DefaultTransport)
+	if err != nil {
+		t.Fatalf("failed to create layer cache: %v", err)
+	}
+	client := &http.Client{Transport: layerCache}
+	fetchBlob(t, client, server.URL+"/v2/function/blobs/"+digestOf(first))
+	fetchBlob(t, client, server.URL+"/v2/function/blobs/"+digestOf(second))
+
+	if _, err := os.Stat(filepath.Join(dir, "sha256-"+digestOf(first)[len("sha256:"):])); !os.IsNotExist(err) {
+		t.Fatalf("expected the least recently used layer to be evicted")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sha256-"+digestOf(second)[len("sha256:"):])); err != nil {
+		t.Fatalf("expected the most recently used layer to stay cached: %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+)
+
+type PackageCacheStats struct {
+	Hits   int64
+	Misses int64
+}
+
+// PackageCache keeps the deployment packages downloaded during verification on disk, keyed by their code sha256, so
+// functions and versions sharing a package download it once. A package is only kept when its content matches its
+// code sha256. Without a directory the packages are kept in a temporary one for the run, removed by Close.
+type PackageCache struct {
+	dir       string
+	temporary bool
+
+	mu       sync.Mutex
+	inflight map[string]*sync.Mutex
+
+	hits   int64
+	misses int64
+}
+
+func NewPackageCache(dir string) (*PackageCache, error) {
+	temporary := dir == ""
+	if temporary {
+		var err error
+		if dir, err = os.MkdirTemp("", "fc-package-cache-"); err != nil {
+			return nil, fmt.Errorf("failed to create package cache directory. %v", err)
+		}
+	} else if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create package cache directory: %s. %v", dir, err)
+	}
+	return &PackageCache{dir: dir, temporary: temporary, inflight: map[string]*sync.Mutex{}}, nil
+}
+
+func (c *PackageCache) Stats() PackageCacheStats {
+	return PackageCacheStats{Hits: atomic.LoadInt64(&c.hits), Misses: atomic.LoadInt64(&c.misses)}
+}
+
+// Fetch returns the path of the package whose code sha256 is codeSha256, base64 encoded as lambda reports it. The
+// package is downloaded to the given path by download when it isn't cached yet, concurrent fetches of the same package
+// wait for a single download. A package without a valid code sha256 is downloaded without being cached.
+func (c *PackageCache) Fetch(codeSha256 string, download func(path string) error) (string, error) {
+	digest, err := base64.StdEncoding.DecodeString(codeSha256)
+	if err != nil || len(digest) != sha256.Size {
+		atomic.AddInt64(&c.misses, 1)
+		uncached, err := os.CreateTemp("", "fc-package-")
+		if err != nil {
+			return "", err
+		}
+		uncached.Close()
+		return uncached.Name(), download(uncached.Name())
+	}
+	key := fmt.Sprintf("%x", digest)
+
+	lock := c.digestLock(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	path := c.packagePath(key)
+	if _, err = os.Stat(path); err == nil {
+		atomic.AddInt64(&c.hits, 1)
+		return path, nil
+	}
+	atomic.AddInt64(&c.misses, 1)
+	tmp, err := os.CreateTemp(c.dir, ".tmp-")
+	if err != nil {
+		return "", err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	if err = download(tmp.Name()); err != nil {
+		return "", err
+	}
+	content, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return "", err
+	}
+	if actual := fmt.Sprintf("%x", sha256.Sum256(content)); actual != key {
+		return "", fmt.Errorf("package digest mismatch, expected: sha256:%s, got: sha256:%s", key, actual)
+	}
+	return path, os.Rename(tmp.Name(), path)
+}
+
+// Close removes the packages of a cache kept for the run only.
+func (c *PackageCache) Close() error {
+	if !c.temporary {
+		return nil
+	}
+	return os.RemoveAll(c.dir)
+}
+
+func (c *PackageCache) digestLock(key string) *sync.Mutex {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	lock, ok := c.inflight[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		c.inflight[key] = lock
+	}
+	return lock
+}
+
+func (c *PackageCache) packagePath(key string) string {
+	return filepath.Join(c.dir, "sha256-"+key+".zip")
+}
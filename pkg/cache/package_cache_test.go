@@ -0,0 +1,116 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"os"
+	"testing"
+)
+
+func codeSha256Of(data []byte) string {
+	digest := sha256.Sum256(data)
+	return base64.StdEncoding.EncodeToString(digest[:])
+}
+
+func countingDownload(content []byte, downloads *int) func(path string) error {
+	return func(path string) error {
+		*downloads++
+		return os.WriteFile(path, content, 0600)
+	}
+}
+
+func TestPackageCacheDownloadsAPackageOnce(t *testing.T) {
+	content := []byte("package")
+	c, err := NewPackageCache("")
+	if err != nil {
+		t.Fatalf("failed to create package cache: %v", err)
+	}
+	downloads := 0
+	first, err := c.Fetch(codeSha256Of(content), countingDownload(content, &downloads))
+	if err != nil {
+		t.Fatalf("failed to fetch package: %v", err)
+	}
+	// the second fetch of the same digest fails the test if it downloads
+	second, err := c.Fetch(codeSha256Of(content), func(path string) error {
+		t.Fatalf("expected the cached package to be reused instead of downloaded again")
+		return nil
+	})
+	if err != nil || second != first || downloads != 1 {
+		t.Fatalf("expected the package to be downloaded once, got: %d downloads, %s, %s, %v", downloads, first, second, err)
+	}
+	if cached, err := os.ReadFile(second); err != nil || string(cached) != string(content) {
+		t.Fatalf("expected the cached package content, got: %q, %v", cached, err)
+	}
+	if stats := c.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Fatalf("expected 1 hit and 1 miss, got: %+v", stats)
+	}
+	if err = c.Close(); err != nil {
+		t.Fatalf("failed to close package cache: %v", err)
+	}
+	if _, err = os.Stat(first); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected the temporary cache to be removed, got: %v", err)
+	}
+}
+
+func TestPackageCacheDirIsKeptAcrossRuns(t *testing.T) {
+	dir := t.TempDir()
+	content := []byte("package")
+	downloads := 0
+	for run := 0; run < 2; run++ {
+		c, err := NewPackageCache(dir)
+		if err != nil {
+			t.Fatalf("failed to create package cache: %v", err)
+		}
+		if _, err = c.Fetch(codeSha256Of(content), countingDownload(content, &downloads)); err != nil {
+			t.Fatalf("failed to fetch package: %v", err)
+		}
+		if err = c.Close(); err != nil {
+			t.Fatalf("failed to close package cache: %v", err)
+		}
+	}
+	if downloads != 1 {
+		t.Fatalf("expected the package to be downloaded by the first run only, got: %d downloads", downloads)
+	}
+}
+
+func TestPackageCacheRejectsMismatchingPackages(t *testing.T) {
+	c, err := NewPackageCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create package cache: %v", err)
+	}
+	downloads := 0
+	if _, err = c.Fetch(codeSha256Of([]byte("expected")), countingDownload([]byte("tampered"), &downloads)); err == nil {
+		t.Fatalf("expected a package not matching its code sha256 to be rejected")
+	}
+	if _, err = c.Fetch(codeSha256Of([]byte("expected")), countingDownload([]byte("expected"), &downloads)); err != nil || downloads != 2 {
+		t.Fatalf("expected the rejected package to be downloaded again, got: %d downloads, %v", downloads, err)
+	}
+
+	// a package without a valid code sha256 is downloaded every time
+	for i := 0; i < 2; i++ {
+		path, err := c.Fetch("not a digest", countingDownload([]byte("package"), &downloads))
+		if err != nil {
+			t.Fatalf("failed to fetch package: %v", err)
+		}
+		os.Remove(path)
+	}
+	if downloads != 4 {
+		t.Fatalf("expected the uncached package to be downloaded twice, got: %d downloads", downloads-2)
+	}
+}
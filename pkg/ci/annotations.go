@@ -0,0 +1,114 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ci
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	ProviderKey = "ci.provider"
+	CommitKey   = "ci.commit"
+	BuildURLKey = "ci.build-url"
+	ActorKey    = "ci.actor"
+	RefKey      = "ci.ref"
+)
+
+// Annotations returns the standard provenance annotations of the CI environment the process runs in, or nil
+// outside of a supported CI: GitHub Actions, GitLab CI and AWS CodeBuild.
+func Annotations() map[string]string {
+	return Detect(os.Getenv)
+}
+
+// Detect is Annotations reading the environment through getenv.
+func Detect(getenv func(string) string) map[string]string {
+	var annotations map[string]string
+	switch {
+	case getenv("GITHUB_ACTIONS") == "true":
+		annotations = map[string]string{
+			ProviderKey: "github-actions",
+			CommitKey:   getenv("GITHUB_SHA"),
+			ActorKey:    getenv("GITHUB_ACTOR"),
+			RefKey:      getenv("GITHUB_REF"),
+		}
+		if getenv("GITHUB_SERVER_URL") != "" && getenv("GITHUB_REPOSITORY") != "" && getenv("GITHUB_RUN_ID") != "" {
+			annotations[BuildURLKey] = fmt.Sprintf("%s/%s/actions/runs/%s", getenv("GITHUB_SERVER_URL"),
+				getenv("GITHUB_REPOSITORY"), getenv("GITHUB_RUN_ID"))
+		}
+	case getenv("GITLAB_CI") == "true":
+		annotations = map[string]string{
+			ProviderKey: "gitlab-ci",
+			CommitKey:   getenv("CI_COMMIT_SHA"),
+			BuildURLKey: getenv("CI_JOB_URL"),
+			ActorKey:    getenv("GITLAB_USER_LOGIN"),
+			RefKey:      getenv("CI_COMMIT_REF_NAME"),
+		}
+	case getenv("CODEBUILD_BUILD_ID") != "":
+		ref := getenv("CODEBUILD_WEBHOOK_HEAD_REF")
+		if ref == "" {
+			ref = getenv("CODEBUILD_SOURCE_VERSION")
+		}
+		annotations = map[string]string{
+			ProviderKey: "aws-codebuild",
+			CommitKey:   getenv("CODEBUILD_RESOLVED_SOURCE_VERSION"),
+			BuildURLKey: getenv("CODEBUILD_BUILD_URL"),
+			ActorKey:    getenv("CODEBUILD_INITIATOR"),
+			RefKey:      ref,
+		}
+	default:
+		return nil
+	}
+	for key, value := range annotations {
+		if value == "" {
+			delete(annotations, key)
+		}
+	}
+	return annotations
+}
+
+// ParseAnnotations parses key=value annotations given on the command line. Keys must not be empty and values must
+// fit on a single line, annotations are printed one per line on verification.
+func ParseAnnotations(annotations []string) (map[string]string, error) {
+	parsed := map[string]string{}
+	for _, annotation := range annotations {
+		kv := strings.SplitN(annotation, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("unable to parse annotation: %s", annotation)
+		}
+		if strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("annotation: %s has an empty key", annotation)
+		}
+		if strings.ContainsAny(kv[0]+kv[1], "\r\n") {
+			return nil, fmt.Errorf("annotation: %q contains a newline", annotation)
+		}
+		parsed[kv[0]] = kv[1]
+	}
+	return parsed, nil
+}
+
+// Merge adds the detected annotations to the explicit ones, an explicit annotation overrides a detected one.
+func Merge(explicit map[string]string, detected map[string]string) map[string]string {
+	merged := map[string]string{}
+	for key, value := range detected {
+		merged[key] = value
+	}
+	for key, value := range explicit {
+		merged[key] = value
+	}
+	return merged
+}
@@ -0,0 +1,91 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ci
+
+import (
+	"testing"
+)
+
+func TestDetectGithubActions(t *testing.T) {
+	env := map[string]string{
+		"GITHUB_ACTIONS":    "true",
+		"GITHUB_SHA":        "0123abc",
+		"GITHUB_ACTOR":      "octocat",
+		"GITHUB_REF":        "refs/heads/main",
+		"GITHUB_SERVER_URL": "https://github.com",
+		"GITHUB_REPOSITORY": "openclarity/function-clarity",
+		"GITHUB_RUN_ID":     "42",
+	}
+	annotations := Detect(func(key string) string { return env[key] })
+	expected := map[string]string{
+		ProviderKey: "github-actions",
+		CommitKey:   "0123abc",
+		ActorKey:    "octocat",
+		RefKey:      "refs/heads/main",
+		BuildURLKey: "https://github.com/openclarity/function-clarity/actions/runs/42",
+	}
+	if len(annotations) != len(expected) {
+		t.Fatalf("expected annotations: %v, got: %v", expected, annotations)
+	}
+	for key, value := range expected {
+		if annotations[key] != value {
+			t.Fatalf("annotation: %s, expected: %s, got: %s", key, value, annotations[key])
+		}
+	}
+}
+
+func TestDetectSkipsMissingValues(t *testing.T) {
+	env := map[string]string{"CODEBUILD_BUILD_ID": "build:1", "CODEBUILD_RESOLVED_SOURCE_VERSION": "abc"}
+	annotations := Detect(func(key string) string { return env[key] })
+	if annotations[ProviderKey] != "aws-codebuild" || annotations[CommitKey] != "abc" {
+		t.Fatalf("unexpected annotations: %v", annotations)
+	}
+	if _, ok := annotations[ActorKey]; ok {
+		t.Fatalf("missing values should not be annotated: %v", annotations)
+	}
+	if Detect(func(string) string { return "" }) != nil {
+		t.Fatalf("expected no annotations outside of CI")
+	}
+}
+
+func TestMergeExplicitOverridesDetected(t *testing.T) {
+	explicit, err := ParseAnnotations([]string{"ci.commit=override", "team=payments"})
+	if err != nil {
+		t.Fatalf("failed to parse annotations: %v", err)
+	}
+	merged := Merge(explicit, map[string]string{CommitKey: "detected", ProviderKey: "gitlab-ci"})
+	if merged[CommitKey] != "override" || merged[ProviderKey] != "gitlab-ci" || merged["team"] != "payments" {
+		t.Fatalf("unexpected merged annotations: %v", merged)
+	}
+	if _, err = ParseAnnotations([]string{"invalid"}); err == nil {
+		t.Fatalf("expected error for annotation without value")
+	}
+}
+
+func TestParseAnnotationsValidation(t *testing.T) {
+	parsed, err := ParseAnnotations([]string{"pipeline=https://ci.example.com/build?id=1,2", "empty="})
+	if err != nil {
+		t.Fatalf("failed to parse annotations: %v", err)
+	}
+	if parsed["pipeline"] != "https://ci.example.com/build?id=1,2" || parsed["empty"] != "" {
+		t.Fatalf("unexpected parsed annotations: %v", parsed)
+	}
+	for _, invalid := range []string{"=value", " =value", "commit=abc\ndef", "build\r=1"} {
+		if _, err = ParseAnnotations([]string{invalid}); err == nil {
+			t.Fatalf("expected error for annotation: %q", invalid)
+		}
+	}
+}
@@ -20,30 +20,50 @@ import (
 	"bytes"
 	"context"
 	b64 "encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
 	"github.com/aws/aws-sdk-go-v2/service/cloudtrail"
+	trailTypes "github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
 	lambdaTypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqsTypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmTypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
 	"github.com/google/uuid"
+	"github.com/openclarity/function-clarity/pkg/cache"
 	i "github.com/openclarity/function-clarity/pkg/init"
+	"github.com/openclarity/function-clarity/pkg/metadata"
+	"github.com/openclarity/function-clarity/pkg/options"
 	"github.com/openclarity/function-clarity/pkg/utils"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	awskms "github.com/sigstore/sigstore/pkg/signature/kms/aws"
+	sigoptions "github.com/sigstore/sigstore/pkg/signature/options"
+	"golang.org/x/exp/slog"
 	"gopkg.in/yaml.v3"
 	"io"
-	"log"
+	"net/url"
 	"os"
+	"path"
 	"strconv"
 	"strings"
 	"text/template"
@@ -53,14 +73,65 @@ import (
 const FunctionClarityBucketName = "functionclarity"
 const FunctionClarityLambdaVerierName = "FunctionClarityLambdaVerifier"
 
+// TufRootFileName is the name of the private TUF root packaged with the verifier code.
+const TufRootFileName = "tuf-root.json"
+
+// FunctionClarityTrailName is the name of the trail created by init when no existing trail is given.
+const FunctionClarityTrailName = "FunctionClarityTrail"
+
+const lambdaTimeLayout = "2006-01-02T15:04:05.000-0700"
+
+// maxDeleteObjects is the maximum number of keys in a single s3 DeleteObjects request.
+const maxDeleteObjects = 1000
+
 type AwsClient struct {
 	accessKey    string
 	secretKey    string
 	s3           string
 	region       string
 	lambdaRegion string
+	// roleArn is assumed for the lambda calls, to reach the functions of another account
+	roleArn string
+	// assumeRole, when set, is assumed with the client credentials for every call
+	assumeRole *AssumeRole
+	// kmsKeyArn, when set, encrypts the objects put in the signature bucket
+	kmsKeyArn string
+	// endpoint, when set, replaces the endpoint of every service, e.g. the one of LocalStack
+	endpoint string
+	// maxRetries is the number of times a throttled or failed call is retried
+	maxRetries int
+	// packageCache, when set, keeps the downloaded function and layer packages by code sha256
+	packageCache *cache.PackageCache
+	// timeout bounds the checks of the resources and the downloads of the signatures and packages, 0 doesn't
+	timeout time.Duration
+	// ctx, when set, is the context of the calls of the methods taking none, cancelling it aborts them
+	ctx context.Context
 }
 
+// AssumeRole is an IAM role assumed with the base credentials of a client.
+type AssumeRole struct {
+	RoleArn     string
+	ExternalId  string
+	SessionName string
+	Duration    time.Duration
+}
+
+const (
+	// DefaultAssumeRoleSessionName is the session name of an assumed role when none is given.
+	DefaultAssumeRoleSessionName = "function-clarity"
+	// DefaultAssumeRoleDuration is the session duration of an assumed role when none is given, the sts default.
+	DefaultAssumeRoleDuration = 15 * time.Minute
+)
+
+// DefaultMaxRetries is the number of times a call is retried when none is given.
+const DefaultMaxRetries = 5
+
+// DefaultTimeout bounds an aws operation when no timeout is given.
+const DefaultTimeout = 60 * time.Second
+
+// maxRetryBackoff caps the exponential backoff between the attempts of a call.
+var maxRetryBackoff = 20 * time.Second
+
 func NewAwsClient(accessKey string, secretKey string, s3 string, region string, lambdaRegion string) *AwsClient {
 	p := new(AwsClient)
 	p.accessKey = accessKey
@@ -68,6 +139,8 @@ func NewAwsClient(accessKey string, secretKey string, s3 string, region string,
 	p.s3 = s3
 	p.region = region
 	p.lambdaRegion = lambdaRegion
+	p.maxRetries = DefaultMaxRetries
+	p.timeout = DefaultTimeout
 	return p
 }
 
@@ -76,32 +149,176 @@ func NewAwsClientInit(accessKey string, secretKey string, region string) *AwsCli
 	p.accessKey = accessKey
 	p.secretKey = secretKey
 	p.region = region
+	p.maxRetries = DefaultMaxRetries
+	p.timeout = DefaultTimeout
+	return p
+}
+
+// NewAwsClientInitWithEndpoint returns an init client calling the given endpoint for every service instead of the aws
+// ones, e.g. LocalStack for hermetic tests. The s3 buckets are addressed in the path of the urls.
+func NewAwsClientInitWithEndpoint(accessKey string, secretKey string, region string, endpoint string) *AwsClient {
+	p := NewAwsClientInit(accessKey, secretKey, region)
+	p.endpoint = endpoint
 	return p
 }
 
+// NewAwsClientFromDefaultChain returns an init client resolving its credentials through the default aws credential
+// chain: the environment, the shared config and credentials files, sso and the instance profile.
+func NewAwsClientFromDefaultChain(region string) *AwsClient {
+	return NewAwsClientInit("", "", region)
+}
+
+// NewAwsClientWithRole returns a copy of the client making every call with the given role, assumed with the client
+// credentials.
+func NewAwsClientWithRole(base *AwsClient, role AssumeRole) *AwsClient {
+	p := *base
+	if role.SessionName == "" {
+		role.SessionName = DefaultAssumeRoleSessionName
+	}
+	p.assumeRole = &role
+	return &p
+}
+
+// WithAssumedRole returns a copy of the client calling lambda with the given role, assumed with the client
+// credentials. The signature bucket is still accessed with the client credentials.
+func (o *AwsClient) WithAssumedRole(roleArn string) *AwsClient {
+	p := *o
+	p.roleArn = roleArn
+	return &p
+}
+
+// WithKmsKey returns a copy of the client encrypting the objects it puts in the signature bucket with the kms key.
+func (o *AwsClient) WithKmsKey(keyArn string) *AwsClient {
+	p := *o
+	p.kmsKeyArn = keyArn
+	return &p
+}
+
+// WithPackageCache returns a copy of the client fetching the function and layer packages through the cache.
+func (o *AwsClient) WithPackageCache(packageCache *cache.PackageCache) *AwsClient {
+	p := *o
+	p.packageCache = packageCache
+	return &p
+}
+
+// WithMaxRetries returns a copy of the client retrying a throttled or failed call the given number of times.
+func (o *AwsClient) WithMaxRetries(maxRetries int) *AwsClient {
+	p := *o
+	p.maxRetries = maxRetries
+	return &p
+}
+
+// WithTimeout returns a copy of the client bounding each operation by the timeout, retries included. A timeout of 0
+// leaves the operations bounded by the context of the caller only.
+func (o *AwsClient) WithTimeout(timeout time.Duration) *AwsClient {
+	p := *o
+	p.timeout = timeout
+	return &p
+}
+
+// WithContext returns a copy of the client making the calls of the methods that take no context with the given one,
+// so cancelling it aborts them. The methods taking a context use the one they are given.
+func (o *AwsClient) WithContext(ctx context.Context) *AwsClient {
+	p := *o
+	p.ctx = ctx
+	return &p
+}
+
+// baseContext returns the context of the client, the background context when none was given.
+func (o *AwsClient) baseContext() context.Context {
+	if o.ctx == nil {
+		return context.Background()
+	}
+	return o.ctx
+}
+
+// withTimeout returns a context of the parent bounded by the timeout of the client.
+func (o *AwsClient) withTimeout(parent context.Context) (context.Context, context.CancelFunc) {
+	if o.timeout <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeout(parent, o.timeout)
+}
+
+// existenceCheckError is the error of a check of whether a resource exists: any error but a timeout or a cancellation
+// means it doesn't exist or isn't accessible.
+func (o *AwsClient) existenceCheckError(operation string, err error) error {
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		return nil
+	}
+	return o.timedOut(operation, err)
+}
+
+// timedOut names the operation in the error when it exceeded its deadline, other errors are returned as is.
+func (o *AwsClient) timedOut(operation string, err error) error {
+	if err == nil || !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	if o.timeout <= 0 {
+		return fmt.Errorf("%s timed out: %w", operation, err)
+	}
+	return fmt.Errorf("%s timed out after %s: %w", operation, o.timeout, err)
+}
+
+// The tag of the signature objects, certificates and metadata included, expired by the signature lifecycle rule. The
+// signer pins and the verifier code aren't tagged.
+const (
+	signatureTagKey   = "function-clarity"
+	signatureTagValue = "signature"
+)
+
+// signatureObjectSuffixes are the suffixes of the keys of the signature objects.
+var signatureObjectSuffixes = []string{".sig", ".crt.base64", "." + metadata.FileType}
+
+// taggedSignature tags the object put when it is a signature object.
+func taggedSignature(input *s3.PutObjectInput) *s3.PutObjectInput {
+	for _, suffix := range signatureObjectSuffixes {
+		if strings.HasSuffix(aws.ToString(input.Key), suffix) {
+			input.Tagging = aws.String(url.QueryEscape(signatureTagKey) + "=" + url.QueryEscape(signatureTagValue))
+			break
+		}
+	}
+	return input
+}
+
+// encryptedWith sets the kms key, if any, to encrypt the object put.
+func encryptedWith(kmsKeyArn string, input *s3.PutObjectInput) *s3.PutObjectInput {
+	if kmsKeyArn != "" {
+		input.ServerSideEncryption = s3types.ServerSideEncryptionAwsKms
+		input.SSEKMSKeyId = aws.String(kmsKeyArn)
+	}
+	return input
+}
+
 func (o *AwsClient) ResolvePackageType(funcIdentifier string) (string, error) {
+	ctx, cancel := o.withTimeout(o.baseContext())
+	defer cancel()
 	cfg := o.getConfigForLambda()
 	lambdaClient := lambda.NewFromConfig(*cfg)
 	input := &lambda.GetFunctionInput{
 		FunctionName: aws.String(funcIdentifier),
 	}
-	result, err := lambdaClient.GetFunction(context.TODO(), input)
+	result, err := lambdaClient.GetFunction(ctx, input)
 	if err != nil {
-		return "", err
+		return "", o.timedOut("resolve package type of function: "+funcIdentifier, err)
 	}
 	return string(result.Configuration.PackageType), nil
 }
 
 func (o *AwsClient) Upload(signature string, identity string, isKeyless bool) error {
+	ctx := o.baseContext()
 	cfg := o.getConfig()
 
-	uploader := manager.NewUploader(s3.NewFromConfig(*cfg))
+	uploader := manager.NewUploader(newS3Client(cfg))
 	// Upload the file to S3.
-	_, err := uploader.Upload(context.TODO(), &s3.PutObjectInput{
+	_, err := uploader.Upload(ctx, encryptedWith(o.kmsKeyArn, taggedSignature(&s3.PutObjectInput{
 		Bucket: aws.String(o.s3),
 		Key:    aws.String(identity + ".sig"),
 		Body:   strings.NewReader(signature),
-	})
+	})))
 	if err != nil {
 		return err
 	}
@@ -113,83 +330,311 @@ func (o *AwsClient) Upload(signature string, identity string, isKeyless bool) er
 			return err
 		}
 
-		result, err := uploader.Upload(context.TODO(), &s3.PutObjectInput{
+		result, err := uploader.Upload(ctx, encryptedWith(o.kmsKeyArn, taggedSignature(&s3.PutObjectInput{
 			Bucket: aws.String(o.s3),
 			Key:    aws.String(identity + ".crt.base64"),
 			Body:   f,
-		})
+		})))
 		if err != nil {
 			return err
 		}
-		fmt.Printf("\ncertificate file uploaded to, %s\n", aws.ToString(&result.Location))
+		slog.Info("certificate file uploaded", "location", aws.ToString(&result.Location))
 	}
 	return nil
 }
 
+func (o *AwsClient) UploadFile(content string, fileName string, outputType string) error {
+	ctx := o.baseContext()
+	cfg := o.getConfig()
+	uploader := manager.NewUploader(newS3Client(cfg))
+	_, err := uploader.Upload(ctx, encryptedWith(o.kmsKeyArn, taggedSignature(&s3.PutObjectInput{
+		Bucket: aws.String(o.s3),
+		Key:    aws.String(fileName + "." + outputType),
+		Body:   strings.NewReader(content),
+	})))
+	return err
+}
+
 func (o *AwsClient) Download(fileName string, outputType string) error {
+	ctx, cancel := o.withTimeout(o.baseContext())
+	defer cancel()
 	cfg := o.getConfig()
-	downloader := manager.NewDownloader(s3.NewFromConfig(*cfg))
+	downloader := manager.NewDownloader(newS3Client(cfg))
 
 	outputFile := "/tmp/" + fileName + "." + outputType
 	f, err := os.Create(outputFile)
 	if err != nil {
-		return err
+		return o.timedOut("download "+fileName+"."+outputType, err)
 	}
 	defer f.Close()
 
-	_, err = downloader.Download(context.TODO(), f, &s3.GetObjectInput{
+	_, err = downloader.Download(ctx, f, &s3.GetObjectInput{
 		Bucket: aws.String(o.s3),
 		Key:    aws.String(fileName + "." + outputType),
 	})
 
 	if err != nil {
-		return err
+		return o.timedOut("download "+fileName+"."+outputType, err)
+	}
+	return nil
+}
+
+// BucketObject is an object stored in the signature bucket.
+type BucketObject struct {
+	Key          string
+	LastModified time.Time
+	Size         int64
+}
+
+// ListBucketObjects returns every object in the signature bucket, following the list pagination until exhausted.
+func (o *AwsClient) ListBucketObjects(ctx context.Context) ([]BucketObject, error) {
+	cfg := o.getConfig()
+	return listBucketObjects(ctx, newS3Client(cfg), o.s3)
+}
+
+func listBucketObjects(ctx context.Context, s3Client s3.ListObjectsV2APIClient, bucket string) ([]BucketObject, error) {
+	paginator := s3.NewListObjectsV2Paginator(s3Client, &s3.ListObjectsV2Input{Bucket: aws.String(bucket)})
+	var objects []BucketObject
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list bucket: %s: %w", bucket, err)
+		}
+		for _, object := range page.Contents {
+			objects = append(objects, BucketObject{Key: aws.ToString(object.Key), LastModified: aws.ToTime(object.LastModified), Size: object.Size})
+		}
+	}
+	return objects, nil
+}
+
+// GetBucketObject returns the content of the object in the signature bucket.
+func (o *AwsClient) GetBucketObject(ctx context.Context, key string) ([]byte, error) {
+	cfg := o.getConfig()
+	result, err := newS3Client(cfg).GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(o.s3), Key: aws.String(key)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %s from bucket: %s: %w", key, o.s3, err)
+	}
+	defer result.Body.Close()
+	content, err := io.ReadAll(result.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object: %s from bucket: %s: %w", key, o.s3, err)
+	}
+	return content, nil
+}
+
+// PutBucketObject stores the content under the key in the signature bucket, replacing any existing object.
+func (o *AwsClient) PutBucketObject(ctx context.Context, key string, content []byte) error {
+	cfg := o.getConfig()
+	_, err := newS3Client(cfg).PutObject(ctx, encryptedWith(o.kmsKeyArn, taggedSignature(&s3.PutObjectInput{Bucket: aws.String(o.s3), Key: aws.String(key), Body: bytes.NewReader(content)})))
+	if err != nil {
+		return fmt.Errorf("failed to put object: %s to bucket: %s: %w", key, o.s3, err)
+	}
+	return nil
+}
+
+// DeleteBucketObjects deletes the objects from the signature bucket, stopping at the first object that fails.
+func (o *AwsClient) DeleteBucketObjects(ctx context.Context, keys []string) error {
+	cfg := o.getConfig()
+	s3Client := newS3Client(cfg)
+	for start := 0; start < len(keys); start += maxDeleteObjects {
+		end := start + maxDeleteObjects
+		if end > len(keys) {
+			end = len(keys)
+		}
+		var identifiers []s3types.ObjectIdentifier
+		for _, key := range keys[start:end] {
+			identifiers = append(identifiers, s3types.ObjectIdentifier{Key: aws.String(key)})
+		}
+		result, err := s3Client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(o.s3),
+			Delete: &s3types.Delete{Objects: identifiers, Quiet: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete objects from bucket: %s: %w", o.s3, err)
+		}
+		if len(result.Errors) > 0 {
+			return fmt.Errorf("failed to delete object: %s from bucket: %s: %s", aws.ToString(result.Errors[0].Key), o.s3,
+				aws.ToString(result.Errors[0].Message))
+		}
 	}
 	return nil
 }
 
+// ArchiveBucketObjects moves the objects under the prefix of the signature bucket.
+func (o *AwsClient) ArchiveBucketObjects(ctx context.Context, keys []string, prefix string) error {
+	cfg := o.getConfig()
+	s3Client := newS3Client(cfg)
+	for _, key := range keys {
+		input := &s3.CopyObjectInput{
+			Bucket:     aws.String(o.s3),
+			CopySource: aws.String(url.PathEscape(o.s3 + "/" + key)),
+			Key:        aws.String(prefix + key),
+		}
+		if o.kmsKeyArn != "" {
+			input.ServerSideEncryption = s3types.ServerSideEncryptionAwsKms
+			input.SSEKMSKeyId = aws.String(o.kmsKeyArn)
+		}
+		_, err := s3Client.CopyObject(ctx, input)
+		if err != nil {
+			return fmt.Errorf("failed to archive object: %s in bucket: %s: %w", key, o.s3, err)
+		}
+	}
+	return o.DeleteBucketObjects(ctx, keys)
+}
+
+// GetSignatureTimestamp returns the time the signature of the identity was last uploaded, signing an identity
+// again overwrites its signature so this is the time of the most recent matching signature.
+func (o *AwsClient) GetSignatureTimestamp(identity string) (time.Time, error) {
+	ctx, cancel := o.withTimeout(o.baseContext())
+	defer cancel()
+	cfg := o.getConfig()
+	s3Client := newS3Client(cfg)
+	result, err := s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(o.s3),
+		Key:    aws.String(identity + ".sig"),
+	})
+	if err != nil {
+		return time.Time{}, o.timedOut("get signature timestamp of identity: "+identity, err)
+	}
+	if result.LastModified == nil {
+		return time.Time{}, fmt.Errorf("no upload time for signature of identity: %s", identity)
+	}
+	return *result.LastModified, nil
+}
+
 func (o *AwsClient) GetFuncCode(funcIdentifier string) (string, error) {
+	ctx, cancel := o.withTimeout(o.baseContext())
+	defer cancel()
 	cfg := o.getConfigForLambda()
 	lambdaClient := lambda.NewFromConfig(*cfg)
 	input := &lambda.GetFunctionInput{
 		FunctionName: aws.String(funcIdentifier),
 	}
-	result, err := lambdaClient.GetFunction(context.TODO(), input)
+	result, err := lambdaClient.GetFunction(ctx, input)
 	if err != nil {
-		return "", err
+		return "", o.timedOut("get code of function: "+funcIdentifier, err)
+	}
+	var codeSha256 *string
+	if result.Configuration != nil {
+		codeSha256 = result.Configuration.CodeSha256
+	}
+	path, err := o.downloadCode(ctx, codeSha256, result.Code.Location)
+	return path, o.timedOut("download code of function: "+funcIdentifier, err)
+}
+
+// GetLayerCode downloads and extracts the content of the layer version, the layer arn includes the version.
+func (o *AwsClient) GetLayerCode(layerArn string) (string, error) {
+	ctx, cancel := o.withTimeout(o.baseContext())
+	defer cancel()
+	cfg := o.getConfigForLambda()
+	lambdaClient := lambda.NewFromConfig(*cfg)
+	result, err := lambdaClient.GetLayerVersionByArn(ctx, &lambda.GetLayerVersionByArnInput{Arn: aws.String(layerArn)})
+	if err != nil {
+		return "", o.timedOut("get code of layer: "+layerArn, err)
 	}
+	path, err := o.downloadCode(ctx, result.Content.CodeSha256, result.Content.Location)
+	return path, o.timedOut("download code of layer: "+layerArn, err)
+}
+
+// downloadCode downloads the zip at the presigned location and extracts it, returning the path of the extracted content.
+// With a package cache, a zip already downloaded with the same code sha256 is extracted instead.
+func (o *AwsClient) downloadCode(ctx context.Context, codeSha256 *string, location *string) (string, error) {
 	contentName := uuid.New().String()
-	zipFileName := contentName + ".zip"
-	if err := utils.DownloadFile(contentName+".zip", result.Code.Location); err != nil {
+	zipPath := "/tmp/" + contentName + ".zip"
+	if o.packageCache != nil && codeSha256 != nil {
+		var err error
+		zipPath, err = o.packageCache.Fetch(*codeSha256, func(path string) error {
+			return utils.DownloadFileTo(ctx, path, location)
+		})
+		if err != nil {
+			return "", err
+		}
+	} else if err := utils.DownloadFileTo(ctx, zipPath, location); err != nil {
 		return "", err
 	}
-	if err := utils.ExtractZip("/tmp/"+zipFileName, "/tmp/"+contentName); err != nil {
+	if err := utils.ExtractZip(zipPath, "/tmp/"+contentName); err != nil {
 		return "", err
 	}
 	return "/tmp/" + contentName, nil
 }
 
+// FunctionConfig is the part of a function configuration needed to select functions for verification.
+type FunctionConfig struct {
+	FunctionName string
+	FunctionArn  string
+	PackageType  string
+	CodeSha256   string
+	CodeSize     int64
+	Region       string
+	Runtime      string
+	Handler      string
+	MemorySize   int32
+	Timeout      int32
+	Architecture string
+}
+
+// ListAllFunctions returns every function in the lambda region, following the list pagination until exhausted.
+func (o *AwsClient) ListAllFunctions(ctx context.Context) ([]FunctionConfig, error) {
+	cfg := o.getConfigForLambda()
+	return listAllFunctions(ctx, lambda.NewFromConfig(*cfg), o.lambdaRegion)
+}
+
+func listAllFunctions(ctx context.Context, lambdaClient lambda.ListFunctionsAPIClient, region string) ([]FunctionConfig, error) {
+	var functions []FunctionConfig
+	paginator := lambda.NewListFunctionsPaginator(lambdaClient, &lambda.ListFunctionsInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list functions in region: %s: %w", region, err)
+		}
+		for _, function := range page.Functions {
+			architecture := string(lambdaTypes.ArchitectureX8664)
+			if len(function.Architectures) > 0 {
+				architecture = string(function.Architectures[0])
+			}
+			functions = append(functions, FunctionConfig{
+				FunctionName: aws.ToString(function.FunctionName),
+				FunctionArn:  aws.ToString(function.FunctionArn),
+				PackageType:  string(function.PackageType),
+				CodeSha256:   aws.ToString(function.CodeSha256),
+				CodeSize:     function.CodeSize,
+				Region:       region,
+				Runtime:      string(function.Runtime),
+				Handler:      aws.ToString(function.Handler),
+				MemorySize:   aws.ToInt32(function.MemorySize),
+				Timeout:      aws.ToInt32(function.Timeout),
+				Architecture: architecture,
+			})
+		}
+	}
+	return functions, nil
+}
+
+// IsFuncInRegions tells whether the function region matches one of the regions, region names or globs like us-* and
+// eu-west-?. The region of a function being enabled, matching it is the same as matching the enabled regions.
 func (o *AwsClient) IsFuncInRegions(regions []string) bool {
 	for _, value := range regions {
-		if o.lambdaRegion == value {
+		if matched, err := path.Match(value, o.lambdaRegion); err == nil && matched {
 			return true
 		}
 	}
 	return false
 }
 func (o *AwsClient) FuncContainsTags(funcIdentifier string, tagKes []string) (bool, error) {
+	ctx, cancel := o.withTimeout(o.baseContext())
+	defer cancel()
 	cfg := o.getConfigForLambda()
 	lambdaClient := lambda.NewFromConfig(*cfg)
 	err := o.convertToArnIfNeeded(&funcIdentifier)
 	if err != nil {
-		return false, err
+		return false, o.timedOut("list tags of function: "+funcIdentifier, err)
 	}
 	input := &lambda.ListTagsInput{
 		Resource: aws.String(funcIdentifier),
 	}
-	resp, err := lambdaClient.ListTags(context.TODO(), input)
+	resp, err := lambdaClient.ListTags(ctx, input)
 	if err != nil {
-		return false, err
+		return false, o.timedOut("list tags of function: "+funcIdentifier, err)
 	}
 	for _, tag := range tagKes {
 		if _, exist := resp.Tags[tag]; exist {
@@ -199,34 +644,252 @@ func (o *AwsClient) FuncContainsTags(funcIdentifier string, tagKes []string) (bo
 	return false, nil
 }
 
+func (o *AwsClient) GetFuncTags(funcIdentifier string) (map[string]string, error) {
+	ctx, cancel := o.withTimeout(o.baseContext())
+	defer cancel()
+	cfg := o.getConfigForLambda()
+	lambdaClient := lambda.NewFromConfig(*cfg)
+	if err := o.convertToArnIfNeeded(&funcIdentifier); err != nil {
+		return nil, o.timedOut("list tags of function: "+funcIdentifier, err)
+	}
+	resp, err := lambdaClient.ListTags(ctx, &lambda.ListTagsInput{Resource: aws.String(funcIdentifier)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch func tags. %v", err)
+	}
+	return resp.Tags, nil
+}
+
 func (o *AwsClient) Notify(msg string, topicARN string) error {
+	ctx := o.baseContext()
 	cfg := o.getConfig()
 	snsClient := sns.NewFromConfig(*cfg)
-	result, err := snsClient.Publish(context.TODO(), &sns.PublishInput{
+	result, err := snsClient.Publish(ctx, &sns.PublishInput{
 		Message:  &msg,
 		TopicArn: &topicARN,
 	})
 	if err != nil {
-		return fmt.Errorf("error publishing the message: %s to topic: %s", msg, topicARN)
+		return fmt.Errorf("error publishing the message: %s to topic: %s: %w", msg, topicARN, err)
 	}
 
-	fmt.Println("Message ID: " + *result.MessageId)
+	slog.Info("message published", "topic", topicARN, "messageId", aws.ToString(result.MessageId))
 	return nil
 }
 
+// SendQueueMessages sends the messages to the sqs queue in a single batch, the entries the queue didn't accept are
+// returned. The queue is called in the region of its url.
+// PutParameter writes a string parameter in the given region, replacing the previous value.
+func (o *AwsClient) PutParameter(region string, name string, value string, tier string) error {
+	ctx := o.baseContext()
+	cfg := o.getConfig()
+	ssmClient := ssm.NewFromConfig(*cfg, func(opts *ssm.Options) {
+		if region != "" {
+			opts.Region = region
+		}
+	})
+	_, err := ssmClient.PutParameter(ctx, &ssm.PutParameterInput{
+		Name:      aws.String(name),
+		Value:     aws.String(value),
+		Type:      ssmTypes.ParameterTypeString,
+		Tier:      ssmTypes.ParameterTier(tier),
+		Overwrite: aws.Bool(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put ssm parameter: %s. %v", name, err)
+	}
+	return nil
+}
+
+func (o *AwsClient) SendQueueMessages(queueUrl string, messages []QueueMessage) ([]QueueMessageFailure, error) {
+	ctx := o.baseContext()
+	cfg := o.getConfig()
+	queue := options.ResultQueue{URL: queueUrl}
+	sqsClient := sqs.NewFromConfig(*cfg, func(opts *sqs.Options) {
+		if region := queue.Region(); region != "" {
+			opts.Region = region
+		}
+	})
+	input := &sqs.SendMessageBatchInput{QueueUrl: aws.String(queueUrl)}
+	for _, message := range messages {
+		entry := sqsTypes.SendMessageBatchRequestEntry{Id: aws.String(message.Id), MessageBody: aws.String(message.Body)}
+		if message.DeduplicationId != "" {
+			entry.MessageDeduplicationId = aws.String(message.DeduplicationId)
+		}
+		if message.GroupId != "" {
+			entry.MessageGroupId = aws.String(message.GroupId)
+		}
+		if len(message.Attributes) > 0 {
+			entry.MessageAttributes = map[string]sqsTypes.MessageAttributeValue{}
+			for name, value := range message.Attributes {
+				entry.MessageAttributes[name] = sqsTypes.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(value)}
+			}
+		}
+		input.Entries = append(input.Entries, entry)
+	}
+	result, err := sqsClient.SendMessageBatch(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("error sending messages to queue: %s. %v", queueUrl, err)
+	}
+	var failures []QueueMessageFailure
+	for _, failed := range result.Failed {
+		failures = append(failures, QueueMessageFailure{Id: aws.ToString(failed.Id), Code: aws.ToString(failed.Code),
+			Message: aws.ToString(failed.Message), SenderFault: failed.SenderFault})
+	}
+	return failures, nil
+}
+
 func (o *AwsClient) GetFuncImageURI(funcIdentifier string) (string, error) {
+	ctx, cancel := o.withTimeout(o.baseContext())
+	defer cancel()
 	cfg := o.getConfigForLambda()
 	lambdaClient := lambda.NewFromConfig(*cfg)
 	input := &lambda.GetFunctionInput{
 		FunctionName: aws.String(funcIdentifier),
 	}
-	result, err := lambdaClient.GetFunction(context.TODO(), input)
+	result, err := lambdaClient.GetFunction(ctx, input)
 	if err != nil {
-		return "", err
+		return "", o.timedOut("get image uri of function: "+funcIdentifier, err)
 	}
 	return *result.Code.ImageUri, nil
 }
 
+// GetFuncResolvedImageURI returns the image uri of the function pinned to the digest lambda resolved on deployment, the
+// image it runs even when the tag it was deployed with was moved since.
+func (o *AwsClient) GetFuncResolvedImageURI(funcIdentifier string) (string, error) {
+	ctx, cancel := o.withTimeout(o.baseContext())
+	defer cancel()
+	cfg := o.getConfigForLambda()
+	lambdaClient := lambda.NewFromConfig(*cfg)
+	result, err := lambdaClient.GetFunction(ctx, &lambda.GetFunctionInput{FunctionName: aws.String(funcIdentifier)})
+	if err != nil {
+		return "", o.timedOut("get image uri of function: "+funcIdentifier, err)
+	}
+	if result.Code.ResolvedImageUri == nil {
+		return "", fmt.Errorf("function: %s has no resolved image uri, it isn't an image function", funcIdentifier)
+	}
+	return *result.Code.ResolvedImageUri, nil
+}
+
+// GetVerifierState returns the state of the verifier function deployed in the client region, Active once it can be
+// invoked.
+func (o *AwsClient) GetVerifierState(ctx context.Context) (string, error) {
+	cfg := o.getConfig()
+	lambdaClient := lambda.NewFromConfig(*cfg)
+	result, err := lambdaClient.GetFunction(ctx, &lambda.GetFunctionInput{FunctionName: aws.String(FunctionClarityLambdaVerierName)})
+	if err != nil {
+		return "", fmt.Errorf("failed to get verifier function: %s. %v", FunctionClarityLambdaVerierName, err)
+	}
+	return string(result.Configuration.State), nil
+}
+
+// GetFuncCodeSha256 returns the sha256 lambda computed for the function code, the image digest for image functions.
+func (o *AwsClient) GetFuncCodeSha256(funcIdentifier string) (string, error) {
+	ctx, cancel := o.withTimeout(o.baseContext())
+	defer cancel()
+	cfg := o.getConfigForLambda()
+	lambdaClient := lambda.NewFromConfig(*cfg)
+	result, err := lambdaClient.GetFunction(ctx, &lambda.GetFunctionInput{FunctionName: aws.String(funcIdentifier)})
+	if err != nil {
+		return "", o.timedOut("get code sha256 of function: "+funcIdentifier, err)
+	}
+	return aws.ToString(result.Configuration.CodeSha256), nil
+}
+
+func (o *AwsClient) GetFuncArchitecture(funcIdentifier string) (string, error) {
+	ctx, cancel := o.withTimeout(o.baseContext())
+	defer cancel()
+	cfg := o.getConfigForLambda()
+	lambdaClient := lambda.NewFromConfig(*cfg)
+	input := &lambda.GetFunctionInput{
+		FunctionName: aws.String(funcIdentifier),
+	}
+	result, err := lambdaClient.GetFunction(ctx, input)
+	if err != nil {
+		return "", o.timedOut("get architecture of function: "+funcIdentifier, err)
+	}
+	if len(result.Configuration.Architectures) == 0 {
+		return string(lambdaTypes.ArchitectureX8664), nil
+	}
+	return string(result.Configuration.Architectures[0]), nil
+}
+
+// GetFuncLayers returns the layer version ARNs attached to the function, in the order they are extracted.
+func (o *AwsClient) GetFuncLayers(funcIdentifier string) (*metadata.LayersConfig, error) {
+	ctx, cancel := o.withTimeout(o.baseContext())
+	defer cancel()
+	cfg := o.getConfigForLambda()
+	lambdaClient := lambda.NewFromConfig(*cfg)
+	input := &lambda.GetFunctionInput{
+		FunctionName: aws.String(funcIdentifier),
+	}
+	result, err := lambdaClient.GetFunction(ctx, input)
+	if err != nil {
+		return nil, o.timedOut("get layers of function: "+funcIdentifier, err)
+	}
+	layersConfig := &metadata.LayersConfig{Arns: []string{}}
+	for _, layer := range result.Configuration.Layers {
+		layersConfig.Arns = append(layersConfig.Arns, aws.ToString(layer.Arn))
+	}
+	return layersConfig, nil
+}
+
+// GetFuncCodeSigningConfig returns the AWS native code signing config attached to the function, nil when it has none.
+func (o *AwsClient) GetFuncCodeSigningConfig(funcIdentifier string) (*CodeSigningConfig, error) {
+	ctx := o.baseContext()
+	cfg := o.getConfigForLambda()
+	return functionCodeSigningConfig(ctx, lambda.NewFromConfig(*cfg), funcIdentifier)
+}
+
+type codeSigningConfigAPIClient interface {
+	GetFunctionCodeSigningConfig(ctx context.Context, params *lambda.GetFunctionCodeSigningConfigInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionCodeSigningConfigOutput, error)
+	GetCodeSigningConfig(ctx context.Context, params *lambda.GetCodeSigningConfigInput, optFns ...func(*lambda.Options)) (*lambda.GetCodeSigningConfigOutput, error)
+}
+
+func functionCodeSigningConfig(ctx context.Context, lambdaClient codeSigningConfigAPIClient, funcIdentifier string) (*CodeSigningConfig, error) {
+	attached, err := lambdaClient.GetFunctionCodeSigningConfig(ctx, &lambda.GetFunctionCodeSigningConfigInput{FunctionName: aws.String(funcIdentifier)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch func code signing config. %v", err)
+	}
+	if aws.ToString(attached.CodeSigningConfigArn) == "" {
+		return nil, nil
+	}
+	result, err := lambdaClient.GetCodeSigningConfig(ctx, &lambda.GetCodeSigningConfigInput{CodeSigningConfigArn: attached.CodeSigningConfigArn})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch code signing config: %s. %v", aws.ToString(attached.CodeSigningConfigArn), err)
+	}
+	codeSigningConfig := &CodeSigningConfig{Arn: aws.ToString(attached.CodeSigningConfigArn)}
+	if result.CodeSigningConfig != nil {
+		if policies := result.CodeSigningConfig.CodeSigningPolicies; policies != nil {
+			codeSigningConfig.UntrustedArtifactOnDeployment = string(policies.UntrustedArtifactOnDeployment)
+		}
+		if publishers := result.CodeSigningConfig.AllowedPublishers; publishers != nil {
+			codeSigningConfig.AllowedPublishers = publishers.SigningProfileVersionArns
+		}
+	}
+	return codeSigningConfig, nil
+}
+
+func (o *AwsClient) GetFuncLastModified(funcIdentifier string) (time.Time, error) {
+	ctx, cancel := o.withTimeout(o.baseContext())
+	defer cancel()
+	cfg := o.getConfigForLambda()
+	lambdaClient := lambda.NewFromConfig(*cfg)
+	input := &lambda.GetFunctionInput{
+		FunctionName: aws.String(funcIdentifier),
+	}
+	result, err := lambdaClient.GetFunction(ctx, input)
+	if err != nil {
+		return time.Time{}, o.timedOut("get last modified time of function: "+funcIdentifier, err)
+	}
+	if result.Configuration.LastModified == nil {
+		return time.Time{}, fmt.Errorf("no last modified time for function: %s", funcIdentifier)
+	}
+	lastModified, err := time.Parse(lambdaTimeLayout, *result.Configuration.LastModified)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse last modified time of function: %s: %w", funcIdentifier, err)
+	}
+	return lastModified, nil
+}
+
 func (o *AwsClient) HandleDetect(funcIdentifier *string, failed bool) error {
 	if err := o.convertToArnIfNeeded(funcIdentifier); err != nil {
 		return err
@@ -241,6 +904,7 @@ func (o *AwsClient) HandleDetect(funcIdentifier *string, failed bool) error {
 }
 
 func (o *AwsClient) tagFunction(funcIdentifier string, tag string, tagValue string) error {
+	ctx := o.baseContext()
 	cfg := o.getConfigForLambda()
 	lambdaClient := lambda.NewFromConfig(*cfg)
 	input := &lambda.TagResourceInput{
@@ -249,7 +913,7 @@ func (o *AwsClient) tagFunction(funcIdentifier string, tag string, tagValue stri
 			tag: tagValue,
 		},
 	}
-	_, err := lambdaClient.TagResource(context.TODO(), input)
+	_, err := lambdaClient.TagResource(ctx, input)
 	if err != nil {
 		return fmt.Errorf("failed to tag function. %v", err)
 	}
@@ -288,13 +952,14 @@ func (o *AwsClient) BlockFunction(funcIdentifier *string) error {
 }
 
 func (o *AwsClient) updateConcurrencyLevel(funcIdentifier string, concurrencyLevel *int32) error {
+	ctx := o.baseContext()
 	cfg := o.getConfig()
 	lambdaClient := lambda.NewFromConfig(*cfg)
 	input := &lambda.PutFunctionConcurrencyInput{
 		FunctionName:                 &funcIdentifier,
 		ReservedConcurrentExecutions: concurrencyLevel,
 	}
-	result, err := lambdaClient.PutFunctionConcurrency(context.TODO(), input)
+	result, err := lambdaClient.PutFunctionConcurrency(ctx, input)
 	if *result.ReservedConcurrentExecutions != *concurrencyLevel {
 		return fmt.Errorf("failed to update function concurrency to %d. %v", *concurrencyLevel, err)
 	}
@@ -302,12 +967,13 @@ func (o *AwsClient) updateConcurrencyLevel(funcIdentifier string, concurrencyLev
 }
 
 func (o *AwsClient) DeleteConcurrencyLevel(funcIdentifier string) error {
+	ctx := o.baseContext()
 	cfg := o.getConfig()
 	lambdaClient := lambda.NewFromConfig(*cfg)
 	input := &lambda.DeleteFunctionConcurrencyInput{
 		FunctionName: &funcIdentifier,
 	}
-	_, err := lambdaClient.DeleteFunctionConcurrency(context.TODO(), input)
+	_, err := lambdaClient.DeleteFunctionConcurrency(ctx, input)
 	if err != nil {
 		return fmt.Errorf("failed to update function concurrency to 0. %v", err)
 	}
@@ -315,19 +981,81 @@ func (o *AwsClient) DeleteConcurrencyLevel(funcIdentifier string) error {
 }
 
 func (o *AwsClient) GetConcurrencyLevel(funcIdentifier string) (*int32, error) {
+	ctx := o.baseContext()
 	cfg := o.getConfigForLambda()
 	lambdaClient := lambda.NewFromConfig(*cfg)
 	input := &lambda.GetFunctionConcurrencyInput{
 		FunctionName: &funcIdentifier,
 	}
-	result, err := lambdaClient.GetFunctionConcurrency(context.TODO(), input)
+	result, err := lambdaClient.GetFunctionConcurrency(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch func concurrencly level. %v", err)
 	}
 	return result.ReservedConcurrentExecutions, nil
 }
 
+// GetFuncConcurrency returns the reserved and provisioned concurrency of the function. When the function was
+// blocked by function clarity, the reserved concurrency prior to the block is returned.
+func (o *AwsClient) GetFuncConcurrency(funcIdentifier string) (*metadata.ConcurrencyConfig, error) {
+	ctx, cancel := o.withTimeout(o.baseContext())
+	defer cancel()
+	if err := o.convertToArnIfNeeded(&funcIdentifier); err != nil {
+		return nil, o.timedOut("get concurrency of function: "+funcIdentifier, err)
+	}
+	reserved, err := o.GetConcurrencyLevel(funcIdentifier)
+	if err != nil {
+		return nil, o.timedOut("get concurrency of function: "+funcIdentifier, err)
+	}
+	cfg := o.getConfigForLambda()
+	lambdaClient := lambda.NewFromConfig(*cfg)
+	tags, err := lambdaClient.ListTags(ctx, &lambda.ListTagsInput{Resource: aws.String(funcIdentifier)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch func tags. %v", err)
+	}
+	if prevLevel, blocked := tags.Tags[utils.FunctionClarityConcurrencyTagKey]; blocked {
+		reserved = nil
+		if prevLevel != "nil" {
+			prevLevelInt, err := strconv.ParseInt(prevLevel, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse func concurrency level tag: %s. %v", prevLevel, err)
+			}
+			prevLevelInt32 := int32(prevLevelInt)
+			reserved = &prevLevelInt32
+		}
+	}
+	provisioned, err := listProvisionedConcurrency(ctx, lambdaClient, funcIdentifier)
+	if err != nil {
+		return nil, o.timedOut("get concurrency of function: "+funcIdentifier, err)
+	}
+	return &metadata.ConcurrencyConfig{ReservedConcurrentExecutions: reserved, ProvisionedConcurrency: provisioned}, nil
+}
+
+// listProvisionedConcurrency returns the provisioned concurrency of every qualifier of the function, following the
+// list pagination until exhausted. It is nil when no qualifier has provisioned concurrency.
+func listProvisionedConcurrency(ctx context.Context, lambdaClient lambda.ListProvisionedConcurrencyConfigsAPIClient,
+	funcIdentifier string) (map[string]int32, error) {
+	var provisioned map[string]int32
+	paginator := lambda.NewListProvisionedConcurrencyConfigsPaginator(lambdaClient, &lambda.ListProvisionedConcurrencyConfigsInput{
+		FunctionName: aws.String(funcIdentifier),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch func provisioned concurrency. %v", err)
+		}
+		for _, provisionedConfig := range page.ProvisionedConcurrencyConfigs {
+			if provisioned == nil {
+				provisioned = map[string]int32{}
+			}
+			qualifier := (*provisionedConfig.FunctionArn)[strings.LastIndex(*provisionedConfig.FunctionArn, ":")+1:]
+			provisioned[qualifier] = aws.ToInt32(provisionedConfig.RequestedProvisionedConcurrentExecutions)
+		}
+	}
+	return provisioned, nil
+}
+
 func (o *AwsClient) UnblockFunction(funcIdentifier *string) error {
+	ctx := o.baseContext()
 	if err := o.tagFunction(*funcIdentifier, utils.FunctionVerifyResultTagKey, utils.FunctionSignedTagValue); err != nil {
 		return fmt.Errorf("failed to tag function with success result: %s. %v", *funcIdentifier, err)
 	}
@@ -344,7 +1072,7 @@ func (o *AwsClient) UnblockFunction(funcIdentifier *string) error {
 			return fmt.Errorf("failed to unblock function (set concurrency level to prev value): %s. %v", *funcIdentifier, err)
 		}
 	} else {
-		log.Printf("function not blocked by func clarity, not changing concurrency level")
+		slog.Info("function not blocked by function-clarity, not changing concurrency level", "function", *funcIdentifier)
 		return nil
 	}
 	concurrencyLevelTagName := utils.FunctionClarityConcurrencyTagKey
@@ -354,7 +1082,7 @@ func (o *AwsClient) UnblockFunction(funcIdentifier *string) error {
 	untagFunctionInput := &lambda.UntagResourceInput{
 		Resource: funcIdentifier,
 		TagKeys:  untagKeyArray}
-	_, err = lambdaClient.UntagResource(context.TODO(), untagFunctionInput)
+	_, err = lambdaClient.UntagResource(ctx, untagFunctionInput)
 	if err != nil {
 		return fmt.Errorf("failed to untag func clarity concurrency level tag for func: %s. %v", *funcIdentifier, err)
 	}
@@ -362,13 +1090,14 @@ func (o *AwsClient) UnblockFunction(funcIdentifier *string) error {
 }
 
 func (o *AwsClient) convertToArnIfNeeded(funcIdentifier *string) error {
+	ctx := o.baseContext()
 	if !arn.IsARN(*funcIdentifier) {
 		cfg := o.getConfigForLambda()
 		lambdaClient := lambda.NewFromConfig(*cfg)
 		input := &lambda.GetFunctionInput{
 			FunctionName: aws.String(*funcIdentifier),
 		}
-		result, err := lambdaClient.GetFunction(context.TODO(), input)
+		result, err := lambdaClient.GetFunction(ctx, input)
 		if err != nil {
 			return fmt.Errorf("failed to get function by name: %s", *funcIdentifier)
 		}
@@ -378,18 +1107,19 @@ func (o *AwsClient) convertToArnIfNeeded(funcIdentifier *string) error {
 }
 
 func (o *AwsClient) GetConcurrencyLevelTag(funcIdentifier string, tag string) (error, *int32) {
+	ctx := o.baseContext()
 	cfg := o.getConfig()
 	lambdaClient := lambda.NewFromConfig(*cfg)
 	input := &lambda.ListTagsInput{
 		Resource: aws.String(funcIdentifier),
 	}
-	resp, err := lambdaClient.ListTags(context.TODO(), input)
+	resp, err := lambdaClient.ListTags(ctx, input)
 	if err != nil {
 		return err, nil
 	}
 	concurrencyLevel, exist := resp.Tags[tag]
 	if !exist {
-		log.Printf("function not blocked by function-clarity, nothing to do")
+		slog.Info("function not blocked by function-clarity, nothing to do")
 		noConcurrency := int32(-1)
 		return nil, &noConcurrency
 	}
@@ -402,59 +1132,413 @@ func (o *AwsClient) GetConcurrencyLevelTag(funcIdentifier string, tag string) (e
 }
 
 func (o *AwsClient) GetEcrToken() (*ecr.GetAuthorizationTokenOutput, error) {
+	ctx := o.baseContext()
 	cfg := o.getConfig()
 	ecrClient := ecr.NewFromConfig(*cfg)
-	output, err := ecrClient.GetAuthorizationToken(context.TODO(), &ecr.GetAuthorizationTokenInput{})
+	output, err := ecrClient.GetAuthorizationToken(ctx, &ecr.GetAuthorizationTokenInput{})
 	if err != nil {
 		return nil, err
 	}
 	return output, nil
 }
 
-func (o *AwsClient) ValidateCredentials() bool {
+// ValidateCredentials checks the credentials, or the role assumed with them, get the caller identity.
+func (o *AwsClient) ValidateCredentials(ctx context.Context) error {
+	ctx, cancel := o.withTimeout(ctx)
+	defer cancel()
+	cfg := o.getConfig()
+	stsClient := sts.NewFromConfig(*cfg)
+	if _, err := stsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{}); err != nil {
+		return o.timedOut("validate credentials", fmt.Errorf("failed to get caller identity. %w", err))
+	}
+	return nil
+}
+
+// EnabledRegions returns the regions enabled for the account, as listed from the given region. The client region
+// isn't used since it is the one being validated.
+func (o *AwsClient) EnabledRegions(ctx context.Context, from string) ([]string, error) {
+	ctx, cancel := o.withTimeout(ctx)
+	defer cancel()
+	p := *o
+	p.region = from
+	ec2Client := ec2.NewFromConfig(*p.getConfig())
+	result, err := ec2Client.DescribeRegions(ctx, &ec2.DescribeRegionsInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe regions. %v", err)
+	}
+	var regions []string
+	for _, region := range result.Regions {
+		regions = append(regions, aws.ToString(region.RegionName))
+	}
+	return regions, nil
+}
+
+// CallerIdentity returns the arn and account of the credentials in use.
+func (o *AwsClient) CallerIdentity(ctx context.Context) (string, string, error) {
 	cfg := o.getConfig()
 	stsClient := sts.NewFromConfig(*cfg)
-	if _, err := stsClient.GetCallerIdentity(context.TODO(), &sts.GetCallerIdentityInput{}); err != nil {
-		return false
+	result, err := stsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get caller identity. %v", err)
+	}
+	return aws.ToString(result.Arn), aws.ToString(result.Account), nil
+}
+
+// HeadBucket checks the signature bucket exists and the credentials may access it.
+func (o *AwsClient) HeadBucket(ctx context.Context) error {
+	cfg := o.getConfig()
+	s3Client := newS3Client(cfg)
+	if _, err := s3Client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(o.s3)}); err != nil {
+		return fmt.Errorf("failed to access bucket: %s. %v", o.s3, err)
+	}
+	return nil
+}
+
+// IsBucketExist tells whether the bucket exists and the credentials may access it. It fails only when the check
+// timed out or was cancelled, since the bucket may then exist.
+func (o *AwsClient) IsBucketExist(bucketName string) (bool, error) {
+	ctx, cancel := o.withTimeout(o.baseContext())
+	defer cancel()
+	cfg := o.getConfig()
+	s3Client := newS3Client(cfg)
+	if _, err := s3Client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(bucketName)}); err != nil {
+		return false, o.existenceCheckError("check bucket: "+bucketName, err)
+	}
+	return true, nil
+}
+
+// IsStorageExist tells whether the bucket exists and the credentials may access it.
+func (o *AwsClient) IsStorageExist(name string) bool {
+	exists, err := o.IsBucketExist(name)
+	return err == nil && exists
+}
+
+// CreateStorage creates the bucket in the client region, encrypted with the kms key of the client if any, like the
+// bucket created on deployment.
+func (o *AwsClient) CreateStorage(name string) error {
+	return createBucket(o.baseContext(), o.getConfig(), name, o.kmsKeyArn)
+}
+
+// KmsPublicKeyPEM returns the PEM encoded public key of the kms signing key reference, i.e: awskms:///<key arn>, read
+// with the credentials of the client.
+func (o *AwsClient) KmsPublicKeyPEM(ctx context.Context, keyRef string) ([]byte, error) {
+	ctx, cancel := o.withTimeout(ctx)
+	defer cancel()
+	_, keyId, _, err := awskms.ParseReference(keyRef)
+	if err != nil {
+		return nil, err
+	}
+	cfg := o.getConfig()
+	region := cfg.Region
+	if keyArn, err := arn.Parse(keyId); err == nil {
+		region = keyArn.Region
+	}
+	signerVerifier, err := awskms.LoadSignerVerifier(ctx, keyRef, config.WithCredentialsProvider(cfg.Credentials), config.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kms key: %s. %v", keyRef, err)
+	}
+	publicKey, err := signerVerifier.PublicKey(sigoptions.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get public key of kms key: %s. %v", keyRef, err)
+	}
+	return cryptoutils.MarshalPublicKeyToPEM(publicKey)
+}
+
+// GetBucketRegion returns the region the bucket is in, whichever the client region.
+func (o *AwsClient) GetBucketRegion(bucket string) (string, error) {
+	ctx := o.baseContext()
+	cfg := o.getConfig()
+	region, err := manager.GetBucketRegion(ctx, newS3Client(cfg), bucket)
+	if err != nil {
+		return "", fmt.Errorf("failed to get region of bucket: %s. %v", bucket, err)
+	}
+	return region, nil
+}
+
+// signatureRetentionRuleId is the id of the bucket lifecycle rule expiring the signature objects.
+const signatureRetentionRuleId = "function-clarity-signature-retention"
+
+// SetBucketLifecycle expires the signature objects of the bucket after the number of days, the other rules of the
+// bucket lifecycle are kept. The rule is removed when expireDays is 0. Only the objects tagged as signatures on upload
+// are expired, the verifier code and the signer pins are kept.
+func (o *AwsClient) SetBucketLifecycle(bucket string, expireDays int) error {
+	ctx := o.baseContext()
+	cfg := o.getConfig()
+	s3Client := newS3Client(cfg)
+	var existing []s3types.LifecycleRule
+	current, err := s3Client.GetBucketLifecycleConfiguration(ctx, &s3.GetBucketLifecycleConfigurationInput{Bucket: aws.String(bucket)})
+	var apiErr smithy.APIError
+	if err == nil {
+		existing = current.Rules
+	} else if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "NoSuchLifecycleConfiguration" {
+		return fmt.Errorf("failed to get lifecycle of bucket: %s. %v", bucket, err)
+	}
+	rules := signatureLifecycleRules(existing, expireDays)
+	if len(rules) == 0 {
+		if len(existing) == 0 {
+			return nil
+		}
+		if _, err = s3Client.DeleteBucketLifecycle(ctx, &s3.DeleteBucketLifecycleInput{Bucket: aws.String(bucket)}); err != nil {
+			return fmt.Errorf("failed to delete lifecycle of bucket: %s. %v", bucket, err)
+		}
+		return nil
+	}
+	if _, err = s3Client.PutBucketLifecycleConfiguration(ctx, &s3.PutBucketLifecycleConfigurationInput{
+		Bucket:                 aws.String(bucket),
+		LifecycleConfiguration: &s3types.BucketLifecycleConfiguration{Rules: rules},
+	}); err != nil {
+		return fmt.Errorf("failed to set lifecycle of bucket: %s. %v", bucket, err)
+	}
+	return nil
+}
+
+// signatureLifecycleRules returns the existing rules with the signature retention rule replaced, or removed when
+// expireDays is 0.
+func signatureLifecycleRules(existing []s3types.LifecycleRule, expireDays int) []s3types.LifecycleRule {
+	var rules []s3types.LifecycleRule
+	for _, rule := range existing {
+		if aws.ToString(rule.ID) != signatureRetentionRuleId {
+			rules = append(rules, rule)
+		}
+	}
+	if expireDays <= 0 {
+		return rules
+	}
+	return append(rules, s3types.LifecycleRule{
+		ID:         aws.String(signatureRetentionRuleId),
+		Status:     s3types.ExpirationStatusEnabled,
+		Filter:     &s3types.LifecycleRuleFilterMemberTag{Value: s3types.Tag{Key: aws.String(signatureTagKey), Value: aws.String(signatureTagValue)}},
+		Expiration: &s3types.LifecycleExpiration{Days: int32(expireDays)},
+	})
+}
+
+// ValidateKmsKey checks the kms key exists, is enabled and is a symmetric encryption key, the only keys s3 encrypts
+// objects with.
+func (o *AwsClient) ValidateKmsKey(keyArn string) error {
+	ctx, cancel := o.withTimeout(o.baseContext())
+	defer cancel()
+	cfg := o.getConfig()
+	kmsClient := kms.NewFromConfig(*cfg)
+	result, err := kmsClient.DescribeKey(ctx, &kms.DescribeKeyInput{KeyId: aws.String(keyArn)})
+	if err != nil {
+		return fmt.Errorf("failed to describe kms key: %s. %v", keyArn, err)
+	}
+	key := result.KeyMetadata
+	if key.KeyState != kmstypes.KeyStateEnabled {
+		return fmt.Errorf("kms key: %s is %s, expected it enabled", keyArn, key.KeyState)
 	}
-	return true
+	if key.KeyUsage != kmstypes.KeyUsageTypeEncryptDecrypt || key.KeySpec != kmstypes.KeySpecSymmetricDefault {
+		return fmt.Errorf("kms key: %s is a %s %s key, expected a %s %s key to encrypt the signatures", keyArn,
+			key.KeySpec, key.KeyUsage, kmstypes.KeySpecSymmetricDefault, kmstypes.KeyUsageTypeEncryptDecrypt)
+	}
+	return nil
 }
 
-func (o *AwsClient) IsBucketExist(bucketName string) bool {
+// writeProbeKey is the object put in the bucket to check it is writable, deleted right after.
+const writeProbeKey = "function-clarity-write-probe"
+
+// CheckBucketWritable checks the credentials may write the signatures to the bucket, by putting and then deleting an
+// object encrypted like the signatures.
+func (o *AwsClient) CheckBucketWritable(ctx context.Context) error {
 	cfg := o.getConfig()
-	s3Client := s3.NewFromConfig(*cfg)
-	if _, err := s3Client.HeadBucket(context.TODO(), &s3.HeadBucketInput{Bucket: aws.String(bucketName)}); err != nil {
-		return false
+	s3Client := newS3Client(cfg)
+	if _, err := s3Client.PutObject(ctx, encryptedWith(o.kmsKeyArn, &s3.PutObjectInput{
+		Bucket: aws.String(o.s3),
+		Key:    aws.String(writeProbeKey),
+		Body:   strings.NewReader(writeProbeKey),
+	})); err != nil {
+		return fmt.Errorf("failed to write to bucket: %s. %v", o.s3, err)
+	}
+	if _, err := s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String(o.s3), Key: aws.String(writeProbeKey)}); err != nil {
+		return fmt.Errorf("failed to delete from bucket: %s. %v", o.s3, err)
 	}
-	return true
+	return nil
 }
 
-func (o *AwsClient) IsSnsTopicExist(topicArn string) bool {
+// IsSnsTopicExist tells whether the topic exists and the credentials may access it, it fails only when the check timed
+// out or was cancelled.
+func (o *AwsClient) IsSnsTopicExist(topicArn string) (bool, error) {
+	ctx, cancel := o.withTimeout(o.baseContext())
+	defer cancel()
 	cfg := o.getConfig()
 	snsClient := sns.NewFromConfig(*cfg)
-	if _, err := snsClient.GetTopicAttributes(context.TODO(), &sns.GetTopicAttributesInput{TopicArn: aws.String(topicArn)}); err != nil {
-		return false
+	if _, err := snsClient.GetTopicAttributes(ctx, &sns.GetTopicAttributesInput{TopicArn: aws.String(topicArn)}); err != nil {
+		return false, o.existenceCheckError("check sns topic: "+topicArn, err)
 	}
-	return true
+	return true, nil
 }
 
-func (o *AwsClient) IsCloudTrailExist(trailName string) bool {
+// CreateSnsTopic creates the topic in the client region and returns its arn. Creating a topic is idempotent, the arn of
+// the existing topic with the name is returned.
+func (o *AwsClient) CreateSnsTopic(name string) (string, error) {
+	ctx := o.baseContext()
+	cfg := o.getConfig()
+	snsClient := sns.NewFromConfig(*cfg)
+	result, err := snsClient.CreateTopic(ctx, &sns.CreateTopicInput{Name: aws.String(name)})
+	if err != nil {
+		return "", fmt.Errorf("failed to create sns topic: %s. %v", name, err)
+	}
+	return aws.ToString(result.TopicArn), nil
+}
+
+// SubscribeEmail subscribes the email address to the topic, the subscription is pending until confirmed from the
+// email sent to the address.
+func (o *AwsClient) SubscribeEmail(topicArn string, email string) error {
+	ctx := o.baseContext()
+	cfg := o.getConfig()
+	snsClient := sns.NewFromConfig(*cfg)
+	if _, err := snsClient.Subscribe(ctx, &sns.SubscribeInput{
+		TopicArn: aws.String(topicArn),
+		Protocol: aws.String("email"),
+		Endpoint: aws.String(email),
+	}); err != nil {
+		return fmt.Errorf("failed to subscribe: %s to sns topic: %s. %v", email, topicArn, err)
+	}
+	return nil
+}
+
+// IsCloudTrailExist tells whether the trail exists and the credentials may access it, it fails only when the check
+// timed out or was cancelled.
+func (o *AwsClient) IsCloudTrailExist(trailName string) (bool, error) {
+	ctx, cancel := o.withTimeout(o.baseContext())
+	defer cancel()
 	cfg := o.getConfig()
 	svt := cloudtrail.NewFromConfig(*cfg)
-	if _, err := svt.GetTrail(context.TODO(), &cloudtrail.GetTrailInput{Name: &trailName}); err != nil {
-		return false
+	if _, err := svt.GetTrail(ctx, &cloudtrail.GetTrailInput{Name: &trailName}); err != nil {
+		return false, o.existenceCheckError("check trail: "+trailName, err)
+	}
+	return true, nil
+}
+
+// IsCloudTrailLogging tells whether the trail records the events, a stopped trail doesn't trigger the verifier.
+func (o *AwsClient) IsCloudTrailLogging(trailName string) (bool, error) {
+	ctx := o.baseContext()
+	cfg := o.getConfig()
+	trailClient := cloudtrail.NewFromConfig(*cfg)
+	result, err := trailClient.GetTrailStatus(ctx, &cloudtrail.GetTrailStatusInput{Name: aws.String(trailName)})
+	if err != nil {
+		return false, fmt.Errorf("failed to get status of trail: %s. %v", trailName, err)
+	}
+	return aws.ToBool(result.IsLogging), nil
+}
+
+// CreateCloudTrail creates a multi-region trail of the management events in the client region, logging to the bucket.
+// The bucket is created if missing and its policy is granted to cloudtrail, the statements already in it are kept.
+// Nothing is done when a trail with the name already exists in the region.
+func (o *AwsClient) CreateCloudTrail(name string, bucket string) error {
+	ctx := o.baseContext()
+	cfg := o.getConfig()
+	trailClient := cloudtrail.NewFromConfig(*cfg)
+	if _, err := trailClient.GetTrail(ctx, &cloudtrail.GetTrailInput{Name: aws.String(name)}); err == nil {
+		return nil
+	}
+	_, account, err := o.CallerIdentity(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create trail: %s. %v", name, err)
+	}
+	if err = createBucket(ctx, cfg, bucket, o.kmsKeyArn); err != nil {
+		return fmt.Errorf("failed to create trail bucket: %s. %v", bucket, err)
+	}
+	s3Client := newS3Client(cfg)
+	var existing string
+	current, err := s3Client.GetBucketPolicy(ctx, &s3.GetBucketPolicyInput{Bucket: aws.String(bucket)})
+	var apiErr smithy.APIError
+	if err == nil {
+		existing = aws.ToString(current.Policy)
+	} else if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "NoSuchBucketPolicy" {
+		return fmt.Errorf("failed to get policy of bucket: %s. %v", bucket, err)
+	}
+	policy, err := trailBucketPolicy(existing, utils.Partition(cfg.Region), bucket, account)
+	if err != nil {
+		return fmt.Errorf("failed to grant bucket: %s to cloudtrail. %v", bucket, err)
+	}
+	if _, err = s3Client.PutBucketPolicy(ctx, &s3.PutBucketPolicyInput{Bucket: aws.String(bucket), Policy: aws.String(policy)}); err != nil {
+		return fmt.Errorf("failed to grant bucket: %s to cloudtrail. %v", bucket, err)
+	}
+	_, err = trailClient.CreateTrail(ctx, &cloudtrail.CreateTrailInput{
+		Name:                       aws.String(name),
+		S3BucketName:               aws.String(bucket),
+		IsMultiRegionTrail:         aws.Bool(true),
+		IncludeGlobalServiceEvents: aws.Bool(true),
+	})
+	var exists *trailTypes.TrailAlreadyExistsException
+	if err != nil && !errors.As(err, &exists) {
+		return fmt.Errorf("failed to create trail: %s. %v", name, err)
+	}
+	if _, err = trailClient.StartLogging(ctx, &cloudtrail.StartLoggingInput{Name: aws.String(name)}); err != nil {
+		return fmt.Errorf("failed to start logging of trail: %s. %v", name, err)
+	}
+	return nil
+}
+
+// The ids of the bucket policy statements granting cloudtrail to log to the bucket.
+const (
+	trailAclCheckSid = "FunctionClarityTrailAclCheck"
+	trailWriteSid    = "FunctionClarityTrailWrite"
+)
+
+// trailBucketPolicy returns the existing bucket policy, if any, with the statements granting cloudtrail to log the
+// events of the account to the bucket. Statements left by a previous call are replaced.
+func trailBucketPolicy(existing string, partition string, bucket string, account string) (string, error) {
+	policy := map[string]interface{}{"Version": "2012-10-17"}
+	if existing != "" {
+		if err := json.Unmarshal([]byte(existing), &policy); err != nil {
+			return "", fmt.Errorf("failed to parse bucket policy. %v", err)
+		}
+	}
+	var statements []interface{}
+	switch current := policy["Statement"].(type) {
+	case []interface{}:
+		statements = current
+	case map[string]interface{}:
+		statements = []interface{}{current}
+	}
+	kept := []interface{}{}
+	for _, statement := range statements {
+		if s, ok := statement.(map[string]interface{}); ok && (s["Sid"] == trailAclCheckSid || s["Sid"] == trailWriteSid) {
+			continue
+		}
+		kept = append(kept, statement)
+	}
+	principal := map[string]string{"Service": "cloudtrail.amazonaws.com"}
+	policy["Statement"] = append(kept,
+		map[string]interface{}{
+			"Sid":       trailAclCheckSid,
+			"Effect":    "Allow",
+			"Principal": principal,
+			"Action":    "s3:GetBucketAcl",
+			"Resource":  "arn:" + partition + ":s3:::" + bucket,
+		},
+		map[string]interface{}{
+			"Sid":       trailWriteSid,
+			"Effect":    "Allow",
+			"Principal": principal,
+			"Action":    "s3:PutObject",
+			"Resource":  "arn:" + partition + ":s3:::" + bucket + "/AWSLogs/" + account + "/*",
+			"Condition": map[string]interface{}{"StringEquals": map[string]string{"s3:x-amz-acl": "bucket-owner-full-control"}},
+		})
+	content, err := json.Marshal(policy)
+	if err != nil {
+		return "", err
 	}
-	return true
+	return string(content), nil
 }
 
 func (o *AwsClient) DeployFunctionClarity(trailName string, keyPath string, deploymentConfig i.AWSInput, suffix string) error {
+	ctx := o.baseContext()
 	cfg := o.getConfig()
-	if err := uploadFuncClarityCode(cfg, keyPath, deploymentConfig.Bucket); err != nil {
+	if err := uploadFuncClarityCode(ctx, cfg, keyPath, deploymentConfig.TufRootPath, deploymentConfig.Bucket, deploymentConfig.KmsKeyArn); err != nil {
 		return fmt.Errorf("failed to upload function clarity code: %w", err)
 	}
+	if deploymentConfig.TufRootPath != "" {
+		deploymentConfig.TufRootPath = TufRootFileName
+	}
+	if err := o.SetBucketLifecycle(deploymentConfig.Bucket, deploymentConfig.SignatureRetentionDays); err != nil {
+		return err
+	}
 	cloudformationClient := cloudformation.NewFromConfig(*cfg)
 	funcClarityStackName := "function-clarity-stack" + suffix
-	stackExists, err := stackExists(funcClarityStackName, cloudformationClient)
+	stackExists, err := stackExists(ctx, funcClarityStackName, cloudformationClient)
 	if err != nil {
 		return fmt.Errorf("failed to check if stack exists: %w", err)
 	}
@@ -462,21 +1546,21 @@ func (o *AwsClient) DeployFunctionClarity(trailName string, keyPath string, depl
 		return fmt.Errorf("function clarity already deployed, please delete stack before you dpeloy")
 	}
 
-	err, stackCalculatedTemplate := calculateStackTemplate(trailName, cfg, deploymentConfig, suffix)
+	err, stackCalculatedTemplate := calculateStackTemplate(ctx, trailName, cfg, deploymentConfig, suffix)
 	if err != nil {
 		return err
 	}
 	stackName := funcClarityStackName
-	_, err = cloudformationClient.CreateStack(context.TODO(), &cloudformation.CreateStackInput{
+	_, err = cloudformationClient.CreateStack(ctx, &cloudformation.CreateStackInput{
 		TemplateBody: &stackCalculatedTemplate,
 		StackName:    &stackName,
 		Capabilities: []types.Capability{types.CapabilityCapabilityIam},
 	})
-	fmt.Println("deployment request sent to provider")
+	slog.Info("deployment request sent to provider")
 	if err != nil {
 		return fmt.Errorf("failed to create stack: %w", err)
 	}
-	fmt.Println("waiting for deployment to complete")
+	slog.Info("waiting for deployment to complete")
 
 	var timeout bool
 	timer := time.NewTimer(5 * time.Minute)
@@ -489,7 +1573,7 @@ func (o *AwsClient) DeployFunctionClarity(trailName string, keyPath string, depl
 	}()
 
 	for {
-		stacks, err := cloudformationClient.DescribeStacks(context.TODO(), &cloudformation.DescribeStacksInput{StackName: aws.String(stackName)})
+		stacks, err := cloudformationClient.DescribeStacks(ctx, &cloudformation.DescribeStacksInput{StackName: aws.String(stackName)})
 		if err != nil {
 			return fmt.Errorf("failed to create stack: %w", err)
 		}
@@ -501,18 +1585,24 @@ func (o *AwsClient) DeployFunctionClarity(trailName string, keyPath string, depl
 		}
 		time.Sleep(30 * time.Second)
 	}
+	if trailName != "" {
+		if err = linkTrailLogs(ctx, cfg, trailName, stackName); err != nil {
+			return fmt.Errorf("failed to send the events of trail: %s to cloudwatch logs: %w", trailName, err)
+		}
+	}
 
-	fmt.Println("deployment finished successfully")
+	slog.Info("deployment finished successfully")
 	return nil
 }
 
 func (o *AwsClient) UpdateVerifierFucConfig(action *string, includedFuncTagKeys *[]string, includedFuncRegions *[]string, topic *string) error {
+	ctx := o.baseContext()
 	cfg := o.getConfig()
 	lambdaClient := lambda.NewFromConfig(*cfg)
 	input := &lambda.GetFunctionConfigurationInput{
 		FunctionName: aws.String(FunctionClarityLambdaVerierName),
 	}
-	functionConfiguration, err := lambdaClient.GetFunctionConfiguration(context.TODO(), input)
+	functionConfiguration, err := lambdaClient.GetFunctionConfiguration(ctx, input)
 	if err != nil {
 		return fmt.Errorf("failed to update configuration: %w", err)
 	}
@@ -547,7 +1637,7 @@ func (o *AwsClient) UpdateVerifierFucConfig(action *string, includedFuncTagKeys
 	environment.Variables = functionConfiguration.Environment.Variables
 	environment.Variables[ConfigEnvVariableName] = configEncoded
 	updateFunctionEnvInput := lambda.UpdateFunctionConfigurationInput{FunctionName: aws.String(FunctionClarityLambdaVerierName), Environment: &environment}
-	_, err = lambdaClient.UpdateFunctionConfiguration(context.TODO(), &updateFunctionEnvInput)
+	_, err = lambdaClient.UpdateFunctionConfiguration(ctx, &updateFunctionEnvInput)
 	if err != nil {
 		return fmt.Errorf("failed to update configuration: %w", err)
 	}
@@ -569,7 +1659,7 @@ func (o *AwsClient) FillNotificationDetails(notification *Notification, function
 	return nil
 }
 
-func calculateStackTemplate(trailName string, cfg *aws.Config, config i.AWSInput, suffix string) (error, string) {
+func calculateStackTemplate(ctx context.Context, trailName string, cfg *aws.Config, config i.AWSInput, suffix string) (error, string) {
 	templateFile := "unified-template.template"
 	content, err := os.ReadFile(templateFile)
 	if err != nil {
@@ -593,19 +1683,21 @@ func calculateStackTemplate(trailName string, cfg *aws.Config, config i.AWSInput
 		data["withTrail"] = "True"
 	} else {
 		svt := cloudtrail.NewFromConfig(*cfg)
-		trail, err := svt.GetTrail(context.TODO(), &cloudtrail.GetTrailInput{Name: &trailName})
+		trail, err := svt.GetTrail(ctx, &cloudtrail.GetTrailInput{Name: &trailName})
 		if err != nil {
 			return err, ""
 		}
-		if err = trailValid(trail); err != nil {
-			return err, ""
-		}
-		cloudWatchArn, err := arn.Parse(*trail.Trail.CloudWatchLogsLogGroupArn)
-		if err != nil {
-			return err, ""
+		if aws.ToString(trail.Trail.CloudWatchLogsLogGroupArn) == "" {
+			// the log group is created with the stack and linked to the trail once deployed, see linkTrailLogs
+			data["withTrailLogs"] = "True"
+		} else {
+			cloudWatchArn, err := arn.Parse(*trail.Trail.CloudWatchLogsLogGroupArn)
+			if err != nil {
+				return err, ""
+			}
+			data["logGroupArn"] = *trail.Trail.CloudWatchLogsLogGroupArn
+			data["logGroupName"] = strings.Split(cloudWatchArn.Resource, ":")[1]
 		}
-		data["logGroupArn"] = *trail.Trail.CloudWatchLogsLogGroupArn
-		data["logGroupName"] = strings.Split(cloudWatchArn.Resource, ":")[1]
 	}
 	tmpl := template.Must(template.New("template.json").Parse(templateBody))
 	buf := &bytes.Buffer{}
@@ -616,57 +1708,179 @@ func calculateStackTemplate(trailName string, cfg *aws.Config, config i.AWSInput
 	return err, stackCalculatedTemplate
 }
 
-func trailValid(trail *cloudtrail.GetTrailOutput) error {
-	if *trail.Trail.CloudWatchLogsLogGroupArn == "" {
-		return fmt.Errorf("trail doesn't have cloudwatch logs defined")
+// linkTrailLogs sends the events of a trail without cloudwatch logs, e.g. one created by init, to the log group
+// created for it with the stack.
+func linkTrailLogs(ctx context.Context, cfg *aws.Config, trailName string, stackName string) error {
+	trailClient := cloudtrail.NewFromConfig(*cfg)
+	trail, err := trailClient.GetTrail(ctx, &cloudtrail.GetTrailInput{Name: aws.String(trailName)})
+	if err != nil {
+		return err
 	}
-	return nil
+	if aws.ToString(trail.Trail.CloudWatchLogsLogGroupArn) != "" {
+		return nil
+	}
+	trailArn, err := arn.Parse(aws.ToString(trail.Trail.TrailARN))
+	if err != nil {
+		return err
+	}
+	cloudformationClient := cloudformation.NewFromConfig(*cfg)
+	physicalId := func(logicalId string) (string, error) {
+		resource, err := cloudformationClient.DescribeStackResource(ctx, &cloudformation.DescribeStackResourceInput{
+			StackName:         aws.String(stackName),
+			LogicalResourceId: aws.String(logicalId),
+		})
+		if err != nil {
+			return "", err
+		}
+		return aws.ToString(resource.StackResourceDetail.PhysicalResourceId), nil
+	}
+	logGroup, err := physicalId("FunctionClarityLogGroup")
+	if err != nil {
+		return err
+	}
+	role, err := physicalId("FunctionClarityCloudTrailToCloudWatchLogsRole")
+	if err != nil {
+		return err
+	}
+	_, err = trailClient.UpdateTrail(ctx, &cloudtrail.UpdateTrailInput{
+		Name:                      aws.String(trailName),
+		CloudWatchLogsLogGroupArn: aws.String(fmt.Sprintf("arn:%s:logs:%s:%s:log-group:%s:*", trailArn.Partition, cfg.Region, trailArn.AccountID, logGroup)),
+		CloudWatchLogsRoleArn:     aws.String(fmt.Sprintf("arn:%s:iam::%s:role/%s", trailArn.Partition, trailArn.AccountID, role)),
+	})
+	return err
 }
 
 func (o *AwsClient) getConfig() *aws.Config {
-	cfg, err := config.LoadDefaultConfig(context.TODO(),
+	ctx := o.baseContext()
+	cfg, err := config.LoadDefaultConfig(ctx,
 		config.WithRegion(o.region))
 	if o.accessKey != "" && o.secretKey != "" {
-		cfg, err = config.LoadDefaultConfig(context.TODO(),
+		cfg, err = config.LoadDefaultConfig(ctx,
 			config.WithRegion(o.region),
 			config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(o.accessKey, o.secretKey, "")))
 	}
 	if err != nil {
 		panic(fmt.Sprintf("failed loading config, %v", err))
 	}
+	o.withEndpoint(&cfg)
+	o.withRetryer(&cfg)
+	o.withAssumedRole(&cfg)
 	return &cfg
 }
 
+// withEndpoint makes the config resolve the endpoint of the client, if any, for every service.
+func (o *AwsClient) withEndpoint(cfg *aws.Config) {
+	if o.endpoint == "" {
+		return
+	}
+	endpoint := o.endpoint
+	cfg.EndpointResolverWithOptions = aws.EndpointResolverWithOptionsFunc(func(service, region string, options ...interface{}) (aws.Endpoint, error) {
+		return aws.Endpoint{URL: endpoint, SigningRegion: region, HostnameImmutable: true}, nil
+	})
+}
+
+// withRetryer makes the config retry a throttled or failed call up to the client max retries, backing off exponentially
+// with jitter between the attempts.
+func (o *AwsClient) withRetryer(cfg *aws.Config) {
+	maxAttempts := o.maxRetries + 1
+	cfg.Retryer = func() aws.Retryer {
+		return retry.NewStandard(func(options *retry.StandardOptions) {
+			options.MaxAttempts = maxAttempts
+			options.MaxBackoff = maxRetryBackoff
+			options.Backoff = retry.NewExponentialJitterBackoff(maxRetryBackoff)
+		})
+	}
+}
+
+// newS3Client returns an s3 client of the config, addressing the buckets in the url path when the config resolves a
+// custom endpoint since those seldom serve the bucket subdomains.
+func newS3Client(cfg *aws.Config) *s3.Client {
+	return s3.NewFromConfig(*cfg, func(options *s3.Options) {
+		options.UsePathStyle = cfg.EndpointResolverWithOptions != nil
+	})
+}
+
+// withAssumedRole replaces the config credentials with the ones of the client assumed role, if any.
+func (o *AwsClient) withAssumedRole(cfg *aws.Config) {
+	if o.assumeRole == nil {
+		return
+	}
+	role := *o.assumeRole
+	cfg.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(sts.NewFromConfig(*cfg), role.RoleArn, func(options *stscreds.AssumeRoleOptions) {
+		options.RoleSessionName = role.SessionName
+		if role.ExternalId != "" {
+			options.ExternalID = aws.String(role.ExternalId)
+		}
+		if role.Duration > 0 {
+			options.Duration = role.Duration
+		}
+	}))
+}
+
 func (o *AwsClient) getConfigForLambda() *aws.Config {
-	cfg, err := config.LoadDefaultConfig(context.TODO(),
+	ctx := o.baseContext()
+	cfg, err := config.LoadDefaultConfig(ctx,
 		config.WithRegion(o.lambdaRegion))
 	if o.accessKey != "" && o.secretKey != "" {
-		cfg, err = config.LoadDefaultConfig(context.TODO(),
+		cfg, err = config.LoadDefaultConfig(ctx,
 			config.WithRegion(o.lambdaRegion),
 			config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(o.accessKey, o.secretKey, "")))
 	}
 	if err != nil {
 		panic(fmt.Sprintf("failed loading config, %v", err))
 	}
+	o.withEndpoint(&cfg)
+	o.withRetryer(&cfg)
+	o.withAssumedRole(&cfg)
+	if o.roleArn != "" {
+		cfg.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), o.roleArn))
+	}
 	return &cfg
 }
 
-func uploadFuncClarityCode(cfg *aws.Config, keyPath string, bucket string) error {
-	s3Client := s3.NewFromConfig(*cfg)
+// createBucket creates the bucket in the config region, a bucket already owned isn't an error. A bucket created is
+// encrypted by default with the kms key, if any, an existing bucket is left as is.
+func createBucket(ctx context.Context, cfg *aws.Config, bucket string, kmsKeyArn string) error {
+	s3Client := newS3Client(cfg)
 	var err error
 	if cfg.Region != "us-east-1" {
-		_, err = s3Client.CreateBucket(context.TODO(), &s3.CreateBucketInput{
+		_, err = s3Client.CreateBucket(ctx, &s3.CreateBucketInput{
 			Bucket:                    aws.String(bucket),
 			CreateBucketConfiguration: &s3types.CreateBucketConfiguration{LocationConstraint: s3types.BucketLocationConstraint(cfg.Region)},
 		})
 	} else {
-		_, err = s3Client.CreateBucket(context.TODO(), &s3.CreateBucketInput{
+		_, err = s3Client.CreateBucket(ctx, &s3.CreateBucketInput{
 			Bucket: aws.String(bucket),
 		})
 	}
 
 	var bne *s3types.BucketAlreadyOwnedByYou
-	if err != nil && !errors.As(err, &bne) {
+	if errors.As(err, &bne) {
+		return nil
+	}
+	if err != nil || kmsKeyArn == "" {
+		return err
+	}
+	_, err = s3Client.PutBucketEncryption(ctx, &s3.PutBucketEncryptionInput{
+		Bucket: aws.String(bucket),
+		ServerSideEncryptionConfiguration: &s3types.ServerSideEncryptionConfiguration{
+			Rules: []s3types.ServerSideEncryptionRule{{
+				ApplyServerSideEncryptionByDefault: &s3types.ServerSideEncryptionByDefault{
+					SSEAlgorithm:   s3types.ServerSideEncryptionAwsKms,
+					KMSMasterKeyID: aws.String(kmsKeyArn),
+				},
+				BucketKeyEnabled: true,
+			}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encrypt bucket: %s with kms key: %s. %v", bucket, kmsKeyArn, err)
+	}
+	return nil
+}
+
+func uploadFuncClarityCode(ctx context.Context, cfg *aws.Config, keyPath string, tufRootPath string, bucket string, kmsKeyArn string) error {
+	if err := createBucket(ctx, cfg, bucket, kmsKeyArn); err != nil {
 		return err
 	}
 	archive, err := os.Create("function-clarity.zip")
@@ -704,8 +1918,24 @@ func uploadFuncClarityCode(cfg *aws.Config, keyPath string, bucket string) error
 			return err
 		}
 	}
+
+	if tufRootPath != "" {
+		tufRoot, err := os.Open(tufRootPath)
+		if err != nil {
+			return err
+		}
+		defer tufRoot.Close()
+
+		w3, err := zipWriter.Create(TufRootFileName)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(w3, tufRoot); err != nil {
+			return err
+		}
+	}
 	zipWriter.Close()
-	uploader := manager.NewUploader(s3.NewFromConfig(*cfg))
+	uploader := manager.NewUploader(newS3Client(cfg))
 	// Upload the file to S3.
 	//p := mpb.New()
 	file, err := os.Open("function-clarity.zip")
@@ -725,24 +1955,24 @@ func uploadFuncClarityCode(cfg *aws.Config, keyPath string, bucket string) error
 	if err != nil {
 		return err
 	}
-	fmt.Println("Uploading function-clarity function code to s3 bucket, this may take a few minutes")
-	_, err = uploader.Upload(context.TODO(), &s3.PutObjectInput{
+	slog.Info("uploading function-clarity function code to s3 bucket, this may take a few minutes")
+	_, err = uploader.Upload(ctx, encryptedWith(kmsKeyArn, &s3.PutObjectInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String("function-clarity.zip"),
 		Body:   file,
-	})
+	}))
 	if err != nil {
 		return err
 	}
-	fmt.Println("function-clarity function code upload successfully")
+	slog.Info("function-clarity function code uploaded successfully")
 	return nil
 }
 
-func stackExists(stackNameOrID string, cfClient *cloudformation.Client) (bool, error) {
+func stackExists(ctx context.Context, stackNameOrID string, cfClient *cloudformation.Client) (bool, error) {
 	describeStacksInput := &cloudformation.DescribeStacksInput{
 		StackName: aws.String(stackNameOrID),
 	}
-	_, err := cfClient.DescribeStacks(context.TODO(), describeStacksInput)
+	_, err := cfClient.DescribeStacks(ctx, describeStacksInput)
 
 	if err != nil {
 		// If the stack doesn't exist, then no worries
@@ -0,0 +1,437 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdaTypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pagedLambda serves its functions and provisioned concurrency configs in pages of pageSize, the marker is the
+// index of the first item of the next page.
+type pagedLambda struct {
+	functions   []string
+	provisioned []string
+	pageSize    int
+	failOnPage  int
+	calls       int
+}
+
+func (l *pagedLambda) page(marker *string, total int) (int, int, *string, error) {
+	l.calls++
+	if l.failOnPage > 0 && l.calls == l.failOnPage {
+		return 0, 0, nil, errors.New("throttled")
+	}
+	return pageBounds(marker, total, l.pageSize)
+}
+
+func pageBounds(marker *string, total int, pageSize int) (int, int, *string, error) {
+	start := 0
+	if marker != nil {
+		if _, err := fmt.Sscanf(*marker, "%d", &start); err != nil {
+			return 0, 0, nil, err
+		}
+	}
+	end := start + pageSize
+	if end >= total {
+		return start, total, nil, nil
+	}
+	return start, end, aws.String(fmt.Sprint(end)), nil
+}
+
+func (l *pagedLambda) ListFunctions(_ context.Context, input *lambda.ListFunctionsInput, _ ...func(*lambda.Options)) (*lambda.ListFunctionsOutput, error) {
+	start, end, next, err := l.page(input.Marker, len(l.functions))
+	if err != nil {
+		return nil, err
+	}
+	output := &lambda.ListFunctionsOutput{NextMarker: next}
+	for _, name := range l.functions[start:end] {
+		output.Functions = append(output.Functions, lambdaTypes.FunctionConfiguration{FunctionName: aws.String(name)})
+	}
+	return output, nil
+}
+
+func (l *pagedLambda) ListProvisionedConcurrencyConfigs(_ context.Context, input *lambda.ListProvisionedConcurrencyConfigsInput,
+	_ ...func(*lambda.Options)) (*lambda.ListProvisionedConcurrencyConfigsOutput, error) {
+	start, end, next, err := l.page(input.Marker, len(l.provisioned))
+	if err != nil {
+		return nil, err
+	}
+	output := &lambda.ListProvisionedConcurrencyConfigsOutput{NextMarker: next}
+	for _, qualifier := range l.provisioned[start:end] {
+		output.ProvisionedConcurrencyConfigs = append(output.ProvisionedConcurrencyConfigs, lambdaTypes.ProvisionedConcurrencyConfigListItem{
+			FunctionArn:                              aws.String("arn:aws:lambda:us-east-1:123456789012:function:f:" + qualifier),
+			RequestedProvisionedConcurrentExecutions: aws.Int32(1),
+		})
+	}
+	return output, nil
+}
+
+type pagedBucket struct {
+	keys     []string
+	pageSize int
+}
+
+func (b *pagedBucket) ListObjectsV2(_ context.Context, input *s3.ListObjectsV2Input, _ ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	start, end, next, err := pageBounds(input.ContinuationToken, len(b.keys), b.pageSize)
+	if err != nil {
+		return nil, err
+	}
+	output := &s3.ListObjectsV2Output{NextContinuationToken: next, IsTruncated: next != nil}
+	for _, key := range b.keys[start:end] {
+		output.Contents = append(output.Contents, s3types.Object{Key: aws.String(key)})
+	}
+	return output, nil
+}
+
+func names(prefix string, count int) []string {
+	var result []string
+	for i := 0; i < count; i++ {
+		result = append(result, fmt.Sprintf("%s-%d", prefix, i))
+	}
+	return result
+}
+
+func TestListAllFunctionsFollowsEveryPage(t *testing.T) {
+	for _, count := range []int{0, 1, 50, 51, 123} {
+		client := &pagedLambda{functions: names("function", count), pageSize: 50}
+		functions, err := listAllFunctions(context.Background(), client, "us-east-1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(functions) != count {
+			t.Fatalf("expected %d functions, got: %d", count, len(functions))
+		}
+		for i, function := range functions {
+			if function.FunctionName != client.functions[i] || function.Region != "us-east-1" {
+				t.Fatalf("unexpected function at %d: %+v", i, function)
+			}
+		}
+	}
+}
+
+func TestListAllFunctionsFailsOnPageError(t *testing.T) {
+	client := &pagedLambda{functions: names("function", 120), pageSize: 50, failOnPage: 2}
+	functions, err := listAllFunctions(context.Background(), client, "us-east-1")
+	if err == nil {
+		t.Fatalf("expected an error instead of a partial list, got: %d functions", len(functions))
+	}
+}
+
+func TestListProvisionedConcurrencyFollowsEveryPage(t *testing.T) {
+	client := &pagedLambda{provisioned: names("alias", 25), pageSize: 10}
+	provisioned, err := listProvisionedConcurrency(context.Background(), client, "f")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(provisioned) != 25 {
+		t.Fatalf("expected 25 qualifiers, got: %v", provisioned)
+	}
+}
+
+func TestListBucketObjectsFollowsEveryPage(t *testing.T) {
+	bucket := &pagedBucket{keys: names("object", 2500), pageSize: 1000}
+	objects, err := listBucketObjects(context.Background(), bucket, "bucket")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(objects) != 2500 || objects[2499].Key != "object-2499" {
+		t.Fatalf("expected 2500 objects, got: %d", len(objects))
+	}
+}
+
+func TestNewAwsClientWithRoleDefaultsSessionName(t *testing.T) {
+	base := NewAwsClientInit("access", "secret", "us-east-1")
+	client := NewAwsClientWithRole(base, AssumeRole{RoleArn: "arn:aws:iam::123456789012:role/deployer", ExternalId: "external"})
+	if base.assumeRole != nil {
+		t.Fatalf("the base client was modified")
+	}
+	if client.assumeRole.SessionName != DefaultAssumeRoleSessionName {
+		t.Fatalf("expected session name: %s, got: %s", DefaultAssumeRoleSessionName, client.assumeRole.SessionName)
+	}
+	if client.accessKey != "access" || client.region != "us-east-1" {
+		t.Fatalf("expected the base client credentials and region, got: %+v", client)
+	}
+}
+
+func TestIsFuncInRegionsMatchesGlobs(t *testing.T) {
+	client := NewAwsClient("", "", "", "us-east-1", "eu-west-3")
+	for _, tc := range []struct {
+		regions []string
+		in      bool
+	}{
+		{regions: []string{"eu-west-3"}, in: true},
+		{regions: []string{"eu-*"}, in: true},
+		{regions: []string{"us-east-1", "eu-west-?"}, in: true},
+		{regions: []string{"eu-west"}},
+		{regions: []string{"us-*", "ap-*"}},
+	} {
+		if in := client.IsFuncInRegions(tc.regions); in != tc.in {
+			t.Errorf("IsFuncInRegions(%v) = %t, expected %t", tc.regions, in, tc.in)
+		}
+	}
+}
+
+func TestTrailBucketPolicyKeepsExistingStatements(t *testing.T) {
+	existing := `{"Version":"2012-10-17","Statement":[{"Sid":"Keep","Effect":"Deny","Principal":"*","Action":"s3:DeleteBucket","Resource":"arn:aws:s3:::signatures"},` +
+		`{"Sid":"FunctionClarityTrailWrite","Effect":"Allow","Principal":{"Service":"cloudtrail.amazonaws.com"},"Action":"s3:PutObject","Resource":"arn:aws:s3:::signatures/AWSLogs/000000000000/*"}]}`
+	for _, current := range []string{"", existing} {
+		policy, err := trailBucketPolicy(current, "aws", "signatures", "123456789012")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var parsed struct {
+			Statement []struct {
+				Sid      string
+				Resource string
+			}
+		}
+		if err = json.Unmarshal([]byte(policy), &parsed); err != nil {
+			t.Fatalf("invalid policy: %s, %v", policy, err)
+		}
+		var sids []string
+		for _, statement := range parsed.Statement {
+			sids = append(sids, statement.Sid)
+			if statement.Sid == trailWriteSid && statement.Resource != "arn:aws:s3:::signatures/AWSLogs/123456789012/*" {
+				t.Errorf("unexpected write resource: %s", statement.Resource)
+			}
+		}
+		expected := []string{trailAclCheckSid, trailWriteSid}
+		if current != "" {
+			expected = append([]string{"Keep"}, expected...)
+		}
+		if fmt.Sprint(sids) != fmt.Sprint(expected) {
+			t.Errorf("existing policy: %q, expected statements: %v, got: %v", current, expected, sids)
+		}
+	}
+}
+
+func TestSignatureLifecycleRulesKeepsOtherRules(t *testing.T) {
+	other := s3types.LifecycleRule{ID: aws.String("logs"), Status: s3types.ExpirationStatusEnabled}
+	previous := s3types.LifecycleRule{ID: aws.String(signatureRetentionRuleId), Expiration: &s3types.LifecycleExpiration{Days: 7}}
+	rules := signatureLifecycleRules([]s3types.LifecycleRule{other, previous}, 30)
+	if len(rules) != 2 || aws.ToString(rules[0].ID) != "logs" {
+		t.Fatalf("expected the other rule and the signature rule, got: %+v", rules)
+	}
+	if rules[1].Expiration.Days != 30 {
+		t.Fatalf("expected the signatures to expire after 30 days, got: %d", rules[1].Expiration.Days)
+	}
+	filter, ok := rules[1].Filter.(*s3types.LifecycleRuleFilterMemberTag)
+	if !ok || aws.ToString(filter.Value.Key) != signatureTagKey {
+		t.Fatalf("expected the rule to target the signature tag, got: %+v", rules[1].Filter)
+	}
+	if rules = signatureLifecycleRules([]s3types.LifecycleRule{other, previous}, 0); len(rules) != 1 || aws.ToString(rules[0].ID) != "logs" {
+		t.Fatalf("expected the signature rule to be removed, got: %+v", rules)
+	}
+}
+
+func TestTaggedSignatureSkipsOtherObjects(t *testing.T) {
+	for key, tagged := range map[string]bool{
+		"abc.sig":                 true,
+		"abc.key.sig":             true,
+		"abc.crt.base64":          true,
+		"abc.metadata.json":       true,
+		"abc.pin.json":            false,
+		"function-clarity.zip":    false,
+		"archive/abc.keyless.sig": true,
+	} {
+		input := taggedSignature(&s3.PutObjectInput{Key: aws.String(key)})
+		if (input.Tagging != nil) != tagged {
+			t.Errorf("key: %s, expected tagged: %t, got tagging: %v", key, tagged, aws.ToString(input.Tagging))
+		}
+	}
+}
+
+func TestNewAwsClientInitWithEndpointResolvesEveryService(t *testing.T) {
+	cfg := NewAwsClientInitWithEndpoint("access", "secret", "us-east-1", "http://localhost:4566").getConfig()
+	for _, service := range []string{s3.ServiceID, lambda.ServiceID} {
+		endpoint, err := cfg.EndpointResolverWithOptions.ResolveEndpoint(service, "us-east-1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if endpoint.URL != "http://localhost:4566" || endpoint.SigningRegion != "us-east-1" {
+			t.Fatalf("service: %s, unexpected endpoint: %+v", service, endpoint)
+		}
+	}
+	if cfg := NewAwsClientInit("access", "secret", "us-east-1").getConfig(); cfg.EndpointResolverWithOptions != nil {
+		t.Fatalf("expected the aws endpoints without an endpoint url")
+	}
+}
+
+func TestRetryThrottledCall(t *testing.T) {
+	maxRetryBackoff = time.Millisecond
+	for _, tc := range []struct {
+		maxRetries int
+		exists     bool
+	}{
+		{maxRetries: 2, exists: true},
+		{maxRetries: 1, exists: false},
+	} {
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls <= 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		client := NewAwsClientInitWithEndpoint("access", "secret", "us-east-1", server.URL).WithMaxRetries(tc.maxRetries)
+		if exists, err := client.IsBucketExist("signatures"); err != nil || exists != tc.exists {
+			t.Errorf("max retries: %d, expected bucket exists: %t, got: %t, %v", tc.maxRetries, tc.exists, exists, err)
+		}
+		if expected := tc.maxRetries + 1; calls != expected {
+			t.Errorf("max retries: %d, expected %d calls, got: %d", tc.maxRetries, expected, calls)
+		}
+		server.Close()
+	}
+}
+
+func TestOperationTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+	client := NewAwsClientInitWithEndpoint("access", "secret", "us-east-1", server.URL).WithTimeout(50 * time.Millisecond)
+
+	exists, err := client.IsBucketExist("signatures")
+	if exists || !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "check bucket: signatures timed out after 50ms") {
+		t.Fatalf("expected the bucket check to time out, got: %t, %v", exists, err)
+	}
+	if _, err = client.IsSnsTopicExist("arn:aws:sns:us-east-1:123456789012:alerts"); err == nil || !strings.Contains(err.Error(), "check sns topic") {
+		t.Fatalf("expected the topic check to time out, got: %v", err)
+	}
+	err = client.ValidateCredentials(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "validate credentials timed out") {
+		t.Fatalf("expected the credentials validation to time out, got: %v", err)
+	}
+
+	// other errors mean the resource doesn't exist
+	notFound := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer notFound.Close()
+	client = NewAwsClientInitWithEndpoint("access", "secret", "us-east-1", notFound.URL).WithTimeout(time.Second)
+	if exists, err = client.IsBucketExist("signatures"); exists || err != nil {
+		t.Fatalf("expected a missing bucket without error, got: %t, %v", exists, err)
+	}
+}
+
+func TestCancelledContext(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// no timeout, only the cancellation of the context aborts the calls
+	client := NewAwsClientInitWithEndpoint("access", "secret", "us-east-1", server.URL).WithTimeout(0).WithContext(ctx)
+	client.s3 = "signatures"
+	client.lambdaRegion = "us-east-1"
+	defer os.Remove("/tmp/cancelled-identity.sig")
+
+	for name, call := range map[string]func() error{
+		"IsBucketExist": func() error {
+			_, err := client.IsBucketExist("signatures")
+			return err
+		},
+		"ResolvePackageType": func() error {
+			_, err := client.ResolvePackageType("function")
+			return err
+		},
+		"GetFuncCode": func() error {
+			_, err := client.GetFuncCode("function")
+			return err
+		},
+		"Download": func() error {
+			return client.Download("cancelled-identity", "sig")
+		},
+		"Notify": func() error {
+			return client.Notify("message", "arn:aws:sns:us-east-1:123456789012:alerts")
+		},
+		"ValidateCredentials": func() error {
+			return NewAwsClientInitWithEndpoint("access", "secret", "us-east-1", server.URL).WithTimeout(0).ValidateCredentials(ctx)
+		},
+	} {
+		start := time.Now()
+		err := call()
+		if err == nil {
+			t.Errorf("%s: expected a cancelled context to fail the call", name)
+		} else if !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: expected a context canceled error, got: %v", name, err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("%s: expected the call to abort promptly, took: %s", name, elapsed)
+		}
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), struct{}{}, "scan")
+	bound := WithContext(NewAwsClient("access", "secret", "signatures", "us-east-1", "us-east-1"), ctx)
+	if awsClient, ok := bound.(*AwsClient); !ok || awsClient.baseContext() != ctx {
+		t.Fatalf("expected the aws client to be bound to the context")
+	}
+	if NewAwsClient("access", "secret", "signatures", "us-east-1", "us-east-1").baseContext() != context.Background() {
+		t.Fatalf("expected an unbound client to use the background context")
+	}
+}
+
+func TestVerifierInvocations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("Action") != "GetMetricStatistics" ||
+			r.Form.Get("Dimensions.member.1.Value") != FunctionClarityLambdaVerierName || r.Form.Get("Period") != "86400" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if !strings.Contains(r.Header.Get("Authorization"), "/us-east-1/monitoring/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, `<GetMetricStatisticsResponse><GetMetricStatisticsResult><Label>Invocations</Label><Datapoints>`+
+			`<member><Sum>3.0</Sum><Unit>Count</Unit></member><member><Sum>4.0</Sum><Unit>Count</Unit></member>`+
+			`</Datapoints></GetMetricStatisticsResult></GetMetricStatisticsResponse>`)
+	}))
+	defer server.Close()
+	client := NewAwsClientInitWithEndpoint("access", "secret", "us-east-1", server.URL)
+	invocations, err := client.VerifierInvocations(context.Background(), time.Now().Add(-24*time.Hour))
+	if err != nil || invocations != 7 {
+		t.Fatalf("expected 7 invocations, got: %d, %v", invocations, err)
+	}
+}
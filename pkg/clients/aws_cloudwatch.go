@@ -0,0 +1,105 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// cloudWatchTimeout bounds the cloudwatch calls, made outside of the sdk retryer.
+const cloudWatchTimeout = 30 * time.Second
+
+// getMetricStatisticsResponse is the part of the GetMetricStatistics query api response read.
+type getMetricStatisticsResponse struct {
+	Datapoints []struct {
+		Sum float64 `xml:"Sum"`
+	} `xml:"GetMetricStatisticsResult>Datapoints>member"`
+}
+
+// VerifierInvocations returns the number of invocations of the verifier function deployed in the client region since
+// the given time, from its cloudwatch Invocations metric. The cloudwatch query api is called directly, signed with the
+// client credentials, the module doesn't depend on the cloudwatch sdk.
+func (o *AwsClient) VerifierInvocations(ctx context.Context, since time.Time) (int, error) {
+	end := time.Now().UTC()
+	period := end.Sub(since).Round(time.Minute)
+	if period < time.Minute {
+		period = time.Minute
+	}
+	form := url.Values{
+		"Action":                    {"GetMetricStatistics"},
+		"Version":                   {"2010-08-01"},
+		"Namespace":                 {"AWS/Lambda"},
+		"MetricName":                {"Invocations"},
+		"Dimensions.member.1.Name":  {"FunctionName"},
+		"Dimensions.member.1.Value": {FunctionClarityLambdaVerierName},
+		"StartTime":                 {end.Add(-period).Format(time.RFC3339)},
+		"EndTime":                   {end.Format(time.RFC3339)},
+		"Period":                    {fmt.Sprint(int(period.Seconds()))},
+		"Statistics.member.1":       {"Sum"},
+	}
+	endpoint := o.endpoint
+	if endpoint == "" {
+		endpoint = "https://monitoring." + o.region + ".amazonaws.com"
+	}
+	body := form.Encode()
+	ctx, cancel := context.WithTimeout(ctx, cloudWatchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/", strings.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	cfg := o.getConfig()
+	credentials, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve aws credentials: %w", err)
+	}
+	payloadHash := sha256.Sum256([]byte(body))
+	if err = v4.NewSigner().SignHTTP(ctx, credentials, req, hex.EncodeToString(payloadHash[:]), "monitoring", o.region, time.Now()); err != nil {
+		return 0, fmt.Errorf("failed to sign cloudwatch request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get invocations of verifier function: %w", err)
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read invocations of verifier function: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to get invocations of verifier function: %s: %s", resp.Status, strings.TrimSpace(string(content)))
+	}
+	var statistics getMetricStatisticsResponse
+	if err = xml.Unmarshal(content, &statistics); err != nil {
+		return 0, fmt.Errorf("invalid cloudwatch response: %w", err)
+	}
+	invocations := 0.0
+	for _, datapoint := range statistics.Datapoints {
+		invocations += datapoint.Sum
+	}
+	return int(invocations), nil
+}
@@ -0,0 +1,409 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/google/uuid"
+	"github.com/openclarity/function-clarity/pkg/metadata"
+	"github.com/openclarity/function-clarity/pkg/utils"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	azureManagementResource = "https://management.azure.com/"
+	azureStorageResource    = "https://storage.azure.com/"
+	azureManagementEndpoint = "https://management.azure.com"
+	azureWebApiVersion      = "2022-03-01"
+	azureStorageApiVersion  = "2021-08-06"
+	// azurePackageSetting is the app setting of a function app running from a package, the package url when it is
+	// deployed from a blob.
+	azurePackageSetting = "WEBSITE_RUN_FROM_PACKAGE"
+	azureImagePrefix    = "DOCKER|"
+)
+
+// ErrBlobNotFound is the error of a blob missing from the container.
+var ErrBlobNotFound = errors.New("azure: blob doesn't exist")
+
+// AzureClient signs and verifies the packages of azure function apps with a service principal. The signatures are
+// stored in a blob container of the storage account, and a function is identified by the resource id of its app,
+// i.e: /subscriptions/<subscription>/resourceGroups/<group>/providers/Microsoft.Web/sites/<app>.
+type AzureClient struct {
+	tenantId           string
+	clientId           string
+	clientSecret       string
+	container          string
+	blobEndpoint       string
+	managementEndpoint string
+	httpClient         *http.Client
+	tokenMutex         sync.Mutex
+	tokens             map[string]*adal.ServicePrincipalToken
+	// token returns an access token for the resource, the service principal token by default.
+	token func(resource string) (string, error)
+}
+
+func NewAzureClientInit(tenantId string, clientId string, clientSecret string, storageAccount string, container string) *AzureClient {
+	p := &AzureClient{
+		tenantId:           tenantId,
+		clientId:           clientId,
+		clientSecret:       clientSecret,
+		container:          container,
+		blobEndpoint:       "https://" + storageAccount + ".blob.core.windows.net",
+		managementEndpoint: azureManagementEndpoint,
+		httpClient:         &http.Client{Timeout: time.Minute},
+		tokens:             map[string]*adal.ServicePrincipalToken{},
+	}
+	p.token = p.servicePrincipalToken
+	return p
+}
+
+func (p *AzureClient) servicePrincipalToken(resource string) (string, error) {
+	p.tokenMutex.Lock()
+	defer p.tokenMutex.Unlock()
+	token, ok := p.tokens[resource]
+	if !ok {
+		config := auth.NewClientCredentialsConfig(p.clientId, p.clientSecret, p.tenantId)
+		config.Resource = resource
+		var err error
+		if token, err = config.ServicePrincipalToken(); err != nil {
+			return "", fmt.Errorf("failed to create service principal token: %w", err)
+		}
+		p.tokens[resource] = token
+	}
+	if err := token.EnsureFresh(); err != nil {
+		return "", fmt.Errorf("failed to get a token for the service principal: %w", err)
+	}
+	return token.OAuthToken(), nil
+}
+
+// ValidateCredentials checks the service principal gets tokens for the management and storage apis.
+func (p *AzureClient) ValidateCredentials(ctx context.Context) error {
+	for _, resource := range []string{azureManagementResource, azureStorageResource} {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := p.token(resource); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// do sends the request with a token for the resource, the response body is closed on a non 2xx status.
+func (p *AzureClient) do(method string, url string, resource string, body []byte, headers map[string]string) (*http.Response, error) {
+	token, err := p.token(resource)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if resource == azureStorageResource {
+		req.Header.Set("x-ms-version", azureStorageApiVersion)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	content, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode == http.StatusNotFound && resource == azureStorageResource {
+		return resp, fmt.Errorf("%s: %w", url, ErrBlobNotFound)
+	}
+	return resp, fmt.Errorf("%s %s: unexpected status: %s: %s", method, url, resp.Status, strings.TrimSpace(string(content)))
+}
+
+func (p *AzureClient) blobURL(name string) string {
+	return p.blobEndpoint + "/" + p.container + "/" + name
+}
+
+// IsStorageExist tells whether the container exists in the storage account and the service principal may access it.
+func (p *AzureClient) IsStorageExist(name string) bool {
+	resp, err := p.do(http.MethodHead, p.blobEndpoint+"/"+name+"?restype=container", azureStorageResource, nil, nil)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return true
+}
+
+// CreateStorage creates a private container in the storage account, an existing container is kept.
+func (p *AzureClient) CreateStorage(name string) error {
+	resp, err := p.do(http.MethodPut, p.blobEndpoint+"/"+name+"?restype=container", azureStorageResource, nil, nil)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusConflict {
+			return nil
+		}
+		return fmt.Errorf("failed to create container %s: %w", name, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (p *AzureClient) putBlob(name string, content []byte) error {
+	resp, err := p.do(http.MethodPut, p.blobURL(name), azureStorageResource, content, map[string]string{"x-ms-blob-type": "BlockBlob"})
+	if err != nil {
+		return fmt.Errorf("failed to upload blob %s: %w", name, err)
+	}
+	return resp.Body.Close()
+}
+
+func (p *AzureClient) Upload(signature string, identity string, isKeyless bool) error {
+	if err := p.putBlob(identity+".sig", []byte(signature)); err != nil {
+		return err
+	}
+	if isKeyless {
+		certificate, err := os.ReadFile("/tmp/" + identity + ".crt.base64")
+		if err != nil {
+			return err
+		}
+		if err := p.putBlob(identity+".crt.base64", certificate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *AzureClient) UploadFile(content string, fileName string, outputType string) error {
+	return p.putBlob(fileName+"."+outputType, []byte(content))
+}
+
+func (p *AzureClient) Download(fileName string, outputType string) error {
+	objectName := fileName + "." + outputType
+	resp, err := p.do(http.MethodGet, p.blobURL(objectName), azureStorageResource, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	outputFile := "/tmp/" + objectName
+	f, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (p *AzureClient) GetSignatureTimestamp(identity string) (time.Time, error) {
+	resp, err := p.do(http.MethodHead, p.blobURL(identity+".sig"), azureStorageResource, nil, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	resp.Body.Close()
+	return http.ParseTime(resp.Header.Get("Last-Modified"))
+}
+
+// getFunctionApp decodes the properties of the function app resource under path, i.e: config/web.
+func (p *AzureClient) getFunctionApp(method string, funcIdentifier string, path string, properties interface{}) error {
+	url := p.managementEndpoint + funcIdentifier + "/" + path + "?api-version=" + azureWebApiVersion
+	resp, err := p.do(method, url, azureManagementResource, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get function app: %s: %w", funcIdentifier, err)
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(&struct {
+		Properties interface{} `json:"properties"`
+	}{Properties: properties})
+}
+
+func (p *AzureClient) linuxFxVersion(funcIdentifier string) (string, error) {
+	var config struct {
+		LinuxFxVersion string `json:"linuxFxVersion"`
+	}
+	if err := p.getFunctionApp(http.MethodGet, funcIdentifier, "config/web", &config); err != nil {
+		return "", err
+	}
+	return config.LinuxFxVersion, nil
+}
+
+// ResolvePackageType returns Image for the function apps running a custom container, Zip for the others.
+func (p *AzureClient) ResolvePackageType(funcIdentifier string) (string, error) {
+	linuxFxVersion, err := p.linuxFxVersion(funcIdentifier)
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(strings.ToUpper(linuxFxVersion), azureImagePrefix) {
+		return "Image", nil
+	}
+	return "Zip", nil
+}
+
+// packageURL returns the url of the package the function app runs from. Only packages deployed from a url can be
+// downloaded, the ones deployed to the app storage with WEBSITE_RUN_FROM_PACKAGE=1 can't.
+func (p *AzureClient) packageURL(funcIdentifier string) (string, error) {
+	settings := map[string]string{}
+	if err := p.getFunctionApp(http.MethodPost, funcIdentifier, "config/appsettings/list", &settings); err != nil {
+		return "", err
+	}
+	url := settings[azurePackageSetting]
+	if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		return "", fmt.Errorf("function app: %s doesn't run from a package url, set %s to the url of its package", funcIdentifier, azurePackageSetting)
+	}
+	return url, nil
+}
+
+func (p *AzureClient) downloadPackage(funcIdentifier string) (string, error) {
+	url, err := p.packageURL(funcIdentifier)
+	if err != nil {
+		return "", err
+	}
+	zipFileName := uuid.New().String() + ".zip"
+	if err := utils.DownloadFile(zipFileName, &url); err != nil {
+		return "", err
+	}
+	return zipFileName, nil
+}
+
+func (p *AzureClient) GetFuncCode(funcIdentifier string) (string, error) {
+	zipFileName, err := p.downloadPackage(funcIdentifier)
+	if err != nil {
+		return "", err
+	}
+	contentName := strings.TrimSuffix(zipFileName, ".zip")
+	if err := utils.ExtractZip("/tmp/"+zipFileName, "/tmp/"+contentName); err != nil {
+		return "", err
+	}
+	return "/tmp/" + contentName, nil
+}
+
+// GetFuncCodeSha256 returns the base64 sha256 of the function app package, the same encoding lambda uses.
+func (p *AzureClient) GetFuncCodeSha256(funcIdentifier string) (string, error) {
+	zipFileName, err := p.downloadPackage(funcIdentifier)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove("/tmp/" + zipFileName)
+
+	f, err := os.Open("/tmp/" + zipFileName)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
+
+func (p *AzureClient) GetFuncImageURI(funcIdentifier string) (string, error) {
+	linuxFxVersion, err := p.linuxFxVersion(funcIdentifier)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(strings.ToUpper(linuxFxVersion), azureImagePrefix) {
+		return "", fmt.Errorf("function app: %s doesn't run a custom container", funcIdentifier)
+	}
+	return linuxFxVersion[len(azureImagePrefix):], nil
+}
+
+// GetFuncResolvedImageURI returns the image uri of the function app as is, the digest it runs isn't resolved.
+func (p *AzureClient) GetFuncResolvedImageURI(funcIdentifier string) (string, error) {
+	return p.GetFuncImageURI(funcIdentifier)
+}
+
+// GetFuncArchitecture returns an empty architecture, function app containers run on the default platform.
+func (p *AzureClient) GetFuncArchitecture(funcIdentifier string) (string, error) {
+	return "", nil
+}
+
+func (p *AzureClient) GetFuncLastModified(funcIdentifier string) (time.Time, error) {
+	return time.Time{}, errNotSupported("reading the last modified time of a function", "azure")
+}
+
+// IsFuncInRegions is always false, function apps aren't filtered by region.
+func (p *AzureClient) IsFuncInRegions(regions []string) bool {
+	return false
+}
+
+func (p *AzureClient) FuncContainsTags(funcIdentifier string, tagKes []string) (bool, error) {
+	return false, errNotSupported("filtering functions by tag", "azure")
+}
+
+func (p *AzureClient) GetFuncTags(funcIdentifier string) (map[string]string, error) {
+	return nil, errNotSupported("reading function tags", "azure")
+}
+
+func (p *AzureClient) GetFuncConcurrency(funcIdentifier string) (*metadata.ConcurrencyConfig, error) {
+	return nil, errNotSupported("reading function concurrency", "azure")
+}
+
+func (p *AzureClient) GetFuncLayers(funcIdentifier string) (*metadata.LayersConfig, error) {
+	return nil, errNotSupported("reading function layers", "azure")
+}
+
+func (p *AzureClient) GetLayerCode(layerArn string) (string, error) {
+	return "", errNotSupported("downloading layer code", "azure")
+}
+
+func (p *AzureClient) GetFuncCodeSigningConfig(funcIdentifier string) (*CodeSigningConfig, error) {
+	return nil, errNotSupported("reading the code signing config of a function", "azure")
+}
+
+func (p *AzureClient) HandleBlock(funcIdentifier *string, failed bool) error {
+	return errNotSupported("the block action", "azure")
+}
+
+func (p *AzureClient) HandleDetect(funcIdentifier *string, failed bool) error {
+	return errNotSupported("the detect action", "azure")
+}
+
+func (p *AzureClient) Notify(msg string, snsArn string) error {
+	return errNotSupported("sns notifications", "azure")
+}
+
+func (p *AzureClient) SendQueueMessages(queueUrl string, messages []QueueMessage) ([]QueueMessageFailure, error) {
+	return nil, errNotSupported("result queues", "azure")
+}
+
+func (p *AzureClient) FillNotificationDetails(notification *Notification, functionIdentifier string) error {
+	return errNotSupported("notification details", "azure")
+}
+
+func (p *AzureClient) ListAllFunctions(ctx context.Context) ([]FunctionConfig, error) {
+	return nil, errNotSupported("listing functions", "azure")
+}
+
+func (p *AzureClient) PutParameter(region string, name string, value string, tier string) error {
+	return errNotSupported("parameter store", "azure")
+}
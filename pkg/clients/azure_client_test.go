@@ -0,0 +1,139 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeAzure serves the blobs of a container and the config of a function app, requests without the token fail.
+type fakeAzure struct {
+	blobs          map[string]string
+	linuxFxVersion string
+	packageSetting string
+}
+
+func (f *fakeAzure) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/config/web"):
+		_, _ = io.WriteString(w, `{"properties":{"linuxFxVersion":"`+f.linuxFxVersion+`"}}`)
+	case strings.HasSuffix(r.URL.Path, "/config/appsettings/list") && r.Method == http.MethodPost:
+		_, _ = io.WriteString(w, `{"properties":{"WEBSITE_RUN_FROM_PACKAGE":"`+f.packageSetting+`"}}`)
+	case strings.HasPrefix(r.URL.Path, "/signatures/"):
+		name := strings.TrimPrefix(r.URL.Path, "/signatures/")
+		switch r.Method {
+		case http.MethodPut:
+			if r.Header.Get("x-ms-blob-type") != "BlockBlob" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			content, _ := io.ReadAll(r.Body)
+			f.blobs[name] = string(content)
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodGet, http.MethodHead:
+			content, ok := f.blobs[name]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Last-Modified", time.Date(2022, 11, 1, 10, 0, 0, 0, time.UTC).Format(http.TimeFormat))
+			_, _ = io.WriteString(w, content)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newFakeAzureClient(t *testing.T, fake *fakeAzure) *AzureClient {
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	client := NewAzureClientInit("tenant", "client", "secret", "account", "signatures")
+	client.blobEndpoint = server.URL
+	client.managementEndpoint = server.URL
+	client.token = func(resource string) (string, error) {
+		return "token", nil
+	}
+	return client
+}
+
+func TestAzureClientSignatureStore(t *testing.T) {
+	fake := &fakeAzure{blobs: map[string]string{}}
+	client := newFakeAzureClient(t, fake)
+	if err := client.Upload("signature", "identity", false); err != nil {
+		t.Fatalf("failed to upload: %v", err)
+	}
+	if fake.blobs["identity.sig"] != "signature" {
+		t.Fatalf("expected the signature to be uploaded, got: %v", fake.blobs)
+	}
+	if err := client.Download("identity", "sig"); err != nil {
+		t.Fatalf("failed to download: %v", err)
+	}
+	defer os.Remove("/tmp/identity.sig")
+	content, err := os.ReadFile("/tmp/identity.sig")
+	if err != nil || string(content) != "signature" {
+		t.Fatalf("expected the downloaded signature, got: %s, %v", content, err)
+	}
+	signedAt, err := client.GetSignatureTimestamp("identity")
+	if err != nil || !signedAt.Equal(time.Date(2022, 11, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the last modified time of the signature, got: %v, %v", signedAt, err)
+	}
+	err = client.Download("missing", "sig")
+	if err == nil || !IsObjectNotFound(err) {
+		t.Fatalf("expected a missing blob to be not found, got: %v", err)
+	}
+}
+
+func TestAzureClientResolvePackageType(t *testing.T) {
+	fake := &fakeAzure{linuxFxVersion: "DOCKER|registry.azurecr.io/app:v1"}
+	client := newFakeAzureClient(t, fake)
+	const app = "/subscriptions/s/resourceGroups/g/providers/Microsoft.Web/sites/app"
+	packageType, err := client.ResolvePackageType(app)
+	if err != nil || packageType != "Image" {
+		t.Fatalf("expected an image function app, got: %s, %v", packageType, err)
+	}
+	image, err := client.GetFuncImageURI(app)
+	if err != nil || image != "registry.azurecr.io/app:v1" {
+		t.Fatalf("expected the image of the function app, got: %s, %v", image, err)
+	}
+	fake.linuxFxVersion = "DOTNET|6.0"
+	if packageType, err = client.ResolvePackageType(app); err != nil || packageType != "Zip" {
+		t.Fatalf("expected a zip function app, got: %s, %v", packageType, err)
+	}
+}
+
+func TestAzureClientPackageURL(t *testing.T) {
+	fake := &fakeAzure{packageSetting: "https://account.blob.core.windows.net/packages/app.zip"}
+	client := newFakeAzureClient(t, fake)
+	const app = "/subscriptions/s/resourceGroups/g/providers/Microsoft.Web/sites/app"
+	url, err := client.packageURL(app)
+	if err != nil || url != fake.packageSetting {
+		t.Fatalf("expected the package url, got: %s, %v", url, err)
+	}
+	fake.packageSetting = "1"
+	if _, err = client.packageURL(app); err == nil {
+		t.Fatalf("expected a package deployed to the app storage to fail")
+	}
+}
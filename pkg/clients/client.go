@@ -15,26 +15,117 @@
 
 package clients
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/openclarity/function-clarity/pkg/metadata"
+	"strings"
+	"time"
+)
+
 type Notification struct {
 	AccountId          string
 	FunctionName       string
 	FunctionIdentifier string
 	Action             string
 	Region             string
+	Reason             string
+	Signer             string `json:",omitempty"`
+	Digest             string `json:",omitempty"`
+	ScanId             string `json:",omitempty"`
+	DeduplicationKey   string `json:",omitempty"`
+	// DryRun is set when the block action only ran dry, the function wasn't blocked.
+	DryRun bool `json:",omitempty"`
+}
+
+const CodeSigningPolicyEnforce = "Enforce"
+
+// CodeSigningConfig is the AWS native code signing config attached to a function. UntrustedArtifactOnDeployment is
+// Enforce when lambda rejects deployments of code not signed by an allowed publisher, and Warn when it only logs them.
+type CodeSigningConfig struct {
+	Arn                           string
+	UntrustedArtifactOnDeployment string
+	AllowedPublishers             []string
+}
+
+// QueueMessage is an entry of a batch sent to an sqs queue, the id is unique within the batch. The deduplication
+// and group ids are only set for fifo queues.
+type QueueMessage struct {
+	Id              string
+	Body            string
+	Attributes      map[string]string
+	DeduplicationId string
+	GroupId         string
+}
+
+// QueueMessageFailure is an entry of a batch the queue didn't accept. Entries failing because of the sender, e.g. a
+// message too large, fail again when retried.
+type QueueMessageFailure struct {
+	Id          string
+	Code        string
+	Message     string
+	SenderFault bool
 }
 
 const ConfigEnvVariableName = "CONFIGURATION"
 
+// SignatureStore holds the signatures, certificates and metadata next to the identity they are for, in an s3 or gcs
+// bucket, or an azure blob container. Download writes the file to /tmp/<fileName>.<outputType>, a missing file is an
+// error IsObjectNotFound tells apart.
+type SignatureStore interface {
+	Upload(signature string, identity string, isKeyless bool) error
+	Download(fileName string, outputType string) error
+	GetSignatureTimestamp(identity string) (time.Time, error)
+	UploadFile(content string, fileName string, outputType string) error
+}
+
+// IsObjectNotFound tells whether the error of a SignatureStore call is for a missing file, whichever the store.
+func IsObjectNotFound(err error) bool {
+	var nsk *s3types.NoSuchKey
+	return errors.As(err, &nsk) || errors.Is(err, ErrBlobNotFound) || strings.Contains(err.Error(), "storage: object doesn't exist")
+}
+
+// errNotSupported is the error of a Client method the cloud doesn't implement.
+func errNotSupported(operation string, cloud string) error {
+	return fmt.Errorf("%s is not supported on %s", operation, cloud)
+}
+
+// WithContext returns the client making its calls with the context, so cancelling it aborts them, when the client
+// supports one as AwsClient does. Other clients are returned as is.
+func WithContext(client Client, ctx context.Context) Client {
+	if awsClient, ok := client.(*AwsClient); ok && ctx != nil {
+		return awsClient.WithContext(ctx)
+	}
+	return client
+}
+
 type Client interface {
 	ResolvePackageType(funcIdentifier string) (string, error)
 	GetFuncCode(funcIdentifier string) (string, error)
 	GetFuncImageURI(funcIdentifier string) (string, error)
+	// GetFuncResolvedImageURI returns the image uri of an image function pinned to the digest it runs, whether it was
+	// deployed with a tag or a digest.
+	GetFuncResolvedImageURI(funcIdentifier string) (string, error)
+	GetFuncCodeSha256(funcIdentifier string) (string, error)
+	GetFuncArchitecture(funcIdentifier string) (string, error)
+	GetFuncLastModified(funcIdentifier string) (time.Time, error)
 	IsFuncInRegions(regions []string) bool
 	FuncContainsTags(funcIdentifier string, tagKes []string) (bool, error)
-	Upload(signature string, identity string, isKeyless bool) error
-	Download(fileName string, outputType string) error
+	GetFuncTags(funcIdentifier string) (map[string]string, error)
+	SignatureStore
+	GetFuncConcurrency(funcIdentifier string) (*metadata.ConcurrencyConfig, error)
+	GetFuncLayers(funcIdentifier string) (*metadata.LayersConfig, error)
+	// GetLayerCode downloads and extracts the content of the layer version, returning the path of the extracted
+	// content like GetFuncCode.
+	GetLayerCode(layerArn string) (string, error)
+	GetFuncCodeSigningConfig(funcIdentifier string) (*CodeSigningConfig, error)
 	HandleBlock(funcIdentifier *string, failed bool) error
 	HandleDetect(funcIdentifier *string, failed bool) error
 	Notify(msg string, snsArn string) error
+	SendQueueMessages(queueUrl string, messages []QueueMessage) ([]QueueMessageFailure, error)
 	FillNotificationDetails(notification *Notification, functionIdentifier string) error
+	ListAllFunctions(ctx context.Context) ([]FunctionConfig, error)
+	PutParameter(region string, name string, value string, tier string) error
 }
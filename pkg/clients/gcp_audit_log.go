@@ -0,0 +1,69 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// GCPFunctionDeployMethods are the audit log methods deploying a function code, the other methods don't change
+// what the function runs.
+var GCPFunctionDeployMethods = []string{
+	"google.cloud.functions.v1.CloudFunctionsService.CreateFunction",
+	"google.cloud.functions.v1.CloudFunctionsService.UpdateFunction",
+	"google.cloud.functions.v2.FunctionService.CreateFunction",
+	"google.cloud.functions.v2.FunctionService.UpdateFunction",
+}
+
+type gcpAuditLogEntry struct {
+	ProtoPayload struct {
+		MethodName   string `json:"methodName"`
+		ResourceName string `json:"resourceName"`
+	} `json:"protoPayload"`
+}
+
+// ParseGCPAuditLogEvent returns the name of the function deployed by the Cloud Audit Logs entry delivered by eventarc,
+// i.e: projects/project/locations/location/functions/function. It returns an empty name for the entries of other
+// methods, which aren't verified.
+func ParseGCPAuditLogEvent(data []byte) (string, error) {
+	var entry gcpAuditLogEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return "", fmt.Errorf("failed to parse audit log entry: %w", err)
+	}
+	deployed := false
+	for _, method := range GCPFunctionDeployMethods {
+		deployed = deployed || method == entry.ProtoPayload.MethodName
+	}
+	if !deployed {
+		return "", nil
+	}
+	name := entry.ProtoPayload.ResourceName
+	if parts := strings.Split(name, "/"); len(parts) != 6 || parts[0] != "projects" || parts[2] != "locations" || parts[4] != "functions" {
+		return "", fmt.Errorf("unexpected function name in audit log entry: %s", name)
+	}
+	return name, nil
+}
+
+// GCPFunctionLocation returns the location of the function name, i.e: projects/project/locations/location/functions/function.
+func GCPFunctionLocation(name string) string {
+	parts := strings.Split(name, "/")
+	if len(parts) < 4 {
+		return ""
+	}
+	return parts[3]
+}
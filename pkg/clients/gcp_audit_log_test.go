@@ -0,0 +1,74 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"testing"
+)
+
+func TestParseGCPAuditLogEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "gen1 create",
+			data: `{"protoPayload":{"methodName":"google.cloud.functions.v1.CloudFunctionsService.CreateFunction","resourceName":"projects/p/locations/us-central1/functions/f"}}`,
+			want: "projects/p/locations/us-central1/functions/f",
+		},
+		{
+			name: "gen2 update",
+			data: `{"protoPayload":{"methodName":"google.cloud.functions.v2.FunctionService.UpdateFunction","resourceName":"projects/p/locations/europe-west1/functions/f"}}`,
+			want: "projects/p/locations/europe-west1/functions/f",
+		},
+		{
+			name: "other method",
+			data: `{"protoPayload":{"methodName":"google.cloud.functions.v1.CloudFunctionsService.DeleteFunction","resourceName":"projects/p/locations/us-central1/functions/f"}}`,
+		},
+		{
+			name:    "unexpected resource",
+			data:    `{"protoPayload":{"methodName":"google.cloud.functions.v2.FunctionService.CreateFunction","resourceName":"projects/p/locations/us-central1/operations/o"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "malformed",
+			data:    `{`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseGCPAuditLogEvent([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseGCPAuditLogEvent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("ParseGCPAuditLogEvent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGCPFunctionLocation(t *testing.T) {
+	if got := GCPFunctionLocation("projects/p/locations/us-central1/functions/f"); got != "us-central1" {
+		t.Fatalf("expected us-central1, got: %s", got)
+	}
+	if got := GCPFunctionLocation("f"); got != "" {
+		t.Fatalf("expected no location, got: %s", got)
+	}
+}
@@ -24,9 +24,14 @@ import (
 	"cloud.google.com/go/run/apiv2/runpb"
 	"cloud.google.com/go/storage"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
 	"github.com/google/uuid"
+	"github.com/openclarity/function-clarity/pkg/metadata"
 	"github.com/openclarity/function-clarity/pkg/utils"
+	"golang.org/x/exp/slog"
+	"golang.org/x/oauth2/google"
 	"io"
 	"os"
 	"strings"
@@ -35,16 +40,78 @@ import (
 
 type GCPClient struct {
 	bucket         string
+	location       string
 	functionRegion string
+	project        string
 }
 
 func NewGCPClientInit(bucket string, location string, functionRegion string) *GCPClient {
 	p := new(GCPClient)
 	p.bucket = bucket
+	p.location = location
 	p.functionRegion = functionRegion
 	return p
 }
 
+// WithProject returns a copy of the client creating its buckets in the project.
+func (p *GCPClient) WithProject(project string) *GCPClient {
+	c := *p
+	c.project = project
+	return &c
+}
+
+// ValidateCredentials checks the application default credentials are found and a token is issued with them, the
+// other calls of the client fail on the first request otherwise.
+func (p *GCPClient) ValidateCredentials(ctx context.Context) error {
+	credentials, err := google.FindDefaultCredentials(ctx, storage.ScopeReadWrite)
+	if err != nil {
+		return fmt.Errorf("failed to find gcp credentials: %w", err)
+	}
+	if _, err = credentials.TokenSource.Token(); err != nil {
+		return fmt.Errorf("failed to get a token with the gcp credentials: %w", err)
+	}
+	return nil
+}
+
+// IsStorageExist tells whether the bucket exists and the credentials may access it.
+func (p *GCPClient) IsStorageExist(name string) bool {
+	ctx := context.Background()
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return false
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	_, err = client.Bucket(name).Attrs(ctx)
+	return err == nil
+}
+
+// CreateStorage creates the bucket in the project of the client, in the location of the client.
+func (p *GCPClient) CreateStorage(name string) error {
+	if p.project == "" {
+		return fmt.Errorf("failed to create bucket %s: no project to create it in", name)
+	}
+	ctx := context.Background()
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return fmt.Errorf("storage.NewClient: %w", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	attrs := &storage.BucketAttrs{Location: p.location, UniformBucketLevelAccess: storage.UniformBucketLevelAccess{Enabled: true}}
+	if err := client.Bucket(name).Create(ctx, p.project, attrs); err != nil {
+		return fmt.Errorf("failed to create bucket %s: %w", name, err)
+	}
+	slog.Info("bucket created", "bucket", name)
+	return nil
+}
+
 func (p *GCPClient) Upload(signature string, identity string, isKeyless bool) error {
 	ctx := context.Background()
 
@@ -66,7 +133,7 @@ func (p *GCPClient) Upload(signature string, identity string, isKeyless bool) er
 	if err := wc.Close(); err != nil {
 		return fmt.Errorf("Writer.Close: %w", err)
 	}
-	fmt.Printf("Uploaded %v to: %v\n", identity+".sig", p.bucket)
+	slog.Info("signature file uploaded", "object", identity+".sig", "bucket", p.bucket)
 
 	if isKeyless {
 		certificatePath := "/tmp/" + identity + ".crt.base64"
@@ -84,7 +151,28 @@ func (p *GCPClient) Upload(signature string, identity string, isKeyless bool) er
 		if err := wc.Close(); err != nil {
 			return fmt.Errorf("Writer.Close: %w", err)
 		}
-		fmt.Printf("Certificate %v, uploaded to: %v\n", identity+".crt.base64", p.bucket)
+		slog.Info("certificate file uploaded", "object", identity+".crt.base64", "bucket", p.bucket)
+	}
+	return nil
+}
+
+func (p *GCPClient) UploadFile(content string, fileName string, outputType string) error {
+	ctx := context.Background()
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return fmt.Errorf("storage.NewClient: %w", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	wc := client.Bucket(p.bucket).Object(fileName + "." + outputType).NewWriter(ctx)
+	if _, err = io.Copy(wc, strings.NewReader(content)); err != nil {
+		return fmt.Errorf("io.Copy: %w", err)
+	}
+	if err := wc.Close(); err != nil {
+		return fmt.Errorf("Writer.Close: %w", err)
 	}
 	return nil
 }
@@ -152,6 +240,38 @@ func getDownloadURLFuncGen2(funcIdentifier string) (string, error) {
 	return downloadUrl.DownloadUrl, nil
 }
 
+// GetFuncCodeSha256 returns the base64 sha256 of the function source archive, the same encoding lambda uses.
+func (p *GCPClient) GetFuncCodeSha256(funcIdentifier string) (string, error) {
+	url, err := getDownloadURLFuncGen1(funcIdentifier)
+	if err != nil {
+		url, err = getDownloadURLFuncGen2(funcIdentifier)
+		if err != nil {
+			return "", fmt.Errorf("failed to get function: %w", err)
+		}
+	}
+	zipFileName := uuid.New().String() + ".zip"
+	if err := utils.DownloadFile(zipFileName, &url); err != nil {
+		return "", err
+	}
+	defer os.Remove("/tmp/" + zipFileName)
+
+	f, err := os.Open("/tmp/" + zipFileName)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
+
+// GetFuncResolvedImageURI returns the image uri of the service as is, the digest it runs isn't resolved.
+func (p *GCPClient) GetFuncResolvedImageURI(funcIdentifier string) (string, error) {
+	return p.GetFuncImageURI(funcIdentifier)
+}
+
 func (p *GCPClient) GetFuncImageURI(funcIdentifier string) (string, error) {
 	ctx := context.Background()
 	client, err := run.NewServicesClient(ctx)
@@ -173,12 +293,22 @@ func (p *GCPClient) GetFuncImageURI(funcIdentifier string) (string, error) {
 	return "", fmt.Errorf("there are no image connected to service: %v\n", funcIdentifier)
 }
 
+// GetFuncArchitecture returns an empty architecture, cloud run images run on the default platform.
+func (p *GCPClient) GetFuncArchitecture(funcIdentifier string) (string, error) {
+	return "", nil
+}
+
+func (p *GCPClient) GetFuncLastModified(funcIdentifier string) (time.Time, error) {
+	return time.Time{}, errNotSupported("reading the last modified time of a function", "gcp")
+}
+
+// IsFuncInRegions is always false, cloud run services aren't filtered by region.
 func (p *GCPClient) IsFuncInRegions(regions []string) bool {
-	panic("not yet supported")
+	return false
 }
 
 func (p *GCPClient) FuncContainsTags(funcIdentifier string, tagKes []string) (bool, error) {
-	panic("not yet supported")
+	return false, errNotSupported("filtering functions by tag", "gcp")
 }
 
 func (p *GCPClient) Download(fileName string, outputType string) error {
@@ -211,22 +341,72 @@ func (p *GCPClient) Download(fileName string, outputType string) error {
 	if err = f.Close(); err != nil {
 		return fmt.Errorf("f.Close: %v", err)
 	}
-	fmt.Printf("Downloaded %v to: %v\n", objectName, outputFile)
+	slog.Debug("downloaded object", "object", objectName, "file", outputFile)
 	return nil
 }
 
+func (p *GCPClient) GetSignatureTimestamp(identity string) (time.Time, error) {
+	ctx := context.Background()
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("storage.NewClient: %w", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	attrs, err := client.Bucket(p.bucket).Object(identity + ".sig").Attrs(ctx)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Object(%q).Attrs: %w", identity+".sig", err)
+	}
+	return attrs.Updated, nil
+}
+
+func (p *GCPClient) GetFuncTags(funcIdentifier string) (map[string]string, error) {
+	return nil, errNotSupported("reading function tags", "gcp")
+}
+
+func (p *GCPClient) SendQueueMessages(queueUrl string, messages []QueueMessage) ([]QueueMessageFailure, error) {
+	return nil, errNotSupported("result queues", "gcp")
+}
+
+func (p *GCPClient) GetFuncCodeSigningConfig(funcIdentifier string) (*CodeSigningConfig, error) {
+	return nil, errNotSupported("reading the code signing config of a function", "gcp")
+}
+
+func (p *GCPClient) GetFuncConcurrency(funcIdentifier string) (*metadata.ConcurrencyConfig, error) {
+	return nil, errNotSupported("reading function concurrency", "gcp")
+}
+
+func (p *GCPClient) GetFuncLayers(funcIdentifier string) (*metadata.LayersConfig, error) {
+	return nil, errNotSupported("reading function layers", "gcp")
+}
+
+func (p *GCPClient) GetLayerCode(layerArn string) (string, error) {
+	return "", errNotSupported("downloading layer code", "gcp")
+}
+
 func (p *GCPClient) HandleBlock(funcIdentifier *string, failed bool) error {
-	panic("not yet supported")
+	return errNotSupported("the block action", "gcp")
 }
 
 func (p *GCPClient) HandleDetect(funcIdentifier *string, failed bool) error {
-	panic("not yet supported")
+	return errNotSupported("the detect action", "gcp")
 }
 
 func (p *GCPClient) Notify(msg string, snsArn string) error {
-	panic("not yet supported")
+	return errNotSupported("sns notifications", "gcp")
 }
 
 func (p *GCPClient) FillNotificationDetails(notification *Notification, functionIdentifier string) error {
-	panic("not yet supported")
+	return errNotSupported("notification details", "gcp")
+}
+
+func (p *GCPClient) ListAllFunctions(ctx context.Context) ([]FunctionConfig, error) {
+	return nil, errNotSupported("listing functions", "gcp")
+}
+
+func (p *GCPClient) PutParameter(region string, name string, value string, tier string) error {
+	return errNotSupported("parameter store", "gcp")
 }
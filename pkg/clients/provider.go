@@ -0,0 +1,51 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"context"
+	"fmt"
+)
+
+// CloudProvider is the part of a cloud client the commands share whichever the cloud: checking the credentials,
+// the storage holding the signatures and downloading the package of a function. The storage is an s3 or gcs bucket,
+// or an azure blob container. Listing the functions and the parameter store are aws only, the gcp and azure clients
+// return an error for them.
+type CloudProvider interface {
+	SignatureStore
+	ValidateCredentials(ctx context.Context) error
+	IsStorageExist(name string) bool
+	CreateStorage(name string) error
+	// GetFuncCode downloads and extracts the package of the function, returning the path of the extracted content.
+	GetFuncCode(funcIdentifier string) (string, error)
+}
+
+var (
+	_ CloudProvider = (*AwsClient)(nil)
+	_ CloudProvider = (*GCPClient)(nil)
+	_ CloudProvider = (*AzureClient)(nil)
+)
+
+// EnsureStorage validates the credentials of the provider and creates the storage when it doesn't exist.
+func EnsureStorage(ctx context.Context, provider CloudProvider, name string) error {
+	if err := provider.ValidateCredentials(ctx); err != nil {
+		return fmt.Errorf("validation error: %w", err)
+	}
+	if provider.IsStorageExist(name) {
+		return nil
+	}
+	return provider.CreateStorage(name)
+}
@@ -0,0 +1,155 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mockProvider keeps its storages and files in memory, the files of a storage are keyed by name.type.
+type mockProvider struct {
+	credentialsErr error
+	createErr      error
+	storage        string
+	storages       map[string]map[string]string
+}
+
+func newMockProvider(storage string) *mockProvider {
+	return &mockProvider{storage: storage, storages: map[string]map[string]string{}}
+}
+
+func (m *mockProvider) ValidateCredentials(ctx context.Context) error {
+	return m.credentialsErr
+}
+
+func (m *mockProvider) IsStorageExist(name string) bool {
+	_, ok := m.storages[name]
+	return ok
+}
+
+func (m *mockProvider) CreateStorage(name string) error {
+	if m.createErr != nil {
+		return m.createErr
+	}
+	m.storages[name] = map[string]string{}
+	return nil
+}
+
+func (m *mockProvider) GetFuncCode(funcIdentifier string) (string, error) {
+	return "", fmt.Errorf("function not found: %s", funcIdentifier)
+}
+
+func (m *mockProvider) Upload(signature string, identity string, isKeyless bool) error {
+	return m.UploadFile(signature, identity, "sig")
+}
+
+func (m *mockProvider) UploadFile(content string, fileName string, outputType string) error {
+	files, ok := m.storages[m.storage]
+	if !ok {
+		return fmt.Errorf("storage doesn't exist: %s", m.storage)
+	}
+	files[fileName+"."+outputType] = content
+	return nil
+}
+
+func (m *mockProvider) Download(fileName string, outputType string) error {
+	content, ok := m.storages[m.storage][fileName+"."+outputType]
+	if !ok {
+		return fmt.Errorf("%s.%s: %w", fileName, outputType, ErrBlobNotFound)
+	}
+	return os.WriteFile("/tmp/"+fileName+"."+outputType, []byte(content), 0600)
+}
+
+func (m *mockProvider) GetSignatureTimestamp(identity string) (time.Time, error) {
+	if _, ok := m.storages[m.storage][identity+".sig"]; !ok {
+		return time.Time{}, ErrBlobNotFound
+	}
+	return time.Date(2022, 11, 1, 0, 0, 0, 0, time.UTC), nil
+}
+
+func TestEnsureStorage(t *testing.T) {
+	provider := newMockProvider("signatures")
+	if err := EnsureStorage(context.Background(), provider, "signatures"); err != nil {
+		t.Fatalf("failed to ensure storage: %v", err)
+	}
+	if !provider.IsStorageExist("signatures") {
+		t.Fatalf("expected the missing storage to be created")
+	}
+	provider.createErr = errors.New("already created")
+	if err := EnsureStorage(context.Background(), provider, "signatures"); err != nil {
+		t.Fatalf("expected an existing storage to be kept, got: %v", err)
+	}
+	if err := EnsureStorage(context.Background(), provider, "other"); err == nil || !strings.Contains(err.Error(), "already created") {
+		t.Fatalf("expected the creation error, got: %v", err)
+	}
+
+	provider = newMockProvider("signatures")
+	provider.credentialsErr = errors.New("expired")
+	err := EnsureStorage(context.Background(), provider, "signatures")
+	if err == nil || !strings.HasPrefix(err.Error(), "validation error") {
+		t.Fatalf("expected a validation error, got: %v", err)
+	}
+	if provider.IsStorageExist("signatures") {
+		t.Fatalf("expected no storage created with invalid credentials")
+	}
+}
+
+func TestCloudProviderSignatureStore(t *testing.T) {
+	var provider CloudProvider = newMockProvider("signatures")
+	if err := provider.Upload("signature", "identity", false); err == nil {
+		t.Fatalf("expected an upload to a missing storage to fail")
+	}
+	if err := EnsureStorage(context.Background(), provider, "signatures"); err != nil {
+		t.Fatalf("failed to ensure storage: %v", err)
+	}
+	if err := provider.Upload("signature", "identity", false); err != nil {
+		t.Fatalf("failed to upload: %v", err)
+	}
+	if err := provider.Download("identity", "sig"); err != nil {
+		t.Fatalf("failed to download: %v", err)
+	}
+	defer os.Remove("/tmp/identity.sig")
+	content, err := os.ReadFile("/tmp/identity.sig")
+	if err != nil || string(content) != "signature" {
+		t.Fatalf("expected the uploaded signature, got: %s, %v", content, err)
+	}
+	if _, err := provider.GetSignatureTimestamp("identity"); err != nil {
+		t.Fatalf("failed to get signature timestamp: %v", err)
+	}
+	if err := provider.Download("missing", "sig"); !IsObjectNotFound(err) {
+		t.Fatalf("expected a missing file to be not found, got: %v", err)
+	}
+}
+
+func TestAwsOnlyOperationsFailOnOtherClouds(t *testing.T) {
+	for _, client := range []Client{&GCPClient{}, &AzureClient{}} {
+		if _, err := client.ListAllFunctions(context.Background()); err == nil {
+			t.Fatalf("expected listing the functions to fail on %T", client)
+		}
+		if err := client.PutParameter("us-east-1", "name", "value", "Standard"); err == nil {
+			t.Fatalf("expected putting a parameter to fail on %T", client)
+		}
+		if _, err := client.GetFuncLastModified("function"); err == nil {
+			t.Fatalf("expected reading the last modified time to fail on %T", client)
+		}
+	}
+}
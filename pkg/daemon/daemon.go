@@ -0,0 +1,177 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/openclarity/function-clarity/pkg/metrics"
+	"github.com/openclarity/function-clarity/pkg/verify"
+	"github.com/robfig/cron/v3"
+	"golang.org/x/exp/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const shutdownTimeout = 30 * time.Second
+
+// Scan verifies every function in scope once, an error means the functions could not be enumerated.
+type Scan func(ctx context.Context) (verify.ScanSummary, error)
+
+type Options struct {
+	Interval      time.Duration
+	Schedule      string
+	ListenAddress string
+}
+
+// Daemon runs a scan on a schedule until its context is done, and serves health and metrics endpoints meanwhile.
+type Daemon struct {
+	schedule      cron.Schedule
+	scan          Scan
+	listenAddress string
+
+	mu               sync.Mutex
+	scans            int64
+	scanErrors       int64
+	results          map[string]int64
+	lastScan         time.Time
+	lastScanDuration time.Duration
+	// inaccessibleScopes is the number of accounts and regions the last successful scan couldn't access
+	inaccessibleScopes int
+}
+
+func New(o Options, scan Scan) (*Daemon, error) {
+	var schedule cron.Schedule
+	switch {
+	case o.Schedule != "":
+		parsed, err := cron.ParseStandard(o.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule: %s: %w", o.Schedule, err)
+		}
+		schedule = parsed
+	case o.Interval >= time.Second:
+		schedule = cron.Every(o.Interval)
+	default:
+		return nil, fmt.Errorf("either a schedule or an interval of at least one second is required")
+	}
+	return &Daemon{
+		schedule:      schedule,
+		scan:          scan,
+		listenAddress: o.ListenAddress,
+		results:       map[string]int64{},
+	}, nil
+}
+
+// Run scans right away and then on every scheduled time. When the context is done the running scan stops before
+// its next function, and the http server is shut down.
+func (d *Daemon) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", d.listenAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen on: %s: %w", d.listenAddress, err)
+	}
+	server := &http.Server{Handler: d.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	serverErr := make(chan error, 1)
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+	slog.Info("serving health and metrics", "address", listener.Addr().String())
+
+	d.runScan(ctx)
+	for {
+		next := d.schedule.Next(time.Now())
+		slog.Info("next scan", "at", next.Format(time.RFC3339))
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			slog.Info("shutting down")
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cancel()
+			return server.Shutdown(shutdownCtx)
+		case err := <-serverErr:
+			timer.Stop()
+			return fmt.Errorf("health and metrics server failed: %w", err)
+		case <-timer.C:
+			d.runScan(ctx)
+		}
+	}
+}
+
+func (d *Daemon) runScan(ctx context.Context) {
+	start := time.Now()
+	summary, err := d.scan(ctx)
+	duration := time.Since(start)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.scans++
+	d.lastScan = start
+	d.lastScanDuration = duration
+	if err != nil {
+		d.scanErrors++
+		slog.Error("scan failed", err)
+		return
+	}
+	for _, result := range summary.Results {
+		d.results[result.Result]++
+	}
+	d.inaccessibleScopes = len(summary.InaccessibleScopes)
+	for _, scope := range summary.InaccessibleScopes {
+		slog.Warn("inaccessible scope", "scope", scope.Scope, "reason", scope.Reason)
+	}
+	slog.Info("scan done", "duration", duration.Round(time.Millisecond), "passed", summary.Count(verify.ResultPassed),
+		"failed", summary.Count(verify.ResultFailed), "untrustedSigner", summary.Count(verify.ResultUntrustedSigner),
+		"awsCodeSigningMissing", summary.Count(verify.ResultAwsCodeSigningMissing),
+		"imageDigestUnpinned", summary.Count(verify.ResultImageDigestUnpinned), "errors", summary.Count(verify.ResultError),
+		"timedOut", summary.Count(verify.ResultTimedOut), "inaccessibleScopes", len(summary.InaccessibleScopes))
+}
+
+func (d *Daemon) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/metrics", d.serveMetrics)
+	return mux
+}
+
+// serveMetrics writes the daemon counters in the prometheus text exposition format, followed by the verification
+// metrics when they are enabled.
+func (d *Daemon) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	fmt.Fprintf(w, "# HELP fc_daemon_scans_total Number of scans run.\n# TYPE fc_daemon_scans_total counter\nfc_daemon_scans_total %d\n", d.scans)
+	fmt.Fprintf(w, "# HELP fc_daemon_scan_errors_total Number of scans that failed to enumerate functions.\n# TYPE fc_daemon_scan_errors_total counter\nfc_daemon_scan_errors_total %d\n", d.scanErrors)
+	fmt.Fprintf(w, "# HELP fc_daemon_functions_verified_total Number of function verifications by result.\n# TYPE fc_daemon_functions_verified_total counter\n")
+	for _, result := range []string{verify.ResultPassed, verify.ResultFailed, verify.ResultUntrustedSigner, verify.ResultAwsCodeSigningMissing,
+		verify.ResultImageDigestUnpinned, verify.ResultError, verify.ResultTimedOut} {
+		fmt.Fprintf(w, "fc_daemon_functions_verified_total{result=%q} %d\n", result, d.results[result])
+	}
+	fmt.Fprintf(w, "# HELP fc_daemon_inaccessible_scopes Number of accounts and regions the last scan couldn't access.\n# TYPE fc_daemon_inaccessible_scopes gauge\nfc_daemon_inaccessible_scopes %d\n", d.inaccessibleScopes)
+	if !d.lastScan.IsZero() {
+		fmt.Fprintf(w, "# HELP fc_daemon_last_scan_timestamp_seconds Start time of the last scan.\n# TYPE fc_daemon_last_scan_timestamp_seconds gauge\nfc_daemon_last_scan_timestamp_seconds %d\n", d.lastScan.Unix())
+		fmt.Fprintf(w, "# HELP fc_daemon_last_scan_duration_seconds Duration of the last scan.\n# TYPE fc_daemon_last_scan_duration_seconds gauge\nfc_daemon_last_scan_duration_seconds %f\n", d.lastScanDuration.Seconds())
+	}
+	if registry := metrics.Default(); registry != nil {
+		registry.Write(w) //nolint:errcheck
+	}
+}
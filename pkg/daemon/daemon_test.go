@@ -0,0 +1,101 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package daemon
+
+import (
+	"context"
+	"github.com/openclarity/function-clarity/pkg/verify"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewRequiresSchedule(t *testing.T) {
+	scan := func(ctx context.Context) (verify.ScanSummary, error) { return verify.ScanSummary{}, nil }
+	if _, err := New(Options{}, scan); err == nil {
+		t.Fatalf("expected error without schedule or interval")
+	}
+	if _, err := New(Options{Schedule: "not a cron"}, scan); err == nil {
+		t.Fatalf("expected error for invalid schedule")
+	}
+	if _, err := New(Options{Schedule: "*/5 * * * *"}, scan); err != nil {
+		t.Fatalf("unexpected error for valid schedule: %v", err)
+	}
+}
+
+func TestMetricsAfterScan(t *testing.T) {
+	scan := func(ctx context.Context) (verify.ScanSummary, error) {
+		return verify.ScanSummary{Results: []verify.VerificationResult{
+			{FunctionIdentifier: "a", Result: verify.ResultPassed},
+			{FunctionIdentifier: "b", Result: verify.ResultFailed},
+			{FunctionIdentifier: "c", Result: verify.ResultPassed},
+		}}, nil
+	}
+	d, err := New(Options{Interval: time.Hour}, scan)
+	if err != nil {
+		t.Fatalf("failed to create daemon: %v", err)
+	}
+	d.runScan(context.Background())
+
+	server := httptest.NewServer(d.Handler())
+	defer server.Close()
+	resp, err := server.Client().Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("failed to get metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	for _, expected := range []string{
+		"fc_daemon_scans_total 1",
+		`fc_daemon_functions_verified_total{result="passed"} 2`,
+		`fc_daemon_functions_verified_total{result="failed"} 1`,
+	} {
+		if !strings.Contains(string(body), expected) {
+			t.Fatalf("expected metrics to contain: %s, got:\n%s", expected, body)
+		}
+	}
+}
+
+func TestRunStopsOnCancel(t *testing.T) {
+	var scans int64
+	scan := func(ctx context.Context) (verify.ScanSummary, error) {
+		atomic.AddInt64(&scans, 1)
+		return verify.ScanSummary{}, nil
+	}
+	d, err := New(Options{Interval: time.Second, ListenAddress: "127.0.0.1:0"}, scan)
+	if err != nil {
+		t.Fatalf("failed to create daemon: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.Run(ctx) }()
+	time.Sleep(1500 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error on shutdown: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("daemon didn't stop after cancel")
+	}
+	if atomic.LoadInt64(&scans) < 2 {
+		t.Fatalf("expected an initial and a scheduled scan, got: %d", scans)
+	}
+}
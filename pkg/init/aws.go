@@ -15,21 +15,192 @@
 
 package init
 
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// AWSInput is the configuration gathered by init, written to the config file read by the other commands.
 type AWSInput struct {
-	AccessKey           string
-	SecretKey           string
-	Region              string
-	Bucket              string
-	Action              string
-	PublicKey           string
-	PrivateKey          string
-	CloudTrail          CloudTrail
-	IsKeyless           bool
-	SnsTopicArn         string
+	AccessKey string
+	SecretKey string
+	Region    string
+	Bucket    string
+	KmsKeyArn string
+	// SignatureRetentionDays expires the signatures in the bucket after the number of days, never when 0.
+	SignatureRetentionDays int
+	Action                 string
+	// PublicKey and PrivateKey are the key pair files, or with KmsKeyRef the file its public key is written to and
+	// the key reference.
+	PublicKey  string
+	PrivateKey string
+	// PrivateKeyPassword is only passed on to cosign through COSIGN_PASSWORD, never written to a config file.
+	PrivateKeyPassword string `yaml:"-" json:"-"`
+	// KmsKeyRef signs with an aws kms key instead of a local key pair, i.e: awskms:///<key arn>.
+	KmsKeyRef string
+	// KeyDir and KeyPrefix name the key pair generated when no PublicKey is given, <KeyDir>/<KeyPrefix>.pub and .key.
+	KeyDir     string
+	KeyPrefix  string
+	CloudTrail CloudTrail
+	IsKeyless  bool
+	// FulcioUrl and RekorUrl point to a private sigstore instance, the public one when empty.
+	FulcioUrl string
+	RekorUrl  string
+	// TufRootPath and TufMirrorUrl replace the public sigstore TUF repository, the root is deployed with the verifier.
+	TufRootPath     string
+	TufMirrorUrl    string
+	Notifiers       []string
+	SnsTopicArn     string
+	SlackWebhookUrl string
+	WebhookUrl      string
+	WebhookHeaders  map[string]string
+	// IncludedFuncTagKeys and IncludedFuncTags limit the verification to the functions with one of the tag keys or
+	// tags, ExcludedFuncTagKeys skips the functions with one of its keys and wins over the included ones.
 	IncludedFuncTagKeys []string
+	IncludedFuncTags    map[string]string
+	ExcludedFuncTagKeys []string
+	// ExcludedFuncRegions wins over IncludedFuncRegions for a region in both.
 	IncludedFuncRegions []string
+	ExcludedFuncRegions []string
+	VerifyConcurrency   bool
+	VerifyLayers        bool
+	SignatureFreshness  time.Duration
+	ClockSkew           time.Duration
+	// Concurrency bounds the functions the verifier lambda verifies at once, the number of CPUs when 0, each within
+	// FunctionTimeout when set.
+	Concurrency     int
+	FunctionTimeout time.Duration
+	// Timeout bounds each aws operation, the --timeout flag.
+	Timeout time.Duration
+	// EnableMetrics records the metrics of the verifier lambda, pushed to MetricsPushGateway when set.
+	EnableMetrics         bool
+	MetricsPushGateway    string
+	PinSigner             bool
+	RequireKeyAndKeyless  bool
+	UntrustedSignerAction string
+	DryRun                bool
+	RequireAwsCodeSigning bool
+	RequireImageDigestPin bool
+	RequireSignedLayers   bool
+	EnforceSCT            bool
+	CertIdentity          string
+	CertOidcIssuer        string
+	CertIdentityRegexp    string
+	CertOidcIssuerRegexp  string
+	IncludedSigners       []options.SignerIdentity
+	Offline               bool
+	// TlogUpload uploads the signatures made with a key to rekor, and requires their log entry on verification.
+	TlogUpload          bool
+	NotificationRouting options.NotificationRouting
+	ResultQueue         options.ResultQueue
+	AssumeRoleArn       string
+	ExternalId          string
+	AssumeRoleDuration  time.Duration
+	// EndpointUrl replaces the aws endpoints during init and deployment, e.g. to test against LocalStack.
+	EndpointUrl string
+}
+
+// UnmarshalJSON reads the durations as strings like "30s", as in the YAML configuration, or as nanoseconds.
+func (i *AWSInput) UnmarshalJSON(data []byte) error {
+	type fields AWSInput
+	input := struct {
+		*fields
+		SignatureFreshness jsonDuration
+		ClockSkew          jsonDuration
+		FunctionTimeout    jsonDuration
+		Timeout            jsonDuration
+		AssumeRoleDuration jsonDuration
+	}{
+		fields:             (*fields)(i),
+		SignatureFreshness: jsonDuration(i.SignatureFreshness),
+		ClockSkew:          jsonDuration(i.ClockSkew),
+		FunctionTimeout:    jsonDuration(i.FunctionTimeout),
+		Timeout:            jsonDuration(i.Timeout),
+		AssumeRoleDuration: jsonDuration(i.AssumeRoleDuration),
+	}
+	if err := json.Unmarshal(data, &input); err != nil {
+		return err
+	}
+	i.SignatureFreshness = time.Duration(input.SignatureFreshness)
+	i.ClockSkew = time.Duration(input.ClockSkew)
+	i.FunctionTimeout = time.Duration(input.FunctionTimeout)
+	i.Timeout = time.Duration(input.Timeout)
+	i.AssumeRoleDuration = time.Duration(input.AssumeRoleDuration)
+	return nil
+}
+
+type jsonDuration time.Duration
+
+func (d *jsonDuration) UnmarshalJSON(data []byte) error {
+	var nanoseconds int64
+	if err := json.Unmarshal(data, &nanoseconds); err == nil {
+		*d = jsonDuration(nanoseconds)
+		return nil
+	}
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("invalid duration: %s, expected a string like \"30s\"", data)
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid duration: %w", err)
+	}
+	*d = jsonDuration(duration)
+	return nil
 }
 
 type CloudTrail struct {
 	Name string
 }
+
+// LoadAWSInputFromFile reads the init configuration from a YAML file, or a JSON file when its extension is .json.
+// The keys are the ones of the config file written by init, i.e: accesskey, region, cloudtrail.name.
+func LoadAWSInputFromFile(path string) (*AWSInput, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read init configuration: %s: %w", path, err)
+	}
+	input := &AWSInput{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(content, input)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, input)
+	default:
+		return nil, fmt.Errorf("unsupported init configuration: %s, expected a .yaml, .yml or .json file", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse init configuration: %s: %w", path, err)
+	}
+	return input, nil
+}
+
+// DefaultInputFile is the file under the home directory the init answers are saved to. It sits next to the ~/.fc
+// config file read by the other commands, which rules out a ~/.fc directory.
+const DefaultInputFile = ".fc-init.yaml"
+
+// SaveAWSInput writes the init configuration as YAML so it can be given back to init with --config. The secret key is
+// only written when includeSecrets is true, the file is readable by its owner only either way.
+func SaveAWSInput(i *AWSInput, path string, includeSecrets bool) error {
+	input := *i
+	if !includeSecrets {
+		input.SecretKey = ""
+	}
+	content, err := yaml.Marshal(&input)
+	if err != nil {
+		return fmt.Errorf("failed to marshal init configuration: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create init configuration directory: %s: %w", filepath.Dir(path), err)
+	}
+	if err = os.WriteFile(path, content, 0600); err != nil {
+		return fmt.Errorf("failed to write init configuration: %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package init
+
+import (
+	"encoding/json"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoadAWSInputFromFileRoundTrip(t *testing.T) {
+	input := AWSInput{
+		AccessKey:           "access",
+		SecretKey:           "secret",
+		Region:              "us-east-1",
+		Bucket:              "signatures",
+		Action:              "block",
+		DryRun:              true,
+		PublicKey:           "cosign.pub",
+		PrivateKey:          "cosign.key",
+		CloudTrail:          CloudTrail{Name: "trail"},
+		Notifiers:           []string{"sns", "webhook"},
+		WebhookUrl:          "https://hooks.example.com/alerts",
+		WebhookHeaders:      map[string]string{"Authorization": "Bearer token"},
+		SnsTopicArn:         "arn:aws:sns:us-east-1:123456789012:alerts",
+		IncludedFuncTagKeys: []string{"team"},
+		IncludedFuncTags:    map[string]string{"environment": "production"},
+		ExcludedFuncTagKeys: []string{"fc-exclude"},
+		IncludedFuncRegions: []string{"us-east-1", "eu-west-1"},
+		ExcludedFuncRegions: []string{"eu-west-1"},
+		VerifyLayers:        true,
+		IncludedSigners:     []options.SignerIdentity{{IssuerRegexp: "^https://accounts\\.google\\.com$", SubjectRegexp: "@example\\.com$"}},
+		SignatureFreshness:  time.Hour,
+		ClockSkew:           options.DefaultClockSkew,
+		Concurrency:         4,
+		FunctionTimeout:     time.Minute,
+		Timeout:             30 * time.Second,
+		EnableMetrics:       true,
+		MetricsPushGateway:  "http://pushgateway:9091",
+		NotificationRouting: options.NotificationRouting{TagKey: "team", Routes: map[string]string{"payments": "https://hooks.example.com/payments"}},
+		ResultQueue:         options.ResultQueue{URL: "https://sqs.us-east-1.amazonaws.com/123456789012/results", Attributes: map[string]string{"stage": "prod"}},
+	}
+	for _, format := range []struct {
+		file    string
+		marshal func(interface{}) ([]byte, error)
+	}{
+		{"init.yaml", yaml.Marshal},
+		{"init.json", json.Marshal},
+	} {
+		content, err := format.marshal(input)
+		if err != nil {
+			t.Fatalf("failed to marshal %s: %v", format.file, err)
+		}
+		path := filepath.Join(t.TempDir(), format.file)
+		if err = os.WriteFile(path, content, 0600); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadAWSInputFromFile(path)
+		if err != nil {
+			t.Fatalf("failed to load %s: %v", format.file, err)
+		}
+		if !reflect.DeepEqual(*loaded, input) {
+			t.Fatalf("%s didn't round trip, got: %+v", format.file, *loaded)
+		}
+	}
+	if _, err := LoadAWSInputFromFile(filepath.Join(t.TempDir(), "init.toml")); err == nil {
+		t.Fatalf("expected an error for an unsupported extension")
+	}
+}
+
+func TestLoadAWSInputFromFileDurations(t *testing.T) {
+	for file, content := range map[string]string{
+		"init.yaml": "region: us-east-1\ntimeout: 30s\nclockskew: 2m\nfunctiontimeout: 1m30s\n",
+		"init.json": `{"region": "us-east-1", "timeout": "30s", "clockskew": "2m", "functiontimeout": 90000000000}`,
+	} {
+		path := filepath.Join(t.TempDir(), file)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadAWSInputFromFile(path)
+		if err != nil {
+			t.Fatalf("failed to load %s: %v", file, err)
+		}
+		if loaded.Region != "us-east-1" || loaded.Timeout != 30*time.Second || loaded.ClockSkew != 2*time.Minute ||
+			loaded.FunctionTimeout != 90*time.Second {
+			t.Fatalf("%s durations weren't parsed, got: %+v", file, *loaded)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "init.json")
+	if err := os.WriteFile(path, []byte(`{"timeout": "thirty seconds"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadAWSInputFromFile(path); err == nil {
+		t.Fatalf("expected an error for an invalid duration")
+	}
+}
+
+func TestSaveAWSInput(t *testing.T) {
+	input := AWSInput{
+		AccessKey:          "access",
+		SecretKey:          "secret",
+		Region:             "us-east-1",
+		PrivateKeyPassword: "password",
+		CloudTrail:         CloudTrail{Name: "trail"},
+		ClockSkew:          options.DefaultClockSkew,
+	}
+	for _, includeSecrets := range []bool{false, true} {
+		path := filepath.Join(t.TempDir(), "fc", "init.yaml")
+		if err := SaveAWSInput(&input, path, includeSecrets); err != nil {
+			t.Fatalf("failed to save init configuration: %v", err)
+		}
+		loaded, err := LoadAWSInputFromFile(path)
+		if err != nil {
+			t.Fatalf("failed to load init configuration: %v", err)
+		}
+		expectedSecret := ""
+		if includeSecrets {
+			expectedSecret = input.SecretKey
+		}
+		if loaded.SecretKey != expectedSecret {
+			t.Fatalf("includeSecrets: %t, expected secret key: %q, got: %q", includeSecrets, expectedSecret, loaded.SecretKey)
+		}
+		if loaded.PrivateKeyPassword != "" {
+			t.Fatalf("includeSecrets: %t, expected the private key password not to be saved", includeSecrets)
+		}
+		if loaded.AccessKey != input.AccessKey || loaded.Region != input.Region || loaded.CloudTrail != input.CloudTrail || loaded.ClockSkew != input.ClockSkew {
+			t.Fatalf("includeSecrets: %t, got: %+v", includeSecrets, *loaded)
+		}
+	}
+	if input.SecretKey != "secret" {
+		t.Fatalf("the saved input was modified")
+	}
+}
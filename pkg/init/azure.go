@@ -0,0 +1,30 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package init
+
+// AzureInput is the configuration gathered by init azure. The service principal of TenantId, ClientId and
+// ClientSecret signs and verifies the function apps, it needs the Storage Blob Data Contributor role on the
+// StorageAccount and read access to the function apps. The signatures are stored in Container, created when missing.
+type AzureInput struct {
+	TenantId       string
+	ClientId       string
+	ClientSecret   string
+	StorageAccount string
+	Container      string
+	IsKeyless      bool
+	PublicKey      string
+	PrivateKey     string
+}
@@ -0,0 +1,27 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package init
+
+// GCPInput is the configuration gathered by init gcp. The bucket holding the signatures is created in Project, in
+// Location, when it doesn't exist. Location is also where the audit log trigger verifying the deployed functions runs.
+type GCPInput struct {
+	Project    string
+	Location   string
+	Bucket     string
+	IsKeyless  bool
+	PublicKey  string
+	PrivateKey string
+}
@@ -0,0 +1,119 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package init
+
+import (
+	"encoding/pem"
+	"fmt"
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"os"
+	"path/filepath"
+)
+
+// DefaultKeyPrefix is the file name prefix of a generated key pair, the one of cosign generate-key-pair.
+const DefaultKeyPrefix = "cosign"
+
+// GenerateKeyPair writes a cosign key pair to <prefix>.pub and <prefix>.key in dir, created when missing, the private
+// key encrypted with the password of pf. Existing key files are only overwritten with force, the error otherwise
+// wraps os.ErrExist.
+func GenerateKeyPair(dir string, prefix string, force bool, pf cosign.PassFunc) (string, string, error) {
+	if prefix == "" {
+		prefix = DefaultKeyPrefix
+	}
+	publicKeyPath, privateKeyPath := filepath.Join(dir, prefix+".pub"), filepath.Join(dir, prefix+".key")
+	if !force {
+		for _, path := range []string{publicKeyPath, privateKeyPath} {
+			if _, err := os.Stat(path); err == nil {
+				return "", "", fmt.Errorf("key file: %s: %w", path, os.ErrExist)
+			}
+		}
+	}
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return "", "", fmt.Errorf("failed to create key directory: %s: %w", dir, err)
+		}
+	}
+	keys, err := cosign.GenerateKeyPair(pf)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate key pair: %w", err)
+	}
+	if err = os.WriteFile(privateKeyPath, keys.PrivateBytes, 0600); err != nil {
+		return "", "", fmt.Errorf("failed to write private key: %s: %w", privateKeyPath, err)
+	}
+	if err = os.WriteFile(publicKeyPath, keys.PublicBytes, 0644); err != nil {
+		return "", "", fmt.Errorf("failed to write public key: %s: %w", publicKeyPath, err)
+	}
+	return publicKeyPath, privateKeyPath, nil
+}
+
+// IsPrivateKeyEncrypted tells whether the cosign private key at path needs a password, keys generated with an empty
+// password decrypt without one.
+func IsPrivateKeyEncrypted(path string) (bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read private key: %s: %w", path, err)
+	}
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return false, fmt.Errorf("invalid private key: %s, expected a PEM encoded key", path)
+	}
+	if block.Type != cosign.CosignPrivateKeyPemType {
+		return false, nil
+	}
+	_, err = cosign.LoadPrivateKey(content, []byte{})
+	return err != nil, nil
+}
+
+// CheckPrivateKeyPassword fails when the cosign private key at path doesn't decrypt with the password.
+func CheckPrivateKeyPassword(path string, password string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read private key: %s: %w", path, err)
+	}
+	if _, err = cosign.LoadPrivateKey(content, []byte(password)); err != nil {
+		return fmt.Errorf("failed to decrypt private key: %s, wrong password: %w", path, err)
+	}
+	return nil
+}
+
+// ValidateKeyPair loads the cosign private key, decrypted with the password, and fails when the public key isn't the
+// one of the private key, which would otherwise only show on verification.
+func ValidateKeyPair(publicKeyPath string, privateKeyPath string, password string) error {
+	content, err := os.ReadFile(publicKeyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read public key: %s: %w", publicKeyPath, err)
+	}
+	publicKey, err := cryptoutils.UnmarshalPEMToPublicKey(content)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %s: %w", publicKeyPath, err)
+	}
+	if content, err = os.ReadFile(privateKeyPath); err != nil {
+		return fmt.Errorf("failed to read private key: %s: %w", privateKeyPath, err)
+	}
+	signerVerifier, err := cosign.LoadPrivateKey(content, []byte(password))
+	if err != nil {
+		return fmt.Errorf("invalid private key: %s: %w", privateKeyPath, err)
+	}
+	privatePublicKey, err := signerVerifier.PublicKey()
+	if err != nil {
+		return fmt.Errorf("failed to derive the public key of private key: %s: %w", privateKeyPath, err)
+	}
+	if err = cryptoutils.EqualKeys(publicKey, privatePublicKey); err != nil {
+		return fmt.Errorf("public key: %s doesn't match private key: %s", publicKeyPath, privateKeyPath)
+	}
+	return nil
+}
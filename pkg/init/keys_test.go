@@ -0,0 +1,106 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package init
+
+import (
+	"errors"
+	"github.com/sigstore/cosign/pkg/cosign"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writePrivateKey(t *testing.T, password string) string {
+	keys, err := cosign.GenerateKeyPair(func(bool) ([]byte, error) { return []byte(password), nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "cosign.key")
+	if err = os.WriteFile(path, keys.PrivateBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestIsPrivateKeyEncrypted(t *testing.T) {
+	encrypted, err := IsPrivateKeyEncrypted(writePrivateKey(t, "secret"))
+	if err != nil || !encrypted {
+		t.Fatalf("expected a key with a password to be encrypted, got: %v, %v", encrypted, err)
+	}
+	encrypted, err = IsPrivateKeyEncrypted(writePrivateKey(t, ""))
+	if err != nil || encrypted {
+		t.Fatalf("expected a key with an empty password not to be encrypted, got: %v, %v", encrypted, err)
+	}
+}
+
+func TestCheckPrivateKeyPassword(t *testing.T) {
+	path := writePrivateKey(t, "secret")
+	if err := CheckPrivateKeyPassword(path, "secret"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := CheckPrivateKeyPassword(path, "wrong"); err == nil {
+		t.Fatalf("expected an error for a wrong password")
+	}
+}
+
+func TestValidateKeyPair(t *testing.T) {
+	keys, err := cosign.GenerateKeyPair(func(bool) ([]byte, error) { return []byte("secret"), nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	publicKey, privateKey := filepath.Join(dir, "cosign.pub"), filepath.Join(dir, "cosign.key")
+	if err = os.WriteFile(publicKey, keys.PublicBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(privateKey, keys.PrivateBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = ValidateKeyPair(publicKey, privateKey, "secret"); err != nil {
+		t.Fatalf("unexpected error for a matching key pair: %v", err)
+	}
+	if err = ValidateKeyPair(publicKey, writePrivateKey(t, "secret"), "secret"); err == nil || !strings.Contains(err.Error(), "doesn't match") {
+		t.Fatalf("expected an error for a mismatching key pair, got: %v", err)
+	}
+	if err = ValidateKeyPair(publicKey, privateKey, "wrong"); err == nil {
+		t.Fatalf("expected an error for a wrong password")
+	}
+}
+
+func TestGenerateKeyPair(t *testing.T) {
+	pf := func(bool) ([]byte, error) { return []byte("secret"), nil }
+	dir := filepath.Join(t.TempDir(), "keys", "prod")
+	publicKey, privateKey, err := GenerateKeyPair(dir, "signer", false, pf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if publicKey != filepath.Join(dir, "signer.pub") || privateKey != filepath.Join(dir, "signer.key") {
+		t.Fatalf("unexpected key paths: %s, %s", publicKey, privateKey)
+	}
+	if err = ValidateKeyPair(publicKey, privateKey, "secret"); err != nil {
+		t.Fatalf("expected a valid key pair, got: %v", err)
+	}
+	if _, _, err = GenerateKeyPair(dir, "signer", false, pf); !errors.Is(err, os.ErrExist) {
+		t.Fatalf("expected existing key files not to be overwritten, got: %v", err)
+	}
+	if _, _, err = GenerateKeyPair(dir, "signer", true, pf); err != nil {
+		t.Fatalf("expected existing key files to be overwritten with force, got: %v", err)
+	}
+	if err = ValidateKeyPair(publicKey, privateKey, "secret"); err != nil {
+		t.Fatalf("expected the overwritten key pair to be valid, got: %v", err)
+	}
+}
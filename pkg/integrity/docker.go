@@ -32,26 +32,27 @@ type DockerAuth struct {
 	Auths map[string]Auth `json:"auths"`
 }
 
-func InitDocker(awsClient *clients.AwsClient) error {
-	ecrToken, err := awsClient.GetEcrToken()
-	if err != nil {
-		return err
-	}
+// InitDocker writes the docker config with the ecr credentials of the regions of the clients, the images of all of
+// them can then be pulled concurrently.
+func InitDocker(awsClients ...*clients.AwsClient) error {
 	dockerAuth := DockerAuth{Auths: map[string]Auth{}}
-	for _, ad := range ecrToken.AuthorizationData {
-		usernamePassword, err := base64.StdEncoding.DecodeString(*ad.AuthorizationToken)
+	for _, awsClient := range awsClients {
+		ecrToken, err := awsClient.GetEcrToken()
 		if err != nil {
 			return err
 		}
-		split := strings.Split(string(usernamePassword), ":")
-		dockerAuth.Auths[*ad.ProxyEndpoint] = Auth{
-			Username: split[0],
-			Password: split[1],
+		for _, ad := range ecrToken.AuthorizationData {
+			usernamePassword, err := base64.StdEncoding.DecodeString(*ad.AuthorizationToken)
+			if err != nil {
+				return err
+			}
+			split := strings.Split(string(usernamePassword), ":")
+			dockerAuth.Auths[*ad.ProxyEndpoint] = Auth{
+				Username: split[0],
+				Password: split[1],
+			}
 		}
 	}
-	if err != nil {
-		return err
-	}
 
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
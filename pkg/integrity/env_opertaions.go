@@ -16,22 +16,54 @@
 package integrity
 
 import (
+	"fmt"
 	"github.com/spf13/viper"
-	"log"
 	"os"
 	"strconv"
 )
 
 const ExperimentalEnv = "COSIGN_EXPERIMENTAL"
 
-func IsExperimentalEnv() bool {
+// Every signature is also stored under a name of its signing mode, so signing an identity both with a key and
+// keyless keeps both signatures, the <identity>.sig object holds the signature of the last signing.
+const (
+	KeySignatureType     = "key.sig"
+	KeylessSignatureType = "keyless.sig"
+)
+
+// RekorBundleType is the transparency log entry of a keyless signature, stored next to the signature so it can be
+// verified without querying rekor.
+const RekorBundleType = "rekor-bundle.json"
+
+func SignatureTypeFor(isKeyless bool) string {
+	if isKeyless {
+		return KeylessSignatureType
+	}
+	return KeySignatureType
+}
+
+// EnableExperimental turns on the experimental mode of cosign, in which it uploads signatures made with a key to the
+// transparency log and looks them up there on verification. The returned function restores the previous mode.
+func EnableExperimental() func() {
+	previous, set := os.LookupEnv(ExperimentalEnv)
+	os.Setenv(ExperimentalEnv, "1")
+	return func() {
+		if set {
+			os.Setenv(ExperimentalEnv, previous)
+		} else {
+			os.Unsetenv(ExperimentalEnv)
+		}
+	}
+}
+
+func IsExperimentalEnv() (bool, error) {
 	env, err := strconv.ParseBool(os.Getenv(ExperimentalEnv))
 	if err != nil {
-		log.Fatal("can't read env variable")
+		return false, fmt.Errorf("can't read env variable %s: %w", ExperimentalEnv, err)
 	}
 	config := viper.GetBool("isKeyless")
 	if env || config {
-		return true
+		return true, nil
 	}
-	return false
+	return false, nil
 }
@@ -0,0 +1,39 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"os"
+	"testing"
+)
+
+func TestEnableExperimentalRestoresMode(t *testing.T) {
+	t.Setenv(ExperimentalEnv, "0")
+	restore := EnableExperimental()
+	if os.Getenv(ExperimentalEnv) != "1" {
+		t.Fatalf("expected experimental mode to be enabled")
+	}
+	restore()
+	if os.Getenv(ExperimentalEnv) != "0" {
+		t.Fatalf("expected experimental mode to be restored, got: %s", os.Getenv(ExperimentalEnv))
+	}
+
+	os.Unsetenv(ExperimentalEnv)
+	EnableExperimental()()
+	if _, set := os.LookupEnv(ExperimentalEnv); set {
+		t.Fatalf("expected experimental mode to be unset again")
+	}
+}
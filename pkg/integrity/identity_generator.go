@@ -17,6 +17,7 @@ package integrity
 
 import (
 	"crypto/sha256"
+	"crypto/sha512"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -24,13 +25,50 @@ import (
 	"strings"
 )
 
+// The digest algorithms of code identities, sha256 unless another one is chosen at sign time.
+const (
+	DigestSha256           = "sha256"
+	DigestSha512           = "sha512"
+	DefaultDigestAlgorithm = DigestSha256
+)
+
+// DigestAlgorithms are the supported digest algorithms, in the order a verifier looks up the signature of each.
+var DigestAlgorithms = []string{DigestSha256, DigestSha512}
+
 type IdentityGenerator interface {
 	GenerateIdentity(path string) (string, error)
+	GenerateZipIdentity(zipData []byte) (string, error)
+}
+
+// NewIdentityGenerator returns the identity generator of the digest algorithm, the default one when empty.
+func NewIdentityGenerator(algorithm string) (IdentityGenerator, error) {
+	switch strings.ToLower(algorithm) {
+	case "", DigestSha256:
+		return &Sha256{}, nil
+	case DigestSha512:
+		return &Sha512{}, nil
+	}
+	return nil, fmt.Errorf("unsupported digest algorithm: %s, expected one of: %s", algorithm, strings.Join(DigestAlgorithms, ", "))
 }
 
 type Sha256 struct{}
 
 func (o *Sha256) GenerateIdentity(path string) (string, error) {
+	return generateIdentity(path, func(data []byte) string {
+		return fmt.Sprintf("%x", sha256.Sum256(data))
+	})
+}
+
+type Sha512 struct{}
+
+func (o *Sha512) GenerateIdentity(path string) (string, error) {
+	return generateIdentity(path, func(data []byte) string {
+		return fmt.Sprintf("%x", sha512.Sum512(data))
+	})
+}
+
+// generateIdentity digests each file along with its path relative to the code root, and then the sorted digests.
+func generateIdentity(path string, digest func([]byte) string) (string, error) {
 	var identities []string
 	rootFolderName := ""
 	err := filepath.WalkDir(path,
@@ -49,8 +87,7 @@ func (o *Sha256) GenerateIdentity(path string) (string, error) {
 				} else {
 					dataString = dataString + path[strings.Index(path, rootFolderName)+len(rootFolderName)+1:]
 				}
-				sha := sha256.Sum256([]byte(dataString))
-				identities = append(identities, fmt.Sprintf("%x", sha))
+				identities = append(identities, digest([]byte(dataString)))
 			} else if rootFolderName == "" {
 				rootFolderName = d.Name()
 			}
@@ -61,6 +98,5 @@ func (o *Sha256) GenerateIdentity(path string) (string, error) {
 	}
 	sort.Strings(identities)
 	joinedShaString := strings.Join(identities[:], ",")
-	identitiesSha256 := sha256.Sum256([]byte(joinedShaString))
-	return fmt.Sprintf("%x", identitiesSha256), nil
+	return digest([]byte(joinedShaString)), nil
 }
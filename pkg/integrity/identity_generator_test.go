@@ -16,6 +16,7 @@
 package integrity
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -58,3 +59,28 @@ func TestGenerateIdentityUniqueness(t *testing.T) {
 		t.Fatalf("Error. The generated identities should be diffrent")
 	}
 }
+
+func TestNewIdentityGenerator(t *testing.T) {
+	const pathToSourceCode = "../../test_utils/source_for_testing/code_for_testing/"
+
+	sha256Identity, err := new(Sha256).GenerateIdentity(pathToSourceCode)
+	if err != nil {
+		t.Fatalf("Failed to generate code identity for code in: %s", pathToSourceCode)
+	}
+	for algorithm, length := range map[string]int{"": 64, DigestSha256: 64, "SHA512": 128, DigestSha512: 128} {
+		generator, err := NewIdentityGenerator(algorithm)
+		if err != nil {
+			t.Fatalf("unexpected error for digest algorithm: %q: %v", algorithm, err)
+		}
+		identity, err := generator.GenerateIdentity(pathToSourceCode)
+		if err != nil {
+			t.Fatalf("Failed to generate %q code identity for code in: %s", algorithm, pathToSourceCode)
+		}
+		if len(identity) != length || (length == 64) != (identity == sha256Identity) {
+			t.Fatalf("unexpected %q identity: %s", algorithm, identity)
+		}
+	}
+	if _, err = NewIdentityGenerator("md5"); err == nil || !strings.Contains(err.Error(), "unsupported digest algorithm: md5") {
+		t.Fatalf("expected an unsupported digest algorithm error, got: %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// GenerateZipIdentity returns the sha256 identity of the files of the zip, see computeNormalizedDigest.
+func (o *Sha256) GenerateZipIdentity(zipData []byte) (string, error) {
+	return computeNormalizedDigest(zipData)
+}
+
+// GenerateZipIdentity returns the sha512 identity of the files of the zip, see computeNormalizedDigest.
+func (o *Sha512) GenerateZipIdentity(zipData []byte) (string, error) {
+	return normalizedDigest(zipData, func(data []byte) string {
+		return fmt.Sprintf("%x", sha512.Sum512(data))
+	})
+}
+
+// computeNormalizedDigest returns the digest of the files of the zip, their paths and contents, whichever their order
+// in the archive, their modification times or the zip metadata. A rebuild of the same code zipped again has the same
+// digest, which is the identity GenerateIdentity computes once the zip is extracted, as a deployed function is.
+func computeNormalizedDigest(zipData []byte) (string, error) {
+	return normalizedDigest(zipData, func(data []byte) string {
+		return fmt.Sprintf("%x", sha256.Sum256(data))
+	})
+}
+
+func normalizedDigest(zipData []byte, digest func([]byte) string) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
+	if err != nil {
+		return "", fmt.Errorf("failed to open zip archive: %w", err)
+	}
+	var identities []string
+	names := map[string]bool{}
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		name := path.Clean(f.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return "", fmt.Errorf("invalid file path in zip archive: %s", f.Name)
+		}
+		if names[name] {
+			return "", fmt.Errorf("duplicate file in zip archive: %s", f.Name)
+		}
+		names[name] = true
+		content, err := readZipFile(f)
+		if err != nil {
+			return "", err
+		}
+		identities = append(identities, digest([]byte(fmt.Sprintf("%x", content)+name)))
+	}
+	sort.Strings(identities)
+	return digest([]byte(strings.Join(identities, ","))), nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	reader, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file in zip archive: %s: %w", f.Name, err)
+	}
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file in zip archive: %s: %w", f.Name, err)
+	}
+	return content, nil
+}
@@ -0,0 +1,117 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type zipEntry struct {
+	name    string
+	content string
+}
+
+func zipOf(t *testing.T, modified time.Time, entries ...zipEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	writer.SetComment("built at " + modified.String())
+	for _, entry := range entries {
+		f, err := writer.CreateHeader(&zip.FileHeader{Name: entry.name, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = f.Write([]byte(entry.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestComputeNormalizedDigest(t *testing.T) {
+	handler := zipEntry{"main.py", "def handler(event, context):\n    return 'ok'\n"}
+	helper := zipEntry{"lib/helper.py", "VALUE = 1\n"}
+	built := zipOf(t, time.Date(2022, 11, 1, 10, 0, 0, 0, time.UTC), handler, helper)
+	rebuilt := zipOf(t, time.Date(2023, 3, 15, 8, 30, 0, 0, time.UTC), helper, zipEntry{"lib/", ""}, handler)
+	if bytes.Equal(built, rebuilt) {
+		t.Fatalf("expected the zips to differ")
+	}
+
+	digest, err := computeNormalizedDigest(built)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rebuiltDigest, err := computeNormalizedDigest(rebuilt)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if digest != rebuiltDigest {
+		t.Fatalf("expected differently ordered zips of the same files to have the same digest, got: %s, %s", digest, rebuiltDigest)
+	}
+
+	changed, err := computeNormalizedDigest(zipOf(t, time.Time{}, handler, zipEntry{"lib/helper.py", "VALUE = 2\n"}))
+	if err != nil || changed == digest {
+		t.Fatalf("expected changed content to change the digest, got: %s, %v", changed, err)
+	}
+	renamed, err := computeNormalizedDigest(zipOf(t, time.Time{}, handler, zipEntry{"lib/other.py", helper.content}))
+	if err != nil || renamed == digest {
+		t.Fatalf("expected a renamed file to change the digest, got: %s, %v", renamed, err)
+	}
+
+	// the digest is the identity of the extracted files, the one a deployed function is verified with
+	dir := filepath.Join(t.TempDir(), "code")
+	for _, entry := range []zipEntry{handler, helper} {
+		path := filepath.Join(dir, entry.name)
+		if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err = os.WriteFile(path, []byte(entry.content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	identity, err := new(Sha256).GenerateIdentity(dir)
+	if err != nil || identity != digest {
+		t.Fatalf("expected the identity of the extracted files: %s, got: %s, %v", identity, digest, err)
+	}
+	sha512Identity, err := new(Sha512).GenerateIdentity(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if zipIdentity, err := new(Sha512).GenerateZipIdentity(rebuilt); err != nil || zipIdentity != sha512Identity {
+		t.Fatalf("expected the sha512 identity of the extracted files: %s, got: %s, %v", sha512Identity, zipIdentity, err)
+	}
+}
+
+func TestComputeNormalizedDigestRejectsInvalidZips(t *testing.T) {
+	tests := map[string][]byte{
+		"not a zip":      []byte("not a zip"),
+		"duplicate file": zipOf(t, time.Time{}, zipEntry{"main.py", "a"}, zipEntry{"./main.py", "b"}),
+		"outside path":   zipOf(t, time.Time{}, zipEntry{"../main.py", "a"}),
+	}
+	for name, zipData := range tests {
+		if _, err := computeNormalizedDigest(zipData); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"fmt"
+	"golang.org/x/exp/slog"
+	"io"
+	"strings"
+)
+
+// Formats of the records written by the loggers of NewLogger.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+const (
+	DefaultLevel  = "info"
+	DefaultFormat = FormatText
+	redacted      = "REDACTED"
+)
+
+var levels = map[string]slog.Level{
+	"debug": slog.DebugLevel,
+	"info":  slog.InfoLevel,
+	"warn":  slog.WarnLevel,
+	"error": slog.ErrorLevel,
+}
+
+// sensitiveKeys are substrings of the attribute keys whose values are never written, such as a secret key or the
+// auth header of a webhook.
+var sensitiveKeys = []string{"secret", "password", "token", "credential", "privatekey", "private-key", "header", "webhook"}
+
+func ParseLevel(level string) (slog.Level, error) {
+	l, ok := levels[strings.ToLower(level)]
+	if !ok {
+		return 0, fmt.Errorf("unsupported log level: %s, expected one of: debug, info, warn, error", level)
+	}
+	return l, nil
+}
+
+// NewLogger returns a logger writing the records of at least the given level to w, as logfmt text or as one JSON
+// object per line. The values of attributes with a sensitive key are redacted.
+func NewLogger(w io.Writer, level string, format string) (slog.Logger, error) {
+	l, err := ParseLevel(level)
+	if err != nil {
+		return slog.Logger{}, err
+	}
+	handlerOptions := slog.HandlerOptions{Level: l, ReplaceAttr: redact}
+	switch strings.ToLower(format) {
+	case FormatText:
+		return slog.New(handlerOptions.NewTextHandler(w)), nil
+	case FormatJSON:
+		return slog.New(handlerOptions.NewJSONHandler(w)), nil
+	}
+	return slog.Logger{}, fmt.Errorf("unsupported log format: %s, expected one of: %s, %s", format, FormatText, FormatJSON)
+}
+
+// Configure sets the logger of the package level functions of slog, used throughout function-clarity.
+func Configure(w io.Writer, level string, format string) error {
+	logger, err := NewLogger(w, level, format)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+func redact(a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return slog.String(a.Key, redacted)
+		}
+	}
+	return a
+}
@@ -0,0 +1,75 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNewLoggerFiltersByLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(&buf, "warn", FormatText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("verifying function", "function", "f1")
+	logger.Warn("verification of function timed out, skipping", "function", "f2")
+	out := buf.String()
+	if strings.Contains(out, "f1") || !strings.Contains(out, "level=WARN") || !strings.Contains(out, "function=f2") {
+		t.Fatalf("expected only the warning to be logged, got: %s", out)
+	}
+}
+
+func TestNewLoggerWritesJSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(&buf, "debug", "JSON")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Debug("verifying function", "function", "f1")
+	record := map[string]interface{}{}
+	if err = json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("expected a json record, got: %s: %v", buf.String(), err)
+	}
+	if record["msg"] != "verifying function" || record["function"] != "f1" || record["level"] != "DEBUG" {
+		t.Fatalf("unexpected record: %v", record)
+	}
+}
+
+func TestNewLoggerRedactsSensitiveAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(&buf, "info", FormatText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("config loaded", "secretKey", "s3cr3t", "webhookHeaders", map[string]string{"Authorization": "Bearer t0k3n"}, "region", "us-east-1")
+	out := buf.String()
+	if strings.Contains(out, "s3cr3t") || strings.Contains(out, "t0k3n") || !strings.Contains(out, "region=us-east-1") {
+		t.Fatalf("expected the credentials to be redacted, got: %s", out)
+	}
+}
+
+func TestNewLoggerRejectsUnsupportedOptions(t *testing.T) {
+	if _, err := NewLogger(&bytes.Buffer{}, "verbose", FormatText); err == nil {
+		t.Fatal("expected an unsupported level to fail")
+	}
+	if _, err := NewLogger(&bytes.Buffer{}, "info", "xml"); err == nil {
+		t.Fatal("expected an unsupported format to fail")
+	}
+}
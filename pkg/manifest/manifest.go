@@ -0,0 +1,142 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manifest
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/verify"
+	"gopkg.in/yaml.v3"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	DeviationMissing       = "missing"
+	DeviationDigestDiffers = "digest-differs"
+	DeviationNotVerified   = "not-verified"
+)
+
+// Manifest lists the functions of a release with the digest each is expected to run. The digest is the code sha256
+// reported by lambda, base64 or hex encoded, optionally prefixed with sha256:. It is read as yaml, so a json
+// manifest is accepted too.
+type Manifest struct {
+	Functions []Function `yaml:"functions"`
+}
+
+type Function struct {
+	Name   string `yaml:"name"`
+	Digest string `yaml:"digest"`
+}
+
+type Deviation struct {
+	FunctionName string
+	Kind         string
+	Details      string
+}
+
+func Load(path string) (*Manifest, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %s: %w", path, err)
+	}
+	m := &Manifest{}
+	if err = yaml.Unmarshal(content, m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %s: %w", path, err)
+	}
+	if len(m.Functions) == 0 {
+		return nil, fmt.Errorf("invalid manifest: %s: no functions listed", path)
+	}
+	listed := map[string]bool{}
+	for _, function := range m.Functions {
+		if function.Name == "" || function.Digest == "" {
+			return nil, fmt.Errorf("invalid manifest: %s: every function requires a name and a digest", path)
+		}
+		if listed[function.Name] {
+			return nil, fmt.Errorf("invalid manifest: %s: function: %s listed more than once", path, function.Name)
+		}
+		listed[function.Name] = true
+	}
+	return m, nil
+}
+
+// Names returns the names of the listed functions.
+func (m *Manifest) Names() map[string]bool {
+	names := map[string]bool{}
+	for _, function := range m.Functions {
+		names[function.Name] = true
+	}
+	return names
+}
+
+// Check reports every listed function that isn't deployed, runs another digest than expected, or didn't pass
+// verification. The results hold the verification result of each deployed listed function, keyed by function name.
+// Functions deployed but not listed are ignored. Deviations are sorted by function name.
+func (m *Manifest) Check(live []clients.FunctionConfig, results map[string]string) []Deviation {
+	var deviations []Deviation
+	liveByName := map[string]clients.FunctionConfig{}
+	for _, function := range live {
+		liveByName[function.FunctionName] = function
+	}
+	for _, expected := range m.Functions {
+		actual, ok := liveByName[expected.Name]
+		if !ok {
+			deviations = append(deviations, Deviation{FunctionName: expected.Name, Kind: DeviationMissing,
+				Details: "function is in the manifest but not deployed"})
+			continue
+		}
+		if !digestMatches(expected.Digest, actual.CodeSha256) {
+			deviations = append(deviations, Deviation{FunctionName: expected.Name, Kind: DeviationDigestDiffers,
+				Details: fmt.Sprintf("expected: %s, deployed: %s", expected.Digest, actual.CodeSha256)})
+		}
+		if result := results[expected.Name]; result != verify.ResultPassed {
+			if result == "" {
+				result = "not run"
+			}
+			deviations = append(deviations, Deviation{FunctionName: expected.Name, Kind: DeviationNotVerified,
+				Details: "verification result: " + result})
+		}
+	}
+	sort.SliceStable(deviations, func(i, j int) bool {
+		return deviations[i].FunctionName < deviations[j].FunctionName
+	})
+	return deviations
+}
+
+// digestMatches compares the digests as bytes, lambda reports the code sha256 of zip functions base64 encoded and
+// the image digest of image functions hex encoded.
+func digestMatches(expected string, actual string) bool {
+	expectedSum, expectedOk := decodeDigest(expected)
+	actualSum, actualOk := decodeDigest(actual)
+	if !expectedOk || !actualOk {
+		return strings.TrimPrefix(expected, "sha256:") == strings.TrimPrefix(actual, "sha256:")
+	}
+	return string(expectedSum) == string(actualSum)
+}
+
+func decodeDigest(digest string) ([]byte, bool) {
+	digest = strings.TrimPrefix(digest, "sha256:")
+	if sum, err := hex.DecodeString(digest); err == nil && len(sum) == 32 {
+		return sum, true
+	}
+	if sum, err := base64.StdEncoding.DecodeString(digest); err == nil && len(sum) == 32 {
+		return sum, true
+	}
+	return nil, false
+}
@@ -0,0 +1,96 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manifest
+
+import (
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/verify"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	zipDigest    = "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+	zipDigestHex = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+func TestCheck(t *testing.T) {
+	release := &Manifest{Functions: []Function{
+		{Name: "released", Digest: zipDigest},
+		{Name: "hex", Digest: "sha256:" + zipDigestHex},
+		{Name: "patched", Digest: zipDigest},
+		{Name: "unsigned", Digest: zipDigest},
+		{Name: "deleted", Digest: zipDigest},
+	}}
+	live := []clients.FunctionConfig{
+		{FunctionName: "released", CodeSha256: zipDigest},
+		{FunctionName: "hex", CodeSha256: zipDigest},
+		{FunctionName: "patched", CodeSha256: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa="},
+		{FunctionName: "unsigned", CodeSha256: zipDigest},
+		{FunctionName: "unlisted", CodeSha256: "bbb"},
+	}
+	results := map[string]string{"released": verify.ResultPassed, "hex": verify.ResultPassed, "patched": verify.ResultPassed,
+		"unsigned": verify.ResultFailed}
+	expected := []Deviation{
+		{FunctionName: "deleted", Kind: DeviationMissing},
+		{FunctionName: "patched", Kind: DeviationDigestDiffers},
+		{FunctionName: "unsigned", Kind: DeviationNotVerified, Details: "verification result: failed"},
+	}
+	deviations := release.Check(live, results)
+	if len(deviations) != len(expected) {
+		t.Fatalf("expected deviations: %+v, got: %+v", expected, deviations)
+	}
+	for i, d := range deviations {
+		if d.FunctionName != expected[i].FunctionName || d.Kind != expected[i].Kind {
+			t.Fatalf("expected deviation: %+v, got: %+v", expected[i], d)
+		}
+		if expected[i].Details != "" && d.Details != expected[i].Details {
+			t.Fatalf("expected details: %s, got: %s", expected[i].Details, d.Details)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{name: "yaml", content: "functions:\n  - name: a\n    digest: " + zipDigest + "\n"},
+		{name: "json", content: `{"functions": [{"name": "a", "digest": "` + zipDigestHex + `"}]}`},
+		{name: "empty", content: "functions: []\n", err: "no functions listed"},
+		{name: "missing digest", content: "functions:\n  - name: a\n", err: "requires a name and a digest"},
+		{name: "duplicate", content: "functions:\n  - name: a\n    digest: x\n  - name: a\n    digest: y\n", err: "listed more than once"},
+	}
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), "manifest.yaml")
+		if err := os.WriteFile(path, []byte(test.content), 0600); err != nil {
+			t.Fatalf("failed to write manifest: %v", err)
+		}
+		_, err := Load(path)
+		if test.err == "" {
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", test.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Fatalf("%s: expected error containing: %s, got: %v", test.name, test.err, err)
+		}
+	}
+}
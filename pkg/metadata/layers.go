@@ -0,0 +1,90 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LayersConfig holds the ordered layer version ARNs attached to a function. The order is kept because a
+// layer extracted later overrides files of the layers before it.
+type LayersConfig struct {
+	Arns []string `json:"arns"`
+}
+
+type LayerDiff struct {
+	Added     []string
+	Removed   []string
+	Reordered bool
+}
+
+// DiffLayers compares the current layers of a function with the signed ones. A layer version ARN contains the
+// version, so a layer replaced by another version of it is reported as removed and added.
+func (c *LayersConfig) DiffLayers(current *LayersConfig) LayerDiff {
+	diff := LayerDiff{}
+	signed := map[string]bool{}
+	for _, arn := range c.Arns {
+		signed[arn] = true
+	}
+	attached := map[string]bool{}
+	var currentCommon []string
+	for _, arn := range current.Arns {
+		attached[arn] = true
+		if signed[arn] {
+			currentCommon = append(currentCommon, arn)
+		} else {
+			diff.Added = append(diff.Added, arn)
+		}
+	}
+	var signedCommon []string
+	for _, arn := range c.Arns {
+		if attached[arn] {
+			signedCommon = append(signedCommon, arn)
+		} else {
+			diff.Removed = append(diff.Removed, arn)
+		}
+	}
+	for i := range signedCommon {
+		if signedCommon[i] != currentCommon[i] {
+			diff.Reordered = true
+			break
+		}
+	}
+	return diff
+}
+
+func (d LayerDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && !d.Reordered
+}
+
+func (d LayerDiff) String() string {
+	var changes []string
+	if len(d.Added) > 0 {
+		changes = append(changes, fmt.Sprintf("added: [%s]", strings.Join(d.Added, ", ")))
+	}
+	if len(d.Removed) > 0 {
+		changes = append(changes, fmt.Sprintf("removed: [%s]", strings.Join(d.Removed, ", ")))
+	}
+	if d.Reordered {
+		changes = append(changes, "reordered")
+	}
+	return strings.Join(changes, ", ")
+}
+
+func (c *LayersConfig) String() string {
+	return fmt.Sprintf("[%s]", strings.Join(c.Arns, ", "))
+}
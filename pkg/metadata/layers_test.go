@@ -0,0 +1,68 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffLayers(t *testing.T) {
+	signed := &LayersConfig{Arns: []string{"arn:layer:a:1", "arn:layer:b:3", "arn:layer:c:2"}}
+	tests := []struct {
+		name     string
+		current  []string
+		expected LayerDiff
+	}{
+		{"identical", []string{"arn:layer:a:1", "arn:layer:b:3", "arn:layer:c:2"}, LayerDiff{}},
+		{"added", []string{"arn:layer:a:1", "arn:layer:b:3", "arn:layer:c:2", "arn:layer:d:1"},
+			LayerDiff{Added: []string{"arn:layer:d:1"}}},
+		{"removed", []string{"arn:layer:a:1", "arn:layer:c:2"}, LayerDiff{Removed: []string{"arn:layer:b:3"}}},
+		{"reordered", []string{"arn:layer:c:2", "arn:layer:a:1", "arn:layer:b:3"}, LayerDiff{Reordered: true}},
+		{"version substituted", []string{"arn:layer:a:1", "arn:layer:b:4", "arn:layer:c:2"},
+			LayerDiff{Added: []string{"arn:layer:b:4"}, Removed: []string{"arn:layer:b:3"}}},
+		{"all removed", []string{}, LayerDiff{Removed: []string{"arn:layer:a:1", "arn:layer:b:3", "arn:layer:c:2"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diff := signed.DiffLayers(&LayersConfig{Arns: test.current})
+			if !reflect.DeepEqual(diff, test.expected) {
+				t.Fatalf("expected diff: {%s}, got: {%s}", test.expected, diff)
+			}
+			if diff.IsEmpty() != (test.name == "identical") {
+				t.Fatalf("unexpected emptiness for diff: {%s}", diff)
+			}
+		})
+	}
+}
+
+func TestEmptyLayersBaselineIsRecorded(t *testing.T) {
+	m := &SignatureMetadata{Layers: &LayersConfig{Arns: []string{}}}
+	if m.IsEmpty() {
+		t.Fatalf("expected metadata with an empty layers baseline not to be empty")
+	}
+	content, err := m.Marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal metadata: %v", err)
+	}
+	parsed, err := Unmarshal(content)
+	if err != nil {
+		t.Fatalf("failed to unmarshal metadata: %v", err)
+	}
+	if parsed.Layers == nil {
+		t.Fatalf("expected the empty layers baseline to survive a round trip, got: %s", content)
+	}
+}
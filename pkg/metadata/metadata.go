@@ -0,0 +1,110 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const FileType = "metadata.json"
+
+// SignatureMetadata holds function configuration recorded at sign time, it is stored next to the code
+// signature under FunctionFileName(<function arn>, <code identity>).metadata.json when the code is signed for a
+// baseline function, under <code identity>.metadata.json otherwise. The metadata is content addressed and signed on its
+// own, so Identity of its content has a signature in the bucket just like a code identity. DigestAlgorithm is the
+// algorithm of the code identity, only recorded when it isn't the default sha256.
+type SignatureMetadata struct {
+	Concurrency     *ConcurrencyConfig `json:"concurrency,omitempty"`
+	Layers          *LayersConfig      `json:"layers,omitempty"`
+	Annotations     map[string]string  `json:"annotations,omitempty"`
+	DigestAlgorithm string             `json:"digestAlgorithm,omitempty"`
+}
+
+type ConcurrencyConfig struct {
+	ReservedConcurrentExecutions *int32           `json:"reservedConcurrentExecutions,omitempty"`
+	ProvisionedConcurrency       map[string]int32 `json:"provisionedConcurrency,omitempty"`
+}
+
+func (m *SignatureMetadata) IsEmpty() bool {
+	return m.Concurrency == nil && m.Layers == nil && len(m.Annotations) == 0 && m.DigestAlgorithm == ""
+}
+
+func (m *SignatureMetadata) Marshal() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+func Unmarshal(content []byte) (*SignatureMetadata, error) {
+	m := &SignatureMetadata{}
+	if err := json.Unmarshal(content, m); err != nil {
+		return nil, fmt.Errorf("failed to parse signature metadata: %w", err)
+	}
+	return m, nil
+}
+
+func Identity(content []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(content))
+}
+
+// FunctionFileName names the signature metadata recorded for the function, so functions running the same code keep
+// a concurrency and layers baseline each.
+func FunctionFileName(functionArn string, codeIdentity string) string {
+	return strings.ReplaceAll(functionArn, ":", "_") + "." + codeIdentity
+}
+
+// FileNames returns the names the signature metadata of the function may be stored under, the one recorded for the
+// function first, then the one recorded for its code when it was signed without a baseline function.
+func FileNames(functionArn string, codeIdentity string) []string {
+	return []string{FunctionFileName(functionArn, codeIdentity), codeIdentity}
+}
+
+func (c *ConcurrencyConfig) Equal(other *ConcurrencyConfig) bool {
+	if (c.ReservedConcurrentExecutions == nil) != (other.ReservedConcurrentExecutions == nil) {
+		return false
+	}
+	if c.ReservedConcurrentExecutions != nil && *c.ReservedConcurrentExecutions != *other.ReservedConcurrentExecutions {
+		return false
+	}
+	if len(c.ProvisionedConcurrency) != len(other.ProvisionedConcurrency) {
+		return false
+	}
+	for qualifier, value := range c.ProvisionedConcurrency {
+		if otherValue, ok := other.ProvisionedConcurrency[qualifier]; !ok || otherValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *ConcurrencyConfig) String() string {
+	reserved := "unreserved"
+	if c.ReservedConcurrentExecutions != nil {
+		reserved = fmt.Sprintf("%d", *c.ReservedConcurrentExecutions)
+	}
+	var qualifiers []string
+	for qualifier := range c.ProvisionedConcurrency {
+		qualifiers = append(qualifiers, qualifier)
+	}
+	sort.Strings(qualifiers)
+	var provisioned []string
+	for _, qualifier := range qualifiers {
+		provisioned = append(provisioned, fmt.Sprintf("%s=%d", qualifier, c.ProvisionedConcurrency[qualifier]))
+	}
+	return fmt.Sprintf("reserved concurrency: %s, provisioned concurrency: [%s]", reserved, strings.Join(provisioned, ", "))
+}
@@ -0,0 +1,66 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"testing"
+)
+
+func int32Ptr(v int32) *int32 {
+	return &v
+}
+
+func TestConcurrencyConfigEqual(t *testing.T) {
+	baseline := &ConcurrencyConfig{ReservedConcurrentExecutions: int32Ptr(10), ProvisionedConcurrency: map[string]int32{"live": 5}}
+	tests := []struct {
+		name    string
+		current *ConcurrencyConfig
+		equal   bool
+	}{
+		{"identical", &ConcurrencyConfig{ReservedConcurrentExecutions: int32Ptr(10), ProvisionedConcurrency: map[string]int32{"live": 5}}, true},
+		{"reserved zeroed", &ConcurrencyConfig{ReservedConcurrentExecutions: int32Ptr(0), ProvisionedConcurrency: map[string]int32{"live": 5}}, false},
+		{"reserved removed", &ConcurrencyConfig{ProvisionedConcurrency: map[string]int32{"live": 5}}, false},
+		{"provisioned changed", &ConcurrencyConfig{ReservedConcurrentExecutions: int32Ptr(10), ProvisionedConcurrency: map[string]int32{"live": 1}}, false},
+		{"provisioned moved to another alias", &ConcurrencyConfig{ReservedConcurrentExecutions: int32Ptr(10), ProvisionedConcurrency: map[string]int32{"beta": 5}}, false},
+		{"provisioned removed", &ConcurrencyConfig{ReservedConcurrentExecutions: int32Ptr(10)}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if equal := baseline.Equal(test.current); equal != test.equal {
+				t.Fatalf("expected equal to be %v for baseline {%s} and current {%s}", test.equal, baseline, test.current)
+			}
+		})
+	}
+}
+
+func TestSignatureMetadataIdentityIsStable(t *testing.T) {
+	m := &SignatureMetadata{Concurrency: &ConcurrencyConfig{ReservedConcurrentExecutions: int32Ptr(3)}}
+	content, err := m.Marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal metadata: %v", err)
+	}
+	parsed, err := Unmarshal(content)
+	if err != nil {
+		t.Fatalf("failed to unmarshal metadata: %v", err)
+	}
+	reMarshaled, err := parsed.Marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal metadata: %v", err)
+	}
+	if Identity(content) != Identity(reMarshaled) {
+		t.Fatalf("expected metadata identity to survive a round trip")
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/cosign/pkg/signature"
+)
+
+const SignerPinFileType = "pin.json"
+
+// SignerPin is the signer a function was verified with the first time. Key signers are pinned by the sha256
+// fingerprint of their public key, keyless signers by their certificate subject and issuer, since their keys are
+// ephemeral. The algorithm is pinned in both cases so a signer switching to a weaker key is detected.
+type SignerPin struct {
+	Algorithm   string `json:"algorithm"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+	Subject     string `json:"subject,omitempty"`
+	Issuer      string `json:"issuer,omitempty"`
+}
+
+func PinForPublicKey(pub crypto.PublicKey) (*SignerPin, error) {
+	algorithm, err := keyAlgorithm(pub)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public key: %w", err)
+	}
+	return &SignerPin{Algorithm: algorithm, Fingerprint: fmt.Sprintf("%x", sha256.Sum256(der))}, nil
+}
+
+func PinForCertificate(cert *x509.Certificate) (*SignerPin, error) {
+	algorithm, err := keyAlgorithm(cert.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	extensions := cosign.CertExtensions{Cert: cert}
+	return &SignerPin{Algorithm: algorithm, Subject: signature.CertSubject(cert), Issuer: extensions.GetIssuer()}, nil
+}
+
+func keyAlgorithm(pub crypto.PublicKey) (string, error) {
+	switch key := pub.(type) {
+	case *ecdsa.PublicKey:
+		return "ecdsa-" + key.Curve.Params().Name, nil
+	case *rsa.PublicKey:
+		return fmt.Sprintf("rsa-%d", key.N.BitLen()), nil
+	case ed25519.PublicKey:
+		return "ed25519", nil
+	default:
+		return "", fmt.Errorf("unsupported public key type: %T", pub)
+	}
+}
+
+func (p *SignerPin) Equal(other *SignerPin) bool {
+	return *p == *other
+}
+
+func (p *SignerPin) String() string {
+	if p.Fingerprint != "" {
+		return fmt.Sprintf("algorithm: %s, key fingerprint: %s", p.Algorithm, p.Fingerprint)
+	}
+	return fmt.Sprintf("algorithm: %s, subject: %s, issuer: %s", p.Algorithm, p.Subject, p.Issuer)
+}
+
+func (p *SignerPin) Marshal() ([]byte, error) {
+	return json.Marshal(p)
+}
+
+func UnmarshalSignerPin(content []byte) (*SignerPin, error) {
+	p := &SignerPin{}
+	if err := json.Unmarshal(content, p); err != nil {
+		return nil, fmt.Errorf("failed to parse signer pin: %w", err)
+	}
+	return p, nil
+}
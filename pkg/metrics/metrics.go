@@ -0,0 +1,144 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const contentType = "text/plain; version=0.0.4"
+
+// DurationBuckets are the upper bounds, in seconds, of the buckets of the verification duration histogram. Verifying
+// a function downloads its code or image, so it takes from a fraction of a second to minutes.
+var DurationBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+
+// Registry holds the verification counters by result and the verification duration histogram.
+type Registry struct {
+	mu            sync.Mutex
+	verifications map[string]int64
+	// bucketCounts holds the observations of each bucket of DurationBuckets, the last one is the +Inf bucket
+	bucketCounts  []int64
+	durationSum   float64
+	durationCount int64
+}
+
+func NewRegistry() *Registry {
+	return &Registry{verifications: map[string]int64{}, bucketCounts: make([]int64, len(DurationBuckets)+1)}
+}
+
+// defaultRegistry is nil until Enable is called, the verifications aren't recorded meanwhile.
+var defaultRegistry atomic.Pointer[Registry]
+
+// Enable starts recording the verifications in the default registry and returns it, it is a no-op once enabled.
+func Enable() *Registry {
+	defaultRegistry.CompareAndSwap(nil, NewRegistry())
+	return defaultRegistry.Load()
+}
+
+// Default returns the default registry, nil when metrics aren't enabled.
+func Default() *Registry {
+	return defaultRegistry.Load()
+}
+
+// ObserveVerification records a verification with its result in the default registry, if metrics are enabled.
+func ObserveVerification(result string, duration time.Duration) {
+	if r := defaultRegistry.Load(); r != nil {
+		r.ObserveVerification(result, duration)
+	}
+}
+
+func (r *Registry) ObserveVerification(result string, duration time.Duration) {
+	seconds := duration.Seconds()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.verifications[result]++
+	r.bucketCounts[sort.SearchFloat64s(DurationBuckets, seconds)]++
+	r.durationSum += seconds
+	r.durationCount++
+}
+
+// Write writes the metrics in the prometheus text exposition format.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var b bytes.Buffer
+	b.WriteString("# HELP fc_verifications_total Number of function verifications by result.\n# TYPE fc_verifications_total counter\n")
+	results := make([]string, 0, len(r.verifications))
+	for result := range r.verifications {
+		results = append(results, result)
+	}
+	sort.Strings(results)
+	for _, result := range results {
+		fmt.Fprintf(&b, "fc_verifications_total{result=%q} %d\n", result, r.verifications[result])
+	}
+	b.WriteString("# HELP fc_verification_duration_seconds Duration of the function verifications.\n# TYPE fc_verification_duration_seconds histogram\n")
+	var cumulative int64
+	for i, bound := range DurationBuckets {
+		cumulative += r.bucketCounts[i]
+		fmt.Fprintf(&b, "fc_verification_duration_seconds_bucket{le=\"%g\"} %d\n", bound, cumulative)
+	}
+	cumulative += r.bucketCounts[len(DurationBuckets)]
+	fmt.Fprintf(&b, "fc_verification_duration_seconds_bucket{le=\"+Inf\"} %d\n", cumulative)
+	fmt.Fprintf(&b, "fc_verification_duration_seconds_sum %g\nfc_verification_duration_seconds_count %d\n", r.durationSum, r.durationCount)
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// Handler serves the metrics of the registry, for a prometheus scrape of /metrics.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		r.Write(w) //nolint:errcheck
+	})
+}
+
+// Push replaces the metrics of the job and instance in the prometheus push gateway at gatewayUrl with the ones of the
+// registry, for a short-lived process such as the verifier lambda that can't be scraped. Each instance pushing its own
+// counters, e.g. every lambda execution environment, needs its own instance label, it is left out when empty.
+func (r *Registry) Push(ctx context.Context, gatewayUrl string, job string, instance string) error {
+	var body bytes.Buffer
+	if err := r.Write(&body); err != nil {
+		return err
+	}
+	pushUrl := strings.TrimSuffix(gatewayUrl, "/") + "/metrics/job/" + url.PathEscape(job)
+	if instance != "" {
+		pushUrl += "/instance/" + url.PathEscape(instance)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, pushUrl, &body)
+	if err != nil {
+		return fmt.Errorf("invalid push gateway url: %s: %w", gatewayUrl, err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push metrics to: %s: %w", gatewayUrl, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to push metrics to: %s: status %d", gatewayUrl, resp.StatusCode)
+	}
+	return nil
+}
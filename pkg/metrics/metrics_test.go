@@ -0,0 +1,109 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteExposition(t *testing.T) {
+	r := NewRegistry()
+	r.ObserveVerification("passed", 200*time.Millisecond)
+	r.ObserveVerification("passed", 3*time.Second)
+	r.ObserveVerification("failed", 5*time.Minute)
+	var out strings.Builder
+	if err := r.Write(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"# TYPE fc_verifications_total counter",
+		`fc_verifications_total{result="passed"} 2`,
+		`fc_verifications_total{result="failed"} 1`,
+		"# TYPE fc_verification_duration_seconds histogram",
+		`fc_verification_duration_seconds_bucket{le="0.1"} 0`,
+		`fc_verification_duration_seconds_bucket{le="0.25"} 1`,
+		`fc_verification_duration_seconds_bucket{le="5"} 2`,
+		`fc_verification_duration_seconds_bucket{le="120"} 2`,
+		`fc_verification_duration_seconds_bucket{le="+Inf"} 3`,
+		"fc_verification_duration_seconds_sum 303.2",
+		"fc_verification_duration_seconds_count 3",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Fatalf("expected metrics to contain: %s, got:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestObserveVerificationOnlyOnceEnabled(t *testing.T) {
+	ObserveVerification("passed", time.Second)
+	if Default() != nil {
+		t.Fatalf("expected no registry before metrics are enabled")
+	}
+	r := Enable()
+	if Enable() != r || Default() != r {
+		t.Fatalf("expected enabling metrics again to keep the registry")
+	}
+	ObserveVerification("error", time.Second)
+	var out strings.Builder
+	r.Write(&out) //nolint:errcheck
+	if strings.Contains(out.String(), `result="passed"`) || !strings.Contains(out.String(), `fc_verifications_total{result="error"} 1`) {
+		t.Fatalf("expected only the verification observed once enabled, got:\n%s", out.String())
+	}
+}
+
+func TestHandlerAndPush(t *testing.T) {
+	r := NewRegistry()
+	r.ObserveVerification("passed", time.Second)
+
+	server := httptest.NewServer(r.Handler())
+	defer server.Close()
+	resp, err := server.Client().Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), `fc_verifications_total{result="passed"} 1`) || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain") {
+		t.Fatalf("unexpected metrics response: %s\n%s", resp.Header.Get("Content-Type"), body)
+	}
+
+	var pushed, method, path string
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		content, _ := io.ReadAll(req.Body)
+		pushed, method, path = string(content), req.Method, req.URL.EscapedPath()
+	}))
+	defer gateway.Close()
+	if err = r.Push(context.Background(), gateway.URL+"/", "function-clarity", "2022/11/01/[$LATEST]abc"); err != nil {
+		t.Fatal(err)
+	}
+	if method != http.MethodPut || path != "/metrics/job/function-clarity/instance/2022%2F11%2F01%2F%5B$LATEST%5Dabc" || !strings.Contains(pushed, "fc_verification_duration_seconds_count 1") {
+		t.Fatalf("unexpected push: %s %s\n%s", method, path, pushed)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer failing.Close()
+	if err = r.Push(context.Background(), failing.URL, "function-clarity", ""); err == nil || !strings.Contains(err.Error(), "status 400") {
+		t.Fatalf("expected a rejected push to fail, got: %v", err)
+	}
+}
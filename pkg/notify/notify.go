@@ -0,0 +1,114 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const webhookTimeout = 10 * time.Second
+
+// The kinds of notifiers init lets choose from.
+const (
+	KindSNS     = "sns"
+	KindSlack   = "slack"
+	KindWebhook = "webhook"
+)
+
+var Kinds = []string{KindSNS, KindSlack, KindWebhook}
+
+// ValidateKinds fails on a notifier kind that isn't one of Kinds.
+func ValidateKinds(kinds []string) error {
+	for _, kind := range kinds {
+		valid := false
+		for _, known := range Kinds {
+			if kind == known {
+				valid = true
+			}
+		}
+		if !valid {
+			return fmt.Errorf("unsupported notifier: %s, expected one of: %s", kind, strings.Join(Kinds, ", "))
+		}
+	}
+	return nil
+}
+
+// VerificationEvent is the notification of a function that failed verification. Its JSON is the message published
+// to sns.
+type VerificationEvent clients.Notification
+
+// Notifier sends the event of a failed verification to a channel.
+type Notifier interface {
+	Notify(ctx context.Context, event VerificationEvent) error
+}
+
+// SNSNotifier publishes the event as JSON to an sns topic.
+type SNSNotifier struct {
+	Client   clients.Client
+	TopicArn string
+}
+
+func (n *SNSNotifier) Notify(ctx context.Context, event VerificationEvent) error {
+	msg, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return n.Client.Notify(string(msg), n.TopicArn)
+}
+
+// ForChannel returns the notifier of a notification channel: the event is posted as JSON to a webhook url, or
+// published to an sns topic arn.
+func ForChannel(client clients.Client, channel string) Notifier {
+	if isWebhook(channel) {
+		return NewWebhookNotifier(channel, nil)
+	}
+	return &SNSNotifier{Client: client, TopicArn: channel}
+}
+
+// post posts the JSON body to the url within the timeout and returns the response status code, any status but 2xx
+// is an error.
+func post(ctx context.Context, url string, body []byte, headers map[string]string, timeout time.Duration) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("error posting the message to webhook: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("error posting the message to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("error posting the message to webhook, status: %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+func isWebhook(channel string) bool {
+	return strings.HasPrefix(channel, "https://") || strings.HasPrefix(channel, "http://")
+}
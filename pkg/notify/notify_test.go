@@ -0,0 +1,100 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type snsClient struct {
+	clients.Client
+	published map[string]string
+}
+
+func (c *snsClient) Notify(msg string, snsArn string) error {
+	c.published[snsArn] = msg
+	return nil
+}
+
+func TestForChannelWebhookAndSns(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+	}))
+	defer server.Close()
+	client := &snsClient{published: map[string]string{}}
+	event := VerificationEvent{FunctionName: "orders", Reason: "unsigned"}
+	if err := ForChannel(client, server.URL).Notify(context.Background(), event); err != nil {
+		t.Fatalf("failed to notify webhook: %v", err)
+	}
+	expected, _ := json.Marshal(clients.Notification(event))
+	if received != string(expected) || len(client.published) != 0 {
+		t.Fatalf("expected the event to be posted to the webhook only, got: %s, published: %v", received, client.published)
+	}
+	topic := "arn:aws:sns:us-east-1:123456789012:payments"
+	if err := ForChannel(client, topic).Notify(context.Background(), event); err != nil {
+		t.Fatalf("failed to notify topic: %v", err)
+	}
+	if client.published[topic] != string(expected) {
+		t.Fatalf("expected the sns message to be the notification json, got: %s", client.published[topic])
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	if err := ForChannel(client, failing.URL).Notify(context.Background(), event); err == nil {
+		t.Fatalf("expected an error when the webhook fails")
+	}
+}
+
+func TestSlackNotifier(t *testing.T) {
+	var message slackMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&message)
+	}))
+	defer server.Close()
+	notifier := &SlackNotifier{WebhookURL: server.URL}
+	event := VerificationEvent{FunctionName: "function:orders", Region: "us-east-1", AccountId: "123456789012", Action: "block",
+		Reason: "code verification error: no signature", Digest: "abc="}
+	if err := notifier.Notify(context.Background(), event); err != nil {
+		t.Fatalf("failed to notify slack: %v", err)
+	}
+	for _, expected := range []string{"function:orders", "us-east-1", "`abc=`", "*Action taken:* block", "no signature"} {
+		if !strings.Contains(message.Text, expected) {
+			t.Fatalf("expected %q in the slack message, got: %s", expected, message.Text)
+		}
+	}
+
+	if text := slackText(VerificationEvent{FunctionName: "function:orders"}); !strings.Contains(text, "*Action taken:* none") {
+		t.Fatalf("expected no action taken, got: %s", text)
+	}
+	if text := slackText(VerificationEvent{FunctionName: "function:orders", Action: "block", DryRun: true}); !strings.Contains(text, "*Action taken:* none, dry run: would block") {
+		t.Fatalf("expected the dry run block, got: %s", text)
+	}
+}
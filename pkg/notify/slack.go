@@ -0,0 +1,69 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SlackNotifier posts the event to a slack incoming webhook as a message.
+type SlackNotifier struct {
+	WebhookURL string
+}
+
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+func (n *SlackNotifier) Notify(ctx context.Context, event VerificationEvent) error {
+	msg, err := json.Marshal(slackMessage{Text: slackText(event)})
+	if err != nil {
+		return err
+	}
+	if _, err = post(ctx, n.WebhookURL, msg, nil, webhookTimeout); err != nil {
+		return fmt.Errorf("failed to notify slack: %w", err)
+	}
+	return nil
+}
+
+// slackText formats the event in slack mrkdwn. The signatures are looked up by the digest of the deployed code, the
+// digest reported is the one that didn't verify.
+func slackText(event VerificationEvent) string {
+	action := event.Action
+	if action == "" {
+		action = "none"
+	}
+	if event.DryRun {
+		action = "none, dry run: would " + action
+	}
+	lines := []string{
+		":rotating_light: *function clarity verification failed*",
+		"*Function:* " + event.FunctionName,
+		"*Region:* " + event.Region,
+		"*Account:* " + event.AccountId,
+	}
+	if event.Digest != "" {
+		lines = append(lines, "*Digest:* `"+event.Digest+"` (deployed, not matching any trusted signature)")
+	}
+	if event.Signer != "" {
+		lines = append(lines, "*Signed by:* "+event.Signer)
+	}
+	lines = append(lines, "*Action taken:* "+action, "*Reason:* "+event.Reason)
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,83 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/exp/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	webhookRetries = 3
+	webhookBackoff = time.Second
+)
+
+// WebhookNotifier posts the event as JSON to a url, with custom headers such as an auth token. Posts failing with a
+// 5xx status or without a response are retried with an exponential backoff, each post is bounded by the timeout.
+type WebhookNotifier struct {
+	URL     string
+	Headers map[string]string
+	Retries int
+	Backoff time.Duration
+	Timeout time.Duration
+}
+
+func NewWebhookNotifier(url string, headers map[string]string) *WebhookNotifier {
+	return &WebhookNotifier{URL: url, Headers: headers, Retries: webhookRetries, Backoff: webhookBackoff, Timeout: webhookTimeout}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, event VerificationEvent) error {
+	msg, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	backoff := n.Backoff
+	for attempt := 0; ; attempt++ {
+		status, err := post(ctx, n.URL, msg, n.Headers, n.Timeout)
+		if err == nil {
+			return nil
+		}
+		if attempt >= n.Retries || (status != 0 && status < http.StatusInternalServerError) {
+			return err
+		}
+		slog.Warn("failed to post to webhook, retrying", "error", err, "backoff", backoff)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// ParseHeaders parses key:value headers, the value may contain colons.
+func ParseHeaders(headers []string) (map[string]string, error) {
+	parsed := map[string]string{}
+	for _, header := range headers {
+		key, value, found := strings.Cut(header, ":")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid webhook header: %s, expected key:value", header)
+		}
+		parsed[key] = strings.TrimSpace(value)
+	}
+	return parsed, nil
+}
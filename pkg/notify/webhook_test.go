@@ -0,0 +1,109 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWebhookNotifierBodyAndHeaders(t *testing.T) {
+	var body map[string]interface{}
+	var authorization, contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		contentType = r.Header.Get("Content-Type")
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}))
+	defer server.Close()
+	notifier := NewWebhookNotifier(server.URL, map[string]string{"Authorization": "Bearer token"})
+	event := VerificationEvent{AccountId: "123456789012", FunctionName: "function:orders", FunctionIdentifier: "arn:aws:lambda:us-east-1:123456789012:function:orders",
+		Action: "detect", Region: "us-east-1", Reason: "unsigned", Digest: "abc="}
+	if err := notifier.Notify(context.Background(), event); err != nil {
+		t.Fatalf("failed to notify webhook: %v", err)
+	}
+	if authorization != "Bearer token" || contentType != "application/json" {
+		t.Fatalf("expected the custom and content type headers, got authorization: %s, content type: %s", authorization, contentType)
+	}
+	expected := map[string]interface{}{"AccountId": "123456789012", "FunctionName": "function:orders",
+		"FunctionIdentifier": "arn:aws:lambda:us-east-1:123456789012:function:orders", "Action": "detect", "Region": "us-east-1",
+		"Reason": "unsigned", "Digest": "abc="}
+	if len(body) != len(expected) {
+		t.Fatalf("unexpected body fields: %v", body)
+	}
+	for key, value := range expected {
+		if body[key] != value {
+			t.Fatalf("expected %s: %v in the body, got: %v", key, value, body)
+		}
+	}
+}
+
+func TestWebhookNotifierRetries(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	notifier := &WebhookNotifier{URL: server.URL, Retries: 3, Backoff: time.Millisecond, Timeout: time.Second}
+	if err := notifier.Notify(context.Background(), VerificationEvent{}); err != nil {
+		t.Fatalf("expected the post to succeed after retries, got: %v", err)
+	}
+	if atomic.LoadInt32(&calls) != 3 {
+		t.Fatalf("expected 3 posts, got: %d", calls)
+	}
+
+	atomic.StoreInt32(&calls, 0)
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer rejecting.Close()
+	notifier.URL = rejecting.URL
+	if err := notifier.Notify(context.Background(), VerificationEvent{}); err == nil || atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("expected a 4xx to fail without retrying, got: %v after %d posts", err, calls)
+	}
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slow.Close()
+	notifier = &WebhookNotifier{URL: slow.URL, Timeout: 20 * time.Millisecond}
+	if err := notifier.Notify(context.Background(), VerificationEvent{}); err == nil {
+		t.Fatalf("expected the post to time out")
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	headers, err := ParseHeaders([]string{"Authorization: Bearer a:b", "X-Team:payments"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if headers["Authorization"] != "Bearer a:b" || headers["X-Team"] != "payments" {
+		t.Fatalf("unexpected headers: %v", headers)
+	}
+	for _, invalid := range []string{"no-colon", ":value"} {
+		if _, err = ParseHeaders([]string{invalid}); err == nil {
+			t.Fatalf("expected an error for header: %s", invalid)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"github.com/spf13/cobra"
+)
+
+const (
+	DefaultLayerCacheDir          = "/tmp/fc-layer-cache"
+	DefaultLayerCacheSizeMb       = 256
+	DefaultMaxConcurrentLayerPull = 4
+)
+
+type LayerCacheOptions struct {
+	Dir                string
+	MaxSizeMb          int64
+	MaxConcurrentPulls int
+}
+
+func (o *LayerCacheOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.Dir, "layer-cache-dir", DefaultLayerCacheDir,
+		"directory in which image layers fetched during verification are cached")
+
+	cmd.Flags().Int64Var(&o.MaxSizeMb, "layer-cache-size", DefaultLayerCacheSizeMb,
+		"maximum size in MB of the image layer cache, 0 disables the cache")
+
+	cmd.Flags().IntVar(&o.MaxConcurrentPulls, "max-concurrent-pulls", DefaultMaxConcurrentLayerPull,
+		"maximum number of image layers pulled concurrently")
+}
@@ -0,0 +1,44 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+)
+
+// DefaultMetricsJob is the job label of the metrics pushed to a push gateway.
+const DefaultMetricsJob = "function-clarity"
+
+type MetricsOptions struct {
+	Enabled     bool
+	PushGateway string
+}
+
+func (o *MetricsOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&o.Enabled, "enable-metrics", false,
+		"record prometheus counters of the verifications by result and a histogram of their duration")
+
+	cmd.Flags().StringVar(&o.PushGateway, "metrics-push-gateway", "",
+		"url of a prometheus push gateway the metrics are pushed to once the verifications are done, with --enable-metrics")
+}
+
+func (o *MetricsOptions) Validate() error {
+	if o.PushGateway != "" && !o.Enabled {
+		return fmt.Errorf("--metrics-push-gateway requires --enable-metrics")
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"github.com/spf13/cobra"
+)
+
+type PackageCacheOptions struct {
+	Dir      string
+	Disabled bool
+}
+
+func (o *PackageCacheOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.Dir, "cache-dir", "",
+		"directory in which the deployment packages downloaded by --all are kept across runs by code sha256, a temporary directory for the run by default")
+
+	cmd.Flags().BoolVar(&o.Disabled, "no-cache", false,
+		"download the deployment package of every function verified by --all, even when it is shared with another function")
+}
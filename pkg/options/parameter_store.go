@@ -0,0 +1,96 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"regexp"
+	"strings"
+)
+
+const (
+	ParameterTierStandard = "Standard"
+	ParameterTierAdvanced = "Advanced"
+
+	maxStandardParameterSize = 4 * 1024
+	maxAdvancedParameterSize = 8 * 1024
+	// maxParameterNameLength and maxParameterHierarchyLevels are the ssm limits on parameter names.
+	maxParameterNameLength      = 1011
+	maxParameterHierarchyLevels = 15
+)
+
+var parameterNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_./-]+$`)
+
+// ParameterStore records every code signature in an ssm parameter named after the function it was signed for, for
+// tooling using parameter store as a registry of the signed code.
+type ParameterStore struct {
+	Prefix   string
+	Function string
+}
+
+func (o *ParameterStore) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.Prefix, "ssm-parameter-prefix", "",
+		"record the digest, signature location and signer of the signed code in an ssm parameter under this prefix, "+
+			"named <prefix>/<account>/<region>/<function name>, i.e: /function-clarity/signatures")
+
+	cmd.Flags().StringVar(&o.Function, "ssm-function", "",
+		"arn of the function the ssm parameter is written for, defaults to --baseline-function")
+}
+
+func (o *ParameterStore) Enabled() bool {
+	return o.Prefix != ""
+}
+
+// ParameterName returns the name of the parameter of the function, the function arn
+// arn:partition:lambda:region:account:function:name is mapped to <prefix>/<account>/<region>/<name> since ssm
+// names don't allow colons.
+func (o *ParameterStore) ParameterName(functionArn string) (string, error) {
+	parts := strings.Split(functionArn, ":")
+	if len(parts) < 7 || parts[0] != "arn" || parts[2] != "lambda" || parts[5] != "function" {
+		return "", fmt.Errorf("invalid function arn: %s, the ssm parameter requires arn:aws:lambda:<region>:<account>:function:<name>", functionArn)
+	}
+	prefix := strings.TrimSuffix(o.Prefix, "/")
+	if !strings.HasPrefix(prefix, "/") {
+		return "", fmt.Errorf("invalid ssm parameter prefix: %s, expected a path starting with /", o.Prefix)
+	}
+	if root := strings.ToLower(strings.SplitN(strings.TrimPrefix(prefix, "/"), "/", 2)[0]); strings.HasPrefix(root, "aws") || strings.HasPrefix(root, "ssm") {
+		return "", fmt.Errorf("invalid ssm parameter prefix: %s, names starting with aws or ssm are reserved", o.Prefix)
+	}
+	name := fmt.Sprintf("%s/%s/%s/%s", prefix, parts[4], parts[3], parts[6])
+	if !parameterNameRegex.MatchString(name) {
+		return "", fmt.Errorf("invalid ssm parameter name: %s, only letters, numbers and _.-/ are allowed", name)
+	}
+	if len(name) > maxParameterNameLength {
+		return "", fmt.Errorf("ssm parameter name: %s is longer than %d characters", name, maxParameterNameLength)
+	}
+	if levels := strings.Count(name, "/"); levels > maxParameterHierarchyLevels {
+		return "", fmt.Errorf("ssm parameter name: %s has %d levels, at most %d are allowed", name, levels, maxParameterHierarchyLevels)
+	}
+	return name, nil
+}
+
+// Tier returns the tier of a parameter holding a value of the given size, values up to 4KB are stored as standard
+// parameters and larger ones up to 8KB as advanced parameters.
+func (o *ParameterStore) Tier(size int) (string, error) {
+	switch {
+	case size <= maxStandardParameterSize:
+		return ParameterTierStandard, nil
+	case size <= maxAdvancedParameterSize:
+		return ParameterTierAdvanced, nil
+	}
+	return "", fmt.Errorf("the value is %d bytes, ssm parameters hold at most %d bytes", size, maxAdvancedParameterSize)
+}
@@ -0,0 +1,55 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParameterName(t *testing.T) {
+	o := &ParameterStore{Prefix: "/function-clarity/signatures/"}
+	name, err := o.ParameterName("arn:aws:lambda:us-east-1:123456789012:function:orders")
+	if err != nil || name != "/function-clarity/signatures/123456789012/us-east-1/orders" {
+		t.Fatalf("unexpected parameter name: %s, %v", name, err)
+	}
+	if _, err = o.ParameterName("orders"); err == nil {
+		t.Fatalf("expected an error for a function name instead of an arn")
+	}
+	for _, prefix := range []string{"function-clarity", "/aws/signatures", "/SSM-signatures", "/with space"} {
+		o.Prefix = prefix
+		if _, err = o.ParameterName("arn:aws:lambda:us-east-1:123456789012:function:orders"); err == nil {
+			t.Fatalf("expected an error for prefix: %s", prefix)
+		}
+	}
+	o.Prefix = "/" + strings.Repeat("a/", 14)
+	if _, err = o.ParameterName("arn:aws:lambda:us-east-1:123456789012:function:orders"); err == nil {
+		t.Fatalf("expected an error for a name deeper than %d levels", maxParameterHierarchyLevels)
+	}
+}
+
+func TestParameterTier(t *testing.T) {
+	o := &ParameterStore{}
+	if tier, err := o.Tier(maxStandardParameterSize); err != nil || tier != ParameterTierStandard {
+		t.Fatalf("expected a standard parameter, got: %s, %v", tier, err)
+	}
+	if tier, err := o.Tier(maxStandardParameterSize + 1); err != nil || tier != ParameterTierAdvanced {
+		t.Fatalf("expected an advanced parameter, got: %s, %v", tier, err)
+	}
+	if _, err := o.Tier(maxAdvancedParameterSize + 1); err == nil {
+		t.Fatalf("expected an error for a value larger than an advanced parameter")
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"fmt"
+	"github.com/openclarity/function-clarity/pkg/utils"
+	"github.com/spf13/cobra"
+	"net/url"
+	"strings"
+)
+
+// ResultQueue is the sqs queue every verification result is sent to, one message per function, for processing
+// downstream of function clarity. The attributes are added to every message.
+type ResultQueue struct {
+	URL        string
+	Attributes map[string]string
+}
+
+func (o *ResultQueue) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.URL, "result-queue-url", "",
+		"url of an sqs queue every verification result is sent to, i.e: https://sqs.us-east-1.amazonaws.com/123456789012/results")
+
+	cmd.Flags().StringToStringVar(&o.Attributes, "result-queue-attributes", nil,
+		"message attributes added to every result sent to --result-queue-url, i.e: pipeline=release,stage=prod")
+}
+
+func (o *ResultQueue) Enabled() bool {
+	return o.URL != ""
+}
+
+// FIFO tells whether the queue is a fifo queue, their names end with .fifo.
+func (o *ResultQueue) FIFO() bool {
+	return strings.HasSuffix(o.URL, ".fifo")
+}
+
+// Arn returns the arn of the queue, parsed from its url: https://sqs.<region>.amazonaws.com/<account>/<name>.
+func (o *ResultQueue) Arn() (string, error) {
+	u, err := url.Parse(o.URL)
+	if err != nil {
+		return "", fmt.Errorf("invalid result queue url: %s: %w", o.URL, err)
+	}
+	host := strings.Split(u.Hostname(), ".")
+	path := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(host) < 3 || host[0] != "sqs" || len(path) != 2 {
+		return "", fmt.Errorf("invalid result queue url: %s, expected https://sqs.<region>.amazonaws.com/<account>/<name>", o.URL)
+	}
+	return fmt.Sprintf("arn:%s:sqs:%s:%s:%s", utils.Partition(host[1]), host[1], path[0], path[1]), nil
+}
+
+// Region returns the region of the queue, empty when the url has none.
+func (o *ResultQueue) Region() string {
+	arn, err := o.Arn()
+	if err != nil {
+		return ""
+	}
+	return strings.Split(arn, ":")[3]
+}
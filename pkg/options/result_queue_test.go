@@ -0,0 +1,44 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"testing"
+)
+
+func TestResultQueueArn(t *testing.T) {
+	queue := ResultQueue{URL: "https://sqs.eu-west-1.amazonaws.com/123456789012/results"}
+	arn, err := queue.Arn()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if arn != "arn:aws:sqs:eu-west-1:123456789012:results" {
+		t.Fatalf("unexpected arn: %s", arn)
+	}
+	if region := queue.Region(); region != "eu-west-1" {
+		t.Fatalf("unexpected region: %s", region)
+	}
+	queue.URL = "https://sqs.us-gov-west-1.amazonaws.com/123456789012/results"
+	if arn, _ = queue.Arn(); arn != "arn:aws-us-gov:sqs:us-gov-west-1:123456789012:results" {
+		t.Fatalf("unexpected govcloud arn: %s", arn)
+	}
+	for _, invalid := range []string{"https://example.com/results", "https://sqs.eu-west-1.amazonaws.com/results", "results"} {
+		queue.URL = invalid
+		if _, err := queue.Arn(); err == nil {
+			t.Fatalf("expected url: %s to be rejected", invalid)
+		}
+	}
+}
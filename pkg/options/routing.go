@@ -0,0 +1,55 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"github.com/spf13/cobra"
+)
+
+const DefaultRoutingTagKey = "team"
+
+// NotificationRouting routes the notification of a failed verification to the channel of the team owning the
+// function, named by the value of the function's routing tag. A channel is an sns topic arn or an http(s) webhook
+// url, functions without a route are notified on the default channel.
+type NotificationRouting struct {
+	TagKey string
+	Routes map[string]string
+}
+
+func (o *NotificationRouting) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.TagKey, "routing-tag-key", DefaultRoutingTagKey,
+		"function tag whose value selects the notification channel of --notification-routes")
+
+	cmd.Flags().StringToStringVar(&o.Routes, "notification-routes", nil,
+		"notification channel, sns topic arn or webhook url, per routing tag value, i.e: payments=arn:aws:sns:...,search=https://..., "+
+			"failures of functions without a route are notified on --sns-topic-arn")
+}
+
+func (o *NotificationRouting) Enabled() bool {
+	return len(o.Routes) > 0
+}
+
+// ChannelFor returns the channel routed to the function tags, or the default channel.
+func (o *NotificationRouting) ChannelFor(tags map[string]string, defaultChannel string) string {
+	tagKey := o.TagKey
+	if tagKey == "" {
+		tagKey = DefaultRoutingTagKey
+	}
+	if channel, ok := o.Routes[tags[tagKey]]; ok && channel != "" {
+		return channel
+	}
+	return defaultChannel
+}
@@ -0,0 +1,48 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"testing"
+)
+
+func TestNotificationRoutingChannelFor(t *testing.T) {
+	routing := NotificationRouting{Routes: map[string]string{
+		"payments": "arn:aws:sns:us-east-1:123456789012:payments",
+		"search":   "https://hooks.example.com/search",
+	}}
+	tests := []struct {
+		name     string
+		tags     map[string]string
+		expected string
+	}{
+		{"routed to sns", map[string]string{"team": "payments"}, "arn:aws:sns:us-east-1:123456789012:payments"},
+		{"routed to webhook", map[string]string{"team": "search"}, "https://hooks.example.com/search"},
+		{"unknown team", map[string]string{"team": "billing"}, "default"},
+		{"untagged", nil, "default"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if channel := routing.ChannelFor(test.tags, "default"); channel != test.expected {
+				t.Fatalf("expected channel: %s, got: %s", test.expected, channel)
+			}
+		})
+	}
+	routing.TagKey = "owner"
+	if channel := routing.ChannelFor(map[string]string{"team": "payments", "owner": "search"}, "default"); channel != "https://hooks.example.com/search" {
+		t.Fatalf("expected the custom tag key to select the channel, got: %s", channel)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultFunctionTimeoutBase  = 2 * time.Minute
+	DefaultFunctionTimeoutPerMb = 2 * time.Second
+	DefaultImageFunctionTimeout = 10 * time.Minute
+	imageFunctionPackageType    = "Image"
+	bytesPerMb                  = 1024 * 1024
+	// InaccessibleScopeSkip reports the accounts and regions that can't be accessed and scans the others.
+	InaccessibleScopeSkip = "skip"
+	// InaccessibleScopeFail fails the scan before verifying any function when an account or region can't be accessed.
+	InaccessibleScopeFail = "fail"
+	// ParallelismAuto scales the number of functions verified concurrently to the scan size and to throttling.
+	ParallelismAuto = "auto"
+)
+
+// ScanOptions apply when verifying all the functions of a scope.
+type ScanOptions struct {
+	FunctionTimeout time.Duration
+	Parallelism     string
+}
+
+func (o *ScanOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().DurationVar(&o.FunctionTimeout, "function-timeout", 0,
+		"time after which the verification of a single function is abandoned and the function reported as timed out, "+
+			"0 derives the timeout from the code size (2m plus 2s per MB, 10m for image functions)")
+	cmd.Flags().StringVar(&o.Parallelism, "parallelism", "1",
+		"number of functions verified concurrently, or auto to start from the number of functions in scope, "+
+			"backing off on aws throttling and ramping up while the api is responsive")
+}
+
+// ParallelismLevel returns the fixed number of concurrent verifications, or auto when parallelism is auto.
+func (o *ScanOptions) ParallelismLevel() (int, bool, error) {
+	if o.Parallelism == "" {
+		return 1, false, nil
+	}
+	if o.Parallelism == ParallelismAuto {
+		return 0, true, nil
+	}
+	level, err := strconv.Atoi(o.Parallelism)
+	if err != nil || level < 1 {
+		return 0, false, fmt.Errorf("invalid parallelism: %s, expected a positive number or %s", o.Parallelism, ParallelismAuto)
+	}
+	return level, false, nil
+}
+
+// TimeoutFor returns the verification timeout of a function with the given package type and code size.
+func (o *ScanOptions) TimeoutFor(packageType string, codeSize int64) time.Duration {
+	if o.FunctionTimeout > 0 {
+		return o.FunctionTimeout
+	}
+	if packageType == imageFunctionPackageType {
+		return DefaultImageFunctionTimeout
+	}
+	return DefaultFunctionTimeoutBase + time.Duration(codeSize/bytesPerMb)*DefaultFunctionTimeoutPerMb
+}
+
+// ScanOutputOptions write the results of every scan to a directory, one file per region or account.
+type ScanOutputOptions struct {
+	ResultsDir  string
+	PartitionBy string
+}
+
+func (o *ScanOutputOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.ResultsDir, "concurrency-safe-output", "",
+		"write the results of every scan to this directory, one JSON file per --partition-by value plus an index.json manifest, "+
+			"files are replaced atomically so scans running in parallel may share the directory")
+	cmd.Flags().StringVar(&o.PartitionBy, "partition-by", "region",
+		"partition the results written to --concurrency-safe-output by region or account")
+}
+
+// ScopeOptions select the accounts and regions of a scan. The account of the configured credentials is scanned in
+// every region, and so is the account of every assumed role.
+type ScopeOptions struct {
+	Regions        []string
+	AssumeRoles    []string
+	OnInaccessible string
+}
+
+func (o *ScopeOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&o.Regions, "function-region", nil, "aws regions of the verified functions, i.e: us-east-1,us-west-1")
+	cmd.Flags().StringSliceVar(&o.AssumeRoles, "assume-roles", nil,
+		"arns of roles assumed to also scan the functions of other accounts, one role per account")
+	cmd.Flags().StringVar(&o.OnInaccessible, "on-inaccessible-scope", InaccessibleScopeSkip,
+		"what to do when an account or region can't be accessed, e.g. the role can't be assumed or the region is disabled: "+
+			"skip reports it and scans the others, fail fails the scan before verifying any function")
+}
+
+// FailOnInaccessible tells whether an inaccessible account or region fails the scan.
+func (o *ScopeOptions) FailOnInaccessible() (bool, error) {
+	switch o.OnInaccessible {
+	case "", InaccessibleScopeSkip:
+		return false, nil
+	case InaccessibleScopeFail:
+		return true, nil
+	}
+	return false, fmt.Errorf("invalid on-inaccessible-scope: %s, expected %s or %s", o.OnInaccessible, InaccessibleScopeSkip, InaccessibleScopeFail)
+}
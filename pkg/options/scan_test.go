@@ -0,0 +1,50 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScanTimeoutFor(t *testing.T) {
+	o := &ScanOptions{}
+	if timeout := o.TimeoutFor("Zip", 50*1024*1024); timeout != DefaultFunctionTimeoutBase+50*DefaultFunctionTimeoutPerMb {
+		t.Fatalf("unexpected timeout for zip function: %s", timeout)
+	}
+	if timeout := o.TimeoutFor("Image", 0); timeout != DefaultImageFunctionTimeout {
+		t.Fatalf("unexpected timeout for image function: %s", timeout)
+	}
+	o.FunctionTimeout = 30 * time.Second
+	if timeout := o.TimeoutFor("Zip", 50*1024*1024); timeout != 30*time.Second {
+		t.Fatalf("configured timeout should take precedence, got: %s", timeout)
+	}
+}
+
+func TestScopeFailOnInaccessible(t *testing.T) {
+	o := &ScopeOptions{}
+	if fail, err := o.FailOnInaccessible(); err != nil || fail {
+		t.Fatalf("inaccessible scopes should be skipped by default, got: %v %v", fail, err)
+	}
+	o.OnInaccessible = InaccessibleScopeFail
+	if fail, err := o.FailOnInaccessible(); err != nil || !fail {
+		t.Fatalf("expected fail fast, got: %v %v", fail, err)
+	}
+	o.OnInaccessible = "ignore"
+	if _, err := o.FailOnInaccessible(); err == nil {
+		t.Fatalf("expected an error for an unknown action")
+	}
+}
@@ -21,6 +21,17 @@ import (
 )
 
 type SignBlobOptions struct {
+	BaselineFunction  string
+	RecordConcurrency bool
+	RecordLayers      bool
+	Annotations       []string
+	Annotation        []string
+	NoCIAnnotations   bool
+	DigestAlgorithm   string
+	NormalizeZip      bool
+	TlogUpload        bool
+	TrustRoots        TrustRootOptions
+	ParameterStore    ParameterStore
 	options.SignBlobOptions
 }
 
@@ -30,6 +41,8 @@ func (o *SignBlobOptions) AddFlags(cmd *cobra.Command) {
 	o.Rekor.AddFlags(cmd)
 	o.OIDC.AddFlags(cmd)
 	o.Registry.AddFlags(cmd)
+	o.TrustRoots.AddFlags(cmd)
+	o.ParameterStore.AddFlags(cmd)
 
 	cmd.Flags().BoolVar(&o.Base64Output, "b64", true,
 		"whether to base64 encode the output")
@@ -47,4 +60,31 @@ func (o *SignBlobOptions) AddFlags(cmd *cobra.Command) {
 
 	cmd.Flags().BoolVarP(&o.SkipConfirmation, "yes", "y", false,
 		"skip confirmation prompts for non-destructive operations")
+
+	cmd.Flags().StringVar(&o.BaselineFunction, "baseline-function", "",
+		"deployed function whose configuration is recorded in the signature metadata as a baseline")
+
+	cmd.Flags().BoolVar(&o.RecordConcurrency, "record-concurrency", false,
+		"record the reserved/provisioned concurrency of the baseline function in the signature metadata")
+
+	cmd.Flags().BoolVar(&o.RecordLayers, "record-layers", false,
+		"record the ordered layer versions of the baseline function in the signature metadata")
+
+	cmd.Flags().StringSliceVarP(&o.Annotations, "annotations", "a", nil,
+		"extra key=value annotations recorded in the signature metadata")
+
+	cmd.Flags().StringArrayVar(&o.Annotation, "annotation", nil,
+		"extra key=value annotation recorded in the signature metadata, repeatable, the value may contain commas")
+
+	cmd.Flags().BoolVar(&o.TlogUpload, "tlog-upload", false,
+		"upload the signature made with a key to the rekor transparency log at --rekor-url, keyless signatures always are")
+
+	cmd.Flags().BoolVar(&o.NoCIAnnotations, "no-ci-annotations", false,
+		"don't annotate the signature with the commit, build url, actor and ref of the detected CI environment")
+
+	cmd.Flags().StringVar(&o.DigestAlgorithm, "digest-algorithm", "sha256",
+		"algorithm of the code digest that is signed, sha256 or sha512, recorded in the signature metadata")
+
+	cmd.Flags().BoolVar(&o.NormalizeZip, "normalize-zip", false,
+		"sign the digest of the files of a .zip code path, whichever their order and modification times, instead of the digest of the archive")
 }
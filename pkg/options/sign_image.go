@@ -21,6 +21,9 @@ import (
 )
 
 type SignOptions struct {
+	TrustRoots      TrustRootOptions
+	Annotation      []string
+	NoCIAnnotations bool
 	options.SignOptions
 }
 
@@ -31,6 +34,7 @@ func (o *SignOptions) AddFlags(cmd *cobra.Command) {
 	o.SecurityKey.AddFlags(cmd)
 	o.AnnotationOptions.AddFlags(cmd)
 	o.Registry.AddFlags(cmd)
+	o.TrustRoots.AddFlags(cmd)
 
 	cmd.Flags().StringVar(&o.Cert, "certificate", "",
 		"path to the X.509 certificate in PEM format to include in the OCI Signature")
@@ -67,4 +71,10 @@ func (o *SignOptions) AddFlags(cmd *cobra.Command) {
 
 	cmd.Flags().BoolVar(&o.NoTlogUpload, "no-tlog-upload", false,
 		"whether to not upload the transparency log")
+
+	cmd.Flags().StringArrayVar(&o.Annotation, "annotation", nil,
+		"extra key=value annotation to sign, repeatable, the value may contain commas")
+
+	cmd.Flags().BoolVar(&o.NoCIAnnotations, "no-ci-annotations", false,
+		"don't annotate the signature with the commit, build url, actor and ref of the detected CI environment")
 }
@@ -0,0 +1,77 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SignerIdentity matches the certificate of a keyless signature by its OIDC issuer and its subject, an empty regular
+// expression matches any value.
+type SignerIdentity struct {
+	IssuerRegexp  string
+	SubjectRegexp string
+}
+
+func (s SignerIdentity) String() string {
+	return "issuer=" + s.IssuerRegexp + ",subject=" + s.SubjectRegexp
+}
+
+// ParseSignerIdentity parses a signer given as issuer=<regexp>,subject=<regexp>, either part can be left out to
+// match any issuer or subject.
+func ParseSignerIdentity(value string) (SignerIdentity, error) {
+	var signer SignerIdentity
+	switch {
+	case strings.HasPrefix(value, "issuer="):
+		signer.IssuerRegexp, signer.SubjectRegexp, _ = strings.Cut(strings.TrimPrefix(value, "issuer="), ",subject=")
+	case strings.HasPrefix(value, "subject="):
+		signer.SubjectRegexp = strings.TrimPrefix(value, "subject=")
+	default:
+		return signer, fmt.Errorf("invalid signer: %s, expected issuer=<regexp>,subject=<regexp>", value)
+	}
+	if err := signer.Validate(); err != nil {
+		return signer, err
+	}
+	return signer, nil
+}
+
+// ParseSignerIdentities parses the signers given as repeated flags.
+func ParseSignerIdentities(values []string) ([]SignerIdentity, error) {
+	var signers []SignerIdentity
+	for _, value := range values {
+		signer, err := ParseSignerIdentity(value)
+		if err != nil {
+			return nil, err
+		}
+		signers = append(signers, signer)
+	}
+	return signers, nil
+}
+
+// Validate checks the signer matches something and its regular expressions compile.
+func (s SignerIdentity) Validate() error {
+	if s.IssuerRegexp == "" && s.SubjectRegexp == "" {
+		return fmt.Errorf("invalid signer: %s, an issuer or a subject is required", s)
+	}
+	for _, expression := range []string{s.IssuerRegexp, s.SubjectRegexp} {
+		if _, err := regexp.Compile(expression); err != nil {
+			return fmt.Errorf("invalid signer: %s: %w", s, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSignerIdentity(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected SignerIdentity
+		fail     bool
+	}{
+		{value: `issuer=^https://token\.actions\.githubusercontent\.com$,subject=^https://github\.com/org/repo/`,
+			expected: SignerIdentity{IssuerRegexp: `^https://token\.actions\.githubusercontent\.com$`, SubjectRegexp: `^https://github\.com/org/repo/`}},
+		{value: "issuer=https://accounts.google.com", expected: SignerIdentity{IssuerRegexp: "https://accounts.google.com"}},
+		{value: `subject=^ci-[a-z]{1,8}@example\.com$`, expected: SignerIdentity{SubjectRegexp: `^ci-[a-z]{1,8}@example\.com$`}},
+		{value: "https://accounts.google.com", fail: true},
+		{value: "issuer=,subject=", fail: true},
+		{value: "subject=(", fail: true},
+	}
+	for _, test := range tests {
+		signer, err := ParseSignerIdentity(test.value)
+		if test.fail {
+			if err == nil {
+				t.Fatalf("expected %s to fail", test.value)
+			}
+			continue
+		}
+		if err != nil || signer != test.expected {
+			t.Fatalf("%s: expected %+v, got: %+v, %v", test.value, test.expected, signer, err)
+		}
+	}
+}
+
+func TestParseSignerIdentities(t *testing.T) {
+	signers, err := ParseSignerIdentities([]string{"subject=staging@example.com", "subject=prod@example.com"})
+	expected := []SignerIdentity{{SubjectRegexp: "staging@example.com"}, {SubjectRegexp: "prod@example.com"}}
+	if err != nil || !reflect.DeepEqual(signers, expected) {
+		t.Fatalf("expected %+v, got: %+v, %v", expected, signers, err)
+	}
+	if signers, err = ParseSignerIdentities(nil); err != nil || signers != nil {
+		t.Fatalf("expected no signer, got: %+v, %v", signers, err)
+	}
+	if _, err = ParseSignerIdentities([]string{"subject=a", "b"}); err == nil {
+		t.Fatalf("expected an invalid signer to fail")
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+
+// FulcioURL returns the fulcio url of a private sigstore instance, or the public fulcio when it is empty.
+func FulcioURL(configured string) string {
+	if configured == "" {
+		return co.DefaultFulcioURL
+	}
+	return configured
+}
+
+// RekorURL returns the rekor url of a private sigstore instance, or the public rekor when it is empty.
+func RekorURL(configured string) string {
+	if configured == "" {
+		return co.DefaultRekorURL
+	}
+	return configured
+}
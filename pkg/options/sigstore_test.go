@@ -0,0 +1,36 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"testing"
+)
+
+func TestSigstoreURLsFallBackToPublicInstance(t *testing.T) {
+	if url := FulcioURL(""); url != co.DefaultFulcioURL {
+		t.Fatalf("unexpected fulcio url: %s", url)
+	}
+	if url := RekorURL(""); url != co.DefaultRekorURL {
+		t.Fatalf("unexpected rekor url: %s", url)
+	}
+	if url := FulcioURL("https://fulcio.internal.example.com"); url != "https://fulcio.internal.example.com" {
+		t.Fatalf("unexpected fulcio url: %s", url)
+	}
+	if url := RekorURL("https://rekor.internal.example.com"); url != "https://rekor.internal.example.com" {
+		t.Fatalf("unexpected rekor url: %s", url)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"strings"
+)
+
+// ParseTagFilter splits the entries of a function tag filter into the bare tag keys, matching any function with the
+// key, and the key=value entries, matching the functions with the key set to the value. A value may itself contain
+// '=', only the first one separates the key. Empty entries are ignored.
+func ParseTagFilter(entries []string) ([]string, map[string]string) {
+	var keys []string
+	var tags map[string]string
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, value, found := strings.Cut(entry, "=")
+		if !found {
+			keys = append(keys, entry)
+			continue
+		}
+		if tags == nil {
+			tags = map[string]string{}
+		}
+		tags[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return keys, tags
+}
+
+// MatchesTagFilter tells whether the function tags have one of the keys, or one of the tags with its value.
+func MatchesTagFilter(funcTags map[string]string, keys []string, tags map[string]string) bool {
+	for _, key := range keys {
+		if _, ok := funcTags[key]; ok {
+			return true
+		}
+	}
+	for key, value := range tags {
+		if funcValue, ok := funcTags[key]; ok && funcValue == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTagFilter(t *testing.T) {
+	keys, tags := ParseTagFilter([]string{"team", " environment=production ", "", "query=a=b", "owner="})
+	if !reflect.DeepEqual(keys, []string{"team"}) {
+		t.Fatalf("unexpected keys: %v", keys)
+	}
+	expected := map[string]string{"environment": "production", "query": "a=b", "owner": ""}
+	if !reflect.DeepEqual(tags, expected) {
+		t.Fatalf("unexpected tags: %v", tags)
+	}
+	if keys, tags = ParseTagFilter([]string{""}); keys != nil || tags != nil {
+		t.Fatalf("expected an empty filter, got keys: %v, tags: %v", keys, tags)
+	}
+}
+
+func TestMatchesTagFilter(t *testing.T) {
+	funcTags := map[string]string{"team": "payments", "environment": "staging"}
+	tests := []struct {
+		name    string
+		keys    []string
+		tags    map[string]string
+		matches bool
+	}{
+		{name: "key present", keys: []string{"team"}, matches: true},
+		{name: "key missing", keys: []string{"owner"}},
+		{name: "value matches", tags: map[string]string{"environment": "staging"}, matches: true},
+		{name: "value differs", tags: map[string]string{"environment": "production"}},
+		{name: "key or value", keys: []string{"owner"}, tags: map[string]string{"team": "payments"}, matches: true},
+	}
+	for _, test := range tests {
+		if matches := MatchesTagFilter(funcTags, test.keys, test.tags); matches != test.matches {
+			t.Fatalf("%s: expected matches: %t, got: %t", test.name, test.matches, matches)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/sigstore/sigstore/pkg/tuf"
+	"github.com/spf13/cobra"
+	"os"
+	"sync"
+)
+
+const (
+	fulcioRootEnv     = "SIGSTORE_ROOT_FILE"
+	rekorPublicKeyEnv = "SIGSTORE_REKOR_PUBLIC_KEY"
+	ctLogPublicKeyEnv = "SIGSTORE_CT_LOG_PUBLIC_KEY_FILE"
+)
+
+var (
+	tufOnce sync.Once
+	tufErr  error
+)
+
+// TrustRootOptions replace the public sigstore trust roots, so keyless signing and verification can run against a
+// private or locally running fulcio and rekor, used together with --fulcio-url and --rekor-url. TufRoot and TufMirror
+// replace the public sigstore TUF repository the roots are otherwise fetched from.
+type TrustRootOptions struct {
+	FulcioRoot     string
+	RekorPublicKey string
+	CTLogPublicKey string
+	TufRoot        string
+	TufMirror      string
+}
+
+func (o *TrustRootOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.FulcioRoot, "fulcio-root", "",
+		"path to the PEM encoded root certificates of the fulcio CA, replacing the public sigstore root")
+
+	cmd.Flags().StringVar(&o.RekorPublicKey, "rekor-public-key", "",
+		"path to the PEM encoded public key of the rekor log, replacing the public sigstore key")
+
+	cmd.Flags().StringVar(&o.CTLogPublicKey, "ctlog-public-key", "",
+		"path to the PEM encoded public key of the certificate transparency log, replacing the public sigstore key")
+
+	cmd.Flags().StringVar(&o.TufRoot, "tuf-root", "",
+		"path to the initial root.json of a private sigstore TUF repository, replacing the public sigstore root")
+
+	cmd.Flags().StringVar(&o.TufMirror, "tuf-mirror", "",
+		"url of a private sigstore TUF repository mirror, the public sigstore mirror when empty")
+}
+
+// Apply exposes the trust roots to cosign, which reads them from the environment. It must run before the first
+// keyless operation since cosign loads the roots once per process.
+func (o *TrustRootOptions) Apply() error {
+	for env, path := range map[string]string{
+		fulcioRootEnv:     o.FulcioRoot,
+		rekorPublicKeyEnv: o.RekorPublicKey,
+		ctLogPublicKeyEnv: o.CTLogPublicKey,
+	} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("failed to read trust root: %s: %w", path, err)
+		}
+		if err := os.Setenv(env, path); err != nil {
+			return fmt.Errorf("failed to set trust root: %s: %w", env, err)
+		}
+	}
+	if o.TufRoot == "" && o.TufMirror == "" {
+		return nil
+	}
+	tufOnce.Do(func() {
+		tufErr = initTuf(o.TufRoot, o.TufMirror)
+	})
+	return tufErr
+}
+
+// initTuf initializes the TUF client of cosign against the private mirror, trusting the given root. The client is a
+// singleton, so the first initialization of the process holds for the following operations.
+func initTuf(rootPath string, mirror string) error {
+	var root []byte
+	if rootPath != "" {
+		var err error
+		if root, err = LoadTufRoot(rootPath); err != nil {
+			return err
+		}
+	}
+	if mirror == "" {
+		mirror = tuf.DefaultRemoteRoot
+	}
+	if err := tuf.Initialize(context.Background(), mirror, root); err != nil {
+		return fmt.Errorf("failed to initialize TUF root: %s from mirror: %s: %w", rootPath, mirror, err)
+	}
+	return nil
+}
+
+// LoadTufRoot reads the initial root.json of a TUF repository.
+func LoadTufRoot(path string) ([]byte, error) {
+	root, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TUF root: %s: %w", path, err)
+	}
+	var metadata struct {
+		Signed     json.RawMessage `json:"signed"`
+		Signatures json.RawMessage `json:"signatures"`
+	}
+	if err = json.Unmarshal(root, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse TUF root: %s: %w", path, err)
+	}
+	if metadata.Signed == nil || metadata.Signatures == nil {
+		return nil, fmt.Errorf("invalid TUF root: %s, expected signed root metadata", path)
+	}
+	return root, nil
+}
@@ -0,0 +1,46 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package options
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadTufRoot(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "root.json")
+	if err := os.WriteFile(valid, []byte(`{"signed":{"_type":"root","version":1},"signatures":[]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTufRoot(valid); err != nil {
+		t.Fatalf("failed to load TUF root: %v", err)
+	}
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`{"keys":[]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for path, expected := range map[string]string{
+		invalid:                         "invalid TUF root",
+		filepath.Join(dir, "none.json"): "failed to read TUF root",
+	} {
+		if _, err := LoadTufRoot(path); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected error: %s for TUF root: %s, got: %v", expected, path, err)
+		}
+	}
+}
@@ -18,20 +18,97 @@ package options
 import (
 	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
 	"github.com/spf13/cobra"
+	"sync"
+	"time"
 )
 
+const DefaultClockSkew = 2 * time.Minute
+
 type VerifyOpts struct {
-	BundlePath string
+	BundlePath           string
+	LayerCache           LayerCacheOptions
+	TrustRoots           TrustRootOptions
+	VerifyConcurrency    bool
+	VerifyLayers         bool
+	ShowAnnotations      bool
+	Offline              bool
+	TlogVerify           bool
+	SignatureFreshness   time.Duration
+	ClockSkew            time.Duration
+	PinSigner            bool
+	RequireKeyAndKeyless bool
+	CertIdentityRegexp   string
+	CertOidcIssuerRegexp string
+	// IncludedSigners are the identities allowed to sign keylessly, any identity when empty.
+	IncludedSigners       []SignerIdentity
+	UntrustedSignerAction string
+	// DryRun only logs and notifies the functions the block action would block or unblock, without changing them.
+	DryRun                bool
+	RequireAwsCodeSigning bool
+	RequireImageDigestPin bool
+	RequireSignedLayers   bool
+	IncludedFuncTags      map[string]string
+	ExcludedFuncTagKeys   []string
+	ExcludedFuncRegions   []string
+	VexOutput             string
+	SigningIdentityOutput string
+	ScanID                string
+	NotificationRouting   NotificationRouting
+	SlackWebhookURL       string
+	WebhookURL            string
+	WebhookHeaders        map[string]string
+	ResultQueue           ResultQueue
+	// RecordedAnnotations collects the annotations recorded at sign time of the verified functions, when set.
+	RecordedAnnotations *AnnotationRecorder
 	co.VerifyOptions
 }
 
+// AddFlags adds the flags of the verification of aws functions: the common flags, and the flags of the checks only the
+// aws client implements, such as the drift of the function concurrency and layers or its code signing config.
 func (o *VerifyOpts) AddFlags(cmd *cobra.Command) {
+	o.AddCommonFlags(cmd)
+	o.LayerCache.AddFlags(cmd)
+
+	cmd.Flags().BoolVar(&o.VerifyConcurrency, "verify-concurrency", false,
+		"fail verification when the function concurrency drifted from the baseline recorded at sign time (zip functions)")
+
+	cmd.Flags().BoolVar(&o.VerifyLayers, "verify-layers", false,
+		"fail verification when layers were added to, removed from or reordered in the function since the baseline recorded at sign time (zip functions)")
+
+	cmd.Flags().DurationVar(&o.SignatureFreshness, "signature-freshness", 0,
+		"fail verification when the function code was modified longer than this after its most recent signature, 0 disables the check (zip functions)")
+
+	cmd.Flags().StringVar(&o.UntrustedSignerAction, "untrusted-signer-action", "",
+		"action for functions whose code matches a signature made by an untrusted key or identity (detect|block|none), defaults to the action (zip functions)")
+
+	cmd.Flags().BoolVar(&o.DryRun, "dry-run", false,
+		"with the block action, only log and notify the functions that would be blocked or unblocked, without changing their concurrency")
+
+	cmd.Flags().BoolVar(&o.RequireAwsCodeSigning, "require-aws-code-signing", false,
+		"fail verification of functions passing the signature verification that don't have an AWS code signing config attached with an enforce policy (zip functions)")
+
+	cmd.Flags().BoolVar(&o.RequireSignedLayers, "require-signed-layers", false,
+		"fail verification of functions with an attached layer whose content isn't signed, sign layers with: sign aws layer (zip functions)")
+
+	cmd.Flags().BoolVar(&o.PinSigner, "pin-signer", false,
+		"pin the signer key and algorithm of each function on its first verification, and fail when it later verifies with another signer")
+}
+
+// AddOutputFlag adds the cosign output format flag, to the commands not defining an output flag of their own.
+func (o *VerifyOpts) AddOutputFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "json",
+		"output format for the signing image information (json|text)")
+}
+
+// AddCommonFlags adds the flags of the verification every cloud supports.
+func (o *VerifyOpts) AddCommonFlags(cmd *cobra.Command) {
 	o.SecurityKey.AddFlags(cmd)
 	o.Rekor.AddFlags(cmd)
 	o.CertVerify.AddFlags(cmd)
 	o.Registry.AddFlags(cmd)
 	o.SignatureDigest.AddFlags(cmd)
 	o.AnnotationOptions.AddFlags(cmd)
+	o.TrustRoots.AddFlags(cmd)
 
 	cmd.Flags().BoolVar(&o.CheckClaims, "check-claims", true,
 		"whether to check the claims found")
@@ -39,9 +116,6 @@ func (o *VerifyOpts) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&o.Attachment, "attachment", "",
 		"related image attachment to sign (sbom), default none")
 
-	cmd.Flags().StringVarP(&o.Output, "output", "o", "json",
-		"output format for the signing image information (json|text)")
-
 	cmd.Flags().StringVar(&o.SignatureRef, "signature", "",
 		"signature content or path or remote URL")
 
@@ -50,4 +124,64 @@ func (o *VerifyOpts) AddFlags(cmd *cobra.Command) {
 
 	cmd.Flags().StringVar(&o.BundlePath, "bundle", "",
 		"path to bundle FILE")
+
+	cmd.Flags().BoolVar(&o.ShowAnnotations, "show-annotations", false,
+		"print the annotations recorded in the signature metadata at sign time, such as the commit and build url (zip functions)")
+
+	cmd.Flags().BoolVar(&o.Offline, "offline", false,
+		"verify keyless signatures against the rekor bundle stored at sign time without querying the transparency log. "+
+			"The bundle proves the signature was logged when signed, but a revoked or removed log entry isn't noticed and "+
+			"the log inclusion proof isn't checked, only the signed entry timestamp")
+
+	cmd.Flags().BoolVar(&o.TlogVerify, "tlog-verify", false,
+		"require signatures made with a key to have an entry in the rekor transparency log at --rekor-url, i.e. signed with --tlog-upload, ignored with --offline")
+
+	cmd.Flags().DurationVar(&o.ClockSkew, "clock-skew", DefaultClockSkew,
+		"tolerated clock difference between signer and verifier when checking the certificate validity window and the signature freshness, 0 disables the tolerance")
+
+	cmd.Flags().StringVar(&o.CertIdentityRegexp, "certificate-identity-regexp", "",
+		"regular expression the identity of a valid keyless signing certificate matches, along with --certificate-identity when given (zip functions)")
+
+	cmd.Flags().StringVar(&o.CertOidcIssuerRegexp, "certificate-oidc-issuer-regexp", "",
+		"regular expression the OIDC issuer of a valid keyless signing certificate matches, along with --certificate-oidc-issuer when given (zip functions)")
+
+	cmd.Flags().BoolVar(&o.RequireKeyAndKeyless, "require-key-and-keyless", false,
+		"require both a valid signature made with the public key and a valid keyless signature, e.g. while migrating from key-based to keyless signing")
+
+	cmd.Flags().BoolVar(&o.RequireImageDigestPin, "require-image-digest-pin", false,
+		"fail verification of image functions referencing their image by tag instead of an @sha256: digest, even when the image is signed (image functions)")
+}
+
+// BaselineChecksEnabled reports whether any check against the function configuration recorded at sign time was requested.
+func (o *VerifyOpts) BaselineChecksEnabled() bool {
+	return o.VerifyConcurrency || o.VerifyLayers
+}
+
+// MetadataRequired reports whether the signature metadata recorded at sign time is needed by the verification.
+func (o *VerifyOpts) MetadataRequired() bool {
+	return o.BaselineChecksEnabled() || o.ShowAnnotations || o.RecordedAnnotations != nil
+}
+
+// AnnotationRecorder holds the annotations of the verified functions by function identifier, it is safe for
+// concurrent verifications.
+type AnnotationRecorder struct {
+	mu          sync.Mutex
+	annotations map[string]map[string]string
+}
+
+func NewAnnotationRecorder() *AnnotationRecorder {
+	return &AnnotationRecorder{annotations: map[string]map[string]string{}}
+}
+
+func (r *AnnotationRecorder) Record(functionIdentifier string, annotations map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.annotations[functionIdentifier] = annotations
+}
+
+// Get returns the annotations recorded for the function, nil when its signature metadata wasn't read.
+func (r *AnnotationRecorder) Get(functionIdentifier string) map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.annotations[functionIdentifier]
 }
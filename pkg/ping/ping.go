@@ -0,0 +1,77 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ping
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const endpointTimeout = 10 * time.Second
+
+// Check is the outcome of a single connectivity check, Detail describes what the check found when it passed.
+type Check struct {
+	Name    string
+	Latency time.Duration
+	Detail  string
+	Err     error
+}
+
+// Run times the check.
+func Run(name string, check func() (string, error)) Check {
+	start := time.Now()
+	detail, err := check()
+	return Check{Name: name, Latency: time.Since(start), Detail: detail, Err: err}
+}
+
+// Reachable checks the endpoint answers http requests, any response below 500 shows the service is up even when
+// the path requires authentication.
+func Reachable(ctx context.Context, url string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, endpointTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint: %s: %w", url, err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("endpoint: %s unreachable: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return "", fmt.Errorf("endpoint: %s unavailable, status: %s", url, resp.Status)
+	}
+	return fmt.Sprintf("%s %s", url, resp.Status), nil
+}
+
+// Endpoint joins the service url and the api path checked for reachability.
+func Endpoint(serviceURL string, path string) string {
+	return strings.TrimSuffix(serviceURL, "/") + path
+}
+
+// Failed returns the number of checks that failed.
+func Failed(checks []Check) int {
+	failed := 0
+	for _, check := range checks {
+		if check.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
@@ -0,0 +1,66 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ping
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/log":
+			w.WriteHeader(http.StatusOK)
+		case "/protected":
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	for _, path := range []string{"/api/v1/log", "/protected"} {
+		if _, err := Reachable(context.Background(), Endpoint(server.URL+"/", path)); err != nil {
+			t.Fatalf("expected %s to be reachable: %v", path, err)
+		}
+	}
+	if _, err := Reachable(context.Background(), server.URL+"/down"); err == nil || !strings.Contains(err.Error(), "unavailable") {
+		t.Fatalf("expected a server error to fail the check, got: %v", err)
+	}
+	url := server.URL
+	server.Close()
+	if _, err := Reachable(context.Background(), url); err == nil || !strings.Contains(err.Error(), "unreachable") {
+		t.Fatalf("expected a closed server to fail the check, got: %v", err)
+	}
+}
+
+func TestRunAndFailed(t *testing.T) {
+	checks := []Check{
+		Run("ok", func() (string, error) { return "identity", nil }),
+		Run("denied", func() (string, error) { return "", errors.New("access denied") }),
+	}
+	if checks[0].Detail != "identity" || checks[0].Err != nil || checks[1].Err == nil {
+		t.Fatalf("unexpected checks: %+v", checks)
+	}
+	if failed := Failed(checks); failed != 1 {
+		t.Fatalf("expected 1 failed check, got: %d", failed)
+	}
+}
@@ -0,0 +1,361 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"fmt"
+	"github.com/openclarity/function-clarity/pkg/utils"
+)
+
+const (
+	InitMode   = "init"
+	VerifyMode = "verify"
+	SignMode   = "sign"
+	PruneMode  = "prune"
+)
+
+type Document struct {
+	Version   string      `json:"Version"`
+	Statement []Statement `json:"Statement"`
+}
+
+type Statement struct {
+	Sid      string   `json:"Sid"`
+	Effect   string   `json:"Effect"`
+	Action   []string `json:"Action"`
+	Resource []string `json:"Resource"`
+}
+
+// Params are the resources the policy is scoped to. Empty AccountId and Region are replaced with a wildcard,
+// an empty TrailName means function clarity creates its own trail during init.
+type Params struct {
+	Bucket      string
+	TrailName   string
+	SnsTopicArn string
+	// RoutedTopicArns are the sns topics failures are routed to per owning team.
+	RoutedTopicArns []string
+	// ResultQueueArn is the sqs queue verification results are sent to, if any.
+	ResultQueueArn string
+	// KmsKeyArn is the kms key encrypting the signatures, if any.
+	KmsKeyArn string
+	Region    string
+	AccountId string
+	// Partition is the partition of the arns, the one of the Region when empty, i.e: aws-us-gov for us-gov-west-1.
+	Partition string
+}
+
+// AwsPolicy returns the least privilege policy required to run function clarity in the given mode:
+// init covers init, deploy and update-func-config, verify covers manual verification and the verifier function,
+// sign covers code and image signing and signature import, prune covers pruning stale signatures.
+func AwsPolicy(mode string, p Params) (*Document, error) {
+	if p.Bucket == "" {
+		return nil, fmt.Errorf("bucket is required to generate a policy")
+	}
+	if p.AccountId == "" {
+		p.AccountId = "*"
+	}
+	if p.Partition == "" {
+		p.Partition = utils.Partition(p.Region)
+	}
+	if p.Region == "" {
+		p.Region = "*"
+	}
+	var statements []Statement
+	switch mode {
+	case InitMode:
+		statements = initStatements(p)
+	case VerifyMode:
+		statements = verifyStatements(p)
+	case SignMode:
+		statements = signStatements(p)
+	case PruneMode:
+		statements = pruneStatements(p)
+	default:
+		return nil, fmt.Errorf("unsupported policy mode: %s, supported modes: %s, %s, %s, %s", mode, InitMode, VerifyMode, SignMode, PruneMode)
+	}
+	if p.KmsKeyArn != "" {
+		statements = append(statements, Statement{
+			Sid:      "SignatureKey",
+			Effect:   "Allow",
+			Action:   []string{"kms:Decrypt", "kms:GenerateDataKey"},
+			Resource: []string{p.KmsKeyArn},
+		})
+	}
+	return &Document{Version: "2012-10-17", Statement: statements}, nil
+}
+
+func bucketArn(p Params, bucket string) string {
+	return fmt.Sprintf("arn:%s:s3:::%s", p.Partition, bucket)
+}
+
+func functionsArn(p Params) string {
+	return fmt.Sprintf("arn:%s:lambda:*:%s:function:*", p.Partition, p.AccountId)
+}
+
+func repositoriesArn(p Params) string {
+	return fmt.Sprintf("arn:%s:ecr:*:%s:repository/*", p.Partition, p.AccountId)
+}
+
+func initStatements(p Params) []Statement {
+	statements := []Statement{
+		{
+			Sid:      "ValidateCredentials",
+			Effect:   "Allow",
+			Action:   []string{"sts:GetCallerIdentity"},
+			Resource: []string{"*"},
+		},
+		{
+			// the region and the region filters are checked against the regions enabled for the account
+			Sid:      "ValidateRegions",
+			Effect:   "Allow",
+			Action:   []string{"ec2:DescribeRegions"},
+			Resource: []string{"*"},
+		},
+		{
+			// the lifecycle expires the signatures after the retention days, if any, the location warns of a bucket in
+			// another region and the encryption is set when the bucket is created
+			Sid:    "SignatureBucket",
+			Effect: "Allow",
+			Action: []string{"s3:CreateBucket", "s3:ListBucket", "s3:GetBucketLocation", "s3:GetObject", "s3:PutObject",
+				"s3:GetLifecycleConfiguration", "s3:PutLifecycleConfiguration", "s3:PutEncryptionConfiguration"},
+			Resource: []string{bucketArn(p, p.Bucket), bucketArn(p, p.Bucket) + "/*"},
+		},
+		{
+			Sid:      "DeployStack",
+			Effect:   "Allow",
+			Action:   []string{"cloudformation:CreateStack", "cloudformation:DescribeStacks"},
+			Resource: []string{fmt.Sprintf("arn:%s:cloudformation:%s:%s:stack/function-clarity-stack*/*", p.Partition, p.Region, p.AccountId)},
+		},
+		{
+			Sid:      "VerifierRole",
+			Effect:   "Allow",
+			Action:   []string{"iam:CreateRole", "iam:GetRole", "iam:PutRolePolicy", "iam:GetRolePolicy", "iam:PassRole"},
+			Resource: []string{fmt.Sprintf("arn:%s:iam::%s:role/function-clarity-stack*", p.Partition, p.AccountId)},
+		},
+		{
+			Sid:    "VerifierFunction",
+			Effect: "Allow",
+			Action: []string{"lambda:CreateFunction", "lambda:GetFunction", "lambda:AddPermission", "lambda:PutFunctionConcurrency",
+				"lambda:GetFunctionConfiguration", "lambda:UpdateFunctionConfiguration"},
+			Resource: []string{fmt.Sprintf("arn:%s:lambda:%s:%s:function:FunctionClarityLambda*", p.Partition, p.Region, p.AccountId)},
+		},
+		{
+			Sid:      "VerifierTrigger",
+			Effect:   "Allow",
+			Action:   []string{"logs:CreateLogGroup", "logs:DescribeLogGroups", "logs:PutRetentionPolicy", "logs:PutSubscriptionFilter"},
+			Resource: []string{fmt.Sprintf("arn:%s:logs:%s:%s:log-group:*", p.Partition, p.Region, p.AccountId)},
+		},
+		{
+			// the public key of a kms signing key is written next to the config for the verifier
+			Sid:      "SigningKey",
+			Effect:   "Allow",
+			Action:   []string{"kms:GetPublicKey"},
+			Resource: []string{fmt.Sprintf("arn:%s:kms:%s:%s:key/*", p.Partition, p.Region, p.AccountId)},
+		},
+	}
+	if p.KmsKeyArn != "" {
+		statements = append(statements, Statement{
+			Sid:      "ValidateSignatureKey",
+			Effect:   "Allow",
+			Action:   []string{"kms:DescribeKey"},
+			Resource: []string{p.KmsKeyArn},
+		})
+	}
+	if p.SnsTopicArn != "" {
+		statements = append(statements, Statement{
+			// a missing topic is created and an email address subscribed to it
+			Sid:      "ValidateNotificationTopic",
+			Effect:   "Allow",
+			Action:   []string{"sns:GetTopicAttributes", "sns:CreateTopic", "sns:Subscribe"},
+			Resource: []string{p.SnsTopicArn},
+		})
+	}
+	if p.TrailName != "" {
+		statements = append(statements, Statement{
+			Sid:    "ExistingTrail",
+			Effect: "Allow",
+			// the trail is updated to send its events to the log group of the stack when it has none
+			Action:   []string{"cloudtrail:GetTrail", "cloudtrail:UpdateTrail"},
+			Resource: []string{fmt.Sprintf("arn:%s:cloudtrail:%s:%s:trail/%s", p.Partition, p.Region, p.AccountId, p.TrailName)},
+		})
+	} else {
+		statements = append(statements,
+			Statement{
+				Sid:      "CreateTrail",
+				Effect:   "Allow",
+				Action:   []string{"cloudtrail:CreateTrail", "cloudtrail:GetTrail", "cloudtrail:StartLogging", "cloudtrail:PutEventSelectors"},
+				Resource: []string{fmt.Sprintf("arn:%s:cloudtrail:%s:%s:trail/FunctionClarityTrail", p.Partition, p.Region, p.AccountId)},
+			},
+			Statement{
+				Sid:      "TrailBucket",
+				Effect:   "Allow",
+				Action:   []string{"s3:CreateBucket", "s3:GetBucketPolicy", "s3:PutBucketPolicy", "s3:PutLifecycleConfiguration", "s3:PutEncryptionConfiguration"},
+				Resource: []string{bucketArn(p, "function-clarity-stack*"), bucketArn(p, p.Bucket)},
+			})
+	}
+	return statements
+}
+
+func verifyStatements(p Params) []Statement {
+	statements := []Statement{
+		{
+			Sid:    "VerifyFunctions",
+			Effect: "Allow",
+			Action: []string{"lambda:GetFunction", "lambda:ListTags", "lambda:TagResource", "lambda:UntagResource",
+				"lambda:GetFunctionConcurrency", "lambda:PutFunctionConcurrency", "lambda:DeleteFunctionConcurrency",
+				"lambda:ListProvisionedConcurrencyConfigs", "lambda:GetFunctionCodeSigningConfig"},
+			Resource: []string{functionsArn(p)},
+		},
+		{
+			// required by --require-aws-code-signing, which reads the policy of the attached code signing config
+			Sid:      "ReadCodeSigningConfigs",
+			Effect:   "Allow",
+			Action:   []string{"lambda:GetCodeSigningConfig"},
+			Resource: []string{fmt.Sprintf("arn:%s:lambda:*:%s:code-signing-config:*", p.Partition, p.AccountId)},
+		},
+		{
+			// required by --require-signed-layers, which verifies the content of the attached layers of any account
+			Sid:      "ReadLayers",
+			Effect:   "Allow",
+			Action:   []string{"lambda:GetLayerVersion"},
+			Resource: []string{layersArn(p)},
+		},
+		{
+			// required by serve, which enumerates the functions to verify
+			Sid:      "ListFunctions",
+			Effect:   "Allow",
+			Action:   []string{"lambda:ListFunctions"},
+			Resource: []string{"*"},
+		},
+		{
+			// listing the bucket lets a missing signature be told apart from a denied read
+			Sid:      "ReadSignatures",
+			Effect:   "Allow",
+			Action:   []string{"s3:GetObject", "s3:ListBucket"},
+			Resource: []string{bucketArn(p, p.Bucket), bucketArn(p, p.Bucket) + "/*"},
+		},
+		{
+			Sid:      "RecordSignerPins",
+			Effect:   "Allow",
+			Action:   []string{"s3:PutObject"},
+			Resource: []string{bucketArn(p, p.Bucket) + "/*.pin.json"},
+		},
+		{
+			Sid:      "RegistryLogin",
+			Effect:   "Allow",
+			Action:   []string{"ecr:GetAuthorizationToken"},
+			Resource: []string{"*"},
+		},
+		{
+			Sid:      "ReadImages",
+			Effect:   "Allow",
+			Action:   []string{"ecr:BatchGetImage", "ecr:GetDownloadUrlForLayer"},
+			Resource: []string{repositoriesArn(p)},
+		},
+	}
+	var topics []string
+	if p.SnsTopicArn != "" {
+		topics = append(topics, p.SnsTopicArn)
+	}
+	topics = append(topics, p.RoutedTopicArns...)
+	if len(topics) > 0 {
+		statements = append(statements, Statement{
+			Sid:      "Notify",
+			Effect:   "Allow",
+			Action:   []string{"sns:Publish"},
+			Resource: topics,
+		})
+	}
+	if p.ResultQueueArn != "" {
+		statements = append(statements, Statement{
+			Sid:      "SendResults",
+			Effect:   "Allow",
+			Action:   []string{"sqs:SendMessage"},
+			Resource: []string{p.ResultQueueArn},
+		})
+	}
+	return statements
+}
+
+func signStatements(p Params) []Statement {
+	return []Statement{
+		{
+			// signatures are tagged to be expired by the bucket lifecycle
+			Sid:      "UploadSignatures",
+			Effect:   "Allow",
+			Action:   []string{"s3:PutObject", "s3:PutObjectTagging"},
+			Resource: []string{bucketArn(p, p.Bucket) + "/*"},
+		},
+		{
+			Sid:    "RecordBaseline",
+			Effect: "Allow",
+			Action: []string{"lambda:GetFunction", "lambda:ListTags", "lambda:GetFunctionConcurrency",
+				"lambda:ListProvisionedConcurrencyConfigs"},
+			Resource: []string{functionsArn(p)},
+		},
+		{
+			// required by sign aws layer, which signs the content of a layer version
+			Sid:      "ReadLayers",
+			Effect:   "Allow",
+			Action:   []string{"lambda:GetLayerVersion"},
+			Resource: []string{layersArn(p)},
+		},
+		{
+			Sid:      "RegistryLogin",
+			Effect:   "Allow",
+			Action:   []string{"ecr:GetAuthorizationToken"},
+			Resource: []string{"*"},
+		},
+		{
+			Sid:    "PushImageSignatures",
+			Effect: "Allow",
+			Action: []string{"ecr:BatchGetImage", "ecr:GetDownloadUrlForLayer", "ecr:BatchCheckLayerAvailability",
+				"ecr:InitiateLayerUpload", "ecr:UploadLayerPart", "ecr:CompleteLayerUpload", "ecr:PutImage"},
+			Resource: []string{repositoriesArn(p)},
+		},
+	}
+}
+
+func pruneStatements(p Params) []Statement {
+	return []Statement{
+		{
+			Sid:      "ListFunctions",
+			Effect:   "Allow",
+			Action:   []string{"lambda:ListFunctions"},
+			Resource: []string{"*"},
+		},
+		{
+			// the code of every function is fetched to compute its identity
+			Sid:      "ReadFunctionCode",
+			Effect:   "Allow",
+			Action:   []string{"lambda:GetFunction"},
+			Resource: []string{functionsArn(p)},
+		},
+		{
+			// put is required when archiving instead of deleting, along with the tagging copied with the signatures
+			Sid:    "PruneSignatures",
+			Effect: "Allow",
+			Action: []string{"s3:ListBucket", "s3:GetObject", "s3:PutObject", "s3:DeleteObject", "s3:GetObjectTagging",
+				"s3:PutObjectTagging"},
+			Resource: []string{bucketArn(p, p.Bucket), bucketArn(p, p.Bucket) + "/*"},
+		},
+	}
+}
+
+// layersArn matches every layer version, layers are shared across accounts.
+func layersArn(p Params) string {
+	return fmt.Sprintf("arn:%s:lambda:*:*:layer:*:*", p.Partition)
+}
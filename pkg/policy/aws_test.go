@@ -0,0 +1,202 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"strings"
+	"testing"
+)
+
+func hasAction(doc *Document, action string, resource string) bool {
+	for _, s := range doc.Statement {
+		for _, a := range s.Action {
+			if a != action {
+				continue
+			}
+			for _, r := range s.Resource {
+				if r == resource {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func TestVerifyPolicyScopedToBucketAndTopic(t *testing.T) {
+	topic := "arn:aws:sns:us-east-1:123456789012:fc"
+	doc, err := AwsPolicy(VerifyMode, Params{Bucket: "signatures", SnsTopicArn: topic, AccountId: "123456789012"})
+	if err != nil {
+		t.Fatalf("failed to generate policy: %v", err)
+	}
+	if !hasAction(doc, "s3:GetObject", "arn:aws:s3:::signatures/*") {
+		t.Fatalf("expected signature read on the configured bucket")
+	}
+	if !hasAction(doc, "sns:Publish", topic) {
+		t.Fatalf("expected publish on the configured topic")
+	}
+	if hasAction(doc, "s3:PutObject", "arn:aws:s3:::signatures/*") {
+		t.Fatalf("verify policy must not allow uploading signatures")
+	}
+	if !hasAction(doc, "lambda:GetCodeSigningConfig", "arn:aws:lambda:*:123456789012:code-signing-config:*") {
+		t.Fatalf("expected code signing config read in the account")
+	}
+}
+
+func TestVerifyPolicyIncludesRoutedTopics(t *testing.T) {
+	routed := "arn:aws:sns:us-east-1:123456789012:payments"
+	doc, err := AwsPolicy(VerifyMode, Params{Bucket: "signatures", RoutedTopicArns: []string{routed}})
+	if err != nil {
+		t.Fatalf("failed to generate policy: %v", err)
+	}
+	if !hasAction(doc, "sns:Publish", routed) {
+		t.Fatalf("expected publish on the routed topic")
+	}
+}
+
+func TestVerifyPolicyIncludesResultQueue(t *testing.T) {
+	queue := "arn:aws:sqs:us-east-1:123456789012:results"
+	doc, err := AwsPolicy(VerifyMode, Params{Bucket: "signatures", ResultQueueArn: queue})
+	if err != nil {
+		t.Fatalf("failed to generate policy: %v", err)
+	}
+	if !hasAction(doc, "sqs:SendMessage", queue) {
+		t.Fatalf("expected send on the result queue")
+	}
+}
+
+func TestInitPolicyTrail(t *testing.T) {
+	doc, err := AwsPolicy(InitMode, Params{Bucket: "signatures", TrailName: "existing", Region: "us-east-1", AccountId: "123456789012"})
+	if err != nil {
+		t.Fatalf("failed to generate policy: %v", err)
+	}
+	trail := "arn:aws:cloudtrail:us-east-1:123456789012:trail/existing"
+	if !hasAction(doc, "cloudtrail:GetTrail", trail) {
+		t.Fatalf("expected read on the existing trail")
+	}
+	for _, s := range doc.Statement {
+		for _, a := range s.Action {
+			if a == "cloudtrail:CreateTrail" {
+				t.Fatalf("trail creation must not be allowed when an existing trail is configured")
+			}
+		}
+	}
+
+	doc, err = AwsPolicy(InitMode, Params{Bucket: "signatures"})
+	if err != nil {
+		t.Fatalf("failed to generate policy: %v", err)
+	}
+	if !hasAction(doc, "cloudtrail:CreateTrail", "arn:aws:cloudtrail:*:*:trail/FunctionClarityTrail") {
+		t.Fatalf("expected trail creation when no trail is configured")
+	}
+}
+
+func TestInitPolicyCoversInitCalls(t *testing.T) {
+	topic := "arn:aws:sns:us-east-1:123456789012:fc"
+	key := "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+	doc, err := AwsPolicy(InitMode, Params{Bucket: "signatures", SnsTopicArn: topic, KmsKeyArn: key, Region: "us-east-1", AccountId: "123456789012"})
+	if err != nil {
+		t.Fatalf("failed to generate policy: %v", err)
+	}
+	calls := []struct {
+		call     string
+		action   string
+		resource string
+	}{
+		{"GetCallerIdentity", "sts:GetCallerIdentity", "*"},
+		{"DescribeRegions", "ec2:DescribeRegions", "*"},
+		{"HeadBucket", "s3:ListBucket", "arn:aws:s3:::signatures"},
+		{"GetBucketLocation", "s3:GetBucketLocation", "arn:aws:s3:::signatures"},
+		{"CreateBucket", "s3:CreateBucket", "arn:aws:s3:::signatures"},
+		{"PutBucketEncryption", "s3:PutEncryptionConfiguration", "arn:aws:s3:::signatures"},
+		{"PutBucketLifecycleConfiguration", "s3:PutLifecycleConfiguration", "arn:aws:s3:::signatures"},
+		{"PutObject", "s3:PutObject", "arn:aws:s3:::signatures/*"},
+		{"GetTopicAttributes", "sns:GetTopicAttributes", topic},
+		{"CreateTopic", "sns:CreateTopic", topic},
+		{"Subscribe", "sns:Subscribe", topic},
+		{"DescribeKey", "kms:DescribeKey", key},
+		{"GetPublicKey", "kms:GetPublicKey", "arn:aws:kms:us-east-1:123456789012:key/*"},
+		{"CreateTrail", "cloudtrail:CreateTrail", "arn:aws:cloudtrail:us-east-1:123456789012:trail/FunctionClarityTrail"},
+		{"PutBucketEncryption of the trail bucket", "s3:PutEncryptionConfiguration", "arn:aws:s3:::function-clarity-stack*"},
+		{"CreateStack", "cloudformation:CreateStack", "arn:aws:cloudformation:us-east-1:123456789012:stack/function-clarity-stack*/*"},
+	}
+	for _, c := range calls {
+		if !hasAction(doc, c.action, c.resource) {
+			t.Errorf("expected %s on %s, called by init through %s", c.action, c.resource, c.call)
+		}
+	}
+}
+
+func TestPrunePolicyScopedToBucket(t *testing.T) {
+	doc, err := AwsPolicy(PruneMode, Params{Bucket: "signatures", AccountId: "123456789012"})
+	if err != nil {
+		t.Fatalf("failed to generate policy: %v", err)
+	}
+	if !hasAction(doc, "s3:DeleteObject", "arn:aws:s3:::signatures/*") {
+		t.Fatalf("expected signature deletion on the configured bucket")
+	}
+	if !hasAction(doc, "lambda:GetFunction", "arn:aws:lambda:*:123456789012:function:*") {
+		t.Fatalf("expected function code read in the configured account")
+	}
+}
+
+func TestPolicyAllowsSignatureKey(t *testing.T) {
+	key := "arn:aws:kms:us-east-1:123456789012:key/1234abcd"
+	for _, mode := range []string{InitMode, VerifyMode, SignMode, PruneMode} {
+		doc, err := AwsPolicy(mode, Params{Bucket: "signatures", KmsKeyArn: key})
+		if err != nil {
+			t.Fatalf("failed to generate policy: %v", err)
+		}
+		if !hasAction(doc, "kms:GenerateDataKey", key) || !hasAction(doc, "kms:Decrypt", key) {
+			t.Fatalf("mode: %s, expected the signature key to be usable", mode)
+		}
+	}
+	doc, err := AwsPolicy(SignMode, Params{Bucket: "signatures"})
+	if err != nil {
+		t.Fatalf("failed to generate policy: %v", err)
+	}
+	for _, s := range doc.Statement {
+		if s.Sid == "SignatureKey" {
+			t.Fatalf("unexpected kms statement without a key")
+		}
+	}
+}
+
+func TestPolicyInGovCloudPartition(t *testing.T) {
+	doc, err := AwsPolicy(SignMode, Params{Bucket: "signatures", Region: "us-gov-west-1", AccountId: "123456789012"})
+	if err != nil {
+		t.Fatalf("failed to generate policy: %v", err)
+	}
+	if !hasAction(doc, "s3:PutObject", "arn:aws-us-gov:s3:::signatures/*") {
+		t.Fatalf("expected the signature bucket in the govcloud partition")
+	}
+	for _, s := range doc.Statement {
+		for _, resource := range s.Resource {
+			if strings.HasPrefix(resource, "arn:aws:") {
+				t.Fatalf("unexpected resource outside the govcloud partition: %s", resource)
+			}
+		}
+	}
+}
+
+func TestUnsupportedMode(t *testing.T) {
+	if _, err := AwsPolicy("deploy", Params{Bucket: "signatures"}); err == nil {
+		t.Fatalf("expected error for unsupported mode")
+	}
+	if _, err := AwsPolicy(SignMode, Params{}); err == nil {
+		t.Fatalf("expected error when bucket is missing")
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prune
+
+import (
+	"errors"
+	"fmt"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/integrity"
+	"github.com/openclarity/function-clarity/pkg/metadata"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	ArchivePrefix   = "archive/"
+	zipPackageType  = "Zip"
+	signatureType   = "sig"
+	certificateType = "crt.base64"
+	DefaultMinAge   = 7 * 24 * time.Hour
+)
+
+// prunableTypes are the object types function clarity stores per code identity or per function, other objects such
+// as the verifier code are never pruned.
+var prunableTypes = []string{signatureType, certificateType, metadata.FileType, metadata.SignerPinFileType}
+
+// LiveObjects holds the bucket keys still referenced by deployed functions.
+type LiveObjects map[string]bool
+
+// Add records the objects of the functions of a single region, the client must target that region. The code of
+// every zip function and of the layers attached to it is downloaded to compute its identity, an error fails the whole
+// prune since the objects of a function whose identity is unknown can't be told apart from stale ones.
+func (l LiveObjects) Add(client clients.Client, functions []clients.FunctionConfig) error {
+	layers := map[string]bool{}
+	for _, function := range functions {
+		l[strings.ReplaceAll(function.FunctionArn, ":", "_")+"."+metadata.SignerPinFileType] = true
+		if function.PackageType != zipPackageType {
+			// image signatures are stored in the registry next to the image
+			continue
+		}
+		codePath, err := client.GetFuncCode(function.FunctionArn)
+		if err != nil {
+			return fmt.Errorf("failed to fetch code of function: %s: %w", function.FunctionArn, err)
+		}
+		if err = l.addCode(client, codePath, func(identity string) []string {
+			return metadata.FileNames(function.FunctionArn, identity)
+		}); err != nil {
+			return fmt.Errorf("function: %s: %w", function.FunctionArn, err)
+		}
+		layersConfig, err := client.GetFuncLayers(function.FunctionArn)
+		if err != nil {
+			return fmt.Errorf("failed to get layers of function: %s: %w", function.FunctionArn, err)
+		}
+		for _, layerArn := range layersConfig.Arns {
+			if layers[layerArn] {
+				continue
+			}
+			layers[layerArn] = true
+			layerPath, err := client.GetLayerCode(layerArn)
+			if err != nil {
+				return fmt.Errorf("failed to fetch content of layer: %s: %w", layerArn, err)
+			}
+			// layers are signed without a baseline function, their metadata is named after their identity only
+			if err = l.addCode(client, layerPath, func(identity string) []string {
+				return []string{identity}
+			}); err != nil {
+				return fmt.Errorf("layer: %s: %w", layerArn, err)
+			}
+		}
+	}
+	return nil
+}
+
+// addCode records the signatures of the code at codePath and of the signature metadata stored under the names of
+// its identity. The code may be signed with any of the digest algorithms.
+func (l LiveObjects) addCode(client clients.Client, codePath string, metadataNames func(identity string) []string) error {
+	for _, algorithm := range integrity.DigestAlgorithms {
+		hash, err := integrity.NewIdentityGenerator(algorithm)
+		if err != nil {
+			return err
+		}
+		identity, err := hash.GenerateIdentity(codePath)
+		if err != nil {
+			return fmt.Errorf("failed to generate identity: %w", err)
+		}
+		l.addIdentity(identity)
+		for _, metadataName := range metadataNames(identity) {
+			l[metadataName+"."+metadata.FileType] = true
+			metadataIdentity, err := downloadMetadataIdentity(client, metadataName)
+			if err != nil {
+				return fmt.Errorf("failed to get signature metadata: %s: %w", metadataName, err)
+			}
+			if metadataIdentity != "" {
+				l.addIdentity(metadataIdentity)
+			}
+		}
+	}
+	return nil
+}
+
+// Keep records identities that aren't referenced by functions but must not be pruned, e.g. signed snapshots.
+func (l LiveObjects) Keep(identities []string) {
+	for _, identity := range identities {
+		l.addIdentity(identity)
+		l[identity+"."+metadata.FileType] = true
+	}
+}
+
+func (l LiveObjects) addIdentity(identity string) {
+	l[identity+"."+signatureType] = true
+	l[identity+"."+certificateType] = true
+}
+
+func downloadMetadataIdentity(client clients.Client, metadataName string) (string, error) {
+	if err := client.Download(metadataName, metadata.FileType); err != nil {
+		var nsk *s3types.NoSuchKey
+		if errors.As(err, &nsk) {
+			return "", nil
+		}
+		return "", err
+	}
+	content, err := os.ReadFile("/tmp/" + metadataName + "." + metadata.FileType)
+	if err != nil {
+		return "", err
+	}
+	return metadata.Identity(content), nil
+}
+
+// Stale returns the prunable objects not referenced by live functions. Objects younger than minAge are kept, code
+// is usually signed before the function running it is deployed. Archived objects are never returned.
+func Stale(objects []clients.BucketObject, live LiveObjects, minAge time.Duration, now time.Time) []clients.BucketObject {
+	var stale []clients.BucketObject
+	for _, object := range objects {
+		if strings.Contains(object.Key, "/") || !isPrunable(object.Key) || live[object.Key] {
+			continue
+		}
+		if now.Sub(object.LastModified) < minAge {
+			continue
+		}
+		stale = append(stale, object)
+	}
+	return stale
+}
+
+func isPrunable(key string) bool {
+	for _, t := range prunableTypes {
+		if strings.HasSuffix(key, "."+t) {
+			return true
+		}
+	}
+	return false
+}
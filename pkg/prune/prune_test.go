@@ -0,0 +1,179 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prune
+
+import (
+	"errors"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/integrity"
+	"github.com/openclarity/function-clarity/pkg/metadata"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type codeClient struct {
+	clients.Client
+	code   map[string]string
+	layers map[string][]string
+	files  map[string]string
+}
+
+func (c *codeClient) GetFuncCode(funcIdentifier string) (string, error) {
+	content, ok := c.code[funcIdentifier]
+	if !ok {
+		return "", errors.New("access denied")
+	}
+	dir := filepath.Join(os.TempDir(), "prune-test-"+filepath.Base(funcIdentifier))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return dir, os.WriteFile(filepath.Join(dir, "main.py"), []byte(content), 0600)
+}
+
+func (c *codeClient) GetFuncLayers(funcIdentifier string) (*metadata.LayersConfig, error) {
+	return &metadata.LayersConfig{Arns: c.layers[funcIdentifier]}, nil
+}
+
+func (c *codeClient) GetLayerCode(layerArn string) (string, error) {
+	return c.GetFuncCode(layerArn)
+}
+
+func (c *codeClient) Download(fileName string, outputType string) error {
+	content, ok := c.files[fileName+"."+outputType]
+	if !ok {
+		return &s3types.NoSuchKey{}
+	}
+	return os.WriteFile("/tmp/"+fileName+"."+outputType, []byte(content), 0600)
+}
+
+func identityOf(t *testing.T, client *codeClient, arn string) string {
+	path, err := client.GetFuncCode(arn)
+	if err != nil {
+		t.Fatalf("failed to get code: %v", err)
+	}
+	integrityCalculator := integrity.Sha256{}
+	identity, err := integrityCalculator.GenerateIdentity(path)
+	if err != nil {
+		t.Fatalf("failed to generate identity: %v", err)
+	}
+	return identity
+}
+
+func TestLiveObjectsAndStale(t *testing.T) {
+	signed := "arn:aws:lambda:us-east-1:123456789012:function:signed"
+	client := &codeClient{code: map[string]string{signed: "print('signed')"}, files: map[string]string{}}
+	identity := identityOf(t, client, signed)
+	metadataContent := `{"annotations":{"ci.provider":"github-actions"}}`
+	client.files[identity+"."+metadata.FileType] = metadataContent
+	metadataIdentity := metadata.Identity([]byte(metadataContent))
+	functionMetadataContent := `{"concurrency":{"reservedConcurrentExecutions":5}}`
+	client.files[metadata.FunctionFileName(signed, identity)+"."+metadata.FileType] = functionMetadataContent
+	functionMetadataIdentity := metadata.Identity([]byte(functionMetadataContent))
+
+	live := LiveObjects{}
+	live.Keep([]string{"snapshot"})
+	err := live.Add(client, []clients.FunctionConfig{
+		{FunctionArn: signed, PackageType: "Zip"},
+		{FunctionArn: "arn:aws:lambda:us-east-1:123456789012:function:image", PackageType: "Image"},
+	})
+	if err != nil {
+		t.Fatalf("failed to add live objects: %v", err)
+	}
+
+	now := time.Now()
+	old := now.Add(-30 * 24 * time.Hour)
+	objects := []clients.BucketObject{
+		{Key: identity + ".sig", LastModified: old},
+		{Key: identity + ".metadata.json", LastModified: old},
+		{Key: metadataIdentity + ".sig", LastModified: old},
+		{Key: "arn_aws_lambda_us-east-1_123456789012_function_signed." + identity + ".metadata.json", LastModified: old},
+		{Key: functionMetadataIdentity + ".sig", LastModified: old},
+		{Key: "arn_aws_lambda_us-east-1_123456789012_function_image.pin.json", LastModified: old},
+		{Key: "snapshot.sig", LastModified: old},
+		{Key: "function-clarity.zip", LastModified: old},
+		{Key: "archive/deleted.sig", LastModified: old},
+		{Key: "deleted.sig", LastModified: old},
+		{Key: "deleted.crt.base64", LastModified: old},
+		{Key: "arn_aws_lambda_us-east-1_123456789012_function_deleted.pin.json", LastModified: old},
+		{Key: "arn_aws_lambda_us-east-1_123456789012_function_deleted." + identity + ".metadata.json", LastModified: old},
+		{Key: "not-yet-deployed.sig", LastModified: now.Add(-time.Hour)},
+	}
+	stale := Stale(objects, live, DefaultMinAge, now)
+	expected := []string{"deleted.sig", "deleted.crt.base64", "arn_aws_lambda_us-east-1_123456789012_function_deleted.pin.json",
+		"arn_aws_lambda_us-east-1_123456789012_function_deleted." + identity + ".metadata.json"}
+	if len(stale) != len(expected) {
+		t.Fatalf("expected stale objects: %v, got: %+v", expected, stale)
+	}
+	for i, object := range stale {
+		if object.Key != expected[i] {
+			t.Fatalf("expected stale object: %s, got: %s", expected[i], object.Key)
+		}
+	}
+}
+
+func TestLiveObjectsFailsWhenCodeIsUnavailable(t *testing.T) {
+	client := &codeClient{code: map[string]string{}, files: map[string]string{}}
+	err := LiveObjects{}.Add(client, []clients.FunctionConfig{{FunctionArn: "arn:aws:lambda:us-east-1:123456789012:function:denied", PackageType: "Zip"}})
+	if err == nil {
+		t.Fatalf("expected an error when the identity of a function can't be computed")
+	}
+}
+
+func TestLiveObjectsKeepsLayerSignatures(t *testing.T) {
+	function := "arn:aws:lambda:us-east-1:123456789012:function:layered"
+	layer := "arn:aws:lambda:us-east-1:123456789012:layer:shared:3"
+	client := &codeClient{
+		code:   map[string]string{function: "print('layered')", layer: "def shared(): pass"},
+		layers: map[string][]string{function: {layer}},
+		files:  map[string]string{},
+	}
+	layerPath, err := client.GetLayerCode(layer)
+	if err != nil {
+		t.Fatalf("failed to get layer code: %v", err)
+	}
+	var objects []clients.BucketObject
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	for _, algorithm := range integrity.DigestAlgorithms {
+		hash, err := integrity.NewIdentityGenerator(algorithm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		layerIdentity, err := hash.GenerateIdentity(layerPath)
+		if err != nil {
+			t.Fatalf("failed to generate identity: %v", err)
+		}
+		metadataContent := `{"annotations":{"layer":"shared"},"digestAlgorithm":"` + algorithm + `"}`
+		client.files[layerIdentity+"."+metadata.FileType] = metadataContent
+		objects = append(objects,
+			clients.BucketObject{Key: layerIdentity + ".sig", LastModified: old},
+			clients.BucketObject{Key: layerIdentity + ".crt.base64", LastModified: old},
+			clients.BucketObject{Key: layerIdentity + ".metadata.json", LastModified: old},
+			clients.BucketObject{Key: metadata.Identity([]byte(metadataContent)) + ".sig", LastModified: old})
+	}
+	objects = append(objects, clients.BucketObject{Key: "detached-layer.sig", LastModified: old})
+
+	live := LiveObjects{}
+	if err = live.Add(client, []clients.FunctionConfig{{FunctionArn: function, PackageType: "Zip"}}); err != nil {
+		t.Fatalf("failed to add live objects: %v", err)
+	}
+	stale := Stale(objects, live, DefaultMinAge, time.Now())
+	if len(stale) != 1 || stale[0].Key != "detached-layer.sig" {
+		t.Fatalf("expected only the signature of the detached layer to be stale, got: %+v", stale)
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// DeduplicationKey identifies the result of a function verification in a scan, so consumers can drop the copies of a
+// result delivered again by a retry. It is the hex sha256 of the function identifier, the sha256 of the function
+// code and the scan id, each followed by a newline.
+func DeduplicationKey(functionIdentifier string, digest string, scanID string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(functionIdentifier+"\n"+digest+"\n"+scanID+"\n")))
+}
@@ -0,0 +1,93 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	OpenVEXContext = "https://openvex.dev/ns"
+	// UnverifiedFunction is the condition reported for functions without a valid function clarity signature.
+	UnverifiedFunction = "function-clarity:unverified-function"
+	StatusAffected     = "affected"
+	vexAuthor          = "function-clarity"
+)
+
+type VexDocument struct {
+	Context    string         `json:"@context"`
+	ID         string         `json:"@id"`
+	Author     string         `json:"author"`
+	Role       string         `json:"role,omitempty"`
+	Timestamp  time.Time      `json:"timestamp"`
+	Version    int            `json:"version"`
+	Tooling    string         `json:"tooling,omitempty"`
+	Statements []VexStatement `json:"statements"`
+}
+
+type VexStatement struct {
+	Vulnerability   string   `json:"vulnerability"`
+	Products        []string `json:"products"`
+	Status          string   `json:"status"`
+	StatusNotes     string   `json:"status_notes,omitempty"`
+	ActionStatement string   `json:"action_statement,omitempty"`
+}
+
+// UnverifiedFunctionStatement states that the function is affected by the unverified condition, the verification
+// failure is kept in the status notes.
+func UnverifiedFunctionStatement(product string, reason string) VexStatement {
+	return VexStatement{
+		Vulnerability:   UnverifiedFunction,
+		Products:        []string{product},
+		Status:          StatusAffected,
+		StatusNotes:     reason,
+		ActionStatement: "sign the function code or image with function clarity, or block the function until it is signed",
+	}
+}
+
+func NewVexDocument(statements []VexStatement, timestamp time.Time) (*VexDocument, error) {
+	if statements == nil {
+		statements = []VexStatement{}
+	}
+	content, err := json.Marshal(statements)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal vex statements: %w", err)
+	}
+	return &VexDocument{
+		Context:    OpenVEXContext,
+		ID:         fmt.Sprintf("https://openvex.dev/docs/public/vex-%x", sha256.Sum256(append(content, timestamp.String()...))),
+		Author:     vexAuthor,
+		Timestamp:  timestamp.UTC(),
+		Version:    1,
+		Tooling:    vexAuthor,
+		Statements: statements,
+	}, nil
+}
+
+func WriteVexDocument(path string, doc *VexDocument) error {
+	content, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal vex document: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write vex document: %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteUnverifiedFunctionVex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vex.json")
+	arn := "arn:aws:lambda:us-east-1:123456789012:function:unsigned"
+	doc, err := NewVexDocument([]VexStatement{UnverifiedFunctionStatement(arn, "verification error: no signature")}, time.Now())
+	if err != nil {
+		t.Fatalf("failed to create vex document: %v", err)
+	}
+	if err := WriteVexDocument(path, doc); err != nil {
+		t.Fatalf("failed to write vex document: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read vex document: %v", err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(content, &raw); err != nil {
+		t.Fatalf("vex document is not valid json: %v", err)
+	}
+	if raw["@context"] != OpenVEXContext {
+		t.Fatalf("unexpected context: %v", raw["@context"])
+	}
+	statements := raw["statements"].([]interface{})
+	if len(statements) != 1 {
+		t.Fatalf("expected a single statement, got: %d", len(statements))
+	}
+	statement := statements[0].(map[string]interface{})
+	if statement["status"] != StatusAffected || statement["vulnerability"] != UnverifiedFunction {
+		t.Fatalf("unexpected statement: %v", statement)
+	}
+	if products := statement["products"].([]interface{}); products[0] != arn {
+		t.Fatalf("expected function as product, got: %v", products)
+	}
+}
+
+func TestEmptyVexDocument(t *testing.T) {
+	doc, err := NewVexDocument(nil, time.Now())
+	if err != nil {
+		t.Fatalf("failed to create vex document: %v", err)
+	}
+	content, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("failed to marshal vex document: %v", err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(content, &raw); err != nil {
+		t.Fatalf("vex document is not valid json: %v", err)
+	}
+	if statements, ok := raw["statements"].([]interface{}); !ok || len(statements) != 0 {
+		t.Fatalf("expected empty statements list, got: %v", raw["statements"])
+	}
+}
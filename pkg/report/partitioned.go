@@ -0,0 +1,219 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	PartitionByRegion  = "region"
+	PartitionByAccount = "account"
+	ManifestFileName   = "index.json"
+	unknownPartition   = "unknown"
+	manifestLockName   = ".index.lock"
+	lockRetryInterval  = 50 * time.Millisecond
+	lockTimeout        = 10 * time.Second
+	// staleLockAge is the age after which a lock left by a crashed writer is removed.
+	staleLockAge = 30 * time.Second
+)
+
+type FunctionResult struct {
+	FunctionIdentifier string `json:"functionIdentifier"`
+	Region             string `json:"region,omitempty"`
+	Account            string `json:"account,omitempty"`
+	Result             string `json:"result"`
+	Reason             string `json:"reason,omitempty"`
+	DurationMs         int64  `json:"durationMs"`
+	// Signer describes the untrusted key or identity that signed the function code.
+	Signer string `json:"signer,omitempty"`
+	// SigningIdentity is set for keylessly signed functions that passed verification.
+	SigningIdentity *SigningIdentity `json:"signingIdentity,omitempty"`
+	// Digest is the sha256 of the function code, and ScanID the scan the result is part of.
+	Digest           string `json:"digest,omitempty"`
+	ScanID           string `json:"scanId,omitempty"`
+	DeduplicationKey string `json:"deduplicationKey,omitempty"`
+	// Action is the action applied to the function when it failed verification, would-block for a dry run block.
+	Action string `json:"action,omitempty"`
+}
+
+// ResultsFile holds the results of a single partition, each file is a complete JSON document on its own.
+type ResultsFile struct {
+	Partition   string           `json:"partition"`
+	PartitionBy string           `json:"partitionBy"`
+	GeneratedAt time.Time        `json:"generatedAt"`
+	Results     []FunctionResult `json:"results"`
+}
+
+type Manifest struct {
+	GeneratedAt time.Time       `json:"generatedAt"`
+	Files       []ManifestEntry `json:"files"`
+	// InaccessibleScopes lists the accounts and regions the scans couldn't access, with the error of each.
+	InaccessibleScopes []InaccessibleScope `json:"inaccessibleScopes,omitempty"`
+}
+
+type ManifestEntry struct {
+	Partition   string    `json:"partition"`
+	PartitionBy string    `json:"partitionBy"`
+	File        string    `json:"file"`
+	Count       int       `json:"count"`
+	GeneratedAt time.Time `json:"generatedAt"`
+}
+
+// NewFunctionResult fills the region and account of the result from the function ARN, they are left empty when the
+// identifier isn't an ARN.
+func NewFunctionResult(functionIdentifier string, result string, reason string, duration time.Duration) FunctionResult {
+	r := FunctionResult{FunctionIdentifier: functionIdentifier, Result: result, Reason: reason, DurationMs: duration.Milliseconds()}
+	// arn:partition:lambda:region:account:function:name
+	if parts := strings.Split(functionIdentifier, ":"); len(parts) >= 7 && parts[0] == "arn" {
+		r.Region = parts[3]
+		r.Account = parts[4]
+	}
+	return r
+}
+
+// WritePartitionedResults writes one results file per region or account into dir and updates the manifest index
+// of the directory. Every file is written to a temporary file first and renamed into place, so readers and
+// scans running in parallel never see a partially written file. The manifest is rebuilt from the files in the
+// directory while holding a lock, so it also lists the partitions written by other scans.
+func WritePartitionedResults(dir string, partitionBy string, results []FunctionResult, generatedAt time.Time) error {
+	if partitionBy != PartitionByRegion && partitionBy != PartitionByAccount {
+		return fmt.Errorf("unsupported partition: %s, expected %s or %s", partitionBy, PartitionByRegion, PartitionByAccount)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create results directory: %s: %w", dir, err)
+	}
+	partitions := map[string][]FunctionResult{}
+	for _, result := range results {
+		partition := result.Region
+		if partitionBy == PartitionByAccount {
+			partition = result.Account
+		}
+		if partition == "" {
+			partition = unknownPartition
+		}
+		partitions[partition] = append(partitions[partition], result)
+	}
+	for partition, partitionResults := range partitions {
+		file := ResultsFile{Partition: partition, PartitionBy: partitionBy, GeneratedAt: generatedAt.UTC(), Results: partitionResults}
+		if err := writeJSONAtomically(filepath.Join(dir, partitionFileName(partitionBy, partition)), file); err != nil {
+			return err
+		}
+	}
+	return updateManifest(dir, generatedAt)
+}
+
+func partitionFileName(partitionBy string, partition string) string {
+	return fmt.Sprintf("%s-%s.json", partitionBy, strings.NewReplacer("/", "_", string(os.PathSeparator), "_").Replace(partition))
+}
+
+func updateManifest(dir string, generatedAt time.Time) error {
+	unlock, err := lockDir(dir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to list results directory: %s: %w", dir, err)
+	}
+	manifest := Manifest{GeneratedAt: generatedAt.UTC(), Files: []ManifestEntry{}}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || name == ManifestFileName || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return fmt.Errorf("failed to read results file: %s: %w", name, err)
+		}
+		if strings.HasPrefix(name, inaccessibleScopePrefix) {
+			var scope InaccessibleScope
+			if err = json.Unmarshal(content, &scope); err == nil {
+				manifest.InaccessibleScopes = append(manifest.InaccessibleScopes, scope)
+			}
+			continue
+		}
+		var file ResultsFile
+		if err = json.Unmarshal(content, &file); err != nil || file.PartitionBy == "" {
+			// not a results file
+			continue
+		}
+		manifest.Files = append(manifest.Files, ManifestEntry{Partition: file.Partition, PartitionBy: file.PartitionBy, File: name,
+			Count: len(file.Results), GeneratedAt: file.GeneratedAt})
+	}
+	sort.Slice(manifest.Files, func(i, j int) bool {
+		return manifest.Files[i].File < manifest.Files[j].File
+	})
+	return writeJSONAtomically(filepath.Join(dir, ManifestFileName), manifest)
+}
+
+// lockDir serializes manifest updates of scans writing into the same directory, including scans of other processes.
+func lockDir(dir string) (func(), error) {
+	lockPath := filepath.Join(dir, manifestLockName)
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to lock results directory: %s: %w", dir, err)
+		}
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for the lock of results directory: %s", dir)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+func writeJSONAtomically(path string, v interface{}) error {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", filepath.Base(path), err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file for %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err = os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
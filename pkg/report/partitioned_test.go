@@ -0,0 +1,116 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewFunctionResultParsesArn(t *testing.T) {
+	r := NewFunctionResult("arn:aws:lambda:eu-west-1:123456789012:function:payments", "passed", "", 1500*time.Millisecond)
+	if r.Region != "eu-west-1" || r.Account != "123456789012" || r.DurationMs != 1500 {
+		t.Fatalf("unexpected result: %+v", r)
+	}
+	if r = NewFunctionResult("payments", "passed", "", 0); r.Region != "" || r.Account != "" {
+		t.Fatalf("expected no region and account for a function name, got: %+v", r)
+	}
+}
+
+func TestWritePartitionedResults(t *testing.T) {
+	dir := t.TempDir()
+	results := []FunctionResult{
+		NewFunctionResult("arn:aws:lambda:us-east-1:111111111111:function:a", "passed", "", 0),
+		NewFunctionResult("arn:aws:lambda:us-east-1:222222222222:function:b", "failed", "no signature", 0),
+		NewFunctionResult("arn:aws:lambda:eu-west-1:111111111111:function:c", "passed", "", 0),
+	}
+	if err := WritePartitionedResults(dir, PartitionByRegion, results, time.Now()); err != nil {
+		t.Fatalf("failed to write results: %v", err)
+	}
+	manifest := readManifest(t, dir)
+	if len(manifest.Files) != 2 {
+		t.Fatalf("expected 2 partitions, got: %+v", manifest.Files)
+	}
+	counts := map[string]int{}
+	for _, entry := range manifest.Files {
+		counts[entry.Partition] = entry.Count
+		var file ResultsFile
+		content, err := os.ReadFile(filepath.Join(dir, entry.File))
+		if err != nil {
+			t.Fatalf("failed to read partition file: %v", err)
+		}
+		if err = json.Unmarshal(content, &file); err != nil {
+			t.Fatalf("partition file: %s is not valid json: %v", entry.File, err)
+		}
+	}
+	if counts["us-east-1"] != 2 || counts["eu-west-1"] != 1 {
+		t.Fatalf("unexpected partition counts: %v", counts)
+	}
+	if err := WritePartitionedResults(dir, "function", results, time.Now()); err == nil {
+		t.Fatalf("expected an error for an unsupported partition")
+	}
+}
+
+func TestWritePartitionedResultsFromParallelScans(t *testing.T) {
+	dir := t.TempDir()
+	regions := []string{"us-east-1", "us-east-2", "us-west-1", "us-west-2", "eu-west-1", "eu-central-1"}
+	var wg sync.WaitGroup
+	errs := make(chan error, len(regions))
+	for _, region := range regions {
+		wg.Add(1)
+		go func(region string) {
+			defer wg.Done()
+			var results []FunctionResult
+			for i := 0; i < 50; i++ {
+				results = append(results, NewFunctionResult(fmt.Sprintf("arn:aws:lambda:%s:111111111111:function:f%d", region, i), "passed", "", 0))
+			}
+			errs <- WritePartitionedResults(dir, PartitionByRegion, results, time.Now())
+		}(region)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("failed to write results: %v", err)
+		}
+	}
+	manifest := readManifest(t, dir)
+	if len(manifest.Files) != len(regions) {
+		t.Fatalf("expected the manifest to list all %d regions, got: %+v", len(regions), manifest.Files)
+	}
+	for _, entry := range manifest.Files {
+		if entry.Count != 50 {
+			t.Fatalf("expected 50 results in partition: %s, got: %d", entry.Partition, entry.Count)
+		}
+	}
+}
+
+func readManifest(t *testing.T, dir string) Manifest {
+	content, err := os.ReadFile(filepath.Join(dir, ManifestFileName))
+	if err != nil {
+		t.Fatalf("failed to read manifest: %v", err)
+	}
+	var manifest Manifest
+	if err = json.Unmarshal(content, &manifest); err != nil {
+		t.Fatalf("manifest is not valid json: %v", err)
+	}
+	return manifest
+}
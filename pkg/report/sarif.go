@@ -0,0 +1,171 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const (
+	SarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	SarifVersion = "2.1.0"
+	sarifToolURI = "https://github.com/openclarity/function-clarity"
+)
+
+type SarifReport struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SarifRun `json:"runs"`
+}
+
+type SarifRun struct {
+	Tool    SarifTool     `json:"tool"`
+	Results []SarifResult `json:"results"`
+}
+
+type SarifTool struct {
+	Driver SarifDriver `json:"driver"`
+}
+
+type SarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []SarifRule `json:"rules"`
+}
+
+type SarifRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	ShortDescription     SarifMessage       `json:"shortDescription"`
+	DefaultConfiguration SarifConfiguration `json:"defaultConfiguration"`
+}
+
+type SarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type SarifMessage struct {
+	Text string `json:"text"`
+}
+
+type SarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	RuleIndex  int               `json:"ruleIndex"`
+	Level      string            `json:"level"`
+	Message    SarifMessage      `json:"message"`
+	Locations  []SarifLocation   `json:"locations"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type SarifLocation struct {
+	PhysicalLocation SarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []SarifLogicalLocation `json:"logicalLocations"`
+}
+
+type SarifPhysicalLocation struct {
+	ArtifactLocation SarifArtifactLocation `json:"artifactLocation"`
+}
+
+type SarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type SarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// sarifRules are the rules of the findings, by status of the verification result. Passed results have no finding.
+var sarifRules = []struct {
+	status string
+	rule   SarifRule
+}{
+	{"failed", newSarifRule("fc/unsigned-function", "UnsignedFunction", "The function code or image has no valid function clarity signature", "error")},
+	{"untrusted-signer", newSarifRule("fc/signature-mismatch", "SignatureMismatch", "The function code or image is signed by an untrusted key or identity", "error")},
+	{"unsigned-layer", newSarifRule("fc/unsigned-layer", "UnsignedLayer", "A layer attached to the function isn't signed", "error")},
+	{"aws-code-signing-missing", newSarifRule("fc/aws-code-signing-missing", "AwsCodeSigningMissing", "The function has no enforced AWS code signing config", "error")},
+	{"image-digest-unpinned", newSarifRule("fc/image-digest-unpinned", "ImageDigestUnpinned", "The function references its image by tag instead of digest", "error")},
+	{"error", newSarifRule("fc/verification-error", "VerificationError", "The verification of the function errored", "warning")},
+	{"timed-out", newSarifRule("fc/verification-timed-out", "VerificationTimedOut", "The verification of the function timed out", "warning")},
+}
+
+func newSarifRule(id string, name string, description string, level string) SarifRule {
+	return SarifRule{ID: id, Name: name, ShortDescription: SarifMessage{Text: description}, DefaultConfiguration: SarifConfiguration{Level: level}}
+}
+
+// NewSarifReport maps the results to a SARIF 2.1.0 report with a finding for each function that didn't pass
+// verification, located at the function ARN.
+func NewSarifReport(results []VerificationResult) *SarifReport {
+	run := SarifRun{
+		Tool:    SarifTool{Driver: SarifDriver{Name: vexAuthor, InformationURI: sarifToolURI}},
+		Results: []SarifResult{},
+	}
+	ruleIndex := map[string]int{}
+	for i, r := range sarifRules {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, r.rule)
+		ruleIndex[r.status] = i
+	}
+	for _, result := range results {
+		index, found := ruleIndex[result.Status]
+		if !found {
+			continue
+		}
+		rule := sarifRules[index].rule
+		message := result.Reason
+		if message == "" {
+			message = rule.ShortDescription.Text
+		}
+		finding := SarifResult{
+			RuleID:    rule.ID,
+			RuleIndex: index,
+			Level:     rule.DefaultConfiguration.Level,
+			Message:   SarifMessage{Text: message},
+			Locations: []SarifLocation{{
+				PhysicalLocation: SarifPhysicalLocation{ArtifactLocation: SarifArtifactLocation{URI: result.FunctionArn}},
+				LogicalLocations: []SarifLogicalLocation{{FullyQualifiedName: result.FunctionArn, Kind: "function"}},
+			}},
+		}
+		properties := map[string]string{}
+		if result.Region != "" {
+			properties["region"] = result.Region
+		}
+		if result.Digest != "" {
+			properties["digest"] = result.Digest
+		}
+		if result.Signer != "" {
+			properties["signer"] = result.Signer
+		}
+		if len(properties) > 0 {
+			finding.Properties = properties
+		}
+		run.Results = append(run.Results, finding)
+	}
+	return &SarifReport{Schema: SarifSchema, Version: SarifVersion, Runs: []SarifRun{run}}
+}
+
+// WriteSarifReport writes the SARIF report of the results as indented JSON.
+func WriteSarifReport(w io.Writer, results []VerificationResult) error {
+	content, err := json.MarshalIndent(NewSarifReport(results), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal sarif report: %w", err)
+	}
+	if _, err = w.Write(append(content, '\n')); err != nil {
+		return fmt.Errorf("failed to write sarif report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+)
+
+func sarifTestResults() []VerificationResult {
+	timestamp := time.Date(2022, 11, 1, 10, 0, 0, 0, time.UTC)
+	return []VerificationResult{
+		{FunctionArn: "arn:aws:lambda:us-east-1:123456789012:function:signed", Region: "us-east-1", Status: "passed", Digest: "c2lnbmVk", Timestamp: timestamp},
+		{FunctionArn: "arn:aws:lambda:us-east-1:123456789012:function:unsigned", Region: "us-east-1", Status: "failed",
+			Reason: "code verification error: no signature", Digest: "dW5zaWduZWQ=", Timestamp: timestamp},
+		{FunctionArn: "arn:aws:lambda:eu-west-1:123456789012:function:tampered", Region: "eu-west-1", Status: "untrusted-signer",
+			Reason: "code verification error: invalid signature", Signer: "key sha256:abcd", Timestamp: timestamp},
+		{FunctionArn: "arn:aws:lambda:eu-west-1:123456789012:function:throttled", Region: "eu-west-1", Status: "error", Timestamp: timestamp},
+	}
+}
+
+func TestWriteSarifReportGolden(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteSarifReport(&out, sarifTestResults()); err != nil {
+		t.Fatalf("failed to write sarif report: %v", err)
+	}
+	golden, err := os.ReadFile("testdata/verification.sarif")
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+	if !bytes.Equal(out.Bytes(), golden) {
+		t.Fatalf("sarif report doesn't match testdata/verification.sarif, got:\n%s", out.String())
+	}
+}
+
+func TestSarifReportStructure(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteSarifReport(&out, sarifTestResults()); err != nil {
+		t.Fatalf("failed to write sarif report: %v", err)
+	}
+	var raw struct {
+		Schema  string `json:"$schema"`
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string `json:"name"`
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				RuleIndex int    `json:"ruleIndex"`
+				Level     string `json:"level"`
+				Message   struct {
+					Text string `json:"text"`
+				} `json:"message"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &raw); err != nil {
+		t.Fatalf("sarif report isn't valid json: %v", err)
+	}
+	if raw.Version != SarifVersion || raw.Schema != SarifSchema || len(raw.Runs) != 1 {
+		t.Fatalf("unexpected sarif envelope: version %s, schema %s, %d runs", raw.Version, raw.Schema, len(raw.Runs))
+	}
+	run := raw.Runs[0]
+	if run.Tool.Driver.Name != "function-clarity" {
+		t.Fatalf("unexpected tool name: %s", run.Tool.Driver.Name)
+	}
+	expected := []struct{ ruleID, uri, level string }{
+		{"fc/unsigned-function", "arn:aws:lambda:us-east-1:123456789012:function:unsigned", "error"},
+		{"fc/signature-mismatch", "arn:aws:lambda:eu-west-1:123456789012:function:tampered", "error"},
+		{"fc/verification-error", "arn:aws:lambda:eu-west-1:123456789012:function:throttled", "warning"},
+	}
+	if len(run.Results) != len(expected) {
+		t.Fatalf("expected %d findings, the passed function left out, got %d", len(expected), len(run.Results))
+	}
+	for i, e := range expected {
+		result := run.Results[i]
+		if result.RuleID != e.ruleID || result.Level != e.level || len(result.Locations) != 1 ||
+			result.Locations[0].PhysicalLocation.ArtifactLocation.URI != e.uri {
+			t.Fatalf("unexpected finding %d: %+v", i, result)
+		}
+		if run.Tool.Driver.Rules[result.RuleIndex].ID != result.RuleID {
+			t.Fatalf("rule index %d of finding %d doesn't reference rule %s", result.RuleIndex, i, result.RuleID)
+		}
+		if result.Message.Text == "" {
+			t.Fatalf("finding %d has no message", i)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const inaccessibleScopePrefix = "inaccessible-"
+
+// Scope is an account and region of a scan, the account is the one of the assumed role or, without a role, the one
+// of the configured credentials.
+type Scope struct {
+	Role   string `json:"role,omitempty"`
+	Region string `json:"region"`
+}
+
+func (s Scope) String() string {
+	if s.Role == "" {
+		return "region: " + s.Region
+	}
+	return fmt.Sprintf("role: %s region: %s", s.Role, s.Region)
+}
+
+// InaccessibleScope is a scope a scan couldn't list the functions of, with the error listing them.
+type InaccessibleScope struct {
+	Scope
+	Reason      string    `json:"reason"`
+	GeneratedAt time.Time `json:"generatedAt"`
+}
+
+// WriteInaccessibleScopes writes a file into dir for every inaccessible scope and updates the manifest index of the
+// directory. The file of a scanned scope left by an earlier scan is removed once the scope is accessible again.
+func WriteInaccessibleScopes(dir string, scanned []Scope, inaccessible []InaccessibleScope, generatedAt time.Time) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create results directory: %s: %w", dir, err)
+	}
+	written := map[string]bool{}
+	for _, scope := range inaccessible {
+		scope.GeneratedAt = generatedAt.UTC()
+		name := inaccessibleScopeFileName(scope.Scope)
+		if err := writeJSONAtomically(filepath.Join(dir, name), scope); err != nil {
+			return err
+		}
+		written[name] = true
+	}
+	for _, scope := range scanned {
+		name := inaccessibleScopeFileName(scope)
+		if written[name] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove inaccessible scope file: %s: %w", name, err)
+		}
+	}
+	return updateManifest(dir, generatedAt)
+}
+
+// inaccessibleScopeFileName names the file after the account of the role, roles are expected to be of distinct
+// accounts.
+func inaccessibleScopeFileName(scope Scope) string {
+	account := "default"
+	// arn:partition:iam::account:role/name
+	if parts := strings.Split(scope.Role, ":"); len(parts) >= 6 && parts[0] == "arn" {
+		account = parts[4]
+	}
+	return fmt.Sprintf("%s%s-%s.json", inaccessibleScopePrefix, account, strings.NewReplacer("/", "_", string(os.PathSeparator), "_").Replace(scope.Region))
+}
@@ -0,0 +1,50 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteInaccessibleScopes(t *testing.T) {
+	dir := t.TempDir()
+	own := Scope{Region: "us-east-1"}
+	other := Scope{Role: "arn:aws:iam::222222222222:role/scanner", Region: "us-east-1"}
+	inaccessible := []InaccessibleScope{{Scope: other, Reason: "AccessDenied"}}
+	if err := WriteInaccessibleScopes(dir, []Scope{own, other}, inaccessible, time.Now()); err != nil {
+		t.Fatalf("failed to write inaccessible scopes: %v", err)
+	}
+	manifest := readManifest(t, dir)
+	if len(manifest.Files) != 0 {
+		t.Fatalf("inaccessible scopes should not be listed as results files, got: %+v", manifest.Files)
+	}
+	if len(manifest.InaccessibleScopes) != 1 || manifest.InaccessibleScopes[0].Scope != other || manifest.InaccessibleScopes[0].Reason != "AccessDenied" {
+		t.Fatalf("expected the inaccessible scope in the manifest, got: %+v", manifest.InaccessibleScopes)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "inaccessible-222222222222-us-east-1.json")); err != nil {
+		t.Fatalf("expected a file named after the account of the role: %v", err)
+	}
+
+	if err := WriteInaccessibleScopes(dir, []Scope{own, other}, nil, time.Now()); err != nil {
+		t.Fatalf("failed to write inaccessible scopes: %v", err)
+	}
+	if manifest = readManifest(t, dir); len(manifest.InaccessibleScopes) != 0 {
+		t.Fatalf("a scope accessible again should be removed from the manifest, got: %+v", manifest.InaccessibleScopes)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/sigstore/cosign/pkg/signature"
+	"os"
+	"strings"
+	"time"
+)
+
+// SigningIdentity describes who signed a function keylessly, the OIDC claims fulcio embedded in the signing
+// certificate and the certificate chain up to the fulcio root.
+type SigningIdentity struct {
+	Subject                  string            `json:"subject"`
+	Issuer                   string            `json:"issuer"`
+	GithubWorkflowTrigger    string            `json:"githubWorkflowTrigger,omitempty"`
+	GithubWorkflowSha        string            `json:"githubWorkflowSha,omitempty"`
+	GithubWorkflowName       string            `json:"githubWorkflowName,omitempty"`
+	GithubWorkflowRepository string            `json:"githubWorkflowRepository,omitempty"`
+	GithubWorkflowRef        string            `json:"githubWorkflowRef,omitempty"`
+	Chain                    []CertificateInfo `json:"chain"`
+}
+
+type CertificateInfo struct {
+	Subject      string    `json:"subject"`
+	Issuer       string    `json:"issuer"`
+	SerialNumber string    `json:"serialNumber"`
+	NotBefore    time.Time `json:"notBefore"`
+	NotAfter     time.Time `json:"notAfter"`
+	Fingerprint  string    `json:"fingerprint"`
+}
+
+// SigningIdentityRecord is the JSON document written by verify for a keylessly signed function.
+type SigningIdentityRecord struct {
+	FunctionIdentifier string           `json:"functionIdentifier"`
+	VerifiedAt         time.Time        `json:"verifiedAt"`
+	SigningIdentity    *SigningIdentity `json:"signingIdentity"`
+}
+
+// NewSigningIdentity reads the claims from the leaf certificate, chain holds the certificates from the leaf up to
+// the root, the leaf included.
+func NewSigningIdentity(leaf *x509.Certificate, chain []*x509.Certificate) *SigningIdentity {
+	extensions := cosign.CertExtensions{Cert: leaf}
+	identity := &SigningIdentity{
+		Subject:                  signature.CertSubject(leaf),
+		Issuer:                   extensions.GetIssuer(),
+		GithubWorkflowTrigger:    extensions.GetCertExtensionGithubWorkflowTrigger(),
+		GithubWorkflowSha:        extensions.GetExtensionGithubWorkflowSha(),
+		GithubWorkflowName:       extensions.GetCertExtensionGithubWorkflowName(),
+		GithubWorkflowRepository: extensions.GetCertExtensionGithubWorkflowRepository(),
+		GithubWorkflowRef:        extensions.GetCertExtensionGithubWorkflowRef(),
+	}
+	for _, cert := range chain {
+		identity.Chain = append(identity.Chain, CertificateInfo{
+			Subject:      cert.Subject.String(),
+			Issuer:       cert.Issuer.String(),
+			SerialNumber: cert.SerialNumber.Text(16),
+			NotBefore:    cert.NotBefore.UTC(),
+			NotAfter:     cert.NotAfter.UTC(),
+			Fingerprint:  fmt.Sprintf("%x", sha256.Sum256(cert.Raw)),
+		})
+	}
+	return identity
+}
+
+func (s *SigningIdentity) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "subject: %s, issuer: %s", s.Subject, s.Issuer)
+	if s.GithubWorkflowRepository != "" {
+		fmt.Fprintf(&b, "\ngithub workflow: %s, repository: %s, ref: %s, sha: %s, trigger: %s",
+			s.GithubWorkflowName, s.GithubWorkflowRepository, s.GithubWorkflowRef, s.GithubWorkflowSha, s.GithubWorkflowTrigger)
+	}
+	for i, cert := range s.Chain {
+		fmt.Fprintf(&b, "\nchain[%d]: subject: %s, issuer: %s, serial: %s, valid: %s - %s, sha256: %s", i, cert.Subject,
+			cert.Issuer, cert.SerialNumber, cert.NotBefore.Format(time.RFC3339), cert.NotAfter.Format(time.RFC3339), cert.Fingerprint)
+	}
+	return b.String()
+}
+
+func WriteSigningIdentityRecord(path string, record SigningIdentityRecord) error {
+	content, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal signing identity: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write signing identity: %s: %w", path, err)
+	}
+	return nil
+}
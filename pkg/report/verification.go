@@ -0,0 +1,75 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	// OutputSARIF reports the functions that didn't pass verification as SARIF findings, i.e. for GitHub code scanning.
+	OutputSARIF = "sarif"
+)
+
+// VerificationResult is the machine-readable result of the verification of a function.
+type VerificationResult struct {
+	FunctionArn string `json:"functionArn"`
+	Region      string `json:"region,omitempty"`
+	Status      string `json:"status"`
+	Reason      string `json:"reason,omitempty"`
+	// Signer describes the untrusted key or identity that signed the function code.
+	Signer string `json:"signer,omitempty"`
+	// SignerIdentity is set for keylessly signed functions that passed verification.
+	SignerIdentity *SigningIdentity `json:"signerIdentity,omitempty"`
+	Digest         string           `json:"digest,omitempty"`
+	// Annotations are the annotations recorded at sign time, when the signature metadata holds them.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Timestamp   time.Time         `json:"timestamp"`
+}
+
+func ValidateOutput(output string) error {
+	if output != OutputTable && output != OutputJSON && output != OutputSARIF {
+		return fmt.Errorf("invalid output: %s, expected %s, %s or %s", output, OutputTable, OutputJSON, OutputSARIF)
+	}
+	return nil
+}
+
+// WriteVerificationResults writes the results as an indented JSON array.
+func WriteVerificationResults(w io.Writer, results []VerificationResult) error {
+	if results == nil {
+		results = []VerificationResult{}
+	}
+	return writeJSON(w, results)
+}
+
+// WriteVerificationResult writes the result as an indented JSON object.
+func WriteVerificationResult(w io.Writer, result VerificationResult) error {
+	return writeJSON(w, result)
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("failed to write verification results: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/integrity"
+	"github.com/openclarity/function-clarity/pkg/options"
+	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"golang.org/x/exp/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// BatchResult is the outcome of signing the code of one function of a batch.
+type BatchResult struct {
+	FunctionArn string
+	Err         error
+}
+
+// LoadFunctionArns reads the function arns to sign from a file, one per line. Empty lines and lines starting with #
+// are skipped.
+func LoadFunctionArns(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read function arns file: %s: %w", path, err)
+	}
+	defer f.Close()
+	var functionArns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		functionArns = append(functionArns, line)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read function arns file: %s: %w", path, err)
+	}
+	return functionArns, nil
+}
+
+// SignFunctions signs the code of the deployed zip functions, concurrency at a time. Each function is the baseline
+// of its own signature metadata. A failure doesn't stop the batch, the results are in the order of the arns. The
+// client of a function is created by newClient for the region of its arn.
+func SignFunctions(functionArns []string, concurrency int, newClient func(region string) clients.Client,
+	o *options.SignBlobOptions, ro *co.RootOptions) []BatchResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if o.TlogUpload {
+		// enabled for the whole batch, the concurrent signings would otherwise restore the mode under each other
+		defer integrity.EnableExperimental()()
+	}
+	locks := &identityLocks{locks: map[string]*sync.Mutex{}}
+	return runBatch(functionArns, concurrency, "sign", func(functionArn string) error {
+		return signFunction(functionArn, newClient, locks, o, ro)
+	})
+}
+
+// runBatch runs action on the functions, concurrency at a time, and prints the progress of the batch. The results are
+// in the order of the arns, a function failing with ErrNotSignedWithKey is reported as skipped.
+func runBatch(functionArns []string, concurrency int, verb string, action func(functionArn string) error) []BatchResult {
+	results := make([]BatchResult, len(functionArns))
+	indexes := make(chan int)
+	var progress sync.Mutex
+	done := 0
+	var wg sync.WaitGroup
+	for worker := 0; worker < concurrency; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				err := action(functionArns[index])
+				results[index] = BatchResult{FunctionArn: functionArns[index], Err: err}
+				progress.Lock()
+				done++
+				switch {
+				case errors.Is(err, ErrNotSignedWithKey):
+					slog.Info("skipped function", "function", functionArns[index], "done", done, "total", len(functionArns), "reason", err)
+				case err != nil:
+					slog.Error("failed to "+verb+" function", err, "function", functionArns[index], "done", done, "total", len(functionArns))
+				default:
+					slog.Info(verb+"ed function", "function", functionArns[index], "done", done, "total", len(functionArns))
+				}
+				progress.Unlock()
+			}
+		}()
+	}
+	for index := range functionArns {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+func signFunction(functionArn string, newClient func(region string) clients.Client, locks *identityLocks,
+	o *options.SignBlobOptions, ro *co.RootOptions) error {
+	client, codePath, codeIdentity, err := fetchFunctionCode(functionArn, newClient, o.DigestAlgorithm)
+	if err != nil {
+		return err
+	}
+	// functions deploying the same code share the signature files of its identity, they are signed one at a time
+	unlock := locks.lock(codeIdentity)
+	defer unlock()
+	functionOptions := *o
+	functionOptions.BaselineFunction = functionArn
+	return SignAndUploadCode(client, codePath, &functionOptions, ro)
+}
+
+// fetchFunctionCode downloads the code of the zip function with the client of its region, and computes its identity
+// with the digest algorithm.
+func fetchFunctionCode(functionArn string, newClient func(region string) clients.Client, digestAlgorithm string) (clients.Client, string, string, error) {
+	hash, err := integrity.NewIdentityGenerator(digestAlgorithm)
+	if err != nil {
+		return nil, "", "", err
+	}
+	parsed, err := arn.Parse(functionArn)
+	if err != nil || parsed.Service != "lambda" || !strings.HasPrefix(parsed.Resource, "function:") {
+		return nil, "", "", fmt.Errorf("invalid function arn: %s, expected arn:aws:lambda:<region>:<account>:function:<name>", functionArn)
+	}
+	client := newClient(parsed.Region)
+	packageType, err := client.ResolvePackageType(functionArn)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to resolve package type: %w", err)
+	}
+	if packageType != "Zip" {
+		return nil, "", "", fmt.Errorf("%s function, sign its image with: sign aws image --function", packageType)
+	}
+	codePath, err := client.GetFuncCode(functionArn)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to fetch function code: %w", err)
+	}
+	codeIdentity, err := hash.GenerateIdentity(codePath)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to create identity: %w", err)
+	}
+	return client, codePath, codeIdentity, nil
+}
+
+// identityLocks serializes the signings of a code identity.
+type identityLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func (l *identityLocks) lock(identity string) func() {
+	l.mu.Lock()
+	lock, ok := l.locks[identity]
+	if !ok {
+		lock = &sync.Mutex{}
+		l.locks[identity] = lock
+	}
+	l.mu.Unlock()
+	lock.Lock()
+	return lock.Unlock
+}
@@ -0,0 +1,87 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"fmt"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/options"
+	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type batchClient struct {
+	clients.Client
+}
+
+func (c *batchClient) ResolvePackageType(funcIdentifier string) (string, error) {
+	if strings.HasSuffix(funcIdentifier, ":missing") {
+		return "", fmt.Errorf("function not found")
+	}
+	return "Image", nil
+}
+
+func TestSignFunctionsReportsEveryFailure(t *testing.T) {
+	var mu sync.Mutex
+	var regions []string
+	newClient := func(region string) clients.Client {
+		mu.Lock()
+		regions = append(regions, region)
+		mu.Unlock()
+		return &batchClient{}
+	}
+	functionArns := []string{
+		"arn:aws:lambda:us-east-1:123456789012:function:image",
+		"not-an-arn",
+		"arn:aws:lambda:eu-west-1:123456789012:function:missing",
+	}
+	results := SignFunctions(functionArns, 2, newClient, &options.SignBlobOptions{}, &co.RootOptions{})
+	if len(results) != len(functionArns) {
+		t.Fatalf("expected a result per function, got: %v", results)
+	}
+	for index, expected := range []string{"sign its image", "invalid function arn", "function not found"} {
+		if results[index].FunctionArn != functionArns[index] {
+			t.Fatalf("expected result %d for function: %s, got: %s", index, functionArns[index], results[index].FunctionArn)
+		}
+		if results[index].Err == nil || !strings.Contains(results[index].Err.Error(), expected) {
+			t.Fatalf("expected error: %s for function: %s, got: %v", expected, functionArns[index], results[index].Err)
+		}
+	}
+	if len(regions) != 2 {
+		t.Fatalf("expected a client for the region of each valid arn, got: %v", regions)
+	}
+}
+
+func TestLoadFunctionArns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "functions.txt")
+	content := "# payments\narn:aws:lambda:us-east-1:123456789012:function:a\n\n  arn:aws:lambda:us-east-1:123456789012:function:b  \n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	functionArns, err := LoadFunctionArns(path)
+	if err != nil {
+		t.Fatalf("failed to load function arns: %v", err)
+	}
+	expected := []string{"arn:aws:lambda:us-east-1:123456789012:function:a", "arn:aws:lambda:us-east-1:123456789012:function:b"}
+	if !reflect.DeepEqual(functionArns, expected) {
+		t.Fatalf("expected: %v, got: %v", expected, functionArns)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"context"
+	"fmt"
+	"github.com/openclarity/function-clarity/cmd/function-clarity/cli/verify"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/integrity"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"github.com/sigstore/cosign/pkg/blob"
+	"golang.org/x/exp/slog"
+	"gopkg.in/yaml.v3"
+)
+
+// ImportEntry maps code to a cosign signature (and certificate in keyless mode) of its function clarity identity, made
+// e.g. for the signature store of another deployment. A signature of the code itself, as 'cosign sign-blob' makes over
+// a file, isn't one of its identity.
+type ImportEntry struct {
+	Code        string `yaml:"code"`
+	Signature   string `yaml:"signature"`
+	Certificate string `yaml:"certificate"`
+}
+
+func LoadImportEntries(path string) ([]ImportEntry, error) {
+	content, err := integrity.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import file: %s: %w", path, err)
+	}
+	var entries []ImportEntry
+	if err = yaml.Unmarshal(content, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse import file: %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// ImportCodeSignature registers an existing signature for the code in entry.Code. The signature is stored under the
+// code identity, the same key signatures created by function clarity use, only after it was verified against it. The
+// signature and certificate are read from files or http(s) urls.
+func ImportCodeSignature(client clients.Client, entry ImportEntry, o *options.VerifyOpts, ctx context.Context) error {
+	client = clients.WithContext(client, ctx)
+	if entry.Code == "" || entry.Signature == "" {
+		return fmt.Errorf("both code and signature are required to import a signature")
+	}
+	hash := new(integrity.Sha256)
+	codeIdentity, err := hash.GenerateIdentity(entry.Code)
+	if err != nil {
+		return fmt.Errorf("failed to create identity: %w", err)
+	}
+	isKeyless := false
+	if !o.SecurityKey.Use && o.Key == "" {
+		if isKeyless, err = integrity.IsExperimentalEnv(); err != nil {
+			return err
+		}
+	}
+
+	signature, err := blob.LoadFileOrURL(entry.Signature)
+	if err != nil {
+		return fmt.Errorf("failed to load signature: %s: %w", entry.Signature, err)
+	}
+	if err = integrity.SaveTextToFile(string(signature), "/tmp/"+codeIdentity+".sig"); err != nil {
+		return fmt.Errorf("failed to save signature: %w", err)
+	}
+	if isKeyless {
+		if entry.Certificate == "" {
+			return fmt.Errorf("a certificate is required to import a keyless signature")
+		}
+		certificate, err := blob.LoadFileOrURL(entry.Certificate)
+		if err != nil {
+			return fmt.Errorf("failed to load certificate: %s: %w", entry.Certificate, err)
+		}
+		if err = integrity.SaveTextToFile(string(certificate), "/tmp/"+codeIdentity+".crt.base64"); err != nil {
+			return fmt.Errorf("failed to save certificate: %w", err)
+		}
+	}
+
+	if err = verify.VerifyIdentity(codeIdentity, o, ctx, isKeyless); err != nil {
+		return fmt.Errorf("signature: %s isn't valid for the identity: %s of code: %s, only signatures of the function clarity identity can be imported: %w",
+			entry.Signature, codeIdentity, entry.Code, err)
+	}
+	if err = uploadSignature(client, string(signature), codeIdentity, isKeyless); err != nil {
+		return fmt.Errorf("failed to upload imported signature for identity: %s: %w", codeIdentity, err)
+	}
+	slog.Info("signature imported", "code", entry.Code, "identity", codeIdentity)
+	return nil
+}
@@ -0,0 +1,97 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"context"
+	b64 "encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/integrity"
+	"github.com/openclarity/function-clarity/pkg/metadata"
+	"github.com/openclarity/function-clarity/pkg/options"
+	sigs "github.com/sigstore/cosign/pkg/signature"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"golang.org/x/exp/slog"
+	"strings"
+	"time"
+)
+
+// ParameterRecord is the value of the ssm parameter recording the signature of a function code.
+type ParameterRecord struct {
+	FunctionArn string              `json:"functionArn"`
+	Digest      string              `json:"digest"`
+	Signature   string              `json:"signature"`
+	Certificate string              `json:"certificate,omitempty"`
+	Signer      *metadata.SignerPin `json:"signer,omitempty"`
+	SignedAt    time.Time           `json:"signedAt"`
+}
+
+// recordSignature writes the parameter of the function once its code was signed and uploaded.
+func recordSignature(client clients.Client, store *options.ParameterStore, functionArn string, record ParameterRecord) error {
+	name, err := store.ParameterName(functionArn)
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to serialize ssm parameter: %s: %w", name, err)
+	}
+	tier, err := store.Tier(len(value))
+	if err != nil {
+		return fmt.Errorf("failed to record signature in ssm parameter: %s: %w", name, err)
+	}
+	// arn:partition:lambda:region:account:function:name
+	region := strings.Split(functionArn, ":")[3]
+	if err = client.PutParameter(region, name, string(value), tier); err != nil {
+		return err
+	}
+	slog.Info("signature recorded in ssm parameter", "parameter", name)
+	return nil
+}
+
+// codeSigner returns the signer of the code: the certificate subject and issuer in keyless mode, or the fingerprint
+// of the configured public key. It is nil when neither is available, the private key isn't loaded again since it
+// may prompt for its password.
+func codeSigner(codeIdentity string, publicKey string, isKeyless bool) (*metadata.SignerPin, error) {
+	if isKeyless {
+		content, err := integrity.ReadFile("/tmp/" + codeIdentity + ".crt.base64")
+		if err != nil {
+			return nil, err
+		}
+		if decoded, err := b64.StdEncoding.DecodeString(strings.TrimSpace(string(content))); err == nil {
+			content = decoded
+		}
+		certs, err := cryptoutils.UnmarshalCertificatesFromPEM(content)
+		if err != nil || len(certs) == 0 {
+			return nil, fmt.Errorf("failed to parse signing certificate: %v", err)
+		}
+		return metadata.PinForCertificate(certs[0])
+	}
+	if publicKey == "" {
+		return nil, nil
+	}
+	verifier, err := sigs.PublicKeyFromKeyRef(context.Background(), publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load public key: %w", err)
+	}
+	pub, err := verifier.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	return metadata.PinForPublicKey(pub)
+}
@@ -0,0 +1,244 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/openclarity/function-clarity/cmd/function-clarity/cli/sign"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/integrity"
+	"github.com/openclarity/function-clarity/pkg/metadata"
+	"github.com/openclarity/function-clarity/pkg/options"
+	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"golang.org/x/exp/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrNotSignedWithKey is returned for a function without a signature made with the key being rotated, it is skipped.
+var ErrNotSignedWithKey = errors.New("not signed with the old key")
+
+// RotateKey re-signs the code of the deployed zip functions signed with the private key of oldPublicKey, with the
+// private key of the signing options whose public key is newPublicKey, concurrency at a time. The functions signed
+// with another key, or not signed, fail with ErrNotSignedWithKey. The new signature of a function, and of its
+// signature metadata, is verified with newPublicKey before it replaces the old one, and once more after the upload,
+// the old signature is put back when that fails, so that no function is left without a valid signature.
+func RotateKey(functionArns []string, concurrency int, newClient func(region string) clients.Client, oldPublicKey string,
+	newPublicKey string, o *options.SignBlobOptions, ro *co.RootOptions) ([]BatchResult, error) {
+	oldVerifier, err := loadVerifier(oldPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	newVerifier, err := loadVerifier(newPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if o.TlogUpload {
+		defer integrity.EnableExperimental()()
+	}
+	locks := &identityLocks{locks: map[string]*sync.Mutex{}}
+	return runBatch(functionArns, concurrency, "re-sign", func(functionArn string) error {
+		return rotateFunction(functionArn, newClient, locks, oldVerifier, newVerifier, o, ro)
+	}), nil
+}
+
+func rotateFunction(functionArn string, newClient func(region string) clients.Client, locks *identityLocks,
+	oldVerifier signature.Verifier, newVerifier signature.Verifier, o *options.SignBlobOptions, ro *co.RootOptions) error {
+	client, _, codeIdentity, err := fetchFunctionCode(functionArn, newClient, o.DigestAlgorithm)
+	if err != nil {
+		return err
+	}
+	if err = rotateCode(client, locks, codeIdentity, metadata.FileNames(functionArn, codeIdentity), oldVerifier, newVerifier, o, ro); err != nil {
+		return err
+	}
+	if err = rotateLayers(client, functionArn, locks, oldVerifier, newVerifier, o, ro); err != nil {
+		return fmt.Errorf("code re-signed, %w", err)
+	}
+	return nil
+}
+
+// rotateCode re-signs the code identity, then the signature metadata stored under metadataNames. A signature already
+// made with the new key, e.g. by an interrupted rotation run again, is left as is.
+func rotateCode(client clients.SignatureStore, locks *identityLocks, codeIdentity string, metadataNames []string,
+	oldVerifier signature.Verifier, newVerifier signature.Verifier, o *options.SignBlobOptions, ro *co.RootOptions) error {
+	unlock := locks.lock(codeIdentity)
+	defer unlock()
+	oldSignature, err := downloadKeySignature(client, codeIdentity)
+	if err != nil {
+		return err
+	}
+	if verifyKeySignature(newVerifier, oldSignature, codeIdentity) != nil {
+		if err = verifyKeySignature(oldVerifier, oldSignature, codeIdentity); err != nil {
+			return fmt.Errorf("%w: code identity: %s", ErrNotSignedWithKey, codeIdentity)
+		}
+		if err = rotateSignature(client, codeIdentity, oldSignature, newVerifier, o, ro); err != nil {
+			return err
+		}
+	}
+	for _, metadataName := range metadataNames {
+		if err = rotateMetadata(client, metadataName, oldVerifier, newVerifier, o, ro); err != nil {
+			return fmt.Errorf("code re-signed, %w", err)
+		}
+	}
+	return nil
+}
+
+// rotateLayers re-signs the layers attached to the function, the verifier checks them with the key functions are
+// verified with. Layers signed with another key, or not signed, are left as is.
+func rotateLayers(client clients.Client, functionArn string, locks *identityLocks, oldVerifier signature.Verifier,
+	newVerifier signature.Verifier, o *options.SignBlobOptions, ro *co.RootOptions) error {
+	hash, err := integrity.NewIdentityGenerator(o.DigestAlgorithm)
+	if err != nil {
+		return err
+	}
+	layersConfig, err := client.GetFuncLayers(functionArn)
+	if err != nil {
+		return fmt.Errorf("failed to get layers of function: %w", err)
+	}
+	for _, layerArn := range layersConfig.Arns {
+		layerPath, err := client.GetLayerCode(layerArn)
+		if err != nil {
+			return fmt.Errorf("failed to fetch content of layer: %s: %w", layerArn, err)
+		}
+		layerIdentity, err := hash.GenerateIdentity(layerPath)
+		if err != nil {
+			return fmt.Errorf("failed to generate identity of layer: %s: %w", layerArn, err)
+		}
+		// layers are signed without a baseline function, their metadata is named after their identity only
+		err = rotateCode(client, locks, layerIdentity, []string{layerIdentity}, oldVerifier, newVerifier, o, ro)
+		if errors.Is(err, ErrNotSignedWithKey) {
+			slog.Info("layer not signed with the old key, skipping", "layer", layerArn)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("layer: %s: %w", layerArn, err)
+		}
+	}
+	return nil
+}
+
+// rotateMetadata re-signs the signature metadata stored under the name, when there is one.
+func rotateMetadata(client clients.SignatureStore, metadataName string, oldVerifier signature.Verifier, newVerifier signature.Verifier,
+	o *options.SignBlobOptions, ro *co.RootOptions) error {
+	if err := client.Download(metadataName, metadata.FileType); err != nil {
+		if clients.IsObjectNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get signature metadata: %s: %w", metadataName, err)
+	}
+	content, err := os.ReadFile("/tmp/" + metadataName + "." + metadata.FileType)
+	if err != nil {
+		return fmt.Errorf("failed to read signature metadata: %s: %w", metadataName, err)
+	}
+	metadataIdentity := metadata.Identity(content)
+	oldMetadataSignature, err := downloadKeySignature(client, metadataIdentity)
+	if err != nil {
+		return fmt.Errorf("signature metadata: %w", err)
+	}
+	if verifyKeySignature(newVerifier, oldMetadataSignature, metadataIdentity) == nil {
+		return nil
+	}
+	if err = verifyKeySignature(oldVerifier, oldMetadataSignature, metadataIdentity); err != nil {
+		return fmt.Errorf("signature metadata: %s isn't signed with the old key", metadataName)
+	}
+	if err = rotateSignature(client, metadataIdentity, oldMetadataSignature, newVerifier, o, ro); err != nil {
+		return fmt.Errorf("signature metadata: %w", err)
+	}
+	return nil
+}
+
+// rotateSignature replaces the signature of the identity with one made with the new key, once it verifies with the new
+// public key. The old signature is put back when the uploaded one doesn't verify.
+func rotateSignature(client clients.SignatureStore, identity string, oldSignature string, newVerifier signature.Verifier,
+	o *options.SignBlobOptions, ro *co.RootOptions) error {
+	newSignature, err := sign.SignIdentity(identity, o, ro, false)
+	if err != nil {
+		return fmt.Errorf("failed to sign identity: %s with the new key: %w", identity, err)
+	}
+	if err = verifyKeySignature(newVerifier, newSignature, identity); err != nil {
+		return fmt.Errorf("signature of identity: %s made with the new key doesn't verify with the new public key, the old one is kept: %w", identity, err)
+	}
+	err = uploadSignature(client, newSignature, identity, false)
+	if err == nil {
+		var uploaded string
+		if uploaded, err = downloadKeySignature(client, identity); err == nil {
+			err = verifyKeySignature(newVerifier, uploaded, identity)
+		}
+	}
+	if err == nil {
+		return nil
+	}
+	if restoreErr := restoreKeySignature(client, oldSignature, identity); restoreErr != nil {
+		return fmt.Errorf("failed to upload the new signature of identity: %s: %v, and to restore the old one: %w", identity, err, restoreErr)
+	}
+	return fmt.Errorf("failed to upload the new signature of identity: %s, the old one is restored: %w", identity, err)
+}
+
+func downloadKeySignature(client clients.SignatureStore, identity string) (string, error) {
+	if err := client.Download(identity, "sig"); err != nil {
+		if clients.IsObjectNotFound(err) {
+			return "", fmt.Errorf("%w: no signature of identity: %s", ErrNotSignedWithKey, identity)
+		}
+		return "", fmt.Errorf("failed to get signature of identity: %s: %w", identity, err)
+	}
+	content, err := os.ReadFile("/tmp/" + identity + ".sig")
+	if err != nil {
+		return "", fmt.Errorf("failed to read signature of identity: %s: %w", identity, err)
+	}
+	return string(content), nil
+}
+
+func restoreKeySignature(client clients.SignatureStore, signature string, identity string) error {
+	if err := client.Upload(signature, identity, false); err != nil {
+		return err
+	}
+	return client.UploadFile(signature, identity, integrity.KeySignatureType)
+}
+
+// verifyKeySignature verifies the signature of the identity, base64 encoded like cosign outputs it by default.
+func verifyKeySignature(verifier signature.Verifier, sig string, identity string) error {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(sig))
+	if err != nil {
+		decoded = []byte(sig)
+	}
+	return verifier.VerifySignature(bytes.NewReader(decoded), strings.NewReader(identity))
+}
+
+func loadVerifier(publicKeyPath string) (signature.Verifier, error) {
+	content, err := os.ReadFile(publicKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %s: %w", publicKeyPath, err)
+	}
+	publicKey, err := cryptoutils.UnmarshalPEMToPublicKey(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %s: %w", publicKeyPath, err)
+	}
+	verifier, err := signature.LoadVerifier(publicKey, crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %s: %w", publicKeyPath, err)
+	}
+	return verifier, nil
+}
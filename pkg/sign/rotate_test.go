@@ -0,0 +1,188 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"errors"
+	"github.com/openclarity/function-clarity/cmd/function-clarity/cli/sign"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/integrity"
+	"github.com/openclarity/function-clarity/pkg/metadata"
+	"github.com/openclarity/function-clarity/pkg/options"
+	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/sigstore/cosign/pkg/cosign"
+	"github.com/spf13/viper"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// rotationClient serves the code of the functions and of their layers, and keeps the signature files in memory.
+type rotationClient struct {
+	clients.Client
+	mu        sync.Mutex
+	code      map[string]string
+	layerArns map[string][]string
+	files     map[string]string
+}
+
+func (c *rotationClient) ResolvePackageType(string) (string, error) {
+	return "Zip", nil
+}
+
+func (c *rotationClient) GetFuncCode(funcIdentifier string) (string, error) {
+	return c.code[funcIdentifier], nil
+}
+
+func (c *rotationClient) GetFuncLayers(funcIdentifier string) (*metadata.LayersConfig, error) {
+	return &metadata.LayersConfig{Arns: c.layerArns[funcIdentifier]}, nil
+}
+
+func (c *rotationClient) GetLayerCode(layerArn string) (string, error) {
+	return c.code[layerArn], nil
+}
+
+func (c *rotationClient) Upload(signature string, identity string, _ bool) error {
+	return c.UploadFile(signature, identity, "sig")
+}
+
+func (c *rotationClient) UploadFile(content string, fileName string, outputType string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.files[fileName+"."+outputType] = content
+	return nil
+}
+
+func (c *rotationClient) Download(fileName string, outputType string) error {
+	c.mu.Lock()
+	content, ok := c.files[fileName+"."+outputType]
+	c.mu.Unlock()
+	if !ok {
+		return clients.ErrBlobNotFound
+	}
+	return os.WriteFile("/tmp/"+fileName+"."+outputType, []byte(content), 0600)
+}
+
+func writeKeyPair(t *testing.T, dir string, name string) (string, string) {
+	keys, err := cosign.GenerateKeyPair(func(bool) ([]byte, error) { return []byte{}, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey, privateKey := filepath.Join(dir, name+".pub"), filepath.Join(dir, name+".key")
+	if err = os.WriteFile(publicKey, keys.PublicBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(privateKey, keys.PrivateBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return publicKey, privateKey
+}
+
+func TestRotateKey(t *testing.T) {
+	t.Setenv("COSIGN_PASSWORD", "")
+	dir := t.TempDir()
+	oldPublicKey, oldPrivateKey := writeKeyPair(t, dir, "old")
+	newPublicKey, newPrivateKey := writeKeyPair(t, dir, "new")
+	_, otherPrivateKey := writeKeyPair(t, dir, "other")
+	defer viper.Set("privatekey", "")
+
+	client := &rotationClient{code: map[string]string{}, layerArns: map[string][]string{}, files: map[string]string{}}
+	o := &options.SignBlobOptions{}
+	o.Base64Output = true
+	o.SkipConfirmation = true
+	ro := &co.RootOptions{}
+	signIdentity := func(identity string, privateKey string) {
+		viper.Set("privatekey", privateKey)
+		signed, err := sign.SignIdentity(identity, o, ro, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = client.Upload(signed, identity, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeCode := func(name string) (string, string) {
+		codePath := filepath.Join(dir, name+".zip")
+		if err := os.WriteFile(codePath, []byte(name+" code"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		identity, err := new(integrity.Sha256).GenerateIdentity(codePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return codePath, identity
+	}
+	signWith := func(name string, privateKey string) string {
+		codePath, identity := writeCode(name)
+		functionArn := "arn:aws:lambda:us-east-1:123456789012:function:" + name
+		client.code[functionArn] = codePath
+		if privateKey != "" {
+			signIdentity(identity, privateKey)
+		}
+		return functionArn
+	}
+	functionArns := []string{signWith("rotated", oldPrivateKey), signWith("unsigned", ""), signWith("other", otherPrivateKey)}
+	layerPath, layerIdentity := writeCode("layer")
+	layerArn := "arn:aws:lambda:us-east-1:123456789012:layer:shared:1"
+	client.code[layerArn] = layerPath
+	client.layerArns[functionArns[0]] = []string{layerArn}
+	signIdentity(layerIdentity, oldPrivateKey)
+	_, rotatedIdentity := writeCode("rotated")
+	metadataContent := `{"annotations":{"team":"payments"}}`
+	metadataIdentity := metadata.Identity([]byte(metadataContent))
+	client.files[metadata.FunctionFileName(functionArns[0], rotatedIdentity)+"."+metadata.FileType] = metadataContent
+	signIdentity(metadataIdentity, oldPrivateKey)
+	oldMetadataSignature := client.files[metadataIdentity+".sig"]
+
+	viper.Set("privatekey", newPrivateKey)
+	newClient := func(string) clients.Client { return client }
+	results, err := RotateKey(functionArns, 2, newClient, oldPublicKey, newPublicKey, o, ro)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results[0].Err != nil {
+		t.Fatalf("expected the function signed with the old key to be re-signed, got: %v", results[0].Err)
+	}
+	for _, result := range results[1:] {
+		if !errors.Is(result.Err, ErrNotSignedWithKey) {
+			t.Fatalf("expected function: %s to be skipped, got: %v", result.FunctionArn, result.Err)
+		}
+	}
+	newVerifier, err := loadVerifier(newPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, identity := range []string{rotatedIdentity, layerIdentity, metadataIdentity} {
+		if err = verifyKeySignature(newVerifier, client.files[identity+".sig"], identity); err != nil {
+			t.Fatalf("expected the stored signature of identity: %s to verify with the new key, got: %v", identity, err)
+		}
+	}
+
+	// a rotation interrupted after the code was re-signed resumes with the signature metadata
+	client.files[metadataIdentity+".sig"] = oldMetadataSignature
+	if results, err = RotateKey(functionArns[:1], 1, newClient, oldPublicKey, newPublicKey, o, ro); err != nil || results[0].Err != nil {
+		t.Fatalf("expected the rotation to resume, got: %v, %+v", err, results)
+	}
+	if err = verifyKeySignature(newVerifier, client.files[metadataIdentity+".sig"], metadataIdentity); err != nil {
+		t.Fatalf("expected the resumed rotation to re-sign the signature metadata, got: %v", err)
+	}
+
+	if _, err = RotateKey(functionArns, 1, newClient, filepath.Join(dir, "missing.pub"), newPublicKey, o, ro); err == nil || !strings.Contains(err.Error(), "missing.pub") {
+		t.Fatalf("expected an error for a missing old public key, got: %v", err)
+	}
+}
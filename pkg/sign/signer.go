@@ -16,34 +16,247 @@
 package sign
 
 import (
+	"errors"
 	"fmt"
 	"github.com/openclarity/function-clarity/cmd/function-clarity/cli/sign"
+	"github.com/openclarity/function-clarity/pkg/ci"
 	"github.com/openclarity/function-clarity/pkg/clients"
 	"github.com/openclarity/function-clarity/pkg/integrity"
+	"github.com/openclarity/function-clarity/pkg/metadata"
 	"github.com/openclarity/function-clarity/pkg/options"
 	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
 	"github.com/spf13/viper"
+	"golang.org/x/exp/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
 func SignAndUploadCode(client clients.Client, codePath string, o *options.SignBlobOptions, ro *co.RootOptions) error {
-	hash := new(integrity.Sha256)
-	codeIdentity, err := hash.GenerateIdentity(codePath)
+	hash, err := integrity.NewIdentityGenerator(o.DigestAlgorithm)
+	if err != nil {
+		return err
+	}
+	codeIdentity, err := generateCodeIdentity(hash, codePath, o.NormalizeZip)
 	if err != nil {
 		return fmt.Errorf("failed to create identity: %w", err)
 	}
+	signatureMetadata, err := collectMetadata(client, o)
+	if err != nil {
+		return fmt.Errorf("failed to collect signature metadata: %w", err)
+	}
+	metadataName, err := metadataFileName(client, codeIdentity, o)
+	if err != nil {
+		return err
+	}
+	previousMetadata, err := downloadPreviousMetadata(client, metadataName, codeIdentity)
+	if err != nil {
+		return err
+	}
+	if previousMetadata != nil && previousMetadata.Concurrency != nil && signatureMetadata.Concurrency == nil {
+		slog.Warn("code re-signed without --record-concurrency, the concurrency baseline recorded for the function is dropped",
+			"function", o.BaselineFunction, "baseline", previousMetadata.Concurrency.String())
+	}
+	if previousMetadata != nil && previousMetadata.Layers != nil && signatureMetadata.Layers == nil {
+		slog.Warn("code re-signed without --record-layers, the layers baseline recorded for the function is dropped",
+			"function", o.BaselineFunction, "baseline", previousMetadata.Layers.String())
+	}
 	isKeyless := false
 	privateKey := viper.GetString("privatekey")
-	if !o.SecurityKey.Use && privateKey == "" && integrity.IsExperimentalEnv() {
-		isKeyless = true
+	if !o.SecurityKey.Use && privateKey == "" {
+		if isKeyless, err = integrity.IsExperimentalEnv(); err != nil {
+			return err
+		}
 	}
 
 	signedIdentity, err := sign.SignIdentity(codeIdentity, o, ro, isKeyless)
 	if err != nil {
 		return fmt.Errorf("failed to sign identity: %s with private key in path: %s: %w", codeIdentity, privateKey, err)
 	}
-	if err = client.Upload(signedIdentity, codeIdentity, isKeyless); err != nil {
+	if err = uploadSignature(client, signedIdentity, codeIdentity, isKeyless); err != nil {
 		return fmt.Errorf("failed to upload code signature: identity: %s, signature: %s to bucket: %s: %w", codeIdentity, signedIdentity, viper.GetString("bucket"), err)
 	}
-	fmt.Println("Code uploaded successfully")
+	// the metadata recorded when the code was last signed for the function is replaced even by empty metadata, so that
+	// no baseline the latest signing didn't record is verified
+	if err = signAndUploadMetadata(client, metadataName, signatureMetadata, previousMetadata != nil, o, ro, isKeyless); err != nil {
+		return err
+	}
+	if o.ParameterStore.Enabled() {
+		if err = recordCodeSignature(client, codeIdentity, o, isKeyless); err != nil {
+			return fmt.Errorf("code signature uploaded but not recorded in parameter store: %w", err)
+		}
+	}
+	slog.Info("code uploaded successfully", "identity", codeIdentity)
+	return nil
+}
+
+// generateCodeIdentity returns the identity of the code at codePath. With normalizeZip, a zip archive has the identity
+// of the files it holds, the one its deployed function is verified with, so a rebuild zipping the same files verifies.
+func generateCodeIdentity(hash integrity.IdentityGenerator, codePath string, normalizeZip bool) (string, error) {
+	if !normalizeZip || !strings.EqualFold(filepath.Ext(codePath), ".zip") {
+		return hash.GenerateIdentity(codePath)
+	}
+	zipData, err := os.ReadFile(codePath)
+	if err != nil {
+		return "", err
+	}
+	return hash.GenerateZipIdentity(zipData)
+}
+
+// SignLayer signs the content of the layer version like function code, the functions it is attached to then verify it
+// with --require-signed-layers.
+func SignLayer(client clients.Client, layerArn string, o *options.SignBlobOptions, ro *co.RootOptions) error {
+	layerPath, err := client.GetLayerCode(layerArn)
+	if err != nil {
+		return fmt.Errorf("failed to fetch content of layer: %s: %w", layerArn, err)
+	}
+	return SignAndUploadCode(client, layerPath, o, ro)
+}
+
+func recordCodeSignature(client clients.Client, codeIdentity string, o *options.SignBlobOptions, isKeyless bool) error {
+	functionArn := o.ParameterStore.Function
+	if functionArn == "" {
+		functionArn = o.BaselineFunction
+	}
+	if functionArn == "" {
+		return fmt.Errorf("recording the signature in parameter store requires --ssm-function or a baseline function")
+	}
+	signer, err := codeSigner(codeIdentity, viper.GetString("publickey"), isKeyless)
+	if err != nil {
+		return fmt.Errorf("failed to resolve the signer: %w", err)
+	}
+	bucket := viper.GetString("bucket")
+	record := ParameterRecord{
+		FunctionArn: functionArn,
+		Digest:      codeIdentity,
+		Signature:   fmt.Sprintf("s3://%s/%s.sig", bucket, codeIdentity),
+		Signer:      signer,
+		SignedAt:    time.Now().UTC(),
+	}
+	if isKeyless {
+		record.Certificate = fmt.Sprintf("s3://%s/%s.crt.base64", bucket, codeIdentity)
+	}
+	return recordSignature(client, &o.ParameterStore, functionArn, record)
+}
+
+// metadataFileName names the signature metadata after the arn of the baseline function and the code identity, so code
+// shared by several functions keeps the baseline of each of them. Without a baseline function it is named after the
+// code identity only.
+func metadataFileName(client clients.Client, codeIdentity string, o *options.SignBlobOptions) (string, error) {
+	if o.BaselineFunction == "" {
+		return codeIdentity, nil
+	}
+	details := clients.Notification{}
+	if err := client.FillNotificationDetails(&details, o.BaselineFunction); err != nil {
+		return "", fmt.Errorf("failed to resolve arn of baseline function: %s: %w", o.BaselineFunction, err)
+	}
+	return metadata.FunctionFileName(details.FunctionIdentifier, codeIdentity), nil
+}
+
+// downloadPreviousMetadata returns the signature metadata the verifier reads for the code signed under metadataName,
+// nil when there is none. The verifier falls back to the metadata of the code identity when the function has none of
+// its own, so that one is returned too.
+func downloadPreviousMetadata(client clients.SignatureStore, metadataName string, codeIdentity string) (*metadata.SignatureMetadata, error) {
+	names := []string{metadataName}
+	if metadataName != codeIdentity {
+		names = append(names, codeIdentity)
+	}
+	for _, name := range names {
+		if err := client.Download(name, metadata.FileType); err != nil {
+			if clients.IsObjectNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get previous signature metadata: %s: %w", name, err)
+		}
+		content, err := os.ReadFile("/tmp/" + name + "." + metadata.FileType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read previous signature metadata: %s: %w", name, err)
+		}
+		return metadata.Unmarshal(content)
+	}
+	return nil, nil
+}
+
+func collectMetadata(client clients.Client, o *options.SignBlobOptions) (*metadata.SignatureMetadata, error) {
+	signatureMetadata := &metadata.SignatureMetadata{}
+	if o.RecordConcurrency {
+		if o.BaselineFunction == "" {
+			return nil, fmt.Errorf("recording concurrency requires a baseline function")
+		}
+		concurrencyConfig, err := client.GetFuncConcurrency(o.BaselineFunction)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get concurrency of function: %s: %w", o.BaselineFunction, err)
+		}
+		signatureMetadata.Concurrency = concurrencyConfig
+	}
+	if o.RecordLayers {
+		if o.BaselineFunction == "" {
+			return nil, fmt.Errorf("recording layers requires a baseline function")
+		}
+		layersConfig, err := client.GetFuncLayers(o.BaselineFunction)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get layers of function: %s: %w", o.BaselineFunction, err)
+		}
+		signatureMetadata.Layers = layersConfig
+	}
+	annotations, err := ci.ParseAnnotations(append(append([]string{}, o.Annotations...), o.Annotation...))
+	if err != nil {
+		return nil, err
+	}
+	if !o.NoCIAnnotations {
+		annotations = ci.Merge(annotations, ci.Annotations())
+	}
+	if len(annotations) > 0 {
+		signatureMetadata.Annotations = annotations
+	}
+	if algorithm := strings.ToLower(o.DigestAlgorithm); algorithm != "" && algorithm != integrity.DefaultDigestAlgorithm {
+		// the verifier looks up the signature of each algorithm, the signed metadata records which one was used
+		signatureMetadata.DigestAlgorithm = algorithm
+	}
+	return signatureMetadata, nil
+}
+
+// signAndUploadMetadata signs the metadata content identity the same way code identities are signed,
+// and stores the metadata under metadataName next to the code signature. Empty metadata is only stored to replace
+// existing metadata.
+func signAndUploadMetadata(client clients.SignatureStore, metadataName string, signatureMetadata *metadata.SignatureMetadata,
+	replace bool, o *options.SignBlobOptions, ro *co.RootOptions, isKeyless bool) error {
+	if signatureMetadata.IsEmpty() && !replace {
+		return nil
+	}
+	content, err := signatureMetadata.Marshal()
+	if err != nil {
+		return fmt.Errorf("failed to serialize signature metadata: %w", err)
+	}
+	metadataIdentity := metadata.Identity(content)
+	signedMetadata, err := sign.SignIdentity(metadataIdentity, o, ro, isKeyless)
+	if err != nil {
+		return fmt.Errorf("failed to sign signature metadata: %w", err)
+	}
+	if err = uploadSignature(client, signedMetadata, metadataIdentity, isKeyless); err != nil {
+		return fmt.Errorf("failed to upload signature metadata signature: %w", err)
+	}
+	if err = client.UploadFile(string(content), metadataName, metadata.FileType); err != nil {
+		return fmt.Errorf("failed to upload signature metadata: %w", err)
+	}
 	return nil
 }
+
+func uploadSignature(client clients.SignatureStore, signature string, identity string, isKeyless bool) error {
+	if err := client.Upload(signature, identity, isKeyless); err != nil {
+		return err
+	}
+	if err := client.UploadFile(signature, identity, integrity.SignatureTypeFor(isKeyless)); err != nil {
+		return err
+	}
+	// a bundle is written for keyless signatures and signatures uploaded to the transparency log
+	bundle, err := os.ReadFile("/tmp/" + identity + "." + integrity.RekorBundleType)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read rekor bundle of identity: %s: %w", identity, err)
+	}
+	return client.UploadFile(string(bundle), identity, integrity.RekorBundleType)
+}
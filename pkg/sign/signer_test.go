@@ -0,0 +1,135 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sign
+
+import (
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/integrity"
+	"github.com/openclarity/function-clarity/pkg/metadata"
+	"github.com/openclarity/function-clarity/pkg/options"
+	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"github.com/spf13/viper"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// baselineClient serves the concurrency of the baseline functions and keeps the signature files in memory.
+type baselineClient struct {
+	*rotationClient
+	concurrency map[string]*metadata.ConcurrencyConfig
+	layers      map[string]*metadata.LayersConfig
+}
+
+func (c *baselineClient) FillNotificationDetails(notification *clients.Notification, functionIdentifier string) error {
+	notification.FunctionIdentifier = functionIdentifier
+	return nil
+}
+
+func (c *baselineClient) GetFuncConcurrency(funcIdentifier string) (*metadata.ConcurrencyConfig, error) {
+	return c.concurrency[funcIdentifier], nil
+}
+
+func (c *baselineClient) GetFuncLayers(funcIdentifier string) (*metadata.LayersConfig, error) {
+	return c.layers[funcIdentifier], nil
+}
+
+func TestSignAndUploadCodeRecordsBaselinesPerFunction(t *testing.T) {
+	t.Setenv("COSIGN_PASSWORD", "")
+	dir := t.TempDir()
+	publicKey, privateKey := writeKeyPair(t, dir, "signer")
+	viper.Set("privatekey", privateKey)
+	defer viper.Set("privatekey", "")
+	verifier, err := loadVerifier(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codePath := filepath.Join(dir, "shared.zip")
+	if err = os.WriteFile(codePath, []byte("shared code"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	identity, err := new(integrity.Sha256).GenerateIdentity(codePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, second := "arn:aws:lambda:us-east-1:123456789012:function:first", "arn:aws:lambda:us-east-1:123456789012:function:second"
+	five, ten := int32(5), int32(10)
+	client := &baselineClient{
+		rotationClient: &rotationClient{files: map[string]string{}},
+		concurrency: map[string]*metadata.ConcurrencyConfig{
+			first:  {ReservedConcurrentExecutions: &five},
+			second: {ReservedConcurrentExecutions: &ten},
+		},
+		layers: map[string]*metadata.LayersConfig{
+			first:  {Arns: []string{"arn:aws:lambda:us-east-1:123456789012:layer:shared:1"}},
+			second: {Arns: []string{"arn:aws:lambda:us-east-1:123456789012:layer:shared:2"}},
+		},
+	}
+	o := &options.SignBlobOptions{RecordConcurrency: true, RecordLayers: true, NoCIAnnotations: true}
+	o.Base64Output = true
+	o.SkipConfirmation = true
+	ro := &co.RootOptions{}
+	recorded := func(functionArn string) *metadata.SignatureMetadata {
+		content, ok := client.files[metadata.FunctionFileName(functionArn, identity)+"."+metadata.FileType]
+		if !ok {
+			t.Fatalf("expected signature metadata recorded for function: %s, got files: %v", functionArn, client.files)
+		}
+		metadataIdentity := metadata.Identity([]byte(content))
+		if err := verifyKeySignature(verifier, client.files[metadataIdentity+".sig"], metadataIdentity); err != nil {
+			t.Fatalf("expected the signature metadata of function: %s to be signed: %v", functionArn, err)
+		}
+		signatureMetadata, err := metadata.Unmarshal([]byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signatureMetadata
+	}
+
+	for _, functionArn := range []string{first, second} {
+		o.BaselineFunction = functionArn
+		if err = SignAndUploadCode(client, codePath, o, ro); err != nil {
+			t.Fatalf("failed to sign code for function: %s: %v", functionArn, err)
+		}
+	}
+	for _, functionArn := range []string{first, second} {
+		signatureMetadata := recorded(functionArn)
+		if !signatureMetadata.Concurrency.Equal(client.concurrency[functionArn]) {
+			t.Fatalf("expected the concurrency baseline of function: %s to be {%s}, got: {%s}", functionArn,
+				client.concurrency[functionArn], signatureMetadata.Concurrency)
+		}
+		if diff := signatureMetadata.Layers.DiffLayers(client.layers[functionArn]); !diff.IsEmpty() {
+			t.Fatalf("expected the layers baseline of function: %s to be %s, got: %s", functionArn,
+				client.layers[functionArn], signatureMetadata.Layers)
+		}
+	}
+	if _, ok := client.files[identity+"."+metadata.FileType]; ok {
+		t.Fatalf("expected no signature metadata recorded for the shared code identity")
+	}
+
+	o.BaselineFunction = first
+	o.RecordConcurrency = false
+	o.RecordLayers = false
+	if err = SignAndUploadCode(client, codePath, o, ro); err != nil {
+		t.Fatalf("failed to re-sign code for function: %s: %v", first, err)
+	}
+	if signatureMetadata := recorded(first); signatureMetadata.Concurrency != nil || signatureMetadata.Layers != nil {
+		t.Fatalf("expected re-signing without recording the baselines to drop them, got: %+v", signatureMetadata)
+	}
+	if signatureMetadata := recorded(second); !signatureMetadata.Concurrency.Equal(client.concurrency[second]) || signatureMetadata.Layers == nil {
+		t.Fatalf("expected the baselines of function: %s to be kept, got: %+v", second, signatureMetadata)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	Version = 1
+
+	DeviationMissing       = "missing"
+	DeviationUnexpected    = "unexpected"
+	DeviationDigestDiffers = "digest-differs"
+	DeviationConfigDiffers = "config-differs"
+)
+
+// Snapshot is the golden state of the functions of a region. It is signed like function code, so the whole
+// environment can be checked against a single attested document, for example after restoring it in another region.
+type Snapshot struct {
+	Version   int        `json:"version"`
+	CreatedAt time.Time  `json:"createdAt"`
+	Region    string     `json:"region"`
+	Functions []Function `json:"functions"`
+}
+
+type Function struct {
+	Name         string `json:"name"`
+	PackageType  string `json:"packageType"`
+	CodeSha256   string `json:"codeSha256"`
+	Runtime      string `json:"runtime,omitempty"`
+	Handler      string `json:"handler,omitempty"`
+	MemorySize   int32  `json:"memorySize"`
+	Timeout      int32  `json:"timeout"`
+	Architecture string `json:"architecture"`
+}
+
+type Deviation struct {
+	FunctionName string
+	Kind         string
+	Details      string
+}
+
+func New(functions []clients.FunctionConfig, region string, createdAt time.Time) *Snapshot {
+	s := &Snapshot{Version: Version, CreatedAt: createdAt.UTC(), Region: region, Functions: []Function{}}
+	for _, function := range functions {
+		s.Functions = append(s.Functions, fromConfig(function))
+	}
+	sort.Slice(s.Functions, func(i, j int) bool {
+		return s.Functions[i].Name < s.Functions[j].Name
+	})
+	return s
+}
+
+func fromConfig(function clients.FunctionConfig) Function {
+	return Function{
+		Name:         function.FunctionName,
+		PackageType:  function.PackageType,
+		CodeSha256:   function.CodeSha256,
+		Runtime:      function.Runtime,
+		Handler:      function.Handler,
+		MemorySize:   function.MemorySize,
+		Timeout:      function.Timeout,
+		Architecture: function.Architecture,
+	}
+}
+
+func (s *Snapshot) Write(path string) error {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	if err = os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %s: %w", path, err)
+	}
+	return nil
+}
+
+func Load(path string) (*Snapshot, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %s: %w", path, err)
+	}
+	s := &Snapshot{}
+	if err = json.Unmarshal(content, s); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot: %s: %w", path, err)
+	}
+	if s.Version != Version {
+		return nil, fmt.Errorf("unsupported snapshot version: %d, expected: %d", s.Version, Version)
+	}
+	return s, nil
+}
+
+// Compare reports every function of the live environment deviating from the snapshot, functions are matched by
+// name. Deviations are sorted by function name.
+func (s *Snapshot) Compare(live []clients.FunctionConfig) []Deviation {
+	var deviations []Deviation
+	liveByName := map[string]Function{}
+	for _, function := range live {
+		liveByName[function.FunctionName] = fromConfig(function)
+	}
+	golden := map[string]bool{}
+	for _, expected := range s.Functions {
+		golden[expected.Name] = true
+		actual, ok := liveByName[expected.Name]
+		if !ok {
+			deviations = append(deviations, Deviation{FunctionName: expected.Name, Kind: DeviationMissing,
+				Details: "function is in the snapshot but not deployed"})
+			continue
+		}
+		if actual.CodeSha256 != expected.CodeSha256 {
+			deviations = append(deviations, Deviation{FunctionName: expected.Name, Kind: DeviationDigestDiffers,
+				Details: fmt.Sprintf("codeSha256: %s -> %s", expected.CodeSha256, actual.CodeSha256)})
+		}
+		if changes := configChanges(expected, actual); len(changes) > 0 {
+			deviations = append(deviations, Deviation{FunctionName: expected.Name, Kind: DeviationConfigDiffers,
+				Details: strings.Join(changes, ", ")})
+		}
+	}
+	for _, function := range live {
+		if !golden[function.FunctionName] {
+			deviations = append(deviations, Deviation{FunctionName: function.FunctionName, Kind: DeviationUnexpected,
+				Details: "function is deployed but not in the snapshot"})
+		}
+	}
+	sort.SliceStable(deviations, func(i, j int) bool {
+		return deviations[i].FunctionName < deviations[j].FunctionName
+	})
+	return deviations
+}
+
+func configChanges(expected Function, actual Function) []string {
+	var changes []string
+	if expected.PackageType != actual.PackageType {
+		changes = append(changes, fmt.Sprintf("packageType: %s -> %s", expected.PackageType, actual.PackageType))
+	}
+	if expected.Runtime != actual.Runtime {
+		changes = append(changes, fmt.Sprintf("runtime: %s -> %s", expected.Runtime, actual.Runtime))
+	}
+	if expected.Handler != actual.Handler {
+		changes = append(changes, fmt.Sprintf("handler: %s -> %s", expected.Handler, actual.Handler))
+	}
+	if expected.MemorySize != actual.MemorySize {
+		changes = append(changes, fmt.Sprintf("memorySize: %d -> %d", expected.MemorySize, actual.MemorySize))
+	}
+	if expected.Timeout != actual.Timeout {
+		changes = append(changes, fmt.Sprintf("timeout: %d -> %d", expected.Timeout, actual.Timeout))
+	}
+	if expected.Architecture != actual.Architecture {
+		changes = append(changes, fmt.Sprintf("architecture: %s -> %s", expected.Architecture, actual.Architecture))
+	}
+	return changes
+}
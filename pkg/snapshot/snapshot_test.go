@@ -0,0 +1,74 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCompare(t *testing.T) {
+	golden := New([]clients.FunctionConfig{
+		{FunctionName: "unchanged", PackageType: "Zip", CodeSha256: "aaa", Runtime: "go1.x", MemorySize: 128, Timeout: 3, Architecture: "x86_64"},
+		{FunctionName: "patched", PackageType: "Zip", CodeSha256: "bbb", Runtime: "go1.x", MemorySize: 128, Timeout: 3, Architecture: "x86_64"},
+		{FunctionName: "resized", PackageType: "Zip", CodeSha256: "ccc", Runtime: "go1.x", MemorySize: 128, Timeout: 3, Architecture: "x86_64"},
+		{FunctionName: "deleted", PackageType: "Image", CodeSha256: "ddd", MemorySize: 512, Timeout: 30, Architecture: "arm64"},
+	}, "us-east-1", time.Now())
+	live := []clients.FunctionConfig{
+		{FunctionName: "unchanged", PackageType: "Zip", CodeSha256: "aaa", Runtime: "go1.x", MemorySize: 128, Timeout: 3, Architecture: "x86_64", Region: "us-west-2"},
+		{FunctionName: "patched", PackageType: "Zip", CodeSha256: "eee", Runtime: "go1.x", MemorySize: 128, Timeout: 3, Architecture: "x86_64"},
+		{FunctionName: "resized", PackageType: "Zip", CodeSha256: "ccc", Runtime: "go1.x", MemorySize: 1024, Timeout: 900, Architecture: "x86_64"},
+		{FunctionName: "added", PackageType: "Zip", CodeSha256: "fff", Runtime: "go1.x", MemorySize: 128, Timeout: 3, Architecture: "x86_64"},
+	}
+	expected := []Deviation{
+		{FunctionName: "added", Kind: DeviationUnexpected},
+		{FunctionName: "deleted", Kind: DeviationMissing},
+		{FunctionName: "patched", Kind: DeviationDigestDiffers, Details: "codeSha256: bbb -> eee"},
+		{FunctionName: "resized", Kind: DeviationConfigDiffers, Details: "memorySize: 128 -> 1024, timeout: 3 -> 900"},
+	}
+	deviations := golden.Compare(live)
+	if len(deviations) != len(expected) {
+		t.Fatalf("expected deviations: %+v, got: %+v", expected, deviations)
+	}
+	for i, d := range deviations {
+		if d.FunctionName != expected[i].FunctionName || d.Kind != expected[i].Kind {
+			t.Fatalf("expected deviation: %+v, got: %+v", expected[i], d)
+		}
+		if expected[i].Details != "" && d.Details != expected[i].Details {
+			t.Fatalf("expected details: %s, got: %s", expected[i].Details, d.Details)
+		}
+	}
+}
+
+func TestWriteAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	s := New([]clients.FunctionConfig{{FunctionName: "b"}, {FunctionName: "a"}}, "eu-west-1", time.Now())
+	if err := s.Write(path); err != nil {
+		t.Fatalf("failed to write snapshot: %v", err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("failed to load snapshot: %v", err)
+	}
+	if loaded.Region != "eu-west-1" || len(loaded.Functions) != 2 || loaded.Functions[0].Name != "a" {
+		t.Fatalf("unexpected loaded snapshot: %+v", loaded)
+	}
+	if deviations := loaded.Compare([]clients.FunctionConfig{{FunctionName: "a"}, {FunctionName: "b"}}); len(deviations) != 0 {
+		t.Fatalf("expected no deviations, got: %+v", deviations)
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	i "github.com/openclarity/function-clarity/pkg/init"
+	"gopkg.in/yaml.v3"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	Version = 1
+
+	manifestEntry  = "manifest.json"
+	configEntry    = "config.yaml"
+	publicKeyEntry = "cosign.pub"
+	objectsPrefix  = "objects/"
+)
+
+// State is the configuration of a function clarity deployment together with the content of its signature
+// bucket, signatures, certificates, signature metadata and signer pins. It is exported without secrets so it can be
+// stored and moved to another account.
+type State struct {
+	Manifest  Manifest
+	Config    i.AWSInput
+	PublicKey []byte
+	Objects   map[string][]byte
+}
+
+type Manifest struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exportedAt"`
+	Bucket     string    `json:"bucket"`
+	Region     string    `json:"region"`
+	Objects    int       `json:"objects"`
+}
+
+// Redact clears the credentials, the path of the private key, the slack webhook url, which is a secret itself, and the
+// webhook headers, which may hold tokens. They never leave the exporting machine and are entered again on import.
+func Redact(config i.AWSInput) i.AWSInput {
+	config.AccessKey = ""
+	config.SecretKey = ""
+	config.PrivateKey = ""
+	config.SlackWebhookUrl = ""
+	config.WebhookHeaders = nil
+	return config
+}
+
+// Validate checks the configuration before it is applied, so a corrupted or hand edited archive doesn't produce
+// a deployment failing at verification time.
+func Validate(config i.AWSInput) error {
+	if config.Region == "" {
+		return fmt.Errorf("invalid config: region is missing")
+	}
+	if config.Bucket == "" {
+		return fmt.Errorf("invalid config: bucket is missing")
+	}
+	switch config.Action {
+	case "", "detect", "block":
+	default:
+		return fmt.Errorf("invalid config: unsupported action: %s", config.Action)
+	}
+	switch config.UntrustedSignerAction {
+	case "", "detect", "block", "none":
+	default:
+		return fmt.Errorf("invalid config: unsupported untrusted signer action: %s", config.UntrustedSignerAction)
+	}
+	if config.SnsTopicArn != "" && !strings.HasPrefix(config.SnsTopicArn, "arn:") {
+		return fmt.Errorf("invalid config: sns topic arn: %s isn't an arn", config.SnsTopicArn)
+	}
+	if config.SignatureFreshness < 0 {
+		return fmt.Errorf("invalid config: negative signature freshness: %s", config.SignatureFreshness)
+	}
+	if config.ClockSkew < 0 {
+		return fmt.Errorf("invalid config: negative clock skew: %s", config.ClockSkew)
+	}
+	for value, channel := range config.NotificationRouting.Routes {
+		if strings.HasPrefix(channel, "arn:") {
+			continue
+		}
+		if u, err := url.Parse(channel); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid config: notification route: %s=%s is neither an arn nor a webhook url", value, channel)
+		}
+	}
+	if config.ResultQueue.Enabled() {
+		if _, err := config.ResultQueue.Arn(); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+	}
+	return nil
+}
+
+// Write writes the state as a gzipped tar archive, the bucket objects are stored under objects/ by key.
+func Write(w io.Writer, s *State) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	manifest := s.Manifest
+	manifest.Version = Version
+	manifest.Objects = len(s.Objects)
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state manifest: %w", err)
+	}
+	modTime := manifest.ExportedAt
+	if err = writeEntry(tw, manifestEntry, content, modTime); err != nil {
+		return err
+	}
+	redacted := Redact(s.Config)
+	if content, err = yaml.Marshal(&redacted); err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err = writeEntry(tw, configEntry, content, modTime); err != nil {
+		return err
+	}
+	if len(s.PublicKey) > 0 {
+		if err = writeEntry(tw, publicKeyEntry, s.PublicKey, modTime); err != nil {
+			return err
+		}
+	}
+	keys := make([]string, 0, len(s.Objects))
+	for key := range s.Objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err = writeEntry(tw, objectsPrefix+key, s.Objects[key], modTime); err != nil {
+			return err
+		}
+	}
+	if err = tw.Close(); err != nil {
+		return fmt.Errorf("failed to write state archive: %w", err)
+	}
+	if err = gz.Close(); err != nil {
+		return fmt.Errorf("failed to write state archive: %w", err)
+	}
+	return nil
+}
+
+func writeEntry(tw *tar.Writer, name string, content []byte, modTime time.Time) error {
+	header := &tar.Header{Name: name, Mode: 0600, Size: int64(len(content)), ModTime: modTime, Typeflag: tar.TypeReg}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write state archive entry: %s: %w", name, err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		return fmt.Errorf("failed to write state archive entry: %s: %w", name, err)
+	}
+	return nil
+}
+
+// Read reads a state archive and validates its configuration. Object keys leaving the bucket root, like absolute or
+// parent relative keys, are rejected.
+func Read(r io.Reader) (*State, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	s := &State{Objects: map[string][]byte{}}
+	var hasManifest, hasConfig bool
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read state archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read state archive entry: %s: %w", header.Name, err)
+		}
+		switch {
+		case header.Name == manifestEntry:
+			if err = json.Unmarshal(content, &s.Manifest); err != nil {
+				return nil, fmt.Errorf("failed to parse state manifest: %w", err)
+			}
+			hasManifest = true
+		case header.Name == configEntry:
+			if err = yaml.Unmarshal(content, &s.Config); err != nil {
+				return nil, fmt.Errorf("failed to parse config: %w", err)
+			}
+			hasConfig = true
+		case header.Name == publicKeyEntry:
+			s.PublicKey = content
+		case strings.HasPrefix(header.Name, objectsPrefix):
+			key := strings.TrimPrefix(header.Name, objectsPrefix)
+			if key == "" || key == ".." || path.IsAbs(key) || path.Clean(key) != key || strings.HasPrefix(key, "../") {
+				return nil, fmt.Errorf("invalid object key in state archive: %s", key)
+			}
+			s.Objects[key] = content
+		default:
+			return nil, fmt.Errorf("unexpected entry in state archive: %s", header.Name)
+		}
+	}
+	if !hasManifest || !hasConfig {
+		return nil, fmt.Errorf("invalid state archive: missing %s or %s", manifestEntry, configEntry)
+	}
+	if s.Manifest.Version != Version {
+		return nil, fmt.Errorf("unsupported state archive version: %d, expected: %d", s.Manifest.Version, Version)
+	}
+	if s.Manifest.Objects != len(s.Objects) {
+		return nil, fmt.Errorf("incomplete state archive: %d objects, manifest lists: %d", len(s.Objects), s.Manifest.Objects)
+	}
+	if err = Validate(s.Config); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -0,0 +1,153 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	i "github.com/openclarity/function-clarity/pkg/init"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testState() *State {
+	return &State{
+		Manifest: Manifest{ExportedAt: time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC), Bucket: "functionclarity", Region: "us-east-1"},
+		Config: i.AWSInput{
+			AccessKey:          "AKIAEXAMPLE",
+			SecretKey:          "s3cr3t-value",
+			PrivateKey:         "/home/user/cosign.key",
+			PublicKey:          "cosign.pub",
+			Region:             "us-east-1",
+			Bucket:             "functionclarity",
+			Action:             "block",
+			SignatureFreshness: time.Hour,
+			NotificationRouting: options.NotificationRouting{TagKey: "team", Routes: map[string]string{
+				"payments": "arn:aws:sns:us-east-1:123456789012:payments",
+				"search":   "https://hooks.example.com/search",
+			}},
+			SlackWebhookUrl: "https://hooks.slack.com/services/T000/B000/slack-secret",
+			WebhookUrl:      "https://hooks.example.com/verification",
+			WebhookHeaders:  map[string]string{"Authorization": "Bearer webhook-token"},
+		},
+		PublicKey: []byte("public key"),
+		Objects: map[string][]byte{
+			"abc.sig":        []byte("signature"),
+			"abc.crt.base64": []byte("certificate"),
+			"f.pin.json":     []byte("{}"),
+		},
+	}
+}
+
+func TestWriteAndReadRedactsSecrets(t *testing.T) {
+	var archive bytes.Buffer
+	if err := Write(&archive, testState()); err != nil {
+		t.Fatalf("failed to write state: %v", err)
+	}
+	raw := readRaw(t, archive.Bytes())
+	for _, secret := range []string{"AKIAEXAMPLE", "s3cr3t-value", "cosign.key", "slack-secret", "webhook-token"} {
+		if strings.Contains(raw, secret) {
+			t.Fatalf("expected %s to be redacted from the archive", secret)
+		}
+	}
+	s, err := Read(&archive)
+	if err != nil {
+		t.Fatalf("failed to read state: %v", err)
+	}
+	if s.Config.AccessKey != "" || s.Config.SecretKey != "" || s.Config.PrivateKey != "" || s.Config.SlackWebhookUrl != "" ||
+		len(s.Config.WebhookHeaders) != 0 {
+		t.Fatalf("expected secrets to be redacted, got: %+v", s.Config)
+	}
+	if s.Config.Action != "block" || s.Config.SignatureFreshness != time.Hour || s.Config.NotificationRouting.Routes["search"] != "https://hooks.example.com/search" {
+		t.Fatalf("unexpected config: %+v", s.Config)
+	}
+	if string(s.PublicKey) != "public key" || len(s.Objects) != 3 || string(s.Objects["abc.sig"]) != "signature" {
+		t.Fatalf("unexpected state: %+v", s)
+	}
+	if s.Manifest.Version != Version || s.Manifest.Objects != 3 || s.Manifest.Bucket != "functionclarity" {
+		t.Fatalf("unexpected manifest: %+v", s.Manifest)
+	}
+}
+
+func readRaw(t *testing.T, archive []byte) string {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw bytes.Buffer
+	if _, err = raw.ReadFrom(gz); err != nil {
+		t.Fatal(err)
+	}
+	return raw.String()
+}
+
+func TestReadRejectsInvalidConfig(t *testing.T) {
+	tests := map[string]func(*i.AWSInput){
+		"action":  func(c *i.AWSInput) { c.Action = "delete" },
+		"bucket":  func(c *i.AWSInput) { c.Bucket = "" },
+		"sns":     func(c *i.AWSInput) { c.SnsTopicArn = "topic" },
+		"route":   func(c *i.AWSInput) { c.NotificationRouting.Routes["search"] = "ftp://hooks.example.com" },
+		"untrust": func(c *i.AWSInput) { c.UntrustedSignerAction = "ignore" },
+	}
+	for name, corrupt := range tests {
+		s := testState()
+		corrupt(&s.Config)
+		var archive bytes.Buffer
+		if err := Write(&archive, s); err != nil {
+			t.Fatalf("%s: failed to write state: %v", name, err)
+		}
+		if _, err := Read(&archive); err == nil || !strings.Contains(err.Error(), "invalid config") {
+			t.Fatalf("%s: expected an invalid config error, got: %v", name, err)
+		}
+	}
+}
+
+func TestReadRejectsObjectKeysOutsideBucketRoot(t *testing.T) {
+	for _, key := range []string{"../abc.sig", "/abc.sig", "a/../../b", ".."} {
+		s := testState()
+		s.Objects = map[string][]byte{key: []byte("signature")}
+		var archive bytes.Buffer
+		if err := Write(&archive, s); err != nil {
+			t.Fatalf("failed to write state: %v", err)
+		}
+		if _, err := Read(&archive); err == nil {
+			t.Fatalf("expected key: %s to be rejected", key)
+		}
+	}
+}
+
+func TestReadRejectsIncompleteArchive(t *testing.T) {
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	if err := writeEntry(tw, manifestEntry, []byte(`{"version":1,"objects":2}`), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeEntry(tw, configEntry, []byte("region: us-east-1\nbucket: functionclarity\n"), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeEntry(tw, objectsPrefix+"abc.sig", []byte("signature"), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	tw.Close()
+	gz.Close()
+	if _, err := Read(&archive); err == nil || !strings.Contains(err.Error(), "incomplete state archive") {
+		t.Fatalf("expected an incomplete archive error, got: %v", err)
+	}
+}
@@ -17,6 +17,7 @@ package utils
 
 import (
 	"archive/zip"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -26,16 +27,25 @@ import (
 )
 
 func DownloadFile(fileName string, url *string) error {
+	return DownloadFileTo(context.Background(), "/tmp/"+fileName, url)
+}
+
+// DownloadFileTo downloads the url to the file at path, the download is abandoned when the context is done.
+func DownloadFileTo(ctx context.Context, path string, url *string) error {
 
 	// Get the data
-	resp, err := http.Get(*url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	// Create the file
-	out, err := os.Create("/tmp/" + fileName)
+	out, err := os.Create(path)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,35 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import "testing"
+
+func TestPartition(t *testing.T) {
+	for region, partition := range map[string]string{
+		"us-east-1":      "aws",
+		"eu-west-3":      "aws",
+		"":               "aws",
+		"*":              "aws",
+		"us-gov-west-1":  "aws-us-gov",
+		"cn-northwest-1": "aws-cn",
+		"us-iso-east-1":  "aws-iso",
+		"us-isob-east-1": "aws-iso-b",
+	} {
+		if got := Partition(region); got != partition {
+			t.Errorf("Partition(%q) = %s, expected %s", region, got, partition)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"sort"
+)
+
+const (
+	ParityMatch             = "match"
+	ParityDigestDiffers     = "digest-differs"
+	ParitySignatureDiffers  = "signature-differs"
+	ParityOnlyInSource      = "source-only"
+	ParityOnlyInDestination = "destination-only"
+)
+
+// ComparedFunction is the parity of a function matched by name between two scopes. The digest is the code sha256
+// reported by lambda, the zip package digest for zip functions and the image manifest digest for image functions.
+type ComparedFunction struct {
+	FunctionName      string
+	SourceDigest      string
+	DestinationDigest string
+	SourceResult      string
+	DestinationResult string
+	Parity            string
+}
+
+// CompareFunctions matches the functions of both scopes by name. The results hold the verification result of each
+// function present in both scopes, keyed by function name.
+func CompareFunctions(source []clients.FunctionConfig, destination []clients.FunctionConfig,
+	sourceResults map[string]string, destinationResults map[string]string) []ComparedFunction {
+	compared := map[string]*ComparedFunction{}
+	for _, function := range source {
+		compared[function.FunctionName] = &ComparedFunction{FunctionName: function.FunctionName, SourceDigest: function.CodeSha256, Parity: ParityOnlyInSource}
+	}
+	for _, function := range destination {
+		c, ok := compared[function.FunctionName]
+		if !ok {
+			compared[function.FunctionName] = &ComparedFunction{FunctionName: function.FunctionName, DestinationDigest: function.CodeSha256, Parity: ParityOnlyInDestination}
+			continue
+		}
+		c.DestinationDigest = function.CodeSha256
+		c.SourceResult = sourceResults[c.FunctionName]
+		c.DestinationResult = destinationResults[c.FunctionName]
+		switch {
+		case c.SourceDigest != c.DestinationDigest:
+			c.Parity = ParityDigestDiffers
+		case c.SourceResult != c.DestinationResult:
+			c.Parity = ParitySignatureDiffers
+		default:
+			c.Parity = ParityMatch
+		}
+	}
+	var result []ComparedFunction
+	for _, c := range compared {
+		result = append(result, *c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].FunctionName < result[j].FunctionName
+	})
+	return result
+}
@@ -0,0 +1,58 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"testing"
+)
+
+func TestCompareFunctions(t *testing.T) {
+	source := []clients.FunctionConfig{
+		{FunctionName: "same", CodeSha256: "a"},
+		{FunctionName: "changed", CodeSha256: "b"},
+		{FunctionName: "unsigned", CodeSha256: "c"},
+		{FunctionName: "staging-only", CodeSha256: "d"},
+	}
+	destination := []clients.FunctionConfig{
+		{FunctionName: "same", CodeSha256: "a"},
+		{FunctionName: "changed", CodeSha256: "x"},
+		{FunctionName: "unsigned", CodeSha256: "c"},
+		{FunctionName: "prod-only", CodeSha256: "e"},
+	}
+	sourceResults := map[string]string{"same": ResultPassed, "changed": ResultPassed, "unsigned": ResultPassed}
+	destinationResults := map[string]string{"same": ResultPassed, "changed": ResultPassed, "unsigned": ResultFailed}
+
+	expected := map[string]string{
+		"same":         ParityMatch,
+		"changed":      ParityDigestDiffers,
+		"unsigned":     ParitySignatureDiffers,
+		"staging-only": ParityOnlyInSource,
+		"prod-only":    ParityOnlyInDestination,
+	}
+	compared := CompareFunctions(source, destination, sourceResults, destinationResults)
+	if len(compared) != len(expected) {
+		t.Fatalf("expected %d compared functions, got: %d", len(expected), len(compared))
+	}
+	for i, c := range compared {
+		if i > 0 && compared[i-1].FunctionName > c.FunctionName {
+			t.Fatalf("expected functions sorted by name")
+		}
+		if c.Parity != expected[c.FunctionName] {
+			t.Fatalf("function: %s, expected parity: %s, got: %s", c.FunctionName, expected[c.FunctionName], c.Parity)
+		}
+	}
+}
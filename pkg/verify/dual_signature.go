@@ -0,0 +1,132 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"fmt"
+	"github.com/openclarity/function-clarity/cmd/function-clarity/cli/verify"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/integrity"
+	"github.com/openclarity/function-clarity/pkg/options"
+	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+	v "github.com/sigstore/cosign/cmd/cosign/cli/verify"
+	"os"
+	"strings"
+)
+
+const (
+	keySignature     = "key-based signature"
+	keylessSignature = "keyless signature"
+)
+
+// verifyKeyAndKeyless requires the identity to carry both a valid signature made with the public key and a valid
+// keyless signature, instead of either of them. The failure reports which of the two is missing or invalid.
+func verifyKeyAndKeyless(client clients.Client, functionIdentifier string, identity string, o *options.VerifyOpts, ctx context.Context) error {
+	if err := checkKeyAndKeylessOptions(o); err != nil {
+		return err
+	}
+	var missing []string
+	problem, err := verifySignatureOfType(client, identity, integrity.KeySignatureType, o, ctx, false)
+	if err != nil {
+		return err
+	}
+	if problem != "" {
+		missing = append(missing, fmt.Sprintf("%s: %s", keySignature, problem))
+	}
+	keylessOpts := *o
+	keylessOpts.Key = ""
+	if problem, err = verifySignatureOfType(client, identity, integrity.KeylessSignatureType, &keylessOpts, ctx, true); err != nil {
+		return err
+	}
+	if problem != "" {
+		missing = append(missing, fmt.Sprintf("%s: %s", keylessSignature, problem))
+	}
+	return dualSignatureError(functionIdentifier, missing)
+}
+
+// verifySignatureOfType verifies the signature stored under the name of its signing mode, it is moved in place
+// of <identity>.sig which is where the identity verification reads the signature from. A missing or invalid
+// signature is returned as the problem, other failures as the error.
+func verifySignatureOfType(client clients.Client, identity string, signatureType string, o *options.VerifyOpts,
+	ctx context.Context, isKeyless bool) (string, error) {
+	if err := client.Download(identity, signatureType); err != nil {
+		if clients.IsObjectNotFound(err) {
+			return "missing", nil
+		}
+		return "", fmt.Errorf("failed to get %s of identity: %s: %w", signatureType, identity, err)
+	}
+	if err := os.Rename("/tmp/"+identity+"."+signatureType, "/tmp/"+identity+".sig"); err != nil {
+		return "", err
+	}
+	if isKeyless {
+		if err := client.Download(identity, "crt.base64"); err != nil {
+			if clients.IsObjectNotFound(err) {
+				return "missing certificate", nil
+			}
+			return "", fmt.Errorf("failed to get certificate of identity: %s: %w", identity, err)
+		}
+		if o.Offline {
+			if err := client.Download(identity, integrity.RekorBundleType); err != nil {
+				if clients.IsObjectNotFound(err) {
+					return "missing rekor bundle", nil
+				}
+				return "", fmt.Errorf("failed to get rekor bundle of identity: %s: %w", identity, err)
+			}
+		}
+	}
+	if err := verify.VerifyIdentity(identity, o, ctx, isKeyless); err != nil {
+		return err.Error(), nil
+	}
+	return "", nil
+}
+
+// verifyImageKeyAndKeyless verifies the image once with the public key and once keyless, cosign keeps every
+// signature of an image so both are found whatever the signing order.
+func verifyImageKeyAndKeyless(vc *v.VerifyCommand, functionIdentifier string, imageURI string, architecture string,
+	o *options.VerifyOpts, ctx context.Context) error {
+	if err := checkKeyAndKeylessOptions(o); err != nil {
+		return err
+	}
+	var missing []string
+	if err := verifyImageForArchitecture(vc, imageURI, architecture, o, ctx); err != nil {
+		missing = append(missing, fmt.Sprintf("%s: %v", keySignature, err))
+	}
+	keylessCommand := *vc
+	keylessCommand.KeyRef = ""
+	if err := verifyImageForArchitecture(&keylessCommand, imageURI, architecture, o, ctx); err != nil {
+		missing = append(missing, fmt.Sprintf("%s: %v", keylessSignature, err))
+	}
+	return dualSignatureError(functionIdentifier, missing)
+}
+
+func checkKeyAndKeylessOptions(o *options.VerifyOpts) error {
+	if o.Key == "" {
+		return fmt.Errorf("requiring key-based and keyless signatures requires a public key")
+	}
+	if !co.EnableExperimental() {
+		return fmt.Errorf("requiring key-based and keyless signatures requires keyless verification, set %s=1", integrity.ExperimentalEnv)
+	}
+	return nil
+}
+
+func dualSignatureError(functionIdentifier string, missing []string) error {
+	if len(missing) == 0 {
+		return nil
+	}
+	return VerifyError{Err: fmt.Errorf("function: %s requires both a key-based and a keyless signature, missing or invalid: %s",
+		functionIdentifier, strings.Join(missing, "; "))}
+}
@@ -0,0 +1,59 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"errors"
+	"github.com/openclarity/function-clarity/pkg/integrity"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"strings"
+	"testing"
+)
+
+func TestVerifyKeyAndKeylessReportsMissingSignatures(t *testing.T) {
+	t.Setenv(integrity.ExperimentalEnv, "1")
+	o := &options.VerifyOpts{}
+	o.Key = "cosign.pub"
+	err := verifyKeyAndKeyless(&pinClient{files: map[string]string{}}, "function", "identity", o, context.Background())
+	if !errors.Is(err, VerifyError{}) {
+		t.Fatalf("expected a verification error, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), keySignature+": missing") || !strings.Contains(err.Error(), keylessSignature+": missing") {
+		t.Fatalf("expected both signatures to be reported missing, got: %v", err)
+	}
+}
+
+func TestVerifyKeyAndKeylessReportsMissingCertificate(t *testing.T) {
+	t.Setenv(integrity.ExperimentalEnv, "1")
+	o := &options.VerifyOpts{}
+	o.Key = "cosign.pub"
+	client := &pinClient{files: map[string]string{"identity." + integrity.KeylessSignatureType: "c2lnbmF0dXJl"}}
+	problem, err := verifySignatureOfType(client, "identity", integrity.KeylessSignatureType, o, context.Background(), true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if problem != "missing certificate" {
+		t.Fatalf("expected the certificate to be reported missing, got: %s", problem)
+	}
+}
+
+func TestVerifyKeyAndKeylessRequiresKey(t *testing.T) {
+	t.Setenv(integrity.ExperimentalEnv, "1")
+	if err := verifyKeyAndKeyless(&pinClient{}, "function", "identity", &options.VerifyOpts{}, context.Background()); err == nil {
+		t.Fatalf("expected an error without a public key")
+	}
+}
@@ -29,3 +29,61 @@ func (e VerifyError) Error() string {
 func (m VerifyError) Is(target error) bool {
 	return target == VerifyError{}
 }
+
+// UntrustedSignerError is a verification failure of code matching a stored signature that wasn't made by a trusted
+// key or identity, unlike unsigned code someone did sign it. Signer describes who signed it as far as known.
+type UntrustedSignerError struct {
+	Signer string
+	Err    error
+}
+
+func (e UntrustedSignerError) Error() string {
+	return fmt.Sprintf("verification error: signed by untrusted signer: %s: %v", e.Signer, e.Err)
+}
+
+func (e UntrustedSignerError) Is(target error) bool {
+	return target == VerifyError{} || target == UntrustedSignerError{}
+}
+
+// AwsCodeSigningError is a verification failure of a function whose signature verified, but that isn't also protected
+// by an enforced AWS native code signing config.
+type AwsCodeSigningError struct {
+	Err error
+}
+
+func (e AwsCodeSigningError) Error() string {
+	return fmt.Sprintf("verification error: aws code signing not enforced: %v", e.Err)
+}
+
+func (e AwsCodeSigningError) Is(target error) bool {
+	return target == VerifyError{} || target == AwsCodeSigningError{}
+}
+
+// UnsignedLayerError is a verification failure of a function whose code verified, but with an attached layer whose
+// content has no valid signature, with --require-signed-layers.
+type UnsignedLayerError struct {
+	Layer string
+	Err   error
+}
+
+func (e UnsignedLayerError) Error() string {
+	return fmt.Sprintf("verification error: layer %s not signed: %v", e.Layer, e.Err)
+}
+
+func (e UnsignedLayerError) Is(target error) bool {
+	return target == VerifyError{} || target == UnsignedLayerError{}
+}
+
+// ImageDigestPinError is a verification failure of an image function referencing its image by tag, it lost the
+// digest pin of an immutable deployment whether the image the tag points to is signed or not.
+type ImageDigestPinError struct {
+	Err error
+}
+
+func (e ImageDigestPinError) Error() string {
+	return fmt.Sprintf("verification error: image digest pin lost: %v", e.Err)
+}
+
+func (e ImageDigestPinError) Is(target error) bool {
+	return target == VerifyError{} || target == ImageDigestPinError{}
+}
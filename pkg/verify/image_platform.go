@@ -0,0 +1,106 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/openclarity/function-clarity/pkg/options"
+	v "github.com/sigstore/cosign/cmd/cosign/cli/verify"
+	"golang.org/x/exp/slog"
+)
+
+// platformForArchitecture maps a function architecture to the platform of the image it runs.
+func platformForArchitecture(architecture string) v1.Platform {
+	switch architecture {
+	case "arm64":
+		return v1.Platform{OS: "linux", Architecture: "arm64"}
+	default:
+		return v1.Platform{OS: "linux", Architecture: "amd64"}
+	}
+}
+
+// selectPlatformManifest returns the manifest of the index the function runs, a manifest matches when its os and architecture
+// are the ones of the platform, the variant is compared only when both specify one.
+func selectPlatformManifest(index *v1.IndexManifest, platform v1.Platform) (*v1.Descriptor, error) {
+	for i, manifest := range index.Manifests {
+		if manifest.Platform == nil || manifest.Platform.OS != platform.OS || manifest.Platform.Architecture != platform.Architecture {
+			continue
+		}
+		if platform.Variant != "" && manifest.Platform.Variant != "" && platform.Variant != manifest.Platform.Variant {
+			continue
+		}
+		return &index.Manifests[i], nil
+	}
+	return nil, fmt.Errorf("image index doesn't contain an image for platform: %s", platform)
+}
+
+// verifyImageForArchitecture verifies the image the function runs. For a multi-arch image the signature of every platform image
+// is checked and reported, but only the image of the function architecture, or the index whose signature covers it, can
+// make the verification pass.
+func verifyImageForArchitecture(vc *v.VerifyCommand, imageURI string, architecture string, o *options.VerifyOpts, ctx context.Context) error {
+	ref, err := name.ParseReference(imageURI)
+	if err != nil {
+		return fmt.Errorf("failed to parse image URI: %s: %w", imageURI, err)
+	}
+	desc, err := remote.Get(ref, o.Registry.GetRegistryClientOpts(ctx)...)
+	if err != nil {
+		return fmt.Errorf("failed to fetch image descriptor: %s: %w", imageURI, err)
+	}
+	if !desc.MediaType.IsIndex() {
+		return vc.Exec(ctx, []string{imageURI})
+	}
+	index, err := desc.ImageIndex()
+	if err != nil {
+		return fmt.Errorf("failed to read image index: %s: %w", imageURI, err)
+	}
+	indexManifest, err := index.IndexManifest()
+	if err != nil {
+		return fmt.Errorf("failed to read image index manifest: %s: %w", imageURI, err)
+	}
+	platform := platformForArchitecture(architecture)
+	selected, err := selectPlatformManifest(indexManifest, platform)
+	if err != nil {
+		return err
+	}
+
+	var platformErr error
+	for _, manifest := range indexManifest.Manifests {
+		if manifest.Platform == nil || manifest.Platform.OS == "unknown" {
+			continue
+		}
+		err := vc.Exec(ctx, []string{ref.Context().Digest(manifest.Digest.String()).String()})
+		result := "verified"
+		if err != nil {
+			result = "not verified"
+		}
+		slog.Info("image platform", "platform", manifest.Platform, "digest", manifest.Digest, "result", result)
+		if manifest.Digest == selected.Digest {
+			platformErr = err
+		}
+	}
+	if platformErr == nil {
+		return nil
+	}
+	if err := vc.Exec(ctx, []string{ref.Context().Digest(desc.Digest.String()).String()}); err == nil {
+		slog.Info("image platform verified by the image index signature", "platform", platform, "digest", desc.Digest)
+		return nil
+	}
+	return fmt.Errorf("image for function platform: %s, digest: %s: %w", platform, selected.Digest, platformErr)
+}
@@ -0,0 +1,85 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"testing"
+)
+
+func digest(t *testing.T, hex string) v1.Hash {
+	h, err := v1.NewHash("sha256:" + hex)
+	if err != nil {
+		t.Fatalf("failed to create hash: %v", err)
+	}
+	return h
+}
+
+func multiArchIndex(t *testing.T) *v1.IndexManifest {
+	return &v1.IndexManifest{
+		SchemaVersion: 2,
+		MediaType:     types.OCIImageIndex,
+		Manifests: []v1.Descriptor{
+			{
+				MediaType: types.OCIManifestSchema1,
+				Digest:    digest(t, "1111111111111111111111111111111111111111111111111111111111111111"),
+				Platform:  &v1.Platform{OS: "linux", Architecture: "amd64"},
+			},
+			{
+				MediaType: types.OCIManifestSchema1,
+				Digest:    digest(t, "2222222222222222222222222222222222222222222222222222222222222222"),
+				Platform:  &v1.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"},
+			},
+			{
+				MediaType: types.OCIManifestSchema1,
+				Digest:    digest(t, "3333333333333333333333333333333333333333333333333333333333333333"),
+				Platform:  &v1.Platform{OS: "unknown", Architecture: "unknown"},
+			},
+		},
+	}
+}
+
+func TestSelectPlatformManifest(t *testing.T) {
+	tests := []struct {
+		architecture string
+		digest       string
+	}{
+		{"x86_64", "sha256:1111111111111111111111111111111111111111111111111111111111111111"},
+		{"arm64", "sha256:2222222222222222222222222222222222222222222222222222222222222222"},
+		{"", "sha256:1111111111111111111111111111111111111111111111111111111111111111"},
+	}
+	index := multiArchIndex(t)
+	for _, test := range tests {
+		t.Run(test.architecture, func(t *testing.T) {
+			selected, err := selectPlatformManifest(index, platformForArchitecture(test.architecture))
+			if err != nil {
+				t.Fatalf("failed to select platform manifest: %v", err)
+			}
+			if selected.Digest.String() != test.digest {
+				t.Fatalf("expected digest: %s for architecture: %s, got: %s", test.digest, test.architecture, selected.Digest)
+			}
+		})
+	}
+}
+
+func TestSelectPlatformManifestMissingArchitecture(t *testing.T) {
+	index := multiArchIndex(t)
+	index.Manifests = index.Manifests[:1]
+	if selected, err := selectPlatformManifest(index, platformForArchitecture("arm64")); err == nil {
+		t.Fatalf("expected an error for a missing platform, got digest: %s", selected.Digest)
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/notify"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"golang.org/x/exp/slog"
+)
+
+// routeNotification returns the channel of the team owning the function, the default channel is kept when the
+// function tags can't be read so the failure is still notified.
+func routeNotification(client clients.Client, functionIdentifier string, routing *options.NotificationRouting, defaultChannel string) string {
+	tags, err := client.GetFuncTags(functionIdentifier)
+	if err != nil {
+		slog.Warn("failed to get tags of function, notifying the default channel", "function", functionIdentifier, "error", err)
+		return defaultChannel
+	}
+	return routing.ChannelFor(tags, defaultChannel)
+}
+
+// notifiers returns the notifiers of a failed verification: the sns topic or routed channel, slack and the webhook.
+func notifiers(client clients.Client, o *options.VerifyOpts, channel string) []notify.Notifier {
+	var n []notify.Notifier
+	if channel != "" {
+		n = append(n, notify.ForChannel(client, channel))
+	}
+	if o.SlackWebhookURL != "" {
+		n = append(n, &notify.SlackNotifier{WebhookURL: o.SlackWebhookURL})
+	}
+	if o.WebhookURL != "" {
+		n = append(n, notify.NewWebhookNotifier(o.WebhookURL, o.WebhookHeaders))
+	}
+	return n
+}
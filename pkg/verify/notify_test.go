@@ -0,0 +1,137 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"github.com/openclarity/function-clarity/pkg/report"
+	"testing"
+)
+
+type routingClient struct {
+	clients.Client
+	tags      map[string]string
+	tagsErr   error
+	published map[string]string
+	blocked   []string
+}
+
+func (c *routingClient) GetFuncTags(funcIdentifier string) (map[string]string, error) {
+	return c.tags, c.tagsErr
+}
+
+func (c *routingClient) FillNotificationDetails(notification *clients.Notification, functionIdentifier string) error {
+	notification.FunctionIdentifier = functionIdentifier
+	return nil
+}
+
+func (c *routingClient) GetFuncCodeSha256(funcIdentifier string) (string, error) {
+	return "code-sha256", nil
+}
+
+func (c *routingClient) Notify(msg string, snsArn string) error {
+	c.published[snsArn] = msg
+	return nil
+}
+
+func (c *routingClient) HandleDetect(funcIdentifier *string, failed bool) error {
+	return nil
+}
+
+func (c *routingClient) HandleBlock(funcIdentifier *string, failed bool) error {
+	c.blocked = append(c.blocked, *funcIdentifier)
+	return nil
+}
+
+func TestRouteNotification(t *testing.T) {
+	routing := &options.NotificationRouting{Routes: map[string]string{"payments": "arn:aws:sns:us-east-1:123456789012:payments"}}
+	client := &routingClient{tags: map[string]string{"team": "payments"}}
+	if channel := routeNotification(client, "function", routing, "default"); channel != "arn:aws:sns:us-east-1:123456789012:payments" {
+		t.Fatalf("expected the team channel, got: %s", channel)
+	}
+	client = &routingClient{tagsErr: errors.New("access denied")}
+	if channel := routeNotification(client, "function", routing, "default"); channel != "default" {
+		t.Fatalf("expected the default channel when tags can't be read, got: %s", channel)
+	}
+}
+
+func TestNotificationDeduplicationKey(t *testing.T) {
+	client := &routingClient{published: map[string]string{}}
+	topic := "arn:aws:sns:us-east-1:123456789012:alerts"
+	function := "arn:aws:lambda:us-east-1:123456789012:function:orders"
+	_, err := HandleVerification(client, "", false, function, VerifyError{Err: errors.New("unsigned")}, notifiers(client, &options.VerifyOpts{}, topic), "scan-1", context.Background())
+	if !errors.Is(err, VerifyError{}) {
+		t.Fatalf("expected the verification error, got: %v", err)
+	}
+	var notification clients.Notification
+	if err = json.Unmarshal([]byte(client.published[topic]), &notification); err != nil {
+		t.Fatalf("failed to parse notification: %v", err)
+	}
+	if notification.Digest != "code-sha256" || notification.ScanId != "scan-1" ||
+		notification.DeduplicationKey != report.DeduplicationKey(function, "code-sha256", "scan-1") {
+		t.Fatalf("unexpected deduplication fields: %+v", notification)
+	}
+	if report.DeduplicationKey(function, "code-sha256", "scan-2") == notification.DeduplicationKey {
+		t.Fatalf("results of different scans should have different keys")
+	}
+}
+
+func TestNotifiers(t *testing.T) {
+	client := &routingClient{}
+	if n := notifiers(client, &options.VerifyOpts{}, ""); len(n) != 0 {
+		t.Fatalf("expected no notifier, got: %d", len(n))
+	}
+	n := notifiers(client, &options.VerifyOpts{SlackWebhookURL: "https://hooks.slack.com/services/T/B/X"}, "arn:aws:sns:us-east-1:123456789012:alerts")
+	if len(n) != 2 {
+		t.Fatalf("expected the sns and slack notifiers, got: %d", len(n))
+	}
+}
+
+func TestHandleVerificationDryRun(t *testing.T) {
+	topic := "arn:aws:sns:us-east-1:123456789012:alerts"
+	function := "arn:aws:lambda:us-east-1:123456789012:function:orders"
+	for _, dryRun := range []bool{false, true} {
+		client := &routingClient{published: map[string]string{}}
+		applied, err := HandleVerification(client, ActionBlock, dryRun, function, VerifyError{Err: errors.New("unsigned")},
+			notifiers(client, &options.VerifyOpts{}, topic), "scan-1", context.Background())
+		if !errors.Is(err, VerifyError{}) {
+			t.Fatalf("expected the verification error, got: %v", err)
+		}
+		var notification clients.Notification
+		if err = json.Unmarshal([]byte(client.published[topic]), &notification); err != nil {
+			t.Fatalf("failed to parse notification: %v", err)
+		}
+		if dryRun {
+			if len(client.blocked) != 0 || applied != ActionWouldBlock || notification.Action != ActionBlock || !notification.DryRun {
+				t.Fatalf("expected a dry run block, got: blocked %v, applied %s, notification %+v", client.blocked, applied, notification)
+			}
+			continue
+		}
+		if len(client.blocked) != 1 || applied != ActionBlock || notification.DryRun {
+			t.Fatalf("expected a block, got: blocked %v, applied %s, notification %+v", client.blocked, applied, notification)
+		}
+	}
+
+	client := &routingClient{published: map[string]string{}}
+	applied, err := HandleVerification(client, ActionBlock, true, function, nil, nil, "scan-1", context.Background())
+	if err != nil || applied != "" || len(client.blocked) != 0 {
+		t.Fatalf("expected a passing function to be left as is in a dry run, got: applied %q, blocked %v, error %v", applied, client.blocked, err)
+	}
+}
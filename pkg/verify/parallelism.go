@@ -0,0 +1,151 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"errors"
+	"github.com/aws/smithy-go"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"golang.org/x/exp/slog"
+	"strings"
+	"sync"
+)
+
+const (
+	// functionsPerWorker sizes the initial parallelism of auto mode, a scan starts with a worker per this many
+	// functions in scope.
+	functionsPerWorker = 25
+	maxInitialWorkers  = 4
+	maxAutoWorkers     = 32
+)
+
+var throttlingErrorCodes = []string{"ThrottlingException", "TooManyRequestsException", "Throttling", "RequestLimitExceeded", "SlowDown"}
+
+var throttlingMessages = []string{"ThrottlingException", "TooManyRequestsException", "Rate exceeded"}
+
+// concurrencyLimiter bounds the verifications running at once. In auto mode the bound is halved when a
+// verification is throttled and raised by one once as many verifications as the bound succeed in a row.
+type concurrencyLimiter struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	limit     int
+	max       int
+	active    int
+	successes int
+	auto      bool
+}
+
+func newConcurrencyLimiter(level int, auto bool, functions int) *concurrencyLimiter {
+	l := &concurrencyLimiter{limit: level, max: level, auto: auto}
+	if auto {
+		l.limit = clamp(functions/functionsPerWorker, 1, maxInitialWorkers)
+		l.max = clamp(functions, 1, maxAutoWorkers)
+		slog.Info("parallelism auto: starting", "concurrentVerifications", l.limit, "functions", functions, "max", l.max)
+	}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+func clamp(value int, low int, high int) int {
+	if value < low {
+		return low
+	}
+	if value > high {
+		return high
+	}
+	return value
+}
+
+func (l *concurrencyLimiter) acquire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.active >= l.limit {
+		l.cond.Wait()
+	}
+	l.active++
+}
+
+func (l *concurrencyLimiter) release(throttled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active--
+	if l.auto {
+		previous := l.limit
+		if throttled {
+			l.limit = clamp(l.limit/2, 1, l.max)
+			l.successes = 0
+		} else {
+			l.successes++
+			if l.successes >= l.limit && l.limit < l.max {
+				l.limit++
+				l.successes = 0
+			}
+		}
+		switch {
+		case l.limit < previous:
+			slog.Info("parallelism auto: throttled, lowering concurrent verifications", "from", previous, "to", l.limit)
+		case l.limit > previous:
+			slog.Info("parallelism auto: raising concurrent verifications", "from", previous, "to", l.limit)
+		}
+	}
+	l.cond.Broadcast()
+}
+
+func (l *concurrencyLimiter) currentLimit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// isThrottling tells whether the error comes from an aws api refusing the request rate. Some errors are wrapped
+// without their chain, so the error message is checked as well.
+func isThrottling(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		for _, code := range throttlingErrorCodes {
+			if apiErr.ErrorCode() == code {
+				return true
+			}
+		}
+	}
+	for _, message := range throttlingMessages {
+		if strings.Contains(err.Error(), message) {
+			return true
+		}
+	}
+	return false
+}
+
+// groupBySharedCode groups the indexes of functions deploying the same zip code, in listing order. Their
+// verifications share the temporary files of the code identity, so a group is verified sequentially.
+func groupBySharedCode(functions []clients.FunctionConfig) [][]int {
+	var groups [][]int
+	groupOf := map[string]int{}
+	for index, function := range functions {
+		if function.PackageType == "Zip" && function.CodeSha256 != "" {
+			if group, ok := groupOf[function.CodeSha256]; ok {
+				groups[group] = append(groups[group], index)
+				continue
+			}
+			groupOf[function.CodeSha256] = len(groups)
+		}
+		groups = append(groups, []int{index})
+	}
+	return groups
+}
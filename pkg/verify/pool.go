@@ -0,0 +1,121 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WorkerPool runs verifications concurrently, at most Size of them at once, NumCPU when not set, each within Timeout
+// when set. A verification failing or timing out doesn't stop the others, and the ones not started yet are skipped
+// once the context is done. Verifications of the same key, e.g. of the same function, run one after the other since
+// they share the temporary files of its code. A timed out verification keeps its worker and its key until it returned,
+// so neither the size nor the key exclusion is exceeded by verifications slow to stop.
+type WorkerPool struct {
+	Size    int
+	Timeout time.Duration
+}
+
+// PoolTask is a verification run by a WorkerPool.
+type PoolTask struct {
+	Key    string
+	Verify func(ctx context.Context) error
+}
+
+// PoolError aggregates the errors of the failed verifications of a pool run, in the order of the tasks.
+type PoolError struct {
+	Tasks int
+	Errs  []error
+}
+
+func (e PoolError) Error() string {
+	messages := make([]string, 0, len(e.Errs))
+	for _, err := range e.Errs {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("%d of %d verifications failed: %s", len(e.Errs), e.Tasks, strings.Join(messages, "; "))
+}
+
+func (p WorkerPool) size() int {
+	if p.Size < 1 {
+		return runtime.NumCPU()
+	}
+	return p.Size
+}
+
+// Run runs the tasks and returns a PoolError when any of them failed, timed out or was skipped.
+func (p WorkerPool) Run(ctx context.Context, tasks []PoolTask) error {
+	var groups [][]int
+	groupOf := map[string]int{}
+	for index, task := range tasks {
+		if group, ok := groupOf[task.Key]; ok {
+			groups[group] = append(groups[group], index)
+			continue
+		}
+		groupOf[task.Key] = len(groups)
+		groups = append(groups, []int{index})
+	}
+	queue := make(chan []int, len(groups))
+	for _, group := range groups {
+		queue <- group
+	}
+	close(queue)
+	errs := make([]error, len(tasks))
+	var wg sync.WaitGroup
+	for worker := 0; worker < p.size() && worker < len(groups); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for group := range queue {
+				for _, index := range group {
+					errs[index] = p.run(ctx, tasks[index])
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	poolErr := PoolError{Tasks: len(tasks)}
+	for _, err := range errs {
+		if err != nil {
+			poolErr.Errs = append(poolErr.Errs, err)
+		}
+	}
+	if len(poolErr.Errs) > 0 {
+		return poolErr
+	}
+	return nil
+}
+
+func (p WorkerPool) run(ctx context.Context, task PoolTask) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%s: skipped: %w", task.Key, err)
+	}
+	var err error
+	if p.Timeout > 0 {
+		err = verifyWithTimeout(ctx, p.Timeout, task.Verify)
+	} else {
+		err = task.Verify(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", task.Key, err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerPoolRespectsItsSize(t *testing.T) {
+	var active, maxActive int32
+	var tasks []PoolTask
+	for i := 0; i < 20; i++ {
+		tasks = append(tasks, PoolTask{Key: fmt.Sprintf("func-%d", i), Verify: func(ctx context.Context) error {
+			current := atomic.AddInt32(&active, 1)
+			for {
+				observed := atomic.LoadInt32(&maxActive)
+				if current <= observed || atomic.CompareAndSwapInt32(&maxActive, observed, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&active, -1)
+			return nil
+		}})
+	}
+	if err := (WorkerPool{Size: 3}).Run(context.Background(), tasks); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if maxActive != 3 {
+		t.Fatalf("expected at most and up to 3 concurrent verifications, got: %d", maxActive)
+	}
+	if size := (WorkerPool{}).size(); size != runtime.NumCPU() {
+		t.Fatalf("expected a pool of NumCPU workers by default, got: %d", size)
+	}
+}
+
+func TestWorkerPoolRunsTheTasksOfAKeyInOrder(t *testing.T) {
+	var order []int
+	var tasks []PoolTask
+	for i := 0; i < 5; i++ {
+		i := i
+		tasks = append(tasks, PoolTask{Key: "func", Verify: func(ctx context.Context) error {
+			order = append(order, i)
+			return nil
+		}})
+	}
+	if err := (WorkerPool{Size: 4}).Run(context.Background(), tasks); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fmt.Sprint(order) != "[0 1 2 3 4]" {
+		t.Fatalf("expected the verifications of the same key in order, got: %v", order)
+	}
+}
+
+func TestWorkerPoolAggregatesErrors(t *testing.T) {
+	var verified int32
+	tasks := []PoolTask{
+		{Key: "failing", Verify: func(ctx context.Context) error { return errors.New("unsigned") }},
+		{Key: "hanging", Verify: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+		{Key: "signed", Verify: func(ctx context.Context) error {
+			atomic.AddInt32(&verified, 1)
+			return nil
+		}},
+	}
+	err := (WorkerPool{Size: 2, Timeout: 50 * time.Millisecond}).Run(context.Background(), tasks)
+	var poolErr PoolError
+	if !errors.As(err, &poolErr) || poolErr.Tasks != 3 || len(poolErr.Errs) != 2 || verified != 1 {
+		t.Fatalf("expected the failure and the timeout to be aggregated without stopping the pool, got: %v, %d verified", err, verified)
+	}
+	if !strings.Contains(poolErr.Errs[0].Error(), "failing: unsigned") || !errors.Is(poolErr.Errs[1], errFunctionTimeout) {
+		t.Fatalf("expected the errors in the order of the tasks, got: %v", poolErr.Errs)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = (WorkerPool{Size: 2}).Run(ctx, tasks)
+	if !errors.As(err, &poolErr) || len(poolErr.Errs) != 3 || verified != 1 || !errors.Is(poolErr.Errs[2], context.Canceled) {
+		t.Fatalf("expected every verification to be skipped once the context is cancelled, got: %v, %d verified", err, verified)
+	}
+}
+
+func TestWorkerPoolRespectsItsSizeAndKeysOnTimeout(t *testing.T) {
+	probe := &concurrencyProbe{running: map[string]int{}, max: map[string]int{}}
+	var tasks []PoolTask
+	for i := 0; i < 8; i++ {
+		key := fmt.Sprintf("func-%d", i%4)
+		tasks = append(tasks, PoolTask{Key: key, Verify: func(ctx context.Context) error {
+			probe.enter("all")
+			probe.enter(key)
+			// keep running a while after the timeout, like a download slow to notice the cancellation
+			<-ctx.Done()
+			time.Sleep(20 * time.Millisecond)
+			probe.leave(key)
+			probe.leave("all")
+			return ctx.Err()
+		}})
+	}
+	err := (WorkerPool{Size: 2, Timeout: 5 * time.Millisecond}).Run(context.Background(), tasks)
+	var poolErr PoolError
+	if !errors.As(err, &poolErr) || len(poolErr.Errs) != len(tasks) {
+		t.Fatalf("expected every verification to time out, got: %v", err)
+	}
+	if probe.max["all"] != 2 {
+		t.Fatalf("expected at most and up to 2 concurrent verifications, got: %d", probe.max["all"])
+	}
+	for i := 0; i < 4; i++ {
+		if key := fmt.Sprintf("func-%d", i); probe.max[key] != 1 {
+			t.Fatalf("expected the verifications of %s to never overlap, got: %d at once", key, probe.max[key])
+		}
+	}
+}
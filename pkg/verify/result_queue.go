@@ -0,0 +1,150 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"golang.org/x/exp/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxQueueBatchEntries  = 10
+	maxQueueBatchBytes    = 256 * 1024
+	maxQueueRetries       = 3
+	maxQueueGroupIdLength = 128
+)
+
+var queueRetryBackoff = time.Second
+
+// PublishResults sends one message per result to the result queue, batched within the sqs limits of 10 entries and
+// 256KB per batch. Entries the queue failed to accept for a transient reason are retried with a backoff, the returned
+// error lists the results left undelivered.
+func PublishResults(client clients.Client, queue *options.ResultQueue, results []VerificationResult) error {
+	var messages []clients.QueueMessage
+	functions := map[string]string{}
+	for i, result := range results {
+		functionResult := toFunctionResult(result)
+		body, err := json.Marshal(functionResult)
+		if err != nil {
+			return fmt.Errorf("failed to marshal result of function: %s: %w", result.FunctionIdentifier, err)
+		}
+		attributes := map[string]string{}
+		for name, value := range queue.Attributes {
+			attributes[name] = value
+		}
+		attributes["result"] = result.Result
+		id := strconv.Itoa(i)
+		functions[id] = result.FunctionIdentifier
+		message := clients.QueueMessage{Id: id, Body: string(body), Attributes: attributes}
+		if queue.FIFO() {
+			// results of the same function keep their order, and a result sent again is dropped by the queue
+			message.DeduplicationId = functionResult.DeduplicationKey
+			message.GroupId = queueGroupId(result.FunctionIdentifier)
+		}
+		messages = append(messages, message)
+	}
+	var undelivered []string
+	for _, batch := range queueBatches(messages) {
+		for id, reason := range sendQueueBatch(client, queue.URL, batch) {
+			undelivered = append(undelivered, fmt.Sprintf("function: %s: %s", functions[id], reason))
+		}
+	}
+	if len(undelivered) > 0 {
+		return fmt.Errorf("failed to send %d out of %d results to queue: %s: %s", len(undelivered), len(results), queue.URL,
+			strings.Join(undelivered, ", "))
+	}
+	return nil
+}
+
+// queueGroupId groups the messages by function, identifiers longer than the 128 characters sqs allows are hashed.
+func queueGroupId(functionIdentifier string) string {
+	if len(functionIdentifier) <= maxQueueGroupIdLength {
+		return functionIdentifier
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(functionIdentifier)))
+}
+
+// queueBatches splits the messages into batches within the sqs limits, a message above the size limit on its own is
+// left to the queue to reject.
+func queueBatches(messages []clients.QueueMessage) [][]clients.QueueMessage {
+	var batches [][]clients.QueueMessage
+	var batch []clients.QueueMessage
+	size := 0
+	for _, message := range messages {
+		messageSize := queueMessageSize(message)
+		if len(batch) == maxQueueBatchEntries || (len(batch) > 0 && size+messageSize > maxQueueBatchBytes) {
+			batches = append(batches, batch)
+			batch, size = nil, 0
+		}
+		batch = append(batch, message)
+		size += messageSize
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// queueMessageSize counts the body and the attribute names, types and values, as sqs does for the size limit.
+func queueMessageSize(message clients.QueueMessage) int {
+	size := len(message.Body)
+	for name, value := range message.Attributes {
+		size += len(name) + len("String") + len(value)
+	}
+	return size
+}
+
+// sendQueueBatch returns the reason of every entry of the batch left undelivered, by entry id. Entries failing
+// because of the sender aren't retried.
+func sendQueueBatch(client clients.Client, queueUrl string, batch []clients.QueueMessage) map[string]string {
+	undelivered := map[string]string{}
+	pending := batch
+	for attempt := 0; ; attempt++ {
+		failures, err := client.SendQueueMessages(queueUrl, pending)
+		var retry []clients.QueueMessage
+		if err != nil {
+			retry = pending
+			for _, message := range pending {
+				undelivered[message.Id] = err.Error()
+			}
+		} else {
+			byId := map[string]clients.QueueMessage{}
+			for _, message := range pending {
+				byId[message.Id] = message
+				delete(undelivered, message.Id)
+			}
+			for _, failure := range failures {
+				undelivered[failure.Id] = fmt.Sprintf("%s: %s", failure.Code, failure.Message)
+				if !failure.SenderFault {
+					retry = append(retry, byId[failure.Id])
+				}
+			}
+		}
+		if len(retry) == 0 || attempt == maxQueueRetries {
+			return undelivered
+		}
+		slog.Warn("failed to send results to queue, retrying", "results", len(retry), "queue", queueUrl)
+		time.Sleep(queueRetryBackoff * time.Duration(1<<attempt))
+		pending = retry
+	}
+}
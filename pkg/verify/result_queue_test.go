@@ -0,0 +1,178 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"github.com/openclarity/function-clarity/pkg/report"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// queueClient accepts every message except the ids failing transiently, which fail that many times, and the ids
+// failing because of the sender.
+type queueClient struct {
+	clients.Client
+	transient   map[string]int
+	senderFault map[string]bool
+	callErrors  int
+	batches     [][]clients.QueueMessage
+	delivered   map[string]clients.QueueMessage
+}
+
+func (c *queueClient) SendQueueMessages(queueUrl string, messages []clients.QueueMessage) ([]clients.QueueMessageFailure, error) {
+	c.batches = append(c.batches, messages)
+	if c.callErrors > 0 {
+		c.callErrors--
+		return nil, errors.New("connection reset")
+	}
+	var failures []clients.QueueMessageFailure
+	for _, message := range messages {
+		switch {
+		case c.senderFault[message.Id]:
+			failures = append(failures, clients.QueueMessageFailure{Id: message.Id, Code: "InvalidParameterValue", SenderFault: true})
+		case c.transient[message.Id] > 0:
+			c.transient[message.Id]--
+			failures = append(failures, clients.QueueMessageFailure{Id: message.Id, Code: "InternalError"})
+		default:
+			c.delivered[message.Id] = message
+		}
+	}
+	return failures, nil
+}
+
+func queueResults(count int) []VerificationResult {
+	var results []VerificationResult
+	for i := 0; i < count; i++ {
+		results = append(results, VerificationResult{FunctionIdentifier: "arn:aws:lambda:us-east-1:123456789012:function:f" + strconv.Itoa(i),
+			Result: ResultPassed})
+	}
+	return results
+}
+
+func newQueueClient() *queueClient {
+	return &queueClient{transient: map[string]int{}, senderFault: map[string]bool{}, delivered: map[string]clients.QueueMessage{}}
+}
+
+func TestPublishResultsBatches(t *testing.T) {
+	queueRetryBackoff = 0
+	client := newQueueClient()
+	queue := &options.ResultQueue{URL: "https://sqs.us-east-1.amazonaws.com/123456789012/results", Attributes: map[string]string{"stage": "prod"}}
+	if err := PublishResults(client, queue, queueResults(25)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.batches) != 3 || len(client.batches[0]) != 10 || len(client.batches[2]) != 5 {
+		t.Fatalf("expected batches of 10, 10 and 5 messages, got: %d batches", len(client.batches))
+	}
+	message := client.delivered["24"]
+	if message.Attributes["stage"] != "prod" || message.Attributes["result"] != ResultPassed {
+		t.Fatalf("unexpected attributes: %v", message.Attributes)
+	}
+	var result report.FunctionResult
+	if err := json.Unmarshal([]byte(message.Body), &result); err != nil {
+		t.Fatalf("failed to parse message: %v", err)
+	}
+	if result.FunctionIdentifier != "arn:aws:lambda:us-east-1:123456789012:function:f24" || result.Region != "us-east-1" {
+		t.Fatalf("unexpected message: %s", message.Body)
+	}
+}
+
+func TestPublishResultsSplitsLargeBatches(t *testing.T) {
+	queueRetryBackoff = 0
+	client := newQueueClient()
+	results := queueResults(4)
+	for i := range results {
+		results[i].Reason = strings.Repeat("x", 100*1024)
+	}
+	if err := PublishResults(client, &options.ResultQueue{URL: "https://sqs.us-east-1.amazonaws.com/123456789012/results"}, results); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.batches) != 2 || len(client.batches[0]) != 2 {
+		t.Fatalf("expected 2 batches of 2 messages within 256KB, got: %d batches", len(client.batches))
+	}
+}
+
+func TestPublishResultsRetriesPartialFailures(t *testing.T) {
+	queueRetryBackoff = 0
+	client := newQueueClient()
+	client.transient["3"] = 2
+	client.callErrors = 1
+	if err := PublishResults(client, &options.ResultQueue{URL: "https://sqs.us-east-1.amazonaws.com/123456789012/results"}, queueResults(5)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.delivered) != 5 {
+		t.Fatalf("expected every result delivered, got: %d", len(client.delivered))
+	}
+	// the failed call, the partial failure of 3, and the 2 retries of 3
+	if len(client.batches) != 4 || len(client.batches[3]) != 1 || client.batches[3][0].Id != "3" {
+		t.Fatalf("expected only the failed entry to be retried, got: %d batches", len(client.batches))
+	}
+}
+
+func TestPublishResultsReportsUndelivered(t *testing.T) {
+	queueRetryBackoff = 0
+	client := newQueueClient()
+	client.senderFault["1"] = true
+	client.transient["2"] = maxQueueRetries + 1
+	err := PublishResults(client, &options.ResultQueue{URL: "https://sqs.us-east-1.amazonaws.com/123456789012/results"}, queueResults(4))
+	if err == nil || !strings.Contains(err.Error(), "failed to send 2 out of 4 results") ||
+		!strings.Contains(err.Error(), "function:f1: InvalidParameterValue") || !strings.Contains(err.Error(), "function:f2: InternalError") {
+		t.Fatalf("expected the undelivered results to be reported, got: %v", err)
+	}
+	if len(client.delivered) != 2 {
+		t.Fatalf("expected the other results delivered, got: %d", len(client.delivered))
+	}
+	if len(client.batches) != maxQueueRetries+1 {
+		t.Fatalf("expected %d attempts, got: %d", maxQueueRetries+1, len(client.batches))
+	}
+}
+
+func TestPublishResultsToFifoQueue(t *testing.T) {
+	queueRetryBackoff = 0
+	client := newQueueClient()
+	results := queueResults(2)
+	for i := range results {
+		results[i].Digest = "code-sha256"
+		results[i].ScanID = "scan-1"
+	}
+	if err := PublishResults(client, &options.ResultQueue{URL: "https://sqs.us-east-1.amazonaws.com/123456789012/results.fifo"}, results); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	message := client.delivered["1"]
+	key := report.DeduplicationKey(results[1].FunctionIdentifier, "code-sha256", "scan-1")
+	if message.DeduplicationId != key || message.GroupId != results[1].FunctionIdentifier {
+		t.Fatalf("expected the deduplication key and the function as group, got: %+v", message)
+	}
+	var result report.FunctionResult
+	if err := json.Unmarshal([]byte(message.Body), &result); err != nil {
+		t.Fatalf("failed to parse message: %v", err)
+	}
+	if result.DeduplicationKey != key || result.ScanID != "scan-1" || result.Digest != "code-sha256" {
+		t.Fatalf("expected the deduplication key in the message, got: %s", message.Body)
+	}
+
+	client = newQueueClient()
+	if err := PublishResults(client, &options.ResultQueue{URL: "https://sqs.us-east-1.amazonaws.com/123456789012/results"}, results); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if message = client.delivered["1"]; message.DeduplicationId != "" || message.GroupId != "" {
+		t.Fatalf("standard queues take no deduplication or group id, got: %+v", message)
+	}
+}
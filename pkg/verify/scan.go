@@ -0,0 +1,359 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/google/uuid"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"github.com/openclarity/function-clarity/pkg/report"
+	"golang.org/x/exp/slog"
+	"sync"
+	"time"
+)
+
+const (
+	ResultPassed = "passed"
+	ResultFailed = "failed"
+	ResultError  = "error"
+	// ResultUntrustedSigner marks a function failing verification whose code matches a signature made by an
+	// untrusted key or identity, as opposed to unsigned code.
+	ResultUntrustedSigner = "untrusted-signer"
+	// ResultAwsCodeSigningMissing marks a function passing the signature verification that doesn't have an enforced
+	// AWS code signing config, with --require-aws-code-signing.
+	ResultAwsCodeSigningMissing = "aws-code-signing-missing"
+	// ResultUnsignedLayer marks a function whose code verified with an attached layer that isn't signed, with
+	// --require-signed-layers.
+	ResultUnsignedLayer = "unsigned-layer"
+	// ResultImageDigestUnpinned marks an image function referencing its image by tag, with --require-image-digest-pin.
+	ResultImageDigestUnpinned = "image-digest-unpinned"
+	// ResultTimedOut marks a function whose verification was abandoned after the function timeout, it was skipped
+	// and neither passed nor failed.
+	ResultTimedOut = "timed-out"
+
+	maxThrottledRetries = 2
+)
+
+// The actions applied to a function that failed verification, recorded in its result.
+const (
+	ActionDetect = "detect"
+	ActionBlock  = "block"
+	// ActionWouldBlock marks a function the block action didn't block because of --dry-run.
+	ActionWouldBlock = "would-block"
+)
+
+// The strictness of an audit of every function, see ScanSummary.Fails.
+const (
+	FailOnUnsigned = "unsigned"
+	FailOnError    = "error"
+	FailOnNever    = "never"
+)
+
+type VerificationResult struct {
+	FunctionIdentifier string
+	Result             string
+	Reason             string
+	Signer             string
+	Duration           time.Duration
+	SigningIdentity    *report.SigningIdentity
+	// Digest is the sha256 of the function code, it is part of the deduplication key of the result with the ScanID.
+	Digest string
+	ScanID string
+	// Action is the action applied to the function when it failed verification, empty when none was.
+	Action string
+}
+
+type ScanSummary struct {
+	Results []VerificationResult
+	// Scopes are the accounts and regions scanned, they are only set by multi scope scans.
+	Scopes             []report.Scope
+	InaccessibleScopes []report.InaccessibleScope
+}
+
+// Count returns the number of functions with the given result.
+func (s ScanSummary) Count(result string) int {
+	count := 0
+	for _, r := range s.Results {
+		if r.Result == result {
+			count++
+		}
+	}
+	return count
+}
+
+// Fails tells whether the scan fails given its strictness: unsigned fails when any function didn't pass verification,
+// error only when the verification of a function errored or timed out, and never doesn't fail.
+func (s ScanSummary) Fails(failOn string) (bool, error) {
+	switch failOn {
+	case FailOnUnsigned:
+		return len(s.Results) != s.Count(ResultPassed), nil
+	case FailOnError:
+		return s.Count(ResultError)+s.Count(ResultTimedOut) > 0, nil
+	case FailOnNever:
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid fail-on: %s, expected %s, %s or %s", failOn, FailOnUnsigned, FailOnError, FailOnNever)
+}
+
+// AuditFunctions verifies the functions in scope of the region and tag filters on demand like VerifyOnDemand,
+// --parallelism of them at once: no action is taken and no notification or result is sent. The functions out of
+// scope are left out of the summary.
+func AuditFunctions(client clients.Client, functions []clients.FunctionConfig, o *options.VerifyOpts, so *options.ScanOptions,
+	ctx context.Context, tagKeysFilter []string, filteredRegions []string) (ScanSummary, error) {
+	var inScope []clients.FunctionConfig
+	for _, function := range functions {
+		in, err := isFuncInScope(client, function.FunctionArn, o, tagKeysFilter, filteredRegions)
+		if err != nil {
+			return ScanSummary{}, err
+		}
+		if in {
+			inScope = append(inScope, function)
+		}
+	}
+	summary := verifyFunctions(inScope, so, ctx, func(ctx context.Context, function clients.FunctionConfig) (*report.SigningIdentity, error) {
+		return VerifyOnDemand(client, function.FunctionArn, o, ctx)
+	})
+	digests := map[string]string{}
+	for _, function := range inScope {
+		digests[function.FunctionArn] = function.CodeSha256
+	}
+	for i := range summary.Results {
+		summary.Results[i].Digest = digests[summary.Results[i].FunctionIdentifier]
+	}
+	return summary, nil
+}
+
+// VerifyFunctions verifies the functions, --parallelism of them at once, and records the result of each in listing
+// order. A function failing verification, erroring or timing out doesn't stop the others. Functions not yet verified
+// when the context is done are left out. The results are sent to the result queue, when set. Every result is
+// identified by the scan id of the options, or by a scan id generated for the scan.
+func VerifyFunctions(client clients.Client, functions []clients.FunctionConfig, o *options.VerifyOpts, so *options.ScanOptions,
+	ctx context.Context, action string, topicArn string, tagKeysFilter []string, filteredRegions []string) ScanSummary {
+	// the results are sent to the result queue in batches once the scan is done, instead of one by one
+	perFunction := *o
+	perFunction.ResultQueue = options.ResultQueue{}
+	if perFunction.ScanID == "" {
+		perFunction.ScanID = uuid.NewString()
+	}
+	var appliedMu sync.Mutex
+	applied := map[string]string{}
+	summary := verifyFunctions(functions, so, ctx, func(ctx context.Context, function clients.FunctionConfig) (*report.SigningIdentity, error) {
+		signingIdentity, taken, err := verifyAndHandle(client, function.FunctionArn, &perFunction, ctx, action, topicArn, tagKeysFilter, filteredRegions)
+		appliedMu.Lock()
+		applied[function.FunctionArn] = taken
+		appliedMu.Unlock()
+		return signingIdentity, err
+	})
+	digests := map[string]string{}
+	for _, function := range functions {
+		digests[function.FunctionArn] = function.CodeSha256
+	}
+	for i := range summary.Results {
+		summary.Results[i].Digest = digests[summary.Results[i].FunctionIdentifier]
+		summary.Results[i].ScanID = perFunction.ScanID
+		summary.Results[i].Action = applied[summary.Results[i].FunctionIdentifier]
+	}
+	if o.ResultQueue.Enabled() {
+		if err := PublishResults(client, &o.ResultQueue, summary.Results); err != nil {
+			slog.Error("failed to publish the verification results", err)
+		}
+	}
+	return summary
+}
+
+type functionVerifier func(ctx context.Context, function clients.FunctionConfig) (*report.SigningIdentity, error)
+
+func verifyFunctions(functions []clients.FunctionConfig, so *options.ScanOptions, ctx context.Context, verifyFunc functionVerifier) ScanSummary {
+	level, auto, err := so.ParallelismLevel()
+	if err != nil {
+		slog.Warn("invalid parallelism, verifying one function at a time", "error", err)
+		level, auto = 1, false
+	}
+	limiter := newConcurrencyLimiter(level, auto, len(functions))
+	groups := make(chan []int)
+	go func() {
+		defer close(groups)
+		for _, group := range groupBySharedCode(functions) {
+			select {
+			case groups <- group:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	results := make([]*VerificationResult, len(functions))
+	var wg sync.WaitGroup
+	for worker := 0; worker < limiter.max; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for group := range groups {
+				for _, index := range group {
+					results[index] = verifyFunction(ctx, functions[index], so, limiter, verifyFunc)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if auto {
+		slog.Info("parallelism auto: scan done", "concurrentVerifications", limiter.currentLimit())
+	}
+	summary := ScanSummary{}
+	for _, result := range results {
+		if result != nil {
+			summary.Results = append(summary.Results, *result)
+		}
+	}
+	return summary
+}
+
+// verifyFunction returns nil when the context is done before the verification starts. In auto parallelism a
+// throttled verification is retried once the limiter lowered the parallelism.
+func verifyFunction(ctx context.Context, function clients.FunctionConfig, so *options.ScanOptions, limiter *concurrencyLimiter,
+	verifyFunc functionVerifier) *VerificationResult {
+	timeout := so.TimeoutFor(function.PackageType, function.CodeSize)
+	for attempt := 0; ; attempt++ {
+		limiter.acquire()
+		if ctx.Err() != nil {
+			limiter.release(false)
+			return nil
+		}
+		start := time.Now()
+		var signingIdentity *report.SigningIdentity
+		err := verifyWithTimeout(ctx, timeout, func(ctx context.Context) error {
+			identity, err := verifyFunc(ctx, function)
+			signingIdentity = identity
+			return err
+		})
+		throttled := isThrottling(err)
+		limiter.release(throttled)
+		if throttled && limiter.auto && attempt < maxThrottledRetries && ctx.Err() == nil {
+			slog.Warn("verification of function was throttled, retrying", "function", function.FunctionArn)
+			continue
+		}
+		if errors.Is(err, errFunctionTimeout) {
+			slog.Warn("verification of function timed out, skipping", "function", function.FunctionArn, "timeout", timeout)
+			return &VerificationResult{FunctionIdentifier: function.FunctionArn, Result: ResultTimedOut, Duration: time.Since(start),
+				Reason: fmt.Sprintf("verification didn't finish within %s, skipped", timeout)}
+		}
+		result := newVerificationResult(function.FunctionArn, err, signingIdentity, time.Since(start))
+		return &result
+	}
+}
+
+// newVerificationResult classifies the outcome of a finished verification.
+func newVerificationResult(functionIdentifier string, err error, signingIdentity *report.SigningIdentity, duration time.Duration) VerificationResult {
+	result := VerificationResult{FunctionIdentifier: functionIdentifier, Result: ResultPassed, Duration: duration}
+	switch {
+	case errors.Is(err, UntrustedSignerError{}):
+		var untrusted UntrustedSignerError
+		errors.As(err, &untrusted)
+		result.Result = ResultUntrustedSigner
+		result.Reason = err.Error()
+		result.Signer = untrusted.Signer
+	case errors.Is(err, AwsCodeSigningError{}):
+		result.Result = ResultAwsCodeSigningMissing
+		result.Reason = err.Error()
+	case errors.Is(err, UnsignedLayerError{}):
+		result.Result = ResultUnsignedLayer
+		result.Reason = err.Error()
+	case errors.Is(err, ImageDigestPinError{}):
+		result.Result = ResultImageDigestUnpinned
+		result.Reason = err.Error()
+	case errors.Is(err, VerifyError{}):
+		result.Result = ResultFailed
+		result.Reason = err.Error()
+	case err != nil:
+		result.Result = ResultError
+		result.Reason = err.Error()
+	default:
+		result.SigningIdentity = signingIdentity
+	}
+	return result
+}
+
+// WriteScanResults writes the results of the scan to dir, partitioned by region or account, along with the
+// inaccessible scopes of the scan.
+func WriteScanResults(summary ScanSummary, dir string, partitionBy string) error {
+	var results []report.FunctionResult
+	for _, r := range summary.Results {
+		results = append(results, toFunctionResult(r))
+	}
+	generatedAt := time.Now()
+	if err := report.WritePartitionedResults(dir, partitionBy, results, generatedAt); err != nil {
+		return fmt.Errorf("failed to write scan results: %w", err)
+	}
+	if len(summary.Scopes) > 0 {
+		if err := report.WriteInaccessibleScopes(dir, summary.Scopes, summary.InaccessibleScopes, generatedAt); err != nil {
+			return fmt.Errorf("failed to write inaccessible scopes: %w", err)
+		}
+	}
+	return nil
+}
+
+// Report converts the result to the machine-readable result printed by verify with --output json.
+func (r VerificationResult) Report(annotations map[string]string, timestamp time.Time) report.VerificationResult {
+	result := report.VerificationResult{
+		FunctionArn:    r.FunctionIdentifier,
+		Status:         r.Result,
+		Reason:         r.Reason,
+		Signer:         r.Signer,
+		SignerIdentity: r.SigningIdentity,
+		Digest:         r.Digest,
+		Annotations:    annotations,
+		Timestamp:      timestamp.UTC(),
+	}
+	if parsed, err := arn.Parse(r.FunctionIdentifier); err == nil {
+		result.Region = parsed.Region
+	}
+	return result
+}
+
+func toFunctionResult(r VerificationResult) report.FunctionResult {
+	result := report.NewFunctionResult(r.FunctionIdentifier, r.Result, r.Reason, r.Duration)
+	result.Signer = r.Signer
+	result.SigningIdentity = r.SigningIdentity
+	result.Digest = r.Digest
+	result.ScanID = r.ScanID
+	result.Action = r.Action
+	if r.ScanID != "" {
+		result.DeduplicationKey = report.DeduplicationKey(r.FunctionIdentifier, r.Digest, r.ScanID)
+	}
+	return result
+}
+
+var errFunctionTimeout = errors.New("function verification timed out")
+
+// verifyWithTimeout cancels the verification once the timeout passes, and reports it timed out only once it returned:
+// the aws calls and downloads of the verification stop on the context, and a verification whose context is done
+// doesn't act on the function. So a timed out verification neither acts on a function reported as timed out, nor
+// keeps using the files of its code once its slot is given to the next one.
+func verifyWithTimeout(ctx context.Context, timeout time.Duration, verifyFunc func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := verifyFunc(ctx)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		if errors.Is(ctxErr, context.DeadlineExceeded) {
+			return errFunctionTimeout
+		}
+		return ctxErr
+	}
+	return err
+}
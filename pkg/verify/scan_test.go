@@ -0,0 +1,262 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aws/smithy-go"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"github.com/openclarity/function-clarity/pkg/report"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestVerifyWithTimeout(t *testing.T) {
+	var returned int32
+	start := time.Now()
+	err := verifyWithTimeout(context.Background(), 50*time.Millisecond, func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+		}
+		time.Sleep(50 * time.Millisecond)
+		atomic.StoreInt32(&returned, 1)
+		return ctx.Err()
+	})
+	if !errors.Is(err, errFunctionTimeout) {
+		t.Fatalf("expected timeout, got: %v", err)
+	}
+	if atomic.LoadInt32(&returned) != 1 {
+		t.Fatalf("expected the timeout to be reported once the verification returned")
+	}
+	if time.Since(start) >= time.Second {
+		t.Fatalf("expected the verification to be cancelled on the timeout, took: %s", time.Since(start))
+	}
+
+	verifyErr := VerifyError{Err: errors.New("not signed")}
+	err = verifyWithTimeout(context.Background(), time.Second, func(ctx context.Context) error {
+		return verifyErr
+	})
+	if !errors.Is(err, VerifyError{}) {
+		t.Fatalf("expected the verification result, got: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = verifyWithTimeout(ctx, time.Second, func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation to be reported as is, got: %v", err)
+	}
+}
+
+func testFunctions(count int) []clients.FunctionConfig {
+	var functions []clients.FunctionConfig
+	for i := 0; i < count; i++ {
+		functions = append(functions, clients.FunctionConfig{FunctionArn: fmt.Sprintf("function-%d", i), PackageType: "Zip", CodeSha256: fmt.Sprintf("sha-%d", i)})
+	}
+	return functions
+}
+
+// concurrencyProbe records the highest number of verifications running at once, per key.
+type concurrencyProbe struct {
+	mu      sync.Mutex
+	running map[string]int
+	max     map[string]int
+}
+
+func (p *concurrencyProbe) enter(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running[key]++
+	if p.running[key] > p.max[key] {
+		p.max[key] = p.running[key]
+	}
+}
+
+func (p *concurrencyProbe) leave(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running[key]--
+}
+
+func TestVerifyFunctionsParallelism(t *testing.T) {
+	probe := &concurrencyProbe{running: map[string]int{}, max: map[string]int{}}
+	functions := testFunctions(12)
+	// functions sharing code are never verified concurrently
+	functions[1].CodeSha256 = "shared"
+	functions[2].CodeSha256 = "shared"
+	functions[3].CodeSha256 = "shared"
+	summary := verifyFunctions(functions, &options.ScanOptions{Parallelism: "3"}, context.Background(),
+		func(ctx context.Context, function clients.FunctionConfig) (*report.SigningIdentity, error) {
+			probe.enter("all")
+			probe.enter(function.CodeSha256)
+			time.Sleep(20 * time.Millisecond)
+			probe.leave(function.CodeSha256)
+			probe.leave("all")
+			return nil, nil
+		})
+	if probe.max["all"] < 2 || probe.max["all"] > 3 {
+		t.Fatalf("expected up to 3 concurrent verifications, got: %d", probe.max["all"])
+	}
+	if probe.max["shared"] != 1 {
+		t.Fatalf("expected functions sharing code to be verified one at a time, got: %d", probe.max["shared"])
+	}
+	if len(summary.Results) != len(functions) {
+		t.Fatalf("expected %d results, got: %d", len(functions), len(summary.Results))
+	}
+	for i, result := range summary.Results {
+		if result.FunctionIdentifier != functions[i].FunctionArn || result.Result != ResultPassed {
+			t.Fatalf("expected results in listing order, got: %+v at %d", result, i)
+		}
+	}
+}
+
+func TestVerifyFunctionsReportsMissingAwsCodeSigning(t *testing.T) {
+	functions := testFunctions(2)
+	summary := verifyFunctions(functions, &options.ScanOptions{Parallelism: "1"}, context.Background(),
+		func(ctx context.Context, function clients.FunctionConfig) (*report.SigningIdentity, error) {
+			if function.FunctionArn == functions[1].FunctionArn {
+				return nil, AwsCodeSigningError{Err: errors.New("no code signing config attached")}
+			}
+			return nil, nil
+		})
+	if summary.Count(ResultPassed) != 1 || summary.Count(ResultAwsCodeSigningMissing) != 1 || summary.Count(ResultFailed) != 0 {
+		t.Fatalf("expected one passed and one missing aws code signing result, got: %+v", summary.Results)
+	}
+}
+
+func TestScanSummaryFails(t *testing.T) {
+	summary := ScanSummary{Results: []VerificationResult{{Result: ResultPassed}, {Result: ResultFailed}}}
+	tests := []struct {
+		failOn string
+		errors bool
+		fails  bool
+	}{
+		{failOn: FailOnUnsigned, fails: true},
+		{failOn: FailOnError},
+		{failOn: FailOnError, errors: true, fails: true},
+		{failOn: FailOnNever, errors: true},
+	}
+	for _, test := range tests {
+		summary := summary
+		if test.errors {
+			summary.Results = append(summary.Results, VerificationResult{Result: ResultTimedOut})
+		}
+		fails, err := summary.Fails(test.failOn)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if fails != test.fails {
+			t.Errorf("fail on: %s, errors: %t, expected fails: %t, got: %t", test.failOn, test.errors, test.fails, fails)
+		}
+	}
+	if _, err := summary.Fails("always"); err == nil {
+		t.Fatalf("expected an invalid fail-on error")
+	}
+}
+
+func TestAuditFunctionsLeavesOutFunctionsOutOfScope(t *testing.T) {
+	// verifying a function would panic, the client doesn't resolve package types
+	client := &taggedClient{tags: map[string]string{"fc-exclude": ""}}
+	o := &options.VerifyOpts{ExcludedFuncTagKeys: []string{"fc-exclude"}}
+	summary, err := AuditFunctions(client, testFunctions(3), o, &options.ScanOptions{Parallelism: "2"}, context.Background(), nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(summary.Results) != 0 {
+		t.Fatalf("expected every function to be left out, got: %+v", summary.Results)
+	}
+}
+
+func TestVerifyFunctionsAutoParallelismRetriesThrottled(t *testing.T) {
+	var mu sync.Mutex
+	throttled := map[string]bool{}
+	summary := verifyFunctions(testFunctions(100), &options.ScanOptions{Parallelism: options.ParallelismAuto}, context.Background(),
+		func(ctx context.Context, function clients.FunctionConfig) (*report.SigningIdentity, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if !throttled[function.FunctionArn] && len(throttled) < 10 {
+				throttled[function.FunctionArn] = true
+				return nil, fmt.Errorf("failed to get function: %w", &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"})
+			}
+			return nil, nil
+		})
+	if summary.Count(ResultPassed) != 100 {
+		t.Fatalf("expected throttled verifications to be retried, got: %+v", summary.Results)
+	}
+}
+
+func TestConcurrencyLimiterAuto(t *testing.T) {
+	limiter := newConcurrencyLimiter(0, true, 100)
+	if limiter.currentLimit() != 4 || limiter.max != 32 {
+		t.Fatalf("expected to start with 4 workers up to 32, got: %d up to %d", limiter.currentLimit(), limiter.max)
+	}
+	limiter.acquire()
+	limiter.release(true)
+	if limiter.currentLimit() != 2 {
+		t.Fatalf("expected throttling to halve the parallelism, got: %d", limiter.currentLimit())
+	}
+	for i := 0; i < 2; i++ {
+		limiter.acquire()
+		limiter.release(false)
+	}
+	if limiter.currentLimit() != 3 {
+		t.Fatalf("expected successes to raise the parallelism, got: %d", limiter.currentLimit())
+	}
+	if small := newConcurrencyLimiter(0, true, 3); small.currentLimit() != 1 || small.max != 3 {
+		t.Fatalf("expected a small scan to start with 1 worker up to 3, got: %d up to %d", small.currentLimit(), small.max)
+	}
+}
+
+func TestIsThrottling(t *testing.T) {
+	if !isThrottling(fmt.Errorf("wrapped: %w", &smithy.GenericAPIError{Code: "TooManyRequestsException"})) {
+		t.Fatalf("expected an api throttling error")
+	}
+	if !isThrottling(fmt.Errorf("failed to fetch func tags. %v", errors.New("api error ThrottlingException: Rate exceeded"))) {
+		t.Fatalf("expected a throttling error wrapped without its chain")
+	}
+	if isThrottling(VerifyError{Err: errors.New("not signed")}) || isThrottling(nil) {
+		t.Fatalf("expected no throttling")
+	}
+}
+
+func TestVerificationResultReport(t *testing.T) {
+	timestamp := time.Date(2022, 11, 1, 10, 0, 0, 0, time.FixedZone("CET", 3600))
+	result := VerificationResult{FunctionIdentifier: "arn:aws:lambda:us-east-1:123456789012:function:func", Result: ResultFailed,
+		Reason: "not signed", Digest: "abc"}
+	reported := result.Report(map[string]string{"team": "payments"}, timestamp)
+	if reported.FunctionArn != result.FunctionIdentifier || reported.Region != "us-east-1" || reported.Status != ResultFailed ||
+		reported.Reason != "not signed" || reported.Digest != "abc" || reported.Annotations["team"] != "payments" {
+		t.Fatalf("unexpected report: %+v", reported)
+	}
+	if !reported.Timestamp.Equal(timestamp) || reported.Timestamp.Location() != time.UTC {
+		t.Fatalf("expected the timestamp in utc, got: %s", reported.Timestamp)
+	}
+
+	reported = VerificationResult{FunctionIdentifier: "func", Result: ResultPassed}.Report(nil, timestamp)
+	if reported.Region != "" {
+		t.Fatalf("expected no region for a function name, got: %s", reported.Region)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"fmt"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/report"
+	"golang.org/x/exp/slog"
+)
+
+// Scope is an account and region of a scan, with the client reaching its functions.
+type Scope struct {
+	report.Scope
+	Client clients.Client
+}
+
+// InaccessibleScopeError fails a scan finding an inaccessible scope with --on-inaccessible-scope fail.
+type InaccessibleScopeError struct {
+	Scope string
+	Err   error
+}
+
+func (e InaccessibleScopeError) Error() string {
+	return fmt.Sprintf("%s is inaccessible: %v", e.Scope, e.Err)
+}
+
+func (e InaccessibleScopeError) Unwrap() error {
+	return e.Err
+}
+
+type scopeVerifier func(ctx context.Context, scope Scope, functions []clients.FunctionConfig) ScanSummary
+
+// ScanScopes lists the functions of every scope before verifying any, so an inaccessible scope fails the scan before
+// any verification when failOnInaccessible is set. Otherwise the inaccessible scopes are recorded in the summary with
+// the error listing their functions, and the functions of the other scopes are verified.
+func ScanScopes(ctx context.Context, scopes []Scope, failOnInaccessible bool, verifyScope scopeVerifier) (ScanSummary, error) {
+	summary := ScanSummary{}
+	functions := make([][]clients.FunctionConfig, len(scopes))
+	accessible := make([]bool, len(scopes))
+	for index, scope := range scopes {
+		scopeFunctions, err := scope.Client.ListAllFunctions(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ScanSummary{}, ctx.Err()
+			}
+			if failOnInaccessible {
+				return ScanSummary{}, InaccessibleScopeError{Scope: scope.String(), Err: err}
+			}
+			slog.Warn("scope is inaccessible, skipping", "scope", scope.String(), "error", err)
+			summary.InaccessibleScopes = append(summary.InaccessibleScopes, report.InaccessibleScope{Scope: scope.Scope, Reason: err.Error()})
+		} else {
+			functions[index] = scopeFunctions
+			accessible[index] = true
+		}
+		summary.Scopes = append(summary.Scopes, scope.Scope)
+	}
+	for index, scope := range scopes {
+		if !accessible[index] {
+			continue
+		}
+		summary.Results = append(summary.Results, verifyScope(ctx, scope, functions[index]).Results...)
+	}
+	return summary, nil
+}
@@ -0,0 +1,83 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"errors"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/report"
+	"testing"
+)
+
+type scopeClient struct {
+	clients.Client
+	functions []clients.FunctionConfig
+	err       error
+}
+
+func (c *scopeClient) ListAllFunctions(ctx context.Context) ([]clients.FunctionConfig, error) {
+	return c.functions, c.err
+}
+
+func testScopes() []Scope {
+	denied := errors.New("AccessDenied: not authorized to perform sts:AssumeRole")
+	return []Scope{
+		{Scope: report.Scope{Region: "us-east-1"}, Client: &scopeClient{functions: testFunctions(2)}},
+		{Scope: report.Scope{Role: "arn:aws:iam::222222222222:role/scanner", Region: "us-east-1"}, Client: &scopeClient{err: denied}},
+		{Scope: report.Scope{Region: "eu-west-1"}, Client: &scopeClient{functions: testFunctions(1)}},
+	}
+}
+
+func TestScanScopesSkipsInaccessibleScopes(t *testing.T) {
+	var verified []string
+	summary, err := ScanScopes(context.Background(), testScopes(), false, func(ctx context.Context, scope Scope, functions []clients.FunctionConfig) ScanSummary {
+		verified = append(verified, scope.Region)
+		s := ScanSummary{}
+		for _, function := range functions {
+			s.Results = append(s.Results, VerificationResult{FunctionIdentifier: function.FunctionArn, Result: ResultPassed})
+		}
+		return s
+	})
+	if err != nil {
+		t.Fatalf("inaccessible scopes should be skipped, got: %v", err)
+	}
+	if len(verified) != 2 || len(summary.Results) != 3 {
+		t.Fatalf("expected the 3 functions of the 2 accessible scopes verified, got scopes: %v results: %+v", verified, summary.Results)
+	}
+	if len(summary.Scopes) != 3 {
+		t.Fatalf("expected every scope recorded as scanned, got: %+v", summary.Scopes)
+	}
+	if len(summary.InaccessibleScopes) != 1 || summary.InaccessibleScopes[0].Role != "arn:aws:iam::222222222222:role/scanner" ||
+		summary.InaccessibleScopes[0].Reason == "" {
+		t.Fatalf("expected the scope of the denied role reported with its error, got: %+v", summary.InaccessibleScopes)
+	}
+}
+
+func TestScanScopesFailFast(t *testing.T) {
+	verified := 0
+	_, err := ScanScopes(context.Background(), testScopes(), true, func(ctx context.Context, scope Scope, functions []clients.FunctionConfig) ScanSummary {
+		verified++
+		return ScanSummary{}
+	})
+	var inaccessible InaccessibleScopeError
+	if !errors.As(err, &inaccessible) || inaccessible.Scope != "role: arn:aws:iam::222222222222:role/scanner region: us-east-1" {
+		t.Fatalf("expected the scan to fail on the inaccessible scope, got: %v", err)
+	}
+	if verified != 0 {
+		t.Fatalf("expected no function verified before failing, %d scopes were verified", verified)
+	}
+}
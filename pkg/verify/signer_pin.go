@@ -0,0 +1,119 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"fmt"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/integrity"
+	"github.com/openclarity/function-clarity/pkg/metadata"
+	"github.com/openclarity/function-clarity/pkg/options"
+	sigs "github.com/sigstore/cosign/pkg/signature"
+	"golang.org/x/exp/slog"
+	"strings"
+)
+
+// verifySignerPin fails verification when the function verified with a different signer than the one pinned on its
+// first verification, a valid signature made with another or weaker key is reported instead of accepted.
+// The first verification of a function records its signer.
+func verifySignerPin(client clients.Client, functionIdentifier string, functionIdentity string, o *options.VerifyOpts,
+	ctx context.Context, isKeyless bool) error {
+	current, err := resolveSigner(functionIdentity, o, ctx, isKeyless)
+	if err != nil {
+		return fmt.Errorf("verify signer pin: failed to resolve signer of function: %s: %w", functionIdentifier, err)
+	}
+	pinName, err := signerPinName(client, functionIdentifier)
+	if err != nil {
+		return fmt.Errorf("verify signer pin: %w", err)
+	}
+	recorded, err := downloadSignerPin(client, pinName)
+	if err != nil {
+		return fmt.Errorf("verify signer pin: failed to get signer pin of function: %s: %w", functionIdentifier, err)
+	}
+	if recorded == nil {
+		content, err := current.Marshal()
+		if err != nil {
+			return err
+		}
+		if err = client.UploadFile(string(content), pinName, metadata.SignerPinFileType); err != nil {
+			return fmt.Errorf("verify signer pin: failed to record signer pin of function: %s: %w", functionIdentifier, err)
+		}
+		slog.Info("pinned signer of function", "function", functionIdentifier, "signer", current)
+		return nil
+	}
+	if !recorded.Equal(current) {
+		return VerifyError{Err: fmt.Errorf("unexpected signer for function: %s, pinned signer: {%s}, current signer: {%s}",
+			functionIdentifier, recorded, current)}
+	}
+	return nil
+}
+
+// resolveSigner returns the signer the identity was verified with: the configured public key, or the certificate
+// stored with the signature in keyless mode.
+func resolveSigner(functionIdentity string, o *options.VerifyOpts, ctx context.Context, isKeyless bool) (*metadata.SignerPin, error) {
+	if isKeyless {
+		certs, err := readSigningCertificates(functionIdentity)
+		if err != nil {
+			return nil, err
+		}
+		return metadata.PinForCertificate(certs[0])
+	}
+	if o.Key == "" {
+		return nil, fmt.Errorf("signer pinning requires a public key or keyless verification")
+	}
+	verifier, err := sigs.PublicKeyFromKeyRef(ctx, o.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load public key: %w", err)
+	}
+	pub, err := verifier.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	return metadata.PinForPublicKey(pub)
+}
+
+// signerPinName names the pin after the function arn, so functions with the same name in several regions or
+// accounts are pinned separately.
+func signerPinName(client clients.Client, functionIdentifier string) (string, error) {
+	functionArn, err := resolveFunctionArn(client, functionIdentifier)
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(functionArn, ":", "_"), nil
+}
+
+func resolveFunctionArn(client clients.Client, functionIdentifier string) (string, error) {
+	details := clients.Notification{}
+	if err := client.FillNotificationDetails(&details, functionIdentifier); err != nil {
+		return "", fmt.Errorf("failed to resolve arn of function: %s: %w", functionIdentifier, err)
+	}
+	return details.FunctionIdentifier, nil
+}
+
+func downloadSignerPin(client clients.Client, pinName string) (*metadata.SignerPin, error) {
+	if err := client.Download(pinName, metadata.SignerPinFileType); err != nil {
+		if clients.IsObjectNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	content, err := integrity.ReadFile("/tmp/" + pinName + "." + metadata.SignerPinFileType)
+	if err != nil {
+		return nil, err
+	}
+	return metadata.UnmarshalSignerPin(content)
+}
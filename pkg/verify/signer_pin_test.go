@@ -0,0 +1,94 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type pinClient struct {
+	clients.Client
+	files map[string]string
+}
+
+func (c *pinClient) FillNotificationDetails(notification *clients.Notification, functionIdentifier string) error {
+	notification.FunctionIdentifier = "arn:aws:lambda:us-east-1:123456789012:function:" + functionIdentifier
+	return nil
+}
+
+func (c *pinClient) UploadFile(content string, fileName string, outputType string) error {
+	c.files[fileName+"."+outputType] = content
+	return nil
+}
+
+func (c *pinClient) Download(fileName string, outputType string) error {
+	content, ok := c.files[fileName+"."+outputType]
+	if !ok {
+		return &s3types.NoSuchKey{}
+	}
+	return os.WriteFile("/tmp/"+fileName+"."+outputType, []byte(content), 0600)
+}
+
+func writePublicKey(t *testing.T, curve elliptic.Curve) string {
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	pemBytes, err := cryptoutils.MarshalPublicKeyToPEM(key.Public())
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "cosign.pub")
+	if err = os.WriteFile(path, pemBytes, 0600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	return path
+}
+
+func TestVerifySignerPin(t *testing.T) {
+	client := &pinClient{files: map[string]string{}}
+	function := "pinned-function-test"
+	pinned := writePublicKey(t, elliptic.P384())
+	defer os.Remove("/tmp/arn_aws_lambda_us-east-1_123456789012_function_" + function + ".pin.json")
+
+	o := &options.VerifyOpts{}
+	o.Key = pinned
+	if err := verifySignerPin(client, function, "", o, context.Background(), false); err != nil {
+		t.Fatalf("first verification should pin the signer: %v", err)
+	}
+	if len(client.files) != 1 {
+		t.Fatalf("expected the signer to be pinned, got files: %v", client.files)
+	}
+	if err := verifySignerPin(client, function, "", o, context.Background(), false); err != nil {
+		t.Fatalf("verification with the pinned signer should pass: %v", err)
+	}
+
+	o.Key = writePublicKey(t, elliptic.P256())
+	if err := verifySignerPin(client, function, "", o, context.Background(), false); !errors.Is(err, VerifyError{}) {
+		t.Fatalf("expected verification error for a different signer, got: %v", err)
+	}
+}
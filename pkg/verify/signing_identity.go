@@ -0,0 +1,119 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"crypto/x509"
+	b64 "encoding/base64"
+	"fmt"
+	"github.com/openclarity/function-clarity/pkg/integrity"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"github.com/openclarity/function-clarity/pkg/report"
+	"github.com/sigstore/cosign/cmd/cosign/cli/fulcio"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"golang.org/x/exp/slog"
+	"os"
+	"strings"
+)
+
+// describeSigningIdentity reports the claims and certificate chain of the keyless signature of the identity, the
+// signature is expected to be verified already. When the chain can't be rebuilt only the leaf certificate is reported.
+func describeSigningIdentity(functionIdentity string, o *options.VerifyOpts) (*report.SigningIdentity, error) {
+	certs, err := readSigningCertificates(functionIdentity)
+	if err != nil {
+		return nil, err
+	}
+	leaf := certs[0]
+	chain, err := signingCertificateChain(leaf, certs[1:], o.CertVerify.CertChain)
+	if err != nil {
+		slog.Warn("failed to build certificate chain of the signing certificate, reporting the signing certificate only", "error", err)
+		chain = []*x509.Certificate{leaf}
+	}
+	return report.NewSigningIdentity(leaf, chain), nil
+}
+
+// readSigningCertificates reads the certificate stored with the keyless signature, the signing certificate comes
+// first and may be followed by the rest of its chain.
+func readSigningCertificates(functionIdentity string) ([]*x509.Certificate, error) {
+	content, err := integrity.ReadFile("/tmp/" + functionIdentity + ".crt.base64")
+	if err != nil {
+		return nil, err
+	}
+	if decoded, err := b64.StdEncoding.DecodeString(strings.TrimSpace(string(content))); err == nil {
+		content = decoded
+	}
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM(content)
+	if err != nil || len(certs) == 0 {
+		return nil, fmt.Errorf("failed to parse signing certificate: %v", err)
+	}
+	return certs, nil
+}
+
+// signingCertificateChain builds the chain from the leaf up to a trusted root, the roots and intermediates come from
+// the --certificate-chain file when given and from the fulcio trust roots otherwise. Fulcio certificates are short
+// lived, so the chain is checked at the time the certificate was issued.
+func signingCertificateChain(leaf *x509.Certificate, attached []*x509.Certificate, certChainPath string) ([]*x509.Certificate, error) {
+	roots, intermediates, err := chainPools(certChainPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, cert := range attached {
+		intermediates.AddCert(cert)
+	}
+	chains, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   leaf.NotBefore,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return chains[0], nil
+}
+
+func chainPools(certChainPath string) (*x509.CertPool, *x509.CertPool, error) {
+	if certChainPath == "" {
+		roots, err := fulcio.GetRoots()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get fulcio roots: %w", err)
+		}
+		intermediates, err := fulcio.GetIntermediates()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get fulcio intermediates: %w", err)
+		}
+		return roots, intermediates, nil
+	}
+	content, err := os.ReadFile(certChainPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read certificate chain: %s: %w", certChainPath, err)
+	}
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM(content)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse certificate chain: %s: %w", certChainPath, err)
+	}
+	roots := x509.NewCertPool()
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs {
+		// a self-signed certificate is a root
+		if cert.CheckSignatureFrom(cert) == nil {
+			roots.AddCert(cert)
+		} else {
+			intermediates.AddCert(cert)
+		}
+	}
+	return roots, intermediates, nil
+}
@@ -0,0 +1,145 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	b64 "encoding/base64"
+	"encoding/pem"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var (
+	oidIssuer             = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	oidWorkflowRepository = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 5}
+)
+
+func newCA(t *testing.T, name string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func writeSigningCertificate(t *testing.T, identity string, ca *x509.Certificate, caKey *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:   big.NewInt(42),
+		EmailAddresses: []string{"signer@example.com"},
+		NotBefore:      time.Now().Add(-time.Minute),
+		NotAfter:       time.Now().Add(-time.Second),
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		ExtraExtensions: []pkix.Extension{
+			{Id: oidIssuer, Value: []byte("https://token.actions.githubusercontent.com")},
+			{Id: oidWorkflowRepository, Value: []byte("openclarity/function-clarity")},
+		},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	path := "/tmp/" + identity + ".crt.base64"
+	if err = os.WriteFile(path, []byte(b64.StdEncoding.EncodeToString(content)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Remove(path) })
+}
+
+func writeCertificateChain(t *testing.T, cert *x509.Certificate) string {
+	path := filepath.Join(t.TempDir(), "chain.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDescribeSigningIdentity(t *testing.T) {
+	root, rootKey := newCA(t, "test-root")
+	identity := "signing-identity-test"
+	writeSigningCertificate(t, identity, root, rootKey)
+	o := &options.VerifyOpts{}
+	o.CertVerify.CertChain = writeCertificateChain(t, root)
+
+	signingIdentity, err := describeSigningIdentity(identity, o)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if signingIdentity.Subject != "signer@example.com" {
+		t.Fatalf("unexpected subject: %s", signingIdentity.Subject)
+	}
+	if signingIdentity.Issuer != "https://token.actions.githubusercontent.com" {
+		t.Fatalf("unexpected issuer: %s", signingIdentity.Issuer)
+	}
+	if signingIdentity.GithubWorkflowRepository != "openclarity/function-clarity" {
+		t.Fatalf("unexpected workflow repository: %s", signingIdentity.GithubWorkflowRepository)
+	}
+	if len(signingIdentity.Chain) != 2 {
+		t.Fatalf("expected the leaf and the root in the chain, got: %v", signingIdentity.Chain)
+	}
+	if signingIdentity.Chain[0].SerialNumber != "2a" || signingIdentity.Chain[1].Subject != "CN=test-root" {
+		t.Fatalf("unexpected chain: %v", signingIdentity.Chain)
+	}
+}
+
+func TestDescribeSigningIdentityWithUntrustedChain(t *testing.T) {
+	root, rootKey := newCA(t, "test-root")
+	other, _ := newCA(t, "other-root")
+	identity := "signing-identity-untrusted-test"
+	writeSigningCertificate(t, identity, root, rootKey)
+	o := &options.VerifyOpts{}
+	o.CertVerify.CertChain = writeCertificateChain(t, other)
+
+	signingIdentity, err := describeSigningIdentity(identity, o)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(signingIdentity.Chain) != 1 || signingIdentity.Chain[0].Issuer != "CN=test-root" {
+		t.Fatalf("expected the signing certificate only, got: %v", signingIdentity.Chain)
+	}
+}
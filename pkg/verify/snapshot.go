@@ -0,0 +1,54 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"fmt"
+	"github.com/openclarity/function-clarity/cmd/function-clarity/cli/verify"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/integrity"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"github.com/openclarity/function-clarity/pkg/snapshot"
+)
+
+// VerifySnapshot verifies the signature of the golden snapshot, signed with the sign code command, and returns the
+// deviations of the live functions from it.
+func VerifySnapshot(client clients.Client, snapshotPath string, live []clients.FunctionConfig, o *options.VerifyOpts,
+	ctx context.Context) ([]snapshot.Deviation, error) {
+	client = clients.WithContext(client, ctx)
+	snapshotIdentity, _, err := resolveCodeIdentity(client, snapshotPath, snapshotPath)
+	if err != nil {
+		return nil, err
+	}
+	isKeyless := false
+	if !o.SecurityKey.Use && o.Key == "" && o.BundlePath == "" {
+		if isKeyless, err = integrity.IsExperimentalEnv(); err != nil {
+			return nil, err
+		}
+	}
+	if err = downloadSignatureAndCertificate(client, snapshotPath, snapshotIdentity, isKeyless, o.Offline); err != nil {
+		return nil, err
+	}
+	if err = verify.VerifyIdentity(snapshotIdentity, o, ctx, isKeyless); err != nil {
+		return nil, VerifyError{Err: fmt.Errorf("snapshot verification error: %w", err)}
+	}
+	golden, err := snapshot.Load(snapshotPath)
+	if err != nil {
+		return nil, err
+	}
+	return golden.Compare(live), nil
+}
@@ -0,0 +1,55 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/integrity"
+	"github.com/openclarity/function-clarity/pkg/metadata"
+	"github.com/openclarity/function-clarity/pkg/options"
+)
+
+// describeUntrustedSigner tells responders who signed an identity whose signature failed verification. A keyless
+// signature carries its certificate, a key-based one doesn't carry the key, so it is described by the digest of the
+// signature along with the trusted key it failed against.
+func describeUntrustedSigner(client clients.Client, identity string, o *options.VerifyOpts, ctx context.Context, isKeyless bool) string {
+	if !isKeyless {
+		// signing keylessly also stores the certificate, which names the signer
+		isKeyless = client.Download(identity, "crt.base64") == nil
+	}
+	if isKeyless {
+		certs, err := readSigningCertificates(identity)
+		if err != nil {
+			return fmt.Sprintf("unknown certificate: %v", err)
+		}
+		pin, err := metadata.PinForCertificate(certs[0])
+		if err != nil {
+			return fmt.Sprintf("unknown certificate: %v", err)
+		}
+		return "certificate " + pin.String()
+	}
+	signer := "unknown key"
+	if content, err := integrity.ReadFile("/tmp/" + identity + ".sig"); err == nil {
+		signer = fmt.Sprintf("unknown key, signature sha256: %x", sha256.Sum256(content))
+	}
+	if trusted, err := resolveSigner(identity, o, ctx, false); err == nil {
+		signer = fmt.Sprintf("%s, trusted key %s", signer, trusted)
+	}
+	return signer
+}
@@ -0,0 +1,74 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"crypto/elliptic"
+	"errors"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestUntrustedSignerErrorIsVerifyError(t *testing.T) {
+	var err error = UntrustedSignerError{Signer: "unknown key", Err: errors.New("invalid signature")}
+	if !errors.Is(err, VerifyError{}) {
+		t.Fatalf("expected an untrusted signer to fail verification")
+	}
+	if !errors.Is(err, UntrustedSignerError{}) {
+		t.Fatalf("expected an untrusted signer error")
+	}
+	if errors.Is(VerifyError{Err: errors.New("missing signature")}, UntrustedSignerError{}) {
+		t.Fatalf("expected a missing signature not to be reported as an untrusted signer")
+	}
+}
+
+func TestDescribeUntrustedKeySigner(t *testing.T) {
+	identity := "untrusted-key-test"
+	client := &pinClient{files: map[string]string{}}
+	if err := os.WriteFile("/tmp/"+identity+".sig", []byte("signature"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Remove("/tmp/" + identity + ".sig") })
+	o := &options.VerifyOpts{}
+	o.Key = writePublicKey(t, elliptic.P256())
+
+	signer := describeUntrustedSigner(client, identity, o, context.Background(), false)
+	if !strings.HasPrefix(signer, "unknown key, signature sha256: ") || !strings.Contains(signer, "trusted key algorithm: ecdsa-P-256, key fingerprint: ") {
+		t.Fatalf("unexpected signer: %s", signer)
+	}
+}
+
+func TestDescribeUntrustedKeylessSigner(t *testing.T) {
+	root, rootKey := newCA(t, "test-root")
+	identity := "untrusted-keyless-test"
+	writeSigningCertificate(t, identity, root, rootKey)
+	content, err := os.ReadFile("/tmp/" + identity + ".crt.base64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// verified with a key, the certificate stored by the keyless signing names the signer
+	client := &pinClient{files: map[string]string{identity + ".crt.base64": string(content)}}
+	o := &options.VerifyOpts{}
+	o.Key = writePublicKey(t, elliptic.P256())
+
+	signer := describeUntrustedSigner(client, identity, o, context.Background(), false)
+	if !strings.Contains(signer, "subject: signer@example.com, issuer: https://token.actions.githubusercontent.com") {
+		t.Fatalf("unexpected signer: %s", signer)
+	}
+}
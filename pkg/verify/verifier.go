@@ -17,64 +17,250 @@ package verify
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/uuid"
 	"github.com/openclarity/function-clarity/cmd/function-clarity/cli/verify"
+	"github.com/openclarity/function-clarity/pkg/cache"
 	"github.com/openclarity/function-clarity/pkg/clients"
 	"github.com/openclarity/function-clarity/pkg/integrity"
+	"github.com/openclarity/function-clarity/pkg/metadata"
+	"github.com/openclarity/function-clarity/pkg/metrics"
+	"github.com/openclarity/function-clarity/pkg/notify"
 	"github.com/openclarity/function-clarity/pkg/options"
+	"github.com/openclarity/function-clarity/pkg/report"
 	v "github.com/sigstore/cosign/cmd/cosign/cli/verify"
-	"strings"
+	"golang.org/x/exp/slog"
+	"sort"
+	"sync"
+	"time"
 )
 
+var layerCache *cache.LayerCache
+var layerCacheErr error
+var layerCacheOnce sync.Once
+
 func Verify(client clients.Client, functionIdentifier string, o *options.VerifyOpts, ctx context.Context,
 	action string, topicArn string, tagKeysFilter []string, filteredRegions []string) error {
+	_, err := VerifyWithSigningIdentity(client, functionIdentifier, o, ctx, action, topicArn, tagKeysFilter, filteredRegions)
+	return err
+}
 
-	if filteredRegions != nil && (len(filteredRegions) > 0) {
-		funcInRegions := client.IsFuncInRegions(filteredRegions)
-		if !funcInRegions {
-			fmt.Printf("function: %s not in regions list: %s, skipping validation", functionIdentifier, filteredRegions)
-			return nil
+// VerifyWithSigningIdentity verifies the function like Verify and also returns the signing identity of a keylessly
+// signed function that passed verification, it is nil otherwise.
+func VerifyWithSigningIdentity(client clients.Client, functionIdentifier string, o *options.VerifyOpts, ctx context.Context,
+	action string, topicArn string, tagKeysFilter []string, filteredRegions []string) (*report.SigningIdentity, error) {
+	signingIdentity, _, err := verifyAndHandle(client, functionIdentifier, o, ctx, action, topicArn, tagKeysFilter, filteredRegions)
+	return signingIdentity, err
+}
+
+// verifyAndHandle verifies the function like VerifyWithSigningIdentity and also returns the action applied to it when
+// it failed verification, see HandleVerification.
+func verifyAndHandle(client clients.Client, functionIdentifier string, o *options.VerifyOpts, ctx context.Context,
+	action string, topicArn string, tagKeysFilter []string, filteredRegions []string) (*report.SigningIdentity, string, error) {
+	start := time.Now()
+	client = clients.WithContext(client, ctx)
+	inScope, err := isFuncInScope(client, functionIdentifier, o, tagKeysFilter, filteredRegions)
+	if err != nil || !inScope {
+		return nil, "", err
+	}
+	packageType, err := resolvePackageType(client, functionIdentifier)
+	if err != nil {
+		return nil, "", err
+	}
+	slog.Debug("verifying function", "function", functionIdentifier, "packageType", packageType, "action", action)
+	signingIdentity, err := verifyPackage(client, functionIdentifier, packageType, o, ctx)
+	slog.Debug("function verification done", "function", functionIdentifier, "verified", err == nil, "duration", time.Since(start))
+	metrics.ObserveVerification(newVerificationResult(functionIdentifier, err, nil, 0).Result, time.Since(start))
+	// a verification abandoned on a timeout or cancellation mustn't act on the function, its outcome isn't reported
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, "", ctxErr
+	}
+	if o.VexOutput != "" {
+		if e := writeVexDocument(client, functionIdentifier, o.VexOutput, err); e != nil {
+			return nil, "", e
 		}
 	}
-
-	if tagKeysFilter != nil && (len(tagKeysFilter) > 0) {
-		funcContainsTag, err := client.FuncContainsTags(functionIdentifier, tagKeysFilter)
-		if err != nil {
-			return fmt.Errorf("check function tags: failed to check tags of function: %s: %w", functionIdentifier, err)
+	if errors.Is(err, UntrustedSignerError{}) && o.UntrustedSignerAction != "" {
+		action = o.UntrustedSignerAction
+		if action == "none" {
+			action = ""
 		}
-		if !funcContainsTag {
-			fmt.Printf("function: %s doesn't contain tag in the list: %s, skipping validation", functionIdentifier, tagKeysFilter)
-			return nil
+	}
+	if errors.Is(err, VerifyError{}) && o.NotificationRouting.Enabled() {
+		topicArn = routeNotification(client, functionIdentifier, &o.NotificationRouting, topicArn)
+	}
+	if o.SigningIdentityOutput != "" && signingIdentity != nil {
+		record := report.SigningIdentityRecord{FunctionIdentifier: functionIdentifier, VerifiedAt: time.Now().UTC(), SigningIdentity: signingIdentity}
+		if e := report.WriteSigningIdentityRecord(o.SigningIdentityOutput, record); e != nil {
+			return nil, "", e
 		}
 	}
+	scanID := o.ScanID
+	if scanID == "" {
+		scanID = uuid.NewString()
+	}
+	applied, handleErr := HandleVerification(client, action, o.DryRun, functionIdentifier, err, notifiers(client, o, topicArn), scanID, ctx)
+	if o.ResultQueue.Enabled() {
+		result := newVerificationResult(functionIdentifier, err, signingIdentity, time.Since(start))
+		result.Digest = functionDigest(client, functionIdentifier)
+		result.ScanID = scanID
+		result.Action = applied
+		if e := PublishResults(client, &o.ResultQueue, []VerificationResult{result}); e != nil {
+			if handleErr == nil {
+				return signingIdentity, applied, e
+			}
+			slog.Error("failed to publish the verification result", e, "function", functionIdentifier)
+		}
+	}
+	return signingIdentity, applied, handleErr
+}
+
+// VerifyOnDemand verifies the function regardless of the region and tag filters, without acting on the result: no
+// action is taken and no notification or result is sent. It returns the signing identity of a keylessly signed
+// function that passed verification, it is nil otherwise.
+func VerifyOnDemand(client clients.Client, functionIdentifier string, o *options.VerifyOpts, ctx context.Context) (*report.SigningIdentity, error) {
+	start := time.Now()
+	client = clients.WithContext(client, ctx)
+	packageType, err := resolvePackageType(client, functionIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	signingIdentity, err := verifyPackage(client, functionIdentifier, packageType, o, ctx)
+	metrics.ObserveVerification(newVerificationResult(functionIdentifier, err, nil, 0).Result, time.Since(start))
+	return signingIdentity, err
+}
+
+// VerifyOnDemandResult verifies the function like VerifyOnDemand and classifies the outcome like a scan does.
+func VerifyOnDemandResult(client clients.Client, functionIdentifier string, o *options.VerifyOpts, ctx context.Context) (VerificationResult, error) {
+	start := time.Now()
+	signingIdentity, err := VerifyOnDemand(client, functionIdentifier, o, ctx)
+	return newVerificationResult(functionIdentifier, err, signingIdentity, time.Since(start)), err
+}
+
+// resolvePackageType returns the package type of the function, Zip or Image.
+func resolvePackageType(client clients.Client, functionIdentifier string) (string, error) {
 	packageType, err := client.ResolvePackageType(functionIdentifier)
 	if err != nil {
-		return fmt.Errorf("failed to resolve package type for function: %s: %w", functionIdentifier, err)
+		return "", fmt.Errorf("failed to resolve package type for function: %s: %w", functionIdentifier, err)
+	}
+	if packageType != "Zip" && packageType != "Image" {
+		return "", fmt.Errorf("unsupported package type: %s for function: %s", packageType, functionIdentifier)
+	}
+	return packageType, nil
+}
+
+// verifyPackage verifies the code or the image of the function, by package type.
+func verifyPackage(client clients.Client, functionIdentifier string, packageType string, o *options.VerifyOpts, ctx context.Context) (*report.SigningIdentity, error) {
+	if packageType == "Image" {
+		return nil, verifyImage(client, functionIdentifier, o, ctx)
+	}
+	signingIdentity, err := verifyCode(client, functionIdentifier, o, ctx)
+	if err == nil && o.RequireSignedLayers {
+		err = verifySignedLayers(client, functionIdentifier, o, ctx)
+	}
+	if err == nil && o.RequireAwsCodeSigning {
+		err = verifyAwsCodeSigning(client, functionIdentifier)
+	}
+	return signingIdentity, err
+}
+
+// isFuncInScope tells whether the function is to be verified given the region and tag filters.
+func isFuncInScope(client clients.Client, functionIdentifier string, o *options.VerifyOpts, tagKeysFilter []string, filteredRegions []string) (bool, error) {
+	if !isFuncInRegionScope(client, functionIdentifier, filteredRegions, o.ExcludedFuncRegions) {
+		return false, nil
+	}
+	includedKeys, includedTags := options.ParseTagFilter(tagKeysFilter)
+	for key, value := range o.IncludedFuncTags {
+		if includedTags == nil {
+			includedTags = map[string]string{}
+		}
+		includedTags[key] = value
+	}
+	inTagScope, err := isFuncInTagScope(client, functionIdentifier, includedKeys, includedTags, o.ExcludedFuncTagKeys)
+	if err != nil {
+		return false, fmt.Errorf("check function tags: failed to check tags of function: %s: %w", functionIdentifier, err)
 	}
-	switch packageType {
-	case "Zip":
-		err = verifyCode(client, functionIdentifier, o, ctx)
-	case "Image":
-		err = verifyImage(client, functionIdentifier, o, ctx)
-	default:
-		return fmt.Errorf("unsupported package type: %s for function: %s", packageType, functionIdentifier)
+	return inTagScope, nil
+}
+
+// isFuncInRegionScope tells whether the function is to be verified given its region: the included regions, or all
+// regions when there are none, minus the excluded regions. A region both included and excluded is excluded.
+func isFuncInRegionScope(client clients.Client, functionIdentifier string, includedRegions []string, excludedRegions []string) bool {
+	if len(excludedRegions) > 0 && client.IsFuncInRegions(excludedRegions) {
+		if len(includedRegions) > 0 && client.IsFuncInRegions(includedRegions) {
+			slog.Warn("function is in a region both included and excluded, the exclusion wins", "function", functionIdentifier, "includedRegions", includedRegions, "excludedRegions", excludedRegions)
+		}
+		slog.Info("function in excluded regions list, skipping validation", "function", functionIdentifier, "excludedRegions", excludedRegions)
+		return false
+	}
+	if len(includedRegions) > 0 && !client.IsFuncInRegions(includedRegions) {
+		slog.Info("function not in regions list, skipping validation", "function", functionIdentifier, "includedRegions", includedRegions)
+		return false
 	}
-	return HandleVerification(client, action, functionIdentifier, err, topicArn)
+	return true
 }
 
-func HandleVerification(client clients.Client, action string, funcIdentifier string, err error, topicArn string) error {
+// isFuncInTagScope tells whether the function is to be verified given its tags. A function is included when it has one
+// of the included tag keys or one of the included tags with its value, every function is included when there are
+// neither. A function with any of the excluded tag keys is skipped even when it is included.
+func isFuncInTagScope(client clients.Client, functionIdentifier string, includedTagKeys []string, includedTags map[string]string,
+	excludedTagKeys []string) (bool, error) {
+	if len(excludedTagKeys) > 0 {
+		excluded, err := client.FuncContainsTags(functionIdentifier, excludedTagKeys)
+		if err != nil {
+			return false, err
+		}
+		if excluded {
+			slog.Info("function contains tag in the excluded list, skipping validation", "function", functionIdentifier, "excludedTagKeys", excludedTagKeys)
+			return false, nil
+		}
+	}
+	if len(includedTagKeys) == 0 && len(includedTags) == 0 {
+		return true, nil
+	}
+	var included bool
+	if len(includedTags) == 0 {
+		var err error
+		if included, err = client.FuncContainsTags(functionIdentifier, includedTagKeys); err != nil {
+			return false, err
+		}
+	} else {
+		funcTags, err := client.GetFuncTags(functionIdentifier)
+		if err != nil {
+			return false, err
+		}
+		included = options.MatchesTagFilter(funcTags, includedTagKeys, includedTags)
+	}
+	if !included {
+		slog.Info("function doesn't contain tag in the list, skipping validation", "function", functionIdentifier, "includedTagKeys", includedTagKeys, "includedTags", includedTags)
+		return false, nil
+	}
+	return true, nil
+}
+
+// HandleVerification applies the action to the function and notifies the notifiers when the verification failed, the
+// notification carries the deduplication key of the result in the scan. A failing notifier doesn't stop the others.
+// In a dry run the block action tags the function with its result like detect, and only logs and notifies that it
+// would block or unblock it. The action applied to a function that failed verification is returned, it is
+// ActionWouldBlock for a dry run block and empty when the action failed.
+func HandleVerification(client clients.Client, action string, dryRun bool, funcIdentifier string, err error, notifiers []notify.Notifier,
+	scanID string, ctx context.Context) (string, error) {
 	if err != nil && !errors.Is(err, VerifyError{}) {
-		return err
+		return "", err
 	}
 	failed := err != nil
+	reason := ""
+	if failed {
+		reason = err.Error()
+	}
 
 	var e error
 	switch action {
 	case "":
-		fmt.Printf("no action defined, nothing to do\n")
+		slog.Info("no action defined, nothing to do", "function", funcIdentifier)
 	case "detect":
 		e = client.HandleDetect(&funcIdentifier, failed)
 		if e != nil {
@@ -87,6 +273,14 @@ func HandleVerification(client clients.Client, action string, funcIdentifier str
 				e = fmt.Errorf("handleVerification failed on function indication: %w", e)
 				break
 			}
+			if dryRun {
+				if failed {
+					slog.Warn("dry run, would block function", "function", funcIdentifier, "reason", reason)
+				} else {
+					slog.Info("dry run, would unblock function if blocked by function clarity", "function", funcIdentifier)
+				}
+				break
+			}
 			e = client.HandleBlock(&funcIdentifier, failed)
 			if e != nil {
 				e = fmt.Errorf("handleVerification failed on function block: %w", e)
@@ -95,23 +289,78 @@ func HandleVerification(client clients.Client, action string, funcIdentifier str
 		}
 	}
 
-	if failed && topicArn != "" {
+	if failed && len(notifiers) > 0 {
 		notification := clients.Notification{}
-		err = client.FillNotificationDetails(&notification, funcIdentifier)
-		if err != nil {
-			return err
+		if fillErr := client.FillNotificationDetails(&notification, funcIdentifier); fillErr != nil {
+			return "", fillErr
 		}
 		notification.Action = action
-		msg, err := json.Marshal(notification)
-		if err != nil {
-			return err
+		notification.DryRun = dryRun && action == ActionBlock
+		notification.Reason = reason
+		var untrusted UntrustedSignerError
+		if errors.As(err, &untrusted) {
+			notification.Signer = untrusted.Signer
+		}
+		notification.Digest = functionDigest(client, funcIdentifier)
+		notification.ScanId = scanID
+		notification.DeduplicationKey = report.DeduplicationKey(funcIdentifier, notification.Digest, scanID)
+		for _, notifier := range notifiers {
+			if notifyErr := notifier.Notify(ctx, notify.VerificationEvent(notification)); notifyErr != nil {
+				if e == nil {
+					e = notifyErr
+				} else {
+					slog.Error("failed to notify", notifyErr, "function", funcIdentifier)
+				}
+			}
 		}
-		e = client.Notify(string(msg), topicArn)
 	}
+	applied := ""
 	if e == nil && failed {
-		return err
+		applied = appliedAction(action, dryRun)
+		return applied, err
+	}
+	return applied, e
+}
+
+// appliedAction returns the action recorded for a function that failed verification: ActionWouldBlock when the block
+// action only ran dry, the action otherwise.
+func appliedAction(action string, dryRun bool) string {
+	if action == ActionBlock && dryRun {
+		return ActionWouldBlock
+	}
+	return action
+}
+
+// functionDigest returns the sha256 of the function code for the deduplication key, it is left empty when it can't
+// be read so the result is still emitted.
+func functionDigest(client clients.Client, functionIdentifier string) string {
+	digest, err := client.GetFuncCodeSha256(functionIdentifier)
+	if err != nil {
+		slog.Warn("failed to get code sha256 of function, emitting its result without it", "function", functionIdentifier, "error", err)
+		return ""
 	}
-	return e
+	return digest
+}
+
+// writeVexDocument writes an OpenVEX document with a statement for the function when its verification failed,
+// and an empty document when it passed. Errors unrelated to the verification itself don't produce a document.
+func writeVexDocument(client clients.Client, functionIdentifier string, path string, err error) error {
+	if err != nil && !errors.Is(err, VerifyError{}) {
+		return nil
+	}
+	var statements []report.VexStatement
+	if err != nil {
+		product := clients.Notification{}
+		if e := client.FillNotificationDetails(&product, functionIdentifier); e != nil {
+			product.FunctionIdentifier = functionIdentifier
+		}
+		statements = append(statements, report.UnverifiedFunctionStatement(product.FunctionIdentifier, err.Error()))
+	}
+	doc, e := report.NewVexDocument(statements, time.Now())
+	if e != nil {
+		return e
+	}
+	return report.WriteVexDocument(path, doc)
 }
 
 func verifyImage(client clients.Client, functionIdentifier string, o *options.VerifyOpts, ctx context.Context) error {
@@ -119,6 +368,25 @@ func verifyImage(client clients.Client, functionIdentifier string, o *options.Ve
 	if err != nil {
 		return fmt.Errorf("failed to fetch function image URI for function: %s: %w", functionIdentifier, err)
 	}
+	if o.RequireImageDigestPin {
+		if err = verifyImageDigestPin(functionIdentifier, imageURI); err != nil {
+			return err
+		}
+	}
+	// the signature of the image the function runs is verified, not the one of the image its tag points to now
+	if imageURI, err = client.GetFuncResolvedImageURI(functionIdentifier); err != nil {
+		return fmt.Errorf("failed to fetch function resolved image URI for function: %s: %w", functionIdentifier, err)
+	}
+	architecture, err := client.GetFuncArchitecture(functionIdentifier)
+	if err != nil {
+		return fmt.Errorf("failed to fetch function architecture for function: %s: %w", functionIdentifier, err)
+	}
+	if err = initLayerCache(o.LayerCache); err != nil {
+		return err
+	}
+	if err = o.TrustRoots.Apply(); err != nil {
+		return err
+	}
 	annotations, err := o.AnnotationsMap()
 	if err != nil {
 		return err
@@ -129,12 +397,18 @@ func verifyImage(client clients.Client, functionIdentifier string, o *options.Ve
 		return err
 	}
 
+	// offline, cosign only verifies the bundle attached to the signature
+	rekorURL := o.Rekor.URL
+	if o.Offline {
+		rekorURL = ""
+	}
 	vc := v.VerifyCommand{
 		RegistryOptions:              o.Registry,
 		CheckClaims:                  o.CheckClaims,
 		KeyRef:                       o.Key,
 		CertRef:                      o.CertVerify.Cert,
 		CertEmail:                    o.CertVerify.CertEmail,
+		CertIdentity:                 o.CertVerify.CertIdentity,
 		CertOidcIssuer:               o.CertVerify.CertOidcIssuer,
 		CertGithubWorkflowTrigger:    o.CertVerify.CertGithubWorkflowTrigger,
 		CertGithubWorkflowSha:        o.CertVerify.CertGithubWorkflowSha,
@@ -146,7 +420,7 @@ func verifyImage(client clients.Client, functionIdentifier string, o *options.Ve
 		Sk:                           o.SecurityKey.Use,
 		Slot:                         o.SecurityKey.Slot,
 		Output:                       o.Output,
-		RekorURL:                     o.Rekor.URL,
+		RekorURL:                     rekorURL,
 		Attachment:                   o.Attachment,
 		Annotations:                  annotations,
 		HashAlgorithm:                hashAlgorithm,
@@ -154,52 +428,404 @@ func verifyImage(client clients.Client, functionIdentifier string, o *options.Ve
 		LocalImage:                   o.LocalImage,
 	}
 
-	if err = vc.Exec(ctx, []string{imageURI}); err != nil {
+	if o.RequireKeyAndKeyless {
+		err = verifyImageKeyAndKeyless(&vc, functionIdentifier, imageURI, architecture, o, ctx)
+	} else {
+		err = verifyImageForArchitecture(&vc, imageURI, architecture, o, ctx)
+	}
+	if layerCache != nil {
+		stats := layerCache.Stats()
+		slog.Info("layer cache", "layersReused", stats.Reused, "bytesReused", stats.BytesReused, "layersFetched", stats.Fetched)
+	}
+	if err != nil {
 		return VerifyError{Err: fmt.Errorf("image verification error: %w", err)}
 	}
+	if o.PinSigner {
+		if o.Key == "" {
+			slog.Warn("signer pinning of image functions requires a public key, skipping signer pin check", "function", functionIdentifier)
+			return nil
+		}
+		return verifySignerPin(client, functionIdentifier, "", o, ctx, false)
+	}
+	return nil
+}
+
+// verifyImageDigestPin rejects an image function whose image uri is a tag. The pinned digest is the authoritative
+// reference of the deployment, a tag may point to another image over time even when each of them is signed.
+func verifyImageDigestPin(functionIdentifier string, imageURI string) error {
+	ref, err := name.ParseReference(imageURI)
+	if err != nil {
+		return fmt.Errorf("failed to parse image URI: %s: %w", imageURI, err)
+	}
+	if _, pinned := ref.(name.Digest); !pinned {
+		return ImageDigestPinError{Err: fmt.Errorf("function: %s references image: %s by tag instead of an @sha256: digest", functionIdentifier, imageURI)}
+	}
 	return nil
 }
 
-func verifyCode(client clients.Client, functionIdentifier string, o *options.VerifyOpts, ctx context.Context) error {
+// initLayerCache routes all registry blob pulls through a shared on-disk cache, so layers shared by
+// several images are fetched once per process. The cache is installed on the first image verification.
+func initLayerCache(o options.LayerCacheOptions) error {
+	layerCacheOnce.Do(func() {
+		if o.MaxSizeMb <= 0 {
+			return
+		}
+		layerCache, layerCacheErr = cache.NewLayerCache(o.Dir, o.MaxSizeMb*1024*1024, o.MaxConcurrentPulls, remote.DefaultTransport)
+		if layerCacheErr == nil {
+			remote.DefaultTransport = layerCache
+		}
+	})
+	return layerCacheErr
+}
+
+func verifyCode(client clients.Client, functionIdentifier string, o *options.VerifyOpts, ctx context.Context) (*report.SigningIdentity, error) {
 	codePath, err := client.GetFuncCode(functionIdentifier)
 	if err != nil {
-		return fmt.Errorf("verify code: failed to fetch function code for function: %s: %w", functionIdentifier, err)
+		return nil, fmt.Errorf("verify code: failed to fetch function code for function: %s: %w", functionIdentifier, err)
 	}
-	integrityCalculator := integrity.Sha256{}
-	functionIdentity, err := integrityCalculator.GenerateIdentity(codePath)
+	functionIdentity, digestAlgorithm, err := resolveCodeIdentity(client, functionIdentifier, codePath)
 	if err != nil {
-		return fmt.Errorf("verify code: failed to generate function identity for function: %s: %w", functionIdentifier, err)
+		return nil, err
 	}
 
 	isKeyless := false
-	if !o.SecurityKey.Use && o.Key == "" && o.BundlePath == "" && integrity.IsExperimentalEnv() {
-		isKeyless = true
+	if o.RequireKeyAndKeyless {
+		// the signer pin is checked against the key
+		if err = verifyKeyAndKeyless(client, functionIdentifier, functionIdentity, o, ctx); err != nil {
+			return nil, err
+		}
+	} else {
+		if !o.SecurityKey.Use && o.Key == "" && o.BundlePath == "" {
+			if isKeyless, err = integrity.IsExperimentalEnv(); err != nil {
+				return nil, err
+			}
+		}
+		if o.CertVerify.EnforceSCT && !isKeyless {
+			slog.Warn("enforce-sct applies to keyless signatures only, ignored for key-based verification", "function", functionIdentifier)
+		}
+		if err = downloadSignatureAndCertificate(client, functionIdentifier, functionIdentity, isKeyless, o.Offline); err != nil {
+			return nil, err
+		}
+		if err = verify.VerifyIdentity(functionIdentity, o, ctx, isKeyless); err != nil {
+			return nil, UntrustedSignerError{Signer: describeUntrustedSigner(client, functionIdentity, o, ctx, isKeyless),
+				Err: fmt.Errorf("code verification error: %w", err)}
+		}
 	}
-	if err = downloadSignatureAndCertificate(client, functionIdentifier, functionIdentity, isKeyless); err != nil {
+	var signingIdentity *report.SigningIdentity
+	if isKeyless || o.RequireKeyAndKeyless {
+		if signingIdentity, err = describeSigningIdentity(functionIdentity, o); err != nil {
+			return nil, fmt.Errorf("verify code: failed to describe signing identity of function: %s: %w", functionIdentifier, err)
+		}
+		slog.Info("keyless signing identity of function", "function", functionIdentifier, "identity", signingIdentity.String())
+	}
+	if o.PinSigner {
+		if err = verifySignerPin(client, functionIdentifier, functionIdentity, o, ctx, isKeyless); err != nil {
+			return nil, err
+		}
+	}
+	if o.SignatureFreshness > 0 {
+		if err = verifySignatureFreshness(client, functionIdentifier, functionIdentity, o.SignatureFreshness, o.ClockSkew); err != nil {
+			return nil, err
+		}
+	}
+	if err = verifyMetadata(client, functionIdentifier, functionIdentity, digestAlgorithm, o, ctx, isKeyless); err != nil {
+		return nil, err
+	}
+	return signingIdentity, nil
+}
+
+// resolveCodeIdentity returns the identity of the code with the digest algorithm it was signed with. The algorithm is
+// read from the store: the signature of the identity of each supported algorithm is looked up in turn, and the
+// default identity is returned when there is none, for the verification to report the missing signature.
+func resolveCodeIdentity(client clients.SignatureStore, functionIdentifier string, codePath string) (string, string, error) {
+	var defaultIdentity string
+	for _, algorithm := range integrity.DigestAlgorithms {
+		hash, err := integrity.NewIdentityGenerator(algorithm)
+		if err != nil {
+			return "", "", err
+		}
+		identity, err := hash.GenerateIdentity(codePath)
+		if err != nil {
+			return "", "", fmt.Errorf("verify code: failed to generate %s identity for function: %s: %w", algorithm, functionIdentifier, err)
+		}
+		if algorithm == integrity.DefaultDigestAlgorithm {
+			defaultIdentity = identity
+		}
+		err = client.Download(identity, "sig")
+		if err == nil {
+			return identity, algorithm, nil
+		}
+		if !clients.IsObjectNotFound(err) {
+			return "", "", fmt.Errorf("verify code: failed to get signed identity for function: %s, function idenity: %s: %w", functionIdentifier, identity, err)
+		}
+	}
+	return defaultIdentity, integrity.DefaultDigestAlgorithm, nil
+}
+
+// verifySignedLayers fails verification when a layer attached to the function has no valid signature. The content of
+// each layer version is verified like the function code, with the same key or keyless identity.
+func verifySignedLayers(client clients.Client, functionIdentifier string, o *options.VerifyOpts, ctx context.Context) error {
+	layers, err := client.GetFuncLayers(functionIdentifier)
+	if err != nil {
+		return fmt.Errorf("verify layers: failed to get layers of function: %s: %w", functionIdentifier, err)
+	}
+	isKeyless := false
+	if !o.SecurityKey.Use && o.Key == "" && o.BundlePath == "" {
+		if isKeyless, err = integrity.IsExperimentalEnv(); err != nil {
+			return err
+		}
+	}
+	for _, layerArn := range layers.Arns {
+		layerPath, err := client.GetLayerCode(layerArn)
+		if err != nil {
+			return fmt.Errorf("verify layers: failed to fetch content of layer: %s of function: %s: %w", layerArn, functionIdentifier, err)
+		}
+		layerIdentity, _, err := resolveCodeIdentity(client, layerArn, layerPath)
+		if err != nil {
+			return err
+		}
+		if err = downloadSignatureAndCertificate(client, layerArn, layerIdentity, isKeyless, o.Offline); err != nil {
+			if errors.Is(err, VerifyError{}) {
+				return UnsignedLayerError{Layer: layerArn, Err: err}
+			}
+			return err
+		}
+		if err = verify.VerifyIdentity(layerIdentity, o, ctx, isKeyless); err != nil {
+			return UnsignedLayerError{Layer: layerArn, Err: fmt.Errorf("layer verification error: %w", err)}
+		}
+	}
+	return nil
+}
+
+// verifyAwsCodeSigning rejects a function without an AWS code signing config enforcing signed deployments. It is
+// checked on top of the signature verification, lambda itself then refuses code not signed by an allowed publisher.
+func verifyAwsCodeSigning(client clients.Client, functionIdentifier string) error {
+	codeSigningConfig, err := client.GetFuncCodeSigningConfig(functionIdentifier)
+	if err != nil {
+		return fmt.Errorf("verify aws code signing: failed to get code signing config of function: %s: %w", functionIdentifier, err)
+	}
+	if codeSigningConfig == nil {
+		return AwsCodeSigningError{Err: fmt.Errorf("no code signing config attached to function: %s", functionIdentifier)}
+	}
+	if codeSigningConfig.UntrustedArtifactOnDeployment != clients.CodeSigningPolicyEnforce {
+		return AwsCodeSigningError{Err: fmt.Errorf("code signing config: %s of function: %s has untrusted artifact policy: %s, expected: %s",
+			codeSigningConfig.Arn, functionIdentifier, codeSigningConfig.UntrustedArtifactOnDeployment, clients.CodeSigningPolicyEnforce)}
+	}
+	return nil
+}
+
+// verifySignatureFreshness rejects code modified too long after its most recent signature. A digest match alone
+// doesn't prove the code was deployed from a current signing, old code may be redeployed while its stale
+// signature is still in the store. The signature and modification times come from s3 and lambda, the clock skew is
+// tolerated on top of the freshness.
+func verifySignatureFreshness(client clients.Client, functionIdentifier string, functionIdentity string, freshness time.Duration,
+	clockSkew time.Duration) error {
+	signedAt, err := client.GetSignatureTimestamp(functionIdentity)
+	if err != nil {
+		return fmt.Errorf("verify signature freshness: failed to get signature time for function: %s: %w", functionIdentifier, err)
+	}
+	lastModified, err := client.GetFuncLastModified(functionIdentifier)
+	if err != nil {
+		return fmt.Errorf("verify signature freshness: failed to get last modified time of function: %s: %w", functionIdentifier, err)
+	}
+	if lastModified.After(signedAt.Add(freshness + clockSkew)) {
+		return VerifyError{Err: fmt.Errorf("stale signature for function: %s, code modified at: %s, most recent signature at: %s, allowed freshness: %s, clock skew: %s",
+			functionIdentifier, lastModified.UTC().Format(time.RFC3339), signedAt.UTC().Format(time.RFC3339), freshness, clockSkew)}
+	}
+	return nil
+}
+
+func verifyMetadata(client clients.Client, functionIdentifier string, functionIdentity string, digestAlgorithm string,
+	o *options.VerifyOpts, ctx context.Context, isKeyless bool) error {
+	if !o.MetadataRequired() {
+		return nil
+	}
+	signatureMetadata, err := downloadMetadata(client, functionIdentifier, functionIdentity, o, ctx, isKeyless)
+	if err != nil {
+		return err
+	}
+	if signatureMetadata == nil {
+		slog.Info("no signature metadata recorded, skipping baseline checks", "function", functionIdentifier)
+		return nil
+	}
+	if err = verifyDigestAlgorithm(functionIdentifier, signatureMetadata, digestAlgorithm); err != nil {
 		return err
 	}
-	if err = verify.VerifyIdentity(functionIdentity, o, ctx, isKeyless); err != nil {
-		return VerifyError{Err: fmt.Errorf("code verification error: %w", err)}
+	if o.ShowAnnotations {
+		logAnnotations(functionIdentifier, signatureMetadata.Annotations)
+	}
+	if o.RecordedAnnotations != nil {
+		o.RecordedAnnotations.Record(functionIdentifier, signatureMetadata.Annotations)
+	}
+	if o.VerifyConcurrency {
+		if err = verifyConcurrency(client, functionIdentifier, signatureMetadata); err != nil {
+			return err
+		}
+	}
+	if o.VerifyLayers {
+		if err = verifyLayers(client, functionIdentifier, signatureMetadata); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-func downloadSignatureAndCertificate(client clients.Client, functionIdentifier string, functionIdentity string, isKeyless bool) error {
+// verifyDigestAlgorithm checks the code was signed with the digest algorithm recorded in its signature metadata,
+// signatures made before the algorithm was recorded are sha256 ones.
+func verifyDigestAlgorithm(functionIdentifier string, signatureMetadata *metadata.SignatureMetadata, digestAlgorithm string) error {
+	recorded := signatureMetadata.DigestAlgorithm
+	if recorded == "" {
+		recorded = integrity.DefaultDigestAlgorithm
+	}
+	if _, err := integrity.NewIdentityGenerator(recorded); err != nil {
+		return VerifyError{Err: fmt.Errorf("signature metadata of function: %s: %w", functionIdentifier, err)}
+	}
+	if recorded != digestAlgorithm {
+		return VerifyError{Err: fmt.Errorf("digest algorithm mismatch for function: %s, signature metadata records: %s, signed digest: %s",
+			functionIdentifier, recorded, digestAlgorithm)}
+	}
+	return nil
+}
+
+// logAnnotations logs the annotations recorded at sign time, sorted by key.
+func logAnnotations(functionIdentifier string, annotations map[string]string) {
+	if len(annotations) == 0 {
+		slog.Info("no annotations recorded in the signature of function", "function", functionIdentifier)
+		return
+	}
+	keys := make([]string, 0, len(annotations))
+	for key := range annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	recorded := make([]string, 0, len(keys))
+	for _, key := range keys {
+		recorded = append(recorded, key+"="+annotations[key])
+	}
+	slog.Info("annotations recorded in the signature of function", "function", functionIdentifier, "annotations", recorded)
+}
+
+func verifyConcurrency(client clients.Client, functionIdentifier string, signatureMetadata *metadata.SignatureMetadata) error {
+	if signatureMetadata.Concurrency == nil {
+		slog.Info("no concurrency baseline recorded, skipping concurrency check", "function", functionIdentifier)
+		return nil
+	}
+	current, err := client.GetFuncConcurrency(functionIdentifier)
+	if err != nil {
+		return fmt.Errorf("verify concurrency: failed to get concurrency of function: %s: %w", functionIdentifier, err)
+	}
+	if !signatureMetadata.Concurrency.Equal(current) {
+		return VerifyError{Err: fmt.Errorf("concurrency drift detected for function: %s, signed baseline: {%s}, current: {%s}",
+			functionIdentifier, signatureMetadata.Concurrency, current)}
+	}
+	return nil
+}
+
+// verifyLayers detects layer substitution, each layer may carry a valid signature of its own while the function
+// runs with a different set or order of layers than the one signed.
+func verifyLayers(client clients.Client, functionIdentifier string, signatureMetadata *metadata.SignatureMetadata) error {
+	if signatureMetadata.Layers == nil {
+		slog.Info("no layers baseline recorded, skipping layers check", "function", functionIdentifier)
+		return nil
+	}
+	current, err := client.GetFuncLayers(functionIdentifier)
+	if err != nil {
+		return fmt.Errorf("verify layers: failed to get layers of function: %s: %w", functionIdentifier, err)
+	}
+	if diff := signatureMetadata.Layers.DiffLayers(current); !diff.IsEmpty() {
+		return VerifyError{Err: fmt.Errorf("layers drift detected for function: %s, %s, signed baseline: %s, current: %s",
+			functionIdentifier, diff, signatureMetadata.Layers, current)}
+	}
+	return nil
+}
+
+// downloadMetadata fetches the metadata recorded at sign time and verifies its signature, it returns nil when
+// the function was signed without metadata. The metadata recorded for the function is preferred over the one recorded
+// for its code, which may be shared by other functions.
+func downloadMetadata(client clients.Client, functionIdentifier string, functionIdentity string, o *options.VerifyOpts,
+	ctx context.Context, isKeyless bool) (*metadata.SignatureMetadata, error) {
+	names, err := metadataNames(client, functionIdentifier, functionIdentity, o)
+	if err != nil {
+		return nil, err
+	}
+	metadataName := ""
+	for _, name := range names {
+		if err = client.Download(name, metadata.FileType); err == nil {
+			metadataName = name
+			break
+		}
+		if !clients.IsObjectNotFound(err) {
+			return nil, fmt.Errorf("verify metadata: failed to get signature metadata for function: %s: %w", functionIdentifier, err)
+		}
+	}
+	if metadataName == "" {
+		return nil, nil
+	}
+	content, err := integrity.ReadFile("/tmp/" + metadataName + "." + metadata.FileType)
+	if err != nil {
+		return nil, fmt.Errorf("verify metadata: failed to read signature metadata for function: %s: %w", functionIdentifier, err)
+	}
+	metadataIdentity := metadata.Identity(content)
+	if o.RequireKeyAndKeyless {
+		if err = verifyKeyAndKeyless(client, functionIdentifier, metadataIdentity, o, ctx); err != nil {
+			return nil, err
+		}
+		return metadata.Unmarshal(content)
+	}
+	if err = downloadSignatureAndCertificate(client, functionIdentifier, metadataIdentity, isKeyless, o.Offline); err != nil {
+		return nil, err
+	}
+	if err = verify.VerifyIdentity(metadataIdentity, o, ctx, isKeyless); err != nil {
+		return nil, UntrustedSignerError{Signer: describeUntrustedSigner(client, metadataIdentity, o, ctx, isKeyless),
+			Err: fmt.Errorf("signature metadata verification error: %w", err)}
+	}
+	return metadata.Unmarshal(content)
+}
+
+// metadataNames returns the names the signature metadata of the function may be stored under. The concurrency and
+// layers baselines are only recorded for functions with an arn, functions of other clouds are looked up by code
+// identity.
+func metadataNames(client clients.Client, functionIdentifier string, functionIdentity string, o *options.VerifyOpts) ([]string, error) {
+	functionArn, err := resolveFunctionArn(client, functionIdentifier)
+	if err != nil {
+		if o.BaselineChecksEnabled() {
+			return nil, fmt.Errorf("verify metadata: %w", err)
+		}
+		return []string{functionIdentity}, nil
+	}
+	return metadata.FileNames(functionArn, functionIdentity), nil
+}
+
+func downloadSignatureAndCertificate(client clients.SignatureStore, functionIdentifier string, functionIdentity string, isKeyless bool,
+	offline bool) error {
 	if err := client.Download(functionIdentity, "sig"); err != nil {
-		var nsk *s3types.NoSuchKey
-		if errors.As(err, &nsk) || strings.Contains(err.Error(), "storage: object doesn't exist") {
+		if clients.IsObjectNotFound(err) {
 			return VerifyError{Err: fmt.Errorf("code verification error: %w", err)}
 		}
 		return fmt.Errorf("verify code: failed to get signed identity for function: %s, function idenity: %s: %w", functionIdentifier, functionIdentity, err)
 	}
 	if isKeyless {
 		if err := client.Download(functionIdentity, "crt.base64"); err != nil {
-			var nsk *s3types.NoSuchKey
-			if errors.As(err, &nsk) || strings.Contains(err.Error(), "storage: object doesn't exist") {
+			if clients.IsObjectNotFound(err) {
 				return VerifyError{Err: fmt.Errorf("code verification error: %w", err)}
 			}
 			return fmt.Errorf("verify code: failed to get certificate for function: %s, function idenity: %s: %w", functionIdentifier, functionIdentity, err)
 		}
+		if offline {
+			return downloadRekorBundle(client, functionIdentifier, functionIdentity)
+		}
+	}
+	return nil
+}
+
+// downloadRekorBundle fetches the transparency log entry recorded at sign time, required to verify a keyless
+// signature offline. Signatures made before the bundle was stored have to be signed again.
+func downloadRekorBundle(client clients.SignatureStore, functionIdentifier string, functionIdentity string) error {
+	if err := client.Download(functionIdentity, integrity.RekorBundleType); err != nil {
+		if clients.IsObjectNotFound(err) {
+			return VerifyError{Err: fmt.Errorf("code verification error: no rekor bundle stored for function: %s, sign it again to verify offline: %w", functionIdentifier, err)}
+		}
+		return fmt.Errorf("verify code: failed to get rekor bundle for function: %s, function idenity: %s: %w", functionIdentifier, functionIdentity, err)
 	}
 	return nil
 }
@@ -0,0 +1,390 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"errors"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/openclarity/function-clarity/pkg/clients"
+	"github.com/openclarity/function-clarity/pkg/integrity"
+	"github.com/openclarity/function-clarity/pkg/metadata"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type freshnessClient struct {
+	clients.Client
+	signedAt     time.Time
+	lastModified time.Time
+}
+
+func (c *freshnessClient) GetSignatureTimestamp(identity string) (time.Time, error) {
+	return c.signedAt, nil
+}
+
+func (c *freshnessClient) GetFuncLastModified(funcIdentifier string) (time.Time, error) {
+	return c.lastModified, nil
+}
+
+func TestVerifySignatureFreshness(t *testing.T) {
+	signedAt := time.Date(2022, 11, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		lastModified time.Time
+		fail         bool
+	}{
+		{name: "deployed within freshness", lastModified: signedAt.Add(30 * time.Minute)},
+		{name: "deployed before signing", lastModified: signedAt.Add(-time.Hour)},
+		{name: "modified after freshness", lastModified: signedAt.Add(2 * time.Hour), fail: true},
+		{name: "within clock skew after freshness", lastModified: signedAt.Add(time.Hour + 2*time.Minute)},
+		{name: "beyond clock skew after freshness", lastModified: signedAt.Add(time.Hour + 2*time.Minute + time.Second), fail: true},
+	}
+	for _, test := range tests {
+		client := &freshnessClient{signedAt: signedAt, lastModified: test.lastModified}
+		err := verifySignatureFreshness(client, "func", "identity", time.Hour, 2*time.Minute)
+		if test.fail {
+			if !errors.Is(err, VerifyError{}) {
+				t.Fatalf("%s: expected verification error, got: %v", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+	}
+}
+
+type codeSigningClient struct {
+	clients.Client
+	config *clients.CodeSigningConfig
+}
+
+func (c *codeSigningClient) GetFuncCodeSigningConfig(funcIdentifier string) (*clients.CodeSigningConfig, error) {
+	return c.config, nil
+}
+
+func TestVerifyAwsCodeSigning(t *testing.T) {
+	arn := "arn:aws:lambda:us-east-1:123456789012:code-signing-config:csc-0123456789abcdef0"
+	tests := []struct {
+		name   string
+		config *clients.CodeSigningConfig
+		fail   bool
+	}{
+		{name: "enforced", config: &clients.CodeSigningConfig{Arn: arn, UntrustedArtifactOnDeployment: "Enforce"}},
+		{name: "warn only", config: &clients.CodeSigningConfig{Arn: arn, UntrustedArtifactOnDeployment: "Warn"}, fail: true},
+		{name: "not attached", fail: true},
+	}
+	for _, test := range tests {
+		err := verifyAwsCodeSigning(&codeSigningClient{config: test.config}, "func")
+		if test.fail {
+			if !errors.Is(err, AwsCodeSigningError{}) || !errors.Is(err, VerifyError{}) {
+				t.Fatalf("%s: expected aws code signing verification error, got: %v", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+	}
+}
+
+func TestVerifyImageDigestPin(t *testing.T) {
+	repository := "123456789012.dkr.ecr.us-east-1.amazonaws.com/orders"
+	digest := "@sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	tests := []struct {
+		name     string
+		imageURI string
+		fail     bool
+	}{
+		{name: "pinned", imageURI: repository + digest},
+		{name: "tag and digest", imageURI: repository + ":v1" + digest},
+		{name: "tag", imageURI: repository + ":v1", fail: true},
+		{name: "implicit latest", imageURI: repository, fail: true},
+	}
+	for _, test := range tests {
+		err := verifyImageDigestPin("func", test.imageURI)
+		if test.fail {
+			if !errors.Is(err, ImageDigestPinError{}) || !errors.Is(err, VerifyError{}) {
+				t.Fatalf("%s: expected lost digest pin verification error, got: %v", test.name, err)
+			}
+			if result := newVerificationResult("func", err, nil, 0); result.Result != ResultImageDigestUnpinned {
+				t.Fatalf("%s: expected result: %s, got: %s", test.name, ResultImageDigestUnpinned, result.Result)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+	}
+}
+
+type onDemandClient struct {
+	clients.Client
+	packageType      string
+	imageURI         string
+	resolvedImageErr error
+}
+
+func (c *onDemandClient) GetFuncResolvedImageURI(funcIdentifier string) (string, error) {
+	return "", c.resolvedImageErr
+}
+
+func (c *onDemandClient) ResolvePackageType(funcIdentifier string) (string, error) {
+	return c.packageType, nil
+}
+
+func (c *onDemandClient) GetFuncImageURI(funcIdentifier string) (string, error) {
+	return c.imageURI, nil
+}
+
+func TestVerifyOnDemandIgnoresScopeAndActions(t *testing.T) {
+	// the client implements neither the scope checks nor the actions, calling them panics
+	o := &options.VerifyOpts{RequireImageDigestPin: true, ExcludedFuncRegions: []string{"*"}, UntrustedSignerAction: "block"}
+	client := &onDemandClient{packageType: "Image", imageURI: "123456789012.dkr.ecr.us-east-1.amazonaws.com/orders:v1"}
+	if _, err := VerifyOnDemand(client, "func", o, context.Background()); !errors.Is(err, ImageDigestPinError{}) {
+		t.Fatalf("expected the unpinned image to fail verification, got: %v", err)
+	}
+	client.packageType = "Wheel"
+	if _, err := VerifyOnDemand(client, "func", o, context.Background()); err == nil || errors.Is(err, VerifyError{}) {
+		t.Fatalf("expected an unsupported package type error, got: %v", err)
+	}
+}
+
+func TestVerifyImageResolvesTheDeployedImage(t *testing.T) {
+	client := &onDemandClient{imageURI: "123456789012.dkr.ecr.us-east-1.amazonaws.com/orders:v1", resolvedImageErr: errors.New("not found")}
+	err := verifyImage(client, "func", &options.VerifyOpts{}, context.Background())
+	if !errors.Is(err, client.resolvedImageErr) {
+		t.Fatalf("expected the image the function runs to be resolved, got: %v", err)
+	}
+}
+
+type layerClient struct {
+	clients.Client
+	layers []string
+	dir    string
+}
+
+func (c *layerClient) GetFuncLayers(funcIdentifier string) (*metadata.LayersConfig, error) {
+	return &metadata.LayersConfig{Arns: c.layers}, nil
+}
+
+func (c *layerClient) GetLayerCode(layerArn string) (string, error) {
+	return c.dir, nil
+}
+
+func (c *layerClient) Download(fileName string, outputType string) error {
+	return &s3types.NoSuchKey{}
+}
+
+func TestVerifySignedLayers(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "lib.py"), []byte("print()"), 0o600); err != nil {
+		t.Fatalf("failed to write layer content: %v", err)
+	}
+	layer := "arn:aws:lambda:us-east-1:123456789012:layer:shared:3"
+	client := &layerClient{dir: dir}
+	o := &options.VerifyOpts{}
+	o.Key = "cosign.pub"
+	if err := verifySignedLayers(client, "func", o, context.Background()); err != nil {
+		t.Fatalf("unexpected error without layers: %v", err)
+	}
+	client.layers = []string{layer}
+	err := verifySignedLayers(client, "func", o, context.Background())
+	var unsigned UnsignedLayerError
+	if !errors.As(err, &unsigned) || unsigned.Layer != layer || !errors.Is(err, VerifyError{}) {
+		t.Fatalf("expected the unsigned layer to fail verification, got: %v", err)
+	}
+	if result := newVerificationResult("func", err, nil, 0); result.Result != ResultUnsignedLayer {
+		t.Fatalf("expected result: %s, got: %s", ResultUnsignedLayer, result.Result)
+	}
+}
+
+type taggedClient struct {
+	clients.Client
+	tags map[string]string
+}
+
+func (c *taggedClient) FuncContainsTags(funcIdentifier string, tagKeys []string) (bool, error) {
+	for _, key := range tagKeys {
+		if _, ok := c.tags[key]; ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (c *taggedClient) GetFuncTags(funcIdentifier string) (map[string]string, error) {
+	return c.tags, nil
+}
+
+func TestIsFuncInTagScope(t *testing.T) {
+	tests := []struct {
+		name         string
+		tags         map[string]string
+		included     []string
+		includedTags map[string]string
+		excluded     []string
+		inScope      bool
+	}{
+		{name: "no filters", tags: map[string]string{"team": "payments"}, inScope: true},
+		{name: "included", tags: map[string]string{"team": "payments"}, included: []string{"team"}, inScope: true},
+		{name: "not included", tags: map[string]string{"owner": "jane"}, included: []string{"team"}},
+		{name: "included value", tags: map[string]string{"environment": "production"}, includedTags: map[string]string{"environment": "production"}, inScope: true},
+		{name: "other value", tags: map[string]string{"environment": "staging"}, includedTags: map[string]string{"environment": "production"}},
+		{name: "included key or value", tags: map[string]string{"team": "payments"}, included: []string{"team"}, includedTags: map[string]string{"environment": "production"}, inScope: true},
+		{name: "excluded", tags: map[string]string{"fc-exclude": ""}, excluded: []string{"fc-exclude"}},
+		{name: "not excluded", tags: map[string]string{"team": "payments"}, excluded: []string{"fc-exclude"}, inScope: true},
+		{name: "exclusion takes precedence", tags: map[string]string{"team": "payments", "fc-exclude": ""}, included: []string{"team"}, excluded: []string{"fc-exclude"}},
+	}
+	for _, test := range tests {
+		client := &taggedClient{tags: test.tags}
+		inScope, err := isFuncInTagScope(client, "func", test.included, test.includedTags, test.excluded)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		if inScope != test.inScope {
+			t.Fatalf("%s: expected in scope: %t, got: %t", test.name, test.inScope, inScope)
+		}
+	}
+}
+
+type regionClient struct {
+	clients.Client
+	region string
+}
+
+func (c *regionClient) IsFuncInRegions(regions []string) bool {
+	for _, region := range regions {
+		if region == c.region {
+			return true
+		}
+	}
+	return false
+}
+
+func TestIsFuncInRegionScope(t *testing.T) {
+	tests := []struct {
+		name     string
+		region   string
+		included []string
+		excluded []string
+		inScope  bool
+	}{
+		{name: "no filters", region: "us-east-1", inScope: true},
+		{name: "included", region: "us-east-1", included: []string{"us-east-1"}, inScope: true},
+		{name: "not included", region: "eu-west-1", included: []string{"us-east-1"}},
+		{name: "excluded from all", region: "ap-south-1", excluded: []string{"ap-south-1"}},
+		{name: "not excluded", region: "us-east-1", excluded: []string{"ap-south-1"}, inScope: true},
+		{name: "included and excluded", region: "us-east-1", included: []string{"us-east-1"}, excluded: []string{"us-east-1"}},
+	}
+	for _, test := range tests {
+		client := &regionClient{region: test.region}
+		if inScope := isFuncInRegionScope(client, "func", test.included, test.excluded); inScope != test.inScope {
+			t.Fatalf("%s: expected in scope: %t, got: %t", test.name, test.inScope, inScope)
+		}
+	}
+}
+
+func TestDownloadRekorBundleOffline(t *testing.T) {
+	identity := "offline-identity"
+	client := &pinClient{files: map[string]string{identity + ".sig": "c2lnbmF0dXJl", identity + ".crt.base64": "Y2VydA=="}}
+	if err := downloadSignatureAndCertificate(client, "function", identity, true, false); err != nil {
+		t.Fatalf("unexpected error without offline verification: %v", err)
+	}
+	err := downloadSignatureAndCertificate(client, "function", identity, true, true)
+	if !errors.Is(err, VerifyError{}) {
+		t.Fatalf("expected a verification error for a missing rekor bundle, got: %v", err)
+	}
+	client.files[identity+"."+integrity.RekorBundleType] = "{}"
+	if err = downloadSignatureAndCertificate(client, "function", identity, true, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err = os.Stat("/tmp/" + identity + "." + integrity.RekorBundleType); err != nil {
+		t.Fatalf("expected the rekor bundle to be downloaded: %v", err)
+	}
+}
+
+type signedIdentitiesClient struct {
+	clients.Client
+	signed map[string]bool
+}
+
+func (c *signedIdentitiesClient) Download(fileName string, outputType string) error {
+	if outputType != "sig" || !c.signed[fileName] {
+		return &s3types.NoSuchKey{}
+	}
+	return nil
+}
+
+func TestResolveCodeIdentity(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.py"), []byte("print()"), 0o600); err != nil {
+		t.Fatalf("failed to write code: %v", err)
+	}
+	sha256Identity, err := new(integrity.Sha256).GenerateIdentity(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sha512Identity, err := new(integrity.Sha512).GenerateIdentity(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		signed    []string
+		identity  string
+		algorithm string
+	}{
+		{name: "unsigned", identity: sha256Identity, algorithm: integrity.DigestSha256},
+		{name: "sha256", signed: []string{sha256Identity}, identity: sha256Identity, algorithm: integrity.DigestSha256},
+		{name: "sha512", signed: []string{sha512Identity}, identity: sha512Identity, algorithm: integrity.DigestSha512},
+		{name: "both", signed: []string{sha256Identity, sha512Identity}, identity: sha256Identity, algorithm: integrity.DigestSha256},
+	}
+	for _, test := range tests {
+		client := &signedIdentitiesClient{signed: map[string]bool{}}
+		for _, identity := range test.signed {
+			client.signed[identity] = true
+		}
+		identity, algorithm, err := resolveCodeIdentity(client, "func", dir)
+		if err != nil || identity != test.identity || algorithm != test.algorithm {
+			t.Fatalf("%s: expected %s identity: %s, got: %s %s, %v", test.name, test.algorithm, test.identity, algorithm, identity, err)
+		}
+	}
+}
+
+func TestVerifyDigestAlgorithm(t *testing.T) {
+	tests := []struct {
+		name     string
+		recorded string
+		signed   string
+		fail     bool
+	}{
+		{name: "recorded before the algorithm", signed: integrity.DigestSha256},
+		{name: "sha512", recorded: integrity.DigestSha512, signed: integrity.DigestSha512},
+		{name: "mismatch", recorded: integrity.DigestSha512, signed: integrity.DigestSha256, fail: true},
+		{name: "unknown", recorded: "md5", signed: integrity.DigestSha256, fail: true},
+	}
+	for _, test := range tests {
+		err := verifyDigestAlgorithm("func", &metadata.SignatureMetadata{DigestAlgorithm: test.recorded}, test.signed)
+		if test.fail != errors.Is(err, VerifyError{}) || (!test.fail && err != nil) {
+			t.Fatalf("%s: expected failure: %v, got: %v", test.name, test.fail, err)
+		}
+	}
+}
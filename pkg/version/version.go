@@ -0,0 +1,79 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package version holds the build metadata of function clarity, injected at build time, e.g:
+// go build -ldflags "-X github.com/openclarity/function-clarity/pkg/version.Version=v1.2.3
+// -X github.com/openclarity/function-clarity/pkg/version.GitCommit=$(git rev-parse HEAD)
+// -X github.com/openclarity/function-clarity/pkg/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+const cosignModule = "github.com/sigstore/cosign"
+
+var (
+	// Version is the semver of the build, dev when not injected.
+	Version = "dev"
+	// GitCommit is the commit the build was made from.
+	GitCommit = "unknown"
+	// BuildDate is the time of the build, in RFC 3339.
+	BuildDate = "unknown"
+)
+
+// Info is the build metadata printed by the version command.
+type Info struct {
+	Version       string
+	GitCommit     string
+	BuildDate     string
+	GoVersion     string
+	CosignVersion string
+}
+
+// Get returns the build metadata, the cosign version is the one of the cosign module the binary was built with.
+func Get() Info {
+	return Info{
+		Version:       Version,
+		GitCommit:     GitCommit,
+		BuildDate:     BuildDate,
+		GoVersion:     runtime.Version(),
+		CosignVersion: cosignVersion(),
+	}
+}
+
+func (i Info) String() string {
+	return fmt.Sprintf("Version:        %s\nGit commit:     %s\nBuild date:     %s\nGo version:     %s\nCosign version: %s\n",
+		i.Version, i.GitCommit, i.BuildDate, i.GoVersion, i.CosignVersion)
+}
+
+func cosignVersion() string {
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	for _, dep := range buildInfo.Deps {
+		if dep.Path != cosignModule {
+			continue
+		}
+		if dep.Replace != nil {
+			return dep.Replace.Version
+		}
+		return dep.Version
+	}
+	return "unknown"
+}
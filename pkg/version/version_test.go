@@ -0,0 +1,39 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	defer func(version, gitCommit, buildDate string) {
+		Version, GitCommit, BuildDate = version, gitCommit, buildDate
+	}(Version, GitCommit, BuildDate)
+	Version, GitCommit, BuildDate = "v1.2.3", "0123abc", "2022-11-01T10:00:00Z"
+
+	info := Get()
+	if info.Version != "v1.2.3" || info.GitCommit != "0123abc" || info.BuildDate != "2022-11-01T10:00:00Z" || info.GoVersion != runtime.Version() {
+		t.Fatalf("unexpected build metadata: %+v", info)
+	}
+	for _, expected := range []string{"v1.2.3", "0123abc", "2022-11-01T10:00:00Z", runtime.Version(), "Cosign version:"} {
+		if !strings.Contains(info.String(), expected) {
+			t.Fatalf("expected %q in:\n%s", expected, info)
+		}
+	}
+}
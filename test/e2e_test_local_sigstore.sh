@@ -0,0 +1,14 @@
+#!/usr/bin/env bash
+echo "starting local sigstore..."
+docker compose -f ./test/sigstore/docker-compose.yml up -d
+trap 'docker compose -f ./test/sigstore/docker-compose.yml down' EXIT
+
+for url in http://localhost:5555/api/v1/rootCert http://localhost:3000/api/v1/log/publicKey http://localhost:5556/dex/.well-known/openid-configuration; do
+  until curl -sf "$url" > /dev/null; do
+    echo "waiting for $url"
+    sleep 5
+  done
+done
+
+echo "local sigstore tests started"
+FC_LOCAL_SIGSTORE=1 go test -timeout 10m -v ./test/sigstore/...
@@ -0,0 +1,131 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sigstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/openclarity/function-clarity/pkg/options"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultFulcioURL = "http://localhost:5555"
+	DefaultRekorURL  = "http://localhost:3000"
+	DefaultDexURL    = "http://localhost:5556/dex"
+
+	testUser     = "test@function-clarity.local"
+	testPassword = "password"
+	clientID     = "sigstore"
+	clientSecret = "sigstore-secret"
+)
+
+// LocalStack points at the sigstore services started by docker-compose.yml in this directory.
+type LocalStack struct {
+	FulcioURL string
+	RekorURL  string
+	DexURL    string
+	client    *http.Client
+}
+
+// LocalStackFromEnv returns the local stack when FC_LOCAL_SIGSTORE is set, service urls can be overridden with
+// FC_FULCIO_URL, FC_REKOR_URL and FC_DEX_URL.
+func LocalStackFromEnv() (*LocalStack, bool) {
+	if os.Getenv("FC_LOCAL_SIGSTORE") == "" {
+		return nil, false
+	}
+	return &LocalStack{
+		FulcioURL: envOrDefault("FC_FULCIO_URL", DefaultFulcioURL),
+		RekorURL:  envOrDefault("FC_REKOR_URL", DefaultRekorURL),
+		DexURL:    envOrDefault("FC_DEX_URL", DefaultDexURL),
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}, true
+}
+
+func envOrDefault(env string, defaultValue string) string {
+	if value := os.Getenv(env); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// TrustRoots fetches the fulcio root certificate and the rekor public key of the local stack into dir, so they can
+// replace the public sigstore trust roots.
+func (s *LocalStack) TrustRoots(dir string) (options.TrustRootOptions, error) {
+	fulcioRoot := filepath.Join(dir, "fulcio-root.pem")
+	if err := s.download(s.FulcioURL+"/api/v1/rootCert", fulcioRoot); err != nil {
+		return options.TrustRootOptions{}, err
+	}
+	rekorPublicKey := filepath.Join(dir, "rekor.pub")
+	if err := s.download(s.RekorURL+"/api/v1/log/publicKey", rekorPublicKey); err != nil {
+		return options.TrustRootOptions{}, err
+	}
+	return options.TrustRootOptions{FulcioRoot: fulcioRoot, RekorPublicKey: rekorPublicKey}, nil
+}
+
+// IdentityToken returns an OIDC token of the static dex user, trusted by the local fulcio.
+func (s *LocalStack) IdentityToken() (string, error) {
+	form := url.Values{
+		"grant_type": {"password"},
+		"username":   {testUser},
+		"password":   {testPassword},
+		"scope":      {"openid email"},
+	}
+	req, err := http.NewRequest(http.MethodPost, s.DexURL+"/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(clientID, clientSecret)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get identity token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to get identity token, status: %d, body: %s", resp.StatusCode, body)
+	}
+	var token struct {
+		IDToken string `json:"id_token"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to parse identity token response: %w", err)
+	}
+	return token.IDToken, nil
+}
+
+func (s *LocalStack) download(source string, path string) error {
+	resp, err := s.client.Get(source)
+	if err != nil {
+		return fmt.Errorf("failed to fetch: %s: %w", source, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch: %s, status: %d", source, resp.StatusCode)
+	}
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0600)
+}
@@ -0,0 +1,66 @@
+// Copyright © 2022 Cisco Systems, Inc. and its affiliates.
+// All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sigstore
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/openclarity/function-clarity/cmd/function-clarity/cli/sign"
+	"github.com/openclarity/function-clarity/cmd/function-clarity/cli/verify"
+	"github.com/openclarity/function-clarity/pkg/options"
+	co "github.com/sigstore/cosign/cmd/cosign/cli/options"
+	"os"
+	"testing"
+)
+
+// TestSignAndVerifyKeylessLocalSigstore signs an identity keyless with the local stack and verifies it with the local
+// trust roots, it runs only when FC_LOCAL_SIGSTORE is set.
+func TestSignAndVerifyKeylessLocalSigstore(t *testing.T) {
+	stack, ok := LocalStackFromEnv()
+	if !ok {
+		t.Skip("FC_LOCAL_SIGSTORE not set, skipping local sigstore test")
+	}
+	t.Setenv("COSIGN_EXPERIMENTAL", "1")
+	trustRoots, err := stack.TrustRoots(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to fetch trust roots: %v", err)
+	}
+	token, err := stack.IdentityToken()
+	if err != nil {
+		t.Fatalf("failed to get identity token: %v", err)
+	}
+
+	identity := fmt.Sprintf("local-sigstore-%s", uuid.New().String())
+	defer os.Remove("/tmp/" + identity + ".sig")
+	defer os.Remove("/tmp/" + identity + ".crt.base64")
+
+	so := &options.SignBlobOptions{TrustRoots: trustRoots}
+	so.Fulcio.URL = stack.FulcioURL
+	so.Fulcio.IdentityToken = token
+	so.Rekor.URL = stack.RekorURL
+	so.Base64Output = true
+	so.SkipConfirmation = true
+	if _, err = sign.SignIdentity(identity, so, &co.RootOptions{Timeout: co.DefaultTimeout}, true); err != nil {
+		t.Fatalf("failed to sign identity: %v", err)
+	}
+
+	vo := &options.VerifyOpts{TrustRoots: trustRoots}
+	vo.Rekor.URL = stack.RekorURL
+	if err = verify.VerifyIdentity(identity, vo, context.Background(), true); err != nil {
+		t.Fatalf("failed to verify identity: %v", err)
+	}
+}